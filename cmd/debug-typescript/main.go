@@ -8,7 +8,7 @@ import (
 	"strings"
 
 	sitter "github.com/smacker/go-tree-sitter"
-	typescript "tree-sitter-typescript"
+	typescript "github.com/janreges/ai-distiller/internal/parser/grammars/tree-sitter-typescript"
 )
 
 func main() {
@@ -38,6 +38,15 @@ type ContentAction interface {
 	GenerateContent(ctx *ActionContext) (*ContentResult, error)
 }
 
+// ImportGraphAction is implemented by content actions that use the import
+// graph of the project. The graph is only built for them.
+type ImportGraphAction interface {
+	ContentAction
+	
+	// UsesImportGraph reports whether ActionContext.ImportGraph is needed
+	UsesImportGraph() bool
+}
+
 // FlowAction is the interface for complex workflow actions that may create multiple files
 type FlowAction interface {
 	AIAction
@@ -55,6 +64,11 @@ type ActionContext struct {
 	Config           *ActionConfig
 	IncludePatterns  []string
 	ExcludePatterns  []string
+
+	// ImportGraph is a Mermaid flowchart of the imports resolved within
+	// the project, or empty when none resolved. It is only set for an
+	// ImportGraphAction.
+	ImportGraph string
 }
 
 // ActionConfig contains configuration for action execution
@@ -303,6 +303,12 @@ func (a *TemplateDiagramsPromptAction) Validate() error {
 	return nil
 }
 
+// UsesImportGraph implements ai.ImportGraphAction: the resolved imports
+// ground the module dependency diagrams
+func (a *TemplateDiagramsPromptAction) UsesImportGraph() bool {
+	return true
+}
+
 func (a *TemplateDiagramsPromptAction) GenerateContent(ctx *ai.ActionContext) (*ai.ContentResult, error) {
 	data := CreateTemplateData(ctx.BaseName)
 	prompt, err := LoadTemplate("diagrams", data)
@@ -312,6 +318,18 @@ func (a *TemplateDiagramsPromptAction) GenerateContent(ctx *ai.ActionContext) (*
 
 	return &ai.ContentResult{
 		ContentBefore: prompt,
-		ContentAfter:  "",
+		ContentAfter:  importGraphSection(ctx.ImportGraph),
 	}, nil
 }
+
+// importGraphSection presents the resolved import graph as ground truth
+// for module dependency diagrams
+func importGraphSection(graph string) string {
+	if graph == "" {
+		return ""
+	}
+	return "\n\n## 🔗 Resolved Import Graph\n\n" +
+		"The following graph was computed by resolving the imports of the analyzed files (`aid deps`). " +
+		"Use it as the source of truth for module and dependency diagrams; modules within import cycles are highlighted.\n\n" +
+		"```mermaid\n" + graph + "```\n"
+}
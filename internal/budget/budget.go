@@ -0,0 +1,364 @@
+// Package budget fits distilled output into a token budget by dropping
+// detail in a fixed order until the rendered output is small enough.
+package budget
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/stripper"
+	"github.com/janreges/ai-distiller/internal/summary"
+)
+
+// Stage is one degradation step
+type Stage string
+
+const (
+	StageImplementation Stage = "implementation"
+	StagePrivate        Stage = "private members"
+	StageDocstrings     Stage = "docstrings"
+	StageNonExported    Stage = "non-exported files"
+	StageLowRanked      Stage = "low-ranked files"
+)
+
+// Stages lists the degradation steps in the order they are applied
+var Stages = []Stage{StageImplementation, StagePrivate, StageDocstrings, StageNonExported, StageLowRanked}
+
+// RenderFunc renders files with the output formatter
+type RenderFunc func(files []*ir.DistilledFile) (string, error)
+
+// RankFunc scores files by importance; the lowest scores are dropped first
+type RankFunc func(files []*ir.DistilledFile) map[string]float64
+
+// Options configures Fit
+type Options struct {
+	// MaxTokens is the budget for the rendered output
+	MaxTokens int
+
+	// Rank scores files for the last stage (default: DefaultRank)
+	Rank RankFunc
+}
+
+// Report describes what Fit removed to meet the budget
+type Report struct {
+	MaxTokens      int
+	OriginalTokens int64
+	FinalTokens    int64
+
+	// Applied lists the stages that were needed, in order
+	Applied []Stage
+
+	// DroppedFiles lists the paths of files removed by the file stages
+	DroppedFiles map[Stage][]string
+
+	// Fits is false when the output exceeds the budget even after all stages
+	Fits bool
+}
+
+// Degraded reports whether anything was omitted
+func (r *Report) Degraded() bool {
+	return len(r.Applied) > 0
+}
+
+// String renders a human-readable omission report
+func (r *Report) String() string {
+	var b strings.Builder
+	if !r.Degraded() {
+		fmt.Fprintf(&b, "Token budget: %d of %d tokens used, nothing omitted\n", r.FinalTokens, r.MaxTokens)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Token budget: reduced ~%d to ~%d tokens (limit %d)\n", r.OriginalTokens, r.FinalTokens, r.MaxTokens)
+	for _, stage := range r.Applied {
+		files := r.DroppedFiles[stage]
+		if len(files) == 0 {
+			fmt.Fprintf(&b, "  omitted: %s\n", stage)
+			continue
+		}
+		fmt.Fprintf(&b, "  omitted: %s (%d)\n", stage, len(files))
+		for _, path := range files {
+			fmt.Fprintf(&b, "    - %s\n", path)
+		}
+	}
+	if !r.Fits {
+		fmt.Fprintf(&b, "  warning: output still exceeds the budget after all reductions\n")
+	}
+	return b.String()
+}
+
+// Fit renders files and, while the output exceeds opts.MaxTokens, applies the
+// degradation stages in order. It returns the reduced files and a report.
+// The input files are not modified.
+func Fit(files []*ir.DistilledFile, render RenderFunc, opts Options) ([]*ir.DistilledFile, *Report, error) {
+	report := &Report{
+		MaxTokens:    opts.MaxTokens,
+		DroppedFiles: make(map[Stage][]string),
+	}
+
+	tokens, err := measure(files, render)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.OriginalTokens = tokens
+	report.FinalTokens = tokens
+
+	if opts.MaxTokens <= 0 || tokens <= int64(opts.MaxTokens) {
+		report.Fits = true
+		return files, report, nil
+	}
+
+	current := files
+	for _, stage := range Stages {
+		var next []*ir.DistilledFile
+		switch stage {
+		case StageImplementation:
+			next = strip(current, stripper.Options{RemoveImplementations: true})
+		case StagePrivate:
+			next = strip(current, stripper.Options{RemovePrivateOnly: true, RemoveProtectedOnly: true, RemoveInternalOnly: true})
+		case StageDocstrings:
+			next = strip(current, stripper.Options{RemoveDocstrings: true})
+		case StageNonExported:
+			var dropped []string
+			next, dropped = dropNonExported(current)
+			report.DroppedFiles[stage] = dropped
+		case StageLowRanked:
+			next, tokens, err = dropLowRanked(current, render, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			report.DroppedFiles[stage] = droppedPaths(current, next)
+		}
+
+		if stage != StageLowRanked {
+			if tokens, err = measure(next, render); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Only report stages that actually removed something
+		if tokens < report.FinalTokens || len(report.DroppedFiles[stage]) > 0 {
+			report.Applied = append(report.Applied, stage)
+		} else {
+			delete(report.DroppedFiles, stage)
+		}
+		current = next
+		report.FinalTokens = tokens
+
+		if tokens <= int64(opts.MaxTokens) {
+			report.Fits = true
+			break
+		}
+	}
+
+	return current, report, nil
+}
+
+// measure renders files and estimates the token count of the output
+func measure(files []*ir.DistilledFile, render RenderFunc) (int64, error) {
+	output, err := render(files)
+	if err != nil {
+		return 0, err
+	}
+	return summary.EstimateTokens(int64(len(output))), nil
+}
+
+// strip applies the stripper to copies of all files
+func strip(files []*ir.DistilledFile, opts stripper.Options) []*ir.DistilledFile {
+	s := stripper.New(opts)
+	result := make([]*ir.DistilledFile, 0, len(files))
+	for _, file := range files {
+		if stripped, ok := file.Accept(s).(*ir.DistilledFile); ok {
+			result = append(result, stripped)
+		}
+	}
+	return result
+}
+
+// dropNonExported removes files that contribute nothing to the public API.
+// The last remaining file is always kept.
+func dropNonExported(files []*ir.DistilledFile) ([]*ir.DistilledFile, []string) {
+	var kept []*ir.DistilledFile
+	var dropped []string
+	for _, file := range files {
+		if IsNonExported(file) {
+			dropped = append(dropped, file.Path)
+			continue
+		}
+		kept = append(kept, file)
+	}
+	if len(kept) == 0 && len(files) > 0 {
+		kept = files[:1]
+		dropped = dropped[1:]
+	}
+	return kept, dropped
+}
+
+// IsNonExported reports whether a file is outside the public API: test
+// files, underscore-prefixed modules and files without any declarations.
+func IsNonExported(file *ir.DistilledFile) bool {
+	path := filepath.ToSlash(file.Path)
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+
+	if isTestFile(base, name) {
+		return true
+	}
+	if strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "__") {
+		return true
+	}
+	for _, child := range file.Children {
+		switch child.(type) {
+		case *ir.DistilledImport, *ir.DistilledComment, *ir.DistilledPackage:
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isTestFile recognizes common test file naming conventions
+func isTestFile(base, name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, "_test") ||
+		strings.HasPrefix(lower, "test_") ||
+		strings.HasSuffix(lower, ".test") ||
+		strings.HasSuffix(lower, ".spec") ||
+		(strings.HasSuffix(lower, "test") && strings.HasSuffix(base, ".java")) ||
+		(strings.HasSuffix(lower, "tests") && strings.HasSuffix(base, ".cs"))
+}
+
+// dropLowRanked removes the lowest-ranked files until the output fits.
+// The highest-ranked file is always kept.
+func dropLowRanked(files []*ir.DistilledFile, render RenderFunc, opts Options) ([]*ir.DistilledFile, int64, error) {
+	if len(files) == 0 {
+		// Nothing to drop; report the size of the empty wrapper as-is
+		tokens, err := measure(nil, render)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, tokens, nil
+	}
+
+	rank := opts.Rank
+	if rank == nil {
+		rank = DefaultRank
+	}
+	scores := rank(files)
+
+	// Order from most to least important
+	ordered := make([]*ir.DistilledFile, len(files))
+	copy(ordered, files)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return scores[ordered[i].Path] > scores[ordered[j].Path]
+	})
+
+	// Estimate per-file sizes to find a starting point, then verify by rendering
+	keep := 0
+	var estimated int64
+	for _, file := range ordered {
+		tokens, err := measure([]*ir.DistilledFile{file}, render)
+		if err != nil {
+			return nil, 0, err
+		}
+		if keep > 0 && estimated+tokens > int64(opts.MaxTokens) {
+			break
+		}
+		estimated += tokens
+		keep++
+	}
+
+	for {
+		kept := inOriginalOrder(files, ordered[:keep])
+		tokens, err := measure(kept, render)
+		if err != nil {
+			return nil, 0, err
+		}
+		if tokens <= int64(opts.MaxTokens) || keep <= 1 {
+			return kept, tokens, nil
+		}
+		keep--
+	}
+}
+
+// DefaultRank scores files by how often other files import them, then by
+// the number of top-level declarations
+func DefaultRank(files []*ir.DistilledFile) map[string]float64 {
+	scores := make(map[string]float64, len(files))
+	modules := make(map[string]string, len(files))
+	for _, file := range files {
+		base := filepath.Base(file.Path)
+		modules[file.Path] = strings.TrimSuffix(base, filepath.Ext(base))
+		for _, child := range file.Children {
+			switch child.(type) {
+			case *ir.DistilledImport, *ir.DistilledComment, *ir.DistilledPackage:
+			default:
+				scores[file.Path]++
+			}
+		}
+	}
+
+	for _, file := range files {
+		for _, child := range file.Children {
+			imp, ok := child.(*ir.DistilledImport)
+			if !ok {
+				continue
+			}
+			for path, module := range modules {
+				if path != file.Path && importRefers(imp.Module, module) {
+					scores[path] += 10
+				}
+			}
+		}
+	}
+	return scores
+}
+
+// importRefers reports whether an import path names the given module
+func importRefers(importPath, module string) bool {
+	importPath = strings.Trim(importPath, `"'<>`)
+	if strings.ContainsAny(importPath, "/\\") {
+		// File-style import such as ./service.js or "pkg/service.h"
+		importPath = filepath.Base(filepath.ToSlash(importPath))
+		importPath = strings.TrimSuffix(importPath, filepath.Ext(importPath))
+	}
+	for _, sep := range []string{".", "::", "\\"} {
+		if idx := strings.LastIndex(importPath, sep); idx >= 0 {
+			importPath = importPath[idx+len(sep):]
+		}
+	}
+	return importPath == module
+}
+
+// inOriginalOrder returns the subset of files in their original order
+func inOriginalOrder(files, subset []*ir.DistilledFile) []*ir.DistilledFile {
+	keep := make(map[*ir.DistilledFile]bool, len(subset))
+	for _, file := range subset {
+		keep[file] = true
+	}
+	result := make([]*ir.DistilledFile, 0, len(subset))
+	for _, file := range files {
+		if keep[file] {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// droppedPaths lists paths present in before but not in after
+func droppedPaths(before, after []*ir.DistilledFile) []string {
+	kept := make(map[string]bool, len(after))
+	for _, file := range after {
+		kept[file.Path] = true
+	}
+	var dropped []string
+	for _, file := range before {
+		if !kept[file.Path] {
+			dropped = append(dropped, file.Path)
+		}
+	}
+	return dropped
+}
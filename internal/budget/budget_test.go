@@ -0,0 +1,179 @@
+package budget
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// render prints a simple listing whose size reflects every node kind
+func render(files []*ir.DistilledFile) (string, error) {
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "file %s\n", file.Path)
+		for _, child := range file.Children {
+			writeNode(&b, child)
+		}
+	}
+	return b.String(), nil
+}
+
+func writeNode(b *strings.Builder, node ir.DistilledNode) {
+	switch n := node.(type) {
+	case *ir.DistilledFunction:
+		fmt.Fprintf(b, "func %s %s\n", n.Name, n.Implementation)
+	case *ir.DistilledComment:
+		fmt.Fprintf(b, "doc %s\n", n.Text)
+	case *ir.DistilledImport:
+		fmt.Fprintf(b, "import %s\n", n.Module)
+	case *ir.DistilledClass:
+		fmt.Fprintf(b, "class %s\n", n.Name)
+		for _, child := range n.Children {
+			writeNode(b, child)
+		}
+	}
+}
+
+func testFiles() []*ir.DistilledFile {
+	body := strings.Repeat("x", 400)
+	return []*ir.DistilledFile{
+		{Path: "core.py", Children: []ir.DistilledNode{
+			&ir.DistilledComment{Text: strings.Repeat("d", 200), Format: "doc"},
+			&ir.DistilledFunction{Name: "run", Visibility: ir.VisibilityPublic, Implementation: body},
+			&ir.DistilledFunction{Name: "_helper", Visibility: ir.VisibilityPrivate, Implementation: body},
+		}},
+		{Path: "util.py", Children: []ir.DistilledNode{
+			&ir.DistilledFunction{Name: "fmt", Visibility: ir.VisibilityPublic},
+		}},
+		{Path: "app.py", Children: []ir.DistilledNode{
+			&ir.DistilledImport{Module: "core"},
+			&ir.DistilledFunction{Name: "main", Visibility: ir.VisibilityPublic},
+		}},
+		{Path: "test_core.py", Children: []ir.DistilledNode{
+			&ir.DistilledFunction{Name: "test_run", Visibility: ir.VisibilityPublic},
+		}},
+	}
+}
+
+func TestFitWithinBudget(t *testing.T) {
+	files := testFiles()
+	fitted, report, err := Fit(files, render, Options{MaxTokens: 100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) != len(files) || report.Degraded() || !report.Fits {
+		t.Errorf("expected output unchanged, got %d files, report %+v", len(fitted), report)
+	}
+}
+
+func TestFitStageOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTokens int
+		applied   []Stage
+		files     []string
+	}{
+		{"implementation", 200, []Stage{StageImplementation}, []string{"core.py", "util.py", "app.py", "test_core.py"}},
+		{"docstrings", 45, []Stage{StageImplementation, StagePrivate, StageDocstrings}, []string{"core.py", "util.py", "app.py", "test_core.py"}},
+		{"non-exported", 25, []Stage{StageImplementation, StagePrivate, StageDocstrings, StageNonExported}, []string{"core.py", "util.py", "app.py"}},
+		{"low-ranked", 15, []Stage{StageImplementation, StagePrivate, StageDocstrings, StageNonExported, StageLowRanked}, []string{"core.py", "util.py"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := testFiles()
+			fitted, report, err := Fit(files, render, Options{MaxTokens: tt.maxTokens})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(report.Applied, tt.applied) {
+				t.Errorf("applied stages = %v, want %v", report.Applied, tt.applied)
+			}
+
+			var paths []string
+			for _, file := range fitted {
+				paths = append(paths, file.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.files) {
+				t.Errorf("kept files = %v, want %v", paths, tt.files)
+			}
+			if !report.Fits || report.FinalTokens > int64(tt.maxTokens) {
+				t.Errorf("expected output to fit, got ~%d tokens", report.FinalTokens)
+			}
+
+			// The input must not be modified
+			if files[0].Children[1].(*ir.DistilledFunction).Implementation == "" {
+				t.Error("input files were modified")
+			}
+		})
+	}
+}
+
+func TestFitReportsOverflow(t *testing.T) {
+	_, report, err := Fit(testFiles(), render, Options{MaxTokens: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Fits {
+		t.Error("expected report to show the budget could not be met")
+	}
+	if !strings.Contains(report.String(), "still exceeds the budget") {
+		t.Errorf("report does not mention overflow:\n%s", report)
+	}
+	if got := report.DroppedFiles[StageNonExported]; !reflect.DeepEqual(got, []string{"test_core.py"}) {
+		t.Errorf("non-exported files = %v", got)
+	}
+}
+
+func TestFitEmpty(t *testing.T) {
+	// Formats like XML render a wrapper even when no files were found
+	header := func([]*ir.DistilledFile) (string, error) {
+		return "<distilled_code></distilled_code>", nil
+	}
+	fitted, report, err := Fit(nil, header, Options{MaxTokens: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fitted) != 0 {
+		t.Errorf("expected no files, got %d", len(fitted))
+	}
+	if report.Fits || report.FinalTokens != report.OriginalTokens {
+		t.Errorf("expected the wrapper to be reported as over budget, got %+v", report)
+	}
+}
+
+func TestIsNonExported(t *testing.T) {
+	decl := []ir.DistilledNode{&ir.DistilledFunction{Name: "f"}}
+	tests := []struct {
+		path     string
+		children []ir.DistilledNode
+		want     bool
+	}{
+		{"pkg/service.go", decl, false},
+		{"pkg/service_test.go", decl, true},
+		{"tests/test_api.py", decl, true},
+		{"src/app.spec.ts", decl, true},
+		{"pkg/_private.py", decl, true},
+		{"pkg/__init__.py", decl, false},
+		{"pkg/empty.py", []ir.DistilledNode{&ir.DistilledImport{Module: "os"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsNonExported(&ir.DistilledFile{Path: tt.path, Children: tt.children}); got != tt.want {
+			t.Errorf("IsNonExported(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultRank(t *testing.T) {
+	scores := DefaultRank(testFiles())
+	if scores["core.py"] <= scores["util.py"] {
+		t.Errorf("imported file should outrank others: %v", scores)
+	}
+	if !importRefers("./lib/core.js", "core") || !importRefers("pkg.core", "core") || importRefers("pkg.core", "pkg") {
+		t.Error("importRefers does not match module names")
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/budget"
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/distill"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// fitTokenBudget degrades the processed result until its formatted output
+// fits into maxTokens. Files are dropped by rank (nil: budget.DefaultRank).
+// It returns the reduced result and what was omitted.
+func fitTokenBudget(ctx context.Context, result ir.DistilledNode, format string, maxTokens int, rank budget.RankFunc) (ir.DistilledNode, *budget.Report, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("budget")
+	defer dbg.Timing(debug.LevelDetailed, "token budget")()
+
+	var files []*ir.DistilledFile
+	single := false
+	switch r := result.(type) {
+	case *ir.DistilledFile:
+		files = []*ir.DistilledFile{r}
+		single = true
+	case *ir.DistilledDirectory:
+		for _, child := range r.Children {
+			if file, ok := child.(*ir.DistilledFile); ok {
+				files = append(files, file)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+
+	render, err := outputRenderer(format, single)
+	if err != nil {
+		return nil, nil, err
+	}
+	fitted, report, err := budget.Fit(files, render, budget.Options{MaxTokens: maxTokens, Rank: rank})
+	if err != nil {
+		return nil, nil, err
+	}
+	dbg.Logf(debug.LevelBasic, "Token budget %d: ~%d -> ~%d tokens, stages applied: %v",
+		maxTokens, report.OriginalTokens, report.FinalTokens, report.Applied)
+
+	switch r := result.(type) {
+	case *ir.DistilledFile:
+		return fitted[0], report, nil
+	case *ir.DistilledDirectory:
+		dir := &ir.DistilledDirectory{BaseNode: r.BaseNode, Path: r.Path}
+		for _, file := range fitted {
+			dir.Children = append(dir.Children, file)
+		}
+		return dir, report, nil
+	}
+	return result, report, nil
+}
+
+// printBudgetReport writes the omission report when anything was dropped
+func printBudgetReport(w io.Writer, report *budget.Report) {
+	if report == nil || (!report.Degraded() && report.Fits) {
+		return
+	}
+	fmt.Fprint(w, report.String())
+}
+
+// outputRenderer returns a function that renders files exactly as
+// runDistiller does, so that size estimates match the output. A single
+// file is formatted on its own instead of as a list of files.
+func outputRenderer(format string, single bool) (func(files []*ir.DistilledFile) (string, error), error) {
+	outputFormatter, err := formatter.Get(format, formatter.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get formatter: %w", err)
+	}
+	return func(files []*ir.DistilledFile) (string, error) {
+		var output strings.Builder
+		if single {
+			for _, file := range files {
+				if err := outputFormatter.Format(&output, file); err != nil {
+					return "", fmt.Errorf("failed to format output: %w", err)
+				}
+			}
+		} else if err := outputFormatter.FormatMultiple(&output, files); err != nil {
+			return "", fmt.Errorf("failed to format output: %w", err)
+		}
+		if format == "text" {
+			return distill.RemoveEmptyFiles(output.String()), nil
+		}
+		return output.String(), nil
+	}, nil
+}
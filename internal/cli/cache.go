@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dustin/go-humanize"
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/performance"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/project"
+	"github.com/spf13/cobra"
+)
+
+// resultCacheDirName is the subdirectory of the project cache holding distilled files
+const resultCacheDirName = "distill"
+
+// cacheCmd manages the on-disk distillation cache
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the distillation cache",
+	Long: `Manage the on-disk cache used by --cache=1.
+
+Distilled files are cached in .aid/cache/distill at the project root, keyed by
+file contents, language processor version and filtering options.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache location, size and usage",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := openResultCache()
+		if err != nil {
+			return err
+		}
+		printCacheStats(cmd.OutOrStdout(), cache)
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached results",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := openResultCache()
+		if err != nil {
+			return err
+		}
+		count := len(cache.Entries())
+		if err := cache.Clear(); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cached file%s from %s\n", count, pluralS(count), cache.Dir())
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// openResultCache opens the distillation cache at the project root
+func openResultCache() (*performance.Cache, error) {
+	cacheDir, err := project.GetCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return performance.NewCache(filepath.Join(cacheDir, resultCacheDirName)).WithVersion(Version), nil
+}
+
+// attachResultCache enables the distillation cache on the processor when --cache=1.
+// It returns nil when caching is disabled or the cache cannot be opened.
+func attachResultCache(dbg debug.Debugger, proc *processor.Processor) *performance.Cache {
+	if !getBoolFlag(useCache, false) {
+		return nil
+	}
+
+	cache, err := openResultCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cache disabled: %v\n", err)
+		return nil
+	}
+
+	dbg.Logf(debug.LevelBasic, "Cache: %s", cache.Dir())
+	proc.WithCache(cache)
+	return cache
+}
+
+// flushResultCache persists the cache index and logs hit statistics
+func flushResultCache(dbg debug.Debugger, cache *performance.Cache) {
+	if cache == nil {
+		return
+	}
+
+	if err := cache.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save cache index: %v\n", err)
+	}
+
+	if stats := cache.Stats(); stats != nil {
+		dbg.Logf(debug.LevelBasic, "Cache: %d hits, %d misses (%.1f%% hit rate)",
+			stats.Hits, stats.Misses, stats.GetHitRate())
+	}
+}
+
+// printCacheStats writes a human-readable overview of the cache contents
+func printCacheStats(w io.Writer, cache *performance.Cache) {
+	type languageStats struct {
+		entries int
+		size    int64
+	}
+
+	entries := cache.Entries()
+	byLanguage := make(map[string]*languageStats)
+	var totalSize, totalHits int64
+	for _, entry := range entries {
+		totalSize += entry.ResultSize
+		totalHits += entry.AccessCount
+
+		language := entry.Language
+		if language == "" {
+			language = "unknown"
+		}
+		if byLanguage[language] == nil {
+			byLanguage[language] = &languageStats{}
+		}
+		byLanguage[language].entries++
+		byLanguage[language].size += entry.ResultSize
+	}
+
+	fmt.Fprintf(w, "Cache directory: %s\n", cache.Dir())
+	fmt.Fprintf(w, "Cached files:    %d\n", len(entries))
+	fmt.Fprintf(w, "Total size:      %s\n", humanize.Bytes(uint64(totalSize)))
+	fmt.Fprintf(w, "Total hits:      %d\n", totalHits)
+	if len(entries) > 0 {
+		fmt.Fprintf(w, "Last used:       %s\n", humanize.Time(entries[0].LastAccess))
+	}
+
+	if len(byLanguage) == 0 {
+		return
+	}
+
+	languages := make([]string, 0, len(byLanguage))
+	for language := range byLanguage {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	fmt.Fprintln(w, "\nBy language:")
+	for _, language := range languages {
+		stats := byLanguage[language]
+		fmt.Fprintf(w, "  %-12s %6d file%s  %s\n", language, stats.entries, pluralS(stats.entries), humanize.Bytes(uint64(stats.size)))
+	}
+}
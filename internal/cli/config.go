@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/config"
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// projectConfigPath is the .aidrc file applied to this run, if any
+	projectConfigPath string
+
+	// configSources records which .aidrc entry provided each flag value
+	configSources map[string]string
+
+	// configInput is the path the applied .aidrc values were resolved for
+	configInput string
+
+	// commandLineFlags records the flags set explicitly on the command line
+	commandLineFlags map[string]bool
+)
+
+// nonConfigurableFlags cannot be set from .aidrc
+var nonConfigurableFlags = map[string]bool{
+	"help":          true,
+	"help-extended": true,
+	"cheat":         true,
+	"version":       true,
+}
+
+// applyProjectConfig applies .aidrc defaults to every flag not set on the
+// command line. The .aidrc is looked up from the first input path, or from
+// the working directory when reading stdin or --files-from alone; other
+// inputs are checked against it by checkProjectConfig.
+func applyProjectConfig(cmd *cobra.Command, args []string) error {
+	projectConfigPath = ""
+	configSources = make(map[string]string)
+
+	// Flags set explicitly on the command line always win
+	commandLineFlags = make(map[string]bool)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		commandLineFlags[f.Name] = true
+	})
+
+	inputPath := "."
+	if len(args) > 0 && args[0] != "-" {
+		inputPath = args[0]
+	}
+	configInput = inputPath
+
+	cfg, err := config.Find(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	if cfg == nil {
+		return nil
+	}
+	projectConfigPath = cfg.Path
+
+	for _, setting := range cfg.Resolve(inputPath) {
+		flag := cmd.Flags().Lookup(setting.Name)
+		if flag == nil || nonConfigurableFlags[setting.Name] {
+			return fmt.Errorf("%s: unknown option %q", setting.Source, setting.Name)
+		}
+		if commandLineFlags[setting.Name] {
+			continue
+		}
+
+		if err := setFlagFromConfig(flag, setting.Value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %q: %w", setting.Source, setting.Value, setting.Name, err)
+		}
+		configSources[setting.Name] = setting.Source
+	}
+
+	return nil
+}
+
+// checkProjectConfig verifies that every input resolves to the same .aidrc
+// values as the path they were applied for. A run applies a single set of
+// options, so inputs from different projects or directory overrides that
+// disagree are rejected unless the option is set on the command line.
+func checkProjectConfig(inputs []string) error {
+	applied, err := projectSettings(configInput)
+	if err != nil {
+		return err
+	}
+
+	for _, input := range inputs {
+		settings, err := projectSettings(input)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(applied)+len(settings))
+		for name := range applied {
+			names = append(names, name)
+		}
+		for name := range settings {
+			if _, ok := applied[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			want, got := applied[name], settings[name]
+			if want == got {
+				continue
+			}
+			return fmt.Errorf("%s and %s resolve to different .aidrc values for --%s (%s vs %s); set --%s on the command line or distill them separately",
+				configInput, input, name, describeSetting(want), describeSetting(got), name)
+		}
+	}
+
+	return nil
+}
+
+// projectSettings returns the .aidrc values that apply to an input,
+// leaving out flags set on the command line
+func projectSettings(inputPath string) (map[string]string, error) {
+	cfg, err := config.Find(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project config: %w", err)
+	}
+
+	settings := make(map[string]string)
+	if cfg == nil {
+		return settings, nil
+	}
+	for _, setting := range cfg.Resolve(inputPath) {
+		if !commandLineFlags[setting.Name] {
+			settings[setting.Name] = setting.Value
+		}
+	}
+	return settings, nil
+}
+
+// describeSetting quotes a config value, or names the built-in default
+func describeSetting(value string) string {
+	if value == "" {
+		return "default"
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+// setFlagFromConfig sets a flag value, replacing (not appending to) list flags
+func setFlagFromConfig(flag *pflag.Flag, value string) error {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if err := slice.Replace(items); err != nil {
+			return err
+		}
+		flag.Changed = true
+		return nil
+	}
+
+	if err := flag.Value.Set(value); err != nil {
+		return err
+	}
+	flag.Changed = true
+	return nil
+}
+
+// logEffectiveConfig prints every flag value together with where it came from
+func logEffectiveConfig(dbg debug.Debugger, cmd *cobra.Command) {
+	if projectConfigPath != "" {
+		dbg.Logf(debug.LevelBasic, "Project config: %s", projectConfigPath)
+	}
+	if !dbg.IsEnabledFor(debug.LevelDetailed) {
+		return
+	}
+
+	var lines []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if nonConfigurableFlags[f.Name] || f.Deprecated != "" {
+			return
+		}
+
+		source := "default"
+		if src, ok := configSources[f.Name]; ok {
+			source = src
+		} else if f.Changed {
+			source = "command line"
+		}
+		lines = append(lines, fmt.Sprintf("--%s=%s (%s)", f.Name, f.Value.String(), source))
+	})
+	sort.Strings(lines)
+
+	dbg.Logf(debug.LevelDetailed, "Effective configuration:")
+	for _, line := range lines {
+		dbg.Logf(debug.LevelDetailed, "  %s", line)
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectConfigFromInputPath(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	other := filepath.Join(root, "other")
+	for _, dir := range []string{filepath.Join(repo, "api"), filepath.Join(repo, "web"), other} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".aidrc"), []byte(`
+format: md
+directories:
+  api:
+    private: 1
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "go.mod"), []byte("module other\n"), 0644))
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("format", "text", "")
+		cmd.Flags().String("private", "0", "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	t.Run("LookupStartsAtInput", func(t *testing.T) {
+		// The working directory has no .aidrc; the input's project does
+		cmd := newCmd()
+		require.NoError(t, applyProjectConfig(cmd, []string{filepath.Join(repo, "api")}))
+		assert.Equal(t, filepath.Join(repo, ".aidrc"), projectConfigPath)
+		assert.Equal(t, "md", cmd.Flags().Lookup("format").Value.String())
+		assert.Equal(t, "1", cmd.Flags().Lookup("private").Value.String())
+	})
+
+	t.Run("ConflictingOverrides", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, applyProjectConfig(cmd, []string{filepath.Join(repo, "api")}))
+		err := checkProjectConfig([]string{filepath.Join(repo, "api"), filepath.Join(repo, "web")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--private")
+	})
+
+	t.Run("ConflictResolvedOnCommandLine", func(t *testing.T) {
+		cmd := newCmd("--private=1")
+		require.NoError(t, applyProjectConfig(cmd, []string{filepath.Join(repo, "api")}))
+		assert.NoError(t, checkProjectConfig([]string{filepath.Join(repo, "api"), filepath.Join(repo, "web")}))
+	})
+
+	t.Run("DifferentProjects", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, applyProjectConfig(cmd, []string{filepath.Join(repo, "web")}))
+		err := checkProjectConfig([]string{filepath.Join(repo, "web"), other})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--format")
+	})
+}
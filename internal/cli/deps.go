@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/deps"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	depsFormat     string
+	depsLevel      string
+	depsOutputFile string
+)
+
+// depsCmd exports the import dependency graph of a project
+var depsCmd = &cobra.Command{
+	Use:   "deps [path]",
+	Short: "Export the import dependency graph of a project",
+	Long: `Resolve the imports of every source file to other files of the project and
+write the dependency graph. Import cycles and the most depended-on modules
+are reported too.
+
+Imports are resolved per language: Go import paths through go.mod, Python
+modules and packages, TypeScript and JavaScript relative paths and tsconfig
+paths, and Java, Kotlin and Scala packages. Other languages are matched by
+file path. Imports of the standard library and third-party packages are
+listed as external in the JSON output.
+
+Formats:
+  text      Readable report (default)
+  dot       Graphviz, e.g. aid deps . --format dot | dot -Tsvg > deps.svg
+  mermaid   Mermaid flowchart for Markdown
+  json      Nodes, edges, cycles and most depended-on modules
+
+Examples:
+  aid deps .
+  aid deps src/ --format mermaid -o deps.mmd
+  aid deps . --level package --format dot
+  aid deps . --format json --exclude "*_test.go"`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runDeps,
+}
+
+func init() {
+	depsCmd.Flags().StringVar(&depsFormat, "format", "text", "Output format: "+strings.Join(deps.Formats, "|"))
+	depsCmd.Flags().StringVar(&depsLevel, "level", "file", "Graph nodes: file|package (directory)")
+	depsCmd.Flags().StringVarP(&depsOutputFile, "output", "o", "", "Write the graph to a file instead of stdout")
+	depsCmd.Flags().StringSliceVar(&includeGlob, "include", nil, "Include file patterns (comma-separated: *.go,*.py or use flag multiple times)")
+	depsCmd.Flags().StringSliceVar(&excludeGlob, "exclude", nil, "Exclude file patterns (comma-separated: *.json,*test* or use flag multiple times)")
+	depsCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Verbose output (use -vv or -vvv for more detail)")
+	rootCmd.AddCommand(depsCmd)
+}
+
+func runDeps(cmd *cobra.Command, args []string) error {
+	if !contains(deps.Formats, depsFormat) {
+		return fmt.Errorf("invalid format: %s (valid: %s)", depsFormat, strings.Join(deps.Formats, ", "))
+	}
+	level := deps.Level(depsLevel)
+	if level != deps.LevelFile && level != deps.LevelPackage {
+		return fmt.Errorf("invalid level: %s (valid: file, package)", depsLevel)
+	}
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot access %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+
+	dbg := debug.New(os.Stderr, verbosity)
+	ctx := debug.NewContext(context.Background(), dbg)
+
+	graph, err := buildDependencyGraph(ctx, path, level)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = cmd.OutOrStdout()
+	if depsOutputFile != "" {
+		f, err := os.Create(depsOutputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", depsOutputFile, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := graph.Write(w, depsFormat); err != nil {
+		return fmt.Errorf("failed to write dependency graph: %w", err)
+	}
+	if depsOutputFile != "" {
+		fmt.Fprintf(os.Stderr, "Dependency graph written to %s (%s)\n", depsOutputFile, graph.Summary())
+	}
+	return nil
+}
+
+// buildDependencyGraph distills the imports of a directory and resolves
+// them to a graph
+func buildDependencyGraph(ctx context.Context, path string, level deps.Level) (*deps.Graph, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("deps")
+	defer dbg.Timing(debug.LevelBasic, "dependency graph")()
+
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	// Declarations are kept so that package members can be matched
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeImplementation = false
+	opts.IncludeComments = false
+	opts.IncludeDocstrings = false
+	opts.IncludeAnnotations = false
+	opts.IncludePatterns = includeGlob
+	opts.ExcludePatterns = excludeGlob
+	opts.BasePath = root
+	opts.FilePathType = "relative"
+
+	result, err := processor.NewWithContext(ctx).ProcessPath(root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process %s: %w", path, err)
+	}
+
+	graph := deps.Build(root, distilledFiles(result), deps.Options{Level: level})
+	dbg.Logf(debug.LevelBasic, "Dependency graph: %s", graph.Summary())
+	return graph, nil
+}
+
+// distilledFiles returns the files of a processed file or directory
+func distilledFiles(result ir.DistilledNode) []*ir.DistilledFile {
+	switch r := result.(type) {
+	case *ir.DistilledFile:
+		return []*ir.DistilledFile{r}
+	case *ir.DistilledDirectory:
+		var files []*ir.DistilledFile
+		for _, child := range r.Children {
+			if file, ok := child.(*ir.DistilledFile); ok {
+				files = append(files, file)
+			}
+		}
+		return files
+	}
+	return nil
+}
+
+// importGraphMaxNodes is the size above which the import graph of an AI
+// action is drawn per package instead of per file
+const importGraphMaxNodes = 50
+
+// importGraphForAction renders the import graph of processed content as a
+// Mermaid flowchart, or returns "" when no imports resolve within the project
+func importGraphForAction(root string, result ir.DistilledNode) string {
+	files := distilledFiles(result)
+	graph := deps.Build(root, files, deps.Options{Level: deps.LevelFile})
+	if len(graph.Nodes) > importGraphMaxNodes {
+		graph = deps.Build(root, files, deps.Options{Level: deps.LevelPackage})
+	}
+	if len(graph.Edges) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if err := graph.WriteMermaid(&b); err != nil {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/irdiff"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat         string
+	diffOutputFile     string
+	diffIncludePrivate bool
+	diffFailOnBreaking bool
+)
+
+// diffCmd compares the API of two snapshots of a code base
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Report API changes between two versions of a code base",
+	Long: `Compare the declarations of two versions of a code base and report added,
+removed and changed classes, functions, fields and parameters. Every change
+is classified as breaking or compatible.
+
+Each side can be:
+  - a directory or source file
+  - a saved IR snapshot (aid --format ir) or json-structured output
+    (aid --format json-structured)
+  - a git revision of the current repository, optionally limited to a
+    directory with REV:DIR (e.g. v1.2.0:src)
+
+Examples:
+  aid diff v1.2.0 .
+  aid diff main:src src --format md -o api-changes.md
+  aid diff api-v1.json api-v2.json --format json
+  aid diff origin/main HEAD --fail-on-breaking`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text|md|json")
+	diffCmd.Flags().StringVarP(&diffOutputFile, "output", "o", "", "Write the report to a file instead of stdout")
+	diffCmd.Flags().BoolVar(&diffIncludePrivate, "private", false, "Also report changes of private and internal declarations")
+	diffCmd.Flags().BoolVar(&diffFailOnBreaking, "fail-on-breaking", false, "Exit with status 1 when breaking changes are found")
+	diffCmd.Flags().StringSliceVar(&includeGlob, "include", nil, "Include file patterns (comma-separated: *.go,*.py or use flag multiple times)")
+	diffCmd.Flags().StringSliceVar(&excludeGlob, "exclude", nil, "Exclude file patterns (comma-separated: *.json,*test* or use flag multiple times)")
+	diffCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Verbose output (use -vv or -vvv for more detail)")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	switch diffFormat {
+	case "text", "md", "json":
+	default:
+		return fmt.Errorf("invalid format: %s (valid: text, md, json)", diffFormat)
+	}
+
+	dbg := debug.New(os.Stderr, verbosity)
+	ctx := debug.NewContext(context.Background(), dbg)
+	proc := processor.NewWithContext(ctx)
+
+	oldSide, err := loadDiffSide(ctx, proc, args[0])
+	if err != nil {
+		return err
+	}
+	newSide, err := loadDiffSide(ctx, proc, args[1])
+	if err != nil {
+		return err
+	}
+
+	// A saved json-structured file lacks some details, so compare both sides
+	// through the same representation to avoid reporting phantom changes
+	if oldSide.lossy != newSide.lossy {
+		if !oldSide.lossy {
+			oldSide.files, err = roundTripStructured(oldSide.files)
+		} else {
+			newSide.files, err = roundTripStructured(newSide.files)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	report := irdiff.CompareAPI(oldSide.files, newSide.files, irdiff.APIOptions{IncludePrivate: diffIncludePrivate})
+
+	if err := writeDiffReport(report, diffFormat, diffOutputFile); err != nil {
+		return err
+	}
+	if diffOutputFile != "" {
+		fmt.Fprintf(os.Stderr, "%s, written to %s\n", report.Summary(), diffOutputFile)
+	}
+
+	// Exit directly so CI logs show the report, not the usage hint
+	if diffFailOnBreaking && report.BreakingCount() > 0 {
+		fmt.Fprintf(os.Stderr, "%d breaking API change%s found\n", report.BreakingCount(), pluralS(report.BreakingCount()))
+		os.Exit(1)
+	}
+	return nil
+}
+
+// writeDiffReport writes the report to a file or stdout
+func writeDiffReport(report *irdiff.APIReport, format, outputPath string) error {
+	var w io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch format {
+	case "md":
+		return report.WriteMarkdown(w)
+	case "json":
+		return report.WriteJSON(w)
+	default:
+		return report.WriteText(w)
+	}
+}
+
+// diffSide is one version of the code base
+type diffSide struct {
+	files []*ir.DistilledFile
+	// lossy is set for sides read from json-structured output
+	lossy bool
+}
+
+// diffOptions returns the processing options for API comparison: all
+// visibilities (to detect visibility changes) and no bodies or comments
+func diffOptions(basePath string) processor.ProcessOptions {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeImplementation = false
+	opts.IncludeComments = false
+	opts.IncludeDocstrings = false
+	opts.IncludeImports = false
+	opts.IncludePatterns = includeGlob
+	opts.ExcludePatterns = excludeGlob
+	opts.BasePath = basePath
+	opts.FilePathType = "relative"
+	return opts
+}
+
+// loadDiffSide distills a directory, file, IR snapshot, json-structured output
+// or git revision
+func loadDiffSide(ctx context.Context, proc *processor.Processor, spec string) (*diffSide, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("diff")
+
+	if info, err := os.Stat(spec); err == nil {
+		if !info.IsDir() && isStructuredJSON(spec) {
+			data, err := os.ReadFile(spec)
+			if err != nil {
+				return nil, err
+			}
+			if isIRDocument(data) {
+				dbg.Logf(debug.LevelBasic, "Reading %s as IR snapshot", spec)
+				root, err := ir.DecodeDocument(bytes.NewReader(data))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", spec, err)
+				}
+				return &diffSide{files: resultFiles(root)}, nil
+			}
+			dbg.Logf(debug.LevelBasic, "Reading %s as json-structured output", spec)
+			files, err := formatter.ReadJSONStructured(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", spec, err)
+			}
+			return &diffSide{files: files, lossy: true}, nil
+		}
+
+		absPath, err := filepath.Abs(spec)
+		if err != nil {
+			return nil, err
+		}
+		dbg.Logf(debug.LevelBasic, "Distilling %s", absPath)
+		result, err := proc.ProcessPath(absPath, diffOptions(absPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to process %s: %w", spec, err)
+		}
+		return &diffSide{files: resultFiles(result)}, nil
+	}
+
+	files, err := distillGitRevision(ctx, proc, spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a path nor a git revision: %w", spec, err)
+	}
+	return &diffSide{files: files}, nil
+}
+
+// isStructuredJSON reports whether a file looks like json-structured output
+func isStructuredJSON(path string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return true
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	head := make([]byte, 64)
+	n, _ := file.Read(head)
+	return bytes.HasPrefix(bytes.TrimSpace(head[:n]), []byte("{"))
+}
+
+// isIRDocument reports whether data is an IR snapshot (aid --format ir)
+func isIRDocument(data []byte) bool {
+	var header struct {
+		Schema string `json:"schema"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Schema == ir.Schema
+}
+
+// distillGitRevision distills the files of a git revision, given as REV or
+// REV:DIR, without checking it out
+func distillGitRevision(ctx context.Context, proc *processor.Processor, spec string) ([]*ir.DistilledFile, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("diff")
+
+	rev, dir, _ := strings.Cut(spec, ":")
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+
+	repoRoot, err := runGit(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	repoRoot = strings.TrimSpace(repoRoot)
+	if _, err := runGit(repoRoot, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git revision: %s", rev)
+	}
+
+	args := []string{"ls-tree", "-r", "-z", "--name-only", rev}
+	if dir != "" {
+		args = append(args, "--", dir)
+	}
+	out, err := runGit(repoRoot, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := filepath.Join(repoRoot, filepath.FromSlash(dir))
+	opts := diffOptions(scope)
+	filter := processor.NewFileFilter(scope, opts)
+
+	var paths []string
+	for _, path := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+		if path == "" {
+			continue
+		}
+		if match, _ := filter.Contains(filepath.Join(repoRoot, filepath.FromSlash(path))); match {
+			paths = append(paths, path)
+		}
+	}
+	dbg.Logf(debug.LevelBasic, "Distilling %d files from %s", len(paths), spec)
+
+	contents, err := readGitBlobs(repoRoot, rev, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ir.DistilledFile
+	for i, path := range paths {
+		file, err := proc.ProcessContent(filepath.Join(repoRoot, filepath.FromSlash(path)), contents[i], opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to process %s:%s: %v\n", rev, path, err)
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// readGitBlobs reads the content of files at a revision with a single
+// git cat-file process
+func readGitBlobs(repoRoot, rev string, paths []string) ([][]byte, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	var input bytes.Buffer
+	for _, path := range paths {
+		fmt.Fprintf(&input, "%s:%s\n", rev, path)
+	}
+
+	cmd := exec.Command("git", "-C", repoRoot, "cat-file", "--batch")
+	cmd.Stdin = &input
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+
+	reader := bufio.NewReader(stdout)
+	contents := make([][]byte, len(paths))
+	for i, path := range paths {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: %v %s", err, strings.TrimSpace(stderr.String()))
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: cannot read %s:%s", rev, path)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: invalid header %q", strings.TrimSpace(header))
+		}
+		contents[i] = make([]byte, size+1) // Content is followed by a newline
+		if _, err := io.ReadFull(reader, contents[i]); err != nil {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		contents[i] = contents[i][:size]
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	return contents, nil
+}
+
+// resultFiles returns the files of a processing result
+func resultFiles(result ir.DistilledNode) []*ir.DistilledFile {
+	switch r := result.(type) {
+	case *ir.DistilledFile:
+		return []*ir.DistilledFile{r}
+	case *ir.DistilledDirectory:
+		var files []*ir.DistilledFile
+		for _, child := range r.Children {
+			if file, ok := child.(*ir.DistilledFile); ok {
+				files = append(files, file)
+			}
+		}
+		return files
+	}
+	return nil
+}
+
+// roundTripStructured reduces files to what json-structured output keeps
+func roundTripStructured(files []*ir.DistilledFile) ([]*ir.DistilledFile, error) {
+	var buf bytes.Buffer
+	f := formatter.NewJSONStructuredFormatter(formatter.Options{Compact: true})
+	if err := f.FormatMultiple(&buf, files); err != nil {
+		return nil, err
+	}
+	return formatter.ReadJSONStructured(&buf)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/irdiff"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDiffSides(t *testing.T) {
+	dir, git := gitRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+
+	writeRepoFile(t, dir, "src/api.py", "def fetch(url):\n    pass\n\ndef close():\n    pass\n")
+	writeRepoFile(t, dir, "README.md", "# readme\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+
+	writeRepoFile(t, dir, "src/api.py", "def fetch(url, retries):\n    pass\n")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	proc := processor.New()
+	ctx := context.Background()
+
+	oldSide, err := loadDiffSide(ctx, proc, "v1:src")
+	require.NoError(t, err)
+	require.Len(t, oldSide.files, 1)
+	assert.Equal(t, "api.py", oldSide.files[0].Path)
+
+	newSide, err := loadDiffSide(ctx, proc, "src")
+	require.NoError(t, err)
+	require.Len(t, newSide.files, 1)
+	assert.Equal(t, "api.py", newSide.files[0].Path)
+
+	report := irdiff.CompareAPI(oldSide.files, newSide.files, irdiff.APIOptions{})
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, "fetch", report.Changes[0].Symbol)
+	assert.Equal(t, []string{"required parameter retries added"}, report.Changes[0].Details)
+	assert.Equal(t, "close", report.Changes[1].Symbol)
+	assert.Equal(t, irdiff.Removed, report.Changes[1].Kind)
+	assert.Equal(t, 2, report.BreakingCount())
+
+	// A saved json-structured side compares against a live one without
+	// reporting details the format does not keep
+	saved, err := roundTripStructured(newSide.files)
+	require.NoError(t, err)
+	assert.Empty(t, irdiff.CompareAPI(saved, newSide.files, irdiff.APIOptions{}).Changes)
+
+	// An IR snapshot keeps every detail and is read without reduction
+	snapshot := filepath.Join(t.TempDir(), "snapshot.json")
+	out, err := os.Create(snapshot)
+	require.NoError(t, err)
+	require.NoError(t, formatter.NewIRFormatter(formatter.Options{}).FormatMultiple(out, newSide.files))
+	require.NoError(t, out.Close())
+	snapshotSide, err := loadDiffSide(ctx, proc, snapshot)
+	require.NoError(t, err)
+	assert.False(t, snapshotSide.lossy)
+	assert.Empty(t, irdiff.CompareAPI(newSide.files, snapshotSide.files, irdiff.APIOptions{IncludePrivate: true}).Changes)
+
+	_, err = loadDiffSide(ctx, proc, "no-such-ref")
+	assert.ErrorContains(t, err, "neither a path nor a git revision")
+}
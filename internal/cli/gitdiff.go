@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/irdiff"
+	"github.com/janreges/ai-distiller/internal/processor"
+)
+
+// gitChange is a file touched between two revisions
+type gitChange struct {
+	status string // A, M, D, T (git --name-status letter)
+	path   string // Relative to the repository root, slash separated
+}
+
+// gitDiffSpec selects the two sides of a diff
+type gitDiffSpec struct {
+	// base is the old revision
+	base string
+	// staged compares the index instead of the working tree
+	staged bool
+}
+
+// newSide describes where new content comes from
+func (s gitDiffSpec) newSide() string {
+	if s.staged {
+		return "index"
+	}
+	return "working tree"
+}
+
+// processGitChanges distills the files below path that changed between the
+// base revision and the working tree or index. Each file only contains the
+// declarations that were added, removed or had their signature changed.
+func processGitChanges(ctx context.Context, proc *processor.Processor, path string, spec gitDiffSpec, opts processor.ProcessOptions) (*ir.DistilledDirectory, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("gitdiff")
+	defer dbg.Timing(debug.LevelBasic, "git diff distillation")()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := path
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	repoRoot, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository: %w", path, err)
+	}
+	repoRoot = strings.TrimSpace(repoRoot)
+	// Resolve symlinks so paths from git and the filesystem compare equal
+	if resolved, err := filepath.EvalSymlinks(repoRoot); err == nil {
+		repoRoot = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+		dir = path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+	}
+
+	if _, err := runGit(repoRoot, "rev-parse", "--verify", "--quiet", spec.base+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git revision: %s", spec.base)
+	}
+
+	changes, err := listGitChanges(repoRoot, path, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply .aidignore, include/exclude and language filters up front so
+	// that the reported count matches the files that are distilled
+	filter := processor.NewFileFilter(dir, opts)
+	type selectedChange struct {
+		change   gitChange
+		absPath  string
+		explicit bool
+	}
+	var selected []selectedChange
+	for _, change := range changes {
+		absPath := filepath.Join(repoRoot, filepath.FromSlash(change.path))
+
+		var match, explicit bool
+		if info.IsDir() {
+			match, explicit = filter.Contains(absPath)
+		} else {
+			match, explicit = filter.MatchFile(absPath)
+		}
+		if !match {
+			dbg.Logf(debug.LevelDetailed, "Skipping %s (filtered)", change.path)
+			continue
+		}
+		selected = append(selected, selectedChange{change: change, absPath: absPath, explicit: explicit})
+	}
+	dbg.Logf(debug.LevelBasic, "%d files changed between %s and %s, %d after filtering",
+		len(changes), spec.base, spec.newSide(), len(selected))
+
+	result := &ir.DistilledDirectory{Path: path}
+	apiChanged := 0
+	for _, sel := range selected {
+		fileOpts := opts
+		fileOpts.ExplicitInclude = sel.explicit
+		file, err := diffGitFile(proc, repoRoot, sel.absPath, sel.change, spec, fileOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", sel.change.path, err)
+			continue
+		}
+		if file == nil {
+			dbg.Logf(debug.LevelDetailed, "%s has no API changes", sel.change.path)
+			continue
+		}
+		apiChanged++
+		result.Children = append(result.Children, file)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d file%s changed since %s, %d with API changes\n",
+		len(selected), pluralS(len(selected)), spec.base, apiChanged)
+	return result, nil
+}
+
+// diffGitFile distills both versions of a changed file and returns the
+// annotated declarations, or nil if no declaration changed
+func diffGitFile(proc *processor.Processor, repoRoot, absPath string, change gitChange, spec gitDiffSpec, opts processor.ProcessOptions) (*ir.DistilledFile, error) {
+	var oldFile, newFile *ir.DistilledFile
+
+	if change.status != "A" {
+		content, err := runGit(repoRoot, "show", spec.base+":"+change.path)
+		if err != nil {
+			return nil, err
+		}
+		if oldFile, err = proc.ProcessContent(absPath, []byte(content), opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if change.status != "D" {
+		var content []byte
+		var err error
+		if spec.staged {
+			var staged string
+			staged, err = runGit(repoRoot, "show", ":"+change.path)
+			content = []byte(staged)
+		} else {
+			content, err = os.ReadFile(absPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if newFile, err = proc.ProcessContent(absPath, content, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := irdiff.Compare(oldFile, newFile)
+	if len(diff.Changes) == 0 {
+		return nil, nil
+	}
+	return diff.File, nil
+}
+
+// excludeAidOutput is a pathspec that leaves out aid's own .aid directories,
+// which hold distilled output and are usually untracked
+const excludeAidOutput = ":(exclude,glob)**/.aid/**"
+
+// listGitChanges returns the files below path that differ between the base
+// revision and the index or working tree, including untracked files
+func listGitChanges(repoRoot, path string, spec gitDiffSpec) ([]gitChange, error) {
+	args := []string{"diff", "--name-status", "--no-renames", "-z"}
+	if spec.staged {
+		args = append(args, "--cached")
+	}
+	args = append(args, spec.base, "--", path, excludeAidOutput)
+
+	out, err := runGit(repoRoot, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []gitChange
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := fields[i][:1]
+		if status == "T" {
+			status = "M"
+		}
+		if status == "U" || status == "X" {
+			continue // Unmerged or unknown
+		}
+		changes = append(changes, gitChange{status: status, path: fields[i+1]})
+	}
+
+	if !spec.staged {
+		out, err := runGit(repoRoot, "ls-files", "--others", "--exclude-standard", "-z", "--", path, excludeAidOutput)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+			if file != "" {
+				changes = append(changes, gitChange{status: "A", path: file})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes, nil
+}
+
+// runGit runs git in dir and returns its standard output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
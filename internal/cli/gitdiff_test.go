@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/irdiff"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gitRepo(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	return dir, git
+}
+
+func writeRepoFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func changedSymbols(result *ir.DistilledDirectory) map[string]map[string]string {
+	symbols := make(map[string]map[string]string)
+	var walk func(file string, nodes []ir.DistilledNode)
+	walk = func(file string, nodes []ir.DistilledNode) {
+		for _, node := range nodes {
+			if kind, ok := irdiff.ChangeOf(node); ok {
+				if symbols[file] == nil {
+					symbols[file] = make(map[string]string)
+				}
+				if fn, ok := node.(*ir.DistilledFunction); ok {
+					symbols[file][fn.Name] = string(kind)
+				}
+			}
+			walk(file, node.GetChildren())
+		}
+	}
+	for _, child := range result.Children {
+		file := child.(*ir.DistilledFile)
+		walk(filepath.Base(file.Path), file.Children)
+	}
+	return symbols
+}
+
+func TestProcessGitChanges(t *testing.T) {
+	dir, git := gitRepo(t)
+
+	writeRepoFile(t, dir, "service.py", "def get(id):\n    return id\n\ndef drop():\n    pass\n")
+	writeRepoFile(t, dir, "body.py", "def same():\n    return 1\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	writeRepoFile(t, dir, "service.py", "def get(id, deep=False):\n    return id\n")
+	writeRepoFile(t, dir, "body.py", "def same():\n    return 2\n")
+	writeRepoFile(t, dir, "new.py", "def create(name):\n    pass\n")
+
+	proc := processor.New()
+	opts := processor.DefaultProcessOptions()
+	opts.BasePath = dir
+	opts.FilePathType = "relative"
+
+	t.Run("WorkingTree", func(t *testing.T) {
+		result, err := processGitChanges(context.Background(), proc, dir, gitDiffSpec{base: "HEAD"}, opts)
+		require.NoError(t, err)
+
+		// body.py only changed an implementation and is left out
+		assert.Equal(t, map[string]map[string]string{
+			"new.py":     {"create": "added"},
+			"service.py": {"get": "signature changed", "drop": "removed"},
+		}, changedSymbols(result))
+	})
+
+	t.Run("Staged", func(t *testing.T) {
+		git("add", "service.py")
+
+		result, err := processGitChanges(context.Background(), proc, dir, gitDiffSpec{base: "HEAD", staged: true}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"service.py": {"get": "signature changed", "drop": "removed"},
+		}, changedSymbols(result))
+	})
+
+	t.Run("UnknownRevision", func(t *testing.T) {
+		_, err := processGitChanges(context.Background(), proc, dir, gitDiffSpec{base: "no-such-ref"}, opts)
+		assert.ErrorContains(t, err, "unknown git revision")
+	})
+}
+
+func TestListGitChangesSkipsAidOutput(t *testing.T) {
+	dir, git := gitRepo(t)
+
+	writeRepoFile(t, dir, "main.py", "def run():\n    pass\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	writeRepoFile(t, dir, "new.py", "def create():\n    pass\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".aid", "cache"), 0755))
+	writeRepoFile(t, dir, ".aid/aid.main.txt", "distilled")
+	writeRepoFile(t, dir, ".aid/cache/result.py", "def cached():\n    pass\n")
+
+	changes, err := listGitChanges(dir, dir, gitDiffSpec{base: "HEAD"})
+	require.NoError(t, err)
+	assert.Equal(t, []gitChange{{status: "A", path: "new.py"}}, changes)
+}
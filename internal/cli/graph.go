@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/semantic"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphOutputFile string
+	graphCallers    string
+	graphCallees    string
+)
+
+// graphCmd builds a project-wide semantic call graph
+var graphCmd = &cobra.Command{
+	Use:   "graph [path]",
+	Short: "Build a semantic call graph for Python and TypeScript code",
+	Long: `Build a project-wide semantic graph of symbols, imports and calls for
+Python and TypeScript files and write it as JSON.
+
+With --callers or --callees the graph is queried instead and a short text
+answer is printed. Symbols can be given by name ("save"), by scoped name
+("UserService.save") or by the full symbol ID from the JSON output.
+
+Examples:
+  aid graph src/ -o graph.json
+  aid graph . --callers UserService.save
+  aid graph . --callees main`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runGraph,
+}
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphOutputFile, "output", "o", "", "Write the JSON graph to a file instead of stdout")
+	graphCmd.Flags().StringVar(&graphCallers, "callers", "", "Show who calls the given symbol")
+	graphCmd.Flags().StringVar(&graphCallees, "callees", "", "Show what the given symbol calls")
+	graphCmd.Flags().StringVar(&filePathType, "file-path-type", "relative", "How paths appear in the JSON graph: relative|absolute (default: relative)")
+	graphCmd.Flags().StringVar(&relativePathPrefix, "relative-path-prefix", "", "Custom prefix for relative paths (e.g., \"src/\")")
+	graphCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Verbose output (use -vv or -vvv for more detail)")
+	rootCmd.AddCommand(graphCmd)
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot access %s: %w", path, err)
+	}
+
+	files := []string{path}
+	projectRoot := path
+	if info.IsDir() {
+		if files, err = processor.CollectFiles(path, processor.DefaultProcessOptions()); err != nil {
+			return err
+		}
+	} else {
+		projectRoot = filepath.Dir(path)
+	}
+
+	dbg := debug.New(os.Stderr, verbosity)
+	ctx := debug.NewContext(context.Background(), dbg)
+
+	analyzer, err := semantic.NewProjectAnalyzer(projectRoot)
+	if err != nil {
+		return err
+	}
+	graph, err := analyzer.AnalyzeFiles(ctx, files)
+	if err != nil {
+		return fmt.Errorf("failed to build semantic graph: %w", err)
+	}
+	if len(graph.FileSymbolTables) == 0 {
+		return fmt.Errorf("no Python or TypeScript files found in %s", path)
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case graphCallers != "":
+		return printCallers(out, graph, graphQuery(graph.ProjectRoot, graphCallers))
+	case graphCallees != "":
+		return printCallees(out, graph, graphQuery(graph.ProjectRoot, graphCallees))
+	}
+
+	graph, err = graph.MapPaths(graphPathMapper(graph.ProjectRoot))
+	if err != nil {
+		return fmt.Errorf("failed to encode semantic graph: %w", err)
+	}
+	data, err := graph.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to encode semantic graph: %w", err)
+	}
+	data = append(data, '\n')
+
+	if graphOutputFile == "" {
+		_, err = out.Write(data)
+		return err
+	}
+	if err := os.WriteFile(graphOutputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", graphOutputFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "Semantic graph written to %s (%d files, %d symbols, %d/%d calls resolved)\n",
+		graphOutputFile, graph.Statistics.TotalFiles, graph.Statistics.TotalSymbols,
+		graph.Statistics.ResolvedCalls, graph.Statistics.TotalCallSites)
+	return nil
+}
+
+// graphPathMapper returns how file paths appear in the JSON graph, following
+// --file-path-type and --relative-path-prefix like the distilled output
+func graphPathMapper(projectRoot string) func(string) string {
+	opts := processor.ProcessOptions{
+		FilePathType:       filePathType,
+		BasePath:           projectRoot,
+		RelativePathPrefix: relativePathPrefix,
+	}
+	return func(path string) string {
+		if !filepath.IsAbs(path) {
+			return path // Builtins and unresolved modules
+		}
+		return processor.DirectoryDisplayPath(path, opts)
+	}
+}
+
+// graphQuery turns a symbol ID copied from the JSON graph, whose file part
+// is relative and may carry --relative-path-prefix, back into the ID the
+// graph uses internally. Other queries are returned unchanged.
+func graphQuery(projectRoot, query string) string {
+	return string(semantic.SymbolID(query).MapPath(func(path string) string {
+		if filepath.IsAbs(path) || strings.HasPrefix(path, "<") {
+			return path // Absolute paths and builtins
+		}
+		path = filepath.ToSlash(path)
+		if prefix := strings.TrimSuffix(filepath.ToSlash(relativePathPrefix), "/"); prefix != "" {
+			path = strings.TrimPrefix(path, prefix+"/")
+		}
+		return filepath.Join(projectRoot, filepath.FromSlash(path))
+	}))
+}
+
+// lookupGraphSymbols resolves a query to symbols, failing when nothing matches
+func lookupGraphSymbols(graph *semantic.SemanticGraph, query string) ([]*semantic.Symbol, error) {
+	symbols := graph.FindSymbols(query)
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("symbol %q not found", query)
+	}
+	return symbols, nil
+}
+
+// printCallers writes every resolved call to the symbols matching query
+func printCallers(w io.Writer, graph *semantic.SemanticGraph, query string) error {
+	symbols, err := lookupGraphSymbols(graph, query)
+	if err != nil {
+		return err
+	}
+
+	for i, symbol := range symbols {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		sites := graph.CallSitesTo(symbol.ID)
+		fmt.Fprintf(w, "Callers of %s (%s): %d\n", graphQualifiedName(symbol), graphLocation(graph, symbol.Location), len(sites))
+		for _, site := range sites {
+			fmt.Fprintf(w, "  %s  %s\n", graphLocation(graph, site.Location), graphSymbolName(graph, site.CallerID))
+		}
+	}
+	return nil
+}
+
+// printCallees writes every call made from the symbols matching query
+func printCallees(w io.Writer, graph *semantic.SemanticGraph, query string) error {
+	symbols, err := lookupGraphSymbols(graph, query)
+	if err != nil {
+		return err
+	}
+
+	for i, symbol := range symbols {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		sites := graph.CallSitesFrom(symbol.ID)
+		fmt.Fprintf(w, "Calls from %s (%s): %d\n", graphQualifiedName(symbol), graphLocation(graph, symbol.Location), len(sites))
+		for _, site := range sites {
+			target := site.CalleeName + " (unresolved)"
+			if site.IsResolved {
+				target = graphSymbolName(graph, site.CalleeID)
+			}
+			fmt.Fprintf(w, "  %s  %s\n", graphLocation(graph, site.Location), target)
+		}
+	}
+	return nil
+}
+
+// graphQualifiedName returns a symbol name prefixed with its scope, e.g. "UserService.save"
+func graphQualifiedName(symbol *semantic.Symbol) string {
+	if symbol.Scope == "" {
+		return symbol.Name
+	}
+	return strings.ReplaceAll(symbol.Scope, "::", ".") + "." + symbol.Name
+}
+
+// graphSymbolName turns a symbol ID into a readable name such as
+// "UserService.save (service.py)" or "print (builtin)"
+func graphSymbolName(graph *semantic.SemanticGraph, id semantic.SymbolID) string {
+	parts := strings.Split(string(id), "::")
+	if len(parts) < 2 {
+		return string(id)
+	}
+
+	name := strings.Join(parts[1:], ".")
+	if parts[0] == "<builtin>" {
+		return name + " (builtin)"
+	}
+	return fmt.Sprintf("%s (%s)", name, graphRelPath(graph, parts[0]))
+}
+
+// graphLocation formats a location as a project-relative file:line
+func graphLocation(graph *semantic.SemanticGraph, loc semantic.FileLocation) string {
+	return fmt.Sprintf("%s:%d", graphRelPath(graph, loc.FilePath), loc.StartLine)
+}
+
+// graphRelPath makes a path relative to the graph's project root when possible
+func graphRelPath(graph *semantic.SemanticGraph, path string) string {
+	if rel, err := filepath.Rel(graph.ProjectRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
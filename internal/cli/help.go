@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -64,7 +65,8 @@ USAGE:
   {{.CommandPath}} [command]{{end}}
 
 PATH:
-  <path>                      Relative or absolute path to source directory or file
+  <path>...                   Relative or absolute paths to source directories or files;
+                              several paths are combined into one output
 
 QUICK START:
   # Most common usage patterns
@@ -92,7 +94,7 @@ CORE OPTIONS:
   -o, --output FILE           Output file (default: .aid/ folder or .aid.*.txt)
       --ai-action ACTION      AI analysis action (see list above)
       --ai-output FILE        Output path for AI action (default: action-specific directory/file)
-      --format FORMAT         Output format: text|md|jsonl|json-structured|xml (default: text)
+      --format FORMAT         Output format: text|md|jsonl|json-structured|xml|ir (default: text)
       --stdout                Print to stdout (in addition to file output)
   -w, --workers NUM           Parallel workers (0=auto, 1=serial, default: 0)
       --file-path-type TYPE   Path format: relative|absolute (default: relative)
@@ -110,7 +112,10 @@ FILTERING (Essential):
 SPECIAL MODES:
   --raw                       Process text files without parsing (overrides all content filters)
   --lang LANGUAGE             Force language: auto|python|typescript|javascript|go|rust|
-                              java|csharp|kotlin|cpp|php|ruby|swift (useful for stdin input)
+                              java|csharp|kotlin|scala|dart|c|cpp|php|ruby|swift|protobuf|
+                              graphql|sql|openapi|hcl|shell|jupyter|vue|svelte|astro (useful
+                              for stdin input)
+  --sql-schema 0|1            Fold SQL migration directories into the final schema (default: 1)
   aid .git                    Git history analysis mode (shows commit history)
   --with-analysis-prompt      Add comprehensive AI prompt for commit quality analysis, patterns,
                               development timeline visualization, and complexity insights
@@ -156,7 +161,7 @@ NAME
     aid - AI Distiller: Extract essential code structure for LLMs
 
 SYNOPSIS
-    aid [OPTIONS] <path>
+    aid [OPTIONS] <path>...
 
 DESCRIPTION
     AI Distiller transforms source code into optimized formats for Large Language Models.
@@ -216,11 +221,29 @@ AI ACTIONS (--ai-action)
 OPTIONS
 
 Primary Options:
-    <path>                      Path to source file or directory [required]
+    <path>...                   Paths to source files or directories [required]
     -o, --output FILE           Write output to file (default: .aid/ folder or .aid.*.txt)
     --ai-action ACTION          Use predefined AI action configuration
     --ai-output FILE            Custom output path for AI action
-    --format FORMAT             Output format (text|md|jsonl|json-structured|xml)
+    --format FORMAT             Output format (text|md|jsonl|json-structured|xml|ir)
+    --max-tokens N              Fit output into ~N tokens; omits implementation, private
+                               members, docstrings, non-exported files, then whole
+                               files by --rank order, and reports what was dropped
+    --symbol NAME               Output only the declarations matching NAME (e.g.
+                               UserService.create) and those they reference
+    --symbol-depth N            Levels of references followed from --symbol (default: 2)
+    --rank                      Order files by importance: import centrality, symbol
+                               fan-in and recent git churn
+    --top-files N               Keep only the N most important files; scores of all
+                               files are listed by --summary-type json
+    --split-tokens N            Write parts of ~N tokens (part-001, part-002, ...) and
+                               index.json into a directory named after the output file
+    --split-unit UNIT           What a part never splits: file|declaration (default: file)
+    --watch                     Keep output current as files change; only changed files
+                               are re-parsed (jsonl + --stdout streams changed files)
+    --changed-since REF         Distill only files changed since a git ref, keeping only
+                               added, removed and signature-changed declarations
+    --staged                    Same for staged changes (index vs HEAD or --changed-since)
 
 Visibility Filtering:
     --public 0|1               Include public members (default: 1)
@@ -254,6 +277,8 @@ Processing Options:
 Path Control:
     --file-path-type TYPE      Path format: relative|absolute (default: relative)
     --relative-path-prefix STR Custom prefix for relative paths
+    --files-from FILE          Read paths from FILE, or stdin for -, one per line or
+                               NUL-separated
 
 Git Mode (when path is .git):
     --git-limit NUM            Limit number of commits (default: 200, 0=all)
@@ -263,8 +288,36 @@ Git Mode (when path is .git):
                               - Complexity analysis with Mermaid visualizations
                               - Bug pattern detection and problem area identification
 
+Call Graph (aid graph [path], Python and TypeScript):
+    -o, --output FILE          Write the JSON graph to a file (default: stdout)
+    --callers SYMBOL           Show who calls SYMBOL (e.g. UserService.save)
+    --callees SYMBOL           Show what SYMBOL calls
+
+Import Graph (aid deps [path], Go, Python, TS/JS, Java/Kotlin/Scala and more):
+    --format FORMAT            Output format: text|dot|mermaid|json (default: text)
+    --level LEVEL              Graph nodes: file|package (default: file)
+    -o, --output FILE          Write the graph to a file (default: stdout)
+
+Render Snapshot (aid render --from FILE, FILE saved with --format ir, - for stdin):
+    --format FORMAT            Output format (default: text)
+    -o, --output FILE          Write the output to a file (default: stdout)
+    --private 0 ...            Filtering flags set to 0 remove more detail; a snapshot
+                               cannot regain what was not saved
+
+API Diff (aid diff <old> <new>, each a directory, IR or json-structured file or git REV[:DIR]):
+    --format FORMAT            Report format: text|md|json (default: text)
+    -o, --output FILE          Write the report to a file (default: stdout)
+    --private                  Also report private and internal declarations
+    --fail-on-breaking         Exit with status 1 when breaking changes are found
+
+MCP Server (aid mcp):
+    --root DIR                 Project root for relative paths (default: $AID_ROOT or cwd)
+                               Serves distill_file, distill_directory, aid_* tools over stdio
+
 Performance:
     -w, --workers NUM          Parallel workers (0=auto, 1=serial, default: 0)
+    --cache 0|1                Reuse results for unchanged files (default: 0)
+                               aid cache stats|clear to inspect or reset the cache
 
 Summary Output:
     --summary-type TYPE        Summary output format (default: visual-progress-bar)
@@ -285,7 +338,8 @@ Diagnostics:
 SUPPORTED LANGUAGES
 
     auto-detected: python, typescript, javascript, go, ruby, swift, rust, 
-    java, csharp, kotlin, cpp, php
+    java, csharp, kotlin, scala, dart, c, cpp, php, protobuf, graphql, sql,
+    openapi, hcl, shell, jupyter, vue, svelte, astro
 
 EXAMPLES
 
@@ -327,7 +381,10 @@ FILES
                  .aid.myproject.priv.prot.impl.txt (private, protected, implementation)
 
     Configuration:
-        No configuration file support - all options via command line
+        .aidrc at the project root of the input path (YAML or TOML) holds
+        default option values, with per-directory overrides under "directories".
+        Command line options always win; use -vv to see where each value came
+        from. Multiple inputs must resolve to the same values.
 
 EXIT STATUS
     0    Success
@@ -350,10 +407,36 @@ Explore the project on GitHub: https://github.com/janreges/ai-distiller%s
 `, gray, reset, gray, getVersionInfo(), reset)
 }
 
+// getCommandHelpTemplate returns the help template for subcommands such as
+// aid deps, listing their own flags instead of the distillation options
+func getCommandHelpTemplate() string {
+	return `{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces}}{{end}}
+
+USAGE:
+  {{.UseLine}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if .HasAvailableSubCommands}}
+
+COMMANDS:{{range .Commands}}{{if .IsAvailableCommand}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+FLAGS:
+{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`
+}
+
 // initializeHelpSystem sets up custom help templates and commands
 func initializeHelpSystem() {
-	// Set custom help template
+	// Set custom help template; subcommands get their own on first use
 	rootCmd.SetHelpTemplate(getHelpTemplate())
+	rootHelp := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if cmd != rootCmd {
+			cmd.SetHelpTemplate(getCommandHelpTemplate())
+		}
+		rootHelp(cmd, args)
+	})
 	
 	// Add extended help functionality
 	rootCmd.Flags().Bool("help-extended", false, "Show extended help documentation")
@@ -361,10 +444,10 @@ func initializeHelpSystem() {
 	
 	// Add help subcommands
 	helpCmd := &cobra.Command{
-		Use:   "help [topic]",
+		Use:   "help [topic|command]",
 		Short: "Get detailed help on specific topics",
-		Long:  "Get detailed help documentation for specific topics like AI actions, filtering, or git mode.",
-		Args:  cobra.MaximumNArgs(1),
+		Long:  "Get detailed help documentation for specific topics like AI actions, filtering, or git mode,\nor for a command such as deps or cache stats.",
+		Args:  cobra.ArbitraryArgs,
 		RunE:  runHelpCommand,
 	}
 	
@@ -399,20 +482,36 @@ func runHelpCommand(cmd *cobra.Command, args []string) error {
 	}
 	
 	topic := args[0]
-	switch topic {
-	case "actions":
+	switch {
+	case len(args) == 1 && topic == "actions":
 		showAIActionsHelp()
-	case "filtering":
+	case len(args) == 1 && topic == "filtering":
 		showFilteringHelp()
-	case "git":
+	case len(args) == 1 && topic == "git":
 		showGitHelp()
 	default:
-		return fmt.Errorf("unknown help topic: %s\nAvailable topics: actions, filtering, git", topic)
+		// Fall back to the help of a command, e.g. aid help cache stats
+		if sub, rest, err := rootCmd.Find(args); err == nil && sub != rootCmd && len(rest) == 0 {
+			return sub.Help()
+		}
+		return fmt.Errorf("unknown help topic: %s\nAvailable topics: actions, filtering, git, %s",
+			strings.Join(args, " "), strings.Join(helpCommandNames(), ", "))
 	}
 	
 	return nil
 }
 
+// helpCommandNames lists the subcommands that have their own help
+func helpCommandNames() []string {
+	var names []string
+	for _, sub := range rootCmd.Commands() {
+		if sub.IsAvailableCommand() && sub.Name() != "help" {
+			names = append(names, sub.Name())
+		}
+	}
+	return names
+}
+
 // showAIActionsHelp displays detailed AI actions documentation
 func showAIActionsHelp() {
 	// Determine if we should use colors
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveInputs returns the paths to distill: the arguments followed by the
+// paths listed by --files-from, each once. Arguments must exist, while
+// listed paths that do not exist, such as files deleted in a git diff, are
+// skipped with a warning. Paths are returned as given.
+func resolveInputs(args []string, filesFrom string, stdin io.Reader) ([]string, error) {
+	var inputs []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		key := path
+		if absPath, err := filepath.Abs(path); err == nil {
+			key = absPath
+		}
+		if !seen[key] {
+			seen[key] = true
+			inputs = append(inputs, path)
+		}
+	}
+
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err != nil {
+			return nil, fmt.Errorf("path does not exist: %s", arg)
+		}
+		add(arg)
+	}
+
+	if filesFrom != "" {
+		listed, err := readFilesFrom(filesFrom, stdin)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range listed {
+			if _, err := os.Stat(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: path does not exist\n", path)
+				continue
+			}
+			add(path)
+		}
+	}
+
+	return inputs, nil
+}
+
+// readFilesFrom reads the paths listed in a file, or in stdin for "-"
+func readFilesFrom(name string, stdin io.Reader) ([]string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --files-from %s: %w", name, err)
+	}
+	return parsePathList(data), nil
+}
+
+// parsePathList splits a list of paths separated by newlines, or by NUL
+// characters as printed by git ls-files -z and find -print0. Blank lines
+// are skipped and Windows line endings are removed.
+func parsePathList(data []byte) []string {
+	separator := []byte("\n")
+	if bytes.IndexByte(data, 0) >= 0 {
+		separator = []byte{0}
+	}
+
+	var paths []string
+	for _, entry := range bytes.Split(data, separator) {
+		path := strings.TrimSuffix(string(entry), "\r")
+		if strings.TrimSpace(path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
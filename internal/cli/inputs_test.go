@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathList(t *testing.T) {
+	assert.Equal(t, []string{"a.go", "dir/b.go"}, parsePathList([]byte("a.go\r\n\n  \ndir/b.go\n")))
+	assert.Equal(t, []string{"a.go", "with space.go"}, parsePathList([]byte("a.go\x00with space.go\x00")))
+	assert.Empty(t, parsePathList(nil))
+}
+
+func TestResolveInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("package x\n"), 0644))
+	}
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+
+	t.Run("ArgumentsAndList", func(t *testing.T) {
+		// Duplicates are dropped and missing listed paths are skipped
+		list := strings.Join([]string{b, a, filepath.Join(dir, "deleted.go")}, "\n")
+		inputs, err := resolveInputs([]string{a, dir}, "-", strings.NewReader(list))
+		require.NoError(t, err)
+		assert.Equal(t, []string{a, dir, b}, inputs)
+	})
+
+	t.Run("ListFile", func(t *testing.T) {
+		listFile := filepath.Join(dir, "files.txt")
+		require.NoError(t, os.WriteFile(listFile, []byte(b+"\n"), 0644))
+		inputs, err := resolveInputs(nil, listFile, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{b}, inputs)
+	})
+
+	t.Run("MissingArgument", func(t *testing.T) {
+		_, err := resolveInputs([]string{a, filepath.Join(dir, "nope")}, "", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "path does not exist")
+	})
+
+	t.Run("MissingListFile", func(t *testing.T) {
+		_, err := resolveInputs(nil, filepath.Join(dir, "nope.txt"), nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,696 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/janreges/ai-distiller/internal/ai"
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/distill"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/mcp"
+	"github.com/janreges/ai-distiller/internal/performance"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var mcpRoot string
+
+// mcpCmd serves the AI Distiller tools over the Model Context Protocol
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run the MCP server over stdio",
+	Long: `Run a Model Context Protocol server on stdin/stdout.
+
+The server exposes the same tools as the @janreges/ai-distiller-mcp package
+(distill_file, distill_directory, aid_hunt_bugs, list_files, ...) but runs
+them in-process, so no Node.js is needed. Parsed files are kept in memory and
+reused until they change on disk.
+
+Relative paths are resolved against --root, $AID_ROOT or the current directory.
+
+Example (Claude Code):
+  claude mcp add aid -- aid mcp`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMCP,
+}
+
+func init() {
+	mcpCmd.Flags().StringVar(&mcpRoot, "root", "", "Project root for relative paths (default: $AID_ROOT or current directory)")
+	mcpCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Log requests to stderr (use -vv or -vvv for more detail)")
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	root := mcpRoot
+	if root == "" {
+		root = os.Getenv("AID_ROOT")
+	}
+	if root != "" {
+		if err := os.Chdir(root); err != nil {
+			return fmt.Errorf("failed to change to project root: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbg := debug.New(os.Stderr, verbosity)
+	ctx = debug.NewContext(ctx, dbg)
+	dbg.Logf(debug.LevelBasic, "AI Distiller MCP server %s starting", Version)
+
+	// Stdout carries the protocol only; logs and diagnostics go to stderr
+	return newMCPServer(ctx).Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// mcpTools implements the MCP tools on top of a long-lived processor
+type mcpTools struct {
+	proc    *processor.Processor
+	results *performance.MemoryCache
+	started time.Time
+}
+
+// newMCPServer creates an MCP server with all AI Distiller tools registered
+func newMCPServer(ctx context.Context) *mcp.Server {
+	results := performance.NewMemoryCache().WithVersion(Version)
+	tools := &mcpTools{
+		proc:    processor.NewWithContext(ctx).WithCache(results),
+		results: results,
+		started: time.Now(),
+	}
+
+	server := mcp.NewServer("AI Distiller MCP", Version)
+	tools.register(server)
+	return server
+}
+
+// Shared tool argument schemas
+var (
+	mcpPropIncludePrivate        = mcp.Property{Type: "boolean", Description: "Include private members (default: false)"}
+	mcpPropIncludeProtected      = mcp.Property{Type: "boolean", Description: "Include protected members (default: false)"}
+	mcpPropIncludeInternal       = mcp.Property{Type: "boolean", Description: "Include internal/package-private members (default: false)"}
+	mcpPropIncludeImplementation = mcp.Property{Type: "boolean", Description: "Include function/method bodies (default: false)"}
+	mcpPropIncludePatterns       = mcp.Property{Type: "string", Description: "File patterns to include (comma-separated, e.g., '*.go,*.py')"}
+	mcpPropExcludePatterns       = mcp.Property{Type: "string", Description: "File patterns to exclude (comma-separated, e.g., '*test*,vendor/**')"}
+)
+
+// mcpActionTool describes a tool that runs an AI action
+type mcpActionTool struct {
+	name        string
+	title       string
+	description string
+	action      string
+	done        string
+	// privateOption and implementationOption expose include_private and
+	// include_implementation arguments, both defaulting to true
+	privateOption        bool
+	implementationOption bool
+	// extra are free-form arguments echoed back to the agent as context
+	extra map[string]mcp.Property
+}
+
+// mcpActionTools mirrors the specialized and workflow tools of the npm MCP server
+var mcpActionTools = []mcpActionTool{
+	{
+		name:          "aid_hunt_bugs",
+		title:         "Hunt for Bugs and Quality Issues",
+		description:   "Generates a bug hunting prompt with distilled code for AI agents to systematically identify potential bugs, logical errors, race conditions, and quality issues.\n\nOUTPUT: A markdown file with the prompt and distilled code. AI agents should read this file and follow its instructions to perform the actual bug analysis.",
+		action:        "prompt-for-bug-hunting",
+		done:          "Bug hunting prompt generated successfully!",
+		privateOption: true,
+		extra: map[string]mcp.Property{
+			"focus_area": {Type: "string", Description: "Specific area to focus on (e.g., 'concurrency', 'memory leaks', 'error handling')"},
+		},
+	},
+	{
+		name:                 "aid_suggest_refactoring",
+		title:                "Suggest Code Refactoring Opportunities",
+		description:          "Generates a refactoring analysis prompt with distilled code for AI agents to identify specific refactoring opportunities for readability, maintainability or performance.\n\nOUTPUT: A markdown file with the prompt and distilled code. AI agents should read this file and follow its instructions to provide refactoring suggestions.",
+		action:               "prompt-for-refactoring-suggestion",
+		done:                 "Refactoring prompt generated!",
+		implementationOption: true,
+		extra: map[string]mcp.Property{
+			"refactoring_goal": {Type: "string", Description: "Goal of refactoring (e.g., 'improve readability', 'reduce complexity', 'modernize code')"},
+		},
+	},
+	{
+		name:        "aid_generate_diagram",
+		title:       "Generate Architecture Diagrams",
+		description: "Generates a diagram creation prompt with distilled code for AI agents to create architectural diagrams in Mermaid format.\n\nOUTPUT: A markdown file with the prompt and distilled code. AI agents should read this file and follow its instructions to create Mermaid diagrams.",
+		action:      "prompt-for-diagrams",
+		done:        "Diagram generation prompt created!",
+		extra: map[string]mcp.Property{
+			"diagram_focus": {Type: "string", Description: "Specific diagram focus (e.g., 'data flow', 'class hierarchy', 'module dependencies')"},
+		},
+	},
+	{
+		name:                 "aid_analyze_security",
+		title:                "Perform Security Analysis",
+		description:          "Generates a security analysis prompt with distilled code for AI agents to perform security audits with an OWASP Top 10 focus.\n\nOUTPUT: A markdown file with the prompt and distilled code. AI agents should read this file and follow its instructions to analyze vulnerabilities and suggest remediation.",
+		action:               "prompt-for-security-analysis",
+		done:                 "Security analysis prompt generated!",
+		privateOption:        true,
+		implementationOption: true,
+		extra: map[string]mcp.Property{
+			"security_focus": {Type: "string", Description: "Specific security concern (e.g., 'SQL injection', 'XSS', 'authentication')"},
+		},
+	},
+	{
+		name:                 "aid_deep_file_analysis",
+		title:                "Deep File-by-File Analysis Workflow",
+		description:          "Generates task lists and prompts for systematic file-by-file analysis across security, performance, maintainability and readability.\n\nOUTPUT: A task list, summary template and directory structure. AI agents should read the task list and follow its instructions.",
+		action:               "flow-for-deep-file-to-file-analysis",
+		done:                 "Deep file-by-file analysis workflow generated!",
+		privateOption:        true,
+		implementationOption: true,
+	},
+	{
+		name:        "aid_multi_file_docs",
+		title:       "Multi-File Documentation Workflow",
+		description: "Creates documentation workflow prompts covering multiple files, their relationships and the overall system architecture.\n\nOUTPUT: Workflow files that AI agents can follow to create interconnected documentation.",
+		action:      "flow-for-multi-file-docs",
+		done:        "Multi-file documentation workflow generated!",
+	},
+	{
+		name:                 "aid_complex_analysis",
+		title:                "Complex Codebase Analysis",
+		description:          "Generates a comprehensive prompt for architecture analysis, compliance checks and strategic recommendations on large codebases.\n\nOUTPUT: A markdown file with the prompt and distilled code.",
+		action:               "prompt-for-complex-codebase-analysis",
+		done:                 "Complex codebase analysis prompt generated!",
+		privateOption:        true,
+		implementationOption: true,
+	},
+	{
+		name:                 "aid_performance_analysis",
+		title:                "Performance Analysis",
+		description:          "Generates a performance analysis prompt that guides AI agents to find bottlenecks, analyze algorithmic complexity and suggest optimizations.\n\nOUTPUT: A markdown file with the prompt and distilled code.",
+		action:               "prompt-for-performance-analysis",
+		done:                 "Performance analysis prompt generated!",
+		implementationOption: true,
+	},
+	{
+		name:          "aid_best_practices",
+		title:         "Best Practices Analysis",
+		description:   "Generates a code quality prompt that assesses code against best practices, design patterns and clean code principles.\n\nOUTPUT: A markdown file with the prompt and distilled code.",
+		action:        "prompt-for-best-practices-analysis",
+		done:          "Best practices analysis prompt generated!",
+		privateOption: true,
+	},
+}
+
+// register adds all tools to the server
+func (t *mcpTools) register(server *mcp.Server) {
+	server.AddTool(mcp.Tool{
+		Name:        "distill_file",
+		Title:       "Extract Code Structure from File",
+		Description: "Extracts essential code structure from a single file. Returns clean, structured code signatures optimized for AI context windows.",
+		InputSchema: mcp.InputSchema{
+			Properties: map[string]mcp.Property{
+				"file_path":              {Type: "string", Description: "Path to the file to distill"},
+				"include_private":        mcpPropIncludePrivate,
+				"include_protected":      mcpPropIncludeProtected,
+				"include_internal":       mcpPropIncludeInternal,
+				"include_implementation": mcpPropIncludeImplementation,
+				"include_comments":       {Type: "boolean", Description: "Include comments (default: false)"},
+				"output_format":          {Type: "string", Description: "Output format (default: text)", Enum: []string{"text", "md", "json"}},
+			},
+			Required: []string{"file_path"},
+		},
+		Handler: t.distillFile,
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "distill_directory",
+		Title:       "Extract Code Structure from Directory",
+		Description: "Extracts essential code structure from an entire directory. Processes all supported languages and returns API/code signatures optimized for AI context windows.",
+		InputSchema: mcp.InputSchema{
+			Properties: map[string]mcp.Property{
+				"directory_path":         {Type: "string", Description: "Path to the directory to distill"},
+				"recursive":              {Type: "boolean", Description: "Process subdirectories recursively (default: true)"},
+				"include_private":        mcpPropIncludePrivate,
+				"include_protected":      mcpPropIncludeProtected,
+				"include_internal":       mcpPropIncludeInternal,
+				"include_implementation": mcpPropIncludeImplementation,
+				"include_patterns":       mcpPropIncludePatterns,
+				"exclude_patterns":       mcpPropExcludePatterns,
+				"output_format":          {Type: "string", Description: "Output format (default: text)", Enum: []string{"text", "md", "json", "jsonl", "xml"}},
+			},
+			Required: []string{"directory_path"},
+		},
+		Handler: t.distillDirectory,
+	})
+
+	for _, spec := range mcpActionTools {
+		spec := spec
+		props := map[string]mcp.Property{
+			"target_path":      {Type: "string", Description: "Path to file or directory to analyze"},
+			"include_patterns": mcpPropIncludePatterns,
+			"exclude_patterns": mcpPropExcludePatterns,
+		}
+		if spec.privateOption {
+			props["include_private"] = mcp.Property{Type: "boolean", Description: "Include all visibility levels (default: true)"}
+		}
+		if spec.implementationOption {
+			props["include_implementation"] = mcp.Property{Type: "boolean", Description: "Include implementation details (default: true)"}
+		}
+		for name, prop := range spec.extra {
+			props[name] = prop
+		}
+
+		server.AddTool(mcp.Tool{
+			Name:        spec.name,
+			Title:       spec.title,
+			Description: spec.description,
+			InputSchema: mcp.InputSchema{Properties: props, Required: []string{"target_path"}},
+			Handler: func(ctx context.Context, args mcp.Arguments) (string, error) {
+				opts := t.actionOptions(args, spec.privateOption && args.Bool("include_private", true),
+					spec.implementationOption && args.Bool("include_implementation", true))
+				report, err := t.runAction(ctx, spec.action, args.String("target_path"), opts)
+				if err != nil {
+					return "", err
+				}
+
+				var text strings.Builder
+				text.WriteString(spec.done + "\n\n")
+				for _, name := range sortedKeys(spec.extra) {
+					if value := args.String(name); value != "" {
+						fmt.Fprintf(&text, "%s: %s\n\n", strings.ReplaceAll(name, "_", " "), value)
+					}
+				}
+				text.WriteString(report)
+				return text.String(), nil
+			},
+		})
+	}
+
+	server.AddTool(mcp.Tool{
+		Name:        "aid_generate_docs",
+		Title:       "Generate Documentation",
+		Description: "Generates documentation prompts with distilled code for AI agents to create API references, usage examples and developer guides.\n\nOUTPUT: Markdown files with documentation prompts and distilled code.",
+		InputSchema: mcp.InputSchema{
+			Properties: map[string]mcp.Property{
+				"target_path":      {Type: "string", Description: "Path to file or directory to document"},
+				"doc_type":         {Type: "string", Description: "Type of documentation to generate", Enum: []string{"single-file-docs", "multi-file-docs", "api-reference"}},
+				"audience":         {Type: "string", Description: "Target audience (e.g., 'developers', 'api-users', 'contributors')"},
+				"include_patterns": mcpPropIncludePatterns,
+				"exclude_patterns": mcpPropExcludePatterns,
+			},
+			Required: []string{"target_path"},
+		},
+		Handler: func(ctx context.Context, args mcp.Arguments) (string, error) {
+			action := "prompt-for-single-file-docs"
+			if docType := args.String("doc_type"); docType == "multi-file-docs" || docType == "api-reference" {
+				action = "flow-for-multi-file-docs"
+			}
+
+			report, err := t.runAction(ctx, action, args.String("target_path"), t.actionOptions(args, false, false))
+			if err != nil {
+				return "", err
+			}
+
+			audience := args.String("audience")
+			if audience == "" {
+				audience = "general"
+			}
+			return fmt.Sprintf("Documentation generation prompt created!\n\nTarget audience: %s\n\n%s", audience, report), nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "aid_analyze",
+		Title:       "Core AI Prompt Generation Engine",
+		Description: "Generates pre-configured prompts with distilled code for AI-driven analysis. Maps directly to aid --ai-action; prefer the specialized tools when available.\n\nIMPORTANT: This tool does not perform analysis - it generates prompt files that AI agents should read and follow.",
+		InputSchema: mcp.InputSchema{
+			Properties: map[string]mcp.Property{
+				"ai_action":              {Type: "string", Description: "AI action to perform", Enum: ai.GetRegistry().GetNames()},
+				"target_path":            {Type: "string", Description: "Path to analyze"},
+				"user_query":             {Type: "string", Description: "Additional context or specific query"},
+				"include_private":        {Type: "boolean", Description: "Include private members (default: false)"},
+				"include_implementation": {Type: "boolean", Description: "Include implementation (default: false)"},
+				"include_patterns":       mcpPropIncludePatterns,
+				"exclude_patterns":       mcpPropExcludePatterns,
+			},
+			Required: []string{"ai_action", "target_path"},
+		},
+		Handler: func(ctx context.Context, args mcp.Arguments) (string, error) {
+			opts := t.actionOptions(args, args.Bool("include_private", false), args.Bool("include_implementation", false))
+			return t.runAction(ctx, args.String("ai_action"), args.String("target_path"), opts)
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "list_files",
+		Title:       "List Project Files",
+		Description: "Lists project files with language detection and statistics. Useful for exploring project structure before distillation.",
+		InputSchema: mcp.InputSchema{
+			Properties: map[string]mcp.Property{
+				"path":      {Type: "string", Description: "Path to list (default: current directory)"},
+				"pattern":   {Type: "string", Description: "File pattern to match (e.g., '*.py' or 'src/*.ts')"},
+				"recursive": {Type: "boolean", Description: "List recursively (default: true)"},
+			},
+		},
+		Handler: t.listFiles,
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "get_capabilities",
+		Title:       "Get AI Distiller Capabilities",
+		Description: "Returns information about AI Distiller capabilities, supported languages, formats, AI actions and available tools.",
+		Handler: func(ctx context.Context, args mcp.Arguments) (string, error) {
+			return t.capabilities(server)
+		},
+	})
+}
+
+// processOptions builds processing options from the shared distill arguments
+func (t *mcpTools) processOptions(args mcp.Arguments, path string) processor.ProcessOptions {
+	opts := processor.ProcessOptions{
+		IncludeComments:       args.Bool("include_comments", false),
+		IncludeImports:        true,
+		IncludeImplementation: args.Bool("include_implementation", false),
+		IncludeDocstrings:     true,
+		IncludeAnnotations:    true,
+		Recursive:             args.Bool("recursive", true),
+		BasePath:              path,
+		FilePathType:          "relative",
+		IncludePatterns:       splitPatterns(args.String("include_patterns")),
+		ExcludePatterns:       splitPatterns(args.String("exclude_patterns")),
+		FoldDirectories:       true,
+	}
+	distill.ApplyVisibility(&opts, true,
+		args.Bool("include_protected", false),
+		args.Bool("include_internal", false),
+		args.Bool("include_private", false))
+	return opts
+}
+
+// actionOptions builds processing options for AI actions
+func (t *mcpTools) actionOptions(args mcp.Arguments, allVisibility, implementation bool) processor.ProcessOptions {
+	opts := t.processOptions(mcp.Arguments{
+		"include_private":        allVisibility,
+		"include_protected":      allVisibility,
+		"include_internal":       allVisibility,
+		"include_implementation": implementation,
+		"include_patterns":       args.String("include_patterns"),
+		"exclude_patterns":       args.String("exclude_patterns"),
+	}, "")
+	return opts
+}
+
+// distill processes a path and renders it with the requested format
+func (t *mcpTools) distill(path string, opts processor.ProcessOptions, format string) (string, error) {
+	if format == "" {
+		format = "text"
+	}
+
+	result, err := t.proc.ProcessPath(path, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to process %s: %w", path, err)
+	}
+
+	output, err := formatDistilled(result, format)
+	if err != nil {
+		return "", err
+	}
+	if format == "text" {
+		output = distill.RemoveEmptyFiles(output)
+	}
+	if strings.TrimSpace(output) == "" {
+		return "", fmt.Errorf("no supported source files found in %s", path)
+	}
+	return output, nil
+}
+
+func (t *mcpTools) distillFile(ctx context.Context, args mcp.Arguments) (string, error) {
+	path, err := mcpAbsPath(args.String("file_path"))
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("file does not exist: %s", args.String("file_path"))
+	} else if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory, use distill_directory", args.String("file_path"))
+	}
+
+	return t.distill(path, t.processOptions(args, path), args.String("output_format"))
+}
+
+func (t *mcpTools) distillDirectory(ctx context.Context, args mcp.Arguments) (string, error) {
+	path, err := mcpAbsPath(args.String("directory_path"))
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("directory does not exist: %s", args.String("directory_path"))
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory, use distill_file", args.String("directory_path"))
+	}
+
+	return t.distill(path, t.processOptions(args, path), args.String("output_format"))
+}
+
+// runAction runs an AI action in-process, writes its output files and
+// returns a short report pointing the agent to them
+func (t *mcpTools) runAction(ctx context.Context, actionName, target string, opts processor.ProcessOptions) (string, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("mcp-action")
+
+	action, err := ai.GetRegistry().Get(actionName)
+	if err != nil {
+		return "", err
+	}
+	if err := action.Validate(); err != nil {
+		return "", fmt.Errorf("action validation failed: %w", err)
+	}
+
+	projectPath, err := mcpAbsPath(target)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(projectPath); err != nil {
+		return "", fmt.Errorf("path does not exist: %s", target)
+	}
+
+	baseName := filepath.Base(projectPath)
+	if baseName == "." || baseName == "/" {
+		baseName = "project"
+	}
+	actionCtx := &ai.ActionContext{
+		ProjectPath:     projectPath,
+		BaseName:        baseName,
+		Timestamp:       time.Now(),
+		IncludePatterns: opts.IncludePatterns,
+		ExcludePatterns: opts.ExcludePatterns,
+		Config:          &ai.ActionConfig{},
+	}
+
+	switch typed := action.(type) {
+	case ai.FlowAction:
+		outputPath, result, err := runFlowAction(dbg, typed, actionCtx)
+		if err != nil {
+			return "", err
+		}
+
+		var report strings.Builder
+		for _, msg := range result.Messages {
+			report.WriteString(msg + "\n")
+		}
+		fmt.Fprintf(&report, "\nOutput saved to: %s (%d file%s)\n", outputPath, len(result.Files), pluralS(len(result.Files)))
+		report.WriteString("AI agents should read the generated task list and follow its instructions.\n")
+		return report.String(), nil
+
+	case ai.ContentAction:
+		opts.BasePath = projectPath
+		distilled, err := t.distill(projectPath, opts, "text")
+		if err != nil {
+			return "", err
+		}
+		actionCtx.DistilledContent = distilled
+
+		outputPath, content, err := buildContentAction(typed, actionCtx)
+		if err != nil {
+			return "", err
+		}
+		if err := writeActionFile(outputPath, content); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Prompt file: %s (%.1f kB)\n\nAI agents should read this file and follow the instructions within it.\n",
+			outputPath, float64(len(content))/1024.0), nil
+
+	default:
+		return "", fmt.Errorf("unknown action type: %s", action.Type())
+	}
+}
+
+// mcpFileInfo describes one file in the list_files result
+type mcpFileInfo struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Modified  string `json:"modified"`
+	Language  string `json:"language"`
+	Extension string `json:"extension"`
+}
+
+func (t *mcpTools) listFiles(ctx context.Context, args mcp.Arguments) (string, error) {
+	base := args.String("path")
+	if base == "" {
+		base = "."
+	}
+	base, err := mcpAbsPath(base)
+	if err != nil {
+		return "", err
+	}
+
+	pattern := args.String("pattern")
+	recursive := args.Bool("recursive", true)
+
+	files := []mcpFileInfo{}
+	languages := make(map[string]int)
+	var totalSize int64
+
+	err = filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if entry.IsDir() {
+			if path != base && (!recursive || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(base, path)
+		if pattern != "" && !matchesListPattern(relPath, pattern) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		language := "unknown"
+		if proc, ok := processor.GetByFilename(path); ok {
+			language = proc.Language()
+		}
+		languages[language]++
+		totalSize += info.Size()
+
+		files = append(files, mcpFileInfo{
+			Path:      filepath.ToSlash(relPath),
+			Size:      info.Size(),
+			Modified:  info.ModTime().UTC().Format(time.RFC3339),
+			Language:  language,
+			Extension: strings.ToLower(filepath.Ext(path)),
+		})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return mcpJSON(map[string]any{
+		"path":       base,
+		"file_count": len(files),
+		"total_size": totalSize,
+		"languages":  languages,
+		"files":      files,
+	})
+}
+
+// matchesListPattern matches a glob against the relative path or the file name
+func matchesListPattern(relPath, pattern string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if matched, _ := filepath.Match(pattern, relPath); matched {
+		return true
+	}
+	matched, _ := filepath.Match(pattern, filepath.Base(relPath))
+	return matched
+}
+
+func (t *mcpTools) capabilities(server *mcp.Server) (string, error) {
+	root, _ := os.Getwd()
+
+	tools := make([]string, 0)
+	for _, tool := range server.Tools() {
+		tools = append(tools, fmt.Sprintf("%s - %s", tool.Name, tool.Title))
+	}
+
+	var prompts, workflows []string
+	for _, action := range ai.GetRegistry().List() {
+		if action.Type() == ai.ActionTypeFlow {
+			workflows = append(workflows, action.Name())
+		} else {
+			prompts = append(prompts, action.Name())
+		}
+	}
+
+	hits, misses := t.results.Stats()
+	return mcpJSON(map[string]any{
+		"server_name":         "AI Distiller MCP",
+		"server_version":      Version,
+		"protocol_version":    mcp.ProtocolVersion,
+		"root_path":           root,
+		"tools":               tools,
+		"supported_languages": processor.List(),
+		"supported_formats":   formatter.List(),
+		"ai_actions": map[string]any{
+			"prompts":   prompts,
+			"workflows": workflows,
+		},
+		"warm_cache": map[string]any{
+			"cached_files": t.results.Len(),
+			"hits":         hits,
+			"misses":       misses,
+			"uptime":       time.Since(t.started).Round(time.Second).String(),
+		},
+	})
+}
+
+// mcpAbsPath resolves a tool path argument against the working directory
+func mcpAbsPath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path must not be empty")
+	}
+	return filepath.Abs(path)
+}
+
+// mcpJSON renders a tool result as indented JSON
+func mcpJSON(value any) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// splitPatterns splits a comma-separated pattern list
+func splitPatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// sortedKeys returns the keys of a property map in sorted order
+func sortedKeys(props map[string]mcp.Property) []string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/rank"
+	"github.com/janreges/ai-distiller/internal/summary"
+)
+
+// churnCommits is the number of recent commits counted as churn
+const churnCommits = 500
+
+// rankFiles scores processed files by importance. Displayed paths are
+// mapped to paths below root for import resolution and git, and back.
+func rankFiles(ctx context.Context, files []*ir.DistilledFile, root, prefix string) []rank.File {
+	dbg := debug.FromContext(ctx).WithSubsystem("rank")
+	defer dbg.Timing(debug.LevelDetailed, "file ranking")()
+
+	prefix = filepath.ToSlash(prefix)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	displayed := make(map[string]string, len(files))
+	relFiles := make([]*ir.DistilledFile, 0, len(files))
+	for _, file := range files {
+		rel := filepath.ToSlash(file.Path)
+		if filepath.IsAbs(file.Path) {
+			if r, err := filepath.Rel(root, file.Path); err == nil {
+				rel = filepath.ToSlash(r)
+			}
+		} else {
+			rel = strings.TrimPrefix(rel, prefix)
+		}
+		displayed[rel] = file.Path
+
+		copied := *file
+		copied.Path = rel
+		relFiles = append(relFiles, &copied)
+	}
+
+	churn, err := gitChurn(root)
+	if err != nil {
+		dbg.Logf(debug.LevelDetailed, "No git churn: %v", err)
+	}
+
+	ranked := rank.Rank(root, relFiles, rank.Options{Churn: churn})
+	for i := range ranked {
+		ranked[i].Path = displayed[ranked[i].Path]
+		if i < 10 {
+			dbg.Logf(debug.LevelDetailed, "Rank %d: %s (score %.3f)", i+1, ranked[i].Path, ranked[i].Score)
+		}
+	}
+	return ranked
+}
+
+// gitChurn counts how many of the recent commits touched each file, by
+// path relative to dir
+func gitChurn(dir string) (map[string]int, error) {
+	out, err := runGit(dir, "log", "-n", fmt.Sprint(churnCommits), "--format=", "--name-only", "--relative", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	churn := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			churn[line]++
+		}
+	}
+	return churn, nil
+}
+
+// applyRanking orders a processed directory by rank (--rank) and keeps only
+// the top files (--top-files, 0 keeps all). Without --rank the kept files
+// stay in their original order.
+func applyRanking(result ir.DistilledNode, ranking []rank.File, order bool, top int) ir.DistilledNode {
+	dir, ok := result.(*ir.DistilledDirectory)
+	if !ok {
+		return result
+	}
+
+	byPath := make(map[string]ir.DistilledNode, len(dir.Children))
+	for _, child := range dir.Children {
+		if file, ok := child.(*ir.DistilledFile); ok {
+			byPath[file.Path] = file
+		}
+	}
+	keep := make(map[string]bool, len(ranking))
+	var ranked []ir.DistilledNode
+	for _, entry := range ranking {
+		if top > 0 && len(ranked) == top {
+			break
+		}
+		if file := byPath[entry.Path]; file != nil {
+			keep[entry.Path] = true
+			ranked = append(ranked, file)
+		}
+	}
+
+	reduced := &ir.DistilledDirectory{BaseNode: dir.BaseNode, Path: dir.Path}
+	if order {
+		reduced.Children = ranked
+		return reduced
+	}
+	for _, child := range dir.Children {
+		if file, ok := child.(*ir.DistilledFile); ok && keep[file.Path] {
+			reduced.Children = append(reduced.Children, child)
+		}
+	}
+	return reduced
+}
+
+// summaryRanking converts a ranking for the summary, marking the files that
+// are part of the output
+func summaryRanking(ranking []rank.File, output ir.DistilledNode) []summary.RankedFile {
+	included := make(map[string]bool)
+	for _, file := range distilledFiles(output) {
+		included[file.Path] = true
+	}
+	result := make([]summary.RankedFile, len(ranking))
+	for i, entry := range ranking {
+		result[i] = summary.RankedFile{
+			Path:       entry.Path,
+			Score:      entry.Score,
+			Centrality: entry.Centrality,
+			Dependents: entry.Dependents,
+			FanIn:      entry.FanIn,
+			Churn:      entry.Churn,
+			Included:   included[entry.Path],
+		}
+	}
+	return result
+}
+
+// printTopFiles tells the user how many files --top-files left out
+func printTopFiles(ranked, kept int) {
+	if kept < ranked {
+		fmt.Fprintf(os.Stderr, "Top files: kept %d of %d file%s by rank (see --summary-type json for all scores)\n",
+			kept, ranked, pluralS(ranked))
+	}
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/distill"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/stripper"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderFrom       string
+	renderFormat     string
+	renderOutputFile string
+)
+
+// renderFilterFlags maps the filtering flags accepted by aid render to the
+// stripper option that removes the category when the flag is 0
+var renderFilterFlags = []struct {
+	name   string
+	usage  string
+	remove func(*stripper.Options)
+}{
+	{"protected", "Set to 0 to remove protected members", func(o *stripper.Options) { o.RemoveProtectedOnly = true }},
+	{"internal", "Set to 0 to remove internal/package-private members", func(o *stripper.Options) { o.RemoveInternalOnly = true }},
+	{"private", "Set to 0 to remove private members", func(o *stripper.Options) { o.RemovePrivateOnly = true }},
+	{"comments", "Set to 0 to remove comments", func(o *stripper.Options) { o.RemoveComments = true }},
+	{"docstrings", "Set to 0 to remove documentation comments", func(o *stripper.Options) { o.RemoveDocstrings = true }},
+	{"implementation", "Set to 0 to remove function/method bodies", func(o *stripper.Options) { o.RemoveImplementations = true }},
+	{"imports", "Set to 0 to remove import statements", func(o *stripper.Options) { o.RemoveImports = true }},
+	{"annotations", "Set to 0 to remove decorators/annotations", func(o *stripper.Options) { o.RemoveAnnotations = true }},
+}
+
+// renderCmd formats a saved IR snapshot without re-parsing the sources
+var renderCmd = &cobra.Command{
+	Use:   "render --from <snapshot.json>",
+	Short: "Format a saved IR snapshot (aid --format ir) in any output format",
+	Long: `Read an IR snapshot written with --format ir and format it again, optionally
+removing more detail with the usual filtering flags. A snapshot can only be
+narrowed: a flag set to 0 removes that category, a flag set to 1 keeps what
+the snapshot contains.
+
+Save the snapshot with everything you may need later, then render views of it:
+  aid ./src --format ir --private=1 --protected=1 --internal=1 --implementation=1 -o snapshot.json
+  aid render --from snapshot.json --format md --private=0
+  aid render --from snapshot.json --implementation=0 --comments=0 -o api.txt
+  cat snapshot.json | aid render --from - --format jsonl`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runRender,
+}
+
+func init() {
+	renderCmd.Flags().StringVar(&renderFrom, "from", "", "IR snapshot to read (- for stdin)")
+	renderCmd.Flags().StringVar(&renderFormat, "format", "text", "Output format: md|text|jsonl|json-structured|xml|ir")
+	renderCmd.Flags().StringVarP(&renderOutputFile, "output", "o", "", "Write the output to a file instead of stdout")
+	for _, flag := range renderFilterFlags {
+		renderCmd.Flags().String(flag.name, "", flag.usage)
+	}
+	renderCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Verbose output (use -vv or -vvv for more detail)")
+	renderCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(renderCmd)
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	dbg := debug.New(os.Stderr, verbosity)
+	ctx := debug.NewContext(context.Background(), dbg)
+
+	if _, err := formatter.Get(renderFormat, formatter.Options{}); err != nil {
+		names := formatter.List()
+		sort.Strings(names)
+		return fmt.Errorf("invalid output format: %s (valid: %s)", renderFormat, strings.Join(names, ", "))
+	}
+
+	stripOpts, err := renderStripOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	root, err := readSnapshot(renderFrom)
+	if err != nil {
+		return err
+	}
+
+	output, err := renderSnapshot(ctx, root, stripOpts, renderFormat)
+	if err != nil {
+		return err
+	}
+
+	if renderOutputFile == "" {
+		fmt.Print(output)
+		return nil
+	}
+	if err := os.WriteFile(renderOutputFile, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	dbg.Logf(debug.LevelBasic, "Wrote output to %s", renderOutputFile)
+	return nil
+}
+
+// renderStripOptions collects the categories to remove from the filtering flags
+func renderStripOptions(cmd *cobra.Command) (stripper.Options, error) {
+	var opts stripper.Options
+	for _, flag := range renderFilterFlags {
+		value, _ := cmd.Flags().GetString(flag.name)
+		if value == "" {
+			continue
+		}
+		keep, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("--%s must be 0 or 1, got %q", flag.name, value)
+		}
+		if !keep {
+			flag.remove(&opts)
+		}
+	}
+	return opts, nil
+}
+
+// readSnapshot reads an IR document from a file or stdin
+func readSnapshot(path string) (ir.DistilledNode, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	root, err := ir.DecodeDocument(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, nil
+}
+
+// renderSnapshot strips and formats a decoded snapshot
+func renderSnapshot(ctx context.Context, root ir.DistilledNode, opts stripper.Options, format string) (string, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("render")
+
+	if opts.HasAnyOption() {
+		dbg.Logf(debug.LevelDetailed, "Applying stripper with options: %+v", opts)
+		s := stripper.New(opts)
+		switch r := root.(type) {
+		case *ir.DistilledFile:
+			root = r.Accept(s)
+		case *ir.DistilledDirectory:
+			stripped := &ir.DistilledDirectory{BaseNode: r.BaseNode, Path: r.Path}
+			for _, child := range r.Children {
+				if file, ok := child.(*ir.DistilledFile); ok {
+					stripped.Children = append(stripped.Children, file.Accept(s))
+				}
+			}
+			root = stripped
+		default:
+			return "", fmt.Errorf("snapshot root must be a file or directory, got %s", root.GetNodeKind())
+		}
+	}
+
+	output, err := formatDistilled(root, format)
+	if err != nil {
+		return "", err
+	}
+	if format == "text" {
+		output = distill.RemoveEmptyFiles(output)
+	}
+	return output, nil
+}
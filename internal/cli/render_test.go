@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/stripper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSnapshot(t *testing.T) {
+	files := []*ir.DistilledFile{{
+		Path:     "service.py",
+		Language: "python",
+		Children: []ir.DistilledNode{
+			&ir.DistilledImport{ImportType: "import", Module: "os"},
+			&ir.DistilledClass{Name: "Service", Visibility: ir.VisibilityPublic, Children: []ir.DistilledNode{
+				&ir.DistilledFunction{Name: "start", Visibility: ir.VisibilityPublic, Implementation: "self._boot()"},
+				&ir.DistilledFunction{Name: "_boot", Visibility: ir.VisibilityPrivate},
+			}},
+		},
+	}}
+
+	snapshot := filepath.Join(t.TempDir(), "snapshot.json")
+	out, err := os.Create(snapshot)
+	require.NoError(t, err)
+	require.NoError(t, formatter.NewIRFormatter(formatter.Options{}).FormatMultiple(out, files))
+	require.NoError(t, out.Close())
+
+	root, err := readSnapshot(snapshot)
+	require.NoError(t, err)
+
+	full, err := renderSnapshot(context.Background(), root, stripper.Options{}, "text")
+	require.NoError(t, err)
+	assert.Contains(t, full, "<file path=\"service.py\">")
+	assert.Contains(t, full, "_boot")
+	assert.Contains(t, full, "self._boot()")
+
+	narrowed, err := renderSnapshot(context.Background(), root, stripper.Options{
+		RemovePrivateOnly:     true,
+		RemoveImplementations: true,
+		RemoveImports:         true,
+	}, "md")
+	require.NoError(t, err)
+	assert.Contains(t, narrowed, "start")
+	assert.NotContains(t, narrowed, "_boot")
+	assert.NotContains(t, narrowed, "import os")
+
+	_, err = readSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
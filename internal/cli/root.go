@@ -8,7 +8,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -17,10 +16,14 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/janreges/ai-distiller/internal/ai"
 	"github.com/janreges/ai-distiller/internal/aiactions"
+	"github.com/janreges/ai-distiller/internal/budget"
 	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/distill"
 	"github.com/janreges/ai-distiller/internal/formatter"
 	"github.com/janreges/ai-distiller/internal/ir"
 	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/rank"
+	"github.com/janreges/ai-distiller/internal/split"
 	"github.com/janreges/ai-distiller/internal/project"
 	"github.com/janreges/ai-distiller/internal/language"
 	"github.com/janreges/ai-distiller/internal/version"
@@ -36,12 +39,23 @@ var (
 	outputFile       string
 	outputToStdout   bool
 	outputFormat     string
+	maxTokens        int
+	symbolQuery      string
+	symbolDepth      int
+	rankOutput       bool
+	topFiles         int
+	splitTokens      int
+	splitUnit        string
+	watchMode        bool
+	changedSince     string
+	stagedOnly       bool
 	stripOptions     []string // Deprecated, kept for backward compatibility
 	includeGlob      []string
 	excludeGlob      []string
 	recursiveStr     string
 	filePathType     string
 	relativePathPrefix string
+	filesFrom        string
 	verbosity        int
 	langOverride     string
 	
@@ -63,6 +77,12 @@ var (
 	// Concurrency flags
 	workers               int
 	
+	// Cache flag
+	useCache              *bool
+	
+	// SQL schema folding flag
+	sqlSchema             *bool
+	
 	// Raw mode flag
 	rawMode               bool
 	
@@ -84,7 +104,7 @@ var (
 
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
-	Use:   "aid [path]",
+	Use:   "aid [path...]",
 	Short: "AI Distiller - Extract essential code structure for LLMs",
 	Long: `AI Distiller (aid) intelligently "distills" source code from any project 
 into a compact, structured format, optimized for the context window of 
@@ -98,13 +118,32 @@ mode and outputs formatted commit history instead of processing source files.
 BASIC OPTIONS:
   -o, --output <file>          Output file (default: auto-generated)
   --stdout                     Print to stdout instead of file
-  --format <type>              Output format: text|md|jsonl|json-structured|xml
-                              (default: text)
+  --format <type>              Output format: text|md|jsonl|json-structured|xml|ir
+                              (default: text; ir is a snapshot for aid render)
+  --max-tokens <num>           Fit output into ~N tokens by omitting detail
+                              (default: 0 = no limit)
+  --symbol <name>              Output only a symbol (e.g. UserService.create) and
+                              the types and functions it references
+  --symbol-depth <num>         Levels of references to follow (default: 2)
+  --rank                       Order files by importance (import centrality,
+                              symbol fan-in, git churn)
+  --top-files <num>            Keep only the N most important files
+                              (default: 0 = all)
+  --split-tokens <num>         Write the output as parts of ~N tokens with an
+                              index, never splitting a file (default: 0 = off)
+  --split-unit <unit>          What a part never splits: file|declaration
+                              (default: file)
+  --watch                      Keep the output current as files change
+                              (re-processes only changed files; Ctrl+C to stop)
+  --changed-since <ref>        Distill only API changes since a git ref
+  --staged                     Distill only API changes in staged files
 
 PATH & OUTPUT CONTROL:
   --file-path-type <type>      How paths appear in output: relative|absolute
                               (default: relative)
   --relative-path-prefix <str> Custom prefix for relative paths (e.g., "src/")
+  --files-from <file|->        Read paths to process from a file or stdin,
+                              one per line or NUL-separated
   -r, --recursive              Process directories recursively
                               0/1 (default: 1)
 
@@ -161,9 +200,13 @@ PROCESSING MODE:
   --raw                        Process all text files without parsing
   --lang <language>            Override language detection
                               Languages: auto|python|typescript|javascript|go|ruby|
-                              swift|rust|java|csharp|kotlin|cpp|php
+                              swift|rust|java|csharp|kotlin|scala|dart|c|
+                              cpp|php|protobuf|graphql|sql|openapi|hcl|
+                              shell|jupyter|vue|svelte|astro
                               (default: auto)
   --tree-sitter                Use tree-sitter parser (experimental)
+  --sql-schema                 Fold SQL migration directories into the final
+                              schema, in migration order (0/1, default: 1)
 
 ───────────────────────────────────────────────────────────────────────────────
 
@@ -171,6 +214,8 @@ PERFORMANCE:
   -w, --workers <num>          Number of parallel workers
                               0=auto (80% CPU), 1=serial, N=use N workers
                               (default: 0)
+  --cache                      Reuse results for unchanged files from .aid/cache
+                              0/1 (default: 0); manage with "aid cache stats|clear"
 
 SUMMARY OUTPUT:
   --summary-type <type>        Summary format after processing
@@ -195,6 +240,8 @@ EXAMPLES:
   aid docs/ --raw              # Process text files without parsing
   aid . -w 1                   # Force serial processing
   aid --relative-path-prefix="module/" docs/  # Add custom prefix to paths
+  aid src/api src/models cmd/server.go  # Combine several paths in one output
+  git ls-files -z '*.go' | aid --files-from -  # Process a list of files
   aid .git                     # Show git commit history (special mode)
   aid .git --git-limit=50      # Show latest 50 commits
   
@@ -203,7 +250,7 @@ EXAMPLES:
   aid --include "*.go,*.py"    # Only Go and Python files
   aid --exclude "vendor/**,node_modules/**"  # Skip dependency directories
   aid src/ --include "**/*.ts" --exclude "**/*.spec.ts"  # TypeScript without tests`,
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.ArbitraryArgs,
 	RunE: runDistiller,
 }
 
@@ -213,7 +260,7 @@ func Execute() error {
 	rootCmd.SilenceErrors = true
 	rootCmd.SilenceUsage = true
 	
-	if err := rootCmd.Execute(); err != nil {
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
 		// Print error in red color
 		red := "\033[31m"
 		bold := "\033[1m"
@@ -229,10 +276,16 @@ func Execute() error {
 		
 		fmt.Fprintf(os.Stderr, "%s%sError: %s%s\n", red, bold, err.Error(), reset)
 		
+		// Subcommands have their own usage
+		if cmd != rootCmd {
+			fmt.Fprintf(os.Stderr, "\nRun '%s --help' for usage.\n", cmd.CommandPath())
+			return err
+		}
+		
 		// Show helpful usage for common errors
 		fmt.Fprintln(os.Stderr)
 		fmt.Fprintln(os.Stderr, "Usage:")
-		fmt.Fprintln(os.Stderr, "  aid [path] [flags]")
+		fmt.Fprintln(os.Stderr, "  aid [path...] [flags]")
 		fmt.Fprintln(os.Stderr)
 		fmt.Fprintln(os.Stderr, "Examples:")
 		fmt.Fprintln(os.Stderr, "  aid .                  # Process current directory")
@@ -267,7 +320,17 @@ func initFlags() {
 	// Output flags
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path (default: .aid.<dir>.[options].txt)")
 	rootCmd.Flags().BoolVar(&outputToStdout, "stdout", false, "Print to stdout (in addition to file)")
-	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: md|text|jsonl|json-structured|xml (default: text)")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: md|text|jsonl|json-structured|xml|ir (default: text)")
+	rootCmd.Flags().BoolVar(&watchMode, "watch", false, "Keep the output up to date, re-processing only changed files (directories only, Ctrl+C to stop)")
+	rootCmd.Flags().StringVar(&changedSince, "changed-since", "", "Distill only files changed since a git ref, marking added, removed and signature-changed declarations")
+	rootCmd.Flags().BoolVar(&stagedOnly, "staged", false, "Like --changed-since, but compare staged changes (index) against HEAD or the --changed-since ref")
+	rootCmd.Flags().StringVar(&symbolQuery, "symbol", "", "Output only the named symbol (e.g. UserService.create) and the types and functions it references")
+	rootCmd.Flags().IntVar(&symbolDepth, "symbol-depth", 2, "Levels of references followed from --symbol (0=symbol only)")
+	rootCmd.Flags().BoolVar(&rankOutput, "rank", false, "Order files by importance: import graph centrality, symbol fan-in and recent git churn")
+	rootCmd.Flags().IntVar(&topFiles, "top-files", 0, "Keep only the N most important files, ranked as for --rank (0=all)")
+	rootCmd.Flags().IntVar(&splitTokens, "split-tokens", 0, "Write the output as part-001, part-002, ... of ~N tokens each plus index.json, in a directory named after the output file (0=one file)")
+	rootCmd.Flags().StringVar(&splitUnit, "split-unit", "file", "What a part never splits: file, or declaration to divide large files between classes and functions")
+	rootCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Fit output into ~N tokens, dropping implementation, private members, docstrings, non-exported and low-ranked files as needed (0=no limit)")
 
 	// Legacy processing flags (deprecated)
 	rootCmd.Flags().StringSliceVar(&stripOptions, "strip", nil, "DEPRECATED: Use individual filtering flags instead")
@@ -279,6 +342,7 @@ func initFlags() {
 	rootCmd.Flags().StringVarP(&recursiveStr, "recursive", "r", "1", "Process directories recursively (0/1, default: 1)")
 	rootCmd.Flags().StringVar(&filePathType, "file-path-type", "relative", "How paths appear in output: relative|absolute (default: relative)")
 	rootCmd.Flags().StringVar(&relativePathPrefix, "relative-path-prefix", "", "Custom prefix for relative paths (e.g., \"src/\")")
+	rootCmd.Flags().StringVar(&filesFrom, "files-from", "", "Read paths to process from a file or - for stdin (one per line or NUL-separated)")
 
 	// General flags
 	rootCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Verbose output (use -vv or -vvv for more detail)")
@@ -287,7 +351,7 @@ func initFlags() {
 	
 
 	// Language override flag
-	rootCmd.Flags().StringVar(&langOverride, "lang", "auto", "Override language detection: auto|python|typescript|javascript|go|ruby|swift|rust|java|csharp|kotlin|cpp|php")
+	rootCmd.Flags().StringVar(&langOverride, "lang", "auto", "Override language detection: auto|python|typescript|javascript|go|ruby|swift|rust|java|csharp|kotlin|scala|dart|c|cpp|php|protobuf|graphql|sql|openapi|hcl|shell|jupyter|vue|svelte|astro")
 	
 	// New filtering flags - visibility
 	rootCmd.Flags().String("public", "1", "Include public members (0/1, default: 1)")
@@ -308,6 +372,8 @@ func initFlags() {
 	
 	// Concurrency flags
 	rootCmd.Flags().IntVarP(&workers, "workers", "w", 0, "Number of parallel workers (0=auto/80% CPU cores, 1=serial, default: 0)")
+	rootCmd.Flags().String("cache", "0", "Cache distilled files between runs in .aid/cache (0/1, default: 0)")
+	rootCmd.Flags().String("sql-schema", "1", "Fold SQL migration folders into their final schema (0/1, default: 1)")
 	
 	// Raw mode flag
 	rootCmd.Flags().BoolVar(&rawMode, "raw", false, "Raw mode: process all text files without parsing (txt, md, json, yaml, etc.")
@@ -342,6 +408,12 @@ func initFlags() {
 			os.Exit(0)
 		}
 		
+		// Apply .aidrc defaults before flag values are interpreted
+		if err := applyProjectConfig(cmd, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		
 		// Parse boolean flags
 		parseBoolFlag(cmd, "public", &includePublic)
 		parseBoolFlag(cmd, "protected", &includeProtected)
@@ -352,6 +424,8 @@ func initFlags() {
 		parseBoolFlag(cmd, "implementation", &includeImplementation)
 		parseBoolFlag(cmd, "imports", &includeImports)
 		parseBoolFlag(cmd, "annotations", &includeAnnotations)
+		parseBoolFlag(cmd, "cache", &useCache)
+		parseBoolFlag(cmd, "sql-schema", &sqlSchema)
 		
 		// Validate mutually exclusive flags
 		if includeList != "" && excludeList != "" {
@@ -368,12 +442,14 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 	
 	// Log startup info
 	dbg.Logf(debug.LevelBasic, "AI Distiller %s starting", Version)
+	logEffectiveConfig(dbg, cmd)
 	
-	// Check if stdin is available (not a TTY) or explicitly requested with "-"
+	// Check if stdin is available (not a TTY) or explicitly requested with "-".
+	// With several paths or --files-from, stdin is never source code.
 	stdinAvailable := false
-	if len(args) > 0 && args[0] == "-" {
+	if len(args) == 1 && args[0] == "-" {
 		stdinAvailable = true
-	} else {
+	} else if len(args) <= 1 && filesFrom == "" {
 		// Check if stdin is piped
 		stat, _ := os.Stdin.Stat()
 		if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -387,12 +463,35 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 	}
 	
 	// If no arguments provided, show help
-	if len(args) == 0 {
+	if len(args) == 0 && filesFrom == "" {
 		return cmd.Help()
 	}
 	
+	// Collect the paths from arguments and --files-from
+	inputs, err := resolveInputs(args, filesFrom, os.Stdin)
+	if err != nil {
+		return err
+	}
+	if err := checkProjectConfig(inputs); err != nil {
+		return err
+	}
+	multipleInputs := len(inputs) != 1 || filesFrom != ""
+	if multipleInputs {
+		switch {
+		case watchMode:
+			return fmt.Errorf("--watch accepts a single path")
+		case changedSince != "" || stagedOnly:
+			return fmt.Errorf("--changed-since and --staged accept a single path")
+		case aiAction != "" || aiAnalysisTaskList:
+			return fmt.Errorf("--ai-action accepts a single path")
+		}
+	}
+
 	// Handle file/directory input
-	inputPath := args[0]
+	inputPath := "."
+	if len(inputs) > 0 {
+		inputPath = inputs[0]
+	}
 
 	// Resolve absolute path
 	absPath, err := filepath.Abs(inputPath)
@@ -400,13 +499,8 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to resolve path: %w", err)
 	}
 
-	// Check if path exists
-	if _, err := os.Stat(absPath); err != nil {
-		return fmt.Errorf("path does not exist: %s", inputPath)
-	}
-
 	// Check if the path is a .git directory
-	if filepath.Base(absPath) == ".git" {
+	if filepath.Base(absPath) == ".git" && !multipleInputs {
 		// For git mode, default to stdout unless output file is explicitly specified
 		// This is different from regular mode where we auto-generate filenames
 		return handleGitMode(ctx, absPath)
@@ -426,17 +520,65 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 
 	// Generate output filename if not specified and not using stdout
 	if outputFile == "" && !outputToStdout {
-		outputFile = generateOutputFilename(absPath, stripOptions, outputFormat)
+		outputName := absPath
+		if multipleInputs {
+			outputName = "files"
+		}
+		outputFile = generateOutputFilename(outputName, stripOptions, outputFormat)
 	}
 
 	// Validate output format
-	validFormats := []string{"md", "text", "jsonl", "json-structured", "xml"}
+	validFormats := []string{"md", "text", "jsonl", "json-structured", "xml", "ir"}
 	if !contains(validFormats, outputFormat) {
 		return fmt.Errorf("invalid output format: %s (valid: %s)", outputFormat, strings.Join(validFormats, ", "))
 	}
+	if maxTokens < 0 {
+		return fmt.Errorf("invalid --max-tokens: %d (must be 0 or positive)", maxTokens)
+	}
+	gitDiff := changedSince != "" || stagedOnly
+	if gitDiff && watchMode {
+		return fmt.Errorf("--watch cannot be combined with --changed-since or --staged")
+	}
+	if symbolDepth < 0 {
+		return fmt.Errorf("invalid --symbol-depth: %d (must be 0 or positive)", symbolDepth)
+	}
+	if topFiles < 0 {
+		return fmt.Errorf("invalid --top-files: %d (must be 0 or positive)", topFiles)
+	}
+	if (rankOutput || topFiles > 0) && watchMode {
+		return fmt.Errorf("--rank and --top-files cannot be combined with --watch")
+	}
+	if splitTokens < 0 {
+		return fmt.Errorf("invalid --split-tokens: %d (must be 0 or positive)", splitTokens)
+	}
+	if unit := split.Unit(splitUnit); unit != split.UnitFile && unit != split.UnitDeclaration {
+		return fmt.Errorf("invalid --split-unit: %s (valid: file, declaration)", splitUnit)
+	}
+	if splitTokens > 0 {
+		switch {
+		case watchMode:
+			return fmt.Errorf("--split-tokens cannot be combined with --watch")
+		case outputToStdout:
+			return fmt.Errorf("--split-tokens writes files and cannot be combined with --stdout")
+		}
+	}
+	if symbolQuery != "" {
+		switch {
+		case watchMode:
+			return fmt.Errorf("--symbol cannot be combined with --watch")
+		case gitDiff:
+			return fmt.Errorf("--symbol cannot be combined with --changed-since or --staged")
+		case rawMode:
+			return fmt.Errorf("--symbol cannot be combined with --raw")
+		}
+	}
 
 	// Log configuration using debugger
-	dbg.Logf(debug.LevelBasic, "Input: %s", absPath)
+	if multipleInputs {
+		dbg.Logf(debug.LevelBasic, "Input: %d paths", len(inputs))
+	} else {
+		dbg.Logf(debug.LevelBasic, "Input: %s", absPath)
+	}
 	dbg.Logf(debug.LevelBasic, "Output: %s", outputFile)
 	dbg.Logf(debug.LevelBasic, "Format: %s", outputFormat)
 	
@@ -457,46 +599,113 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 		getBoolFlag(includeImports, true),
 		getBoolFlag(includeAnnotations, true))
 
-	// Create processor options from flags
+	// Create processor options from flags; with --symbol everything is kept
+	// until the symbol's dependencies are known
 	procOpts := createProcessOptionsFromFlags()
+	symbolStripOpts := procOpts.ToStripperOptions()
+	if symbolQuery != "" {
+		procOpts = symbolProcessOptions(procOpts)
+	}
 
 	// Create the processor with context
 	proc := processor.NewWithContext(ctx)
+	resultCache := attachResultCache(dbg, proc)
 	
 
 	// Log workers configuration
-	actualWorkers := workers
-	if actualWorkers == 0 {
-		actualWorkers = int(float64(runtime.NumCPU()) * 0.8)
-		if actualWorkers < 1 {
-			actualWorkers = 1
-		}
-	}
 	if workers != 1 {
-		dbg.Logf(debug.LevelBasic, "Using %d parallel workers (%d CPU cores available)", actualWorkers, runtime.NumCPU())
+		dbg.Logf(debug.LevelBasic, "Using %d parallel workers (%d CPU cores available)", processor.WorkerCount(workers), runtime.NumCPU())
 	}
 
-	// Set base path information
+	// Set base path information; several paths are shown relative to the
+	// working directory
 	procOpts.BasePath = inputPath
+	if multipleInputs {
+		procOpts.BasePath = "."
+	}
 	procOpts.FilePathType = filePathType
 	procOpts.RelativePathPrefix = relativePathPrefix
 	
 	// If user provided absolute path and didn't specify file-path-type, default to absolute
-	if filepath.IsAbs(inputPath) && !cmd.Flags().Changed("file-path-type") {
+	if filepath.IsAbs(inputPath) && !multipleInputs && !cmd.Flags().Changed("file-path-type") {
 		procOpts.FilePathType = "absolute"
 	}
 	
+	// Watch mode keeps its own per-file results and never returns on its own
+	if watchMode {
+		return runWatch(ctx, proc, absPath, procOpts)
+	}
+
 	// Track processing time
 	startTime := time.Now()
 	
-	// Process the input
-	result, err := proc.ProcessPath(absPath, procOpts)
+	// Process the input; in git diff mode only changed declarations are kept
+	var result ir.DistilledNode
+	if gitDiff {
+		spec := gitDiffSpec{base: changedSince, staged: stagedOnly}
+		if spec.base == "" {
+			spec.base = "HEAD"
+		}
+		result, err = processGitChanges(ctx, proc, absPath, spec, procOpts)
+	} else if multipleInputs {
+		absInputs := make([]string, 0, len(inputs))
+		for _, input := range inputs {
+			absInput, err := filepath.Abs(input)
+			if err != nil {
+				return fmt.Errorf("failed to resolve path: %w", err)
+			}
+			absInputs = append(absInputs, absInput)
+		}
+		result, err = proc.ProcessPaths(absInputs, procOpts)
+	} else {
+		result, err = proc.ProcessPath(absPath, procOpts)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to process: %w", err)
 	}
 	if result == nil {
 		return fmt.Errorf("no result returned from processing")
 	}
+	flushResultCache(dbg, resultCache)
+	if symbolQuery != "" {
+		if result, err = extractSymbol(ctx, result, symbolQuery, symbolDepth, symbolStripOpts); err != nil {
+			return err
+		}
+	}
+	originalResult := result
+
+	// Rank files by importance; the ranking also decides which files the
+	// token budget drops first
+	rankRoot, err := filepath.Abs(procOpts.BasePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if info, err := os.Stat(rankRoot); err == nil && !info.IsDir() {
+		rankRoot = filepath.Dir(rankRoot)
+	}
+	var ranking []rank.File
+	if rankOutput || topFiles > 0 {
+		ranking = rankFiles(ctx, distilledFiles(result), rankRoot, procOpts.RelativePathPrefix)
+		result = applyRanking(result, ranking, rankOutput, topFiles)
+		printTopFiles(len(ranking), len(distilledFiles(result)))
+	}
+
+	// Drop detail until the output fits into the token budget
+	var budgetReport *budget.Report
+	if maxTokens > 0 {
+		// Files are ranked before detail such as implementations is dropped
+		unbudgeted := result
+		rankFn := func([]*ir.DistilledFile) map[string]float64 {
+			if ranking == nil {
+				return rank.Scores(rankFiles(ctx, distilledFiles(unbudgeted), rankRoot, procOpts.RelativePathPrefix))
+			}
+			return rank.Scores(ranking)
+		}
+		result, budgetReport, err = fitTokenBudget(ctx, result, outputFormat, maxTokens, rankFn)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Create formatter based on format
 	formatterOpts := formatter.Options{}
@@ -553,28 +762,39 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 	dbg.Logf(debug.LevelDetailed, "Output format: %s", outputFormat)
 	if outputFormat == "text" {
 		// Remove empty file tags with only whitespace between them
-		emptyFilePattern := regexp.MustCompile(`(?s)<file path="[^"]+">\s*</file>\s*`)
-		cleanedStr := emptyFilePattern.ReplaceAllString(outputStr, "")
+		cleanedStr := distill.RemoveEmptyFiles(outputStr)
 		dbg.Logf(debug.LevelDetailed, "Regex cleanup: before=%d bytes, after=%d bytes, pattern matches=%d", 
-			len(outputStr), len(cleanedStr), len(emptyFilePattern.FindAllString(outputStr, -1)))
+			len(outputStr), len(cleanedStr), len(distill.EmptyFilePattern.FindAllString(outputStr, -1)))
 		outputStr = cleanedStr
 	}
 
-	// Write to file if not stdout-only
-	if outputFile != "" && !outputToStdout {
-		if err := os.WriteFile(outputFile, []byte(outputStr), 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+	var splitParts int
+	if splitTokens > 0 {
+		// Write parts with an index into a directory instead of one file;
+		// the index is what to read first
+		dir := splitOutputDir(outputFile)
+		splitParts, err = writeSplitOutput(ctx, result, outputFormat, splitTokens, split.Unit(splitUnit), dir)
+		if err != nil {
+			return err
+		}
+		outputFile = filepath.Join(dir, split.IndexName)
+	} else {
+		// Write to file if not stdout-only
+		if outputFile != "" && !outputToStdout {
+			if err := os.WriteFile(outputFile, []byte(outputStr), 0644); err != nil {
+				return fmt.Errorf("failed to write output file: %w", err)
+			}
+			dbg.Logf(debug.LevelBasic, "Wrote output to %s", outputFile)
 		}
-		dbg.Logf(debug.LevelBasic, "Wrote output to %s", outputFile)
-	}
 
-	// Write to stdout if requested
-	if outputToStdout || outputFile == "" {
-		fmt.Print(outputStr)
+		// Write to stdout if requested
+		if outputToStdout || outputFile == "" {
+			fmt.Print(outputStr)
+		}
 	}
 
-	// Print advanced summary to stderr (only when not using stdin)
-	if len(args) > 0 && args[0] != "-" && summaryFormat != "off" {
+	// Print advanced summary to stderr (stdin input has returned earlier)
+	if summaryFormat != "off" {
 		// Calculate processing duration
 		duration := time.Since(startTime)
 		
@@ -584,7 +804,7 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 			// For raw mode, the original size equals distilled size (no compression)
 			originalSize = int64(output.Len())
 		} else {
-			originalSize = getOriginalSize(result)
+			originalSize = getOriginalSize(originalResult)
 		}
 		distilledSize := int64(output.Len())
 		
@@ -597,8 +817,18 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 			Duration:        duration,
 			FileCount:       fileCount,
 			OutputPath:      outputFile,
+			SplitParts:      splitParts,
 			IsStdout:        outputToStdout || outputFile == "",
 		}
+		if resultCache != nil {
+			cacheStats := resultCache.Stats()
+			stats.CacheEnabled = true
+			stats.CacheHits = cacheStats.Hits
+			stats.CacheMisses = cacheStats.Misses
+		}
+		if ranking != nil {
+			stats.Ranking = summaryRanking(ranking, result)
+		}
 		
 		// Print summary
 		summaryOpts := summary.Options{
@@ -610,6 +840,9 @@ func runDistiller(cmd *cobra.Command, args []string) error {
 		summary.Print(os.Stderr, stats, summaryOpts)
 	}
 
+	// Tell the user what had to be left out to meet --max-tokens
+	printBudgetReport(os.Stderr, budgetReport)
+
 	return nil
 }
 
@@ -695,6 +928,8 @@ func generateOutputFilename(path string, stripOptions []string, format string) s
 		ext = ".jsonl"
 	case "json-structured":
 		ext = ".json"
+	case "ir":
+		ext = ".ir.json"
 	case "xml":
 		ext = ".xml"
 	}
@@ -822,6 +1057,7 @@ func createProcessOptionsFromFlags() processor.ProcessOptions {
 		opts.RemoveProtectedOnly = contains(stripOptions, "protected")
 		opts.Workers = workers
 		opts.RawMode = rawMode
+		opts.FoldDirectories = filterFromFlags().SQLSchema
 		return opts
 	}
 	
@@ -833,6 +1069,7 @@ func createProcessOptionsFromFlags() processor.ProcessOptions {
 		opts.Recursive = recursiveStr != "0"
 		opts.IncludePatterns = includeGlob
 		opts.ExcludePatterns = excludeGlob
+		opts.FoldDirectories = filterFromFlags().SQLSchema
 		return opts
 	}
 	if excludeList != "" {
@@ -842,51 +1079,34 @@ func createProcessOptionsFromFlags() processor.ProcessOptions {
 		opts.Recursive = recursiveStr != "0"
 		opts.IncludePatterns = includeGlob
 		opts.ExcludePatterns = excludeGlob
+		opts.FoldDirectories = filterFromFlags().SQLSchema
 		return opts
 	}
 	
-	// Use individual flags with defaults
-	// fmt.Printf("DEBUG: includeComments=%v\n", includeComments)
-	opts.IncludeComments = getBoolFlag(includeComments, false)
-	opts.IncludeImports = getBoolFlag(includeImports, true)
-	opts.IncludeImplementation = getBoolFlag(includeImplementation, false)
-	
-	// Handle visibility flags
-	includePublicVal := getBoolFlag(includePublic, true)
-	includeProtectedVal := getBoolFlag(includeProtected, false)
-	includeInternalVal := getBoolFlag(includeInternal, false)
-	includePrivateVal := getBoolFlag(includePrivate, false)
-	
-	// Convert to stripper options
-	// If only public is included, remove all non-public
-	if includePublicVal && !includeProtectedVal && !includeInternalVal && !includePrivateVal {
-		opts.IncludePrivate = false
-	} else {
-		opts.IncludePrivate = true
-		// Set specific removal flags based on what's NOT included
-		opts.RemovePrivateOnly = !includePrivateVal
-		opts.RemoveProtectedOnly = !includeProtectedVal
-		opts.RemoveInternalOnly = !includeInternalVal
+	return filterFromFlags().ProcessOptions()
+}
+
+// filterFromFlags collects the individual filtering flags, falling back to
+// the defaults shared with pkg/distiller
+func filterFromFlags() distill.Filter {
+	defaults := distill.DefaultFilter()
+	return distill.Filter{
+		Public:         getBoolFlag(includePublic, defaults.Public),
+		Protected:      getBoolFlag(includeProtected, defaults.Protected),
+		Internal:       getBoolFlag(includeInternal, defaults.Internal),
+		Private:        getBoolFlag(includePrivate, defaults.Private),
+		Comments:       getBoolFlag(includeComments, defaults.Comments),
+		Docstrings:     getBoolFlag(includeDocstrings, defaults.Docstrings),
+		Implementation: getBoolFlag(includeImplementation, defaults.Implementation),
+		Imports:        getBoolFlag(includeImports, defaults.Imports),
+		Annotations:    getBoolFlag(includeAnnotations, defaults.Annotations),
+		Include:        includeGlob,
+		Exclude:        excludeGlob,
+		Recursive:      recursiveStr != "0",
+		Raw:            rawMode,
+		Workers:        workers,
+		SQLSchema:      getBoolFlag(sqlSchema, defaults.SQLSchema),
 	}
-	
-	// Handle docstrings and annotations
-	opts.IncludeDocstrings = getBoolFlag(includeDocstrings, true)
-	opts.IncludeAnnotations = getBoolFlag(includeAnnotations, true)
-	
-	// Set workers value
-	opts.Workers = workers
-	
-	// Set raw mode
-	opts.RawMode = rawMode
-	
-	// Set recursive - parse from global recursiveStr
-	opts.Recursive = recursiveStr != "0"
-	
-	// Set include/exclude patterns
-	opts.IncludePatterns = includeGlob
-	opts.ExcludePatterns = excludeGlob
-	
-	return opts
 }
 
 // getBoolFlag returns the value of a bool flag or its default
@@ -1261,6 +1481,9 @@ func collectSourceFiles(projectPath string) ([]string, error) {
 		".cpp": true, ".cc": true, ".cxx": true, ".c": true, ".h": true, ".hpp": true,
 		".cs": true, ".fs": true, ".vb": true,
 		".scala": true, ".clj": true, ".cljs": true,
+
+		// Interface definitions
+		".proto": true,
 		
 		// Frontend frameworks and templates
 		".vue": true, ".svelte": true, ".astro": true,
@@ -1799,12 +2022,15 @@ func handleAIAction(ctx context.Context, projectPath string) error {
 		}
 		
 		// First, distill the content
-		distilledContent, err := distillForAction(ctx, projectPath)
+		distilledContent, result, err := distillForAction(ctx, projectPath)
 		if err != nil {
 			return fmt.Errorf("failed to distill content: %w", err)
 		}
 		
 		actionCtx.DistilledContent = distilledContent
+		if graphAction, ok := action.(ai.ImportGraphAction); ok && graphAction.UsesImportGraph() {
+			actionCtx.ImportGraph = importGraphForAction(projectPath, result)
+		}
 		return executeContentAction(ctx, contentAction, actionCtx)
 		
 	default:
@@ -1812,8 +2038,9 @@ func handleAIAction(ctx context.Context, projectPath string) error {
 	}
 }
 
-// distillForAction runs the distiller to get content for AI actions
-func distillForAction(ctx context.Context, projectPath string) (string, error) {
+// distillForAction runs the distiller to get content for AI actions. The
+// processed tree is returned too.
+func distillForAction(ctx context.Context, projectPath string) (string, ir.DistilledNode, error) {
 	dbg := debug.FromContext(ctx).WithSubsystem("distill-for-action")
 	
 	// Create processor options from flags
@@ -1823,23 +2050,33 @@ func distillForAction(ctx context.Context, projectPath string) (string, error) {
 	
 	// Create the processor
 	proc := processor.NewWithContext(ctx)
+	resultCache := attachResultCache(dbg, proc)
 	
 	// Process the input
 	result, err := proc.ProcessPath(projectPath, procOpts)
 	if err != nil {
-		return "", fmt.Errorf("failed to process: %w", err)
+		return "", nil, fmt.Errorf("failed to process: %w", err)
 	}
+	flushResultCache(dbg, resultCache)
 	
 	// Always use text format for AI actions
-	formatterOpts := formatter.Options{}
-	outputFormatter, err := formatter.Get("text", formatterOpts)
+	output, err := formatDistilled(result, "text")
 	if err != nil {
-		return "", fmt.Errorf("failed to get formatter: %w", err)
+		return "", nil, err
 	}
 	
-	// Format the output
+	dbg.Logf(debug.LevelBasic, "Distilled %d bytes of content", len(output))
+	return output, result, nil
+}
+
+// formatDistilled renders a processed file or directory with the named formatter
+func formatDistilled(result ir.DistilledNode, format string) (string, error) {
+	outputFormatter, err := formatter.Get(format, formatter.Options{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get formatter: %w", err)
+	}
+
 	var output strings.Builder
-	
 	switch r := result.(type) {
 	case *ir.DistilledFile:
 		if err := outputFormatter.Format(&output, r); err != nil {
@@ -1858,8 +2095,7 @@ func distillForAction(ctx context.Context, projectPath string) (string, error) {
 	default:
 		return "", fmt.Errorf("unexpected result type: %T", result)
 	}
-	
-	dbg.Logf(debug.LevelBasic, "Distilled %d bytes of content", output.Len())
+
 	return output.String(), nil
 }
 
@@ -1868,52 +2104,17 @@ func executeFlowAction(ctx context.Context, action ai.FlowAction, actionCtx *ai.
 	startTime := time.Now()
 	dbg := debug.FromContext(ctx).WithSubsystem("flow-action")
 	
-	// Determine output path
-	outputPath := actionCtx.Config.OutputPath
-	if outputPath == "" {
-		outputPath = action.DefaultOutput()
-	}
-	outputPath = ai.ExpandTemplate(outputPath, actionCtx)
-	
-	// Ensure output path is within project
-	if err := ai.ValidateOutputPath(outputPath, actionCtx.ProjectPath); err != nil {
-		return fmt.Errorf("invalid output path: %w", err)
-	}
-	
-	// Execute the flow
-	result, err := action.ExecuteFlow(actionCtx)
+	// Execute the flow and write its files
+	outputPath, result, err := runFlowAction(dbg, action, actionCtx)
 	if err != nil {
-		return fmt.Errorf("flow execution failed: %w", err)
-	}
-	
-	// Create output directory
-	if err := os.MkdirAll(outputPath, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return err
 	}
 	
-	// Write all files and track total size
 	var totalSize int64
-	fileCount := 0
-	for relPath, content := range result.Files {
-		fullPath := filepath.Join(outputPath, relPath)
-		
-		// Create parent directory
-		parentDir := filepath.Dir(fullPath)
-		if err := os.MkdirAll(parentDir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", parentDir, err)
-		}
-		
-		// Write file
-		contentBytes := []byte(content)
-		if err := os.WriteFile(fullPath, contentBytes, 0644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", fullPath, err)
-		}
-		
-		totalSize += int64(len(contentBytes))
-		fileCount++
-		sizeKB := float64(len(contentBytes)) / 1024.0
-		dbg.Logf(debug.LevelDetailed, "Wrote file: %s (%.1f kB)", fullPath, sizeKB)
+	for _, content := range result.Files {
+		totalSize += int64(len(content))
 	}
+	fileCount := len(result.Files)
 	
 	// Calculate duration and total size
 	duration := time.Since(startTime)
@@ -1936,47 +2137,20 @@ func executeContentAction(ctx context.Context, action ai.ContentAction, actionCt
 	startTime := time.Now()
 	dbg := debug.FromContext(ctx).WithSubsystem("content-action")
 	
-	// Generate content
-	result, err := action.GenerateContent(actionCtx)
+	// Generate the prompt around the distilled content
+	outputPath, content, err := buildContentAction(action, actionCtx)
 	if err != nil {
-		return fmt.Errorf("content generation failed: %w", err)
-	}
-	
-	// Determine output path
-	outputPath := actionCtx.Config.OutputPath
-	if outputPath == "" {
-		outputPath = action.DefaultOutput()
-	}
-	outputPath = ai.ExpandTemplate(outputPath, actionCtx)
-	
-	// Ensure output path is within project
-	if err := ai.ValidateOutputPath(outputPath, actionCtx.ProjectPath); err != nil {
-		return fmt.Errorf("invalid output path: %w", err)
+		return err
 	}
 	
-	// Build final content
-	var finalContent strings.Builder
-	finalContent.WriteString(result.ContentBefore)
-	finalContent.WriteString(actionCtx.DistilledContent)
-	finalContent.WriteString(result.ContentAfter)
-	
-	content := []byte(finalContent.String())
-	
 	// If outputToStdout is set, print to stdout
 	if outputToStdout {
 		fmt.Print(string(content))
 		
 		// Also save to file unless explicitly disabled
 		if outputPath != "" && outputPath != "-" {
-			// Create parent directory
-			parentDir := filepath.Dir(outputPath)
-			if err := os.MkdirAll(parentDir, 0755); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", parentDir, err)
-			}
-			
-			// Write output file
-			if err := os.WriteFile(outputPath, content, 0644); err != nil {
-				return fmt.Errorf("failed to write output file: %w", err)
+			if err := writeActionFile(outputPath, content); err != nil {
+				return err
 			}
 			
 			// Print info message to stderr so it doesn't mix with stdout content
@@ -1998,15 +2172,8 @@ func executeContentAction(ctx context.Context, action ai.ContentAction, actionCt
 		}
 	} else {
 		// Original behavior: save to file only
-		// Create parent directory
-		parentDir := filepath.Dir(outputPath)
-		if err := os.MkdirAll(parentDir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", parentDir, err)
-		}
-		
-		// Write output file
-		if err := os.WriteFile(outputPath, content, 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+		if err := writeActionFile(outputPath, content); err != nil {
+			return err
 		}
 		
 		// Get file info for size
@@ -2045,6 +2212,93 @@ func executeContentAction(ctx context.Context, action ai.ContentAction, actionCt
 	return nil
 }
 
+// actionOutputPath expands the output path template of an action and checks
+// that it stays within the project
+func actionOutputPath(action ai.AIAction, actionCtx *ai.ActionContext) (string, error) {
+	outputPath := actionCtx.Config.OutputPath
+	if outputPath == "" {
+		outputPath = action.DefaultOutput()
+	}
+	outputPath = ai.ExpandTemplate(outputPath, actionCtx)
+
+	if err := ai.ValidateOutputPath(outputPath, actionCtx.ProjectPath); err != nil {
+		return "", fmt.Errorf("invalid output path: %w", err)
+	}
+	return outputPath, nil
+}
+
+// runFlowAction executes a flow action and writes its files to the output directory
+func runFlowAction(dbg debug.Debugger, action ai.FlowAction, actionCtx *ai.ActionContext) (string, *ai.FlowResult, error) {
+	outputPath, err := actionOutputPath(action, actionCtx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	result, err := action.ExecuteFlow(actionCtx)
+	if err != nil {
+		return "", nil, fmt.Errorf("flow execution failed: %w", err)
+	}
+
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for relPath, content := range result.Files {
+		fullPath := filepath.Join(outputPath, relPath)
+
+		// Create parent directory
+		parentDir := filepath.Dir(fullPath)
+		if err := os.MkdirAll(parentDir, 0755); err != nil {
+			return "", nil, fmt.Errorf("failed to create directory %s: %w", parentDir, err)
+		}
+
+		// Write file
+		contentBytes := []byte(content)
+		if err := os.WriteFile(fullPath, contentBytes, 0644); err != nil {
+			return "", nil, fmt.Errorf("failed to write file %s: %w", fullPath, err)
+		}
+
+		sizeKB := float64(len(contentBytes)) / 1024.0
+		dbg.Logf(debug.LevelDetailed, "Wrote file: %s (%.1f kB)", fullPath, sizeKB)
+	}
+
+	return outputPath, result, nil
+}
+
+// buildContentAction generates the prompt of a content action around the
+// distilled content and returns it with its output path
+func buildContentAction(action ai.ContentAction, actionCtx *ai.ActionContext) (string, []byte, error) {
+	result, err := action.GenerateContent(actionCtx)
+	if err != nil {
+		return "", nil, fmt.Errorf("content generation failed: %w", err)
+	}
+
+	outputPath, err := actionOutputPath(action, actionCtx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var finalContent strings.Builder
+	finalContent.WriteString(result.ContentBefore)
+	finalContent.WriteString(actionCtx.DistilledContent)
+	finalContent.WriteString(result.ContentAfter)
+
+	return outputPath, []byte(finalContent.String()), nil
+}
+
+// writeActionFile writes an action's output, creating parent directories
+func writeActionFile(outputPath string, content []byte) error {
+	parentDir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(parentDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", parentDir, err)
+	}
+
+	if err := os.WriteFile(outputPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
 // pluralS returns "s" if count is not 1
 func pluralS(count int) string {
 	if count == 1 {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -406,7 +407,7 @@ func TestCLIHelp(t *testing.T) {
 	
 	// Check that help contains expected content
 	assert.Contains(t, output, "AI Distiller")
-	assert.Contains(t, output, "aid [path]")
+	assert.Contains(t, output, "aid [path...]")
 	assert.Contains(t, output, "--output")
 	assert.Contains(t, output, "--format")
 	// Check for new flags instead of deprecated --strip
@@ -417,6 +418,36 @@ func TestCLIHelp(t *testing.T) {
 	assert.Contains(t, output, "--implementation")
 }
 
+func TestSubcommandHelp(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"deps", "--help"}, []string{"aid deps [path]", "--level", "text|dot|mermaid|json"}},
+		{[]string{"help", "deps"}, []string{"aid deps [path]", "--level"}},
+		{[]string{"graph", "--help"}, []string{"aid graph [path]"}},
+		{[]string{"diff", "--help"}, []string{"aid diff <old> <new>"}},
+		{[]string{"help", "cache"}, []string{"stats", "clear"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			rootCmd.SetOut(buf)
+			rootCmd.SetArgs(tt.args)
+			defer rootCmd.SetOut(nil)
+
+			require.NoError(t, rootCmd.Execute())
+			output := buf.String()
+			for _, want := range tt.want {
+				assert.Contains(t, output, want)
+			}
+			// The distillation options belong to the root command only
+			assert.NotContains(t, output, "--ai-action")
+		})
+	}
+}
+
 func TestStripOptionsAbbreviation(t *testing.T) {
 	tests := []struct {
 		options  []string
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/split"
+)
+
+// splitOutputDir returns the directory for the parts of an output file: the
+// output path without its extension
+func splitOutputDir(outputFile string) string {
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile))
+}
+
+// writeSplitOutput renders the processed result into parts of at most
+// maxTokens tokens and writes them with an index into dir. It returns the
+// number of parts.
+func writeSplitOutput(ctx context.Context, result ir.DistilledNode, format string, maxTokens int, unit split.Unit, dir string) (int, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("split")
+	defer dbg.Timing(debug.LevelDetailed, "splitting output")()
+
+	outputFormatter, err := formatter.Get(format, formatter.Options{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get formatter: %w", err)
+	}
+	_, single := result.(*ir.DistilledFile)
+	render, err := outputRenderer(format, single)
+	if err != nil {
+		return 0, err
+	}
+
+	parts, err := split.Split(distilledFiles(result), render, split.Options{
+		MaxTokens: maxTokens,
+		Unit:      unit,
+		Extension: outputFormatter.Extension(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := split.Write(dir, split.NewIndex(parts, maxTokens)); err != nil {
+		return 0, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Split output into %d part%s of up to ~%d tokens; %s lists the files of each part\n",
+		len(parts), pluralS(len(parts)), maxTokens, filepath.Join(dir, split.IndexName))
+	for _, part := range parts {
+		dbg.Logf(debug.LevelDetailed, "%s: ~%d tokens, %d file%s", part.Name, part.Tokens, len(part.Files), pluralS(len(part.Files)))
+		if part.Oversized {
+			hint := ""
+			if unit != split.UnitDeclaration {
+				hint = "; --split-unit declaration divides large files"
+			}
+			fmt.Fprintf(os.Stderr, "  warning: %s exceeds the limit with ~%d tokens (%s)%s\n",
+				part.Name, part.Tokens, strings.Join(part.Files, ", "), hint)
+		}
+	}
+	return len(parts), nil
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/stripper"
+	"github.com/janreges/ai-distiller/internal/symbol"
+)
+
+// symbolProcessOptions returns options that keep everything, so that the
+// references in private members and bodies can be followed. The filters of
+// opts are applied after the symbol is extracted.
+func symbolProcessOptions(opts processor.ProcessOptions) processor.ProcessOptions {
+	opts.IncludeComments = true
+	opts.IncludeImports = true
+	opts.IncludeImplementation = true
+	opts.IncludePrivate = true
+	opts.RemovePrivateOnly = false
+	opts.RemoveProtectedOnly = false
+	opts.RemoveInternalOnly = false
+	opts.IncludeDocstrings = true
+	opts.IncludeAnnotations = true
+	return opts
+}
+
+// extractSymbol reduces the processed result to the declarations matching
+// query and their dependencies, then applies the stripper options
+func extractSymbol(ctx context.Context, result ir.DistilledNode, query string, depth int, stripOpts stripper.Options) (ir.DistilledNode, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("symbol")
+	defer dbg.Timing(debug.LevelDetailed, "symbol extraction")()
+
+	var files []*ir.DistilledFile
+	path := ""
+	switch r := result.(type) {
+	case *ir.DistilledFile:
+		files = []*ir.DistilledFile{r}
+		path = r.Path
+	case *ir.DistilledDirectory:
+		path = r.Path
+		for _, child := range r.Children {
+			if file, ok := child.(*ir.DistilledFile); ok {
+				files = append(files, file)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+
+	extracted, err := symbol.Extract(files, query, symbol.Options{Depth: depth})
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range extracted.Matches {
+		dbg.Logf(debug.LevelDetailed, "Matched %s", match)
+	}
+
+	s := stripper.New(stripOpts)
+	dir := &ir.DistilledDirectory{Path: path}
+	for _, file := range extracted.Files {
+		if stripped, ok := file.Accept(s).(*ir.DistilledFile); ok {
+			dir.Children = append(dir.Children, stripped)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Symbol %s: %d declaration%s matched, %d referenced declaration%s within depth %d, %d file%s\n",
+		query, len(extracted.Matches), pluralS(len(extracted.Matches)),
+		extracted.Dependencies, pluralS(extracted.Dependencies), depth,
+		len(dir.Children), pluralS(len(dir.Children)))
+
+	// A single file keeps its result type, so it is formatted the same way
+	if _, ok := result.(*ir.DistilledFile); ok && len(dir.Children) == 1 {
+		return dir.Children[0], nil
+	}
+	return dir, nil
+}
@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+	"github.com/janreges/ai-distiller/internal/distill"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/watch"
+)
+
+// watchedFile is the last distilled result of a file and the state it was
+// produced from
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	result  *ir.DistilledFile
+}
+
+// watchSession keeps the distilled results of a directory in memory and
+// re-processes only the files that change
+type watchSession struct {
+	ctx    context.Context
+	dbg    debug.Debugger
+	root   string
+	opts   processor.ProcessOptions
+	proc   *processor.Processor
+	filter atomic.Pointer[processor.FileFilter]
+	files  map[string]*watchedFile
+
+	format     string
+	formatter  formatter.Formatter
+	outputFile string
+	stdout     io.Writer
+	// stream emits only changed files as JSONL records instead of
+	// rewriting the whole output
+	stream bool
+}
+
+// runWatch distills dir, writes the output and keeps it current until interrupted
+func runWatch(ctx context.Context, proc *processor.Processor, dir string, opts processor.ProcessOptions) error {
+	dbg := debug.FromContext(ctx).WithSubsystem("watch")
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("--watch requires a directory, got file: %s", dir)
+	}
+
+	outputFormatter, err := formatter.Get(outputFormat, formatter.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to get formatter: %w", err)
+	}
+
+	s := &watchSession{
+		ctx:        ctx,
+		dbg:        dbg,
+		root:       dir,
+		opts:       opts,
+		proc:       proc,
+		files:      make(map[string]*watchedFile),
+		format:     outputFormat,
+		formatter:  outputFormatter,
+		outputFile: outputFile,
+		stdout:     os.Stdout,
+		stream:     outputFormat == "jsonl" && (outputToStdout || outputFile == ""),
+	}
+	if outputToStdout {
+		s.outputFile = ""
+	}
+
+	s.filter.Store(processor.NewFileFilter(dir, opts))
+
+	// Start watching before the initial pass so no change is missed
+	watcher, err := watch.New(dir, watch.Options{
+		SkipDir: func(path string) bool { return s.filter.Load().SkipDir(path) },
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	defer watcher.Close()
+	dbg.Logf(debug.LevelBasic, "Detecting changes with %s", watcher.Method())
+
+	// Initial full pass
+	start := time.Now()
+	changed, removed := s.rescan(dir)
+	if err := s.emit(changed, removed, true); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Watching %s (%d files, %s)\n", dir, len(s.files), time.Since(start).Round(time.Millisecond))
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr, "Stopped watching")
+			return nil
+		case err := <-watcher.Errors():
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		case batch, ok := <-watcher.Changes():
+			if !ok {
+				return nil
+			}
+			start := time.Now()
+			changed, removed := s.apply(batch)
+			if len(changed) == 0 && len(removed) == 0 {
+				continue
+			}
+			if err := s.emit(changed, removed, false); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Updated %d file%s, removed %d in %s\n",
+				len(changed), pluralS(len(changed)), len(removed), time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
+
+// apply updates the results for a batch of changed paths and returns the
+// files whose output changed and the files that were removed
+func (s *watchSession) apply(batch []string) (changed, removed []string) {
+	for _, path := range batch {
+		// A changed .aidignore can include or exclude anything, start over
+		if filepath.Base(path) == ".aidignore" {
+			s.dbg.Logf(debug.LevelBasic, "%s changed, rescanning", path)
+			s.filter.Store(processor.NewFileFilter(s.root, s.opts))
+			return s.rescan(s.root)
+		}
+	}
+
+	filter := s.filter.Load()
+	for _, path := range batch {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// Deleted file or directory
+			removed = append(removed, s.removeUnder(path)...)
+		case info.IsDir():
+			c, r := s.rescan(path)
+			changed = append(changed, c...)
+			removed = append(removed, r...)
+		default:
+			if match, explicit := filter.Contains(path); match {
+				if s.update(path, info, explicit) {
+					changed = append(changed, path)
+				}
+			} else if _, tracked := s.files[path]; tracked {
+				delete(s.files, path)
+				removed = append(removed, path)
+			}
+		}
+	}
+	return uniqueSorted(changed), uniqueSorted(removed)
+}
+
+// rescan synchronizes all files below dir with the filesystem
+func (s *watchSession) rescan(dir string) (changed, removed []string) {
+	seen := make(map[string]bool)
+	filter := s.filter.Load()
+
+	if dir == s.root || !filter.SkipDir(dir) {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Files may disappear while scanning
+			}
+			if info.IsDir() {
+				if path != dir && filter.SkipDir(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			match, explicit := filter.Contains(path)
+			if !match {
+				return nil
+			}
+			seen[path] = true
+			if s.update(path, info, explicit) {
+				changed = append(changed, path)
+			}
+			return nil
+		})
+	}
+
+	prefix := dir + string(filepath.Separator)
+	for path := range s.files {
+		if (path == dir || strings.HasPrefix(path, prefix)) && !seen[path] {
+			delete(s.files, path)
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// update re-processes a file if its size or modification time changed
+func (s *watchSession) update(path string, info os.FileInfo, explicit bool) bool {
+	if prev, ok := s.files[path]; ok && prev.size == info.Size() && prev.modTime.Equal(info.ModTime()) {
+		return false
+	}
+
+	fileOpts := s.opts
+	fileOpts.ExplicitInclude = explicit
+	result, err := s.proc.ProcessFile(path, fileOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", path, err)
+		// Keep the previous result until the file parses again
+		return false
+	}
+
+	s.dbg.Logf(debug.LevelDetailed, "Re-processed %s", path)
+	s.files[path] = &watchedFile{size: info.Size(), modTime: info.ModTime(), result: result}
+	return true
+}
+
+// removeUnder forgets path and every tracked file below it
+func (s *watchSession) removeUnder(path string) []string {
+	var removed []string
+	prefix := path + string(filepath.Separator)
+	for tracked := range s.files {
+		if tracked == path || strings.HasPrefix(tracked, prefix) {
+			delete(s.files, tracked)
+			removed = append(removed, tracked)
+		}
+	}
+	return removed
+}
+
+// results returns the current results in path order
+func (s *watchSession) results() []*ir.DistilledFile {
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	results := make([]*ir.DistilledFile, len(paths))
+	for i, path := range paths {
+		results[i] = s.files[path].result
+	}
+	return results
+}
+
+// emit publishes the current state: a rewritten output file, the full output
+// on stdout, or a JSONL stream of changed files
+func (s *watchSession) emit(changed, removed []string, initial bool) error {
+	if s.stream {
+		return s.streamChanges(changed, removed, initial)
+	}
+
+	dir := &ir.DistilledDirectory{Path: s.root, Children: filesAsNodes(s.results())}
+	var result ir.DistilledNode = processor.FoldDirectory(dir, s.opts)
+	if maxTokens > 0 {
+		fitted, report, err := fitTokenBudget(s.ctx, result, s.format, maxTokens, nil)
+		if err != nil {
+			return err
+		}
+		result = fitted
+		printBudgetReport(os.Stderr, report)
+	}
+
+	output, err := formatDistilled(result, s.format)
+	if err != nil {
+		return err
+	}
+	if s.format == "text" {
+		output = distill.RemoveEmptyFiles(output)
+	}
+
+	if s.outputFile == "" {
+		_, err := io.WriteString(s.stdout, output)
+		return err
+	}
+	return writeFileAtomic(s.outputFile, []byte(output))
+}
+
+// streamChanges writes JSONL records for changed files; a file record
+// replaces all earlier records with the same path
+func (s *watchSession) streamChanges(changed, removed []string, initial bool) error {
+	encoder := json.NewEncoder(s.stdout)
+	for _, path := range removed {
+		record := map[string]interface{}{"type": "file_removed", "path": s.displayPath(path)}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	files := s.results()
+	if !initial {
+		files = files[:0]
+		for _, path := range changed {
+			if file, ok := s.files[path]; ok {
+				files = append(files, file.result)
+			}
+		}
+	}
+	return s.formatter.FormatMultiple(s.stdout, files)
+}
+
+// displayPath returns the path as it appears in the output for a tracked or removed file
+func (s *watchSession) displayPath(path string) string {
+	if s.opts.FilePathType == "absolute" {
+		return path
+	}
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil {
+		return path
+	}
+
+	prefix := s.opts.RelativePathPrefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") && !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += "/"
+	}
+	return prefix + rel
+}
+
+// writeFileAtomic replaces a file so that readers never see partial content
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// filesAsNodes converts files to directory children
+func filesAsNodes(files []*ir.DistilledFile) []ir.DistilledNode {
+	nodes := make([]ir.DistilledNode, len(files))
+	for i, file := range files {
+		nodes[i] = file
+	}
+	return nodes
+}
+
+// uniqueSorted sorts paths and drops duplicates
+func uniqueSorted(paths []string) []string {
+	sort.Strings(paths)
+	result := paths[:0]
+	for i, path := range paths {
+		if i == 0 || path != paths[i-1] {
+			result = append(result, path)
+		}
+	}
+	return result
+}
@@ -0,0 +1,289 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/janreges/ai-distiller/internal/project"
+	"gopkg.in/yaml.v3"
+)
+
+// DirectoriesKey is the config section holding per-directory overrides
+const DirectoriesKey = "directories"
+
+// Config holds default flag values loaded from an .aidrc file
+type Config struct {
+	// Path is the absolute path of the loaded config file
+	Path string
+
+	// Format is the detected file format: "yaml" or "toml"
+	Format string
+
+	// Settings maps flag names to their default values
+	Settings map[string]string
+
+	// Directories holds per-directory overrides, most specific last
+	Directories []DirectoryConfig
+}
+
+// DirectoryConfig holds flag overrides for inputs inside a directory
+type DirectoryConfig struct {
+	// Path is the directory as written in the config (relative to the config file)
+	Path string
+
+	// Settings maps flag names to their override values
+	Settings map[string]string
+}
+
+// Setting is a single resolved flag value together with where it came from
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// Find loads the .aidrc file at the project root containing inputPath.
+// It returns nil without an error when the project has no .aidrc file.
+func Find(inputPath string) (*Config, error) {
+	info, err := project.FindRootFrom(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(info.Path, project.ConfigFileName)
+	if stat, err := os.Stat(path); err != nil || stat.IsDir() {
+		return nil, nil
+	}
+
+	return Load(path)
+}
+
+// Load reads and parses a config file in YAML or TOML format
+func Load(path string) (*Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", absPath, err)
+	}
+	cfg.Path = absPath
+
+	return cfg, nil
+}
+
+// Parse parses config data, detecting YAML or TOML from its content
+func Parse(data []byte) (*Config, error) {
+	format := detectFormat(data)
+
+	var raw map[string]any
+	switch format {
+	case "toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	}
+
+	cfg := &Config{
+		Format:   format,
+		Settings: make(map[string]string),
+	}
+
+	for key, value := range raw {
+		name := normalizeKey(key)
+		if name == DirectoriesKey {
+			dirs, err := parseDirectories(value)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Directories = dirs
+			continue
+		}
+
+		str, err := stringifyValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("option %q: %w", key, err)
+		}
+		cfg.Settings[name] = str
+	}
+
+	return cfg, nil
+}
+
+// Resolve returns the settings that apply to the given input path.
+// Base settings come first, followed by matching directory overrides
+// ordered from least to most specific, so later entries win.
+func (c *Config) Resolve(inputPath string) []Setting {
+	source := project.ConfigFileName
+	if c.Path != "" {
+		source = c.Path
+	}
+
+	settings := sortedSettings(c.Settings, source)
+
+	absInput, err := filepath.Abs(inputPath)
+	if err != nil {
+		return settings
+	}
+
+	baseDir := filepath.Dir(c.Path)
+	for _, dir := range c.Directories {
+		dirPath := dir.Path
+		if !filepath.IsAbs(dirPath) {
+			dirPath = filepath.Join(baseDir, dirPath)
+		}
+
+		rel, err := filepath.Rel(dirPath, absInput)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		dirSource := fmt.Sprintf("%s [%s.%s]", source, DirectoriesKey, dir.Path)
+		settings = append(settings, sortedSettings(dir.Settings, dirSource)...)
+	}
+
+	return settings
+}
+
+// parseDirectories converts the directories section into overrides
+func parseDirectories(value any) ([]DirectoryConfig, error) {
+	section, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%q must be a table of directory paths", DirectoriesKey)
+	}
+
+	var dirs []DirectoryConfig
+	for path, rawSettings := range section {
+		settingsMap, ok := rawSettings.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s must be a table of options", DirectoriesKey, path)
+		}
+
+		dir := DirectoryConfig{
+			Path:     filepath.Clean(filepath.FromSlash(path)),
+			Settings: make(map[string]string),
+		}
+		for key, v := range settingsMap {
+			str, err := stringifyValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s option %q: %w", DirectoriesKey, path, key, err)
+			}
+			dir.Settings[normalizeKey(key)] = str
+		}
+		dirs = append(dirs, dir)
+	}
+
+	// Shorter paths are less specific and are applied first
+	sort.Slice(dirs, func(i, j int) bool {
+		if len(dirs[i].Path) != len(dirs[j].Path) {
+			return len(dirs[i].Path) < len(dirs[j].Path)
+		}
+		return dirs[i].Path < dirs[j].Path
+	})
+
+	return dirs, nil
+}
+
+// stringifyValue converts a decoded value to the string form a CLI flag accepts
+func stringifyValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := stringifyValue(item)
+			if err != nil {
+				return "", err
+			}
+			if _, nested := item.([]any); nested {
+				return "", fmt.Errorf("nested lists are not supported")
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any, []map[string]any:
+		return "", fmt.Errorf("tables are only allowed under %q", DirectoriesKey)
+	default:
+		// Fall back to JSON for anything else the decoders may produce
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("unsupported value type %T", value)
+		}
+		return string(data), nil
+	}
+}
+
+// normalizeKey maps config keys to flag names (summary_type -> summary-type)
+func normalizeKey(key string) string {
+	key = strings.TrimSpace(key)
+	key = strings.TrimPrefix(key, "--")
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// sortedSettings turns a settings map into a deterministic slice
+func sortedSettings(settings map[string]string, source string) []Setting {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]Setting, 0, len(names))
+	for _, name := range names {
+		result = append(result, Setting{Name: name, Value: settings[name], Source: source})
+	}
+	return result
+}
+
+// tomlLinePattern matches the first significant line of a TOML document
+var tomlLinePattern = regexp.MustCompile(`^(\[.*\]|[A-Za-z0-9_."'-]+\s*=)`)
+
+// detectFormat guesses the config format from its first significant line
+func detectFormat(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if trimmed == "---" {
+			return "yaml"
+		}
+		if tomlLinePattern.MatchString(trimmed) {
+			return "toml"
+		}
+		return "yaml"
+	}
+	return "yaml"
+}
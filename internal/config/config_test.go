@@ -0,0 +1,156 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseYAML(t *testing.T) {
+	data := []byte(`
+# Project defaults
+format: md
+workers: 4
+private: true
+summary_type: ci-friendly
+include:
+  - "*.go"
+  - "*.py"
+directories:
+  internal/legacy:
+    implementation: 1
+`)
+
+	cfg, err := Parse(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "yaml", cfg.Format)
+	assert.Equal(t, "md", cfg.Settings["format"])
+	assert.Equal(t, "4", cfg.Settings["workers"])
+	assert.Equal(t, "1", cfg.Settings["private"])
+	assert.Equal(t, "ci-friendly", cfg.Settings["summary-type"])
+	assert.Equal(t, "*.go,*.py", cfg.Settings["include"])
+
+	require.Len(t, cfg.Directories, 1)
+	assert.Equal(t, filepath.FromSlash("internal/legacy"), cfg.Directories[0].Path)
+	assert.Equal(t, "1", cfg.Directories[0].Settings["implementation"])
+}
+
+func TestParseTOML(t *testing.T) {
+	data := []byte(`
+# Project defaults
+format = "md"
+workers = 4
+private = true
+exclude = [
+  "*_test.go", # tests
+  'vendor/**',
+]
+
+[directories."internal/legacy"]
+implementation = 1
+comments = false
+`)
+
+	cfg, err := Parse(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "toml", cfg.Format)
+	assert.Equal(t, "md", cfg.Settings["format"])
+	assert.Equal(t, "4", cfg.Settings["workers"])
+	assert.Equal(t, "1", cfg.Settings["private"])
+	assert.Equal(t, "*_test.go,vendor/**", cfg.Settings["exclude"])
+
+	require.Len(t, cfg.Directories, 1)
+	assert.Equal(t, filepath.FromSlash("internal/legacy"), cfg.Directories[0].Path)
+	assert.Equal(t, "1", cfg.Directories[0].Settings["implementation"])
+	assert.Equal(t, "0", cfg.Directories[0].Settings["comments"])
+}
+
+func TestParseTOMLInlineTables(t *testing.T) {
+	// Any valid TOML is accepted, e.g. inline tables and multi-line strings
+	cfg, err := Parse([]byte(`format = """md"""
+directories = { "src" = { private = true } }
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "md", cfg.Settings["format"])
+	require.Len(t, cfg.Directories, 1)
+	assert.Equal(t, "1", cfg.Directories[0].Settings["private"])
+}
+
+func TestParseEmpty(t *testing.T) {
+	// An empty .aidrc is still a valid root marker
+	cfg, err := Parse(nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Settings)
+	assert.Empty(t, cfg.Directories)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"NestedTableYAML", "format:\n  nested: 1\n"},
+		{"InvalidYAML", "format: [md\n"},
+		{"UnterminatedTOMLArray", "include = [\"*.go\",\n"},
+		{"InlineTOMLTable", "format = { a = 1 }\n"},
+		{"ArrayTablesTOML", "[[directories]]\n"},
+		{"TopLevelArrayTablesTOML", "[[format]]\n"},
+		{"TableInArrayTOML", "include = [{ a = 1 }]\n"},
+		{"DuplicateTOMLKey", "format = \"md\"\nformat = \"text\"\n"},
+		{"DirectoryNotTable", "directories:\n  src: 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".aidrc")
+	require.NoError(t, os.WriteFile(path, []byte(`
+private: 0
+format: text
+directories:
+  services:
+    private: 1
+  services/api:
+    format: md
+`), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	// Collapse settings the way the CLI applies them: later entries win
+	resolve := func(input string) (map[string]string, map[string]string) {
+		values := make(map[string]string)
+		sources := make(map[string]string)
+		for _, s := range cfg.Resolve(input) {
+			values[s.Name] = s.Value
+			sources[s.Name] = s.Source
+		}
+		return values, sources
+	}
+
+	values, sources := resolve(root)
+	assert.Equal(t, "0", values["private"])
+	assert.Equal(t, "text", values["format"])
+	assert.Equal(t, path, sources["private"])
+
+	values, sources = resolve(filepath.Join(root, "services", "api", "handlers"))
+	assert.Equal(t, "1", values["private"])
+	assert.Equal(t, "md", values["format"])
+	assert.Contains(t, sources["format"], "directories.services/api")
+
+	// Sibling directories sharing a prefix must not match
+	values, _ = resolve(filepath.Join(root, "services-old"))
+	assert.Equal(t, "0", values["private"])
+}
@@ -0,0 +1,260 @@
+// Package deps builds the import dependency graph of a project by resolving
+// the imports of distilled files to other files of the project.
+package deps
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// Level is the granularity of graph nodes
+type Level string
+
+const (
+	// LevelFile makes every source file a node
+	LevelFile Level = "file"
+	// LevelPackage makes every directory a node
+	LevelPackage Level = "package"
+)
+
+// mostDependedOnLimit is the length of Graph.MostDependedOn
+const mostDependedOnLimit = 10
+
+// Options configures Build
+type Options struct {
+	// Level is the node granularity (default: LevelFile)
+	Level Level
+}
+
+// Graph is an import dependency graph
+type Graph struct {
+	Level Level   `json:"level"`
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+
+	// Cycles lists the groups of nodes that import each other directly or
+	// indirectly, each sorted by ID
+	Cycles [][]string `json:"cycles,omitempty"`
+
+	// MostDependedOn lists the IDs of the nodes with the most dependents
+	MostDependedOn []string `json:"most_depended_on,omitempty"`
+}
+
+// Node is a file or package of the project
+type Node struct {
+	ID       string `json:"id"`
+	Language string `json:"language,omitempty"`
+
+	// Dependents is the number of nodes importing this node
+	Dependents int `json:"dependents"`
+
+	// Dependencies is the number of nodes imported by this node
+	Dependencies int `json:"dependencies"`
+
+	// External lists imports that did not resolve to project files, such
+	// as the standard library and third-party packages
+	External []string `json:"external,omitempty"`
+}
+
+// Edge is an import of one node by another
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Cycle is set when both ends belong to the same import cycle
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+// Build resolves the imports of files to other files of the project rooted
+// at root. File paths must be relative to root. Files that are not
+// imported and import nothing are still part of the graph.
+func Build(root string, files []*ir.DistilledFile, opts Options) *Graph {
+	level := opts.Level
+	if level == "" {
+		level = LevelFile
+	}
+	r := newResolver(root, files)
+
+	graph := &Graph{Level: level}
+	nodes := make(map[string]*Node)
+	external := make(map[string]map[string]bool)
+	edges := make(map[Edge]bool)
+
+	for _, file := range files {
+		from := nodeID(file, level)
+		if nodes[from] == nil {
+			nodes[from] = &Node{ID: from, Language: file.Language}
+			external[from] = make(map[string]bool)
+		}
+		for _, imp := range fileImports(file) {
+			targets := r.resolve(file, imp)
+			if len(targets) == 0 {
+				external[from][cleanModule(imp.Module)] = true
+				continue
+			}
+			for _, target := range targets {
+				if to := nodeID(target, level); to != from {
+					edges[Edge{From: from, To: to}] = true
+				}
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		for module := range external[node.ID] {
+			if module != "" {
+				node.External = append(node.External, module)
+			}
+		}
+		sort.Strings(node.External)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+
+	for edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+		nodes[edge.From].Dependencies++
+		nodes[edge.To].Dependents++
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	graph.Cycles = findCycles(graph)
+	inCycle := make(map[string]int)
+	for i, cycle := range graph.Cycles {
+		for _, id := range cycle {
+			inCycle[id] = i + 1
+		}
+	}
+	for i, edge := range graph.Edges {
+		if group := inCycle[edge.From]; group != 0 && group == inCycle[edge.To] {
+			graph.Edges[i].Cycle = true
+		}
+	}
+
+	graph.MostDependedOn = mostDependedOn(graph.Nodes, mostDependedOnLimit)
+	return graph
+}
+
+// nodeID returns the node a file belongs to
+func nodeID(file *ir.DistilledFile, level Level) string {
+	p := path.Clean(strings.ReplaceAll(file.Path, "\\", "/"))
+	if level == LevelPackage {
+		return path.Dir(p)
+	}
+	return p
+}
+
+// fileImports returns the imports of a file, including those nested in
+// packages or namespaces
+func fileImports(file *ir.DistilledFile) []*ir.DistilledImport {
+	var imports []*ir.DistilledImport
+	ir.Walk(file, func(node ir.DistilledNode) bool {
+		switch n := node.(type) {
+		case *ir.DistilledImport:
+			imports = append(imports, n)
+		case *ir.DistilledFile, *ir.DistilledPackage:
+			return true
+		}
+		return false
+	})
+	return imports
+}
+
+// findCycles returns the strongly connected groups of more than one node,
+// using Tarjan's algorithm
+func findCycles(graph *Graph) [][]string {
+	adjacent := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		adjacent[edge.From] = append(adjacent[edge.From], edge.To)
+	}
+
+	index := 0
+	indices := make(map[string]int)
+	lowlinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var connect func(id string)
+	connect = func(id string) {
+		indices[id] = index
+		lowlinks[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range adjacent[id] {
+			if _, visited := indices[next]; !visited {
+				connect(next)
+				lowlinks[id] = min(lowlinks[id], lowlinks[next])
+			} else if onStack[next] {
+				lowlinks[id] = min(lowlinks[id], indices[next])
+			}
+		}
+
+		if lowlinks[id] != indices[id] {
+			return
+		}
+		var group []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			group = append(group, top)
+			if top == id {
+				break
+			}
+		}
+		if len(group) > 1 {
+			sort.Strings(group)
+			cycles = append(cycles, group)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if _, visited := indices[node.ID]; !visited {
+			connect(node.ID)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// mostDependedOn returns the IDs of up to limit nodes with dependents,
+// most dependents first
+func mostDependedOn(nodes []*Node, limit int) []string {
+	ranked := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Dependents > 0 {
+			ranked = append(ranked, node)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Dependents > ranked[j].Dependents })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	ids := make([]string, len(ranked))
+	for i, node := range ranked {
+		ids[i] = node.ID
+	}
+	return ids
+}
+
+// Node returns the node with the given ID, or nil
+func (g *Graph) Node(id string) *Node {
+	for _, node := range g.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package deps
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// imports builds a file importing the given modules
+func imports(path, language string, modules ...string) *ir.DistilledFile {
+	file := &ir.DistilledFile{Path: path, Language: language}
+	for _, module := range modules {
+		file.Children = append(file.Children, &ir.DistilledImport{ImportType: "import", Module: module})
+	}
+	return file
+}
+
+// writeFiles creates files below a temporary root
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// edges lists the edges of a graph as "from -> to"
+func edges(g *Graph) []string {
+	var lines []string
+	for _, edge := range g.Edges {
+		lines = append(lines, edge.From+" -> "+edge.To)
+	}
+	return lines
+}
+
+func TestBuildGo(t *testing.T) {
+	root := writeFiles(t, map[string]string{"go.mod": "module example.com/app\n\ngo 1.23\n"})
+	files := []*ir.DistilledFile{
+		imports("main.go", "go", "fmt", "example.com/app/store"),
+		imports("store/store.go", "go", "example.com/app/store/internal/db", "github.com/lib/pq"),
+		imports("store/store_extra.go", "go"),
+		imports("store/internal/db/db.go", "go", "database/sql"),
+	}
+
+	g := Build(root, files, Options{})
+	want := []string{
+		"main.go -> store/store.go",
+		"main.go -> store/store_extra.go",
+		"store/store.go -> store/internal/db/db.go",
+	}
+	if got := edges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+	if got := g.Node("store/store.go").External; !reflect.DeepEqual(got, []string{"github.com/lib/pq"}) {
+		t.Errorf("external = %v", got)
+	}
+
+	g = Build(root, files, Options{Level: LevelPackage})
+	want = []string{". -> store", "store -> store/internal/db"}
+	if got := edges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("package edges = %v, want %v", got, want)
+	}
+}
+
+func TestBuildGoTestFiles(t *testing.T) {
+	root := writeFiles(t, map[string]string{"go.mod": "module example.com/app\n\ngo 1.23\n"})
+	files := []*ir.DistilledFile{
+		imports("main.go", "go", "example.com/app/store"),
+		imports("store/store.go", "go"),
+		imports("store/store_test.go", "go", "testing", "example.com/app/store/internal/db"),
+		imports("store/export_test.go", "go", "example.com/app/store"),
+		imports("store/internal/db/db.go", "go"),
+	}
+
+	// Importers of a package do not depend on its tests, but the tests'
+	// own imports are resolved
+	g := Build(root, files, Options{})
+	want := []string{
+		"main.go -> store/store.go",
+		"store/export_test.go -> store/store.go",
+		"store/store_test.go -> store/internal/db/db.go",
+	}
+	if got := edges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+}
+
+func TestBuildPython(t *testing.T) {
+	files := []*ir.DistilledFile{
+		imports("app/__init__.py", "python", ".models"),
+		imports("app/models.py", "python", "app.util", "os"),
+		imports("app/util.py", "python", "app"),
+		{Path: "app/api/views.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledImport{ImportType: "from", Module: "..", Symbols: []ir.ImportedSymbol{{Name: "models"}}},
+			&ir.DistilledImport{ImportType: "from", Module: "app.util", Symbols: []ir.ImportedSymbol{{Name: "slugify"}}},
+		}},
+	}
+
+	g := Build(t.TempDir(), files, Options{})
+	want := []string{
+		"app/__init__.py -> app/models.py",
+		"app/api/views.py -> app/__init__.py",
+		"app/api/views.py -> app/models.py",
+		"app/api/views.py -> app/util.py",
+		"app/models.py -> app/util.py",
+		"app/util.py -> app/__init__.py",
+	}
+	if got := edges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+
+	wantCycles := [][]string{{"app/__init__.py", "app/models.py", "app/util.py"}}
+	if !reflect.DeepEqual(g.Cycles, wantCycles) {
+		t.Errorf("cycles = %v, want %v", g.Cycles, wantCycles)
+	}
+	for _, edge := range g.Edges {
+		if edge.Cycle == strings.HasPrefix(edge.From, "app/api/") {
+			t.Errorf("edge %s -> %s: cycle = %v", edge.From, edge.To, edge.Cycle)
+		}
+	}
+}
+
+func TestBuildTypeScript(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"tsconfig.json": `{
+  // Path aliases
+  "compilerOptions": {
+    "baseUrl": "src",
+    "paths": {
+      "@lib/*": ["lib/*"],
+    },
+  },
+}`,
+	})
+	files := []*ir.DistilledFile{
+		imports("src/main.ts", "typescript", "./app.js", "@lib/format", "components", "react"),
+		imports("src/app.ts", "typescript", "./components"),
+		imports("src/components/index.tsx", "typescript", "../lib/format"),
+		imports("src/lib/format.ts", "typescript"),
+	}
+
+	g := Build(root, files, Options{})
+	want := []string{
+		"src/app.ts -> src/components/index.tsx",
+		"src/components/index.tsx -> src/lib/format.ts",
+		"src/main.ts -> src/app.ts",
+		"src/main.ts -> src/components/index.tsx",
+		"src/main.ts -> src/lib/format.ts",
+	}
+	if got := edges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+	if got := g.Node("src/main.ts").External; !reflect.DeepEqual(got, []string{"react"}) {
+		t.Errorf("external = %v", got)
+	}
+}
+
+func TestBuildJava(t *testing.T) {
+	java := func(path, pkg string, class string, modules ...string) *ir.DistilledFile {
+		file := imports(path, "java", modules...)
+		file.Children = append([]ir.DistilledNode{&ir.DistilledPackage{Name: pkg}}, file.Children...)
+		file.Children = append(file.Children, &ir.DistilledClass{Name: class})
+		return file
+	}
+	files := []*ir.DistilledFile{
+		java("src/com/acme/App.java", "com.acme", "App", "com.acme.model.*", "java.util.List"),
+		java("src/com/acme/model/User.java", "com.acme.model", "User", "com.acme.service.UserService"),
+		java("src/com/acme/model/Role.java", "com.acme.model", "Role"),
+		java("src/com/acme/service/UserService.java", "com.acme.service", "UserService", "com.acme.model.Role.ADMIN"),
+	}
+
+	g := Build(t.TempDir(), files, Options{})
+	want := []string{
+		"src/com/acme/App.java -> src/com/acme/model/Role.java",
+		"src/com/acme/App.java -> src/com/acme/model/User.java",
+		"src/com/acme/model/User.java -> src/com/acme/service/UserService.java",
+		"src/com/acme/service/UserService.java -> src/com/acme/model/Role.java",
+	}
+	if got := edges(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+	if len(g.Cycles) != 0 {
+		t.Errorf("cycles = %v, want none", g.Cycles)
+	}
+	if want := []string{"src/com/acme/model/Role.java", "src/com/acme/model/User.java", "src/com/acme/service/UserService.java"}; !reflect.DeepEqual(g.MostDependedOn, want) {
+		t.Errorf("most depended-on = %v, want %v", g.MostDependedOn, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	files := []*ir.DistilledFile{
+		imports("a.py", "python", "b"),
+		imports("b.py", "python", "a"),
+		imports("c.py", "python", "a"),
+	}
+	g := Build(t.TempDir(), files, Options{})
+	if got, want := g.Summary(), "3 files, 3 internal imports, 1 import cycle"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	tests := map[string][]string{
+		"text":    {"Most depended-on:", "Import cycles:\n  a.py, b.py", "c.py -> a.py"},
+		"dot":     {"digraph dependencies {", `"a.py" -> "b.py" [color=red];`, `"c.py" -> "a.py";`},
+		"mermaid": {"graph LR\n", `n0["a.py"]`, "n2 --> n0", "class n0,n1 cycle"},
+		"json":    {`"level": "file"`, `"cycle": true`, `"most_depended_on": [`},
+	}
+	for format, wants := range tests {
+		var buf bytes.Buffer
+		if err := g.Write(&buf, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s output missing %q:\n%s", format, want, buf.String())
+			}
+		}
+	}
+
+	if err := g.Write(&bytes.Buffer{}, "svg"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,161 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{"text", "dot", "mermaid", "json"}
+
+// Write renders the graph in one of Formats
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		return g.WriteText(w)
+	case "dot":
+		return g.WriteDOT(w)
+	case "mermaid":
+		return g.WriteMermaid(w)
+	case "json":
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+	return fmt.Errorf("invalid format: %s (valid: %s)", format, strings.Join(Formats, ", "))
+}
+
+// Summary describes the graph in one line
+func (g *Graph) Summary() string {
+	unit := "file"
+	if g.Level == LevelPackage {
+		unit = "package"
+	}
+	return fmt.Sprintf("%d %s%s, %d internal import%s, %d import cycle%s",
+		len(g.Nodes), unit, plural(len(g.Nodes)),
+		len(g.Edges), plural(len(g.Edges)),
+		len(g.Cycles), plural(len(g.Cycles)))
+}
+
+// WriteText renders a readable report: the most depended-on nodes, the
+// import cycles and the imports of every node
+func (g *Graph) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dependency graph: %s\n", g.Summary())
+
+	if len(g.MostDependedOn) > 0 {
+		b.WriteString("\nMost depended-on:\n")
+		for _, id := range g.MostDependedOn {
+			fmt.Fprintf(&b, "  %4d  %s\n", g.Node(id).Dependents, id)
+		}
+	}
+
+	if len(g.Cycles) > 0 {
+		b.WriteString("\nImport cycles:\n")
+		for _, cycle := range g.Cycles {
+			fmt.Fprintf(&b, "  %s\n", strings.Join(cycle, ", "))
+		}
+	}
+
+	if len(g.Edges) > 0 {
+		b.WriteString("\nImports:\n")
+		for i := 0; i < len(g.Edges); {
+			from := g.Edges[i].From
+			var targets []string
+			for ; i < len(g.Edges) && g.Edges[i].From == from; i++ {
+				targets = append(targets, g.Edges[i].To)
+			}
+			fmt.Fprintf(&b, "  %s -> %s\n", from, strings.Join(targets, ", "))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteDOT renders the graph for Graphviz. Edges within import cycles are
+// red, and the most depended-on nodes are listed in a comment.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n", g.Summary())
+	if len(g.MostDependedOn) > 0 {
+		fmt.Fprintf(&b, "// Most depended-on: %s\n", g.mostDependedOnList())
+	}
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"Helvetica\", fontsize=10];\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s;\n", dotQuote(node.ID))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(edge.From), dotQuote(edge.To))
+		if edge.Cycle {
+			b.WriteString(" [color=red]")
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart. Nodes within
+// import cycles are highlighted.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	fmt.Fprintf(&b, "  %%%% %s\n", g.Summary())
+	if len(g.MostDependedOn) > 0 {
+		fmt.Fprintf(&b, "  %%%% Most depended-on: %s\n", g.mostDependedOnList())
+	}
+
+	ids := make(map[string]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node.ID], strings.ReplaceAll(node.ID, `"`, "#quot;"))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+
+	if len(g.Cycles) > 0 {
+		b.WriteString("  classDef cycle stroke:#d33,stroke-width:2px\n")
+		var members []string
+		for _, cycle := range g.Cycles {
+			for _, id := range cycle {
+				members = append(members, ids[id])
+			}
+		}
+		fmt.Fprintf(&b, "  class %s cycle\n", strings.Join(members, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mostDependedOnList formats the most depended-on nodes with their counts
+func (g *Graph) mostDependedOnList() string {
+	items := make([]string, len(g.MostDependedOn))
+	for i, id := range g.MostDependedOn {
+		items[i] = fmt.Sprintf("%s (%d)", id, g.Node(id).Dependents)
+	}
+	return strings.Join(items, ", ")
+}
+
+// dotQuote quotes an ID for the DOT language
+func dotQuote(id string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(id) + `"`
+}
+
+func plural(count int) string {
+	if count == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,563 @@
+package deps
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// scriptExtensions are tried in order for extensionless script imports
+var scriptExtensions = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs", ".vue", ".svelte", ".astro"}
+
+// resolver maps imports to project files
+type resolver struct {
+	root  string
+	files []*ir.DistilledFile
+
+	// byPath and byDir index files by their slash-separated path
+	byPath map[string]*ir.DistilledFile
+	byDir  map[string][]*ir.DistilledFile
+
+	// packages indexes files by their declared package, e.g. com.acme.model
+	packages map[string][]*ir.DistilledFile
+
+	// goModules and tsConfigs cache the nearest go.mod and tsconfig.json
+	// by directory
+	goModules map[string]*goModule
+	tsConfigs map[string]*tsConfig
+}
+
+// goModule is a parsed go.mod file
+type goModule struct {
+	path string
+	dir  string
+}
+
+// tsConfig holds the module resolution settings of a tsconfig.json or
+// jsconfig.json file, with directories relative to the project root
+type tsConfig struct {
+	baseURL string
+	hasBase bool
+	paths   map[string][]string
+}
+
+func newResolver(root string, files []*ir.DistilledFile) *resolver {
+	r := &resolver{
+		root:      root,
+		files:     files,
+		byPath:    make(map[string]*ir.DistilledFile),
+		byDir:     make(map[string][]*ir.DistilledFile),
+		packages:  make(map[string][]*ir.DistilledFile),
+		goModules: make(map[string]*goModule),
+		tsConfigs: make(map[string]*tsConfig),
+	}
+	for _, file := range files {
+		p := filePath(file)
+		r.byPath[p] = file
+		r.byDir[path.Dir(p)] = append(r.byDir[path.Dir(p)], file)
+		for _, child := range file.Children {
+			if pkg, ok := child.(*ir.DistilledPackage); ok && pkg.Name != "" {
+				r.packages[pkg.Name] = append(r.packages[pkg.Name], file)
+			}
+		}
+	}
+	return r
+}
+
+// resolve returns the project files an import refers to
+func (r *resolver) resolve(file *ir.DistilledFile, imp *ir.DistilledImport) []*ir.DistilledFile {
+	module := cleanModule(imp.Module)
+	if module == "" {
+		return nil
+	}
+	switch file.Language {
+	case "go":
+		return r.resolveGo(file, module)
+	case "python":
+		return r.resolvePython(file, module, imp.Symbols)
+	case "java", "kotlin", "scala":
+		return r.resolvePackage(module, imp.Symbols)
+	case "typescript", "javascript", "vue", "svelte", "astro":
+		return r.resolveScript(file, module)
+	}
+	return r.resolveOther(file, module)
+}
+
+// resolveGo maps an import path inside the file's Go module to the files of
+// the imported package
+func (r *resolver) resolveGo(file *ir.DistilledFile, module string) []*ir.DistilledFile {
+	mod := r.goModuleFor(filepath.Join(r.root, filepath.FromSlash(path.Dir(filePath(file)))))
+	if mod == nil || (module != mod.path && !strings.HasPrefix(module, mod.path+"/")) {
+		return nil
+	}
+	dir := filepath.Join(mod.dir, filepath.FromSlash(strings.TrimPrefix(module, mod.path)))
+	rel, ok := r.relative(dir)
+	if !ok {
+		return nil
+	}
+
+	var result []*ir.DistilledFile
+	for _, candidate := range r.byDir[rel] {
+		if candidate.Language == "go" && !isGoTest(candidate) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// isGoTest reports whether a file is a Go test file. The go command leaves
+// test files out of a package when another package imports it.
+func isGoTest(file *ir.DistilledFile) bool {
+	return strings.HasSuffix(filePath(file), "_test.go")
+}
+
+// goModuleFor returns the nearest go.mod at or above dir, or nil
+func (r *resolver) goModuleFor(dir string) *goModule {
+	if mod, ok := r.goModules[dir]; ok {
+		return mod
+	}
+
+	var mod *goModule
+	if modulePath := readModulePath(filepath.Join(dir, "go.mod")); modulePath != "" {
+		mod = &goModule{path: modulePath, dir: dir}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		mod = r.goModuleFor(parent)
+	}
+	r.goModules[dir] = mod
+	return mod
+}
+
+// readModulePath returns the module path declared in a go.mod file
+func readModulePath(name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// resolvePython maps relative and absolute module names to modules and
+// packages. Imported names that are submodules, as in
+// "from app import models", are resolved too.
+func (r *resolver) resolvePython(file *ir.DistilledFile, module string, symbols []ir.ImportedSymbol) []*ir.DistilledFile {
+	rest := strings.TrimLeft(module, ".")
+	dots := len(module) - len(rest)
+	target := strings.ReplaceAll(rest, ".", "/")
+
+	var result []*ir.DistilledFile
+	if dots > 0 {
+		base := path.Dir(filePath(file))
+		for i := 1; i < dots; i++ {
+			base = path.Dir(base)
+		}
+		target = path.Join(base, target)
+		result = append(result, r.pythonModule(target)...)
+		for _, symbol := range symbols {
+			result = append(result, r.pythonModule(path.Join(target, symbol.Name))...)
+		}
+		return result
+	}
+
+	result = append(result, r.suffixMatch(file, target)...)
+	for _, symbol := range symbols {
+		if symbol.Name != "*" && !strings.Contains(symbol.Name, ".") {
+			result = append(result, r.suffixMatch(file, target+"/"+symbol.Name)...)
+		}
+	}
+	return result
+}
+
+// pythonModule returns the module file or package __init__.py at a path
+func (r *resolver) pythonModule(target string) []*ir.DistilledFile {
+	for _, candidate := range []string{target + ".py", target + ".pyi", target + "/__init__.py"} {
+		if file := r.byPath[candidate]; file != nil {
+			return []*ir.DistilledFile{file}
+		}
+	}
+	return nil
+}
+
+// resolvePackage maps an import of a declared package member, such as a
+// Java class, to the files of that package declaring the member. Wildcard
+// imports refer to the whole package.
+func (r *resolver) resolvePackage(module string, symbols []ir.ImportedSymbol) []*ir.DistilledFile {
+	if trimmed, ok := strings.CutSuffix(module, ".*"); ok {
+		return r.packages[trimmed]
+	}
+	for _, symbol := range symbols {
+		if symbol.Name == "*" {
+			return r.packages[module]
+		}
+	}
+
+	// Static imports and nested types name a member below the file's type
+	parts := strings.Split(module, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		files := r.packages[strings.Join(parts[:i], ".")]
+		if len(files) == 0 {
+			continue
+		}
+		var result []*ir.DistilledFile
+		for _, file := range files {
+			if declares(file, parts[i]) {
+				result = append(result, file)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// declares reports whether a file is named after name or declares it at
+// the top level
+func declares(file *ir.DistilledFile, name string) bool {
+	base := path.Base(filePath(file))
+	if strings.TrimSuffix(base, path.Ext(base)) == name {
+		return true
+	}
+	var found bool
+	ir.Walk(file, func(node ir.DistilledNode) bool {
+		switch n := node.(type) {
+		case *ir.DistilledFile, *ir.DistilledPackage:
+			return !found
+		case *ir.DistilledClass:
+			found = found || n.Name == name
+		case *ir.DistilledInterface:
+			found = found || n.Name == name
+		case *ir.DistilledStruct:
+			found = found || n.Name == name
+		case *ir.DistilledEnum:
+			found = found || n.Name == name
+		case *ir.DistilledTypeAlias:
+			found = found || n.Name == name
+		case *ir.DistilledFunction:
+			found = found || n.Name == name
+		case *ir.DistilledField:
+			found = found || n.Name == name
+		}
+		return false
+	})
+	return found
+}
+
+// resolveScript maps relative imports and tsconfig path aliases to
+// TypeScript and JavaScript files
+func (r *resolver) resolveScript(file *ir.DistilledFile, module string) []*ir.DistilledFile {
+	dir := path.Dir(filePath(file))
+	if strings.HasPrefix(module, "./") || strings.HasPrefix(module, "../") || module == "." || module == ".." {
+		return r.scriptFile(path.Join(dir, module))
+	}
+
+	config := r.tsConfigFor(filepath.Join(r.root, filepath.FromSlash(dir)))
+	if config == nil {
+		return nil
+	}
+	for pattern, targets := range config.paths {
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		var matched string
+		switch {
+		case !wildcard && module == pattern:
+		case wildcard && strings.HasPrefix(module, prefix) && strings.HasSuffix(module, suffix) && len(module) >= len(prefix)+len(suffix):
+			matched = module[len(prefix) : len(module)-len(suffix)]
+		default:
+			continue
+		}
+		for _, target := range targets {
+			if found := r.scriptFile(path.Join(config.baseURL, strings.Replace(target, "*", matched, 1))); len(found) > 0 {
+				return found
+			}
+		}
+	}
+	if config.hasBase {
+		return r.scriptFile(path.Join(config.baseURL, module))
+	}
+	return nil
+}
+
+// scriptFile finds the file a script import path refers to, trying the
+// usual extensions and index files. A .js extension may name a .ts file.
+func (r *resolver) scriptFile(target string) []*ir.DistilledFile {
+	if file := r.byPath[target]; file != nil {
+		return []*ir.DistilledFile{file}
+	}
+	switch path.Ext(target) {
+	case ".js", ".jsx", ".mjs", ".cjs":
+		target = strings.TrimSuffix(target, path.Ext(target))
+	}
+	for _, ext := range scriptExtensions {
+		if file := r.byPath[target+ext]; file != nil {
+			return []*ir.DistilledFile{file}
+		}
+	}
+	for _, ext := range scriptExtensions {
+		if file := r.byPath[target+"/index"+ext]; file != nil {
+			return []*ir.DistilledFile{file}
+		}
+	}
+	return nil
+}
+
+// tsConfigFor returns the settings of the nearest tsconfig.json or
+// jsconfig.json at or above dir, or nil
+func (r *resolver) tsConfigFor(dir string) *tsConfig {
+	if config, ok := r.tsConfigs[dir]; ok {
+		return config
+	}
+
+	var config *tsConfig
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		if config = r.readTSConfig(dir, filepath.Join(dir, name)); config != nil {
+			break
+		}
+	}
+	if config == nil {
+		if parent := filepath.Dir(dir); parent != dir {
+			config = r.tsConfigFor(parent)
+		}
+	}
+	r.tsConfigs[dir] = config
+	return config
+}
+
+// readTSConfig parses the compiler options used for module resolution.
+// Comments and trailing commas, which tsconfig files allow, are removed
+// first.
+func (r *resolver) readTSConfig(dir, name string) *tsConfig {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+	var parsed struct {
+		CompilerOptions struct {
+			BaseURL string              `json:"baseUrl"`
+			Paths   map[string][]string `json:"paths"`
+		} `json:"compilerOptions"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &parsed); err != nil {
+		return nil
+	}
+
+	// Paths are relative to baseUrl, or to the config file without one
+	configDir := filepath.ToSlash(mustRel(r.root, dir))
+	config := &tsConfig{baseURL: configDir, paths: parsed.CompilerOptions.Paths}
+	if parsed.CompilerOptions.BaseURL != "" {
+		config.baseURL = path.Join(configDir, parsed.CompilerOptions.BaseURL)
+		config.hasBase = true
+	}
+	return config
+}
+
+// resolveOther handles languages without a dedicated resolver: file paths
+// as in C includes and PHP requires, and module paths with "::", "\" or
+// "." separators as in Rust, PHP namespaces and Ruby
+func (r *resolver) resolveOther(file *ir.DistilledFile, module string) []*ir.DistilledFile {
+	// Dart package imports name a file below lib/ of the package
+	if rest, ok := strings.CutPrefix(module, "package:"); ok {
+		if _, inPackage, found := strings.Cut(rest, "/"); found {
+			return r.suffixMatch(file, "lib/"+strings.TrimSuffix(inPackage, path.Ext(inPackage)))
+		}
+		return nil
+	}
+
+	if ext := path.Ext(module); strings.Contains(module, "/") || (ext != "" && r.hasExtension(ext)) {
+		for _, candidate := range []string{path.Join(path.Dir(filePath(file)), module), path.Clean(module)} {
+			if found := r.byPath[candidate]; found != nil {
+				return []*ir.DistilledFile{found}
+			}
+		}
+		if ext != "" {
+			return r.suffixMatch(file, strings.TrimSuffix(module, ext))
+		}
+		return r.suffixMatch(file, module)
+	}
+
+	segments := strings.FieldsFunc(module, func(c rune) bool { return c == ':' || c == '\\' || c == '.' })
+	for len(segments) > 0 && (segments[0] == "crate" || segments[0] == "self" || segments[0] == "super") {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	// The module itself, then its parent when the import names a member,
+	// then without the root namespace, which often maps to another folder
+	candidates := [][]string{segments}
+	if len(segments) > 1 {
+		candidates = append(candidates, segments[:len(segments)-1])
+	}
+	if len(segments) > 2 {
+		candidates = append(candidates, segments[1:])
+	}
+	for _, candidate := range candidates {
+		if found := r.suffixMatch(file, strings.Join(candidate, "/")); len(found) > 0 {
+			return found
+		}
+	}
+	return nil
+}
+
+// hasExtension reports whether any project file has the extension
+func (r *resolver) hasExtension(ext string) bool {
+	for p := range r.byPath {
+		if path.Ext(p) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// suffixMatch returns the files of the importer's language family whose
+// module path ends with target, which has no extension. Index files such
+// as __init__.py, index.js and mod.rs stand for their directory. Among
+// several matches, those closest to the importer win.
+func (r *resolver) suffixMatch(importer *ir.DistilledFile, target string) []*ir.DistilledFile {
+	target = strings.Trim(target, "/")
+	if target == "" {
+		return nil
+	}
+	importerDir := path.Dir(filePath(importer))
+
+	var result []*ir.DistilledFile
+	best := -1
+	for _, candidate := range r.files {
+		if candidate == importer || family(candidate.Language) != family(importer.Language) {
+			continue
+		}
+		key := moduleKey(filePath(candidate))
+		if key != target && !strings.HasSuffix(key, "/"+target) {
+			continue
+		}
+		shared := commonDirs(importerDir, path.Dir(filePath(candidate)))
+		switch {
+		case shared > best:
+			best = shared
+			result = []*ir.DistilledFile{candidate}
+		case shared == best:
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// moduleKey returns a file's path without extension, or the directory of
+// index files
+func moduleKey(p string) string {
+	key := strings.TrimSuffix(p, path.Ext(p))
+	key = strings.TrimSuffix(key, ".d")
+	switch path.Base(key) {
+	case "__init__", "index", "mod":
+		return path.Dir(key)
+	}
+	return key
+}
+
+// commonDirs counts the leading directories two paths share
+func commonDirs(a, b string) int {
+	as := strings.Split(a, "/")
+	bs := strings.Split(b, "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return n
+}
+
+// family groups languages that import each other's files
+func family(language string) string {
+	switch language {
+	case "typescript", "javascript", "vue", "svelte", "astro":
+		return "script"
+	case "c", "cpp":
+		return "c"
+	}
+	return language
+}
+
+// relative converts an absolute path to a slash-separated path relative
+// to the root, reporting false for paths outside it
+func (r *resolver) relative(abs string) (string, bool) {
+	rel, err := filepath.Rel(r.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// mustRel returns target relative to base, or target when that fails
+func mustRel(base, target string) string {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return target
+	}
+	return rel
+}
+
+// filePath returns the slash-separated, cleaned path of a file
+func filePath(file *ir.DistilledFile) string {
+	return path.Clean(strings.ReplaceAll(file.Path, "\\", "/"))
+}
+
+// cleanModule removes quotes and brackets around an import path
+func cleanModule(module string) string {
+	return strings.Trim(strings.TrimSpace(module), "\"'`<>;")
+}
+
+// stripJSONC removes comments and trailing commas from JSON with comments
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Drop a comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+// Package distill holds the flag mapping and output clean-up shared by the
+// aid command and pkg/distiller, so the library distills exactly like the
+// command line.
+package distill
+
+import (
+	"regexp"
+
+	"github.com/janreges/ai-distiller/internal/processor"
+)
+
+// Filter holds the filtering flags of the aid command
+type Filter struct {
+	// Visibility (--public, --protected, --internal, --private)
+	Public    bool
+	Protected bool
+	Internal  bool
+	Private   bool
+
+	// Content (--comments, --docstrings, --implementation, --imports, --annotations)
+	Comments       bool
+	Docstrings     bool
+	Implementation bool
+	Imports        bool
+	Annotations    bool
+
+	// Include and Exclude are file patterns (--include, --exclude)
+	Include []string
+	Exclude []string
+
+	// Recursive processes subdirectories (--recursive)
+	Recursive bool
+
+	// Raw processes all text files without parsing (--raw)
+	Raw bool
+
+	// Workers is the number of parallel workers, 0 for auto (--workers)
+	Workers int
+
+	// SQLSchema folds the SQL migrations of a directory into their final
+	// schema (--sql-schema)
+	SQLSchema bool
+}
+
+// DefaultFilter returns the default flag values of the aid command: public
+// API with docstrings, imports and annotations, recursive, with SQL
+// migrations folded
+func DefaultFilter() Filter {
+	return Filter{
+		Public:      true,
+		Docstrings:  true,
+		Imports:     true,
+		Annotations: true,
+		Recursive:   true,
+		SQLSchema:   true,
+	}
+}
+
+// ProcessOptions converts the flags to processor options. Paths are left
+// to the caller.
+func (f Filter) ProcessOptions() processor.ProcessOptions {
+	opts := processor.ProcessOptions{
+		IncludeComments:       f.Comments,
+		IncludeImports:        f.Imports,
+		IncludeImplementation: f.Implementation,
+		IncludeDocstrings:     f.Docstrings,
+		IncludeAnnotations:    f.Annotations,
+		IncludePatterns:       f.Include,
+		ExcludePatterns:       f.Exclude,
+		Recursive:             f.Recursive,
+		RawMode:               f.Raw,
+		Workers:               f.Workers,
+		FoldDirectories:       f.SQLSchema,
+	}
+	ApplyVisibility(&opts, f.Public, f.Protected, f.Internal, f.Private)
+	return opts
+}
+
+// ApplyVisibility sets the visibility filtering options from the included levels
+func ApplyVisibility(opts *processor.ProcessOptions, public, protected, internal, private bool) {
+	// If only public is included, remove all non-public
+	if public && !protected && !internal && !private {
+		opts.IncludePrivate = false
+		return
+	}
+
+	opts.IncludePrivate = true
+	// Set specific removal flags based on what's NOT included
+	opts.RemovePrivateOnly = !private
+	opts.RemoveProtectedOnly = !protected
+	opts.RemoveInternalOnly = !internal
+}
+
+// EmptyFilePattern matches <file> tags of the text format that have no content
+var EmptyFilePattern = regexp.MustCompile(`(?s)<file path="[^"]+">\s*</file>\s*`)
+
+// RemoveEmptyFiles drops files without any remaining content from text output
+func RemoveEmptyFiles(output string) string {
+	return EmptyFilePattern.ReplaceAllString(output, "")
+}
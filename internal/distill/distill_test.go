@@ -0,0 +1,40 @@
+package distill
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessOptionsVisibility(t *testing.T) {
+	tests := []struct {
+		name                           string
+		protected, private             bool
+		includePrivate                 bool
+		removePrivate, removeProtected bool
+	}{
+		{"public only", false, false, false, false, false},
+		{"with protected", true, false, true, true, false},
+		{"with private", false, true, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := DefaultFilter()
+			filter.Protected = tt.protected
+			filter.Private = tt.private
+
+			opts := filter.ProcessOptions()
+			assert.Equal(t, tt.includePrivate, opts.IncludePrivate)
+			assert.Equal(t, tt.removePrivate, opts.RemovePrivateOnly)
+			assert.Equal(t, tt.removeProtected, opts.RemoveProtectedOnly)
+			assert.True(t, opts.IncludeDocstrings)
+			assert.False(t, opts.IncludeImplementation)
+		})
+	}
+}
+
+func TestRemoveEmptyFiles(t *testing.T) {
+	output := "<file path=\"a.go\">\n</file>\n<file path=\"b.go\">\nfunc B()\n</file>\n"
+	assert.Equal(t, "<file path=\"b.go\">\nfunc B()\n</file>\n", RemoveEmptyFiles(output))
+}
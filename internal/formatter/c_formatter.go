@@ -0,0 +1,231 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// CFormatter formats IR nodes as C declarations
+type CFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewCFormatter creates a new C formatter
+func NewCFormatter() *CFormatter {
+	return &CFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("c"),
+	}
+}
+
+// FormatNode formats an IR node as C code
+func (f *CFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledComment:
+		for i, line := range strings.Split(n.Text, "\n") {
+			line = strings.TrimSpace(line)
+			// Align the stars of block comment lines
+			if i > 0 && strings.HasPrefix(line, "*") {
+				line = " " + line
+			}
+			fmt.Fprintf(w, "%s%s\n", indentStr, line)
+		}
+	case *ir.DistilledImport:
+		if cExtensions(n.Extensions).System {
+			fmt.Fprintf(w, "%s#include <%s>\n", indentStr, n.Module)
+		} else {
+			fmt.Fprintf(w, "%s#include \"%s\"\n", indentStr, n.Module)
+		}
+	case *ir.DistilledStruct:
+		return f.formatStruct(w, n, indent)
+	case *ir.DistilledEnum:
+		return f.formatEnum(w, n, indent)
+	case *ir.DistilledTypeAlias:
+		fmt.Fprintf(w, "%stypedef %s;\n", indentStr, cDeclaration(n.Type.Name, n.Name))
+	case *ir.DistilledFunction:
+		f.formatFunction(w, n, indentStr)
+	case *ir.DistilledField:
+		f.formatField(w, n, indentStr)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+// formatStruct writes a struct or union with its fields
+func (f *CFormatter) formatStruct(w io.Writer, st *ir.DistilledStruct, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	ext := cExtensions(st.Extensions)
+	kind := ext.Kind
+	if kind == "" {
+		kind = "struct"
+	}
+
+	fmt.Fprintf(w, "%s%s {\n", indentStr, cTypeHeader(kind, st.Name, ext))
+	for _, child := range st.Children {
+		if err := f.FormatNode(w, child, indent+1); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "%s}%s;\n", indentStr, cTypeTrailer(st.Name, ext))
+	return nil
+}
+
+// formatEnum writes an enum with its constants
+func (f *CFormatter) formatEnum(w io.Writer, enum *ir.DistilledEnum, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	ext := cExtensions(enum.Extensions)
+
+	fmt.Fprintf(w, "%s%s {\n", indentStr, cTypeHeader("enum", enum.Name, ext))
+	for _, child := range enum.Children {
+		field, ok := child.(*ir.DistilledField)
+		if !ok {
+			if err := f.FormatNode(w, child, indent+1); err != nil {
+				return err
+			}
+			continue
+		}
+		line := field.Name
+		if field.DefaultValue != "" {
+			line += " = " + field.DefaultValue
+		}
+		fmt.Fprintf(w, "%s    %s,\n", indentStr, line)
+	}
+	fmt.Fprintf(w, "%s}%s;\n", indentStr, cTypeTrailer(enum.Name, ext))
+	return nil
+}
+
+// formatFunction writes a function prototype, its body when the
+// implementation is kept, or a function-like macro
+func (f *CFormatter) formatFunction(w io.Writer, fn *ir.DistilledFunction, indent string) {
+	params := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		params[i] = cDeclaration(param.Type.Name, param.Name)
+	}
+
+	if cExtensions(fn.Extensions).Macro {
+		line := "#define " + fn.Name + "(" + strings.Join(params, ", ") + ")"
+		if fn.Implementation != "" {
+			line += " " + fn.Implementation
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+		return
+	}
+
+	line := cStorage(fn.Modifiers, fn.Decorators)
+	returns := ""
+	if fn.Returns != nil {
+		returns = fn.Returns.Name
+	}
+	line += cDeclaration(returns, fn.Name+"("+strings.Join(params, ", ")+")")
+
+	if fn.Implementation == "" {
+		fmt.Fprintf(w, "%s%s;\n", indent, line)
+		return
+	}
+	fmt.Fprintf(w, "%s%s %s\n", indent, line, strings.TrimSpace(fn.Implementation))
+}
+
+// formatField writes a variable, struct field or object-like macro
+func (f *CFormatter) formatField(w io.Writer, field *ir.DistilledField, indent string) {
+	ext := cExtensions(field.Extensions)
+	if ext.Macro {
+		line := "#define " + field.Name
+		if field.DefaultValue != "" {
+			line += " " + field.DefaultValue
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+		return
+	}
+
+	typ := ""
+	if field.Type != nil {
+		typ = field.Type.Name
+	}
+	line := cStorage(field.Modifiers, field.Decorators) + cDeclaration(typ, field.Name)
+	if ext.Bits != "" {
+		line += " : " + ext.Bits
+	}
+	if field.DefaultValue != "" {
+		line += " = " + field.DefaultValue
+	}
+	fmt.Fprintf(w, "%s%s;\n", indent, line)
+}
+
+// cTypeHeader returns the opening of a struct, union or enum definition,
+// e.g. typedef struct node
+func cTypeHeader(kind, name string, ext *ir.CExtensions) string {
+	header := kind
+	if ext.Typedef {
+		header = "typedef " + kind
+		name = ext.Tag
+	}
+	if name != "" {
+		header += " " + name
+	}
+	return header
+}
+
+// cTypeTrailer returns what follows the closing brace of a struct, union or
+// enum definition: the typedef name or the declarators of an anonymous type
+func cTypeTrailer(name string, ext *ir.CExtensions) string {
+	switch {
+	case ext.Typedef:
+		return " " + name
+	case ext.Declarator != "":
+		return " " + ext.Declarator
+	}
+	return ""
+}
+
+// cStorage returns the attributes and storage class of a declaration
+func cStorage(modifiers []ir.Modifier, decorators []string) string {
+	var parts []string
+	parts = append(parts, decorators...)
+	for _, modifier := range modifiers {
+		switch modifier {
+		case ir.ModifierStatic, ir.ModifierExtern, ir.ModifierInline:
+			parts = append(parts, string(modifier))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " ") + " "
+}
+
+// cDeclaration places a name into a type written in C cast notation, e.g.
+// int (*)(int) and handler become int (*handler)(int)
+func cDeclaration(typ, name string) string {
+	if name == "" {
+		return typ
+	}
+	if typ == "" {
+		return name
+	}
+
+	at := len(typ)
+	if i := strings.Index(typ, "(*"); i >= 0 {
+		at = i + strings.Index(typ[i:], ")")
+	} else if i := strings.Index(typ, "["); i >= 0 {
+		at = i
+	}
+	head := strings.TrimRight(typ[:at], " ")
+	if !strings.HasSuffix(head, "*") && !strings.HasSuffix(head, "(") {
+		head += " "
+	}
+	return head + name + typ[at:]
+}
+
+// cExtensions returns the C extensions of a node, or empty extensions if it
+// has none
+func cExtensions(ext *ir.NodeExtensions) *ir.CExtensions {
+	if ext == nil || ext.C == nil {
+		return &ir.CExtensions{}
+	}
+	return ext.C
+}
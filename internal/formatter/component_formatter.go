@@ -0,0 +1,164 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// ComponentFormatter formats Vue, Svelte and Astro components: the component
+// API as a component block, and the script with the JavaScript or
+// TypeScript formatter
+type ComponentFormatter struct {
+	BaseLanguageFormatter
+	typescript *TypeScriptFormatter
+	javascript *JavaScriptFormatter
+	script     LanguageFormatter
+}
+
+// NewComponentFormatter creates a new formatter for the components of a
+// framework
+func NewComponentFormatter(framework string) *ComponentFormatter {
+	f := &ComponentFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter(framework),
+		typescript:            NewTypeScriptFormatter(),
+		javascript:            NewJavaScriptFormatter(),
+	}
+	f.Reset()
+	return f
+}
+
+// Reset resets the formatter state for a new file
+func (f *ComponentFormatter) Reset() {
+	f.typescript.Reset()
+	f.javascript.Reset()
+	f.script = f.javascript
+}
+
+// FormatNode formats a component, or a node of its script
+func (f *ComponentFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	class, ok := node.(*ir.DistilledClass)
+	if !ok || componentExtensions(class.Extensions) == nil {
+		return f.script.FormatNode(w, node, indent)
+	}
+
+	ext := componentExtensions(class.Extensions)
+	if ext.Script == "ts" {
+		f.script = f.typescript
+	}
+	return f.formatComponent(w, class, ext, indent)
+}
+
+// formatComponent writes the props, events, slots and exposed members of a
+// component
+func (f *ComponentFormatter) formatComponent(w io.Writer, class *ir.DistilledClass, ext *ir.ComponentExtensions, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	memberIndent := indentStr + "    "
+
+	fmt.Fprintf(w, "%scomponent %s%s {\n", indentStr, class.Name, componentTypeParams(class.TypeParams))
+	if ext.PropsType != "" {
+		fmt.Fprintf(w, "%sprops %s\n", memberIndent, ext.PropsType)
+	}
+	for _, child := range class.Children {
+		switch n := child.(type) {
+		case *ir.DistilledField:
+			fmt.Fprintf(w, "%s%s\n", memberIndent, componentField(n))
+		case *ir.DistilledFunction:
+			fmt.Fprintf(w, "%s%s\n", memberIndent, componentFunction(n))
+		default:
+			if err := f.script.FormatNode(w, child, indent+1); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+// componentField returns the line of a prop or exposed field
+func componentField(field *ir.DistilledField) string {
+	ext := componentExtensions(field.Extensions)
+	if ext == nil || ext.Kind != "prop" {
+		return "expose " + field.Name
+	}
+
+	line := "prop " + field.Name
+	if ext.Bindable {
+		line = "bindable " + line
+	}
+	if !ext.Required {
+		line += "?"
+	}
+	if field.Type != nil && field.Type.Name != "" {
+		line += ": " + field.Type.Name
+	}
+	if field.DefaultValue != "" {
+		line += " = " + field.DefaultValue
+	}
+	return line
+}
+
+// componentFunction returns the line of an event, slot or exposed function.
+// Slots without props have no parameter list.
+func componentFunction(fn *ir.DistilledFunction) string {
+	kind := "expose"
+	if ext := componentExtensions(fn.Extensions); ext != nil && ext.Kind != "exposed" {
+		kind = ext.Kind
+	}
+	if kind == "slot" && len(fn.Parameters) == 0 {
+		return kind + " " + fn.Name
+	}
+	return kind + " " + fn.Name + componentParameters(fn.Parameters)
+}
+
+// componentParameters returns the parameter list of an event, slot or
+// exposed function
+func componentParameters(params []ir.Parameter) string {
+	parts := make([]string, len(params))
+	for i, param := range params {
+		part := param.Name
+		if param.IsVariadic {
+			part = "..." + part
+		}
+		if param.IsOptional {
+			part += "?"
+		}
+		switch {
+		case param.Name == "":
+			part = param.Type.Name
+		case param.Type.Name != "":
+			part += ": " + param.Type.Name
+		}
+		parts[i] = part
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// componentTypeParams returns the type parameters of a generic component
+func componentTypeParams(params []ir.TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	parts := make([]string, len(params))
+	for i, param := range params {
+		part := param.Name
+		if len(param.Constraints) > 0 {
+			part += " extends " + param.Constraints[0].Name
+		}
+		if param.Default != nil {
+			part += " = " + param.Default.Name
+		}
+		parts[i] = part
+	}
+	return "<" + strings.Join(parts, ", ") + ">"
+}
+
+// componentExtensions returns the component extensions of a node, or nil
+func componentExtensions(ext *ir.NodeExtensions) *ir.ComponentExtensions {
+	if ext == nil {
+		return nil
+	}
+	return ext.Component
+}
@@ -0,0 +1,431 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// DartFormatter formats IR nodes as Dart code
+type DartFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewDartFormatter creates a new Dart formatter
+func NewDartFormatter() *DartFormatter {
+	return &DartFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("dart"),
+	}
+}
+
+// FormatNode formats an IR node as Dart code
+func (f *DartFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	f.formatNode(w, node, indent, nil)
+	return nil
+}
+
+// formatNode writes a node. owner is the enclosing class, whose name
+// constructors have.
+func (f *DartFormatter) formatNode(w io.Writer, node ir.DistilledNode, indent int, owner *ir.DistilledClass) {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledPackage:
+		fmt.Fprintf(w, "%slibrary %s;\n", indentStr, n.Name)
+	case *ir.DistilledImport:
+		fmt.Fprintf(w, "%s%s\n", indentStr, f.formatDirective(n))
+	case *ir.DistilledComment:
+		for i, line := range strings.Split(n.Text, "\n") {
+			line = strings.TrimSpace(line)
+			if i > 0 && strings.HasPrefix(line, "*") {
+				line = " " + line
+			}
+			fmt.Fprintf(w, "%s%s\n", indentStr, line)
+		}
+	case *ir.DistilledClass:
+		f.formatClass(w, n, indent)
+	case *ir.DistilledFunction:
+		fmt.Fprintf(w, "%s\n", f.formatFunction(n, indentStr, owner))
+	case *ir.DistilledField:
+		fmt.Fprintf(w, "%s\n", f.formatField(n, indentStr))
+	case *ir.DistilledTypeAlias:
+		fmt.Fprintf(w, "%s%stypedef %s%s = %s;\n", f.formatAnnotations(nil, n.Visibility, indentStr), indentStr, n.Name, f.formatTypeParams(n.TypeParams), n.Type.Name)
+	default:
+		// Skip unknown nodes
+	}
+}
+
+// formatDirective returns an import, export or part directive
+func (f *DartFormatter) formatDirective(imp *ir.DistilledImport) string {
+	directive := imp.ImportType + " '" + imp.Module + "'"
+	if imp.ImportType == "part of" && !strings.Contains(imp.Module, "/") && !strings.HasSuffix(imp.Module, ".dart") {
+		// Library name
+		directive = "part of " + imp.Module
+	}
+
+	ext := dartExtensions(imp.Extensions)
+	for _, keyword := range ext.Keywords {
+		directive += " " + keyword
+	}
+	var shown []string
+	for _, symbol := range imp.Symbols {
+		if symbol.Name == "*" {
+			directive += " as " + symbol.Alias
+		} else {
+			shown = append(shown, symbol.Name)
+		}
+	}
+	if len(shown) > 0 {
+		directive += " show " + strings.Join(shown, ", ")
+	}
+	if len(ext.Hide) > 0 {
+		directive += " hide " + strings.Join(ext.Hide, ", ")
+	}
+	return directive + ";"
+}
+
+// formatClass writes a class, mixin, extension, extension type or enum
+func (f *DartFormatter) formatClass(w io.Writer, class *ir.DistilledClass, indent int) {
+	indentStr := strings.Repeat("    ", indent)
+	ext := dartExtensions(class.Extensions)
+
+	var parts []string
+	for _, modifier := range []ir.Modifier{ir.ModifierAbstract, ir.ModifierSealed, ir.ModifierFinal} {
+		if dartHasModifier(class.Modifiers, modifier) {
+			parts = append(parts, string(modifier))
+		}
+	}
+	for _, keyword := range ext.Keywords {
+		if keyword != "const" {
+			parts = append(parts, keyword)
+		}
+	}
+
+	isEnum := dartHasModifier(class.Modifiers, ir.ModifierEnum)
+	switch {
+	case isEnum:
+		parts = append(parts, "enum")
+	case ext.Kind == "extension_type":
+		parts = append(parts, "extension type")
+		if dartHasKeyword(ext, "const") {
+			parts = append(parts, "const")
+		}
+	case ext.Kind != "":
+		parts = append(parts, ext.Kind)
+	default:
+		parts = append(parts, "class")
+	}
+	header := strings.Join(parts, " ")
+	if class.Name != "" {
+		header += " " + class.Name
+	}
+	header += f.formatTypeParams(class.TypeParams)
+
+	// The representation of an extension type is part of the header
+	var members []ir.DistilledNode
+	representation := map[string]bool{}
+	for _, child := range class.Children {
+		if fn, ok := child.(*ir.DistilledFunction); ok && dartIsPrimary(fn) {
+			header += strings.TrimPrefix(fn.Name, "constructor") + f.formatParameters(fn)
+			for _, param := range fn.Parameters {
+				representation[param.Name] = true
+			}
+			continue
+		}
+		if field, ok := child.(*ir.DistilledField); ok && representation[field.Name] {
+			continue
+		}
+		members = append(members, child)
+	}
+
+	if len(class.Extends) > 0 {
+		header += " extends " + f.joinTypes(class.Extends)
+	}
+	if len(ext.On) > 0 {
+		header += " on " + strings.Join(ext.On, ", ")
+	}
+	if len(class.Mixins) > 0 {
+		header += " with " + f.joinTypes(class.Mixins)
+	}
+	if len(class.Implements) > 0 {
+		header += " implements " + f.joinTypes(class.Implements)
+	}
+
+	fmt.Fprintf(w, "%s%s%s", f.formatAnnotations(class.Decorators, class.Visibility, indentStr), indentStr, header)
+	if len(members) == 0 {
+		fmt.Fprintln(w, " {}")
+		return
+	}
+	fmt.Fprintln(w, " {")
+
+	if isEnum {
+		// Values come first, separated by commas and ended by a semicolon
+		// before other members
+		var values []*ir.DistilledField
+		var rest []ir.DistilledNode
+		for _, child := range members {
+			if field, ok := child.(*ir.DistilledField); ok && field.Type != nil && field.Type.Name == class.Name && dartHasModifier(field.Modifiers, ir.ModifierStatic) {
+				values = append(values, field)
+				continue
+			}
+			rest = append(rest, child)
+		}
+		for i, value := range values {
+			separator := ","
+			if i == len(values)-1 {
+				separator = ""
+				if len(rest) > 0 {
+					separator = ";"
+				}
+			}
+			fmt.Fprintf(w, "%s%s    %s%s%s\n", f.formatAnnotations(value.Decorators, ir.VisibilityPublic, indentStr+"    "), indentStr, value.Name, value.DefaultValue, separator)
+		}
+		members = rest
+	}
+
+	for _, child := range members {
+		f.formatNode(w, child, indent+1, class)
+	}
+	fmt.Fprintf(w, "%s}\n", indentStr)
+}
+
+// formatFunction returns a function, method, getter, setter, operator or
+// constructor
+func (f *DartFormatter) formatFunction(fn *ir.DistilledFunction, indent string, owner *ir.DistilledClass) string {
+	ext := dartExtensions(fn.Extensions)
+
+	var parts []string
+	for _, modifier := range fn.Modifiers {
+		switch modifier {
+		case ir.ModifierExtern:
+			parts = append(parts, "external")
+		case ir.ModifierStatic:
+			parts = append(parts, "static")
+		case ir.ModifierConst:
+			parts = append(parts, "const")
+		}
+	}
+	for _, keyword := range ext.Keywords {
+		if keyword != "async*" && keyword != "sync*" {
+			parts = append(parts, keyword)
+		}
+	}
+
+	name := fn.Name
+	if strings.HasPrefix(name, "constructor") {
+		if ext.Kind == "factory" {
+			parts = append(parts, "factory")
+		}
+		className := ""
+		if owner != nil {
+			className = owner.Name
+		}
+		name = className + strings.TrimPrefix(name, "constructor")
+	} else if fn.Returns != nil {
+		parts = append(parts, fn.Returns.Name)
+	}
+	switch ext.Kind {
+	case "getter":
+		parts = append(parts, "get")
+	case "setter":
+		parts = append(parts, "set")
+	}
+
+	signature := strings.Join(append(parts, name), " ") + f.formatTypeParams(fn.TypeParams)
+	if ext.Kind != "getter" {
+		signature += f.formatParameters(fn)
+	}
+	if ext.Initializers != "" {
+		signature += " " + ext.Initializers
+	}
+
+	switch {
+	case dartHasKeyword(ext, "async*"):
+		signature += " async*"
+	case dartHasModifier(fn.Modifiers, ir.ModifierAsync):
+		signature += " async"
+	case dartHasKeyword(ext, "sync*"):
+		signature += " sync*"
+	}
+
+	switch {
+	case fn.Implementation == "":
+		signature += ";"
+	case strings.HasPrefix(fn.Implementation, "=>"):
+		signature += " " + dartReindent(fn.Implementation, fn.Location.StartColumn, indent) + ";"
+	default:
+		signature += " " + dartReindent(fn.Implementation, fn.Location.StartColumn, indent)
+	}
+
+	decorators := fn.Decorators
+	if dartHasModifier(fn.Modifiers, ir.ModifierOverride) {
+		decorators = append([]string{"@override"}, decorators...)
+	}
+	return f.formatAnnotations(decorators, fn.Visibility, indent) + indent + signature
+}
+
+// formatField returns a field or top-level variable
+func (f *DartFormatter) formatField(field *ir.DistilledField, indent string) string {
+	ext := dartExtensions(field.Extensions)
+
+	var parts []string
+	if dartHasModifier(field.Modifiers, ir.ModifierStatic) {
+		parts = append(parts, "static")
+	}
+	if dartHasModifier(field.Modifiers, ir.ModifierExtern) {
+		parts = append(parts, "external")
+	}
+	parts = append(parts, ext.Keywords...)
+	switch {
+	case dartHasModifier(field.Modifiers, ir.ModifierConst):
+		parts = append(parts, "const")
+	case dartHasModifier(field.Modifiers, ir.ModifierFinal):
+		parts = append(parts, "final")
+	case field.Type == nil:
+		parts = append(parts, "var")
+	}
+	if field.Type != nil {
+		parts = append(parts, field.Type.Name)
+	}
+
+	declaration := strings.Join(append(parts, field.Name), " ")
+	if field.DefaultValue != "" {
+		declaration += " = " + field.DefaultValue
+	}
+
+	decorators := field.Decorators
+	if dartHasModifier(field.Modifiers, ir.ModifierOverride) {
+		decorators = append([]string{"@override"}, decorators...)
+	}
+	return f.formatAnnotations(decorators, field.Visibility, indent) + indent + declaration + ";"
+}
+
+// formatParameters returns a parameter list with its optional positional
+// parameters in brackets or named parameters in braces
+func (f *DartFormatter) formatParameters(fn *ir.DistilledFunction) string {
+	ext := dartExtensions(fn.Extensions)
+	optionalFrom := len(fn.Parameters) - ext.Optional
+
+	var required, optional []string
+	for i, param := range fn.Parameters {
+		text := strings.Join(append(append([]string{}, param.Decorators...), strings.TrimSpace(param.Type.Name+" "+param.Name)), " ")
+		if param.DefaultValue != "" {
+			text += " = " + param.DefaultValue
+		}
+		if i >= optionalFrom {
+			optional = append(optional, text)
+		} else {
+			required = append(required, text)
+		}
+	}
+
+	if len(optional) > 0 {
+		group := "[" + strings.Join(optional, ", ") + "]"
+		if ext.Named {
+			group = "{" + strings.Join(optional, ", ") + "}"
+		}
+		required = append(required, group)
+	}
+	return "(" + strings.Join(required, ", ") + ")"
+}
+
+// formatAnnotations returns the annotations of a declaration, each on its
+// own line. Protected members have the @protected annotation.
+func (f *DartFormatter) formatAnnotations(decorators []string, visibility ir.Visibility, indent string) string {
+	var result strings.Builder
+	if visibility == ir.VisibilityProtected {
+		result.WriteString(indent + "@protected\n")
+	}
+	for _, decorator := range decorators {
+		if decorator != "@Primary" {
+			result.WriteString(indent + decorator + "\n")
+		}
+	}
+	return result.String()
+}
+
+// formatTypeParams returns type parameters in angle brackets with their
+// bounds
+func (f *DartFormatter) formatTypeParams(params []ir.TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var result []string
+	for _, param := range params {
+		text := param.Name
+		for _, constraint := range param.Constraints {
+			text += " extends " + constraint.Name
+		}
+		result = append(result, text)
+	}
+	return "<" + strings.Join(result, ", ") + ">"
+}
+
+// joinTypes returns the names of types separated by commas
+func (f *DartFormatter) joinTypes(types []ir.TypeRef) string {
+	var names []string
+	for _, typ := range types {
+		names = append(names, typ.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// dartReindent indents the continuation lines of a body, which start at
+// the column of their declaration in the source
+func dartReindent(body string, column int, indent string) string {
+	base := max(column-1, 0)
+	lines := strings.Split(body, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			lines[i] = ""
+			continue
+		}
+		cut := min(base, len(line)-len(trimmed))
+		lines[i] = indent + line[cut:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dartIsPrimary reports whether a function is the representation of an
+// extension type
+func dartIsPrimary(fn *ir.DistilledFunction) bool {
+	for _, decorator := range fn.Decorators {
+		if decorator == "@Primary" {
+			return true
+		}
+	}
+	return false
+}
+
+// dartHasModifier reports whether a list of modifiers has a modifier
+func dartHasModifier(modifiers []ir.Modifier, modifier ir.Modifier) bool {
+	for _, m := range modifiers {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
+
+// dartHasKeyword reports whether a declaration has a keyword
+func dartHasKeyword(ext *ir.DartExtensions, keyword string) bool {
+	for _, k := range ext.Keywords {
+		if k == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// dartExtensions returns the Dart extensions of a node, or empty extensions
+// if it has none
+func dartExtensions(ext *ir.NodeExtensions) *ir.DartExtensions {
+	if ext == nil || ext.Dart == nil {
+		return &ir.DartExtensions{}
+	}
+	return ext.Dart
+}
@@ -193,8 +193,11 @@ func (f *GoFormatter) formatStruct(w io.Writer, class *ir.DistilledClass, indent
 		fmt.Fprintln(w, " {")
 		// Format fields
 		for _, child := range class.Children {
-			if field, ok := child.(*ir.DistilledField); ok {
-				f.formatStructField(w, field, indent+1)
+			switch c := child.(type) {
+			case *ir.DistilledField:
+				f.formatStructField(w, c, indent+1)
+			case *ir.DistilledComment:
+				f.formatComment(w, c, indentStr+"    ")
 			}
 		}
 		fmt.Fprintf(w, "%s}\n", indentStr)
@@ -265,8 +268,11 @@ func (f *GoFormatter) formatInterface(w io.Writer, intf *ir.DistilledInterface,
 		fmt.Fprintln(w, " {")
 		// Format methods
 		for _, child := range intf.Children {
-			if fn, ok := child.(*ir.DistilledFunction); ok {
-				f.formatInterfaceMethod(w, fn, indent+1)
+			switch c := child.(type) {
+			case *ir.DistilledFunction:
+				f.formatInterfaceMethod(w, c, indent+1)
+			case *ir.DistilledComment:
+				f.formatComment(w, c, indentStr+"    ")
 			}
 		}
 		fmt.Fprintf(w, "%s}\n", indentStr)
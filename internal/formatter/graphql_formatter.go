@@ -0,0 +1,192 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// GraphQLFormatter formats IR nodes as GraphQL SDL and operations
+type GraphQLFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewGraphQLFormatter creates a new GraphQL formatter
+func NewGraphQLFormatter() *GraphQLFormatter {
+	return &GraphQLFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("graphql"),
+	}
+}
+
+// FormatNode formats an IR node as GraphQL code
+func (f *GraphQLFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledComment:
+		f.formatComment(w, n, indentStr)
+	case *ir.DistilledStruct:
+		return f.formatType(w, n.Name, n.Extensions, n.Children, indent)
+	case *ir.DistilledInterface:
+		return f.formatType(w, n.Name, n.Extensions, n.Children, indent)
+	case *ir.DistilledEnum:
+		return f.formatType(w, n.Name, n.Extensions, n.Children, indent)
+	case *ir.DistilledTypeAlias:
+		f.formatAlias(w, n, indentStr)
+	case *ir.DistilledFunction:
+		f.formatFunction(w, n, indentStr)
+	case *ir.DistilledField:
+		f.formatField(w, n, indentStr)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+// formatComment writes # comments as comments and doc comments as
+// descriptions
+func (f *GraphQLFormatter) formatComment(w io.Writer, comment *ir.DistilledComment, indent string) {
+	if comment.Format != "doc" {
+		for _, line := range strings.Split(comment.Text, "\n") {
+			fmt.Fprintf(w, "%s# %s\n", indent, line)
+		}
+		return
+	}
+
+	if !strings.Contains(comment.Text, "\n") && !strings.Contains(comment.Text, `"`) {
+		fmt.Fprintf(w, "%s\"%s\"\n", indent, comment.Text)
+		return
+	}
+	fmt.Fprintf(w, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(strings.ReplaceAll(comment.Text, `"""`, `\"""`), "\n") {
+		if line == "" {
+			fmt.Fprintln(w)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(w, "%s\"\"\"\n", indent)
+}
+
+// formatType writes schema, type, input, interface and enum definitions
+func (f *GraphQLFormatter) formatType(w io.Writer, name string, extensions *ir.NodeExtensions, children []ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	ext := graphqlExtensions(extensions)
+
+	header := ext.Kind + " " + name
+	if ext.Kind == "schema" {
+		header = "schema"
+	}
+	if ext.IsExtend {
+		header = "extend " + header
+	}
+	if len(ext.Implements) > 0 {
+		header += " implements " + strings.Join(ext.Implements, " & ")
+	}
+	header += graphqlDirectives(ext.Directives)
+
+	if len(children) == 0 {
+		fmt.Fprintf(w, "%s%s\n", indentStr, header)
+		return nil
+	}
+	fmt.Fprintf(w, "%s%s {\n", indentStr, header)
+	for _, child := range children {
+		if err := f.FormatNode(w, child, indent+1); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+func (f *GraphQLFormatter) formatAlias(w io.Writer, alias *ir.DistilledTypeAlias, indent string) {
+	ext := graphqlExtensions(alias.Extensions)
+
+	line := ext.Kind + " " + alias.Name + graphqlDirectives(ext.Directives)
+	if ext.IsExtend {
+		line = "extend " + line
+	}
+	if alias.Type.Name != "" {
+		line += " = " + alias.Type.Name
+	}
+	fmt.Fprintf(w, "%s%s\n", indent, line)
+}
+
+// formatFunction writes fields with arguments, directive definitions,
+// operations and fragments
+func (f *GraphQLFormatter) formatFunction(w io.Writer, fn *ir.DistilledFunction, indent string) {
+	ext := graphqlExtensions(fn.Extensions)
+
+	var line string
+	switch ext.Kind {
+	case "directive":
+		line = "directive @" + fn.Name + graphqlArguments(fn.Parameters, "")
+		if ext.Repeatable {
+			line += " repeatable"
+		}
+		line += " on " + strings.Join(ext.Locations, " | ")
+	case "fragment":
+		line = "fragment " + fn.Name + " on " + ext.TypeCondition + graphqlDirectives(fn.Decorators)
+	case "query", "mutation", "subscription":
+		line = ext.Kind
+		if fn.Name != "" {
+			line += " " + fn.Name
+		}
+		line += graphqlArguments(fn.Parameters, "$") + graphqlDirectives(fn.Decorators)
+	default:
+		line = fn.Name + graphqlArguments(fn.Parameters, "")
+		if fn.Returns != nil {
+			line += ": " + fn.Returns.Name
+		}
+		line += graphqlDirectives(fn.Decorators)
+	}
+
+	if fn.Implementation != "" {
+		line += " " + fn.Implementation
+	}
+	fmt.Fprintf(w, "%s%s\n", indent, line)
+}
+
+func (f *GraphQLFormatter) formatField(w io.Writer, field *ir.DistilledField, indent string) {
+	line := field.Name
+	if field.Type != nil {
+		line += ": " + field.Type.Name
+	}
+	if field.DefaultValue != "" {
+		line += " = " + field.DefaultValue
+	}
+	fmt.Fprintf(w, "%s%s%s\n", indent, line, graphqlDirectives(field.Decorators))
+}
+
+// graphqlExtensions returns the GraphQL extensions of a node, or empty
+// extensions if it has none
+func graphqlExtensions(ext *ir.NodeExtensions) *ir.GraphQLExtensions {
+	if ext == nil || ext.GraphQL == nil {
+		return &ir.GraphQLExtensions{}
+	}
+	return ext.GraphQL
+}
+
+func graphqlArguments(params []ir.Parameter, prefix string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	args := make([]string, len(params))
+	for i, param := range params {
+		args[i] = prefix + param.Name + ": " + param.Type.Name
+		if param.DefaultValue != "" {
+			args[i] += " = " + param.DefaultValue
+		}
+		args[i] += graphqlDirectives(param.Decorators)
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+func graphqlDirectives(directives []string) string {
+	if len(directives) == 0 {
+		return ""
+	}
+	return " " + strings.Join(directives, " ")
+}
@@ -0,0 +1,186 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// HCLFormatter formats IR nodes as Terraform and HCL configuration
+type HCLFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewHCLFormatter creates a new HCL formatter
+func NewHCLFormatter() *HCLFormatter {
+	return &HCLFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("hcl"),
+	}
+}
+
+// FormatNode formats an IR node as HCL
+func (f *HCLFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledComment:
+		for _, line := range strings.Split(n.Text, "\n") {
+			line = strings.TrimRight(line, " \t\r")
+			if n.Format == "doc" {
+				// Descriptions of variables and outputs
+				line = strings.TrimRight("# "+line, " ")
+			}
+			fmt.Fprintf(w, "%s%s\n", indentStr, line)
+		}
+	case *ir.DistilledImport:
+		fmt.Fprintf(w, "%s%s\n", indentStr, hclRequiredProvider(n))
+	case *ir.DistilledClass:
+		return f.formatClass(w, n, indent)
+	case *ir.DistilledFunction:
+		f.formatBlock(w, n, indentStr)
+	case *ir.DistilledField:
+		f.formatField(w, n, indentStr)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+// formatClass writes the terraform settings block. Consecutive required
+// providers are grouped into a required_providers block.
+func (f *HCLFormatter) formatClass(w io.Writer, class *ir.DistilledClass, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	fmt.Fprintf(w, "%s%s {\n", indentStr, class.Name)
+	inProviders := false
+	for _, child := range class.Children {
+		provider, ok := child.(*ir.DistilledImport)
+		if ok != inProviders {
+			if ok {
+				fmt.Fprintf(w, "%s    required_providers {\n", indentStr)
+			} else {
+				fmt.Fprintf(w, "%s    }\n", indentStr)
+			}
+			inProviders = ok
+		}
+		if ok {
+			fmt.Fprintf(w, "%s        %s\n", indentStr, hclRequiredProvider(provider))
+			continue
+		}
+		if err := f.FormatNode(w, child, indent+1); err != nil {
+			return err
+		}
+	}
+	if inProviders {
+		fmt.Fprintf(w, "%s    }\n", indentStr)
+	}
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+// formatBlock writes a resource, data source, module, provider or other
+// block with its body, or with its main arguments when the body is left out
+func (f *HCLFormatter) formatBlock(w io.Writer, fn *ir.DistilledFunction, indent string) {
+	ext := hclExtensions(fn.Extensions)
+	header := hclBlockHeader(ext.Kind, ext.Labels)
+
+	switch {
+	case fn.Implementation != "":
+		fmt.Fprintf(w, "%s%s %s\n", indent, header, hclReindent(fn.Implementation, indent))
+	case len(ext.Arguments) > 0:
+		fmt.Fprintf(w, "%s%s { %s }\n", indent, header, strings.Join(ext.Arguments, ", "))
+	default:
+		fmt.Fprintf(w, "%s%s\n", indent, header)
+	}
+}
+
+// formatField writes a variable, output, local value or attribute
+func (f *HCLFormatter) formatField(w io.Writer, field *ir.DistilledField, indent string) {
+	ext := hclExtensions(field.Extensions)
+
+	var arguments []string
+	switch ext.Kind {
+	case "variable":
+		if field.Type != nil {
+			arguments = append(arguments, "type = "+field.Type.Name)
+		}
+		if field.DefaultValue != "" {
+			arguments = append(arguments, "default = "+field.DefaultValue)
+		}
+	case "output":
+		if field.DefaultValue != "" {
+			arguments = append(arguments, "value = "+field.DefaultValue)
+		}
+	case "local":
+		fmt.Fprintf(w, "%slocal.%s = %s\n", indent, field.Name, field.DefaultValue)
+		return
+	default:
+		fmt.Fprintf(w, "%s%s = %s\n", indent, field.Name, field.DefaultValue)
+		return
+	}
+
+	if ext.Sensitive {
+		arguments = append(arguments, "sensitive = true")
+	}
+	header := hclBlockHeader(ext.Kind, ext.Labels)
+	if len(arguments) == 0 {
+		fmt.Fprintf(w, "%s%s {}\n", indent, header)
+		return
+	}
+	fmt.Fprintf(w, "%s%s { %s }\n", indent, header, strings.Join(arguments, ", "))
+}
+
+// hclReindent indents the lines of a block body after its opening brace,
+// relative to the line of its closing brace
+func hclReindent(body, indent string) string {
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	last := lines[len(lines)-1]
+	base := last[:len(last)-len(strings.TrimLeft(last, " \t"))]
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = indent + strings.TrimPrefix(lines[i], base)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hclBlockHeader returns the type and quoted labels of a block, e.g.
+// resource "aws_instance" "web"
+func hclBlockHeader(kind string, labels []string) string {
+	header := kind
+	for _, label := range labels {
+		header += " " + strconv.Quote(label)
+	}
+	return header
+}
+
+// hclRequiredProvider returns an entry of a required_providers block
+func hclRequiredProvider(imp *ir.DistilledImport) string {
+	name := imp.Module
+	if len(imp.Symbols) > 0 {
+		name = imp.Symbols[0].Name
+	}
+	var arguments []string
+	// Legacy entries have a version but no source
+	if imp.Module != name {
+		arguments = append(arguments, "source = "+strconv.Quote(imp.Module))
+	}
+	if version := hclExtensions(imp.Extensions).Version; version != "" {
+		arguments = append(arguments, "version = "+strconv.Quote(version))
+	}
+	return fmt.Sprintf("%s = { %s }", name, strings.Join(arguments, ", "))
+}
+
+// hclExtensions returns the HCL extensions of a node, or empty extensions if
+// it has none
+func hclExtensions(ext *ir.NodeExtensions) *ir.HCLExtensions {
+	if ext == nil || ext.HCL == nil {
+		return &ir.HCLExtensions{}
+	}
+	return ext.HCL
+}
@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"io"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// IRFormatter writes the IR itself as a versioned JSON document that can be
+// read back with ir.DecodeDocument
+type IRFormatter struct {
+	BaseFormatter
+}
+
+// NewIRFormatter creates a new IR JSON formatter
+func NewIRFormatter(options Options) *IRFormatter {
+	return &IRFormatter{
+		BaseFormatter: NewBaseFormatter(options),
+	}
+}
+
+// Extension returns the file extension for IR JSON
+func (f *IRFormatter) Extension() string {
+	return ".json"
+}
+
+// Format writes a single file as an IR document
+func (f *IRFormatter) Format(w io.Writer, file *ir.DistilledFile) error {
+	return ir.EncodeDocument(w, file, !f.options.Compact)
+}
+
+// FormatMultiple writes multiple files as an IR document with a directory root
+func (f *IRFormatter) FormatMultiple(w io.Writer, files []*ir.DistilledFile) error {
+	root := &ir.DistilledDirectory{Children: make([]ir.DistilledNode, len(files))}
+	for i, file := range files {
+		root.Children[i] = file
+	}
+	return ir.EncodeDocument(w, root, !f.options.Compact)
+}
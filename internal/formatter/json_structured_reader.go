@@ -0,0 +1,210 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// structuredDocument is a json-structured document: a single file or a
+// project with several files
+type structuredDocument struct {
+	Type  string           `json:"type"`
+	Files []structuredFile `json:"files"`
+	structuredFile
+}
+
+type structuredFile struct {
+	Path      string              `json:"path"`
+	Language  string              `json:"language"`
+	Version   string              `json:"version"`
+	Structure structuredStructure `json:"structure"`
+}
+
+type structuredStructure struct {
+	Packages   []structuredDecl `json:"packages"`
+	Imports    []structuredDecl `json:"imports"`
+	Classes    []structuredDecl `json:"classes"`
+	Interfaces []structuredDecl `json:"interfaces"`
+	Functions  []structuredDecl `json:"functions"`
+	Variables  []structuredDecl `json:"variables"`
+	Types      []structuredDecl `json:"types"`
+}
+
+type structuredDecl struct {
+	Name       string                `json:"name"`
+	Visibility ir.Visibility         `json:"visibility"`
+	Modifiers  []ir.Modifier         `json:"modifiers"`
+	Extends    []string              `json:"extends"`
+	Implements []string              `json:"implements"`
+	Members    *structuredMembers    `json:"members"`
+	Parameters []structuredParameter `json:"parameters"`
+	Returns    string                `json:"returns"`
+	Type       string                `json:"type"`
+	Default    string                `json:"default"`
+	Module     string                `json:"module"`
+	Symbols    []map[string]string   `json:"symbols"`
+	Location   *structuredLocation   `json:"location"`
+}
+
+type structuredMembers struct {
+	Fields  []structuredDecl `json:"fields"`
+	Methods []structuredDecl `json:"methods"`
+}
+
+type structuredParameter struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Optional bool   `json:"optional"`
+	Variadic bool   `json:"variadic"`
+}
+
+type structuredLocation struct {
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// ReadJSONStructured reads output of the json-structured formatter back into
+// IR files. The format is lossy: implementations, comments and details such
+// as type parameters or decorators are not part of it.
+func ReadJSONStructured(r io.Reader) ([]*ir.DistilledFile, error) {
+	var doc structuredDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid json-structured document: %w", err)
+	}
+
+	switch doc.Type {
+	case "project":
+		files := make([]*ir.DistilledFile, len(doc.Files))
+		for i, file := range doc.Files {
+			files[i] = file.toIR()
+		}
+		return files, nil
+	case "file":
+		return []*ir.DistilledFile{doc.structuredFile.toIR()}, nil
+	default:
+		return nil, fmt.Errorf("invalid json-structured document: unknown type %q", doc.Type)
+	}
+}
+
+func (f structuredFile) toIR() *ir.DistilledFile {
+	file := &ir.DistilledFile{Path: f.Path, Language: f.Language, Version: f.Version}
+	s := f.Structure
+
+	for _, d := range s.Packages {
+		file.Children = append(file.Children, &ir.DistilledPackage{BaseNode: d.base(), Name: d.Name})
+	}
+	for _, d := range s.Imports {
+		imp := &ir.DistilledImport{BaseNode: d.base(), ImportType: d.Type, Module: d.Module}
+		for _, symbol := range d.Symbols {
+			imp.Symbols = append(imp.Symbols, ir.ImportedSymbol{Name: symbol["name"], Alias: symbol["alias"]})
+		}
+		file.Children = append(file.Children, imp)
+	}
+	for _, d := range s.Classes {
+		file.Children = append(file.Children, &ir.DistilledClass{
+			BaseNode:   d.base(),
+			Name:       d.Name,
+			Visibility: d.Visibility,
+			Modifiers:  d.Modifiers,
+			Extends:    structuredTypeRefs(d.Extends),
+			Implements: structuredTypeRefs(d.Implements),
+			Children:   d.members(),
+		})
+	}
+	for _, d := range s.Interfaces {
+		file.Children = append(file.Children, &ir.DistilledInterface{
+			BaseNode:   d.base(),
+			Name:       d.Name,
+			Visibility: d.Visibility,
+			Extends:    structuredTypeRefs(d.Extends),
+			Children:   d.members(),
+		})
+	}
+	for _, d := range s.Functions {
+		file.Children = append(file.Children, d.function())
+	}
+	for _, d := range s.Variables {
+		file.Children = append(file.Children, d.field())
+	}
+	for _, d := range s.Types {
+		file.Children = append(file.Children, &ir.DistilledTypeAlias{
+			BaseNode:   d.base(),
+			Name:       d.Name,
+			Visibility: d.Visibility,
+			Type:       ir.TypeRef{Name: d.Type},
+		})
+	}
+	return file
+}
+
+func (d structuredDecl) base() ir.BaseNode {
+	if d.Location == nil {
+		return ir.BaseNode{}
+	}
+	return ir.BaseNode{Location: ir.Location{StartLine: d.Location.StartLine, EndLine: d.Location.EndLine}}
+}
+
+func (d structuredDecl) members() []ir.DistilledNode {
+	if d.Members == nil {
+		return nil
+	}
+	var members []ir.DistilledNode
+	for _, field := range d.Members.Fields {
+		members = append(members, field.field())
+	}
+	for _, method := range d.Members.Methods {
+		members = append(members, method.function())
+	}
+	return members
+}
+
+func (d structuredDecl) function() *ir.DistilledFunction {
+	fn := &ir.DistilledFunction{
+		BaseNode:   d.base(),
+		Name:       d.Name,
+		Visibility: d.Visibility,
+		Modifiers:  d.Modifiers,
+	}
+	for _, p := range d.Parameters {
+		fn.Parameters = append(fn.Parameters, ir.Parameter{
+			Name:         p.Name,
+			Type:         ir.TypeRef{Name: p.Type},
+			DefaultValue: p.Default,
+			IsOptional:   p.Optional,
+			IsVariadic:   p.Variadic,
+		})
+	}
+	if d.Returns != "" {
+		fn.Returns = &ir.TypeRef{Name: d.Returns}
+	}
+	return fn
+}
+
+func (d structuredDecl) field() *ir.DistilledField {
+	field := &ir.DistilledField{
+		BaseNode:     d.base(),
+		Name:         d.Name,
+		Visibility:   d.Visibility,
+		Modifiers:    d.Modifiers,
+		DefaultValue: d.Default,
+	}
+	if d.Type != "" {
+		field.Type = &ir.TypeRef{Name: d.Type}
+	}
+	return field
+}
+
+func structuredTypeRefs(names []string) []ir.TypeRef {
+	if len(names) == 0 {
+		return nil
+	}
+	refs := make([]ir.TypeRef, len(names))
+	for i, name := range names {
+		refs[i] = ir.TypeRef{Name: name}
+	}
+	return refs
+}
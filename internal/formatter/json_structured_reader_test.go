@@ -0,0 +1,97 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadJSONStructured_RoundTrip(t *testing.T) {
+	files := []*ir.DistilledFile{
+		{
+			Path:     "service.py",
+			Language: "python",
+			Version:  "3",
+			Children: []ir.DistilledNode{
+				&ir.DistilledImport{ImportType: "from", Module: "typing", Symbols: []ir.ImportedSymbol{{Name: "List", Alias: "L"}}},
+				&ir.DistilledClass{
+					Name:       "UserService",
+					Visibility: ir.VisibilityPublic,
+					Extends:    []ir.TypeRef{{Name: "Base"}},
+					Children: []ir.DistilledNode{
+						&ir.DistilledField{Name: "count", Visibility: ir.VisibilityPrivate, Type: &ir.TypeRef{Name: "int"}, DefaultValue: "0"},
+						&ir.DistilledFunction{
+							Name:       "get",
+							Visibility: ir.VisibilityPublic,
+							Modifiers:  []ir.Modifier{ir.ModifierAsync},
+							Parameters: []ir.Parameter{
+								{Name: "id", Type: ir.TypeRef{Name: "int"}},
+								{Name: "deep", Type: ir.TypeRef{Name: "bool"}, DefaultValue: "False"},
+								{Name: "args", IsVariadic: true},
+							},
+							Returns: &ir.TypeRef{Name: "dict"},
+						},
+					},
+				},
+				&ir.DistilledFunction{Name: "helper", Visibility: ir.VisibilityPublic},
+			},
+		},
+		{
+			Path:     "types.ts",
+			Language: "typescript",
+			Children: []ir.DistilledNode{
+				&ir.DistilledInterface{Name: "Store", Visibility: ir.VisibilityPublic, Extends: []ir.TypeRef{{Name: "Closer"}}},
+				&ir.DistilledTypeAlias{Name: "ID", Visibility: ir.VisibilityPublic, Type: ir.TypeRef{Name: "string"}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewJSONStructuredFormatter(Options{}).FormatMultiple(&buf, files))
+
+	read, err := ReadJSONStructured(&buf)
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+
+	assert.Equal(t, "service.py", read[0].Path)
+	assert.Equal(t, "python", read[0].Language)
+	require.Len(t, read[0].Children, 3)
+
+	imp := read[0].Children[0].(*ir.DistilledImport)
+	assert.Equal(t, "typing", imp.Module)
+	assert.Equal(t, []ir.ImportedSymbol{{Name: "List", Alias: "L"}}, imp.Symbols)
+
+	class := read[0].Children[1].(*ir.DistilledClass)
+	assert.Equal(t, "UserService", class.Name)
+	assert.Equal(t, []ir.TypeRef{{Name: "Base"}}, class.Extends)
+	require.Len(t, class.Children, 2)
+	assert.Equal(t, files[0].Children[1].(*ir.DistilledClass).Children[0], class.Children[0])
+	assert.Equal(t, files[0].Children[1].(*ir.DistilledClass).Children[1], class.Children[1])
+
+	assert.Equal(t, files[1].Children, read[1].Children)
+}
+
+func TestReadJSONStructured_SingleFile(t *testing.T) {
+	var buf bytes.Buffer
+	file := &ir.DistilledFile{Path: "a.go", Language: "go", Children: []ir.DistilledNode{
+		&ir.DistilledFunction{Name: "New", Visibility: ir.VisibilityPublic},
+	}}
+	require.NoError(t, NewJSONStructuredFormatter(Options{}).Format(&buf, file))
+
+	read, err := ReadJSONStructured(&buf)
+	require.NoError(t, err)
+	require.Len(t, read, 1)
+	assert.Equal(t, file.Children, read[0].Children)
+}
+
+func TestReadJSONStructured_Invalid(t *testing.T) {
+	_, err := ReadJSONStructured(strings.NewReader(`{"type": "something"}`))
+	assert.ErrorContains(t, err, "unknown type")
+
+	_, err = ReadJSONStructured(strings.NewReader(`not json`))
+	assert.Error(t, err)
+}
@@ -36,8 +36,20 @@ func NewLanguageAwareTextFormatter(options Options) *LanguageAwareTextFormatter
 	f.RegisterLanguageFormatter("c#", NewCSharpFormatter()) // Alias
 	f.RegisterLanguageFormatter("kotlin", NewKotlinFormatter())
 	f.RegisterLanguageFormatter("cpp", NewCppFormatter())
+	f.RegisterLanguageFormatter("c", NewCFormatter())
 	f.RegisterLanguageFormatter("c++", NewCppFormatter()) // Alias
 	f.RegisterLanguageFormatter("php", NewPHPFormatter())
+	f.RegisterLanguageFormatter("protobuf", NewProtobufFormatter())
+	f.RegisterLanguageFormatter("graphql", NewGraphQLFormatter())
+	f.RegisterLanguageFormatter("sql", NewSQLFormatter())
+	f.RegisterLanguageFormatter("openapi", NewOpenAPIFormatter())
+	f.RegisterLanguageFormatter("hcl", NewHCLFormatter())
+	f.RegisterLanguageFormatter("scala", NewScalaFormatter())
+	f.RegisterLanguageFormatter("dart", NewDartFormatter())
+	f.RegisterLanguageFormatter("shell", NewShellFormatter())
+	f.RegisterLanguageFormatter("vue", NewComponentFormatter("vue"))
+	f.RegisterLanguageFormatter("svelte", NewComponentFormatter("svelte"))
+	f.RegisterLanguageFormatter("astro", NewComponentFormatter("astro"))
 
 	return f
 }
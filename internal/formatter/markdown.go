@@ -159,7 +159,7 @@ func (f *MarkdownFormatter) getLanguageFromPath(path string) string {
 	}
 	
 	switch ext {
-	case "py":
+	case "py", "ipynb":
 		return "python"
 	case "go":
 		return "go"
@@ -171,6 +171,8 @@ func (f *MarkdownFormatter) getLanguageFromPath(path string) string {
 		return "java"
 	case "cs":
 		return "csharp"
+	case "c", "h":
+		return "c"
 	case "cpp", "cc", "cxx":
 		return "cpp"
 	case "rb":
@@ -181,6 +183,18 @@ func (f *MarkdownFormatter) getLanguageFromPath(path string) string {
 		return "swift"
 	case "kt", "kts":
 		return "kotlin"
+	case "proto":
+		return "protobuf"
+	case "graphql", "gql", "graphqls":
+		return "graphql"
+	case "sql", "ddl", "pgsql":
+		return "sql"
+	case "tf", "tfvars", "hcl":
+		return "hcl"
+	case "scala", "sc":
+		return "scala"
+	case "sh", "bash", "zsh":
+		return "bash"
 	case "php":
 		return "php"
 	default:
@@ -0,0 +1,141 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// OpenAPIFormatter formats IR nodes of API specifications and JSON Schemas
+// as compact operation signatures and schema declarations
+type OpenAPIFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewOpenAPIFormatter creates a new OpenAPI formatter
+func NewOpenAPIFormatter() *OpenAPIFormatter {
+	return &OpenAPIFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("openapi"),
+	}
+}
+
+// FormatNode formats an IR node as an operation or schema
+func (f *OpenAPIFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledComment:
+		for _, line := range strings.Split(n.Text, "\n") {
+			fmt.Fprintf(w, "%s%s\n", indentStr, strings.TrimRight("# "+line, " "))
+		}
+	case *ir.DistilledFunction:
+		f.formatOperation(w, n, indentStr)
+	case *ir.DistilledStruct:
+		return f.formatSchema(w, n, indent)
+	case *ir.DistilledTypeAlias:
+		fmt.Fprintf(w, "%sschema %s = %s\n", indentStr, n.Name, n.Type.Name)
+	case *ir.DistilledField:
+		f.formatProperty(w, n, indentStr)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+// formatOperation writes an operation as
+// METHOD /path operationId(params) -> responses
+func (f *OpenAPIFormatter) formatOperation(w io.Writer, fn *ir.DistilledFunction, indent string) {
+	ext := openAPIExtensions(fn.Extensions)
+
+	line := ext.Method + " " + ext.Path
+	if ext.Kind == "webhook" {
+		line = "webhook " + ext.Path + " " + ext.Method
+	}
+	if ext.OperationID != "" {
+		line += " " + ext.OperationID
+	}
+
+	params := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		var prefix, suffix []string
+		for _, decorator := range param.Decorators {
+			if strings.HasPrefix(decorator, "@") {
+				suffix = append(suffix, decorator)
+			} else {
+				prefix = append(prefix, decorator)
+			}
+		}
+		text := strings.Join(append(prefix, param.Name), " ")
+		if param.IsOptional {
+			text += "?"
+		}
+		text = strings.TrimSpace(text) + ": " + param.Type.Name
+		if param.DefaultValue != "" {
+			text += " = " + param.DefaultValue
+		}
+		params[i] = strings.Join(append([]string{text}, suffix...), " ")
+	}
+	line += "(" + strings.Join(params, ", ") + ")"
+
+	if fn.Returns != nil {
+		line += " -> " + fn.Returns.Name
+	}
+	for _, decorator := range fn.Decorators {
+		line += " " + decorator
+	}
+	fmt.Fprintf(w, "%s%s\n", indent, line)
+}
+
+// formatSchema writes an object schema with its properties
+func (f *OpenAPIFormatter) formatSchema(w io.Writer, schema *ir.DistilledStruct, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	ext := openAPIExtensions(schema.Extensions)
+
+	header := "schema " + schema.Name
+	if len(ext.AllOf) > 0 {
+		header += " extends " + strings.Join(ext.AllOf, ", ")
+	}
+	if len(schema.Children) == 0 {
+		fmt.Fprintf(w, "%s%s {}\n", indentStr, header)
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s%s {\n", indentStr, header)
+	for _, child := range schema.Children {
+		if err := f.FormatNode(w, child, indent+1); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+// formatProperty writes a property as name?: type = default @constraints,
+// where ? marks a property that is not required
+func (f *OpenAPIFormatter) formatProperty(w io.Writer, field *ir.DistilledField, indent string) {
+	line := field.Name
+	if !openAPIExtensions(field.Extensions).Required {
+		line += "?"
+	}
+	if field.Type != nil {
+		line += ": " + field.Type.Name
+	}
+	if field.DefaultValue != "" {
+		line += " = " + field.DefaultValue
+	}
+	for _, decorator := range field.Decorators {
+		line += " " + decorator
+	}
+	fmt.Fprintf(w, "%s%s\n", indent, line)
+}
+
+// openAPIExtensions returns the OpenAPI extensions of a node, or empty
+// extensions if it has none
+func openAPIExtensions(ext *ir.NodeExtensions) *ir.OpenAPIExtensions {
+	if ext == nil || ext.OpenAPI == nil {
+		return &ir.OpenAPIExtensions{}
+	}
+	return ext.OpenAPI
+}
@@ -0,0 +1,248 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// ProtobufFormatter formats IR nodes as Protocol Buffers definitions
+type ProtobufFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewProtobufFormatter creates a new Protocol Buffers formatter
+func NewProtobufFormatter() *ProtobufFormatter {
+	return &ProtobufFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("protobuf"),
+	}
+}
+
+// FormatNode formats an IR node as Protocol Buffers code
+func (f *ProtobufFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledPackage:
+		fmt.Fprintf(w, "%spackage %s;\n", indentStr, n.Name)
+	case *ir.DistilledImport:
+		fmt.Fprintf(w, "%s%s \"%s\";\n", indentStr, n.ImportType, n.Module)
+	case *ir.DistilledComment:
+		f.formatComment(w, n, indentStr)
+	case *ir.DistilledStruct:
+		return f.formatMessage(w, n, indent)
+	case *ir.DistilledEnum:
+		return f.formatEnum(w, n, indent)
+	case *ir.DistilledInterface:
+		return f.formatService(w, n, indent)
+	case *ir.DistilledFunction:
+		f.formatRPC(w, n, indentStr)
+	case *ir.DistilledField:
+		f.formatField(w, n, indentStr)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+func (f *ProtobufFormatter) formatComment(w io.Writer, comment *ir.DistilledComment, indent string) {
+	for _, line := range strings.Split(comment.Text, "\n") {
+		if line != "" {
+			fmt.Fprintf(w, "%s// %s\n", indent, line)
+		} else {
+			fmt.Fprintf(w, "%s//\n", indent)
+		}
+	}
+}
+
+func (f *ProtobufFormatter) formatMessage(w io.Writer, msg *ir.DistilledStruct, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	ext := protobufExtensions(msg.Extensions)
+
+	switch {
+	case ext.IsExtend:
+		fmt.Fprintf(w, "%sextend %s {\n", indentStr, msg.Name)
+	case ext.IsGroup:
+		fmt.Fprintf(w, "%s%sgroup %s = %s {\n", indentStr, protobufLabel(ext.Label), msg.Name, ext.Number)
+	default:
+		fmt.Fprintf(w, "%smessage %s {\n", indentStr, msg.Name)
+	}
+
+	if err := f.formatBody(w, msg.Children, indent+1); err != nil {
+		return err
+	}
+	f.formatRanges(w, ext, indent+1)
+
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+// formatBody formats the children of a message. Consecutive fields of the
+// same oneof, and the comments between them, are grouped in a oneof block.
+func (f *ProtobufFormatter) formatBody(w io.Writer, children []ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	oneof := ""
+
+	for i, child := range children {
+		name := protobufOneof(child)
+		if _, ok := child.(*ir.DistilledComment); ok {
+			// A comment belongs to the oneof of the node it precedes
+			name = oneof
+			for _, next := range children[i+1:] {
+				if _, ok := next.(*ir.DistilledComment); !ok {
+					name = protobufOneof(next)
+					break
+				}
+			}
+		}
+
+		if name != oneof {
+			if oneof != "" {
+				fmt.Fprintf(w, "%s}\n", indentStr)
+			}
+			if name != "" {
+				fmt.Fprintf(w, "%soneof %s {\n", indentStr, name)
+			}
+			oneof = name
+		}
+
+		childIndent := indent
+		if oneof != "" {
+			childIndent++
+		}
+		if err := f.FormatNode(w, child, childIndent); err != nil {
+			return err
+		}
+	}
+
+	if oneof != "" {
+		fmt.Fprintf(w, "%s}\n", indentStr)
+	}
+	return nil
+}
+
+func (f *ProtobufFormatter) formatRanges(w io.Writer, ext *ir.ProtobufExtensions, indent int) {
+	indentStr := strings.Repeat("    ", indent)
+	if len(ext.Reserved) > 0 {
+		fmt.Fprintf(w, "%sreserved %s;\n", indentStr, strings.Join(ext.Reserved, ", "))
+	}
+	if len(ext.ExtensionRanges) > 0 {
+		fmt.Fprintf(w, "%sextensions %s;\n", indentStr, strings.Join(ext.ExtensionRanges, ", "))
+	}
+}
+
+func (f *ProtobufFormatter) formatEnum(w io.Writer, enum *ir.DistilledEnum, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	fmt.Fprintf(w, "%senum %s {\n", indentStr, enum.Name)
+
+	for _, child := range enum.Children {
+		if err := f.FormatNode(w, child, indent+1); err != nil {
+			return err
+		}
+	}
+	f.formatRanges(w, protobufExtensions(enum.Extensions), indent+1)
+
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+func (f *ProtobufFormatter) formatService(w io.Writer, service *ir.DistilledInterface, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	fmt.Fprintf(w, "%sservice %s {\n", indentStr, service.Name)
+
+	for _, child := range service.Children {
+		if err := f.FormatNode(w, child, indent+1); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "%s}\n", indentStr)
+	return nil
+}
+
+func (f *ProtobufFormatter) formatRPC(w io.Writer, rpc *ir.DistilledFunction, indent string) {
+	ext := protobufExtensions(rpc.Extensions)
+
+	request := ""
+	if len(rpc.Parameters) > 0 {
+		request = rpc.Parameters[0].Type.Name
+	}
+	if ext.ClientStreaming {
+		request = "stream " + request
+	}
+	response := ""
+	if rpc.Returns != nil {
+		response = rpc.Returns.Name
+	}
+	if ext.ServerStreaming {
+		response = "stream " + response
+	}
+
+	fmt.Fprintf(w, "%srpc %s(%s) returns (%s)", indent, rpc.Name, request, response)
+	if len(rpc.Decorators) == 0 {
+		fmt.Fprintln(w, ";")
+		return
+	}
+	fmt.Fprintln(w, " {")
+	for _, option := range rpc.Decorators {
+		fmt.Fprintf(w, "%s    option %s;\n", indent, option)
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+func (f *ProtobufFormatter) formatField(w io.Writer, field *ir.DistilledField, indent string) {
+	ext := protobufExtensions(field.Extensions)
+
+	// Syntax statements, options and enum values have no type
+	if ext.IsSyntax {
+		fmt.Fprintf(w, "%s%s = %s;\n", indent, field.Name, field.DefaultValue)
+		return
+	}
+	if ext.IsOption {
+		fmt.Fprintf(w, "%soption %s = %s;\n", indent, field.Name, field.DefaultValue)
+		return
+	}
+	if field.Type == nil {
+		fmt.Fprintf(w, "%s%s = %s%s;\n", indent, field.Name, field.DefaultValue, protobufOptions(field.Decorators))
+		return
+	}
+
+	typeName := field.Type.Name
+	if len(field.Type.TypeArgs) == 2 {
+		typeName = fmt.Sprintf("%s<%s, %s>", typeName, field.Type.TypeArgs[0].Name, field.Type.TypeArgs[1].Name)
+	}
+	fmt.Fprintf(w, "%s%s%s %s = %s%s;\n", indent, protobufLabel(ext.Label), typeName, field.Name, ext.Number, protobufOptions(field.Decorators))
+}
+
+// protobufExtensions returns the protobuf extensions of a node, or empty
+// extensions if it has none
+func protobufExtensions(ext *ir.NodeExtensions) *ir.ProtobufExtensions {
+	if ext == nil || ext.Protobuf == nil {
+		return &ir.ProtobufExtensions{}
+	}
+	return ext.Protobuf
+}
+
+// protobufOneof returns the oneof a field belongs to
+func protobufOneof(node ir.DistilledNode) string {
+	if field, ok := node.(*ir.DistilledField); ok {
+		return protobufExtensions(field.Extensions).Oneof
+	}
+	return ""
+}
+
+func protobufLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+	return label + " "
+}
+
+func protobufOptions(options []string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(options, ", ") + "]"
+}
@@ -56,6 +56,15 @@ func (f *PythonFormatter) formatComment(w io.Writer, comment *ir.DistilledCommen
 		return nil
 	}
 
+	// Documentation comments, such as the markdown cells of notebooks, may
+	// span several lines
+	if comment.Format == "doc" {
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Fprintln(w, strings.TrimRight(indentStr+"# "+line, " "))
+		}
+		return nil
+	}
+
 	// If it's a // comment from the generic parser
 	if strings.HasPrefix(text, "//") {
 		text = strings.TrimPrefix(text, "//")
@@ -103,4 +103,8 @@ func init() {
 	Register("text", func(opts Options) Formatter {
 		return NewLanguageAwareTextFormatter(opts)
 	})
+
+	Register("ir", func(opts Options) Formatter {
+		return NewIRFormatter(opts)
+	})
 }
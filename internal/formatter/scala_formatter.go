@@ -0,0 +1,452 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// ScalaFormatter formats IR nodes as Scala code
+type ScalaFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewScalaFormatter creates a new Scala formatter
+func NewScalaFormatter() *ScalaFormatter {
+	return &ScalaFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("scala"),
+	}
+}
+
+// FormatNode formats an IR node as Scala code
+func (f *ScalaFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	f.formatNode(w, node, indent, "")
+	return nil
+}
+
+// formatNode writes a node. enum is the name of the enclosing enum, whose
+// constants are written as cases.
+func (f *ScalaFormatter) formatNode(w io.Writer, node ir.DistilledNode, indent int, enum string) {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledPackage:
+		fmt.Fprintf(w, "%spackage %s\n", indentStr, n.Name)
+	case *ir.DistilledImport:
+		fmt.Fprintf(w, "%s%s\n", indentStr, f.formatImport(n))
+	case *ir.DistilledComment:
+		f.formatComment(w, n, indentStr)
+	case *ir.DistilledClass:
+		f.formatClass(w, n, indent, enum)
+	case *ir.DistilledInterface:
+		header := indentStr + f.declarationPrefix(n.Visibility, n.Modifiers, n.Extensions) + "trait " + n.Name + f.formatTypeParams(n.TypeParams)
+		if len(n.Extends) > 0 {
+			header += " extends " + f.joinTypes(n.Extends, " with ")
+		}
+		f.formatBody(w, header, n.Children, indent, "")
+	case *ir.DistilledFunction:
+		fmt.Fprintf(w, "%s\n", f.formatFunction(n, indentStr))
+	case *ir.DistilledField:
+		fmt.Fprintf(w, "%s\n", f.formatField(n, indentStr, enum))
+	case *ir.DistilledTypeAlias:
+		header := f.declarationPrefix(n.Visibility, n.Modifiers, n.Extensions) + "type " + n.Name + f.formatTypeParams(n.TypeParams)
+		switch {
+		case n.Type.Name == "":
+		case strings.HasPrefix(n.Type.Name, "<:"), strings.HasPrefix(n.Type.Name, ">:"):
+			header += " " + n.Type.Name
+		default:
+			header += " = " + n.Type.Name
+		}
+		fmt.Fprintf(w, "%s%s\n", indentStr, header)
+	default:
+		// Skip unknown nodes
+	}
+}
+
+// formatImport returns an import of a path, or of selectors from a path
+func (f *ScalaFormatter) formatImport(imp *ir.DistilledImport) string {
+	if len(imp.Symbols) == 1 && imp.Symbols[0].Alias == "" && strings.HasSuffix(imp.Module, "."+imp.Symbols[0].Name) {
+		return "import " + imp.Module
+	}
+
+	var selectors []string
+	for _, symbol := range imp.Symbols {
+		if symbol.Alias != "" {
+			selectors = append(selectors, symbol.Name+" => "+symbol.Alias)
+		} else {
+			selectors = append(selectors, symbol.Name)
+		}
+	}
+	if len(selectors) == 1 && imp.Symbols[0].Alias == "" {
+		return "import " + imp.Module + "." + selectors[0]
+	}
+	return "import " + imp.Module + ".{" + strings.Join(selectors, ", ") + "}"
+}
+
+// formatComment writes a comment, aligning the lines of block comments
+func (f *ScalaFormatter) formatComment(w io.Writer, comment *ir.DistilledComment, indent string) {
+	for i, line := range strings.Split(comment.Text, "\n") {
+		line = strings.TrimSpace(line)
+		if i > 0 && strings.HasPrefix(line, "*") {
+			line = " " + line
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
+
+// formatClass writes a class, case class, object, enum, case of an enum or
+// given instance
+func (f *ScalaFormatter) formatClass(w io.Writer, class *ir.DistilledClass, indent int, enum string) {
+	indentStr := strings.Repeat("    ", indent)
+	ext := scalaExtensions(class.Extensions)
+	modifiers := class.Modifiers
+
+	keyword := "class"
+	switch {
+	case enum != "" && scalaHasModifier(class.Modifiers, ir.ModifierData):
+		// Cases with parameters are case classes without the class keyword
+		keyword = "case"
+		modifiers = nil
+	case scalaHasModifier(class.Modifiers, ir.ModifierEnum):
+		keyword = "enum"
+	case scalaHasKeyword(ext, "given"):
+		keyword = "given"
+	case scalaHasKeyword(ext, "package"):
+		keyword = "package object"
+	case scalaHasModifier(class.Modifiers, ir.ModifierStatic):
+		keyword = "object"
+	}
+
+	var header string
+	if keyword == "given" {
+		header = f.formatAnnotations(class.Decorators, indentStr) + indentStr + f.declarationPrefix(class.Visibility, nil, nil) + "given " + class.Name + f.formatTypeParams(class.TypeParams)
+		if len(class.Implements) > 0 {
+			header += ": " + f.joinTypes(class.Implements, ", ") + " with"
+		}
+		f.formatBody(w, header, class.Children, indent, "")
+		return
+	}
+
+	header = f.formatAnnotations(class.Decorators, indentStr) + indentStr + f.declarationPrefix(class.Visibility, modifiers, class.Extensions) + keyword + " " + class.Name + f.formatTypeParams(class.TypeParams)
+
+	// The primary constructor and the fields of its parameters are part of
+	// the header
+	var members []ir.DistilledNode
+	parameters := map[string]bool{}
+	for _, child := range class.Children {
+		if fn, ok := child.(*ir.DistilledFunction); ok && scalaIsPrimary(fn) {
+			header += f.formatParameterLists(fn)
+			for _, param := range fn.Parameters {
+				parameters[param.Name] = true
+			}
+			continue
+		}
+		members = append(members, child)
+	}
+	var body []ir.DistilledNode
+	for _, child := range members {
+		if field, ok := child.(*ir.DistilledField); ok && parameters[field.Name] {
+			continue
+		}
+		body = append(body, child)
+	}
+
+	if len(class.Extends) > 0 {
+		header += " extends " + f.joinTypes(append(append([]ir.TypeRef{}, class.Extends...), class.Mixins...), " with ")
+	}
+
+	enum = ""
+	if keyword == "enum" {
+		enum = class.Name
+	}
+	f.formatBody(w, header, body, indent, enum)
+}
+
+// formatBody writes a header followed by its members in braces, or the
+// header alone if there are no members
+func (f *ScalaFormatter) formatBody(w io.Writer, header string, children []ir.DistilledNode, indent int, enum string) {
+	if len(children) == 0 {
+		fmt.Fprintf(w, "%s\n", header)
+		return
+	}
+	fmt.Fprintf(w, "%s {\n", header)
+	for _, child := range children {
+		f.formatNode(w, child, indent+1, enum)
+	}
+	fmt.Fprintf(w, "%s}\n", strings.Repeat("    ", indent))
+}
+
+// formatFunction returns a method, secondary constructor or extension method
+func (f *ScalaFormatter) formatFunction(fn *ir.DistilledFunction, indent string) string {
+	ext := scalaExtensions(fn.Extensions)
+	name := fn.Name
+	var decorators []string
+	extension := false
+	for _, decorator := range fn.Decorators {
+		if decorator == "@Extension" {
+			extension = true
+			continue
+		}
+		decorators = append(decorators, decorator)
+	}
+
+	signature := f.declarationPrefix(fn.Visibility, fn.Modifiers, fn.Extensions)
+	if extension {
+		// The receiver type is part of the name
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		signature = "extension " + ext.Receiver + " " + signature
+	}
+	if name == "constructor" {
+		name = "this"
+	}
+
+	signature += "def " + name + f.formatTypeParams(fn.TypeParams) + f.formatParameterLists(fn)
+	if fn.Returns != nil {
+		signature += ": " + fn.Returns.Name
+	}
+	switch {
+	case strings.HasPrefix(fn.Implementation, "\n"):
+		// Indented block
+		signature += " =" + scalaReindent(fn.Implementation, fn.Location.StartColumn, indent)
+	case fn.Implementation != "":
+		signature += " = " + scalaReindent(fn.Implementation, fn.Location.StartColumn, indent)
+	}
+	return f.formatAnnotations(decorators, indent) + indent + signature
+}
+
+// formatField returns a val or var, an alias given or a case of an enum
+func (f *ScalaFormatter) formatField(field *ir.DistilledField, indent, enum string) string {
+	if enum != "" && field.Type != nil && field.Type.Name == enum && scalaHasModifier(field.Modifiers, ir.ModifierStatic) {
+		return strings.TrimRight(indent+"case "+field.Name+" "+field.DefaultValue, " ")
+	}
+
+	ext := scalaExtensions(field.Extensions)
+	var modifiers []ir.Modifier
+	final := false
+	for _, modifier := range field.Modifiers {
+		if modifier == ir.ModifierFinal && !final {
+			// The first final marks a val
+			final = true
+			continue
+		}
+		modifiers = append(modifiers, modifier)
+	}
+
+	declaration := f.declarationPrefix(field.Visibility, modifiers, field.Extensions)
+	switch {
+	case scalaHasKeyword(ext, "given"):
+	case final:
+		declaration += "val "
+	default:
+		declaration += "var "
+	}
+	declaration += field.Name
+	if field.Type != nil {
+		declaration += ": " + field.Type.Name
+	}
+	if field.DefaultValue != "" {
+		declaration += " = " + field.DefaultValue
+	}
+	return f.formatAnnotations(field.Decorators, indent) + indent + declaration
+}
+
+// formatParameterLists returns the parameter lists of a method or
+// constructor, with the using or implicit keyword of context parameters
+func (f *ScalaFormatter) formatParameterLists(fn *ir.DistilledFunction) string {
+	sizes := scalaExtensions(fn.Extensions).ParameterLists
+	if sizes == nil {
+		if len(fn.Parameters) == 0 {
+			return ""
+		}
+		sizes = []int{len(fn.Parameters)}
+	}
+
+	var result strings.Builder
+	next := 0
+	for _, size := range sizes {
+		var params []string
+		for _, param := range fn.Parameters[next:min(next+size, len(fn.Parameters))] {
+			params = append(params, f.formatParameter(param, len(params) == 0))
+		}
+		next += size
+		result.WriteString("(" + strings.Join(params, ", ") + ")")
+	}
+	return result.String()
+}
+
+// formatParameter returns a parameter. The using or implicit keyword of
+// its list is written before the first parameter only.
+func (f *ScalaFormatter) formatParameter(param ir.Parameter, first bool) string {
+	var parts []string
+	for _, decorator := range param.Decorators {
+		if (decorator == "using" || decorator == "implicit") && !first {
+			continue
+		}
+		parts = append(parts, decorator)
+	}
+	declaration := param.Name
+	if param.Type.Name != "" {
+		declaration += ": " + param.Type.Name
+		if param.IsVariadic {
+			declaration += "*"
+		}
+	}
+	if param.DefaultValue != "" {
+		declaration += " = " + param.DefaultValue
+	}
+	return strings.Join(append(parts, declaration), " ")
+}
+
+// formatAnnotations returns the annotations of a declaration, each on its
+// own line
+func (f *ScalaFormatter) formatAnnotations(decorators []string, indent string) string {
+	var result strings.Builder
+	for _, decorator := range decorators {
+		if decorator != "@Primary" {
+			result.WriteString(indent + decorator + "\n")
+		}
+	}
+	return result.String()
+}
+
+// declarationPrefix returns the access modifier and modifiers of a
+// declaration, followed by a space
+func (f *ScalaFormatter) declarationPrefix(visibility ir.Visibility, modifiers []ir.Modifier, extensions *ir.NodeExtensions) string {
+	ext := scalaExtensions(extensions)
+	var parts []string
+
+	qualifier := ""
+	if ext.Qualifier != "" {
+		qualifier = "[" + ext.Qualifier + "]"
+	}
+	switch visibility {
+	case ir.VisibilityPrivate, ir.VisibilityInternal:
+		parts = append(parts, "private"+qualifier)
+	case ir.VisibilityProtected:
+		parts = append(parts, "protected"+qualifier)
+	}
+
+	for _, modifier := range modifiers {
+		switch modifier {
+		case ir.ModifierAbstract:
+			parts = append(parts, "abstract")
+		case ir.ModifierFinal:
+			parts = append(parts, "final")
+		case ir.ModifierSealed:
+			parts = append(parts, "sealed")
+		case ir.ModifierOverride:
+			parts = append(parts, "override")
+		case ir.ModifierInline:
+			parts = append(parts, "inline")
+		case ir.ModifierData:
+			parts = append(parts, "case")
+		}
+	}
+	for _, keyword := range ext.Keywords {
+		if keyword != "package" {
+			parts = append(parts, keyword)
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " ") + " "
+}
+
+// formatTypeParams returns type parameters in brackets with their upper
+// bounds
+func (f *ScalaFormatter) formatTypeParams(params []ir.TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var result []string
+	for _, param := range params {
+		// Upper bounds go before context bounds, e.g. T <: Number : Ordering
+		name, context := param.Name, ""
+		for i := 1; i < len(name); i++ {
+			if name[i] == ':' && name[i-1] != '<' && name[i-1] != '>' {
+				name, context = name[:i], name[i:]
+				break
+			}
+		}
+		for _, constraint := range param.Constraints {
+			name += " <: " + constraint.Name
+		}
+		if context != "" && len(param.Constraints) > 0 {
+			context = " " + context
+		}
+		result = append(result, name+context)
+	}
+	return "[" + strings.Join(result, ", ") + "]"
+}
+
+// joinTypes returns the names of types joined by a separator
+func (f *ScalaFormatter) joinTypes(types []ir.TypeRef, separator string) string {
+	var names []string
+	for _, typ := range types {
+		names = append(names, typ.Name)
+	}
+	return strings.Join(names, separator)
+}
+
+// scalaReindent indents the continuation lines of a body, which start at
+// the column of their declaration in the source
+func scalaReindent(body string, column int, indent string) string {
+	base := max(column-1, 0)
+	lines := strings.Split(body, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			lines[i] = ""
+			continue
+		}
+		cut := min(base, len(line)-len(trimmed))
+		lines[i] = indent + line[cut:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scalaIsPrimary reports whether a function is a primary constructor
+func scalaIsPrimary(fn *ir.DistilledFunction) bool {
+	for _, decorator := range fn.Decorators {
+		if decorator == "@Primary" {
+			return true
+		}
+	}
+	return false
+}
+
+// scalaHasKeyword reports whether a declaration has a keyword
+func scalaHasKeyword(ext *ir.ScalaExtensions, keyword string) bool {
+	for _, k := range ext.Keywords {
+		if k == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// scalaExtensions returns the Scala extensions of a node, or empty
+// extensions if it has none
+func scalaExtensions(ext *ir.NodeExtensions) *ir.ScalaExtensions {
+	if ext == nil || ext.Scala == nil {
+		return &ir.ScalaExtensions{}
+	}
+	return ext.Scala
+}
+
+// scalaHasModifier reports whether a list of modifiers has a modifier
+func scalaHasModifier(modifiers []ir.Modifier, modifier ir.Modifier) bool {
+	for _, m := range modifiers {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
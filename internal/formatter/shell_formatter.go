@@ -0,0 +1,72 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// ShellFormatter formats IR nodes as shell script code
+type ShellFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewShellFormatter creates a new shell formatter
+func NewShellFormatter() *ShellFormatter {
+	return &ShellFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("shell"),
+	}
+}
+
+// FormatNode formats an IR node as shell script code
+func (f *ShellFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledImport:
+		fmt.Fprintf(w, "%s%s %s\n", indentStr, n.ImportType, n.Module)
+	case *ir.DistilledComment:
+		for _, line := range strings.Split(n.Text, "\n") {
+			fmt.Fprintf(w, "%s%s\n", indentStr, strings.TrimSpace(line))
+		}
+	case *ir.DistilledFunction:
+		// Functions without their body are shown as name()
+		fmt.Fprintf(w, "%s%s()", indentStr, n.Name)
+		if n.Implementation != "" {
+			fmt.Fprintf(w, " %s", shellReindent(n.Implementation, n.Location.StartColumn, indentStr))
+		}
+		fmt.Fprintln(w)
+	case *ir.DistilledField:
+		declaration := n.Name
+		if n.DefaultValue != "" {
+			declaration += "=" + n.DefaultValue
+		}
+		if n.Extensions != nil && n.Extensions.Shell != nil && n.Extensions.Shell.Declaration != "" {
+			declaration = n.Extensions.Shell.Declaration + " " + declaration
+		}
+		fmt.Fprintf(w, "%s%s\n", indentStr, declaration)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+// shellReindent indents the continuation lines of a function body, which
+// start at the column of the function in the source
+func shellReindent(body string, column int, indent string) string {
+	base := max(column-1, 0)
+	lines := strings.Split(body, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			lines[i] = ""
+			continue
+		}
+		cut := min(base, len(line)-len(trimmed))
+		lines[i] = indent + line[cut:]
+	}
+	return strings.Join(lines, "\n")
+}
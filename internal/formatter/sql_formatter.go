@@ -0,0 +1,243 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// sqlPlainIdent matches identifiers that do not need quotes
+var sqlPlainIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)*$`)
+
+// SQLFormatter formats IR nodes as SQL DDL statements
+type SQLFormatter struct {
+	BaseLanguageFormatter
+}
+
+// NewSQLFormatter creates a new SQL formatter
+func NewSQLFormatter() *SQLFormatter {
+	return &SQLFormatter{
+		BaseLanguageFormatter: NewBaseLanguageFormatter("sql"),
+	}
+}
+
+// FormatNode formats an IR node as SQL
+func (f *SQLFormatter) FormatNode(w io.Writer, node ir.DistilledNode, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch n := node.(type) {
+	case *ir.DistilledComment:
+		f.formatComment(w, n, indentStr)
+	case *ir.DistilledStruct:
+		ext := sqlExtensions(n.Extensions)
+		if ext.Statement != "" {
+			fmt.Fprintf(w, "%s%s;\n", indentStr, ext.Statement)
+			return nil
+		}
+		return f.formatTable(w, n, indent)
+	case *ir.DistilledEnum:
+		f.formatEnum(w, n, indentStr)
+	case *ir.DistilledFunction:
+		f.formatFunction(w, n, indentStr)
+	case *ir.DistilledField:
+		f.formatIndex(w, n, indentStr)
+	default:
+		// Skip unknown nodes
+	}
+	return nil
+}
+
+func (f *SQLFormatter) formatComment(w io.Writer, comment *ir.DistilledComment, indent string) {
+	for _, line := range sqlCommentLines([]ir.DistilledNode{comment}) {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
+
+// formatTable writes tables and composite types. Indexes and triggers of a
+// table follow its definition.
+func (f *SQLFormatter) formatTable(w io.Writer, table *ir.DistilledStruct, indent int) error {
+	indentStr := strings.Repeat("    ", indent)
+	ext := sqlExtensions(table.Extensions)
+
+	header := "CREATE TABLE " + sqlIdent(table.Name)
+	if ext.Kind == "type" {
+		header = "CREATE TYPE " + sqlIdent(table.Name) + " AS"
+	}
+
+	// Each item is a column or constraint with the comments before it
+	var items [][]string
+	var comments []ir.DistilledNode
+	var after []ir.DistilledNode
+	for _, child := range table.Children {
+		if _, ok := child.(*ir.DistilledComment); ok {
+			comments = append(comments, child)
+			continue
+		}
+		if field, ok := child.(*ir.DistilledField); ok && sqlExtensions(field.Extensions).Kind == "" {
+			items = append(items, append(sqlCommentLines(comments), sqlColumn(field)))
+		} else {
+			after = append(append(after, comments...), child)
+		}
+		comments = nil
+	}
+	for _, constraint := range ext.Constraints {
+		items = append(items, []string{constraint})
+	}
+
+	if len(items) == 0 && len(comments) == 0 {
+		fmt.Fprintf(w, "%s%s;\n", indentStr, header)
+	} else {
+		fmt.Fprintf(w, "%s%s (\n", indentStr, header)
+		for i, item := range items {
+			for j, line := range item {
+				if j == len(item)-1 && i < len(items)-1 {
+					line += ","
+				}
+				fmt.Fprintf(w, "%s    %s\n", indentStr, line)
+			}
+		}
+		for _, line := range sqlCommentLines(comments) {
+			fmt.Fprintf(w, "%s    %s\n", indentStr, line)
+		}
+		fmt.Fprintf(w, "%s);\n", indentStr)
+	}
+
+	for _, child := range after {
+		if err := f.FormatNode(w, child, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *SQLFormatter) formatEnum(w io.Writer, enum *ir.DistilledEnum, indent string) {
+	var values []string
+	for _, child := range enum.Children {
+		if value, ok := child.(*ir.DistilledField); ok {
+			values = append(values, "'"+strings.ReplaceAll(value.Name, "'", "''")+"'")
+		}
+	}
+	fmt.Fprintf(w, "%sCREATE TYPE %s AS ENUM (%s);\n", indent, sqlIdent(enum.Name), strings.Join(values, ", "))
+}
+
+// formatFunction writes views, functions, procedures and triggers
+func (f *SQLFormatter) formatFunction(w io.Writer, fn *ir.DistilledFunction, indent string) {
+	ext := sqlExtensions(fn.Extensions)
+
+	var line string
+	switch ext.Kind {
+	case "view", "materialized_view":
+		line = "CREATE VIEW "
+		if ext.Kind == "materialized_view" {
+			line = "CREATE MATERIALIZED VIEW "
+		}
+		line += sqlIdent(fn.Name)
+		if len(fn.Parameters) > 0 {
+			columns := make([]string, len(fn.Parameters))
+			for i, param := range fn.Parameters {
+				columns[i] = param.Name
+				if param.Name != "*" && !strings.HasSuffix(param.Name, ".*") {
+					columns[i] = sqlIdent(param.Name)
+				}
+			}
+			line += " (" + strings.Join(columns, ", ") + ")"
+		}
+	case "trigger":
+		line = "CREATE TRIGGER " + sqlIdent(fn.Name) + " " + ext.Definition
+	default:
+		line = "CREATE FUNCTION "
+		if ext.Kind == "procedure" {
+			line = "CREATE PROCEDURE "
+		}
+		line += sqlIdent(fn.Name) + "(" + sqlParameters(fn.Parameters) + ")"
+		if fn.Returns != nil {
+			line += " RETURNS " + fn.Returns.Name
+		}
+		for _, decorator := range fn.Decorators {
+			line += " " + decorator
+		}
+	}
+
+	if fn.Implementation != "" {
+		line += " " + fn.Implementation
+	}
+	fmt.Fprintf(w, "%s%s;\n", indent, line)
+}
+
+func (f *SQLFormatter) formatIndex(w io.Writer, index *ir.DistilledField, indent string) {
+	ext := sqlExtensions(index.Extensions)
+
+	line := "CREATE INDEX "
+	if ext.Unique {
+		line = "CREATE UNIQUE INDEX "
+	}
+	if index.Name != "" {
+		line += sqlIdent(index.Name) + " "
+	}
+	fmt.Fprintf(w, "%s%s%s;\n", indent, line, ext.Definition)
+}
+
+// sqlExtensions returns the SQL extensions of a node, or empty extensions
+// if it has none
+func sqlExtensions(ext *ir.NodeExtensions) *ir.SQLExtensions {
+	if ext == nil || ext.SQL == nil {
+		return &ir.SQLExtensions{}
+	}
+	return ext.SQL
+}
+
+// sqlCommentLines returns comments as -- lines
+func sqlCommentLines(comments []ir.DistilledNode) []string {
+	var lines []string
+	for _, node := range comments {
+		for _, line := range strings.Split(node.(*ir.DistilledComment).Text, "\n") {
+			lines = append(lines, strings.TrimRight("-- "+line, " "))
+		}
+	}
+	return lines
+}
+
+func sqlColumn(field *ir.DistilledField) string {
+	parts := []string{sqlIdent(field.Name)}
+	if field.Type != nil && field.Type.Name != "" {
+		parts = append(parts, field.Type.Name)
+	}
+	if field.DefaultValue != "" {
+		parts = append(parts, "DEFAULT "+field.DefaultValue)
+	}
+	parts = append(parts, field.Decorators...)
+	return strings.Join(parts, " ")
+}
+
+func sqlParameters(params []ir.Parameter) string {
+	args := make([]string, len(params))
+	for i, param := range params {
+		var parts []string
+		if param.IsVariadic {
+			parts = append(parts, "VARIADIC")
+		}
+		parts = append(parts, param.Decorators...)
+		if param.Name != "" {
+			parts = append(parts, param.Name)
+		}
+		if param.Type.Name != "" {
+			parts = append(parts, param.Type.Name)
+		}
+		if param.DefaultValue != "" {
+			parts = append(parts, "DEFAULT "+param.DefaultValue)
+		}
+		args[i] = strings.Join(parts, " ")
+	}
+	return strings.Join(args, ", ")
+}
+
+// sqlIdent quotes a name that is not a plain identifier
+func sqlIdent(name string) string {
+	if name == "" || sqlPlainIdent.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,376 @@
+package ir
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Schema identifies documents written by EncodeDocument
+const Schema = "ai-distiller/ir"
+
+// SchemaVersion is the version of the IR JSON schema. Increment it when a
+// change would make older readers misinterpret a document.
+const SchemaVersion = 1
+
+// document is the versioned envelope of a serialized IR tree
+type document struct {
+	Schema  string          `json:"schema"`
+	Version int             `json:"version"`
+	Root    json.RawMessage `json:"root"`
+}
+
+// EncodeDocument writes root as a versioned IR document
+func EncodeDocument(w io.Writer, root DistilledNode, indent bool) error {
+	raw, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	if indent {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(&document{Schema: Schema, Version: SchemaVersion, Root: raw})
+}
+
+// DecodeDocument reads an IR document written by EncodeDocument. A bare
+// node with a "kind" field is accepted as well.
+func DecodeDocument(r io.Reader) (DistilledNode, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid IR document: %w", err)
+	}
+	if doc.Schema == "" && doc.Root == nil {
+		root, err := UnmarshalNode(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IR document: %w", err)
+		}
+		return root, nil
+	}
+	if doc.Schema != Schema {
+		return nil, fmt.Errorf("invalid IR document: unknown schema %q", doc.Schema)
+	}
+	if doc.Version < 1 || doc.Version > SchemaVersion {
+		return nil, fmt.Errorf("unsupported IR schema version %d (supported up to %d)", doc.Version, SchemaVersion)
+	}
+
+	root, err := UnmarshalNode(doc.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IR document: %w", err)
+	}
+	return root, nil
+}
+
+// UnmarshalNode decodes a single node, using its "kind" field to pick the
+// concrete type
+func UnmarshalNode(data []byte) (DistilledNode, error) {
+	var header struct {
+		Kind NodeKind `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var node DistilledNode
+	switch header.Kind {
+	case KindFile:
+		node = &DistilledFile{}
+	case KindDirectory:
+		node = &DistilledDirectory{}
+	case KindPackage:
+		node = &DistilledPackage{}
+	case KindImport:
+		node = &DistilledImport{}
+	case KindClass:
+		node = &DistilledClass{}
+	case KindInterface:
+		node = &DistilledInterface{}
+	case KindStruct:
+		node = &DistilledStruct{}
+	case KindEnum:
+		node = &DistilledEnum{}
+	case KindFunction:
+		node = &DistilledFunction{}
+	case KindField:
+		node = &DistilledField{}
+	case KindTypeAlias:
+		node = &DistilledTypeAlias{}
+	case KindComment:
+		node = &DistilledComment{}
+	case KindError:
+		node = &DistilledError{}
+	case KindRawContent:
+		node = &DistilledRawContent{}
+	case "":
+		return nil, fmt.Errorf("node without kind")
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", header.Kind)
+	}
+
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, fmt.Errorf("%s: %w", header.Kind, err)
+	}
+	return node, nil
+}
+
+// unmarshalNodes decodes a list of child nodes
+func unmarshalNodes(raw []json.RawMessage) ([]DistilledNode, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	nodes := make([]DistilledNode, 0, len(raw))
+	for _, data := range raw {
+		if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+			continue
+		}
+		node, err := UnmarshalNode(data)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledFile
+func (n *DistilledFile) UnmarshalJSON(data []byte) error {
+	type Alias DistilledFile
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"nodes"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledDirectory
+func (n *DistilledDirectory) UnmarshalJSON(data []byte) error {
+	type Alias DistilledDirectory
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"children"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler for DistilledPackage
+func (n *DistilledPackage) MarshalJSON() ([]byte, error) {
+	type Alias DistilledPackage
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledPackage
+func (n *DistilledPackage) UnmarshalJSON(data []byte) error {
+	type Alias DistilledPackage
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"children"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler for DistilledImport
+func (n *DistilledImport) MarshalJSON() ([]byte, error) {
+	type Alias DistilledImport
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// MarshalJSON implements json.Marshaler for DistilledClass
+func (n *DistilledClass) MarshalJSON() ([]byte, error) {
+	type Alias DistilledClass
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledClass
+func (n *DistilledClass) UnmarshalJSON(data []byte) error {
+	type Alias DistilledClass
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"children"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler for DistilledInterface
+func (n *DistilledInterface) MarshalJSON() ([]byte, error) {
+	type Alias DistilledInterface
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledInterface
+func (n *DistilledInterface) UnmarshalJSON(data []byte) error {
+	type Alias DistilledInterface
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"children"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler for DistilledStruct
+func (n *DistilledStruct) MarshalJSON() ([]byte, error) {
+	type Alias DistilledStruct
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledStruct
+func (n *DistilledStruct) UnmarshalJSON(data []byte) error {
+	type Alias DistilledStruct
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"children"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler for DistilledEnum
+func (n *DistilledEnum) MarshalJSON() ([]byte, error) {
+	type Alias DistilledEnum
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for DistilledEnum
+func (n *DistilledEnum) UnmarshalJSON(data []byte) error {
+	type Alias DistilledEnum
+	aux := struct {
+		*Alias
+		Children []json.RawMessage `json:"children"`
+	}{Alias: (*Alias)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	n.Children, err = unmarshalNodes(aux.Children)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler for DistilledTypeAlias
+func (n *DistilledTypeAlias) MarshalJSON() ([]byte, error) {
+	type Alias DistilledTypeAlias
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// MarshalJSON implements json.Marshaler for DistilledFunction
+func (n *DistilledFunction) MarshalJSON() ([]byte, error) {
+	type Alias DistilledFunction
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// MarshalJSON implements json.Marshaler for DistilledField
+func (n *DistilledField) MarshalJSON() ([]byte, error) {
+	type Alias DistilledField
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// MarshalJSON implements json.Marshaler for DistilledComment
+func (n *DistilledComment) MarshalJSON() ([]byte, error) {
+	type Alias DistilledComment
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
+
+// MarshalJSON implements json.Marshaler for DistilledRawContent
+func (n *DistilledRawContent) MarshalJSON() ([]byte, error) {
+	type Alias DistilledRawContent
+	return json.Marshal(&struct {
+		Kind string `json:"kind"`
+		*Alias
+	}{
+		Kind:  string(n.GetNodeKind()),
+		Alias: (*Alias)(n),
+	})
+}
@@ -0,0 +1,120 @@
+package ir
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	symbolID := SymbolID("Service_3_1")
+	private := VisibilityPrivate
+
+	file := &DistilledFile{
+		BaseNode: BaseNode{Location: Location{StartLine: 1, EndLine: 40}},
+		Path:     "service.py",
+		Language: "python",
+		Version:  "3",
+		Metadata: &FileMetadata{Size: 1024, Hash: "abc", LastModified: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		Errors:   []DistilledError{{Message: "unexpected token", Severity: "warning", Code: "E1"}},
+		Children: []DistilledNode{
+			&DistilledPackage{Name: "app", Children: []DistilledNode{
+				&DistilledComment{Text: "package docs", Format: "doc"},
+			}},
+			&DistilledImport{ImportType: "from", Module: "typing", Symbols: []ImportedSymbol{{Name: "List", Alias: "L"}}, IsType: true},
+			&DistilledClass{
+				BaseNode:   BaseNode{SymbolID: &symbolID, Extensions: &NodeExtensions{Python: &PythonExtensions{IsDataclass: true}, Attributes: map[string]any{"source": "model"}}},
+				Name:       "Service",
+				Visibility: VisibilityPublic,
+				Modifiers:  []Modifier{ModifierAbstract},
+				Decorators: []string{"dataclass"},
+				TypeParams: []TypeParam{{Name: "T", Constraints: []TypeRef{{Name: "Base"}}, Default: &TypeRef{Name: "object"}}},
+				Extends:    []TypeRef{{Name: "Generic", TypeArgs: []TypeRef{{Name: "T"}}}},
+				Deprecated: &DeprecationInfo{Version: "2.0", Description: "use Client"},
+				Children: []DistilledNode{
+					&DistilledField{Name: "count", Visibility: VisibilityPrivate, Type: &TypeRef{Name: "int"}, DefaultValue: "0",
+						IsProperty: true, HasGetter: true, SetterVisibility: &private},
+					&DistilledFunction{
+						Name:           "get",
+						Visibility:     VisibilityPublic,
+						Modifiers:      []Modifier{ModifierAsync},
+						Parameters:     []Parameter{{Name: "id", Type: TypeRef{Name: "int"}}, {Name: "rest", IsVariadic: true}},
+						Returns:        &TypeRef{Name: "dict", IsNullable: true},
+						Throws:         []TypeRef{{Name: "KeyError"}},
+						ThrowsInfo:     []ThrowsInfo{{Exception: "KeyError", Description: "missing id"}},
+						Implementation: "return self.items[id]",
+					},
+					&DistilledClass{Name: "Config", Visibility: VisibilityPublic},
+				},
+			},
+			&DistilledInterface{Name: "Store", Visibility: VisibilityPublic, Permits: []TypeRef{{Name: "Disk"}}, Children: []DistilledNode{
+				&DistilledFunction{Name: "load", Visibility: VisibilityPublic, Parameters: []Parameter{}},
+			}},
+			&DistilledStruct{Name: "Point", Visibility: VisibilityPublic, Children: []DistilledNode{
+				&DistilledField{Name: "x", Visibility: VisibilityPublic, Type: &TypeRef{Name: "float", IsArray: true, ArrayDims: 2}},
+			}},
+			&DistilledEnum{Name: "Color", Visibility: VisibilityPublic, Type: &TypeRef{Name: "str"}, Children: []DistilledNode{
+				&DistilledField{Name: "RED", Visibility: VisibilityPublic, DefaultValue: "'red'"},
+			}},
+			&DistilledTypeAlias{Name: "ID", Visibility: VisibilityPublic, Type: TypeRef{Name: "str"}},
+			&DistilledRawContent{Content: "raw text"},
+			&DistilledError{Message: "unsupported construct", Severity: "error"},
+		},
+	}
+	root := &DistilledDirectory{Path: "/src", Children: []DistilledNode{file}}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeDocument(&buf, root, true))
+	assert.Contains(t, buf.String(), `"schema": "ai-distiller/ir"`)
+	assert.Contains(t, buf.String(), `"version": 1`)
+
+	decoded, err := DecodeDocument(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, root, decoded)
+
+	// Every node kind carries its discriminator
+	var kinds []NodeKind
+	Walk(decoded, func(node DistilledNode) bool {
+		kinds = append(kinds, node.GetNodeKind())
+		return true
+	})
+	for _, kind := range []NodeKind{KindDirectory, KindFile, KindPackage, KindImport, KindClass, KindInterface,
+		KindStruct, KindEnum, KindFunction, KindField, KindTypeAlias, KindComment, KindError, KindRawContent} {
+		assert.Contains(t, kinds, kind)
+	}
+}
+
+func TestDecodeDocumentBareNode(t *testing.T) {
+	node, err := DecodeDocument(strings.NewReader(`{"kind": "file", "path": "a.go", "language": "go", "nodes": [{"kind": "function", "name": "New"}]}`))
+	require.NoError(t, err)
+
+	file, ok := node.(*DistilledFile)
+	require.True(t, ok)
+	assert.Equal(t, "a.go", file.Path)
+	require.Len(t, file.Children, 1)
+	assert.Equal(t, "New", file.Children[0].(*DistilledFunction).Name)
+}
+
+func TestDecodeDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"newer version", `{"schema": "ai-distiller/ir", "version": 99, "root": {"kind": "file"}}`, "unsupported IR schema version 99"},
+		{"foreign schema", `{"schema": "other", "version": 1, "root": {}}`, `unknown schema "other"`},
+		{"unknown kind", `{"schema": "ai-distiller/ir", "version": 1, "root": {"kind": "file", "nodes": [{"kind": "macro"}]}}`, `unknown node kind "macro"`},
+		{"missing kind", `{"path": "a.go"}`, "node without kind"},
+		{"not json", `not json`, "invalid IR document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeDocument(strings.NewReader(tt.input))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
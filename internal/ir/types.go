@@ -13,6 +13,9 @@ type Location struct {
 	EndColumn   int `json:"end_column"`
 	StartByte   int `json:"start_byte,omitempty"`
 	EndByte     int `json:"end_byte,omitempty"`
+	// Number of the notebook cell, starting at 1, when lines are counted
+	// from the start of a cell
+	Cell int `json:"cell,omitempty"`
 }
 
 // SymbolID uniquely identifies a symbol within a compilation unit
@@ -127,6 +130,11 @@ func (n *BaseNode) GetLocation() Location {
 	return n.Location
 }
 
+// SetLocation sets the source location of a node
+func (n *BaseNode) SetLocation(loc Location) {
+	n.Location = loc
+}
+
 // GetSymbolID implements DistilledNode
 func (n *BaseNode) GetSymbolID() *SymbolID {
 	return n.SymbolID
@@ -142,6 +150,16 @@ type NodeExtensions struct {
 	CSharp     *CSharpExtensions     `json:"csharp,omitempty"`
 	Rust       *RustExtensions       `json:"rust,omitempty"`
 	PHP        *PHPExtensions        `json:"php,omitempty"`
+	Protobuf   *ProtobufExtensions   `json:"protobuf,omitempty"`
+	GraphQL    *GraphQLExtensions    `json:"graphql,omitempty"`
+	SQL        *SQLExtensions        `json:"sql,omitempty"`
+	OpenAPI    *OpenAPIExtensions    `json:"openapi,omitempty"`
+	C          *CExtensions          `json:"c,omitempty"`
+	Component  *ComponentExtensions  `json:"component,omitempty"`
+	HCL        *HCLExtensions        `json:"hcl,omitempty"`
+	Scala      *ScalaExtensions      `json:"scala,omitempty"`
+	Dart       *DartExtensions       `json:"dart,omitempty"`
+	Shell      *ShellExtensions      `json:"shell,omitempty"`
 	Attributes map[string]any        `json:"attributes,omitempty"`
 }
 
@@ -214,6 +232,184 @@ type PHPExtensions struct {
 	IsTrait bool `json:"is_trait,omitempty"`
 }
 
+// ProtobufExtensions provides Protocol Buffers metadata
+type ProtobufExtensions struct {
+	// Field label: repeated, optional or required
+	Label string `json:"label,omitempty"`
+	// Field number of a field or group
+	Number string `json:"number,omitempty"`
+	// Name of the oneof a field belongs to
+	Oneof string `json:"oneof,omitempty"`
+	// Indicates an option statement, stored as a field with the value as default
+	IsOption bool `json:"is_option,omitempty"`
+	// Indicates a syntax or edition statement, stored as a field named after
+	// the keyword with the quoted value as default
+	IsSyntax bool `json:"is_syntax,omitempty"`
+	// Indicates an extend block, stored as a struct named after the extended message
+	IsExtend bool `json:"is_extend,omitempty"`
+	// Indicates a proto2 group, stored as a struct
+	IsGroup bool `json:"is_group,omitempty"`
+	// Streaming request or response of an RPC
+	ClientStreaming bool `json:"client_streaming,omitempty"`
+	ServerStreaming bool `json:"server_streaming,omitempty"`
+	// Reserved field numbers, ranges and names of a message or enum
+	Reserved []string `json:"reserved,omitempty"`
+	// Extension ranges of a proto2 message
+	ExtensionRanges []string `json:"extension_ranges,omitempty"`
+}
+
+// GraphQLExtensions provides GraphQL schema and operation metadata
+type GraphQLExtensions struct {
+	// Definition kind: type, input, interface, union, scalar, schema,
+	// directive, query, mutation, subscription or fragment
+	Kind string `json:"kind,omitempty"`
+	// Interfaces implemented by a type or interface
+	Implements []string `json:"implements,omitempty"`
+	// Directives applied to a definition that has no decorators
+	Directives []string `json:"directives,omitempty"`
+	// Locations where a directive definition may be applied
+	Locations []string `json:"locations,omitempty"`
+	// Indicates a repeatable directive definition
+	Repeatable bool `json:"repeatable,omitempty"`
+	// Indicates an extend definition, e.g. extend type Query
+	IsExtend bool `json:"is_extend,omitempty"`
+	// Type a fragment applies to
+	TypeCondition string `json:"type_condition,omitempty"`
+}
+
+// SQLExtensions provides SQL schema object metadata
+type SQLExtensions struct {
+	// Object kind: table, view, materialized_view, function, procedure,
+	// trigger, index, type, enum, or alter, drop and comment for changes
+	// whose target is not known
+	Kind string `json:"kind,omitempty"`
+	// Table an index or trigger belongs to
+	Table string `json:"table,omitempty"`
+	// Rest of an index or trigger definition after its name, e.g.
+	// ON users (email)
+	Definition string `json:"definition,omitempty"`
+	// Table constraints, e.g. PRIMARY KEY (id)
+	Constraints []string `json:"constraints,omitempty"`
+	// Indicates a unique index
+	Unique bool `json:"unique,omitempty"`
+	// SQL text of a change whose target is not known
+	Statement string `json:"statement,omitempty"`
+}
+
+// OpenAPIExtensions provides OpenAPI, Swagger and JSON Schema metadata
+type OpenAPIExtensions struct {
+	// Node kind: operation, webhook or schema
+	Kind string `json:"kind,omitempty"`
+	// HTTP method and path of an operation, or the name of a webhook
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// operationId of an operation
+	OperationID string `json:"operation_id,omitempty"`
+	// Tags of an operation
+	Tags []string `json:"tags,omitempty"`
+	// Schemas an object schema is combined with through allOf
+	AllOf []string `json:"all_of,omitempty"`
+	// Indicates a required property
+	Required bool `json:"required,omitempty"`
+}
+
+// CExtensions provides C declaration metadata
+type CExtensions struct {
+	// Kind of a struct: struct or union
+	Kind string `json:"kind,omitempty"`
+	// Tag of a struct, union or enum declared by a typedef, e.g. node in
+	// typedef struct node { ... } node_t
+	Tag string `json:"tag,omitempty"`
+	// Indicates a struct, union or enum declared by a typedef
+	Typedef bool `json:"typedef,omitempty"`
+	// Declarators of an anonymous struct, union or enum, e.g. pos in
+	// struct { int x, y; } pos
+	Declarator string `json:"declarator,omitempty"`
+	// Width of a bit field
+	Bits string `json:"bits,omitempty"`
+	// Indicates a preprocessor macro
+	Macro bool `json:"macro,omitempty"`
+	// Indicates an #include of a system header
+	System bool `json:"system,omitempty"`
+}
+
+// ComponentExtensions provides metadata of Vue, Svelte and Astro components
+type ComponentExtensions struct {
+	// Framework of a component: vue, svelte or astro
+	Framework string `json:"framework,omitempty"`
+	// Language of the component script: js or ts
+	Script string `json:"script,omitempty"`
+	// Kind of a component member: prop, event, slot or exposed
+	Kind string `json:"kind,omitempty"`
+	// Indicates a prop without a default value that must be passed
+	Required bool `json:"required,omitempty"`
+	// Indicates a prop with two-way binding, such as a Vue model or a
+	// Svelte $bindable prop
+	Bindable bool `json:"bindable,omitempty"`
+	// Type of the props of a component whose members are not declared in
+	// the component, e.g. an imported interface
+	PropsType string `json:"props_type,omitempty"`
+}
+
+// HCLExtensions provides Terraform and HCL block metadata
+type HCLExtensions struct {
+	// Block type: resource, data, module, provider, variable, output,
+	// local, terraform, or the type of another block; empty for top-level
+	// attributes
+	Kind string `json:"kind,omitempty"`
+	// Labels of a block, e.g. aws_instance and web
+	Labels []string `json:"labels,omitempty"`
+	// Arguments shown when the body of a block is left out, e.g.
+	// source = "./vpc" or count = 3
+	Arguments []string `json:"arguments,omitempty"`
+	// Indicates a sensitive variable or output
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Version constraint of a required provider
+	Version string `json:"version,omitempty"`
+}
+
+// ScalaExtensions provides Scala declaration metadata
+type ScalaExtensions struct {
+	// Modifiers without an IR modifier, e.g. implicit, lazy, opaque or given
+	Keywords []string `json:"keywords,omitempty"`
+	// Scope of a qualified access modifier, e.g. util in private[util]
+	Qualifier string `json:"qualifier,omitempty"`
+	// Number of parameters in each parameter list of a method or class,
+	// when it has several lists or a single empty list
+	ParameterLists []int `json:"parameter_lists,omitempty"`
+	// Parameters of the extension clause of an extension method, e.g.
+	// (s: String)
+	Receiver string `json:"receiver,omitempty"`
+}
+
+// DartExtensions provides Dart declaration metadata
+type DartExtensions struct {
+	// Declaration kind: mixin, extension or extension_type for classes;
+	// factory, getter, setter or operator for functions
+	Kind string `json:"kind,omitempty"`
+	// Types a mixin is restricted to, or the type an extension applies to
+	On []string `json:"on,omitempty"`
+	// Modifiers without an IR modifier, e.g. base, interface, late or
+	// external
+	Keywords []string `json:"keywords,omitempty"`
+	// Number of trailing parameters in brackets or braces, which are
+	// optional positional or named parameters
+	Optional int `json:"optional,omitempty"`
+	// Indicates that the trailing parameters are named, in braces
+	Named bool `json:"named,omitempty"`
+	// Initializer list or redirection of a constructor, e.g. : super(key)
+	Initializers string `json:"initializers,omitempty"`
+	// Names an import or export hides
+	Hide []string `json:"hide,omitempty"`
+}
+
+// ShellExtensions provides shell script declaration metadata
+type ShellExtensions struct {
+	// Command declaring a variable with its options, e.g. export, readonly
+	// or declare -A; empty for a plain assignment
+	Declaration string `json:"declaration,omitempty"`
+}
+
 // FieldOrigin indicates where a field/method was defined
 type FieldOrigin string
 
@@ -0,0 +1,471 @@
+package irdiff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// APIChange is a classified change of one declaration
+type APIChange struct {
+	File   string     `json:"file"`
+	Symbol string     `json:"symbol"`
+	Kind   ChangeKind `json:"change"`
+	// What is the kind of declaration, e.g. "class", "method" or "field"
+	What string `json:"kind"`
+	// Details explain a signature change, e.g. "parameter deep added"
+	Details      []string `json:"details,omitempty"`
+	OldSignature string   `json:"old,omitempty"`
+	NewSignature string   `json:"new,omitempty"`
+	// Breaking is set when existing callers or implementers can break
+	Breaking bool `json:"breaking"`
+}
+
+// APIReport is the result of comparing two sets of files
+type APIReport struct {
+	Changes      []APIChange `json:"changes"`
+	FilesAdded   []string    `json:"files_added,omitempty"`
+	FilesRemoved []string    `json:"files_removed,omitempty"`
+}
+
+// BreakingCount returns the number of breaking changes
+func (r *APIReport) BreakingCount() int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Breaking {
+			count++
+		}
+	}
+	return count
+}
+
+// APIOptions configures CompareAPI
+type APIOptions struct {
+	// IncludePrivate also reports changes of private and internal
+	// declarations; they are never breaking
+	IncludePrivate bool
+}
+
+// CompareAPI compares two snapshots of a code base. Files are matched by
+// path; every change is classified as breaking or compatible.
+func CompareAPI(old, new []*ir.DistilledFile, opts APIOptions) *APIReport {
+	oldByPath := make(map[string]*ir.DistilledFile, len(old))
+	for _, file := range old {
+		oldByPath[file.Path] = file
+	}
+	newByPath := make(map[string]*ir.DistilledFile, len(new))
+	for _, file := range new {
+		newByPath[file.Path] = file
+	}
+
+	paths := make([]string, 0, len(oldByPath)+len(newByPath))
+	for path := range oldByPath {
+		paths = append(paths, path)
+	}
+	for path := range newByPath {
+		if _, ok := oldByPath[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	report := &APIReport{}
+	for _, path := range paths {
+		oldFile, newFile := oldByPath[path], newByPath[path]
+		switch {
+		case oldFile == nil:
+			report.FilesAdded = append(report.FilesAdded, path)
+		case newFile == nil:
+			report.FilesRemoved = append(report.FilesRemoved, path)
+		}
+
+		language := ""
+		if newFile != nil {
+			language = newFile.Language
+		} else {
+			language = oldFile.Language
+		}
+
+		result := Compare(apiView(oldFile), apiView(newFile))
+		for _, change := range result.Changes {
+			if !opts.IncludePrivate && !isAPI(change.Old) && !isAPI(change.New) {
+				continue
+			}
+			// The package clause of a new or deleted file is implied by the file
+			if change.NodeKind == ir.KindPackage && (oldFile == nil || newFile == nil) &&
+				len(containerChildren(change.Old))+len(containerChildren(change.New)) == 0 {
+				continue
+			}
+			report.Changes = append(report.Changes, classify(path, language, change))
+		}
+	}
+	return report
+}
+
+// apiView hides the members of non-API containers, whose changes cannot
+// affect users of the code
+func apiView(file *ir.DistilledFile) *ir.DistilledFile {
+	if file == nil {
+		return nil
+	}
+	view := *file
+	view.Children = apiNodes(file.Children)
+	return &view
+}
+
+func apiNodes(nodes []ir.DistilledNode) []ir.DistilledNode {
+	result := make([]ir.DistilledNode, len(nodes))
+	for i, node := range nodes {
+		children := containerChildren(node)
+		switch {
+		case children == nil:
+			result[i] = node
+		case isAPI(node):
+			result[i] = withChildren(node, apiNodes(children))
+		default:
+			result[i] = withChildren(node, nil)
+		}
+	}
+	return result
+}
+
+// isAPI reports whether a declaration is visible outside its module
+func isAPI(node ir.DistilledNode) bool {
+	if node == nil {
+		return false
+	}
+	switch visibilityOf(node) {
+	case ir.VisibilityPrivate, ir.VisibilityInternal, ir.VisibilityFilePrivate,
+		ir.VisibilityPackage, ir.VisibilityPrivateProtected:
+		return false
+	}
+	return true
+}
+
+// visibilityRank orders visibilities from most to least restrictive
+func visibilityRank(v ir.Visibility) int {
+	switch v {
+	case ir.VisibilityPrivate, ir.VisibilityFilePrivate:
+		return 0
+	case ir.VisibilityPackage, ir.VisibilityInternal, ir.VisibilityPrivateProtected:
+		return 1
+	case ir.VisibilityProtected, ir.VisibilityProtectedInternal:
+		return 2
+	case ir.VisibilityOpen:
+		return 4
+	default:
+		return 3
+	}
+}
+
+func visibilityOf(node ir.DistilledNode) ir.Visibility {
+	switch n := node.(type) {
+	case *ir.DistilledClass:
+		return n.Visibility
+	case *ir.DistilledInterface:
+		return n.Visibility
+	case *ir.DistilledStruct:
+		return n.Visibility
+	case *ir.DistilledEnum:
+		return n.Visibility
+	case *ir.DistilledFunction:
+		return n.Visibility
+	case *ir.DistilledField:
+		return n.Visibility
+	case *ir.DistilledTypeAlias:
+		return n.Visibility
+	}
+	return ""
+}
+
+func modifiersOf(node ir.DistilledNode, language string) []ir.Modifier {
+	var list []ir.Modifier
+	switch n := node.(type) {
+	case *ir.DistilledClass:
+		list = n.Modifiers
+	case *ir.DistilledInterface:
+		list = n.Modifiers
+	case *ir.DistilledFunction:
+		list = n.Modifiers
+	case *ir.DistilledField:
+		list = n.Modifiers
+	case *ir.DistilledTypeAlias:
+		list = n.Modifiers
+	}
+
+	// The Go parser marks methods with abstract; it is not a modifier there
+	if language == "go" {
+		filtered := make([]ir.Modifier, 0, len(list))
+		for _, modifier := range list {
+			if modifier != ir.ModifierAbstract {
+				filtered = append(filtered, modifier)
+			}
+		}
+		list = filtered
+	}
+	return list
+}
+
+// what names the kind of a declaration for reports
+func what(node, parent ir.DistilledNode, language string) string {
+	if _, ok := node.(*ir.DistilledFunction); ok && (parent != nil || goReceiver(node, language) != "") {
+		return "method"
+	}
+	if _, ok := node.(*ir.DistilledTypeAlias); ok {
+		return "type"
+	}
+	return string(node.GetNodeKind())
+}
+
+// classify explains a change and decides whether it is breaking
+func classify(path, language string, change Change) APIChange {
+	api := APIChange{
+		File:         path,
+		Symbol:       change.Symbol,
+		Kind:         change.Kind,
+		OldSignature: change.OldSignature(),
+		NewSignature: change.NewSignature(),
+	}
+
+	switch change.Kind {
+	case Added:
+		api.What = what(change.New, change.Parent, language)
+		// New members that implementers must provide break them
+		if _, ok := change.New.(*ir.DistilledFunction); ok && isAPI(change.New) {
+			if _, inInterface := change.Parent.(*ir.DistilledInterface); inInterface {
+				api.Breaking = true
+				api.Details = append(api.Details, "implementations must add it")
+			} else if hasModifier(modifiersOf(change.New, language), ir.ModifierAbstract) {
+				api.Breaking = true
+				api.Details = append(api.Details, "abstract; subclasses must implement it")
+			}
+		}
+
+	case Removed:
+		api.What = what(change.Old, change.Parent, language)
+		api.Breaking = isAPI(change.Old)
+
+	default:
+		api.What = what(change.New, change.Parent, language)
+		api.Details, api.Breaking = signatureDetails(change.Old, change.New, language)
+		if !isAPI(change.Old) {
+			// Nobody outside could use it before
+			api.Breaking = false
+		}
+	}
+	return api
+}
+
+// signatureDetails lists the differences between two signatures of a
+// declaration and whether any of them is breaking
+func signatureDetails(old, new ir.DistilledNode, language string) (details []string, breaking bool) {
+	add := func(isBreaking bool, format string, args ...interface{}) {
+		details = append(details, fmt.Sprintf(format, args...))
+		breaking = breaking || isBreaking
+	}
+
+	if old.GetNodeKind() != new.GetNodeKind() {
+		add(true, "%s became %s", what(old, nil, language), what(new, nil, language))
+		return details, breaking
+	}
+
+	oldVis, newVis := visibilityOf(old), visibilityOf(new)
+	if oldVis != newVis {
+		if visibilityRank(newVis) < visibilityRank(oldVis) {
+			add(true, "visibility narrowed from %s to %s", visibilityName(oldVis), visibilityName(newVis))
+		} else {
+			add(false, "visibility widened from %s to %s", visibilityName(oldVis), visibilityName(newVis))
+		}
+	}
+
+	added, removed := diffModifiers(modifiersOf(old, language), modifiersOf(new, language))
+	for _, modifier := range added {
+		switch modifier {
+		case ir.ModifierAbstract, ir.ModifierFinal, ir.ModifierSealed, ir.ModifierStatic,
+			ir.ModifierAsync, ir.ModifierReadonly, ir.ModifierConst:
+			add(true, "%s added", modifier)
+		default:
+			add(false, "%s added", modifier)
+		}
+	}
+	for _, modifier := range removed {
+		switch modifier {
+		case ir.ModifierStatic, ir.ModifierAsync, ir.ModifierVirtual:
+			add(true, "%s removed", modifier)
+		default:
+			add(false, "%s removed", modifier)
+		}
+	}
+
+	switch o := old.(type) {
+	case *ir.DistilledFunction:
+		n := new.(*ir.DistilledFunction)
+		if typeParams(o.TypeParams) != typeParams(n.TypeParams) {
+			add(true, "type parameters changed from %q to %q", typeParams(o.TypeParams), typeParams(n.TypeParams))
+		}
+		parameterDetails(o.Parameters, n.Parameters, add)
+		if oldRet, newRet := optionalType(o.Returns), optionalType(n.Returns); oldRet != newRet {
+			add(true, "return type changed from %s to %s", describeType(oldRet), describeType(newRet))
+		}
+		if typeRefs(o.Throws) != typeRefs(n.Throws) {
+			add(false, "throws changed from %q to %q", typeRefs(o.Throws), typeRefs(n.Throws))
+		}
+		if fmt.Sprint(decorators(o.Decorators)) != fmt.Sprint(decorators(n.Decorators)) {
+			add(false, "decorators changed")
+		}
+
+	case *ir.DistilledField:
+		n := new.(*ir.DistilledField)
+		if oldType, newType := optionalType(o.Type), optionalType(n.Type); oldType != newType {
+			add(true, "type changed from %s to %s", describeType(oldType), describeType(newType))
+		}
+		if o.HasGetter && !n.HasGetter {
+			add(true, "getter removed")
+		}
+		if o.HasSetter && !n.HasSetter {
+			add(true, "setter removed")
+		}
+
+	case *ir.DistilledClass:
+		n := new.(*ir.DistilledClass)
+		baseDetails("base class", o.Extends, n.Extends, add)
+		baseDetails("interface", o.Implements, n.Implements, add)
+		baseDetails("mixin", o.Mixins, n.Mixins, add)
+		if typeParams(o.TypeParams) != typeParams(n.TypeParams) {
+			add(true, "type parameters changed from %q to %q", typeParams(o.TypeParams), typeParams(n.TypeParams))
+		}
+
+	case *ir.DistilledInterface:
+		n := new.(*ir.DistilledInterface)
+		baseDetails("base interface", o.Extends, n.Extends, add)
+		if typeParams(o.TypeParams) != typeParams(n.TypeParams) {
+			add(true, "type parameters changed from %q to %q", typeParams(o.TypeParams), typeParams(n.TypeParams))
+		}
+
+	case *ir.DistilledTypeAlias:
+		n := new.(*ir.DistilledTypeAlias)
+		if typeRef(o.Type) != typeRef(n.Type) {
+			add(true, "type changed from %s to %s", typeRef(o.Type), typeRef(n.Type))
+		}
+
+	case *ir.DistilledEnum:
+		n := new.(*ir.DistilledEnum)
+		if oldType, newType := optionalType(o.Type), optionalType(n.Type); oldType != newType {
+			add(true, "underlying type changed from %s to %s", describeType(oldType), describeType(newType))
+		}
+	}
+
+	if len(details) == 0 {
+		// Be conservative about differences not covered above
+		add(true, "signature changed")
+	}
+	return details, breaking
+}
+
+// parameterDetails compares parameter lists position by position
+func parameterDetails(old, new []ir.Parameter, add func(bool, string, ...interface{})) {
+	for i := 0; i < len(old) && i < len(new); i++ {
+		o, n := old[i], new[i]
+		if o.Name != n.Name {
+			add(false, "parameter %s renamed to %s", o.Name, n.Name)
+		}
+		if typeRef(o.Type) != typeRef(n.Type) {
+			add(true, "parameter %s type changed from %s to %s", n.Name, describeType(typeRef(o.Type)), describeType(typeRef(n.Type)))
+		}
+		if o.IsVariadic != n.IsVariadic {
+			add(true, "parameter %s variadic changed", n.Name)
+		}
+		oldOptional := o.IsOptional || o.DefaultValue != ""
+		newOptional := n.IsOptional || n.DefaultValue != ""
+		switch {
+		case oldOptional && !newOptional:
+			add(true, "parameter %s became required", n.Name)
+		case !oldOptional && newOptional:
+			add(false, "parameter %s became optional", n.Name)
+		case o.DefaultValue != n.DefaultValue:
+			add(false, "parameter %s default changed from %s to %s", n.Name, o.DefaultValue, n.DefaultValue)
+		}
+	}
+
+	for _, p := range new[min(len(old), len(new)):] {
+		if p.IsOptional || p.IsVariadic || p.DefaultValue != "" {
+			add(false, "optional parameter %s added", p.Name)
+		} else {
+			add(true, "required parameter %s added", p.Name)
+		}
+	}
+	for _, p := range old[min(len(old), len(new)):] {
+		add(true, "parameter %s removed", p.Name)
+	}
+}
+
+// baseDetails compares lists of base types
+func baseDetails(label string, old, new []ir.TypeRef, add func(bool, string, ...interface{})) {
+	oldSet := make(map[string]bool, len(old))
+	for _, ref := range old {
+		oldSet[typeRef(ref)] = true
+	}
+	newSet := make(map[string]bool, len(new))
+	for _, ref := range new {
+		newSet[typeRef(ref)] = true
+		if !oldSet[typeRef(ref)] {
+			add(false, "%s %s added", label, typeRef(ref))
+		}
+	}
+	for _, ref := range old {
+		if !newSet[typeRef(ref)] {
+			add(true, "%s %s removed", label, typeRef(ref))
+		}
+	}
+}
+
+func diffModifiers(old, new []ir.Modifier) (added, removed []ir.Modifier) {
+	oldSet := make(map[ir.Modifier]bool, len(old))
+	for _, modifier := range old {
+		oldSet[modifier] = true
+	}
+	newSet := make(map[ir.Modifier]bool, len(new))
+	for _, modifier := range new {
+		newSet[modifier] = true
+		if !oldSet[modifier] {
+			added = append(added, modifier)
+		}
+	}
+	for _, modifier := range old {
+		if !newSet[modifier] {
+			removed = append(removed, modifier)
+		}
+	}
+	return added, removed
+}
+
+func hasModifier(list []ir.Modifier, modifier ir.Modifier) bool {
+	for _, m := range list {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
+
+func optionalType(t *ir.TypeRef) string {
+	if t == nil {
+		return ""
+	}
+	return typeRef(*t)
+}
+
+func describeType(t string) string {
+	if t == "" {
+		return "none"
+	}
+	return t
+}
+
+func visibilityName(v ir.Visibility) string {
+	if v == "" {
+		return "default"
+	}
+	return string(v)
+}
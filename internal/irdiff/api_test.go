@@ -0,0 +1,192 @@
+package irdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+func apiFile(path string, nodes ...ir.DistilledNode) *ir.DistilledFile {
+	return &ir.DistilledFile{Path: path, Language: "python", Children: nodes}
+}
+
+func findChange(t *testing.T, report *APIReport, symbol string) APIChange {
+	t.Helper()
+	for _, change := range report.Changes {
+		if change.Symbol == symbol {
+			return change
+		}
+	}
+	t.Fatalf("no change for %s in %+v", symbol, report.Changes)
+	return APIChange{}
+}
+
+func TestCompareAPIClassification(t *testing.T) {
+	withDefault := function("fetch", "url")
+	withDefault.Parameters = append(withDefault.Parameters, ir.Parameter{Name: "timeout", DefaultValue: "30"})
+
+	retyped := function("load", "path")
+	retyped.Returns = &ir.TypeRef{Name: "bytes"}
+	oldLoad := function("load", "path")
+	oldLoad.Returns = &ir.TypeRef{Name: "str"}
+
+	hidden := function("close")
+	hidden.Visibility = ir.VisibilityPrivate
+
+	privateOld := function("_helper", "a")
+	privateOld.Visibility = ir.VisibilityPrivate
+	privateNew := function("_helper", "a", "b")
+	privateNew.Visibility = ir.VisibilityPrivate
+
+	old := []*ir.DistilledFile{
+		apiFile("api.py",
+			function("fetch", "url"),
+			function("send", "data"),
+			oldLoad,
+			function("close"),
+			function("remove"),
+			privateOld,
+			&ir.DistilledInterface{Name: "Store", Visibility: ir.VisibilityPublic},
+			&ir.DistilledClass{Name: "Repo", Visibility: ir.VisibilityPublic, Extends: []ir.TypeRef{{Name: "Base"}}},
+		),
+		apiFile("gone.py", function("legacy")),
+	}
+	new := []*ir.DistilledFile{
+		apiFile("api.py",
+			withDefault,
+			function("send", "data", "flags"),
+			retyped,
+			hidden,
+			privateNew,
+			&ir.DistilledInterface{Name: "Store", Visibility: ir.VisibilityPublic, Children: []ir.DistilledNode{function("get", "key")}},
+			&ir.DistilledClass{Name: "Repo", Visibility: ir.VisibilityPublic, Implements: []ir.TypeRef{{Name: "Store"}}},
+		),
+		apiFile("fresh.py", function("create")),
+	}
+
+	report := CompareAPI(old, new, APIOptions{})
+
+	tests := []struct {
+		symbol   string
+		breaking bool
+		details  []string
+	}{
+		{"fetch", false, []string{"optional parameter timeout added"}},
+		{"send", true, []string{"required parameter flags added"}},
+		{"load", true, []string{"return type changed from str to bytes"}},
+		{"close", true, []string{"visibility narrowed from public to private"}},
+		{"remove", true, nil},
+		{"Store.get", true, []string{"implementations must add it"}},
+		{"Repo", true, []string{"base class Base removed", "interface Store added"}},
+		{"legacy", true, nil},
+		{"create", false, nil},
+	}
+	for _, tt := range tests {
+		change := findChange(t, report, tt.symbol)
+		if change.Breaking != tt.breaking {
+			t.Errorf("%s: breaking = %v, want %v", tt.symbol, change.Breaking, tt.breaking)
+		}
+		if !reflect.DeepEqual(change.Details, tt.details) {
+			t.Errorf("%s: details = %q, want %q", tt.symbol, change.Details, tt.details)
+		}
+	}
+
+	for _, change := range report.Changes {
+		if change.Symbol == "_helper" {
+			t.Error("private change reported without IncludePrivate")
+		}
+	}
+	if !reflect.DeepEqual(report.FilesAdded, []string{"fresh.py"}) || !reflect.DeepEqual(report.FilesRemoved, []string{"gone.py"}) {
+		t.Errorf("files added %v, removed %v", report.FilesAdded, report.FilesRemoved)
+	}
+
+	withPrivate := CompareAPI(old, new, APIOptions{IncludePrivate: true})
+	if change := findChange(t, withPrivate, "_helper"); change.Breaking {
+		t.Error("private change classified as breaking")
+	}
+}
+
+func TestCompareAPIGoMethods(t *testing.T) {
+	method := func(receiver, name string, params ...string) *ir.DistilledFunction {
+		fn := function(name, params...)
+		fn.Modifiers = []ir.Modifier{ir.ModifierAbstract}
+		fn.Parameters = append([]ir.Parameter{{Name: "r", Type: ir.TypeRef{Name: "*" + receiver}}}, fn.Parameters...)
+		return fn
+	}
+	goFile := func(nodes ...ir.DistilledNode) []*ir.DistilledFile {
+		return []*ir.DistilledFile{{Path: "a.go", Language: "go", Children: nodes}}
+	}
+
+	old := goFile(method("Reader", "Close"), method("Writer", "Close"))
+	new := goFile(method("Reader", "Close"), method("Writer", "Close", "force"), method("Writer", "Flush"))
+
+	report := CompareAPI(old, new, APIOptions{})
+	if len(report.Changes) != 2 {
+		t.Fatalf("changes = %+v, want 2", report.Changes)
+	}
+	changed := findChange(t, report, "Writer.Close")
+	if changed.What != "method" || !changed.Breaking {
+		t.Errorf("Writer.Close = %+v", changed)
+	}
+	// Methods are not abstract in Go; adding one breaks nobody
+	if added := findChange(t, report, "Writer.Flush"); added.Breaking || strings.Contains(added.NewSignature, "abstract") {
+		t.Errorf("Writer.Flush = %+v", added)
+	}
+}
+
+func TestAPIReportOutput(t *testing.T) {
+	report := CompareAPI(
+		[]*ir.DistilledFile{apiFile("api.py", function("fetch", "url"), function("old"))},
+		[]*ir.DistilledFile{apiFile("api.py", function("fetch", "url", "retries"), function("new"))},
+		APIOptions{},
+	)
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"3 API changes (2 breaking, 1 compatible)",
+		"  ! signature changed function fetch: required parameter retries added",
+		"      - public fetch(url)",
+		"      + public fetch(url, retries)",
+		"  + added function new",
+		"  ! removed function old",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := report.WriteMarkdown(&markdown); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown.String(), "## Breaking Changes") || !strings.Contains(markdown.String(), "## Compatible Changes") {
+		t.Errorf("markdown output missing sections:\n%s", markdown.String())
+	}
+
+	var raw bytes.Buffer
+	if err := report.WriteJSON(&raw); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Breaking int         `json:"breaking"`
+		Changes  []APIChange `json:"changes"`
+	}
+	if err := json.Unmarshal(raw.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Breaking != 2 || len(decoded.Changes) != 3 {
+		t.Errorf("json = %s", raw.String())
+	}
+
+	empty := CompareAPI(nil, nil, APIOptions{})
+	if empty.Summary() != "No API changes" {
+		t.Errorf("empty summary = %q", empty.Summary())
+	}
+}
@@ -0,0 +1,576 @@
+// Package irdiff compares two versions of a distilled file and reports which
+// declarations were added, removed or had their signature changed.
+package irdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// ChangeKind classifies a declaration change
+type ChangeKind string
+
+const (
+	Added            ChangeKind = "added"
+	Removed          ChangeKind = "removed"
+	SignatureChanged ChangeKind = "signature changed"
+)
+
+// ChangeAttribute is the NodeExtensions attribute holding the change kind of
+// declarations in an annotated file
+const ChangeAttribute = "change"
+
+// Change describes one changed declaration
+type Change struct {
+	Kind ChangeKind
+	// Symbol is the qualified name, e.g. "UserService.save"
+	Symbol   string
+	NodeKind ir.NodeKind
+	// Old and New are the declarations before and after; Old is nil for
+	// added and New is nil for removed declarations
+	Old ir.DistilledNode
+	New ir.DistilledNode
+	// Parent is the enclosing declaration, nil at file level
+	Parent ir.DistilledNode
+	// Language of the file the declaration belongs to
+	Language string
+}
+
+// OldSignature returns the signature before the change, or ""
+func (c Change) OldSignature() string {
+	return signature(c.Old, c.Language)
+}
+
+// NewSignature returns the signature after the change, or ""
+func (c Change) NewSignature() string {
+	return signature(c.New, c.Language)
+}
+
+// String renders the change as a single line
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s", c.NewSignature())
+	case Removed:
+		return fmt.Sprintf("- %s", c.OldSignature())
+	default:
+		return fmt.Sprintf("~ %s (was: %s)", c.NewSignature(), c.OldSignature())
+	}
+}
+
+// Result is the outcome of comparing two versions of a file
+type Result struct {
+	// Changes lists changed declarations in output order
+	Changes []Change
+
+	// File holds only the changed declarations, each preceded by a marker
+	// comment and tagged with ChangeAttribute. Unchanged containers are kept
+	// around changed members for context.
+	File *ir.DistilledFile
+}
+
+// Compare diffs two versions of a file. Either may be nil for files that were
+// added or deleted. Bodies, comments and imports are ignored; only the
+// declarations and their signatures are compared.
+func Compare(old, new *ir.DistilledFile) *Result {
+	var oldNodes, newNodes []ir.DistilledNode
+	annotated := &ir.DistilledFile{}
+	d := &differ{}
+	if old != nil {
+		oldNodes = old.Children
+		annotated.Path, annotated.Language, annotated.Version = old.Path, old.Language, old.Version
+		d.language = old.Language
+	}
+	if new != nil {
+		newNodes = new.Children
+		annotated.BaseNode = new.BaseNode
+		annotated.Path, annotated.Language, annotated.Version = new.Path, new.Language, new.Version
+		annotated.Metadata = new.Metadata
+		d.language = new.Language
+	}
+
+	changes, kept := d.diffNodes(oldNodes, newNodes, "", nil, nil)
+	annotated.Children = kept
+	return &Result{Changes: changes, File: annotated}
+}
+
+// differ compares the declarations of one file
+type differ struct {
+	language string
+}
+
+// declaration is a named node within one scope
+type declaration struct {
+	key  string
+	name string
+	sig  string
+	node ir.DistilledNode
+	used bool
+}
+
+// diffNodes compares the declarations of one scope and returns the changes
+// and the annotated nodes to keep; oldParent and newParent enclose the scope
+func (d *differ) diffNodes(oldNodes, newNodes []ir.DistilledNode, scope string, oldParent, newParent ir.DistilledNode) ([]Change, []ir.DistilledNode) {
+	olds := d.declarations(oldNodes)
+	news := d.declarations(newNodes)
+
+	byKey := make(map[string][]*declaration)
+	for _, d := range olds {
+		byKey[d.key] = append(byKey[d.key], d)
+	}
+
+	// Pair identical signatures first so that overloads match correctly,
+	// then pair the remaining declarations with the same name
+	partner := make(map[*declaration]*declaration)
+	for _, n := range news {
+		for _, o := range byKey[n.key] {
+			if !o.used && o.sig == n.sig {
+				o.used = true
+				partner[n] = o
+				break
+			}
+		}
+	}
+	for _, n := range news {
+		if partner[n] != nil {
+			continue
+		}
+		for _, o := range byKey[n.key] {
+			if !o.used {
+				o.used = true
+				partner[n] = o
+				break
+			}
+		}
+	}
+
+	var changes []Change
+	var kept []ir.DistilledNode
+	for _, n := range news {
+		symbol := qualify(scope, n.name)
+		o := partner[n]
+
+		if o == nil {
+			changes = append(changes, Change{Kind: Added, Symbol: symbol, NodeKind: n.node.GetNodeKind(), New: n.node, Parent: newParent, Language: d.language})
+			kept = append(kept, d.marker(Added, n.name, nil), annotate(n.node, Added))
+			continue
+		}
+
+		// Compare members of containers that exist in both versions
+		subChanges, subKept := d.diffNodes(containerChildren(o.node), containerChildren(n.node), symbol, o.node, n.node)
+
+		if o.sig != n.sig {
+			changes = append(changes, Change{Kind: SignatureChanged, Symbol: symbol, NodeKind: n.node.GetNodeKind(), Old: o.node, New: n.node, Parent: newParent, Language: d.language})
+			changes = append(changes, subChanges...)
+			// Only the changed members of a changed container are kept
+			changed := withChildren(n.node, subKept)
+			setChange(changed, SignatureChanged)
+			kept = append(kept, d.marker(SignatureChanged, n.name, o.node), changed)
+			continue
+		}
+
+		if len(subChanges) > 0 {
+			changes = append(changes, subChanges...)
+			kept = append(kept, withChildren(n.node, subKept))
+		}
+	}
+
+	for _, o := range olds {
+		if o.used {
+			continue
+		}
+		changes = append(changes, Change{Kind: Removed, Symbol: qualify(scope, o.name), NodeKind: o.node.GetNodeKind(), Old: o.node, Parent: oldParent, Language: d.language})
+		kept = append(kept, d.marker(Removed, o.name, nil), annotate(o.node, Removed))
+	}
+
+	return changes, kept
+}
+
+// declarations returns the named declarations among nodes
+func (d *differ) declarations(nodes []ir.DistilledNode) []*declaration {
+	var result []*declaration
+	for _, node := range nodes {
+		name, family := declarationName(node)
+		if receiver := goReceiver(node, d.language); receiver != "" {
+			name = receiver + "." + name
+		}
+		if name == "" {
+			continue
+		}
+		result = append(result, &declaration{
+			key:  family + ":" + name,
+			name: name,
+			sig:  signature(node, d.language),
+			node: node,
+		})
+	}
+	return result
+}
+
+// declarationName returns the name of a declaration and the family used for
+// matching; types of different kinds match each other so that turning a
+// class into an interface is reported as a signature change
+func declarationName(node ir.DistilledNode) (name, family string) {
+	switch n := node.(type) {
+	case *ir.DistilledFunction:
+		return n.Name, "func"
+	case *ir.DistilledField:
+		return n.Name, "field"
+	case *ir.DistilledClass:
+		return n.Name, "type"
+	case *ir.DistilledInterface:
+		return n.Name, "type"
+	case *ir.DistilledStruct:
+		return n.Name, "type"
+	case *ir.DistilledEnum:
+		return n.Name, "type"
+	case *ir.DistilledTypeAlias:
+		return n.Name, "type"
+	case *ir.DistilledPackage:
+		return n.Name, "package"
+	}
+	return "", ""
+}
+
+// containerChildren returns the members of container declarations
+func containerChildren(node ir.DistilledNode) []ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledClass, *ir.DistilledInterface, *ir.DistilledStruct, *ir.DistilledEnum, *ir.DistilledPackage:
+		return n.GetChildren()
+	}
+	return nil
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// marker creates the comment placed before a changed declaration. It names
+// the declaration because some formatters group comments apart from members.
+func (d *differ) marker(kind ChangeKind, name string, old ir.DistilledNode) *ir.DistilledComment {
+	text := "[" + string(kind) + "] " + name
+	if old != nil {
+		text += ", was: " + signature(old, d.language)
+	}
+	return &ir.DistilledComment{Text: text, Format: "line"}
+}
+
+// annotate copies a declaration with all its members and tags it with the
+// change kind
+func annotate(node ir.DistilledNode, kind ChangeKind) ir.DistilledNode {
+	copied := withChildren(node, containerChildren(node))
+	setChange(copied, kind)
+	return copied
+}
+
+// withChildren returns a shallow copy of node with the given members
+func withChildren(node ir.DistilledNode, children []ir.DistilledNode) ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledClass:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledInterface:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledStruct:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledEnum:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledPackage:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledFunction:
+		c := *n
+		return &c
+	case *ir.DistilledField:
+		c := *n
+		return &c
+	case *ir.DistilledTypeAlias:
+		c := *n
+		return &c
+	}
+	return node
+}
+
+// setChange records the change kind in the node's extension attributes
+func setChange(node ir.DistilledNode, kind ChangeKind) {
+	base := baseNode(node)
+	if base == nil {
+		return
+	}
+
+	extensions := &ir.NodeExtensions{}
+	if base.Extensions != nil {
+		*extensions = *base.Extensions
+	}
+	attributes := make(map[string]any, len(extensions.Attributes)+1)
+	for key, value := range extensions.Attributes {
+		attributes[key] = value
+	}
+	attributes[ChangeAttribute] = string(kind)
+	extensions.Attributes = attributes
+	base.Extensions = extensions
+}
+
+func baseNode(node ir.DistilledNode) *ir.BaseNode {
+	switch n := node.(type) {
+	case *ir.DistilledClass:
+		return &n.BaseNode
+	case *ir.DistilledInterface:
+		return &n.BaseNode
+	case *ir.DistilledStruct:
+		return &n.BaseNode
+	case *ir.DistilledEnum:
+		return &n.BaseNode
+	case *ir.DistilledPackage:
+		return &n.BaseNode
+	case *ir.DistilledFunction:
+		return &n.BaseNode
+	case *ir.DistilledField:
+		return &n.BaseNode
+	case *ir.DistilledTypeAlias:
+		return &n.BaseNode
+	}
+	return nil
+}
+
+// ChangeOf returns the change kind recorded on a node of an annotated file
+func ChangeOf(node ir.DistilledNode) (ChangeKind, bool) {
+	base := baseNode(node)
+	if base == nil || base.Extensions == nil {
+		return "", false
+	}
+	kind, ok := base.Extensions.Attributes[ChangeAttribute].(string)
+	return ChangeKind(kind), ok
+}
+
+// Signature renders the declaration part of a node on one line: visibility,
+// modifiers, name, type parameters, parameters, return and base types.
+// Bodies, docs and default values of fields are not part of the signature.
+func Signature(node ir.DistilledNode) string {
+	return signature(node, "")
+}
+
+// goReceiver returns the receiver type of a Go method, or "". The Go parser
+// represents methods as functions marked abstract whose first parameter is
+// the receiver.
+func goReceiver(node ir.DistilledNode, language string) string {
+	fn, ok := node.(*ir.DistilledFunction)
+	if !ok || language != "go" || len(fn.Parameters) == 0 {
+		return ""
+	}
+	for _, modifier := range fn.Modifiers {
+		if modifier == ir.ModifierAbstract {
+			return strings.TrimLeft(fn.Parameters[0].Type.Name, "*")
+		}
+	}
+	return ""
+}
+
+func signature(node ir.DistilledNode, language string) string {
+	var parts []string
+	switch n := node.(type) {
+	case *ir.DistilledFunction:
+		fnModifiers := n.Modifiers
+		if goReceiver(n, language) != "" {
+			fnModifiers = nil
+			for _, modifier := range n.Modifiers {
+				if modifier != ir.ModifierAbstract {
+					fnModifiers = append(fnModifiers, modifier)
+				}
+			}
+		}
+		parts = append(parts, decorators(n.Decorators)...)
+		parts = append(parts, visibility(n.Visibility)...)
+		parts = append(parts, modifiers(fnModifiers)...)
+		params := make([]string, len(n.Parameters))
+		for i, param := range n.Parameters {
+			params[i] = parameter(param)
+		}
+		sig := n.Name + typeParams(n.TypeParams) + "(" + strings.Join(params, ", ") + ")"
+		if n.Returns != nil {
+			sig += " -> " + typeRef(*n.Returns)
+		}
+		if len(n.Throws) > 0 {
+			sig += " throws " + typeRefs(n.Throws)
+		}
+		parts = append(parts, sig)
+
+	case *ir.DistilledField:
+		parts = append(parts, decorators(n.Decorators)...)
+		parts = append(parts, visibility(n.Visibility)...)
+		parts = append(parts, modifiers(n.Modifiers)...)
+		sig := n.Name
+		if n.Type != nil {
+			sig += ": " + typeRef(*n.Type)
+		}
+		if n.IsProperty {
+			var accessors []string
+			if n.HasGetter {
+				accessors = append(accessors, "get")
+			}
+			if n.HasSetter {
+				accessors = append(accessors, "set")
+			}
+			sig += " { " + strings.Join(accessors, "; ") + " }"
+		}
+		parts = append(parts, sig)
+
+	case *ir.DistilledClass:
+		parts = append(parts, decorators(n.Decorators)...)
+		parts = append(parts, visibility(n.Visibility)...)
+		parts = append(parts, modifiers(n.Modifiers)...)
+		sig := "class " + n.Name + typeParams(n.TypeParams)
+		if len(n.Extends) > 0 {
+			sig += " extends " + typeRefs(n.Extends)
+		}
+		if len(n.Implements) > 0 {
+			sig += " implements " + typeRefs(n.Implements)
+		}
+		if len(n.Mixins) > 0 {
+			sig += " with " + typeRefs(n.Mixins)
+		}
+		parts = append(parts, sig)
+
+	case *ir.DistilledInterface:
+		parts = append(parts, visibility(n.Visibility)...)
+		parts = append(parts, modifiers(n.Modifiers)...)
+		sig := "interface " + n.Name + typeParams(n.TypeParams)
+		if len(n.Extends) > 0 {
+			sig += " extends " + typeRefs(n.Extends)
+		}
+		parts = append(parts, sig)
+
+	case *ir.DistilledStruct:
+		parts = append(parts, visibility(n.Visibility)...)
+		parts = append(parts, "struct "+n.Name+typeParams(n.TypeParams))
+
+	case *ir.DistilledEnum:
+		parts = append(parts, visibility(n.Visibility)...)
+		sig := "enum " + n.Name
+		if n.Type != nil {
+			sig += ": " + typeRef(*n.Type)
+		}
+		parts = append(parts, sig)
+
+	case *ir.DistilledTypeAlias:
+		parts = append(parts, visibility(n.Visibility)...)
+		parts = append(parts, modifiers(n.Modifiers)...)
+		parts = append(parts, "type "+n.Name+typeParams(n.TypeParams)+" = "+typeRef(n.Type))
+
+	case *ir.DistilledPackage:
+		parts = append(parts, "package "+n.Name)
+
+	default:
+		return ""
+	}
+	return strings.Join(parts, " ")
+}
+
+func decorators(list []string) []string {
+	result := make([]string, len(list))
+	for i, decorator := range list {
+		if strings.HasPrefix(decorator, "@") {
+			result[i] = decorator
+		} else {
+			result[i] = "@" + decorator
+		}
+	}
+	return result
+}
+
+func visibility(v ir.Visibility) []string {
+	if v == "" {
+		return nil
+	}
+	return []string{string(v)}
+}
+
+func modifiers(list []ir.Modifier) []string {
+	result := make([]string, len(list))
+	for i, modifier := range list {
+		result[i] = string(modifier)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func parameter(p ir.Parameter) string {
+	var b strings.Builder
+	for _, decorator := range decorators(p.Decorators) {
+		b.WriteString(decorator + " ")
+	}
+	if p.IsVariadic {
+		b.WriteString("...")
+	}
+	b.WriteString(p.Name)
+	if p.IsOptional {
+		b.WriteString("?")
+	}
+	if t := typeRef(p.Type); t != "" {
+		b.WriteString(": " + t)
+	}
+	if p.DefaultValue != "" {
+		b.WriteString(" = " + p.DefaultValue)
+	}
+	return b.String()
+}
+
+func typeParams(params []ir.TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	result := make([]string, len(params))
+	for i, param := range params {
+		result[i] = param.Name
+		if len(param.Constraints) > 0 {
+			result[i] += " " + typeRefs(param.Constraints)
+		}
+		if param.Default != nil {
+			result[i] += " = " + typeRef(*param.Default)
+		}
+	}
+	return "<" + strings.Join(result, ", ") + ">"
+}
+
+func typeRefs(refs []ir.TypeRef) string {
+	result := make([]string, len(refs))
+	for i, ref := range refs {
+		result[i] = typeRef(ref)
+	}
+	return strings.Join(result, ", ")
+}
+
+func typeRef(t ir.TypeRef) string {
+	name := t.Name
+	if t.Package != "" {
+		name = t.Package + "." + name
+	}
+	if len(t.TypeArgs) > 0 {
+		name += "<" + typeRefs(t.TypeArgs) + ">"
+	}
+	if t.IsArray {
+		dims := t.ArrayDims
+		if dims < 1 {
+			dims = 1
+		}
+		name += strings.Repeat("[]", dims)
+	}
+	if t.IsNullable {
+		name += "?"
+	}
+	return name
+}
@@ -0,0 +1,214 @@
+package irdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+func function(name string, params ...string) *ir.DistilledFunction {
+	fn := &ir.DistilledFunction{Name: name, Visibility: ir.VisibilityPublic}
+	for _, param := range params {
+		fn.Parameters = append(fn.Parameters, ir.Parameter{Name: param})
+	}
+	return fn
+}
+
+func changeList(changes []Change) []string {
+	var result []string
+	for _, change := range changes {
+		result = append(result, string(change.Kind)+" "+change.Symbol)
+	}
+	return result
+}
+
+func TestCompare(t *testing.T) {
+	old := &ir.DistilledFile{Path: "svc.py", Children: []ir.DistilledNode{
+		&ir.DistilledClass{Name: "Service", Children: []ir.DistilledNode{
+			function("get", "self", "id"),
+			function("save", "self"),
+			function("drop", "self"),
+		}},
+		function("helper", "x"),
+		&ir.DistilledClass{Name: "Unchanged", Children: []ir.DistilledNode{function("run")}},
+	}}
+
+	helper := function("helper", "x")
+	helper.Implementation = "return x * 2"
+	new := &ir.DistilledFile{Path: "svc.py", Children: []ir.DistilledNode{
+		&ir.DistilledComment{Text: "module comment"},
+		&ir.DistilledClass{Name: "Service", Children: []ir.DistilledNode{
+			function("get", "self", "id", "deep"),
+			function("save", "self"),
+			function("create", "self", "name"),
+		}},
+		helper,
+		&ir.DistilledClass{Name: "Unchanged", Children: []ir.DistilledNode{function("run")}},
+		function("added"),
+	}}
+
+	result := Compare(old, new)
+
+	want := []string{
+		"signature changed Service.get",
+		"added Service.create",
+		"removed Service.drop",
+		"added added",
+	}
+	if got := changeList(result.Changes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes = %v, want %v", got, want)
+	}
+
+	// Only the changed class is kept, with its changed members and markers
+	if len(result.File.Children) != 3 {
+		t.Fatalf("annotated file has %d children, want 3", len(result.File.Children))
+	}
+	class, ok := result.File.Children[0].(*ir.DistilledClass)
+	if !ok || class.Name != "Service" {
+		t.Fatalf("first child = %#v, want class Service", result.File.Children[0])
+	}
+	var texts []string
+	for _, child := range class.Children {
+		switch n := child.(type) {
+		case *ir.DistilledComment:
+			texts = append(texts, n.Text)
+		case *ir.DistilledFunction:
+			kind, _ := ChangeOf(n)
+			texts = append(texts, n.Name+"="+string(kind))
+		}
+	}
+	wantTexts := []string{
+		"[signature changed] get, was: public get(self, id)",
+		"get=signature changed",
+		"[added] create",
+		"create=added",
+		"[removed] drop",
+		"drop=removed",
+	}
+	if !reflect.DeepEqual(texts, wantTexts) {
+		t.Errorf("class members = %q, want %q", texts, wantTexts)
+	}
+
+	// The input must not be modified
+	if _, ok := ChangeOf(new.Children[1].(*ir.DistilledClass).Children[0]); ok {
+		t.Error("Compare modified its input")
+	}
+}
+
+func TestCompareAddedAndDeletedFiles(t *testing.T) {
+	file := &ir.DistilledFile{Path: "a.go", Children: []ir.DistilledNode{
+		&ir.DistilledStruct{Name: "User", Children: []ir.DistilledNode{&ir.DistilledField{Name: "Name"}}},
+		function("New"),
+	}}
+
+	added := Compare(nil, file)
+	if got, want := changeList(added.Changes), []string{"added User", "added New"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("added file changes = %v, want %v", got, want)
+	}
+	if added.File.Path != "a.go" {
+		t.Errorf("added file path = %q", added.File.Path)
+	}
+	// Added containers keep all members
+	if s := added.File.Children[1].(*ir.DistilledStruct); len(s.Children) != 1 {
+		t.Errorf("added struct has %d members, want 1", len(s.Children))
+	}
+
+	removed := Compare(file, nil)
+	if got, want := changeList(removed.Changes), []string{"removed User", "removed New"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deleted file changes = %v, want %v", got, want)
+	}
+	if removed.File.Path != "a.go" {
+		t.Errorf("deleted file path = %q", removed.File.Path)
+	}
+
+	if unchanged := Compare(file, file); len(unchanged.Changes) != 0 || len(unchanged.File.Children) != 0 {
+		t.Errorf("identical files reported %v", changeList(unchanged.Changes))
+	}
+}
+
+func TestCompareOverloads(t *testing.T) {
+	old := &ir.DistilledFile{Children: []ir.DistilledNode{
+		function("parse", "text"),
+		function("parse", "text", "strict"),
+	}}
+	new := &ir.DistilledFile{Children: []ir.DistilledNode{
+		function("parse", "text", "strict"),
+		function("parse", "text", "options"),
+	}}
+
+	result := Compare(old, new)
+	if len(result.Changes) != 1 || result.Changes[0].Kind != SignatureChanged {
+		t.Fatalf("changes = %v, want one signature change", changeList(result.Changes))
+	}
+	change := result.Changes[0]
+	if change.OldSignature() != "public parse(text)" || change.NewSignature() != "public parse(text, options)" {
+		t.Errorf("signatures = %q -> %q", change.OldSignature(), change.NewSignature())
+	}
+}
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		node ir.DistilledNode
+		want string
+	}{
+		{
+			name: "function",
+			node: &ir.DistilledFunction{
+				Name:       "Find",
+				Visibility: ir.VisibilityPublic,
+				Modifiers:  []ir.Modifier{ir.ModifierStatic, ir.ModifierAsync},
+				TypeParams: []ir.TypeParam{{Name: "T", Constraints: []ir.TypeRef{{Name: "any"}}}},
+				Parameters: []ir.Parameter{
+					{Name: "id", Type: ir.TypeRef{Name: "int"}},
+					{Name: "opts", Type: ir.TypeRef{Name: "Option"}, IsVariadic: true},
+					{Name: "limit", Type: ir.TypeRef{Name: "int"}, DefaultValue: "10"},
+				},
+				Returns:        &ir.TypeRef{Name: "List", TypeArgs: []ir.TypeRef{{Name: "T"}}, IsNullable: true},
+				Implementation: "return nil",
+			},
+			want: "public async static Find<T any>(id: int, ...opts: Option, limit: int = 10) -> List<T>?",
+		},
+		{
+			name: "field default is not part of the signature",
+			node: &ir.DistilledField{Name: "count", Visibility: ir.VisibilityPrivate, Type: &ir.TypeRef{Name: "int"}, DefaultValue: "0"},
+			want: "private count: int",
+		},
+		{
+			name: "class",
+			node: &ir.DistilledClass{
+				Name:       "Repo",
+				Extends:    []ir.TypeRef{{Name: "Base"}},
+				Implements: []ir.TypeRef{{Name: "Store"}, {Name: "Closer"}},
+			},
+			want: "class Repo extends Base implements Store, Closer",
+		},
+		{
+			name: "type alias",
+			node: &ir.DistilledTypeAlias{Name: "IDs", Type: ir.TypeRef{Name: "string", IsArray: true}},
+			want: "type IDs = string[]",
+		},
+		{
+			name: "comment",
+			node: &ir.DistilledComment{Text: "hello"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Signature(tt.node); got != tt.want {
+				t.Errorf("Signature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	change := Change{Kind: SignatureChanged, Old: function("f", "a"), New: function("f", "a", "b")}
+	if got := change.String(); !strings.HasPrefix(got, "~ public f(a, b)") {
+		t.Errorf("String() = %q", got)
+	}
+}
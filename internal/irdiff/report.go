@@ -0,0 +1,172 @@
+package irdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Summary returns a one-line overview of the report
+func (r *APIReport) Summary() string {
+	if len(r.Changes) == 0 && len(r.FilesAdded) == 0 && len(r.FilesRemoved) == 0 {
+		return "No API changes"
+	}
+	breaking := r.BreakingCount()
+	return fmt.Sprintf("%d API change%s (%d breaking, %d compatible), %d file%s added, %d removed",
+		len(r.Changes), plural(len(r.Changes)), breaking, len(r.Changes)-breaking,
+		len(r.FilesAdded), plural(len(r.FilesAdded)), len(r.FilesRemoved))
+}
+
+// WriteText writes the report as plain text grouped by file. Breaking
+// changes are marked with "!", other changes with "+", "-" or "~".
+func (r *APIReport) WriteText(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(r.Summary() + "\n")
+
+	for _, group := range r.byFile() {
+		fmt.Fprintf(&b, "\n%s%s\n", group.file, r.fileNote(group.file))
+		for _, change := range group.changes {
+			fmt.Fprintf(&b, "  %s %s %s %s", change.marker(), change.Kind, change.What, change.Symbol)
+			if len(change.Details) > 0 {
+				fmt.Fprintf(&b, ": %s", strings.Join(change.Details, ", "))
+			}
+			b.WriteString("\n")
+			if change.OldSignature != "" {
+				fmt.Fprintf(&b, "      - %s\n", change.OldSignature)
+			}
+			if change.NewSignature != "" {
+				fmt.Fprintf(&b, "      + %s\n", change.NewSignature)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the report as Markdown, breaking changes first
+func (r *APIReport) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# API Changes\n\n")
+	b.WriteString(r.Summary() + "\n")
+
+	sections := []struct {
+		title    string
+		breaking bool
+	}{
+		{"Breaking Changes", true},
+		{"Compatible Changes", false},
+	}
+	for _, section := range sections {
+		var lines []string
+		for _, change := range r.Changes {
+			if change.Breaking != section.breaking {
+				continue
+			}
+			line := fmt.Sprintf("- **%s** %s `%s` in `%s`", change.Kind, change.What, change.Symbol, change.File)
+			if len(change.Details) > 0 {
+				line += ": " + strings.Join(change.Details, ", ")
+			}
+			if change.OldSignature != "" && change.NewSignature != "" {
+				line += fmt.Sprintf("\n  - before: `%s`\n  - after: `%s`", change.OldSignature, change.NewSignature)
+			} else if sig := change.OldSignature + change.NewSignature; sig != "" {
+				line += fmt.Sprintf("\n  - `%s`", sig)
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n## %s\n\n%s\n", section.title, strings.Join(lines, "\n"))
+		}
+	}
+
+	if len(r.FilesAdded) > 0 {
+		b.WriteString("\n## Added Files\n\n")
+		for _, file := range r.FilesAdded {
+			fmt.Fprintf(&b, "- `%s`\n", file)
+		}
+	}
+	if len(r.FilesRemoved) > 0 {
+		b.WriteString("\n## Removed Files\n\n")
+		for _, file := range r.FilesRemoved {
+			fmt.Fprintf(&b, "- `%s`\n", file)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as an indented JSON object
+func (r *APIReport) WriteJSON(w io.Writer) error {
+	changes := r.Changes
+	if changes == nil {
+		changes = []APIChange{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Breaking     int         `json:"breaking"`
+		Compatible   int         `json:"compatible"`
+		Changes      []APIChange `json:"changes"`
+		FilesAdded   []string    `json:"files_added,omitempty"`
+		FilesRemoved []string    `json:"files_removed,omitempty"`
+	}{
+		Breaking:     r.BreakingCount(),
+		Compatible:   len(r.Changes) - r.BreakingCount(),
+		Changes:      changes,
+		FilesAdded:   r.FilesAdded,
+		FilesRemoved: r.FilesRemoved,
+	})
+}
+
+type fileChanges struct {
+	file    string
+	changes []APIChange
+}
+
+// byFile groups changes by file, keeping their order
+func (r *APIReport) byFile() []fileChanges {
+	var groups []fileChanges
+	for _, change := range r.Changes {
+		if len(groups) == 0 || groups[len(groups)-1].file != change.File {
+			groups = append(groups, fileChanges{file: change.File})
+		}
+		groups[len(groups)-1].changes = append(groups[len(groups)-1].changes, change)
+	}
+	return groups
+}
+
+func (r *APIReport) fileNote(file string) string {
+	for _, added := range r.FilesAdded {
+		if added == file {
+			return " (new file)"
+		}
+	}
+	for _, removed := range r.FilesRemoved {
+		if removed == file {
+			return " (removed file)"
+		}
+	}
+	return ""
+}
+
+func (c APIChange) marker() string {
+	switch {
+	case c.Breaking:
+		return "!"
+	case c.Kind == Added:
+		return "+"
+	case c.Kind == Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
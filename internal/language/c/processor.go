@@ -0,0 +1,105 @@
+package c
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/stripper"
+)
+
+var (
+	// cppPatterns match constructs that exist in C++ but not in C
+	cppPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*(class|struct)\s+\w+\s*(final\s*)?:\s*(public|protected|private|virtual)?\s*\w`),
+		regexp.MustCompile(`(?m)^\s*class\s+\w+\s*\{`),
+		regexp.MustCompile(`(?m)^\s*namespace(\s+[\w:]+)?\s*\{`),
+		regexp.MustCompile(`(?m)^\s*template\s*<`),
+		regexp.MustCompile(`(?m)^\s*(public|protected|private)\s*:`),
+		regexp.MustCompile(`(?m)^\s*#\s*include\s*<\w+>`),
+		regexp.MustCompile(`\b(using\s+namespace|constexpr|nullptr|static_cast|reinterpret_cast|dynamic_cast|const_cast|typename|operator\s*\W)`),
+		regexp.MustCompile(`\w::~?\w`),
+		regexp.MustCompile(`\bextern\s*"C`),
+	}
+
+	// ignoredText matches comments, string and character literals and C23
+	// attributes, which may contain anything
+	ignoredText = regexp.MustCompile(`//[^\n]*|/\*[\s\S]*?\*/|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*'|\[\[[\s\S]*?\]\]`)
+)
+
+// Processor handles C source code processing
+type Processor struct {
+	processor.BaseProcessor
+}
+
+// NewProcessor creates a new C processor
+func NewProcessor() *Processor {
+	return &Processor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"c",
+			"1.0.0",
+			[]string{".c", ".h"},
+		),
+	}
+}
+
+// DetectedExtensions implements processor.ExtensionDetector. Only .h
+// headers are shared with C++.
+func (p *Processor) DetectedExtensions() []string {
+	return []string{".h"}
+}
+
+// DetectContent implements processor.ContentDetector. A header is C unless
+// it uses C++ constructs outside of __cplusplus sections.
+func (p *Processor) DetectContent(head []byte) bool {
+	code := blankCplusplus(head)
+	code = ignoredText.ReplaceAllFunc(code, func(match []byte) []byte {
+		// Keep the linkage of extern "C" blocks, which only C++ has
+		if string(match) == `"C"` || string(match) == `"C++"` {
+			return match
+		}
+		return []byte(" ")
+	})
+	for _, pattern := range cppPatterns {
+		if pattern.Match(code) {
+			return false
+		}
+	}
+	return true
+}
+
+// Process implements processor.LanguageProcessor
+func (p *Processor) Process(ctx context.Context, reader io.Reader, filename string) (*ir.DistilledFile, error) {
+	// Read source code
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+
+	// Create a new tree-sitter processor for each call to ensure thread-safety
+	tsProcessor := NewTreeSitterProcessor()
+	return tsProcessor.ProcessSource(ctx, source, filename)
+}
+
+// ProcessWithOptions implements processor.LanguageProcessor
+func (p *Processor) ProcessWithOptions(ctx context.Context, reader io.Reader, filename string, opts processor.ProcessOptions) (*ir.DistilledFile, error) {
+	file, err := p.Process(ctx, reader, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply stripper if any options are set
+	stripperOpts := opts.ToStripperOptions()
+	if stripperOpts.HasAnyOption() {
+		s := stripper.New(stripperOpts)
+		stripped := file.Accept(s)
+		if strippedFile, ok := stripped.(*ir.DistilledFile); ok {
+			return strippedFile, nil
+		}
+	}
+
+	return file, nil
+}
@@ -0,0 +1,214 @@
+package c
+
+import (
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const headerSource = `/**
+ * UART driver.
+ */
+#ifndef UART_H
+#define UART_H
+
+#include <stdint.h>
+#include "config.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+#define UART_BUF_SIZE 64
+#define UART_MIN(a, b) ((a) < (b) ? (a) : (b))
+
+// Line status
+typedef enum {
+    UART_OK = 0,
+    UART_ERR,
+} uart_status_t;
+
+typedef struct uart_dev {
+    volatile uint32_t *base;
+    unsigned int enabled : 1;
+    void (*on_rx)(struct uart_dev *dev, uint8_t byte);
+    union {
+        uint8_t raw[4];
+        uint32_t word;
+    } data;
+    char name[UART_BUF_SIZE];
+} uart_dev_t, *uart_dev_ptr;
+
+typedef void (*uart_cb_t)(int);
+
+/** Initialize the device. */
+int uart_init(uart_dev_t *dev, uint32_t baud);
+extern const char *uart_names[], *uart_default;
+void uart_printf(uart_dev_t *dev, const char *fmt, ...);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* UART_H */
+`
+
+const sourceSource = `#include "uart.h"
+
+static int counter = 0;
+static const uint8_t table[] = { 1, 2, 3 };
+uart_dev_t *current;
+
+static inline void *get(void) { return 0; }
+
+int add(a, b, c)
+int a; char *b;
+{
+    return a + 1;
+}
+`
+
+func TestDetectContent(t *testing.T) {
+	p := NewProcessor()
+	assert.True(t, p.DetectContent([]byte(headerSource)))
+	assert.True(t, p.DetectContent([]byte("int class; /* std::string */\nconst char *s = \"a::b\";\n")))
+
+	assert.False(t, p.DetectContent([]byte("class Widget {\npublic:\n    int x;\n};\n")))
+	assert.False(t, p.DetectContent([]byte("namespace app {\nint f();\n}\n")))
+	assert.False(t, p.DetectContent([]byte("template <typename T>\nT max(T a, T b);\n")))
+	assert.False(t, p.DetectContent([]byte("#include <vector>\n")))
+	assert.False(t, p.DetectContent([]byte("std::size_t count();\n")))
+	// extern "C" outside of a __cplusplus section is C++
+	assert.False(t, p.DetectContent([]byte("extern \"C\" {\nint f(void);\n}\n")))
+}
+
+func TestProcessHeader(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), headerSource, "uart.h")
+	assert.Equal(t, "c", file.Language)
+
+	imports := langtest.Nodes[*ir.DistilledImport](file.Children)
+	require.Len(t, imports, 2)
+	assert.Equal(t, "stdint.h", imports[0].Module)
+	assert.True(t, imports[0].Extensions.C.System)
+	assert.Equal(t, "config.h", imports[1].Module)
+	assert.False(t, imports[1].Extensions.C.System)
+
+	// The include guard is left out
+	fields := langtest.Nodes[*ir.DistilledField](file.Children)
+	require.Len(t, fields, 3)
+	assert.Equal(t, "UART_BUF_SIZE", fields[0].Name)
+	assert.Equal(t, "64", fields[0].DefaultValue)
+	assert.True(t, fields[0].Extensions.C.Macro)
+	assert.Equal(t, "uart_names", fields[1].Name)
+	assert.Equal(t, "const char *[]", fields[1].Type.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierExtern}, fields[1].Modifiers)
+	assert.Equal(t, "const char *", fields[2].Type.Name)
+
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, functions, 3)
+	assert.Equal(t, "UART_MIN", functions[0].Name)
+	assert.True(t, functions[0].Extensions.C.Macro)
+	assert.Equal(t, "((a) < (b) ? (a) : (b))", functions[0].Implementation)
+	assert.Equal(t, "uart_init", functions[1].Name)
+	assert.Equal(t, "int", functions[1].Returns.Name)
+	assert.Equal(t, ir.VisibilityPublic, functions[1].Visibility)
+	require.Len(t, functions[1].Parameters, 2)
+	assert.Equal(t, "dev", functions[1].Parameters[0].Name)
+	assert.Equal(t, "uart_dev_t *", functions[1].Parameters[0].Type.Name)
+	assert.True(t, functions[2].Parameters[2].IsVariadic)
+}
+
+func TestProcessTypedefs(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), headerSource, "uart.h")
+
+	enums := langtest.Nodes[*ir.DistilledEnum](file.Children)
+	require.Len(t, enums, 1)
+	assert.Equal(t, "uart_status_t", enums[0].Name)
+	assert.True(t, enums[0].Extensions.C.Typedef)
+	values := langtest.Nodes[*ir.DistilledField](enums[0].Children)
+	require.Len(t, values, 2)
+	assert.Equal(t, "0", values[0].DefaultValue)
+
+	structs := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, structs, 1)
+	dev := structs[0]
+	assert.Equal(t, "uart_dev_t", dev.Name)
+	assert.Equal(t, "uart_dev", dev.Extensions.C.Tag)
+	assert.Equal(t, "struct", dev.Extensions.C.Kind)
+
+	members := langtest.Nodes[*ir.DistilledField](dev.Children)
+	require.Len(t, members, 4)
+	assert.Equal(t, "volatile uint32_t *", members[0].Type.Name)
+	assert.Equal(t, "1", members[1].Extensions.C.Bits)
+	assert.Equal(t, "void (*)(struct uart_dev *dev, uint8_t byte)", members[2].Type.Name)
+	assert.Equal(t, "char[UART_BUF_SIZE]", members[3].Type.Name)
+
+	// Anonymous members are declared together with their declarators
+	unions := langtest.Nodes[*ir.DistilledStruct](dev.Children)
+	require.Len(t, unions, 1)
+	assert.Equal(t, "union", unions[0].Extensions.C.Kind)
+	assert.Equal(t, "data", unions[0].Extensions.C.Declarator)
+
+	aliases := langtest.Nodes[*ir.DistilledTypeAlias](file.Children)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "uart_dev_ptr", aliases[0].Name)
+	assert.Equal(t, "struct uart_dev *", aliases[0].Type.Name)
+	assert.Equal(t, "uart_cb_t", aliases[1].Name)
+	assert.Equal(t, "void (*)(int)", aliases[1].Type.Name)
+}
+
+func TestProcessSource(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), sourceSource, "uart.c")
+
+	fields := langtest.Nodes[*ir.DistilledField](file.Children)
+	require.Len(t, fields, 3)
+	assert.Equal(t, "counter", fields[0].Name)
+	assert.Equal(t, ir.VisibilityInternal, fields[0].Visibility)
+	assert.Equal(t, "0", fields[0].DefaultValue)
+	// Initializer lists are left out
+	assert.Equal(t, "const uint8_t[]", fields[1].Type.Name)
+	assert.Empty(t, fields[1].DefaultValue)
+	assert.Equal(t, ir.VisibilityPublic, fields[2].Visibility)
+
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, functions, 2)
+	get := functions[0]
+	assert.Equal(t, ir.VisibilityInternal, get.Visibility)
+	assert.Equal(t, []ir.Modifier{ir.ModifierStatic, ir.ModifierInline}, get.Modifiers)
+	assert.Equal(t, "void *", get.Returns.Name)
+	assert.Equal(t, "{ return 0; }", get.Implementation)
+
+	// K&R parameters are int unless declared otherwise
+	add := functions[1]
+	require.Len(t, add.Parameters, 3)
+	assert.Equal(t, "int", add.Parameters[0].Type.Name)
+	assert.Equal(t, "char *", add.Parameters[1].Type.Name)
+	assert.Equal(t, "int", add.Parameters[2].Type.Name)
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	opts.IncludeImplementation = false
+	opts.IncludePrivate = false
+	output := langtest.FormatText(t, NewProcessor(), headerSource+sourceSource, "uart.c", opts)
+
+	assert.NotContains(t, output, "Line status")
+	assert.NotContains(t, output, "counter")
+	assert.NotContains(t, output, "UART_H")
+	assert.Contains(t, output, "#include <stdint.h>\n#include \"config.h\"\n")
+	assert.Contains(t, output, "#define UART_BUF_SIZE 64\n#define UART_MIN(a, b)\n")
+	assert.Contains(t, output, "typedef enum {\n    UART_OK = 0,\n    UART_ERR,\n} uart_status_t;\n")
+	assert.Contains(t, output, "typedef struct uart_dev {\n    volatile uint32_t *base;\n    unsigned int enabled : 1;\n"+
+		"    void (*on_rx)(struct uart_dev *dev, uint8_t byte);\n    union {\n        uint8_t raw[4];\n        uint32_t word;\n    } data;\n"+
+		"    char name[UART_BUF_SIZE];\n} uart_dev_t;\n")
+	assert.Contains(t, output, "typedef struct uart_dev *uart_dev_ptr;\ntypedef void (*uart_cb_t)(int);\n")
+	assert.Contains(t, output, "/** Initialize the device. */\nint uart_init(uart_dev_t *dev, uint32_t baud);\n")
+	assert.Contains(t, output, "extern const char *uart_names[];\nextern const char *uart_default;\n")
+	assert.Contains(t, output, "void uart_printf(uart_dev_t *dev, const char *fmt, ...);\n")
+	assert.Contains(t, output, "uart_dev_t *current;\nint add(int a, char *b, int c);\n")
+}
@@ -0,0 +1,671 @@
+package c
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	sitter "github.com/smacker/go-tree-sitter"
+	tree_sitter_c "github.com/smacker/go-tree-sitter/c"
+)
+
+var (
+	// cplusplusIf matches conditionals on __cplusplus, such as the guards
+	// around extern "C" in headers
+	cplusplusIf = regexp.MustCompile(`^\s*#\s*if(def\s+__cplusplus\b|\s+defined\s*\(?\s*__cplusplus\b)`)
+	// directive matches the conditional directives that open, switch and
+	// close a section
+	directive = regexp.MustCompile(`^\s*#\s*(if|ifdef|ifndef|elif|else|endif)\b`)
+)
+
+// TreeSitterProcessor uses tree-sitter for C parsing
+type TreeSitterProcessor struct {
+	parser *sitter.Parser
+}
+
+// NewTreeSitterProcessor creates a new tree-sitter based processor
+func NewTreeSitterProcessor() *TreeSitterProcessor {
+	parser := sitter.NewParser()
+	parser.SetLanguage(tree_sitter_c.GetLanguage())
+
+	return &TreeSitterProcessor{
+		parser: parser,
+	}
+}
+
+// specifiers holds the parts of a declaration shared by its declarators
+type specifiers struct {
+	// Type with its qualifiers, e.g. const char
+	typ string
+	// Type specifier node, which declares a struct, union or enum when it
+	// has a body
+	typeNode    *sitter.Node
+	modifiers   []ir.Modifier
+	decorators  []string
+	declarators []*sitter.Node
+}
+
+// ProcessSource processes C source code using tree-sitter
+func (p *TreeSitterProcessor) ProcessSource(ctx context.Context, source []byte, filename string) (*ir.DistilledFile, error) {
+	// C++ sections of headers, like extern "C" {, are not valid C
+	source = blankCplusplus(source)
+
+	// Parse the source code
+	tree, err := p.parser.ParseCtx(ctx, nil, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse C code: %w", err)
+	}
+	defer tree.Close()
+
+	// Create distilled file
+	file := &ir.DistilledFile{
+		BaseNode: ir.BaseNode{
+			Location: ir.Location{
+				StartLine: 1,
+				EndLine:   int(tree.RootNode().EndPoint().Row) + 1,
+			},
+		},
+		Path:     filename,
+		Language: "c",
+		Version:  "1.0",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+
+	file.Children = append(file.Children, p.processNode(tree.RootNode(), source, "")...)
+
+	return file, nil
+}
+
+// processNode processes top-level nodes. guard is the name of the enclosing
+// include guard, whose #define is left out.
+func (p *TreeSitterProcessor) processNode(node *sitter.Node, source []byte, guard string) []ir.DistilledNode {
+	switch node.Type() {
+	case "preproc_include":
+		return p.processInclude(node, source)
+	case "preproc_def":
+		if value := node.ChildByFieldName("value"); value == nil && p.fieldText(node, "name", source) == guard {
+			return nil
+		}
+		return []ir.DistilledNode{p.processMacro(node, source)}
+	case "preproc_function_def":
+		return []ir.DistilledNode{p.processFunctionMacro(node, source)}
+	case "function_definition":
+		if function := p.processFunctionDefinition(node, source); function != nil {
+			return []ir.DistilledNode{function}
+		}
+		return nil
+	case "declaration":
+		return p.processDeclaration(node, source, false)
+	case "type_definition":
+		return p.processTypeDefinition(node, source)
+	case "struct_specifier", "union_specifier", "enum_specifier":
+		if node.ChildByFieldName("body") != nil {
+			return []ir.DistilledNode{p.processCompound(node, source)}
+		}
+		return nil
+	case "comment":
+		return []ir.DistilledNode{p.processComment(node, source)}
+	case "preproc_ifdef":
+		if strings.HasPrefix(p.nodeText(node, source), "#ifndef") {
+			guard = p.fieldText(node, "name", source)
+		}
+	case "compound_statement", "expression_statement":
+		// Statements outside of functions are macro invocations or parse
+		// errors
+		return nil
+	}
+
+	// Process the children of the translation unit, of preprocessor
+	// conditionals and of nodes that could not be parsed
+	var result []ir.DistilledNode
+	for i := 0; i < int(node.ChildCount()); i++ {
+		result = append(result, p.processNode(node.Child(i), source, guard)...)
+	}
+	return result
+}
+
+// processInclude handles #include directives
+func (p *TreeSitterProcessor) processInclude(node *sitter.Node, source []byte) []ir.DistilledNode {
+	path := node.ChildByFieldName("path")
+	if path == nil {
+		return nil
+	}
+	text := p.nodeText(path, source)
+	return []ir.DistilledNode{&ir.DistilledImport{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+			Extensions: &ir.NodeExtensions{C: &ir.CExtensions{
+				System: path.Type() == "system_lib_string",
+			}},
+		},
+		Module:     strings.Trim(text, "\"<>"),
+		ImportType: "include",
+	}}
+}
+
+// processMacro handles object-like macros as constants
+func (p *TreeSitterProcessor) processMacro(node *sitter.Node, source []byte) *ir.DistilledField {
+	return &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: &ir.NodeExtensions{C: &ir.CExtensions{Macro: true}},
+		},
+		Name:         p.fieldText(node, "name", source),
+		Visibility:   ir.VisibilityPublic,
+		DefaultValue: strings.TrimSpace(p.fieldText(node, "value", source)),
+	}
+}
+
+// processFunctionMacro handles function-like macros as functions whose
+// replacement list is the implementation
+func (p *TreeSitterProcessor) processFunctionMacro(node *sitter.Node, source []byte) *ir.DistilledFunction {
+	function := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: &ir.NodeExtensions{C: &ir.CExtensions{Macro: true}},
+		},
+		Name:           p.fieldText(node, "name", source),
+		Visibility:     ir.VisibilityPublic,
+		Parameters:     []ir.Parameter{},
+		Implementation: strings.TrimSpace(p.fieldText(node, "value", source)),
+	}
+	if params := node.ChildByFieldName("parameters"); params != nil {
+		for i := 0; i < int(params.NamedChildCount()); i++ {
+			name := p.nodeText(params.NamedChild(i), source)
+			function.Parameters = append(function.Parameters, ir.Parameter{
+				Name:       name,
+				IsVariadic: name == "...",
+			})
+		}
+	}
+	return function
+}
+
+// processFunctionDefinition handles function definitions, including K&R
+// style definitions that declare parameter types before the body
+func (p *TreeSitterProcessor) processFunctionDefinition(node *sitter.Node, source []byte) *ir.DistilledFunction {
+	spec := p.specifiers(node, source)
+	if len(spec.declarators) == 0 {
+		return nil
+	}
+	declarator, pointer := functionDeclarator(spec.declarators[0])
+	if declarator == nil {
+		return nil
+	}
+
+	// K&R parameter declarations
+	knr := map[string]string{}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Type() != "declaration" {
+			continue
+		}
+		paramSpec := p.specifiers(child, source)
+		for _, d := range paramSpec.declarators {
+			name, abstract := p.declarator(d, source)
+			knr[name] = joinType(paramSpec.typ, abstract)
+		}
+	}
+
+	function := p.function(node, spec, declarator, pointer, source, knr)
+	if body := node.ChildByFieldName("body"); body != nil {
+		function.Implementation = p.nodeText(body, source)
+	}
+	return function
+}
+
+// processDeclaration handles declarations of functions and variables, and
+// of the structs, unions and enums declared with them. member is set for
+// struct and union fields.
+func (p *TreeSitterProcessor) processDeclaration(node *sitter.Node, source []byte, member bool) []ir.DistilledNode {
+	spec := p.specifiers(node, source)
+	var result []ir.DistilledNode
+
+	if spec.typeNode != nil && spec.typeNode.ChildByFieldName("body") != nil {
+		compound := p.processCompound(spec.typeNode, source)
+		if spec.typeNode.ChildByFieldName("name") == nil && len(spec.declarators) > 0 {
+			// An anonymous type is declared together with its declarators
+			var declarators []string
+			for _, d := range spec.declarators {
+				declarators = append(declarators, p.declaratorText(d, source))
+			}
+			cExtensions(compound).Declarator = strings.Join(declarators, ", ")
+			return []ir.DistilledNode{compound}
+		}
+		result = append(result, compound)
+	}
+
+	bits := ""
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); child.Type() == "bitfield_clause" {
+			bits = strings.TrimSpace(strings.TrimPrefix(p.nodeText(child, source), ":"))
+		}
+	}
+
+	for _, d := range spec.declarators {
+		if declarator, pointer := functionDeclarator(d); declarator != nil && !member {
+			result = append(result, p.function(node, spec, declarator, pointer, source, nil))
+			continue
+		}
+
+		name, abstract := p.declarator(d, source)
+		field := &ir.DistilledField{
+			BaseNode: ir.BaseNode{
+				Location: p.nodeLocation(node),
+			},
+			Name:       name,
+			Visibility: visibility(spec.modifiers),
+			Type:       &ir.TypeRef{Name: joinType(spec.typ, abstract)},
+			Modifiers:  spec.modifiers,
+			Decorators: spec.decorators,
+		}
+		if bits != "" {
+			field.Extensions = &ir.NodeExtensions{C: &ir.CExtensions{Bits: bits}}
+		}
+		// Initializer lists, such as lookup tables, are left out
+		if value := d.ChildByFieldName("value"); d.Type() == "init_declarator" && value != nil &&
+			value.Type() != "initializer_list" && value.StartPoint().Row == value.EndPoint().Row {
+			field.DefaultValue = p.nodeText(value, source)
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+// processTypeDefinition handles typedefs. A struct, union or enum declared
+// by a typedef is named by it.
+func (p *TreeSitterProcessor) processTypeDefinition(node *sitter.Node, source []byte) []ir.DistilledNode {
+	spec := p.specifiers(node, source)
+	declarators := spec.declarators
+	var result []ir.DistilledNode
+
+	if spec.typeNode != nil && spec.typeNode.ChildByFieldName("body") != nil &&
+		len(declarators) > 0 && declarators[0].Type() == "type_identifier" {
+		compound := p.processCompound(spec.typeNode, source)
+		ext := cExtensions(compound)
+		ext.Typedef = true
+		ext.Tag = p.fieldText(spec.typeNode, "name", source)
+		setName(compound, p.nodeText(declarators[0], source))
+		result = append(result, compound)
+		declarators = declarators[1:]
+		if ext.Tag == "" {
+			// Other declarators cannot refer to an anonymous type
+			return result
+		}
+	}
+
+	for _, d := range declarators {
+		name, abstract := p.declarator(d, source)
+		result = append(result, &ir.DistilledTypeAlias{
+			BaseNode: ir.BaseNode{
+				Location: p.nodeLocation(node),
+			},
+			Name:       name,
+			Visibility: ir.VisibilityPublic,
+			Type:       ir.TypeRef{Name: joinType(spec.typ, abstract)},
+		})
+	}
+	return result
+}
+
+// processCompound handles a struct, union or enum with a body. Structs and
+// unions become structs, enums become enums with their constants as fields.
+func (p *TreeSitterProcessor) processCompound(node *sitter.Node, source []byte) ir.DistilledNode {
+	name := p.fieldText(node, "name", source)
+	body := node.ChildByFieldName("body")
+	base := ir.BaseNode{
+		Location:   p.nodeLocation(node),
+		Extensions: &ir.NodeExtensions{C: &ir.CExtensions{}},
+	}
+
+	if node.Type() == "enum_specifier" {
+		enum := &ir.DistilledEnum{
+			BaseNode:   base,
+			Name:       name,
+			Visibility: ir.VisibilityPublic,
+			Children:   []ir.DistilledNode{},
+		}
+		for i := 0; i < int(body.NamedChildCount()); i++ {
+			child := body.NamedChild(i)
+			switch child.Type() {
+			case "enumerator":
+				enum.Children = append(enum.Children, &ir.DistilledField{
+					BaseNode: ir.BaseNode{
+						Location: p.nodeLocation(child),
+					},
+					Name:         p.fieldText(child, "name", source),
+					Visibility:   ir.VisibilityPublic,
+					DefaultValue: p.fieldText(child, "value", source),
+				})
+			case "comment":
+				enum.Children = append(enum.Children, p.processComment(child, source))
+			}
+		}
+		return enum
+	}
+
+	base.Extensions.C.Kind = strings.TrimSuffix(node.Type(), "_specifier")
+	structNode := &ir.DistilledStruct{
+		BaseNode:   base,
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+		Children:   []ir.DistilledNode{},
+	}
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		child := body.NamedChild(i)
+		switch child.Type() {
+		case "field_declaration":
+			structNode.Children = append(structNode.Children, p.processDeclaration(child, source, true)...)
+		case "comment":
+			structNode.Children = append(structNode.Children, p.processComment(child, source))
+		}
+	}
+	return structNode
+}
+
+// processComment handles comments. Doxygen comments are documentation.
+func (p *TreeSitterProcessor) processComment(node *sitter.Node, source []byte) *ir.DistilledComment {
+	text := p.nodeText(node, source)
+	format := "line"
+	switch {
+	case strings.HasPrefix(text, "/**"), strings.HasPrefix(text, "/*!"),
+		strings.HasPrefix(text, "///"), strings.HasPrefix(text, "//!"):
+		format = "doc"
+	case strings.HasPrefix(text, "/*"):
+		format = "block"
+	}
+
+	return &ir.DistilledComment{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Text:   text,
+		Format: format,
+	}
+}
+
+// function creates a function from a declaration or definition
+func (p *TreeSitterProcessor) function(node *sitter.Node, spec specifiers, declarator *sitter.Node, pointer string, source []byte, knr map[string]string) *ir.DistilledFunction {
+	function := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Name:       p.fieldText(declarator, "declarator", source),
+		Visibility: visibility(spec.modifiers),
+		Parameters: []ir.Parameter{},
+		Modifiers:  spec.modifiers,
+		Decorators: spec.decorators,
+		Returns:    &ir.TypeRef{Name: joinType(spec.typ, pointer)},
+	}
+
+	params := declarator.ChildByFieldName("parameters")
+	for i := 0; i < int(params.NamedChildCount()); i++ {
+		child := params.NamedChild(i)
+		switch child.Type() {
+		case "parameter_declaration":
+			paramSpec := p.specifiers(child, source)
+			param := ir.Parameter{Type: ir.TypeRef{Name: paramSpec.typ}}
+			if len(paramSpec.declarators) > 0 {
+				name, abstract := p.declarator(paramSpec.declarators[0], source)
+				param.Name = name
+				param.Type.Name = joinType(paramSpec.typ, abstract)
+			}
+			function.Parameters = append(function.Parameters, param)
+		case "variadic_parameter":
+			function.Parameters = append(function.Parameters, ir.Parameter{Name: "...", IsVariadic: true})
+		case "identifier":
+			// K&R parameters are int unless declared otherwise
+			name := p.nodeText(child, source)
+			typ, ok := knr[name]
+			if !ok {
+				typ = "int"
+			}
+			function.Parameters = append(function.Parameters, ir.Parameter{Name: name, Type: ir.TypeRef{Name: typ}})
+		}
+	}
+	return function
+}
+
+// specifiers collects the type, storage class and attributes of a
+// declaration, and its declarators
+func (p *TreeSitterProcessor) specifiers(node *sitter.Node, source []byte) specifiers {
+	var spec specifiers
+	var typeParts []string
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch node.FieldNameForChild(i) {
+		case "type":
+			spec.typeNode = child
+			typeParts = append(typeParts, p.typeName(child, source))
+			continue
+		case "declarator":
+			spec.declarators = append(spec.declarators, child)
+			continue
+		}
+
+		switch child.Type() {
+		case "storage_class_specifier":
+			switch text := p.nodeText(child, source); text {
+			case "static":
+				spec.modifiers = append(spec.modifiers, ir.ModifierStatic)
+			case "extern":
+				spec.modifiers = append(spec.modifiers, ir.ModifierExtern)
+			case "inline", "__inline", "__inline__":
+				spec.modifiers = append(spec.modifiers, ir.ModifierInline)
+			default:
+				typeParts = append(typeParts, text)
+			}
+		case "type_qualifier":
+			typeParts = append(typeParts, p.nodeText(child, source))
+		case "attribute_specifier", "attribute_declaration", "ms_declspec_modifier":
+			spec.decorators = append(spec.decorators, p.nodeText(child, source))
+		}
+	}
+	spec.typ = strings.Join(typeParts, " ")
+	return spec
+}
+
+// typeName returns the name of a type specifier. Structs, unions and enums
+// are named by their tag only.
+func (p *TreeSitterProcessor) typeName(node *sitter.Node, source []byte) string {
+	switch node.Type() {
+	case "struct_specifier", "union_specifier", "enum_specifier":
+		keyword := strings.TrimSuffix(node.Type(), "_specifier")
+		if name := node.ChildByFieldName("name"); name != nil {
+			return keyword + " " + p.nodeText(name, source)
+		}
+		return keyword
+	}
+	return strings.Join(strings.Fields(p.nodeText(node, source)), " ")
+}
+
+// declarator returns the declared name and the abstract declarator, which
+// is the rest of the type in C cast notation: * for pointers, [N] for arrays
+// and (*)(int) for function pointers
+func (p *TreeSitterProcessor) declarator(node *sitter.Node, source []byte) (string, string) {
+	if node == nil {
+		return "", ""
+	}
+	inner := node.ChildByFieldName("declarator")
+
+	switch node.Type() {
+	case "identifier", "field_identifier", "type_identifier", "primitive_type":
+		return p.nodeText(node, source), ""
+	case "init_declarator", "attributed_declarator":
+		return p.declarator(inner, source)
+	case "pointer_declarator", "abstract_pointer_declarator":
+		name, abstract := p.declarator(inner, source)
+		pointer := "*"
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if child := node.NamedChild(i); child.Type() == "type_qualifier" {
+				pointer += p.nodeText(child, source) + " "
+			}
+		}
+		if abstract == "" || pointer == "*" {
+			pointer = strings.TrimSpace(pointer)
+		}
+		return name, pointer + abstract
+	case "array_declarator", "abstract_array_declarator":
+		name, abstract := p.declarator(inner, source)
+		dimension := p.nodeText(node, source)
+		if inner != nil {
+			dimension = string(source[inner.EndByte():node.EndByte()])
+		}
+		return name, abstract + strings.Join(strings.Fields(dimension), " ")
+	case "function_declarator", "abstract_function_declarator":
+		name, abstract := p.declarator(inner, source)
+		params := p.fieldText(node, "parameters", source)
+		return name, abstract + strings.Join(strings.Fields(params), " ")
+	case "parenthesized_declarator", "abstract_parenthesized_declarator":
+		if node.NamedChildCount() == 0 {
+			return "", ""
+		}
+		name, abstract := p.declarator(node.NamedChild(0), source)
+		return name, "(" + abstract + ")"
+	}
+	return p.nodeText(node, source), ""
+}
+
+// declaratorText returns a declarator without its initializer
+func (p *TreeSitterProcessor) declaratorText(node *sitter.Node, source []byte) string {
+	if node.Type() == "init_declarator" {
+		node = node.ChildByFieldName("declarator")
+	}
+	return strings.Join(strings.Fields(p.nodeText(node, source)), " ")
+}
+
+// functionDeclarator returns the declarator of a function with the pointers
+// of its return type, or nil if the declarator does not declare a function
+func functionDeclarator(node *sitter.Node) (*sitter.Node, string) {
+	pointer := ""
+	for node != nil {
+		switch node.Type() {
+		case "function_declarator":
+			if name := node.ChildByFieldName("declarator"); name != nil && name.Type() == "identifier" {
+				return node, pointer
+			}
+			return nil, ""
+		case "pointer_declarator":
+			pointer += "*"
+			node = node.ChildByFieldName("declarator")
+		default:
+			return nil, ""
+		}
+	}
+	return nil, ""
+}
+
+// joinType combines a type with an abstract declarator
+func joinType(typ, abstract string) string {
+	switch {
+	case abstract == "":
+		return typ
+	case strings.HasPrefix(abstract, "["):
+		return typ + abstract
+	}
+	return typ + " " + abstract
+}
+
+// visibility returns internal for static declarations, which are visible
+// only in their translation unit
+func visibility(modifiers []ir.Modifier) ir.Visibility {
+	for _, modifier := range modifiers {
+		if modifier == ir.ModifierStatic {
+			return ir.VisibilityInternal
+		}
+	}
+	return ir.VisibilityPublic
+}
+
+// cExtensions returns the C extensions of a struct or enum
+func cExtensions(node ir.DistilledNode) *ir.CExtensions {
+	switch n := node.(type) {
+	case *ir.DistilledStruct:
+		return n.Extensions.C
+	case *ir.DistilledEnum:
+		return n.Extensions.C
+	}
+	return &ir.CExtensions{}
+}
+
+// setName names a struct or enum
+func setName(node ir.DistilledNode, name string) {
+	switch n := node.(type) {
+	case *ir.DistilledStruct:
+		n.Name = name
+	case *ir.DistilledEnum:
+		n.Name = name
+	}
+}
+
+// blankCplusplus replaces the lines of sections that are compiled only as
+// C++ with spaces, keeping line and column numbers
+func blankCplusplus(source []byte) []byte {
+	lines := bytes.SplitAfter(source, []byte("\n"))
+	// depth of the current conditional, and of the conditional on
+	// __cplusplus whose C++ branch is being blanked
+	depth, blanking := 0, 0
+	changed := false
+	for i, line := range lines {
+		if m := directive.FindSubmatch(line); m != nil {
+			switch string(m[1]) {
+			case "if", "ifdef", "ifndef":
+				depth++
+				if blanking == 0 && cplusplusIf.Match(line) {
+					blanking = depth
+					continue
+				}
+			case "elif", "else":
+				if blanking == depth {
+					blanking = 0
+					continue
+				}
+			case "endif":
+				depth--
+				if blanking == depth+1 {
+					blanking = 0
+					continue
+				}
+			}
+		}
+		if blanking > 0 {
+			blanked := bytes.Repeat([]byte(" "), len(bytes.TrimRight(line, "\r\n")))
+			lines[i] = append(blanked, line[len(blanked):]...)
+			changed = true
+		}
+	}
+	if !changed {
+		return source
+	}
+	return bytes.Join(lines, nil)
+}
+
+// Helper methods
+
+// nodeText extracts the text content of a node
+func (p *TreeSitterProcessor) nodeText(node *sitter.Node, source []byte) string {
+	return string(source[node.StartByte():node.EndByte()])
+}
+
+// fieldText extracts the text content of a field of a node, or an empty
+// string if the node does not have it
+func (p *TreeSitterProcessor) fieldText(node *sitter.Node, field string, source []byte) string {
+	if child := node.ChildByFieldName(field); child != nil {
+		return p.nodeText(child, source)
+	}
+	return ""
+}
+
+// nodeLocation creates a Location from a node
+func (p *TreeSitterProcessor) nodeLocation(node *sitter.Node) ir.Location {
+	return ir.Location{
+		StartLine:   int(node.StartPoint().Row) + 1,
+		EndLine:     int(node.EndPoint().Row) + 1,
+		StartColumn: int(node.StartPoint().Column) + 1,
+		EndColumn:   int(node.EndPoint().Column) + 1,
+	}
+}
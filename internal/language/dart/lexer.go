@@ -0,0 +1,226 @@
+package dart
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+// token is a lexical token with the comments before it
+type token struct {
+	kind  tokenKind
+	text  string
+	line  int
+	start int
+	end   int
+
+	// leading are the comments between the previous token and this one
+	leading []comment
+}
+
+// comment is a line, block or documentation comment with its markers
+type comment struct {
+	text   string
+	format string
+	line   int
+	end    int
+}
+
+// punctuators are the operators of more than one character that matter to
+// the parser. Closing angle brackets are single tokens, so >> and >= are
+// never lexed as one token and nested type arguments stay balanced.
+var punctuators = []string{
+	"...?", "...", "??=", "?..", "..", "?.", "??", "=>", "==", "!=", "<=",
+	"&&", "||", "++", "--", "+=", "-=", "*=", "/=", "~/",
+}
+
+// tokenize splits Dart source into tokens
+func tokenize(src []byte) []token {
+	var tokens []token
+	var pending []comment
+	line := 1
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == 0xEF && i+2 < len(src) && src[i+1] == 0xBB && src[i+2] == 0xBF:
+			// Byte order mark
+			i += 3
+		case c == '#' && i == 0 && i+1 < len(src) && src[i+1] == '!':
+			// Script tag
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := i
+			for end < len(src) && src[end] != '\n' {
+				end++
+			}
+			text := strings.TrimRight(string(src[i:end]), " \t\r")
+			format := "line"
+			if strings.HasPrefix(text, "///") && !strings.HasPrefix(text, "////") {
+				format = "doc"
+			}
+			pending = append(pending, comment{text: text, format: format, line: line, end: line})
+			i = end
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := skipBlockComment(src, i)
+			text := string(src[i:end])
+			format := "block"
+			if strings.HasPrefix(text, "/**") && text != "/**/" {
+				format = "doc"
+			}
+			endLine := line + strings.Count(text, "\n")
+			pending = append(pending, comment{text: text, format: format, line: line, end: endLine})
+			line = endLine
+			i = end
+		default:
+			tok := token{line: line, start: i, leading: pending}
+			pending = nil
+			switch {
+			case (c == 'r' || c == 'R') && i+1 < len(src) && (src[i+1] == '\'' || src[i+1] == '"'):
+				tok.kind = tokenString
+				i = skipString(src, i+1, true)
+			case c == '\'' || c == '"':
+				tok.kind = tokenString
+				i = skipString(src, i, false)
+			case isNameStart(c):
+				tok.kind = tokenName
+				i++
+				for i < len(src) && (isNameStart(src[i]) || isDigit(src[i])) {
+					i++
+				}
+			case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+				tok.kind = tokenNumber
+				i++
+				for i < len(src) {
+					if isNameStart(src[i]) || isDigit(src[i]) || (src[i] == '.' && i+1 < len(src) && isDigit(src[i+1])) {
+						i++
+					} else if (src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E') {
+						i++
+					} else {
+						break
+					}
+				}
+			default:
+				tok.kind = tokenPunct
+				i++
+				for _, punct := range punctuators {
+					if strings.HasPrefix(string(src[tok.start:min(tok.start+len(punct), len(src))]), punct) {
+						i = tok.start + len(punct)
+						break
+					}
+				}
+			}
+			tok.end = min(i, len(src))
+			tok.text = string(src[tok.start:tok.end])
+			line += strings.Count(tok.text, "\n")
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, line: line, start: len(src), end: len(src), leading: pending})
+}
+
+// skipBlockComment returns the offset after a block comment, which may be
+// nested
+func skipBlockComment(src []byte, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch {
+		case src[i] == '/' && i+1 < len(src) && src[i+1] == '*':
+			depth++
+			i += 2
+		case src[i] == '*' && i+1 < len(src) && src[i+1] == '/':
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// skipString returns the offset after a string literal starting with its
+// quote at i. Interpolated expressions may contain strings and braces.
+func skipString(src []byte, i int, raw bool) int {
+	quote := src[i]
+	triple := i+2 < len(src) && src[i+1] == quote && src[i+2] == quote
+	if triple {
+		i += 3
+	} else {
+		i++
+	}
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\\' && !raw:
+			i += 2
+		case c == '$' && !raw && i+1 < len(src) && src[i+1] == '{':
+			i = skipInterpolation(src, i+2)
+		case c == quote && !triple:
+			return i + 1
+		case c == quote && i+2 < len(src) && src[i+1] == quote && src[i+2] == quote:
+			return i + 3
+		case c == '\n' && !triple:
+			// Unterminated string
+			return i
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// skipInterpolation returns the offset after the closing brace of an
+// interpolated expression whose body starts at i
+func skipInterpolation(src []byte, i int) int {
+	depth := 1
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '{':
+			depth++
+			i++
+		case c == '}':
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		case c == '\'' || c == '"':
+			i = skipString(src, i, false)
+		case (c == 'r' || c == 'R') && i+1 < len(src) && (src[i+1] == '\'' || src[i+1] == '"'):
+			i = skipString(src, i+1, true)
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
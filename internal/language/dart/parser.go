@@ -0,0 +1,1376 @@
+package dart
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// classModifiers can precede class and mixin declarations
+var classModifiers = map[string]bool{
+	"abstract": true, "base": true, "interface": true, "final": true, "sealed": true, "mixin": true,
+}
+
+// memberModifiers can precede functions, fields and constructors
+var memberModifiers = map[string]bool{
+	"external": true, "static": true, "abstract": true, "covariant": true,
+	"late": true, "final": true, "const": true, "var": true, "factory": true,
+}
+
+// parameterModifiers can precede parameters
+var parameterModifiers = map[string]bool{
+	"required": true, "covariant": true, "final": true, "var": true, "const": true,
+}
+
+// parser is a declaration parser for Dart libraries. Bodies of functions
+// and initializers of fields are kept as source text. It is lenient: a
+// declaration it cannot parse is recorded as an error and skipped.
+type parser struct {
+	src   []byte
+	toks  []token
+	pos   int
+	prev  token
+	lines []int
+	file  *ir.DistilledFile
+}
+
+// parseError is a syntax error at a token
+type parseError struct {
+	tok token
+	msg string
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.tok.line, e.msg)
+}
+
+// span is a range of tokens, end exclusive
+type span struct {
+	start int
+	end   int
+}
+
+// declaration holds the annotations and modifiers before a declaration
+type declaration struct {
+	start      token
+	decorators []string
+	visibility ir.Visibility
+	modifiers  []ir.Modifier
+	keywords   []string
+	// Indicates a factory constructor
+	factory bool
+	// Indicates a const, final or var field
+	field bool
+}
+
+// Parse parses Dart source into a distilled file
+func Parse(src []byte, filename string) *ir.DistilledFile {
+	p := &parser{
+		src:  src,
+		toks: tokenize(src),
+		file: &ir.DistilledFile{
+			Path:     filename,
+			Language: "dart",
+			Version:  "1.0",
+			Children: []ir.DistilledNode{},
+			Errors:   []ir.DistilledError{},
+		},
+	}
+	p.lines = []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+
+	p.file.Children = p.parseBody(func() ([]ir.DistilledNode, error) {
+		return p.parseDeclaration("")
+	}, true)
+	p.file.Location = ir.Location{StartLine: 1, EndLine: len(p.lines)}
+	return p.file
+}
+
+// parseBody parses items with parseItem until a closing brace or the end of
+// the file. Comments before the end become standalone comments.
+func (p *parser) parseBody(parseItem func() ([]ir.DistilledNode, error), topLevel bool) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF || (tok.text == "}" && !topLevel) {
+			return append(nodes, p.comments()...)
+		}
+		if tok.text == ";" || (tok.text == "}" && topLevel) {
+			nodes = append(nodes, p.comments()...)
+			p.next()
+			continue
+		}
+
+		start := p.pos
+		item, err := parseItem()
+		if err != nil {
+			p.addError(err)
+			p.pos = start
+			nodes = append(nodes, p.comments()...)
+			p.skip()
+			continue
+		}
+		nodes = append(nodes, item...)
+	}
+}
+
+// parseDeclaration parses a top-level declaration, or a member of the
+// class, mixin, extension or enum named owner
+func (p *parser) parseDeclaration(owner string) ([]ir.DistilledNode, error) {
+	nodes := p.comments()
+	decl := p.annotations()
+
+	if owner == "" {
+		var node ir.DistilledNode
+		var err error
+		switch p.peek().text {
+		case "library":
+			node, err = p.parseLibrary()
+		case "import", "export", "part":
+			node, err = p.parseDirective()
+		case "typedef":
+			node, err = p.parseTypedef(decl)
+		case "enum":
+			node, err = p.parseEnum(decl)
+		case "extension":
+			node, err = p.parseExtension(decl)
+		default:
+			if p.isClassDeclaration() {
+				node, err = p.parseClass(decl)
+			} else {
+				return p.parseMember(nodes, decl, owner)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nodes, nil
+		}
+		return append(nodes, node), nil
+	}
+
+	return p.parseMember(nodes, decl, owner)
+}
+
+// isClassDeclaration reports whether the next tokens start a class or
+// mixin declaration
+func (p *parser) isClassDeclaration() bool {
+	i := 0
+	for classModifiers[p.peekAt(i).text] {
+		i++
+	}
+	if p.peekAt(i).text == "class" {
+		return true
+	}
+	// A mixin declaration, e.g. base mixin M on A
+	return i > 0 && p.peekAt(i-1).text == "mixin" && p.peekAt(i).kind == tokenName
+}
+
+// parseLibrary parses a library directive into a package
+func (p *parser) parseLibrary() (ir.DistilledNode, error) {
+	start := p.next()
+	var name []string
+	for p.peek().text != ";" && p.peek().kind != tokenEOF {
+		name = append(name, p.next().text)
+	}
+	if _, err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if len(name) == 0 {
+		// Unnamed library
+		return nil, nil
+	}
+	return &ir.DistilledPackage{
+		BaseNode: ir.BaseNode{Location: p.location(start, p.prev)},
+		Name:     strings.Join(name, ""),
+	}, nil
+}
+
+// parseDirective parses import, export, part and part of directives. A
+// prefix is a symbol * with the prefix as its alias, and shown names are
+// symbols.
+func (p *parser) parseDirective() (ir.DistilledNode, error) {
+	start := p.next()
+	imp := &ir.DistilledImport{
+		ImportType: start.text,
+		Symbols:    []ir.ImportedSymbol{},
+	}
+	if start.text == "part" && p.peek().text == "of" {
+		p.next()
+		imp.ImportType = "part of"
+	}
+	ext := &ir.DartExtensions{}
+
+	uri := p.next()
+	if uri.kind == tokenString {
+		imp.Module = stringValue(uri.text)
+	} else {
+		// part of a library name
+		name := []string{uri.text}
+		for p.peek().text != ";" && p.peek().kind != tokenEOF {
+			name = append(name, p.next().text)
+		}
+		imp.Module = strings.Join(name, "")
+	}
+
+	for p.peek().text != ";" {
+		tok := p.next()
+		switch tok.text {
+		case "if":
+			// Configurable import, e.g. if (dart.library.io) 'io.dart'
+			if _, err := p.balanced(); err != nil {
+				return nil, err
+			}
+			p.next()
+		case "deferred":
+			ext.Keywords = append(ext.Keywords, "deferred")
+		case "as":
+			imp.Symbols = append(imp.Symbols, ir.ImportedSymbol{Name: "*", Alias: p.next().text})
+		case "show", "hide":
+			for {
+				name := p.next()
+				if tok.text == "show" {
+					imp.Symbols = append(imp.Symbols, ir.ImportedSymbol{Name: name.text})
+				} else {
+					ext.Hide = append(ext.Hide, name.text)
+				}
+				if p.peek().text != "," {
+					break
+				}
+				p.next()
+			}
+		default:
+			if tok.kind == tokenEOF {
+				return nil, p.errorf(tok, "expected %q, found %q", ";", tok.text)
+			}
+			return nil, p.errorf(tok, "unexpected %q in %s directive", tok.text, imp.ImportType)
+		}
+	}
+	p.next()
+
+	imp.Location = p.location(start, p.prev)
+	if len(ext.Keywords) > 0 || len(ext.Hide) > 0 {
+		imp.Extensions = &ir.NodeExtensions{Dart: ext}
+	}
+	return imp, nil
+}
+
+// parseTypedef parses type aliases, including the older function type
+// alias syntax, e.g. typedef int Compare(a, b)
+func (p *parser) parseTypedef(decl declaration) (ir.DistilledNode, error) {
+	p.next()
+	head, err := p.header()
+	if err != nil {
+		return nil, err
+	}
+	name, typeParams, typ := p.splitHeader(head)
+	if name.start == name.end {
+		return nil, p.errorf(p.peek(), "expected typedef name")
+	}
+
+	alias := &ir.DistilledTypeAlias{
+		Name:       p.text(name),
+		Visibility: visibility(p.text(name), decl),
+		TypeParams: p.typeParams(typeParams),
+	}
+	switch p.peek().text {
+	case "=":
+		p.next()
+		typeStart := p.pos
+		for p.peek().text != ";" && p.peek().kind != tokenEOF {
+			if _, err := p.balanced(); err != nil {
+				return nil, err
+			}
+		}
+		alias.Type = ir.TypeRef{Name: p.text(span{typeStart, p.pos})}
+	case "(":
+		paramsStart := p.pos
+		if _, err := p.balanced(); err != nil {
+			return nil, err
+		}
+		alias.Type = ir.TypeRef{Name: strings.TrimSpace(p.text(typ) + " Function" + p.text(span{paramsStart, p.pos}))}
+	default:
+		return nil, p.errorf(p.peek(), "expected \"=\" or \"(\", found %q", p.peek().text)
+	}
+	if _, err := p.expect(";"); err != nil {
+		return nil, err
+	}
+
+	alias.Location = p.location(decl.start, p.prev)
+	alias.Extensions = dartExtensions(ir.DartExtensions{Keywords: decl.keywords})
+	return alias, nil
+}
+
+// parseClass parses class and mixin declarations, including mixin
+// applications, e.g. class C = B with M
+func (p *parser) parseClass(decl declaration) (ir.DistilledNode, error) {
+	class := &ir.DistilledClass{Children: []ir.DistilledNode{}}
+	ext := ir.DartExtensions{Keywords: decl.keywords}
+
+	for {
+		tok := p.next()
+		if tok.text == "class" {
+			break
+		}
+		if tok.text == "mixin" && p.peek().text != "class" && !classModifiers[p.peek().text] {
+			ext.Kind = "mixin"
+			break
+		}
+		switch tok.text {
+		case "abstract":
+			class.Modifiers = append(class.Modifiers, ir.ModifierAbstract)
+		case "final":
+			class.Modifiers = append(class.Modifiers, ir.ModifierFinal)
+		case "sealed":
+			class.Modifiers = append(class.Modifiers, ir.ModifierSealed)
+		default:
+			ext.Keywords = append(ext.Keywords, tok.text)
+		}
+	}
+
+	name, err := p.name("class name")
+	if err != nil {
+		return nil, err
+	}
+	class.Name = name.text
+	class.Visibility = visibility(name.text, decl)
+	class.Decorators = decl.decorators
+	if class.TypeParams, err = p.typeParameters(); err != nil {
+		return nil, err
+	}
+
+	if p.peek().text == "=" {
+		// Mixin application
+		p.next()
+		class.Extends = []ir.TypeRef{{Name: p.typeUntil()}}
+	}
+	for {
+		switch p.peek().text {
+		case "extends":
+			p.next()
+			class.Extends = []ir.TypeRef{{Name: p.typeUntil()}}
+			continue
+		case "with":
+			p.next()
+			class.Mixins = p.typeList()
+			continue
+		case "implements":
+			p.next()
+			class.Implements = p.typeList()
+			continue
+		case "on":
+			p.next()
+			for _, typ := range p.typeList() {
+				ext.On = append(ext.On, typ.Name)
+			}
+			continue
+		}
+		break
+	}
+
+	if p.peek().text == ";" {
+		p.next()
+	} else {
+		if class.Children, err = p.members(class.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	class.Location = p.location(decl.start, p.prev)
+	class.Extensions = dartExtensions(ext)
+	return class, nil
+}
+
+// parseExtension parses extensions and extension types. Extensions may be
+// unnamed.
+func (p *parser) parseExtension(decl declaration) (ir.DistilledNode, error) {
+	p.next()
+	class := &ir.DistilledClass{Decorators: decl.decorators, Children: []ir.DistilledNode{}}
+	ext := ir.DartExtensions{Kind: "extension", Keywords: decl.keywords}
+
+	isType := p.peek().text == "type" && p.peekAt(1).text != "on" && p.peekAt(1).text != "<"
+	if isType {
+		p.next()
+		ext.Kind = "extension_type"
+		if p.peek().text == "const" {
+			p.next()
+			ext.Keywords = append(ext.Keywords, "const")
+		}
+	}
+
+	if p.peek().kind == tokenName && p.peek().text != "on" {
+		class.Name = p.next().text
+	}
+	class.Visibility = visibility(class.Name, decl)
+	var err error
+	if class.TypeParams, err = p.typeParameters(); err != nil {
+		return nil, err
+	}
+
+	var members []ir.DistilledNode
+	if isType {
+		// The representation declaration is the primary constructor and a
+		// field
+		constructor := &ir.DistilledFunction{
+			Name:       "constructor",
+			Visibility: ir.VisibilityPublic,
+			Decorators: []string{"@Primary"},
+		}
+		if p.peek().text == "." {
+			p.next()
+			constructor.Name = "constructor." + p.next().text
+		}
+		openTok := p.peek()
+		if constructor.Parameters, constructor.Extensions, err = p.parameters(); err != nil {
+			return nil, err
+		}
+		constructor.Location = p.location(openTok, p.prev)
+		members = append(members, constructor)
+		for _, param := range constructor.Parameters {
+			members = append(members, &ir.DistilledField{
+				BaseNode:   ir.BaseNode{Location: constructor.Location},
+				Name:       param.Name,
+				Visibility: visibility(param.Name, declaration{}),
+				Modifiers:  []ir.Modifier{ir.ModifierFinal},
+				Type:       &ir.TypeRef{Name: param.Type.Name},
+			})
+		}
+	}
+
+	for {
+		switch p.peek().text {
+		case "on":
+			p.next()
+			ext.On = append(ext.On, p.typeUntil())
+			continue
+		case "implements":
+			p.next()
+			class.Implements = p.typeList()
+			continue
+		}
+		break
+	}
+
+	body, err := p.members(class.Name)
+	if err != nil {
+		return nil, err
+	}
+	class.Children = append(members, body...)
+	class.Location = p.location(decl.start, p.prev)
+	class.Extensions = dartExtensions(ext)
+	return class, nil
+}
+
+// parseEnum parses enums, including enhanced enums with members, into
+// classes with the enum modifier. Values are constants of the enum type.
+func (p *parser) parseEnum(decl declaration) (ir.DistilledNode, error) {
+	p.next()
+	name, err := p.name("enum name")
+	if err != nil {
+		return nil, err
+	}
+	class := &ir.DistilledClass{
+		Name:       name.text,
+		Visibility: visibility(name.text, decl),
+		Modifiers:  []ir.Modifier{ir.ModifierEnum},
+		Decorators: decl.decorators,
+		Children:   []ir.DistilledNode{},
+	}
+	if class.TypeParams, err = p.typeParameters(); err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().text {
+		case "with":
+			p.next()
+			class.Mixins = p.typeList()
+			continue
+		case "implements":
+			p.next()
+			class.Implements = p.typeList()
+			continue
+		}
+		break
+	}
+
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.peek().text != ";" && p.peek().text != "}" && p.peek().kind != tokenEOF {
+		class.Children = append(class.Children, p.comments()...)
+		valueDecl := p.annotations()
+		value, err := p.name("enum value")
+		if err != nil {
+			return nil, err
+		}
+		argsStart := p.pos
+		for p.peek().text != "," && p.peek().text != ";" && p.peek().text != "}" && p.peek().kind != tokenEOF {
+			if _, err := p.balanced(); err != nil {
+				return nil, err
+			}
+		}
+		class.Children = append(class.Children, &ir.DistilledField{
+			BaseNode:     ir.BaseNode{Location: p.location(valueDecl.start, p.prev)},
+			Name:         value.text,
+			Visibility:   ir.VisibilityPublic,
+			Modifiers:    []ir.Modifier{ir.ModifierStatic, ir.ModifierFinal},
+			Decorators:   valueDecl.decorators,
+			Type:         &ir.TypeRef{Name: class.Name},
+			DefaultValue: p.text(span{argsStart, p.pos}),
+		})
+		if p.peek().text == "," {
+			p.next()
+		}
+	}
+	if p.peek().text == ";" {
+		p.next()
+	}
+
+	class.Children = append(class.Children, p.parseBody(func() ([]ir.DistilledNode, error) {
+		return p.parseDeclaration(class.Name)
+	}, false)...)
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+
+	class.Location = p.location(decl.start, p.prev)
+	return class, nil
+}
+
+// members parses the members of a class body in braces
+func (p *parser) members(owner string) ([]ir.DistilledNode, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	members := p.parseBody(func() ([]ir.DistilledNode, error) {
+		return p.parseDeclaration(owner)
+	}, false)
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// parseMember parses a function, getter, setter, operator, constructor or
+// fields, at the top level or in the body of owner
+func (p *parser) parseMember(nodes []ir.DistilledNode, decl declaration, owner string) ([]ir.DistilledNode, error) {
+	p.modifiers(&decl)
+
+	// Constructors are named after their class, e.g. Point or Point.origin
+	if owner != "" && p.peek().text == owner && (p.peekAt(1).text == "(" || p.peekAt(1).text == ".") {
+		constructor, err := p.parseConstructor(decl)
+		if err != nil {
+			return nil, err
+		}
+		return append(nodes, constructor), nil
+	}
+	if decl.factory {
+		return nil, p.errorf(p.peek(), "expected constructor name, found %q", p.peek().text)
+	}
+
+	head, err := p.header()
+	if err != nil {
+		return nil, err
+	}
+	name, typeParams, typ := p.splitHeader(head)
+	kind := ""
+	switch {
+	case name.start == name.end:
+		return nil, p.errorf(p.peek(), "unexpected %q", p.peek().text)
+	case p.toks[name.start].text == "operator":
+		kind = "operator"
+		name.start++
+	case typ.end > typ.start && (p.toks[typ.end-1].text == "get" || p.toks[typ.end-1].text == "set") && typ.end == name.start:
+		kind = map[string]string{"get": "getter", "set": "setter"}[p.toks[typ.end-1].text]
+		typ.end--
+	}
+
+	if decl.field || (kind == "" && (p.peek().text == "=" || p.peek().text == ";" || p.peek().text == ",")) {
+		fields, err := p.parseFields(decl, typ, name)
+		if err != nil {
+			return nil, err
+		}
+		return append(nodes, fields...), nil
+	}
+
+	fn := &ir.DistilledFunction{
+		Name:       p.text(name),
+		Visibility: visibility(p.text(name), decl),
+		Modifiers:  decl.modifiers,
+		Decorators: decl.decorators,
+		TypeParams: p.typeParams(typeParams),
+		Parameters: []ir.Parameter{},
+	}
+	if kind == "operator" {
+		fn.Name = "operator " + fn.Name
+		fn.Visibility = ir.VisibilityPublic
+	}
+	if typ.end > typ.start {
+		fn.Returns = &ir.TypeRef{Name: p.text(typ)}
+	}
+
+	var params *ir.NodeExtensions
+	if kind != "getter" {
+		if fn.Parameters, params, err = p.parameters(); err != nil {
+			return nil, err
+		}
+	}
+	ext := ir.DartExtensions{Kind: kind, Keywords: decl.keywords}
+	if params != nil {
+		ext.Optional = params.Dart.Optional
+		ext.Named = params.Dart.Named
+	}
+
+	hasBody, err := p.functionBody(fn, &ext)
+	if err != nil {
+		return nil, err
+	}
+	if !hasBody && owner != "" && !hasModifier(fn.Modifiers, ir.ModifierExtern) {
+		fn.Modifiers = append(fn.Modifiers, ir.ModifierAbstract)
+	}
+
+	fn.Location = p.location(decl.start, p.prev)
+	fn.Extensions = dartExtensions(ext)
+	return append(nodes, fn), nil
+}
+
+// parseConstructor parses generative, named, const and factory
+// constructors, with their initializer lists and redirections
+func (p *parser) parseConstructor(decl declaration) (ir.DistilledNode, error) {
+	p.next()
+	fn := &ir.DistilledFunction{
+		Name:       "constructor",
+		Visibility: ir.VisibilityPublic,
+		Modifiers:  decl.modifiers,
+		Decorators: decl.decorators,
+	}
+	if p.peek().text == "." {
+		p.next()
+		name, err := p.name("constructor name")
+		if err != nil {
+			return nil, err
+		}
+		fn.Name = "constructor." + name.text
+		fn.Visibility = visibility(name.text, decl)
+	}
+
+	var params *ir.NodeExtensions
+	var err error
+	if fn.Parameters, params, err = p.parameters(); err != nil {
+		return nil, err
+	}
+	ext := ir.DartExtensions{Keywords: decl.keywords}
+	if decl.factory {
+		ext.Kind = "factory"
+	}
+	if params != nil {
+		ext.Optional = params.Dart.Optional
+		ext.Named = params.Dart.Named
+	}
+
+	if p.peek().text == ":" || (decl.factory && p.peek().text == "=") {
+		// Initializer list or redirection
+		marker := p.next()
+		start := p.pos
+		for p.peek().text != "{" && p.peek().text != ";" && p.peek().text != "=>" && p.peek().kind != tokenEOF {
+			if _, err := p.expressionGroup(); err != nil {
+				return nil, err
+			}
+		}
+		ext.Initializers = marker.text + " " + p.text(span{start, p.pos})
+	}
+	if _, err := p.functionBody(fn, &ext); err != nil {
+		return nil, err
+	}
+
+	fn.Location = p.location(decl.start, p.prev)
+	fn.Extensions = dartExtensions(ext)
+	return fn, nil
+}
+
+// parseFields parses fields and top-level variables, which may declare
+// several names with one type. Values are kept when they fit on one line.
+func (p *parser) parseFields(decl declaration, typ, name span) ([]ir.DistilledNode, error) {
+	var fields []ir.DistilledNode
+	var typeRef *ir.TypeRef
+	if typ.end > typ.start {
+		typeRef = &ir.TypeRef{Name: p.text(typ)}
+	}
+
+	for {
+		if name.end-name.start != 1 || p.toks[name.start].kind != tokenName {
+			return nil, p.errorf(p.toks[name.start], "expected field name, found %q", p.toks[name.start].text)
+		}
+		nameTok := p.toks[name.start]
+		field := &ir.DistilledField{
+			Name:       nameTok.text,
+			Visibility: visibility(nameTok.text, decl),
+			Modifiers:  decl.modifiers,
+			Decorators: decl.decorators,
+			Type:       typeRef,
+		}
+		if p.peek().text == "=" {
+			p.next()
+			start := p.pos
+			for p.peek().text != "," && p.peek().text != ";" && p.peek().kind != tokenEOF {
+				if _, err := p.expressionGroup(); err != nil {
+					return nil, err
+				}
+			}
+			if value := p.source(span{start, p.pos}); !strings.Contains(value, "\n") {
+				field.DefaultValue = value
+			}
+		}
+		field.Location = p.location(decl.start, p.prev)
+		field.Extensions = dartExtensions(ir.DartExtensions{Keywords: decl.keywords})
+		fields = append(fields, field)
+
+		sep := p.next()
+		switch sep.text {
+		case ";":
+			return fields, nil
+		case ",":
+			if p.peek().kind != tokenName {
+				return nil, p.errorf(p.peek(), "expected field name, found %q", p.peek().text)
+			}
+			name = span{p.pos, p.pos + 1}
+			decl.start = p.next()
+		default:
+			return nil, p.errorf(sep, "expected \";\", found %q", sep.text)
+		}
+	}
+}
+
+// functionBody parses the async modifier and the block or expression body
+// of a function, or the semicolon of a declaration without a body
+func (p *parser) functionBody(fn *ir.DistilledFunction, ext *ir.DartExtensions) (bool, error) {
+	switch p.peek().text {
+	case "async":
+		p.next()
+		fn.Modifiers = append(fn.Modifiers, ir.ModifierAsync)
+		if p.peek().text == "*" {
+			p.next()
+			ext.Keywords = append(ext.Keywords, "async*")
+		}
+	case "sync":
+		if p.peekAt(1).text == "*" {
+			p.next()
+			p.next()
+			ext.Keywords = append(ext.Keywords, "sync*")
+		}
+	}
+
+	switch tok := p.peek(); tok.text {
+	case ";":
+		p.next()
+		return false, nil
+	case "{":
+		start := p.pos
+		if _, err := p.balanced(); err != nil {
+			return false, err
+		}
+		fn.Implementation = p.source(span{start, p.pos})
+		return true, nil
+	case "=>":
+		start := p.pos
+		for p.peek().text != ";" && p.peek().kind != tokenEOF {
+			if _, err := p.expressionGroup(); err != nil {
+				return false, err
+			}
+		}
+		fn.Implementation = p.source(span{start, p.pos})
+		if _, err := p.expect(";"); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, p.errorf(tok, "expected function body, found %q", tok.text)
+	}
+}
+
+// parameters parses a parameter list in parentheses. The returned
+// extensions record optional positional or named parameters.
+func (p *parser) parameters() ([]ir.Parameter, *ir.NodeExtensions, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, nil, err
+	}
+	params := []ir.Parameter{}
+	ext := ir.DartExtensions{}
+	optional := false
+
+	for {
+		p.comments()
+		switch tok := p.peek(); tok.text {
+		case ")":
+			p.next()
+			var extensions *ir.NodeExtensions
+			if ext.Optional > 0 {
+				extensions = &ir.NodeExtensions{Dart: &ext}
+			}
+			return params, extensions, nil
+		case "[", "{":
+			p.next()
+			optional = true
+			ext.Named = tok.text == "{"
+			continue
+		case "]", "}":
+			p.next()
+			continue
+		case ",":
+			p.next()
+			continue
+		}
+
+		param, err := p.parameter()
+		if err != nil {
+			return nil, nil, err
+		}
+		if optional {
+			param.IsOptional = true
+			ext.Optional++
+		}
+		params = append(params, param)
+	}
+}
+
+// parameter parses a parameter. Annotations and the required, covariant and
+// final modifiers are its decorators. Initializing formals keep their
+// this. or super. prefix in the name.
+func (p *parser) parameter() (ir.Parameter, error) {
+	param := ir.Parameter{}
+	for {
+		tok := p.peek()
+		if tok.text == "@" {
+			param.Decorators = append(param.Decorators, p.annotation())
+			continue
+		}
+		if parameterModifiers[tok.text] && p.peekAt(1).kind == tokenName {
+			param.Decorators = append(param.Decorators, p.next().text)
+			continue
+		}
+		break
+	}
+
+	head, err := p.header()
+	if err != nil {
+		return param, err
+	}
+	name, _, typ := p.splitHeader(head)
+	if name.start == name.end {
+		return param, p.errorf(p.peek(), "expected parameter name, found %q", p.peek().text)
+	}
+	// this.x and super.x
+	if typ.end-typ.start >= 2 && p.toks[typ.end-1].text == "." && (p.toks[typ.end-2].text == "this" || p.toks[typ.end-2].text == "super") {
+		typ.end -= 2
+		name.start -= 2
+	}
+	param.Name = p.text(name)
+	if typ.end > typ.start {
+		param.Type = ir.TypeRef{Name: p.text(typ)}
+	}
+
+	if p.peek().text == "(" {
+		// Function-typed parameter, e.g. int compare(T a, T b)
+		start := p.pos
+		if _, err := p.balanced(); err != nil {
+			return param, err
+		}
+		param.Type = ir.TypeRef{Name: strings.TrimSpace(param.Type.Name + " Function" + p.text(span{start, p.pos}))}
+		if p.peek().text == "?" {
+			p.next()
+			param.Type.Name += "?"
+		}
+	}
+
+	if p.peek().text == "=" || p.peek().text == ":" {
+		p.next()
+		start := p.pos
+		for !strings.Contains(",)]}", p.peek().text) && p.peek().kind != tokenEOF {
+			if _, err := p.expressionGroup(); err != nil {
+				return param, err
+			}
+		}
+		param.DefaultValue = p.source(span{start, p.pos})
+	}
+	return param, nil
+}
+
+// header parses the return or field type, the name and the type parameters
+// of a declaration, up to its parameters, value or body. It returns spans
+// of single tokens or of type arguments, function types and record types.
+func (p *parser) header() ([]span, error) {
+	var head []span
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, p.errorf(tok, "unexpected end of file")
+		case tok.text == "<":
+			start := p.pos
+			if _, err := p.typeGroup(); err != nil {
+				return nil, err
+			}
+			head = append(head, span{start, p.pos})
+		case tok.text == "(" && (len(head) == 0 || p.isFunctionType(head)):
+			start := p.pos
+			if _, err := p.typeGroup(); err != nil {
+				return nil, err
+			}
+			head = append(head, span{start, p.pos})
+		case tok.text == "operator" && p.peekAt(1).text != "(" && p.peekAt(1).text != ";" && p.peekAt(1).text != "=":
+			// The operator and its symbol are the name, e.g. operator ==
+			start := p.pos
+			p.next()
+			for p.peek().text != "(" && p.peek().kind != tokenEOF {
+				p.next()
+			}
+			head = append(head, span{start, p.pos})
+		case strings.Contains("(;,={})]", tok.text) || tok.text == "=>" || (tok.text == ":" && len(head) > 0):
+			return head, nil
+		default:
+			head = append(head, span{p.pos, p.pos + 1})
+			p.next()
+		}
+	}
+}
+
+// isFunctionType reports whether parentheses after a header are the
+// parameters of a function type, e.g. void Function(int)
+func (p *parser) isFunctionType(head []span) bool {
+	last := head[len(head)-1]
+	if p.toks[last.start].text == "Function" {
+		return true
+	}
+	return p.toks[last.start].text == "<" && len(head) > 1 && p.toks[head[len(head)-2].start].text == "Function"
+}
+
+// splitHeader splits a header into the name, the type parameters and the
+// type before the name
+func (p *parser) splitHeader(head []span) (name, typeParams, typ span) {
+	if len(head) == 0 {
+		return span{p.pos, p.pos}, span{}, span{p.pos, p.pos}
+	}
+	last := head[len(head)-1]
+	if p.toks[last.start].text == "<" && len(head) > 1 {
+		typeParams = last
+		head = head[:len(head)-1]
+	}
+	name = head[len(head)-1]
+	typ = span{name.start, name.start}
+	if len(head) > 1 {
+		typ = span{head[0].start, head[len(head)-2].end}
+	}
+	return name, typeParams, typ
+}
+
+// modifiers parses the modifiers of a member
+func (p *parser) modifiers(decl *declaration) {
+	for memberModifiers[p.peek().text] {
+		// Built-in identifiers are names when followed by parameters or a
+		// value, e.g. a method named late
+		reserved := p.peek().text == "final" || p.peek().text == "const" || p.peek().text == "var"
+		if next := p.peekAt(1).text; !reserved && (next == "(" || next == ";" || next == "=" || next == ".") {
+			return
+		}
+		switch tok := p.next(); tok.text {
+		case "external":
+			decl.modifiers = append(decl.modifiers, ir.ModifierExtern)
+		case "static":
+			decl.modifiers = append(decl.modifiers, ir.ModifierStatic)
+		case "abstract":
+			decl.modifiers = append(decl.modifiers, ir.ModifierAbstract)
+		case "final":
+			decl.modifiers = append(decl.modifiers, ir.ModifierFinal)
+			decl.field = true
+		case "const":
+			decl.modifiers = append(decl.modifiers, ir.ModifierConst)
+			// const also precedes constructors
+			decl.field = p.peek().text != "factory" && p.peekAt(1).text != "(" && p.peekAt(1).text != "."
+		case "var":
+			decl.field = true
+		case "factory":
+			decl.factory = true
+		default:
+			decl.keywords = append(decl.keywords, tok.text)
+		}
+	}
+}
+
+// annotations parses the metadata before a declaration. @protected makes
+// a member protected and @override marks an override.
+func (p *parser) annotations() declaration {
+	decl := declaration{start: p.peek(), visibility: ir.VisibilityPublic}
+	for p.peek().text == "@" {
+		annotation := p.annotation()
+		switch annotation {
+		case "@protected":
+			decl.visibility = ir.VisibilityProtected
+		case "@override":
+			decl.modifiers = append(decl.modifiers, ir.ModifierOverride)
+		default:
+			decl.decorators = append(decl.decorators, annotation)
+		}
+	}
+	return decl
+}
+
+// annotation parses an annotation, e.g. @Deprecated('use bar')
+func (p *parser) annotation() string {
+	start := p.pos
+	p.next()
+	p.next()
+	for p.peek().text == "." && p.peekAt(1).kind == tokenName {
+		p.next()
+		p.next()
+	}
+	// Arguments follow the name directly; parentheses on the next line may
+	// be a record type
+	if p.peek().text == "<" && p.peek().start == p.prev.end {
+		p.typeGroup()
+	}
+	if p.peek().text == "(" && p.peek().start == p.prev.end {
+		p.balanced()
+	}
+	return p.text(span{start, p.pos})
+}
+
+// typeParameters parses type parameters in angle brackets, if any
+func (p *parser) typeParameters() ([]ir.TypeParam, error) {
+	if p.peek().text != "<" {
+		return nil, nil
+	}
+	start := p.pos
+	if _, err := p.typeGroup(); err != nil {
+		return nil, err
+	}
+	return p.typeParams(span{start, p.pos}), nil
+}
+
+// typeParams converts type parameters in angle brackets to the IR, with the
+// bound of each as its constraint
+func (p *parser) typeParams(s span) []ir.TypeParam {
+	if s.end-s.start < 2 {
+		return nil
+	}
+	var params []ir.TypeParam
+	depth := 0
+	paramStart := s.start + 1
+	for i := s.start + 1; i < s.end; i++ {
+		if i < s.end-1 {
+			switch p.toks[i].text {
+			case "<", "(", "[", "{":
+				depth++
+				continue
+			case ">", ")", "]", "}":
+				depth--
+				continue
+			case ",":
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		param := ir.TypeParam{Name: p.text(span{paramStart, i})}
+		for j := paramStart; j < i; j++ {
+			if p.toks[j].text == "extends" {
+				param.Name = p.text(span{paramStart, j})
+				param.Constraints = []ir.TypeRef{{Name: p.text(span{j + 1, i})}}
+				break
+			}
+		}
+		if param.Name != "" {
+			params = append(params, param)
+		}
+		paramStart = i + 1
+	}
+	return params
+}
+
+// typeUntil parses a type up to a comma, a clause keyword or a body
+func (p *parser) typeUntil() string {
+	start := p.pos
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF, tok.text == ",", tok.text == "{", tok.text == ";", tok.text == "(" && p.pos > start && p.toks[p.pos-1].text != "Function":
+			return p.text(span{start, p.pos})
+		case tok.kind == tokenName && (tok.text == "with" || tok.text == "implements" || tok.text == "on" || tok.text == "extends"):
+			return p.text(span{start, p.pos})
+		}
+		if _, err := p.typeGroup(); err != nil {
+			return p.text(span{start, p.pos})
+		}
+	}
+}
+
+// typeList parses comma-separated types
+func (p *parser) typeList() []ir.TypeRef {
+	var types []ir.TypeRef
+	for {
+		types = append(types, ir.TypeRef{Name: p.typeUntil()})
+		if p.peek().text != "," {
+			return types
+		}
+		p.next()
+	}
+}
+
+// balanced consumes a token, or a group in brackets, braces or
+// parentheses with everything inside it
+func (p *parser) balanced() (token, error) {
+	return p.group(false)
+}
+
+// typeGroup consumes a token or a group like balanced, and also type
+// arguments or parameters in angle brackets
+func (p *parser) typeGroup() (token, error) {
+	return p.group(true)
+}
+
+// group consumes a token or a group. Angle brackets are only paired in
+// types, where they cannot be comparison operators.
+func (p *parser) group(angles bool) (token, error) {
+	first := p.next()
+	closers := map[string]string{"(": ")", "[": "]", "{": "}"}
+	if angles {
+		closers["<"] = ">"
+	}
+	closing := closers[first.text]
+	if closing == "" {
+		return first, nil
+	}
+	stack := []string{closing}
+	for len(stack) > 0 {
+		tok := p.next()
+		switch {
+		case tok.kind == tokenEOF:
+			return tok, p.errorf(first, "unclosed %q", first.text)
+		case tok.kind == tokenString:
+		case tok.text == stack[len(stack)-1]:
+			stack = stack[:len(stack)-1]
+		case closers[tok.text] != "":
+			stack = append(stack, closers[tok.text])
+		case tok.text == "}" || tok.text == ")" || tok.text == "]" || (tok.text == ";" && stack[len(stack)-1] == ">"):
+			return tok, p.errorf(first, "unclosed %q", first.text)
+		}
+	}
+	return p.prev, nil
+}
+
+// expressionGroup consumes a token or a group like balanced. Type
+// arguments of collection literals and generic calls, e.g. <int>[] or
+// f<int, String>(), are consumed as a group, so their commas do not end
+// an expression.
+func (p *parser) expressionGroup() (token, error) {
+	if p.peek().text == "<" {
+		start, prev := p.pos, p.prev
+		if tok, err := p.typeGroup(); err == nil && (p.peek().text == "(" || p.peek().text == "[" || p.peek().text == "{") {
+			return tok, nil
+		}
+		p.pos, p.prev = start, prev
+		return p.next(), nil
+	}
+	return p.balanced()
+}
+
+// skip skips a declaration that could not be parsed, up to its semicolon
+// or its body in braces
+func (p *parser) skip() {
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF, tok.text == "}":
+			return
+		case tok.text == ";":
+			p.next()
+			return
+		case tok.text == "{":
+			p.balanced()
+			if p.peek().text == ";" {
+				p.next()
+			}
+			return
+		}
+		p.next()
+	}
+}
+
+// comments returns the comments before the next token as standalone
+// comments
+func (p *parser) comments() []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, c := range p.toks[p.pos].leading {
+		nodes = append(nodes, &ir.DistilledComment{
+			BaseNode: ir.BaseNode{Location: ir.Location{StartLine: c.line, EndLine: c.end}},
+			Text:     c.text,
+			Format:   c.format,
+		})
+	}
+	p.toks[p.pos].leading = nil
+	return nodes
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+		p.prev = tok
+	}
+	return tok
+}
+
+func (p *parser) expect(text string) (token, error) {
+	tok := p.peek()
+	if tok.text != text || tok.kind == tokenString {
+		return tok, p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) name(what string) (token, error) {
+	tok := p.peek()
+	if tok.kind != tokenName {
+		return tok, p.errorf(tok, "expected %s, found %q", what, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	if tok.kind == tokenEOF {
+		tok.text = "end of file"
+	}
+	return &parseError{tok: tok, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) addError(err error) {
+	perr, ok := err.(*parseError)
+	if !ok {
+		perr = &parseError{tok: p.peek(), msg: err.Error()}
+	}
+	p.file.Errors = append(p.file.Errors, ir.DistilledError{
+		BaseNode: ir.BaseNode{Location: p.location(perr.tok, perr.tok)},
+		Message:  perr.msg,
+		Severity: "error",
+	})
+}
+
+// text returns the source of a span of tokens with whitespace and comments
+// collapsed, e.g. a type
+func (p *parser) text(s span) string {
+	var b strings.Builder
+	for i := s.start; i < s.end; i++ {
+		tok := p.toks[i]
+		if i > s.start && p.needsSpace(p.toks[i-1], tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
+
+// source returns the source of a span of tokens as written
+func (p *parser) source(s span) string {
+	if s.end <= s.start {
+		return ""
+	}
+	return string(p.src[p.toks[s.start].start:p.toks[s.end-1].end])
+}
+
+// needsSpace reports whether a space separates two adjacent tokens. Line
+// breaks become spaces, except inside brackets.
+func (p *parser) needsSpace(prev, tok token) bool {
+	if prev.end == tok.start {
+		return false
+	}
+	if !strings.Contains(string(p.src[prev.end:tok.start]), "\n") {
+		return true
+	}
+	switch tok.text {
+	case ",", ")", "]", ">":
+		return false
+	}
+	switch prev.text {
+	case "(", "[", "<":
+		return false
+	}
+	return true
+}
+
+func (p *parser) location(start, end token) ir.Location {
+	// Strings and bodies span lines, so the end line is looked up by offset
+	endLine := sort.SearchInts(p.lines, end.end+1)
+	return ir.Location{
+		StartLine:   start.line,
+		StartColumn: start.start - p.lines[start.line-1] + 1,
+		EndLine:     endLine,
+		EndColumn:   end.end - p.lines[endLine-1] + 1,
+		StartByte:   start.start,
+		EndByte:     end.end,
+	}
+}
+
+// visibility returns the visibility of a declaration. Names starting with
+// an underscore are private to their library.
+func visibility(name string, decl declaration) ir.Visibility {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if strings.HasPrefix(name, "_") {
+		return ir.VisibilityPrivate
+	}
+	if decl.visibility != "" {
+		return decl.visibility
+	}
+	return ir.VisibilityPublic
+}
+
+// dartExtensions returns the extensions of a node, or nil if they hold no
+// metadata
+func dartExtensions(ext ir.DartExtensions) *ir.NodeExtensions {
+	if ext.Kind == "" && len(ext.On) == 0 && len(ext.Keywords) == 0 && ext.Optional == 0 &&
+		ext.Initializers == "" && len(ext.Hide) == 0 {
+		return nil
+	}
+	return &ir.NodeExtensions{Dart: &ext}
+}
+
+// stringValue returns the value of a string literal without its quotes
+func stringValue(s string) string {
+	s = strings.TrimLeft(s, "rR")
+	for _, quote := range []string{`'''`, `"""`, `'`, `"`} {
+		if strings.HasPrefix(s, quote) && strings.HasSuffix(s, quote) && len(s) >= 2*len(quote) {
+			return s[len(quote) : len(s)-len(quote)]
+		}
+	}
+	return s
+}
+
+func hasModifier(modifiers []ir.Modifier, modifier ir.Modifier) bool {
+	for _, m := range modifiers {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package dart
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/stripper"
+)
+
+// Processor handles Dart source code processing
+type Processor struct {
+	processor.BaseProcessor
+}
+
+// NewProcessor creates a new Dart processor
+func NewProcessor() *Processor {
+	return &Processor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"dart",
+			"1.0.0",
+			[]string{".dart"},
+		),
+	}
+}
+
+// Process implements processor.LanguageProcessor
+func (p *Processor) Process(ctx context.Context, reader io.Reader, filename string) (*ir.DistilledFile, error) {
+	// Read source code
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+
+	return Parse(source, filename), nil
+}
+
+// ProcessWithOptions implements processor.LanguageProcessor
+func (p *Processor) ProcessWithOptions(ctx context.Context, reader io.Reader, filename string, opts processor.ProcessOptions) (*ir.DistilledFile, error) {
+	file, err := p.Process(ctx, reader, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply stripper if any options are set
+	stripperOpts := opts.ToStripperOptions()
+	if stripperOpts.HasAnyOption() {
+		s := stripper.New(stripperOpts)
+		stripped := file.Accept(s)
+		if strippedFile, ok := stripped.(*ir.DistilledFile); ok {
+			return strippedFile, nil
+		}
+	}
+
+	return file, nil
+}
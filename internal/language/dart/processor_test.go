@@ -0,0 +1,236 @@
+package dart
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessDirectives(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `library shapes;
+
+import 'dart:math' as math show pi;
+import 'src/heavy.dart' deferred as heavy hide Unused;
+export 'src/point.dart';
+part of 'shapes.dart';
+`, "test.dart")
+	require.Empty(t, file.Errors)
+	require.Len(t, file.Children, 5)
+
+	library := file.Children[0].(*ir.DistilledPackage)
+	assert.Equal(t, "shapes", library.Name)
+
+	math := file.Children[1].(*ir.DistilledImport)
+	assert.Equal(t, "dart:math", math.Module)
+	assert.Equal(t, []ir.ImportedSymbol{{Name: "*", Alias: "math"}, {Name: "pi"}}, math.Symbols)
+
+	heavy := file.Children[2].(*ir.DistilledImport)
+	assert.Equal(t, []string{"deferred"}, heavy.Extensions.Dart.Keywords)
+	assert.Equal(t, []string{"Unused"}, heavy.Extensions.Dart.Hide)
+
+	assert.Equal(t, "export", file.Children[3].(*ir.DistilledImport).ImportType)
+	assert.Equal(t, "part of", file.Children[4].(*ir.DistilledImport).ImportType)
+}
+
+func TestProcessClasses(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+/// A shape.
+abstract class Shape<T extends num> extends Base with Printable implements Comparable<Shape> {
+  const Shape(this.name, [int? id]);
+  factory Shape.parse(String text) => Circle(1);
+  Shape.named({required this.name}) : id = 0;
+
+  final String name;
+  int _id = 0, count = 1;
+
+  double get area;
+  set id(int value) => _id = value;
+
+  @protected
+  void scale(double factor);
+
+  @override
+  bool operator ==(Object other) {
+    return other is Shape && other.name == name;
+  }
+
+  static Future<void> load() async {}
+}
+`, "test.dart")
+	require.Empty(t, file.Errors)
+	require.Len(t, file.Children, 2)
+	assert.Equal(t, "doc", file.Children[0].(*ir.DistilledComment).Format)
+
+	shape := file.Children[1].(*ir.DistilledClass)
+	assert.Equal(t, "Shape", shape.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierAbstract}, shape.Modifiers)
+	assert.Equal(t, []ir.TypeParam{{Name: "T", Constraints: []ir.TypeRef{{Name: "num"}}}}, shape.TypeParams)
+	assert.Equal(t, []ir.TypeRef{{Name: "Base"}}, shape.Extends)
+	assert.Equal(t, []ir.TypeRef{{Name: "Printable"}}, shape.Mixins)
+	assert.Equal(t, []ir.TypeRef{{Name: "Comparable<Shape>"}}, shape.Implements)
+	require.Len(t, shape.Children, 11)
+
+	constructor := shape.Children[0].(*ir.DistilledFunction)
+	assert.Equal(t, "constructor", constructor.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierConst}, constructor.Modifiers)
+	assert.Equal(t, "this.name", constructor.Parameters[0].Name)
+	assert.True(t, constructor.Parameters[1].IsOptional)
+	assert.Equal(t, 1, constructor.Extensions.Dart.Optional)
+	assert.False(t, constructor.Extensions.Dart.Named)
+
+	factory := shape.Children[1].(*ir.DistilledFunction)
+	assert.Equal(t, "constructor.parse", factory.Name)
+	assert.Equal(t, "factory", factory.Extensions.Dart.Kind)
+	assert.Equal(t, "=> Circle(1)", factory.Implementation)
+
+	named := shape.Children[2].(*ir.DistilledFunction)
+	assert.Equal(t, ": id = 0", named.Extensions.Dart.Initializers)
+	assert.True(t, named.Extensions.Dart.Named)
+
+	// Fields declared together share their type
+	id := shape.Children[4].(*ir.DistilledField)
+	assert.Equal(t, ir.VisibilityPrivate, id.Visibility)
+	count := shape.Children[5].(*ir.DistilledField)
+	assert.Equal(t, "int", count.Type.Name)
+	assert.Equal(t, ir.VisibilityPublic, count.Visibility)
+
+	area := shape.Children[6].(*ir.DistilledFunction)
+	assert.Equal(t, "getter", area.Extensions.Dart.Kind)
+	assert.Equal(t, []ir.Modifier{ir.ModifierAbstract}, area.Modifiers)
+
+	setter := shape.Children[7].(*ir.DistilledFunction)
+	assert.Equal(t, "setter", setter.Extensions.Dart.Kind)
+
+	scale := shape.Children[8].(*ir.DistilledFunction)
+	assert.Equal(t, ir.VisibilityProtected, scale.Visibility)
+
+	equals := shape.Children[9].(*ir.DistilledFunction)
+	assert.Equal(t, "operator ==", equals.Name)
+	assert.Contains(t, equals.Modifiers, ir.ModifierOverride)
+}
+
+func TestProcessMixinsAndExtensions(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+base mixin Printable on Shape {
+  late final String label;
+}
+
+extension ShapeList<T extends Shape> on List<T> {
+  double get totalArea => fold(0, (sum, s) => sum + s.area);
+}
+
+extension type const Meters(double value) implements double {}
+`, "test.dart")
+	require.Empty(t, file.Errors)
+	require.Len(t, file.Children, 3)
+
+	printable := file.Children[0].(*ir.DistilledClass)
+	assert.Equal(t, "mixin", printable.Extensions.Dart.Kind)
+	assert.Equal(t, []string{"Shape"}, printable.Extensions.Dart.On)
+	assert.Equal(t, []string{"base"}, printable.Extensions.Dart.Keywords)
+	label := printable.Children[0].(*ir.DistilledField)
+	assert.Equal(t, []string{"late"}, label.Extensions.Dart.Keywords)
+
+	list := file.Children[1].(*ir.DistilledClass)
+	assert.Equal(t, "extension", list.Extensions.Dart.Kind)
+	assert.Equal(t, []string{"List<T>"}, list.Extensions.Dart.On)
+
+	meters := file.Children[2].(*ir.DistilledClass)
+	assert.Equal(t, "extension_type", meters.Extensions.Dart.Kind)
+	require.Len(t, meters.Children, 2)
+	assert.Equal(t, []string{"@Primary"}, meters.Children[0].(*ir.DistilledFunction).Decorators)
+	assert.Equal(t, "value", meters.Children[1].(*ir.DistilledField).Name)
+}
+
+func TestProcessEnums(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+enum Color { red, green }
+
+enum Planet {
+  earth(mass: 5.976e+24);
+
+  const Planet({required this.mass});
+  final double mass;
+}
+`, "test.dart")
+	require.Empty(t, file.Errors)
+	color := file.Children[0].(*ir.DistilledClass)
+	assert.Contains(t, color.Modifiers, ir.ModifierEnum)
+	require.Len(t, color.Children, 2)
+	red := color.Children[0].(*ir.DistilledField)
+	assert.Equal(t, "Color", red.Type.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierStatic, ir.ModifierFinal}, red.Modifiers)
+
+	planet := file.Children[1].(*ir.DistilledClass)
+	require.Len(t, planet.Children, 3)
+	assert.Equal(t, "(mass: 5.976e+24)", planet.Children[0].(*ir.DistilledField).DefaultValue)
+}
+
+func TestProcessRecovery(t *testing.T) {
+	file, err := NewProcessor().Process(context.Background(), strings.NewReader(`
+class Broken {
+  void ok() {}
+  ) ;
+}
+
+void after() {}
+`), "broken.dart")
+	require.NoError(t, err)
+	assert.NotEmpty(t, file.Errors)
+	require.Len(t, file.Children, 2)
+	assert.Equal(t, "after", file.Children[1].(*ir.DistilledFunction).Name)
+}
+
+func TestFormatText(t *testing.T) {
+	source := `library shapes;
+
+/// A circle.
+final class Circle extends Shape {
+  Circle(this.radius, {String name = 'circle'}) : super(name);
+
+  final double radius;
+  final _cache = <String, int>{};
+
+  @override
+  double get area {
+    return 3.14 * radius * radius;
+  }
+}
+
+enum Color { red, green }
+`
+	format := func(opts processor.ProcessOptions) string {
+		return langtest.FormatText(t, NewProcessor(), source, "circle.dart", opts)
+	}
+
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeImplementation = false
+	opts.IncludePrivate = false
+	assert.Equal(t, `<file path="circle.dart">
+library shapes;
+/// A circle.
+final class Circle extends Shape {
+    Circle(this.radius, {String name = 'circle'}) : super(name);
+    final double radius;
+    @override
+    double get area;
+}
+enum Color {
+    red,
+    green
+}
+</file>
+`, format(opts))
+
+	opts.IncludeImplementation = true
+	opts.IncludePrivate = true
+	output := format(opts)
+	assert.Contains(t, output, "    final _cache = <String, int>{};\n")
+	assert.Contains(t, output, "    double get area {\n      return 3.14 * radius * radius;\n    }\n")
+}
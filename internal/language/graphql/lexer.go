@@ -0,0 +1,190 @@
+package graphql
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+// token is a lexical token with the # comments before it
+type token struct {
+	kind  tokenKind
+	text  string
+	line  int
+	start int
+	end   int
+
+	// leading are the comments between the previous token and this one
+	leading []comment
+}
+
+// comment is a # comment with the marker removed
+type comment struct {
+	text string
+	line int
+}
+
+// tokenize splits GraphQL source into tokens. Commas are insignificant in
+// GraphQL and are skipped like whitespace.
+func tokenize(src []byte) []token {
+	var tokens []token
+	var pending []comment
+	line := 1
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case c == 0xEF && i+2 < len(src) && src[i+1] == 0xBB && src[i+2] == 0xBF:
+			// Byte order mark
+			i += 3
+		case c == '#':
+			end := i
+			for end < len(src) && src[end] != '\n' {
+				end++
+			}
+			pending = append(pending, comment{text: strings.TrimSpace(string(src[i+1 : end])), line: line})
+			i = end
+		default:
+			tok := token{line: line, start: i, leading: pending}
+			pending = nil
+			switch {
+			case isNameStart(c):
+				tok.kind = tokenName
+				i++
+				for i < len(src) && (isNameStart(src[i]) || isDigit(src[i])) {
+					i++
+				}
+			case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+				tok.kind = tokenNumber
+				i++
+				for i < len(src) {
+					if isNameStart(src[i]) || isDigit(src[i]) || src[i] == '.' {
+						i++
+					} else if (src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E') {
+						i++
+					} else {
+						break
+					}
+				}
+			case strings.HasPrefix(string(src[i:min(i+3, len(src))]), `"""`):
+				tok.kind = tokenString
+				i += 3
+				for i < len(src) && !strings.HasPrefix(string(src[i:min(i+3, len(src))]), `"""`) {
+					if src[i] == '\\' && strings.HasPrefix(string(src[i+1:min(i+4, len(src))]), `"""`) {
+						i += 4
+						continue
+					}
+					if src[i] == '\n' {
+						line++
+					}
+					i++
+				}
+				i = min(i+3, len(src))
+			case c == '"':
+				tok.kind = tokenString
+				i++
+				for i < len(src) && src[i] != '"' && src[i] != '\n' {
+					if src[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				if i < len(src) && src[i] == '"' {
+					i++
+				}
+			case c == '.' && strings.HasPrefix(string(src[i:min(i+3, len(src))]), "..."):
+				tok.kind = tokenPunct
+				i += 3
+			default:
+				tok.kind = tokenPunct
+				i++
+			}
+			tok.end = min(i, len(src))
+			tok.text = string(src[tok.start:tok.end])
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, line: line, start: len(src), end: len(src), leading: pending})
+}
+
+// stringValue returns the value of a string or block string token
+func stringValue(s string) string {
+	if strings.HasPrefix(s, `"""`) {
+		return blockStringValue(strings.TrimSuffix(strings.TrimPrefix(s, `"""`), `"""`))
+	}
+
+	s = strings.TrimSuffix(strings.TrimPrefix(s, `"`), `"`)
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r', 'b', 'f':
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// blockStringValue removes the common indentation and the leading and
+// trailing blank lines of a block string, as the GraphQL spec defines
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, `\"""`, `"""`), "\n")
+
+	indent := -1
+	for _, l := range lines[1:] {
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(l) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if indent > 0 && len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		}
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,840 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// rootOperations are the default names of the root operation types
+var rootOperations = map[string]string{
+	"query":        "Query",
+	"mutation":     "Mutation",
+	"subscription": "Subscription",
+}
+
+// definitionKeywords start a type system or executable definition
+var definitionKeywords = map[string]bool{
+	"schema": true, "scalar": true, "type": true, "interface": true, "union": true,
+	"enum": true, "input": true, "directive": true, "extend": true,
+	"query": true, "mutation": true, "subscription": true, "fragment": true,
+}
+
+// parser is a recursive descent parser for GraphQL schemas (SDL) and
+// executable documents. It is lenient: a definition it cannot parse is
+// recorded as an error and skipped.
+type parser struct {
+	src   []byte
+	toks  []token
+	pos   int
+	prev  token
+	lines []int
+	file  *ir.DistilledFile
+	roots map[string]string
+}
+
+// parseError is a syntax error at a token
+type parseError struct {
+	tok token
+	msg string
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.tok.line, e.msg)
+}
+
+// Parse parses GraphQL source into a distilled file
+func Parse(src []byte, filename string) *ir.DistilledFile {
+	p := &parser{
+		src:  src,
+		toks: tokenize(src),
+		file: &ir.DistilledFile{
+			Path:     filename,
+			Language: "graphql",
+			Children: []ir.DistilledNode{},
+			Errors:   []ir.DistilledError{},
+		},
+		roots: map[string]string{},
+	}
+	for op, name := range rootOperations {
+		p.roots[op] = name
+	}
+	p.lines = []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+
+	p.file.Children = p.parseBody(p.parseDefinition, true)
+	if len(p.toks) > 1 {
+		p.file.Location = p.location(p.toks[0], p.toks[len(p.toks)-2])
+	}
+	p.markRootFields()
+	return p.file
+}
+
+// parseBody parses items with parseItem until a closing brace or the end of
+// the file. Comments before the end become standalone comments.
+func (p *parser) parseBody(parseItem func() ([]ir.DistilledNode, error), topLevel bool) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.text == "}" {
+			return append(nodes, p.comments()...)
+		}
+
+		start := p.pos
+		item, err := parseItem()
+		if err != nil {
+			p.addError(err)
+			p.pos = start
+			nodes = append(nodes, p.comments()...)
+			p.skip(topLevel)
+			continue
+		}
+		nodes = append(nodes, item...)
+	}
+}
+
+func (p *parser) parseDefinition() ([]ir.DistilledNode, error) {
+	nodes := p.comments()
+	nodes = append(nodes, p.description()...)
+
+	start := p.peek()
+	extend := false
+	if start.text == "extend" {
+		p.next()
+		extend = true
+	}
+
+	var node ir.DistilledNode
+	var err error
+	switch keyword := p.peek(); {
+	case keyword.kind == tokenName && keyword.text == "schema":
+		node, err = p.parseSchema(start, extend)
+	case keyword.kind == tokenName && (keyword.text == "type" || keyword.text == "input"):
+		node, err = p.parseObject(start, extend)
+	case keyword.kind == tokenName && keyword.text == "interface":
+		node, err = p.parseInterface(start, extend)
+	case keyword.kind == tokenName && (keyword.text == "union" || keyword.text == "scalar"):
+		node, err = p.parseAlias(start, extend)
+	case keyword.kind == tokenName && keyword.text == "enum":
+		node, err = p.parseEnum(start, extend)
+	case !extend && keyword.kind == tokenName && keyword.text == "directive":
+		node, err = p.parseDirectiveDefinition(start)
+	case !extend && keyword.kind == tokenName && keyword.text == "fragment":
+		node, err = p.parseFragment(start)
+	case !extend && (keyword.text == "{" || (keyword.kind == tokenName && rootOperations[keyword.text] != "")):
+		node, err = p.parseOperation(start)
+	default:
+		return nil, p.errorf(keyword, "unexpected %q", keyword.text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(nodes, node), nil
+}
+
+// parseSchema parses "schema { query: Query ... }" into a struct with a
+// field per root operation type
+func (p *parser) parseSchema(start token, extend bool) (ir.DistilledNode, error) {
+	p.next()
+	schema := &ir.DistilledStruct{
+		Name:       "schema",
+		Visibility: ir.VisibilityPublic,
+	}
+	ext := &ir.GraphQLExtensions{Kind: "schema", IsExtend: extend, Directives: p.directives()}
+	schema.Extensions = &ir.NodeExtensions{GraphQL: ext}
+
+	if p.peek().text == "{" {
+		children, err := p.parseBlock(func() ([]ir.DistilledNode, error) {
+			nodes := p.comments()
+			op, err := p.name("operation type")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			typ, err := p.name("root type name")
+			if err != nil {
+				return nil, err
+			}
+			p.roots[op.text] = typ.text
+			return append(nodes, &ir.DistilledField{
+				BaseNode:   ir.BaseNode{Location: p.location(op, typ)},
+				Name:       op.text,
+				Visibility: ir.VisibilityPublic,
+				Type:       &ir.TypeRef{Name: typ.text},
+			}), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		schema.Children = children
+	}
+	schema.Location = p.location(start, p.prev)
+	return schema, nil
+}
+
+// parseObject parses object and input object types into structs
+func (p *parser) parseObject(start token, extend bool) (ir.DistilledNode, error) {
+	keyword := p.next()
+	name, err := p.name(keyword.text + " name")
+	if err != nil {
+		return nil, err
+	}
+	ext := &ir.GraphQLExtensions{Kind: keyword.text, IsExtend: extend}
+	if ext.Implements, err = p.implements(); err != nil {
+		return nil, err
+	}
+	ext.Directives = p.directives()
+
+	object := &ir.DistilledStruct{
+		BaseNode:   ir.BaseNode{Extensions: &ir.NodeExtensions{GraphQL: ext}},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if p.peek().text == "{" {
+		parseItem := p.parseFieldDefinition
+		if keyword.text == "input" {
+			parseItem = p.parseInputField
+		}
+		if object.Children, err = p.parseBlock(parseItem); err != nil {
+			return nil, err
+		}
+	}
+	object.Location = p.location(start, p.prev)
+	return object, nil
+}
+
+func (p *parser) parseInterface(start token, extend bool) (ir.DistilledNode, error) {
+	p.next()
+	name, err := p.name("interface name")
+	if err != nil {
+		return nil, err
+	}
+	ext := &ir.GraphQLExtensions{Kind: "interface", IsExtend: extend}
+	if ext.Implements, err = p.implements(); err != nil {
+		return nil, err
+	}
+	ext.Directives = p.directives()
+
+	iface := &ir.DistilledInterface{
+		BaseNode:   ir.BaseNode{Extensions: &ir.NodeExtensions{GraphQL: ext}},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if p.peek().text == "{" {
+		if iface.Children, err = p.parseBlock(p.parseFieldDefinition); err != nil {
+			return nil, err
+		}
+	}
+	iface.Location = p.location(start, p.prev)
+	return iface, nil
+}
+
+// parseAlias parses unions and custom scalars into type aliases. The type of
+// a union lists its members separated by "|".
+func (p *parser) parseAlias(start token, extend bool) (ir.DistilledNode, error) {
+	keyword := p.next()
+	name, err := p.name(keyword.text + " name")
+	if err != nil {
+		return nil, err
+	}
+	ext := &ir.GraphQLExtensions{Kind: keyword.text, IsExtend: extend, Directives: p.directives()}
+
+	var members []string
+	if keyword.text == "union" && p.peek().text == "=" {
+		p.next()
+		if p.peek().text == "|" {
+			p.next()
+		}
+		for {
+			member, err := p.name("union member")
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, member.text)
+			if p.peek().text != "|" {
+				break
+			}
+			p.next()
+		}
+	}
+
+	return &ir.DistilledTypeAlias{
+		BaseNode: ir.BaseNode{
+			Location:   p.location(start, p.prev),
+			Extensions: &ir.NodeExtensions{GraphQL: ext},
+		},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+		Type:       ir.TypeRef{Name: strings.Join(members, " | ")},
+	}, nil
+}
+
+func (p *parser) parseEnum(start token, extend bool) (ir.DistilledNode, error) {
+	p.next()
+	name, err := p.name("enum name")
+	if err != nil {
+		return nil, err
+	}
+	enum := &ir.DistilledEnum{
+		BaseNode: ir.BaseNode{Extensions: &ir.NodeExtensions{GraphQL: &ir.GraphQLExtensions{
+			Kind:       "enum",
+			IsExtend:   extend,
+			Directives: p.directives(),
+		}}},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if p.peek().text == "{" {
+		enum.Children, err = p.parseBlock(func() ([]ir.DistilledNode, error) {
+			nodes := p.comments()
+			nodes = append(nodes, p.description()...)
+			value, err := p.name("enum value")
+			if err != nil {
+				return nil, err
+			}
+			return append(nodes, &ir.DistilledField{
+				Name:       value.text,
+				Visibility: ir.VisibilityPublic,
+				Decorators: p.directives(),
+				BaseNode:   ir.BaseNode{Location: p.location(value, p.prev)},
+			}), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	enum.Location = p.location(start, p.prev)
+	return enum, nil
+}
+
+// parseDirectiveDefinition parses "directive @name(args) on LOCATION | ..."
+func (p *parser) parseDirectiveDefinition(start token) (ir.DistilledNode, error) {
+	p.next()
+	if _, err := p.expect("@"); err != nil {
+		return nil, err
+	}
+	name, err := p.name("directive name")
+	if err != nil {
+		return nil, err
+	}
+	fn := &ir.DistilledFunction{
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if p.peek().text == "(" {
+		if fn.Parameters, err = p.argumentDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+
+	ext := &ir.GraphQLExtensions{Kind: "directive"}
+	if p.peek().text == "repeatable" {
+		p.next()
+		ext.Repeatable = true
+	}
+	if _, err := p.expect("on"); err != nil {
+		return nil, err
+	}
+	if p.peek().text == "|" {
+		p.next()
+	}
+	for {
+		location, err := p.name("directive location")
+		if err != nil {
+			return nil, err
+		}
+		ext.Locations = append(ext.Locations, location.text)
+		if p.peek().text != "|" {
+			break
+		}
+		p.next()
+	}
+
+	fn.Location = p.location(start, p.prev)
+	fn.Extensions = &ir.NodeExtensions{GraphQL: ext}
+	return fn, nil
+}
+
+// parseOperation parses a query, mutation or subscription into a function
+// with the variables as parameters and the selection set as implementation
+func (p *parser) parseOperation(start token) (ir.DistilledNode, error) {
+	fn := &ir.DistilledFunction{Visibility: ir.VisibilityPublic}
+	ext := &ir.GraphQLExtensions{Kind: "query"}
+
+	if p.peek().text != "{" {
+		ext.Kind = p.next().text
+		if p.peek().kind == tokenName {
+			fn.Name = p.next().text
+		}
+		if p.peek().text == "(" {
+			var err error
+			if fn.Parameters, err = p.variableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+		fn.Decorators = p.directives()
+	}
+
+	selection, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	fn.Implementation = selection
+	fn.Location = p.location(start, p.prev)
+	fn.Extensions = &ir.NodeExtensions{GraphQL: ext}
+	return fn, nil
+}
+
+// parseFragment parses "fragment Name on Type { ... }" into a function
+func (p *parser) parseFragment(start token) (ir.DistilledNode, error) {
+	p.next()
+	name, err := p.name("fragment name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("on"); err != nil {
+		return nil, err
+	}
+	typ, err := p.name("type condition")
+	if err != nil {
+		return nil, err
+	}
+	fn := &ir.DistilledFunction{
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+		Decorators: p.directives(),
+	}
+	if fn.Implementation, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	fn.Location = p.location(start, p.prev)
+	fn.Extensions = &ir.NodeExtensions{GraphQL: &ir.GraphQLExtensions{Kind: "fragment", TypeCondition: typ.text}}
+	return fn, nil
+}
+
+// parseFieldDefinition parses a field of an object type or interface. Fields
+// with arguments become functions.
+func (p *parser) parseFieldDefinition() ([]ir.DistilledNode, error) {
+	nodes := p.comments()
+	nodes = append(nodes, p.description()...)
+
+	name, err := p.name("field name")
+	if err != nil {
+		return nil, err
+	}
+	var params []ir.Parameter
+	hasArguments := p.peek().text == "("
+	if hasArguments {
+		if params, err = p.argumentDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	directives := p.directives()
+	location := p.location(name, p.prev)
+
+	if hasArguments {
+		return append(nodes, &ir.DistilledFunction{
+			BaseNode:   ir.BaseNode{Location: location},
+			Name:       name.text,
+			Visibility: ir.VisibilityPublic,
+			Decorators: directives,
+			Parameters: params,
+			Returns:    &ir.TypeRef{Name: typ},
+		}), nil
+	}
+	return append(nodes, &ir.DistilledField{
+		BaseNode:   ir.BaseNode{Location: location},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+		Type:       &ir.TypeRef{Name: typ},
+		Decorators: directives,
+	}), nil
+}
+
+// parseInputField parses a field of an input object type
+func (p *parser) parseInputField() ([]ir.DistilledNode, error) {
+	nodes := p.comments()
+	nodes = append(nodes, p.description()...)
+
+	start := p.peek()
+	param, err := p.inputValue()
+	if err != nil {
+		return nil, err
+	}
+	return append(nodes, &ir.DistilledField{
+		BaseNode:     ir.BaseNode{Location: p.location(start, p.prev)},
+		Name:         param.Name,
+		Visibility:   ir.VisibilityPublic,
+		Type:         &ir.TypeRef{Name: param.Type.Name},
+		DefaultValue: param.DefaultValue,
+		Decorators:   param.Decorators,
+	}), nil
+}
+
+// markRootFields turns the fields of the root operation types into
+// functions, as they are the entry points of the API
+func (p *parser) markRootFields() {
+	roots := map[string]bool{}
+	for _, name := range p.roots {
+		roots[name] = true
+	}
+	for _, node := range p.file.Children {
+		object, ok := node.(*ir.DistilledStruct)
+		if !ok || !roots[object.Name] || object.Extensions.GraphQL.Kind != "type" {
+			continue
+		}
+		for i, child := range object.Children {
+			if field, ok := child.(*ir.DistilledField); ok {
+				object.Children[i] = &ir.DistilledFunction{
+					BaseNode:   field.BaseNode,
+					Name:       field.Name,
+					Visibility: field.Visibility,
+					Decorators: field.Decorators,
+					Parameters: []ir.Parameter{},
+					Returns:    field.Type,
+				}
+			}
+		}
+	}
+}
+
+// parseBlock parses "{ items }"
+func (p *parser) parseBlock(parseItem func() ([]ir.DistilledNode, error)) ([]ir.DistilledNode, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	children := p.parseBody(parseItem, false)
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// argumentDefinitions parses "(name: Type = default @directive, ...)"
+func (p *parser) argumentDefinitions() ([]ir.Parameter, error) {
+	p.next()
+	params := []ir.Parameter{}
+	for p.peek().text != ")" {
+		p.comments()
+		p.description()
+		param, err := p.inputValue()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	p.next()
+	return params, nil
+}
+
+// variableDefinitions parses "($name: Type = default, ...)"
+func (p *parser) variableDefinitions() ([]ir.Parameter, error) {
+	p.next()
+	params := []ir.Parameter{}
+	for p.peek().text != ")" {
+		p.comments()
+		if _, err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		param, err := p.inputValue()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	p.next()
+	return params, nil
+}
+
+// inputValue parses "name: Type = default @directive"
+func (p *parser) inputValue() (ir.Parameter, error) {
+	name, err := p.name("argument name")
+	if err != nil {
+		return ir.Parameter{}, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return ir.Parameter{}, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return ir.Parameter{}, err
+	}
+	param := ir.Parameter{Name: name.text, Type: ir.TypeRef{Name: typ}}
+	if p.peek().text == "=" {
+		p.next()
+		if param.DefaultValue, err = p.value(); err != nil {
+			return ir.Parameter{}, err
+		}
+	}
+	param.Decorators = p.directives()
+	return param, nil
+}
+
+// implements parses "implements A & B"
+func (p *parser) implements() ([]string, error) {
+	if p.peek().text != "implements" {
+		return nil, nil
+	}
+	p.next()
+	if p.peek().text == "&" {
+		p.next()
+	}
+	var names []string
+	for {
+		name, err := p.name("interface name")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name.text)
+		if p.peek().text != "&" {
+			return names, nil
+		}
+		p.next()
+	}
+}
+
+// typeRef parses a type such as "[User!]!" and returns it as written
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if p.peek().text == "[" {
+		p.next()
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if _, err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name("type")
+		if err != nil {
+			return "", err
+		}
+		typ = name.text
+	}
+	if p.peek().text == "!" {
+		p.next()
+		typ += "!"
+	}
+	return typ, nil
+}
+
+// directives parses applied directives such as @deprecated(reason: "x")
+func (p *parser) directives() []string {
+	var directives []string
+	for p.peek().text == "@" && p.peekAt(1).kind == tokenName {
+		start := p.next()
+		p.next()
+		if p.peek().text == "(" {
+			if _, err := p.balanced(); err != nil {
+				break
+			}
+		}
+		directives = append(directives, string(p.src[start.start:p.prev.end]))
+	}
+	return directives
+}
+
+// value parses a constant or variable and returns it as written
+func (p *parser) value() (string, error) {
+	start := p.peek()
+	switch {
+	case start.text == "[" || start.text == "{":
+		if _, err := p.balanced(); err != nil {
+			return "", err
+		}
+	case start.text == "$":
+		p.next()
+		if _, err := p.name("variable name"); err != nil {
+			return "", err
+		}
+	case start.kind == tokenName || start.kind == tokenNumber || start.kind == tokenString:
+		p.next()
+	default:
+		return "", p.errorf(start, "expected value, found %q", start.text)
+	}
+	return string(p.src[start.start:p.prev.end]), nil
+}
+
+// selectionSet parses a selection set and returns it as written
+func (p *parser) selectionSet() (string, error) {
+	start := p.peek()
+	if start.text != "{" {
+		return "", p.errorf(start, "expected selection set, found %q", start.text)
+	}
+	if _, err := p.balanced(); err != nil {
+		return "", err
+	}
+	return string(p.src[start.start:p.prev.end]), nil
+}
+
+// balanced skips a bracketed group starting at the next token
+func (p *parser) balanced() (token, error) {
+	open := p.peek()
+	depth := 0
+	for {
+		tok := p.next()
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		if tok.kind == tokenEOF {
+			return tok, p.errorf(open, "unclosed %q", open.text)
+		}
+		if depth == 0 {
+			return tok, nil
+		}
+	}
+}
+
+// skip skips past the current item: to the next definition at the top
+// level, or to the next line or closing brace inside a block. Only braces
+// are counted, as an unclosed parenthesis is a common error.
+func (p *parser) skip(topLevel bool) {
+	first := p.next()
+	depth := 0
+	if first.text == "{" {
+		depth++
+	}
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return
+		}
+		if depth == 0 {
+			if tok.text == "}" && !topLevel {
+				return
+			}
+			if topLevel && (definitionKeywords[tok.text] || tok.kind == tokenString) && tok.line > first.line {
+				return
+			}
+			if !topLevel && tok.line > p.prev.line && (tok.kind == tokenName || tok.kind == tokenString) {
+				return
+			}
+		}
+		switch tok.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+		p.next()
+	}
+}
+
+// comments returns the # comments before the next token
+func (p *parser) comments() []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, c := range p.toks[p.pos].leading {
+		nodes = append(nodes, &ir.DistilledComment{
+			BaseNode: ir.BaseNode{Location: ir.Location{StartLine: c.line, EndLine: c.line}},
+			Text:     c.text,
+			Format:   "line",
+		})
+	}
+	p.toks[p.pos].leading = nil
+	return nodes
+}
+
+// description returns the description string before a definition as a doc
+// comment
+func (p *parser) description() []ir.DistilledNode {
+	tok := p.peek()
+	if tok.kind != tokenString {
+		return nil
+	}
+	p.next()
+	nodes := []ir.DistilledNode{&ir.DistilledComment{
+		BaseNode: ir.BaseNode{Location: p.location(tok, tok)},
+		Text:     stringValue(tok.text),
+		Format:   "doc",
+	}}
+	return append(p.comments(), nodes...)
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+		p.prev = tok
+	}
+	return tok
+}
+
+func (p *parser) expect(text string) (token, error) {
+	tok := p.peek()
+	if tok.text != text || tok.kind == tokenString {
+		return tok, p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) name(what string) (token, error) {
+	tok := p.peek()
+	if tok.kind != tokenName {
+		return tok, p.errorf(tok, "expected %s, found %q", what, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	if tok.kind == tokenEOF {
+		tok.text = "end of file"
+	}
+	return &parseError{tok: tok, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) addError(err error) {
+	perr, ok := err.(*parseError)
+	if !ok {
+		perr = &parseError{tok: p.peek(), msg: err.Error()}
+	}
+	p.file.Errors = append(p.file.Errors, ir.DistilledError{
+		BaseNode: ir.BaseNode{Location: p.location(perr.tok, perr.tok)},
+		Message:  perr.msg,
+		Severity: "error",
+	})
+}
+
+func (p *parser) location(start, end token) ir.Location {
+	// Block strings and selection sets span lines, so the end line is
+	// looked up by offset
+	endLine := sort.SearchInts(p.lines, end.end+1)
+	return ir.Location{
+		StartLine:   start.line,
+		StartColumn: start.start - p.lines[start.line-1] + 1,
+		EndLine:     endLine,
+		EndColumn:   end.end - p.lines[endLine-1] + 1,
+		StartByte:   start.start,
+		EndByte:     end.end,
+	}
+}
@@ -0,0 +1,220 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaSource = `# Users service
+
+"""
+Requires the given role.
+"""
+directive @auth(requires: Role = ADMIN) repeatable on OBJECT | FIELD_DEFINITION
+
+scalar DateTime
+
+schema {
+  query: RootQuery
+  mutation: Mutation
+}
+
+"A registered user"
+type User implements Node & Entity @key(fields: "id") {
+  id: ID!
+  """
+  Display name
+  with "quotes"
+  """
+  name: String @deprecated(reason: "Use fullName")
+  friends(first: Int = 10, after: String): [User!]!
+}
+
+interface Node {
+  id: ID!
+}
+
+union SearchResult = | User | Post
+
+enum Role {
+  ADMIN
+  USER @deprecated
+}
+
+input CreateUserInput {
+  name: String!
+  role: Role = USER
+}
+
+type RootQuery {
+  me: User
+  user(id: ID!): User @auth(requires: USER)
+}
+
+type Mutation {
+  createUser(input: CreateUserInput!): User!
+}
+
+extend type User {
+  age: Int
+}
+`
+
+const operationSource = `query GetUser($id: ID!, $withFriends: Boolean = false) @cached {
+  user(id: $id) {
+    ...UserParts
+  }
+}
+
+fragment UserParts on User {
+  id
+  name
+}
+
+{ me { id } }
+`
+
+func TestProcessSchema(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), schemaSource, "schema.graphql")
+	assert.Empty(t, file.Errors)
+	assert.Equal(t, "graphql", file.Language)
+
+	structs := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, structs, 6)
+	schema, user, input, query, mutation, extension := structs[0], structs[1], structs[2], structs[3], structs[4], structs[5]
+
+	assert.Equal(t, "schema", schema.Extensions.GraphQL.Kind)
+	assert.Len(t, schema.Children, 2)
+
+	assert.Equal(t, "type", user.Extensions.GraphQL.Kind)
+	assert.Equal(t, []string{"Node", "Entity"}, user.Extensions.GraphQL.Implements)
+	assert.Equal(t, []string{`@key(fields: "id")`}, user.Extensions.GraphQL.Directives)
+	fields := langtest.Nodes[*ir.DistilledField](user.Children)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "ID!", fields[0].Type.Name)
+	assert.Equal(t, []string{`@deprecated(reason: "Use fullName")`}, fields[1].Decorators)
+	friends := langtest.Nodes[*ir.DistilledFunction](user.Children)
+	require.Len(t, friends, 1)
+	assert.Equal(t, "[User!]!", friends[0].Returns.Name)
+	assert.Equal(t, []ir.Parameter{
+		{Name: "first", Type: ir.TypeRef{Name: "Int"}, DefaultValue: "10"},
+		{Name: "after", Type: ir.TypeRef{Name: "String"}},
+	}, friends[0].Parameters)
+
+	assert.Equal(t, "input", input.Extensions.GraphQL.Kind)
+	inputFields := langtest.Nodes[*ir.DistilledField](input.Children)
+	require.Len(t, inputFields, 2)
+	assert.Equal(t, "USER", inputFields[1].DefaultValue)
+
+	// Fields of the root operation types named in the schema are functions
+	queries := langtest.Nodes[*ir.DistilledFunction](query.Children)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "me", queries[0].Name)
+	assert.Equal(t, "User", queries[0].Returns.Name)
+	assert.Equal(t, []string{"@auth(requires: USER)"}, queries[1].Decorators)
+	assert.Len(t, langtest.Nodes[*ir.DistilledFunction](mutation.Children), 1)
+
+	assert.True(t, extension.Extensions.GraphQL.IsExtend)
+	assert.Equal(t, "User", extension.Name)
+
+	interfaces := langtest.Nodes[*ir.DistilledInterface](file.Children)
+	require.Len(t, interfaces, 1)
+	assert.Equal(t, "Node", interfaces[0].Name)
+
+	aliases := langtest.Nodes[*ir.DistilledTypeAlias](file.Children)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "scalar", aliases[0].Extensions.GraphQL.Kind)
+	assert.Equal(t, "union", aliases[1].Extensions.GraphQL.Kind)
+	assert.Equal(t, "User | Post", aliases[1].Type.Name)
+
+	enums := langtest.Nodes[*ir.DistilledEnum](file.Children)
+	require.Len(t, enums, 1)
+	values := langtest.Nodes[*ir.DistilledField](enums[0].Children)
+	require.Len(t, values, 2)
+	assert.Equal(t, []string{"@deprecated"}, values[1].Decorators)
+
+	directives := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, directives, 1)
+	assert.Equal(t, "auth", directives[0].Name)
+	assert.True(t, directives[0].Extensions.GraphQL.Repeatable)
+	assert.Equal(t, []string{"OBJECT", "FIELD_DEFINITION"}, directives[0].Extensions.GraphQL.Locations)
+}
+
+func TestProcessComments(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), schemaSource, "schema.graphql")
+
+	comments := langtest.Nodes[*ir.DistilledComment](file.Children)
+	require.Len(t, comments, 3)
+	assert.Equal(t, "Users service", comments[0].Text)
+	assert.Equal(t, "line", comments[0].Format)
+	assert.Equal(t, "Requires the given role.", comments[1].Text)
+	assert.Equal(t, "doc", comments[1].Format)
+	assert.Equal(t, "A registered user", comments[2].Text)
+}
+
+func TestProcessOperations(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), operationSource, "schema.graphql")
+	assert.Empty(t, file.Errors)
+
+	operations := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, operations, 3)
+
+	assert.Equal(t, "GetUser", operations[0].Name)
+	assert.Equal(t, "query", operations[0].Extensions.GraphQL.Kind)
+	assert.Equal(t, []string{"@cached"}, operations[0].Decorators)
+	require.Len(t, operations[0].Parameters, 2)
+	assert.Equal(t, "false", operations[0].Parameters[1].DefaultValue)
+	assert.True(t, strings.HasPrefix(operations[0].Implementation, "{\n  user(id: $id)"))
+
+	assert.Equal(t, "fragment", operations[1].Extensions.GraphQL.Kind)
+	assert.Equal(t, "User", operations[1].Extensions.GraphQL.TypeCondition)
+
+	assert.Equal(t, "", operations[2].Name)
+	assert.Equal(t, "{ me { id } }", operations[2].Implementation)
+}
+
+func TestProcessErrors(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `type A {
+  ok: String
+  broken(: Int
+  alsoOk: Int
+}
+
+type B = oops
+
+type C { c: Int }
+`, "schema.graphql")
+	require.Len(t, file.Errors, 2)
+	assert.Equal(t, 3, file.Errors[0].Location.StartLine)
+	assert.Equal(t, 7, file.Errors[1].Location.StartLine)
+
+	structs := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, structs, 3)
+	assert.Len(t, structs[0].Children, 2)
+	assert.Equal(t, "B", structs[1].Name)
+	assert.Equal(t, "C", structs[2].Name)
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	opts.IncludeImplementation = false
+	output := langtest.FormatText(t, NewProcessor(), schemaSource+operationSource, "schema.graphql", opts)
+
+	assert.NotContains(t, output, "Users service")
+	assert.Contains(t, output, "\"Requires the given role.\"\ndirective @auth(requires: Role = ADMIN) repeatable on OBJECT | FIELD_DEFINITION\n")
+	assert.Contains(t, output, "    \"\"\"\n    Display name\n    with \"quotes\"\n    \"\"\"\n    name: String")
+	assert.Contains(t, output, "\"A registered user\"\ntype User implements Node & Entity @key(fields: \"id\") {\n")
+	assert.Contains(t, output, "    friends(first: Int = 10, after: String): [User!]!\n")
+	assert.Contains(t, output, "union SearchResult = User | Post\n")
+	assert.Contains(t, output, "    role: Role = USER\n")
+	assert.Contains(t, output, "extend type User {\n")
+	assert.Contains(t, output, "query GetUser($id: ID!, $withFriends: Boolean = false) @cached\n")
+	assert.Contains(t, output, "fragment UserParts on User\n")
+}
@@ -0,0 +1,264 @@
+package hcl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configSource = `# Web tier
+terraform {
+  required_version = ">= 1.5"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = "~> 3.1"
+  }
+  backend "s3" {
+    bucket = "state"
+  }
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+variable "region" {
+  type        = string
+  default     = "eu-west-1"
+  description = "AWS region"
+}
+
+variable "db_password" {
+  type      = string
+  sensitive = true
+  description = <<-EOT
+    Master password.
+    Rotated monthly.
+  EOT
+}
+
+variable "subnets" {
+  type = list(object({
+    cidr = string
+    az   = string
+  }))
+  default = []
+}
+
+locals {
+  # Shared tags
+  tags = { Team = "web" }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+  cidr    = "10.0.0.0/16"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+resource "aws_instance" "web" {
+  count         = 2
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = "t3.micro"
+}
+
+output "ip" {
+  value       = aws_instance.web[0].public_ip
+  description = "Public IP"
+  sensitive   = true
+}
+`
+
+func TestProcessBlocks(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), configSource, "main.tf")
+	assert.Equal(t, "hcl", file.Language)
+
+	tests := []struct {
+		name      string
+		kind      string
+		labels    []string
+		arguments []string
+	}{
+		{"aws", "provider", []string{"aws"}, []string{`alias = "east"`}},
+		{"vpc", "module", []string{"vpc"}, []string{`source = "terraform-aws-modules/vpc/aws"`, `version = "5.1.0"`}},
+		{"aws_ami.ubuntu", "data", []string{"aws_ami", "ubuntu"}, nil},
+		{"aws_instance.web", "resource", []string{"aws_instance", "web"}, []string{"count = 2"}},
+	}
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, functions, len(tests))
+	for i, tt := range tests {
+		fn := functions[i]
+		assert.Equal(t, tt.name, fn.Name)
+		ext := fn.Extensions.HCL
+		assert.Equal(t, tt.kind, ext.Kind, tt.name)
+		assert.Equal(t, tt.labels, ext.Labels, tt.name)
+		assert.Equal(t, tt.arguments, ext.Arguments, tt.name)
+		assert.True(t, strings.HasPrefix(fn.Implementation, "{"), tt.name)
+		assert.True(t, strings.HasSuffix(fn.Implementation, "}"), tt.name)
+	}
+
+	resource := functions[3]
+	assert.Equal(t, 59, resource.Location.StartLine)
+	assert.Equal(t, 63, resource.Location.EndLine)
+}
+
+func TestProcessVariablesAndOutputs(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), configSource, "main.tf")
+
+	fields := langtest.Nodes[*ir.DistilledField](file.Children)
+	require.Len(t, fields, 5)
+
+	region := fields[0]
+	assert.Equal(t, "region", region.Name)
+	assert.Equal(t, "variable", region.Extensions.HCL.Kind)
+	assert.Equal(t, "string", region.Type.Name)
+	assert.Equal(t, `"eu-west-1"`, region.DefaultValue)
+
+	password := fields[1]
+	assert.Equal(t, "db_password", password.Name)
+	assert.True(t, password.Extensions.HCL.Sensitive)
+	assert.Empty(t, password.DefaultValue)
+
+	subnets := fields[2]
+	assert.Equal(t, "subnets", subnets.Name)
+	assert.Equal(t, "list(object({ cidr = string, az = string }))", subnets.Type.Name)
+
+	output := fields[4]
+	assert.Equal(t, "ip", output.Name)
+	assert.Equal(t, "output", output.Extensions.HCL.Kind)
+	assert.Equal(t, "aws_instance.web[0].public_ip", output.DefaultValue)
+	assert.True(t, output.Extensions.HCL.Sensitive)
+
+	// Descriptions become documentation comments before their block
+	var docs []string
+	for _, child := range file.Children {
+		if comment, ok := child.(*ir.DistilledComment); ok && comment.Format == "doc" {
+			docs = append(docs, comment.Text)
+		}
+	}
+	assert.Equal(t, []string{"AWS region", "Master password.\nRotated monthly.", "Public IP"}, docs)
+}
+
+func TestProcessLocals(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), configSource, "main.tf")
+
+	tags := langtest.Nodes[*ir.DistilledField](file.Children)[3]
+	assert.Equal(t, "tags", tags.Name)
+	assert.Equal(t, "local", tags.Extensions.HCL.Kind)
+	assert.Equal(t, ir.VisibilityPrivate, tags.Visibility)
+	assert.Equal(t, `{ Team = "web" }`, tags.DefaultValue)
+
+	var comments []string
+	for _, child := range file.Children {
+		if comment, ok := child.(*ir.DistilledComment); ok && comment.Format == "line" {
+			comments = append(comments, comment.Text)
+		}
+	}
+	assert.Equal(t, []string{"# Web tier", "# Shared tags"}, comments)
+}
+
+func TestProcessTerraform(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), configSource, "main.tf")
+	classes := langtest.Nodes[*ir.DistilledClass](file.Children)
+	require.Len(t, classes, 1)
+	class := classes[0]
+	assert.Equal(t, "terraform", class.Name)
+	require.Len(t, class.Children, 4)
+
+	version, ok := class.Children[0].(*ir.DistilledField)
+	require.True(t, ok)
+	assert.Equal(t, "required_version", version.Name)
+
+	aws, ok := class.Children[1].(*ir.DistilledImport)
+	require.True(t, ok)
+	assert.Equal(t, "hashicorp/aws", aws.Module)
+	assert.Equal(t, "aws", aws.Symbols[0].Name)
+	assert.Equal(t, "~> 5.0", aws.Extensions.HCL.Version)
+
+	random, ok := class.Children[2].(*ir.DistilledImport)
+	require.True(t, ok)
+	assert.Equal(t, "random", random.Module)
+	assert.Equal(t, "~> 3.1", random.Extensions.HCL.Version)
+
+	backend, ok := class.Children[3].(*ir.DistilledFunction)
+	require.True(t, ok)
+	assert.Equal(t, "backend", backend.Extensions.HCL.Kind)
+	assert.Equal(t, "s3", backend.Name)
+}
+
+func TestProcessTfvars(t *testing.T) {
+	source := `region = "eu-west-1"
+tags = {
+  Team = "web"
+}
+`
+	file := langtest.Process(t, NewProcessor(), source, "prod.tfvars")
+	require.Len(t, file.Children, 2)
+
+	tags, ok := file.Children[1].(*ir.DistilledField)
+	require.True(t, ok)
+	assert.Equal(t, "tags", tags.Name)
+	assert.Equal(t, "{\n  Team = \"web\"\n}", tags.DefaultValue)
+	assert.Nil(t, tags.Extensions)
+}
+
+func TestStringValue(t *testing.T) {
+	assert.Equal(t, "a \"b\"", stringValue(`"a \"b\""`))
+	assert.Equal(t, "one\n  two", stringValue("<<-EOT\n    one\n      two\n    EOT"))
+	assert.Equal(t, "  one", stringValue("<<EOT\n  one\nEOT"))
+	assert.Equal(t, "var.name", stringValue("var.name"))
+}
+
+func TestFormatText(t *testing.T) {
+	format := func(opts processor.ProcessOptions) string {
+		return langtest.FormatText(t, NewProcessor(), configSource, "main.tf", opts)
+	}
+
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	opts.IncludeImplementation = false
+	opts.IncludePrivate = false
+	assert.Equal(t, `<file path="main.tf">
+terraform {
+    required_version = ">= 1.5"
+    required_providers {
+        aws = { source = "hashicorp/aws", version = "~> 5.0" }
+        random = { version = "~> 3.1" }
+    }
+    backend "s3"
+}
+provider "aws" { alias = "east" }
+# AWS region
+variable "region" { type = string, default = "eu-west-1" }
+# Master password.
+# Rotated monthly.
+variable "db_password" { type = string, sensitive = true }
+variable "subnets" { type = list(object({ cidr = string, az = string })), default = [] }
+module "vpc" { source = "terraform-aws-modules/vpc/aws", version = "5.1.0" }
+data "aws_ami" "ubuntu"
+resource "aws_instance" "web" { count = 2 }
+# Public IP
+output "ip" { value = aws_instance.web[0].public_ip, sensitive = true }
+</file>
+`, format(opts))
+
+	opts.IncludeImplementation = true
+	opts.IncludePrivate = true
+	output := format(opts)
+	assert.Contains(t, output, "    backend \"s3\" {\n      bucket = \"state\"\n    }\n")
+	assert.Contains(t, output, "local.tags = { Team = \"web\" }\n")
+	assert.Contains(t, output, "resource \"aws_instance\" \"web\" {\n  count         = 2\n")
+}
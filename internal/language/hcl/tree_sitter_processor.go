@@ -0,0 +1,538 @@
+package hcl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	sitter "github.com/smacker/go-tree-sitter"
+	tree_sitter_hcl "github.com/smacker/go-tree-sitter/hcl"
+)
+
+var (
+	// summaryArguments are the arguments of a block that are kept when its
+	// body is left out, in the order they are shown
+	summaryArguments = []string{"source", "version", "alias", "provider", "count", "for_each"}
+
+	// heredoc matches the opening line of a heredoc string
+	heredoc = regexp.MustCompile(`^<<(-?)\s*(\w+)\s*\n`)
+)
+
+// TreeSitterProcessor uses tree-sitter for HCL parsing
+type TreeSitterProcessor struct {
+	parser *sitter.Parser
+}
+
+// NewTreeSitterProcessor creates a new tree-sitter based processor
+func NewTreeSitterProcessor() *TreeSitterProcessor {
+	parser := sitter.NewParser()
+	parser.SetLanguage(tree_sitter_hcl.GetLanguage())
+
+	return &TreeSitterProcessor{
+		parser: parser,
+	}
+}
+
+// attribute is an attribute of a block body
+type attribute struct {
+	node *sitter.Node
+	name string
+	// Expression of the value
+	value *sitter.Node
+}
+
+// block is a block with its type, labels and body
+type block struct {
+	node   *sitter.Node
+	typ    string
+	labels []string
+	// Body between the braces, nil for an empty block
+	body *sitter.Node
+}
+
+// ProcessSource processes HCL source code using tree-sitter
+func (p *TreeSitterProcessor) ProcessSource(ctx context.Context, source []byte, filename string) (*ir.DistilledFile, error) {
+	// Parse the source code
+	tree, err := p.parser.ParseCtx(ctx, nil, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HCL code: %w", err)
+	}
+	defer tree.Close()
+
+	// Create distilled file
+	file := &ir.DistilledFile{
+		BaseNode: ir.BaseNode{
+			Location: ir.Location{
+				StartLine: 1,
+				EndLine:   int(tree.RootNode().EndPoint().Row) + 1,
+			},
+		},
+		Path:     filename,
+		Language: "hcl",
+		Version:  "2",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+
+	// Comments before and after the body are children of the file
+	root := tree.RootNode()
+	for i := 0; i < int(root.ChildCount()); i++ {
+		child := root.Child(i)
+		switch child.Type() {
+		case "body":
+			file.Children = append(file.Children, p.processBody(child, source)...)
+		case "comment":
+			file.Children = append(file.Children, p.processComment(child, source))
+		}
+	}
+
+	return file, nil
+}
+
+// processBody processes the attributes, blocks and comments of the file body
+func (p *TreeSitterProcessor) processBody(body *sitter.Node, source []byte) []ir.DistilledNode {
+	var result []ir.DistilledNode
+	for i := 0; i < int(body.ChildCount()); i++ {
+		child := body.Child(i)
+		switch child.Type() {
+		case "attribute":
+			attr := p.attribute(child, source)
+			result = append(result, p.processAttribute(attr, source, "", ir.VisibilityPublic))
+		case "block":
+			result = append(result, p.processBlock(p.block(child, source), source)...)
+		case "comment":
+			result = append(result, p.processComment(child, source))
+		}
+	}
+	return result
+}
+
+// processBlock processes a top-level block
+func (p *TreeSitterProcessor) processBlock(b block, source []byte) []ir.DistilledNode {
+	switch b.typ {
+	case "variable":
+		return p.processVariable(b, source)
+	case "output":
+		return p.processOutput(b, source)
+	case "locals":
+		return p.processLocals(b, source)
+	case "terraform":
+		return []ir.DistilledNode{p.processTerraform(b, source)}
+	}
+	return []ir.DistilledNode{p.processGenericBlock(b, source)}
+}
+
+// processGenericBlock processes a resource, data source, module, provider or
+// any other block as a function whose body is the implementation
+func (p *TreeSitterProcessor) processGenericBlock(b block, source []byte) *ir.DistilledFunction {
+	ext := &ir.HCLExtensions{
+		Kind:   b.typ,
+		Labels: b.labels,
+	}
+	attrs := p.attributes(b.body, source)
+	for _, name := range summaryArguments {
+		for _, attr := range attrs {
+			if attr.name == name {
+				ext.Arguments = append(ext.Arguments, name+" = "+oneLine(p.nodeText(attr.value, source)))
+			}
+		}
+	}
+
+	implementation := ""
+	if start := p.childOfType(b.node, "block_start"); start != nil {
+		implementation = string(source[start.StartByte():b.node.EndByte()])
+	}
+
+	return &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(b.node),
+			Extensions: &ir.NodeExtensions{HCL: ext},
+		},
+		Name:           b.name(),
+		Visibility:     ir.VisibilityPublic,
+		Implementation: implementation,
+	}
+}
+
+// processVariable processes an input variable, preceded by its description
+func (p *TreeSitterProcessor) processVariable(b block, source []byte) []ir.DistilledNode {
+	field := p.blockField(b, "variable")
+	var description string
+	for _, attr := range p.attributes(b.body, source) {
+		text := p.nodeText(attr.value, source)
+		switch attr.name {
+		case "type":
+			field.Type = &ir.TypeRef{Name: oneLine(text)}
+		case "default":
+			field.DefaultValue = text
+		case "description":
+			description = stringValue(text)
+		case "sensitive":
+			field.Extensions.HCL.Sensitive = text == "true"
+		}
+	}
+	return p.describe(field, description, b.node)
+}
+
+// processOutput processes an output value, preceded by its description
+func (p *TreeSitterProcessor) processOutput(b block, source []byte) []ir.DistilledNode {
+	field := p.blockField(b, "output")
+	var description string
+	for _, attr := range p.attributes(b.body, source) {
+		text := p.nodeText(attr.value, source)
+		switch attr.name {
+		case "value":
+			field.DefaultValue = text
+		case "description":
+			description = stringValue(text)
+		case "sensitive":
+			field.Extensions.HCL.Sensitive = text == "true"
+		}
+	}
+	return p.describe(field, description, b.node)
+}
+
+// processLocals processes the local values of a locals block, which are
+// private to the module
+func (p *TreeSitterProcessor) processLocals(b block, source []byte) []ir.DistilledNode {
+	var result []ir.DistilledNode
+	for _, child := range b.members() {
+		switch child.Type() {
+		case "attribute":
+			attr := p.attribute(child, source)
+			result = append(result, p.processAttribute(attr, source, "local", ir.VisibilityPrivate))
+		case "comment":
+			result = append(result, p.processComment(child, source))
+		}
+	}
+	return result
+}
+
+// processTerraform processes the terraform settings block. Required
+// providers become imports.
+func (p *TreeSitterProcessor) processTerraform(b block, source []byte) *ir.DistilledClass {
+	class := &ir.DistilledClass{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(b.node),
+			Extensions: &ir.NodeExtensions{HCL: &ir.HCLExtensions{Kind: b.typ}},
+		},
+		Name:       b.typ,
+		Visibility: ir.VisibilityPublic,
+	}
+	for _, child := range b.members() {
+		switch child.Type() {
+		case "attribute":
+			attr := p.attribute(child, source)
+			class.Children = append(class.Children, p.processAttribute(attr, source, "", ir.VisibilityPublic))
+		case "block":
+			nested := p.block(child, source)
+			if nested.typ != "required_providers" {
+				class.Children = append(class.Children, p.processGenericBlock(nested, source))
+				continue
+			}
+			for _, attr := range p.attributes(nested.body, source) {
+				class.Children = append(class.Children, p.processRequiredProvider(attr, source))
+			}
+		case "comment":
+			class.Children = append(class.Children, p.processComment(child, source))
+		}
+	}
+	return class
+}
+
+// processRequiredProvider processes an entry of required_providers, either
+// an object with source and version or a legacy version string
+func (p *TreeSitterProcessor) processRequiredProvider(attr attribute, source []byte) *ir.DistilledImport {
+	ext := &ir.HCLExtensions{Kind: "required_providers"}
+	module := attr.name
+	elems := p.objectElements(attr.value, source)
+	if elems == nil {
+		ext.Version = stringValue(p.nodeText(attr.value, source))
+	}
+	for key, value := range elems {
+		switch key {
+		case "source":
+			module = stringValue(value)
+		case "version":
+			ext.Version = stringValue(value)
+		}
+	}
+
+	return &ir.DistilledImport{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(attr.node),
+			Extensions: &ir.NodeExtensions{HCL: ext},
+		},
+		ImportType: "provider",
+		Module:     module,
+		Symbols:    []ir.ImportedSymbol{{Name: attr.name}},
+	}
+}
+
+// processAttribute processes an attribute as a field with its value
+func (p *TreeSitterProcessor) processAttribute(attr attribute, source []byte, kind string, visibility ir.Visibility) *ir.DistilledField {
+	field := &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(attr.node),
+		},
+		Name:         attr.name,
+		Visibility:   visibility,
+		DefaultValue: p.nodeText(attr.value, source),
+	}
+	if kind != "" {
+		field.Extensions = &ir.NodeExtensions{HCL: &ir.HCLExtensions{Kind: kind}}
+	}
+	return field
+}
+
+// processComment processes a comment
+func (p *TreeSitterProcessor) processComment(node *sitter.Node, source []byte) *ir.DistilledComment {
+	text := strings.TrimRight(p.nodeText(node, source), "\r\n")
+	format := "line"
+	if strings.HasPrefix(text, "/*") {
+		format = "block"
+	}
+
+	return &ir.DistilledComment{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Text:   text,
+		Format: format,
+	}
+}
+
+// blockField creates the field of a variable or output block
+func (p *TreeSitterProcessor) blockField(b block, kind string) *ir.DistilledField {
+	return &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(b.node),
+			Extensions: &ir.NodeExtensions{HCL: &ir.HCLExtensions{
+				Kind:   kind,
+				Labels: b.labels,
+			}},
+		},
+		Name:       b.name(),
+		Visibility: ir.VisibilityPublic,
+	}
+}
+
+// describe returns a node preceded by its description as a documentation
+// comment
+func (p *TreeSitterProcessor) describe(node ir.DistilledNode, description string, block *sitter.Node) []ir.DistilledNode {
+	if description == "" {
+		return []ir.DistilledNode{node}
+	}
+	comment := &ir.DistilledComment{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(block),
+		},
+		Text:   description,
+		Format: "doc",
+	}
+	return []ir.DistilledNode{comment, node}
+}
+
+// block returns the type, labels and body of a block node
+func (p *TreeSitterProcessor) block(node *sitter.Node, source []byte) block {
+	b := block{node: node}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch child.Type() {
+		case "identifier":
+			if b.typ == "" {
+				b.typ = p.nodeText(child, source)
+			} else {
+				b.labels = append(b.labels, p.nodeText(child, source))
+			}
+		case "string_lit":
+			b.labels = append(b.labels, stringValue(p.nodeText(child, source)))
+		case "body":
+			b.body = child
+		}
+	}
+	return b
+}
+
+// name returns the name of a block: its labels joined by dots, e.g.
+// aws_instance.web, or its type if it has no labels
+func (b block) name() string {
+	if len(b.labels) == 0 {
+		return b.typ
+	}
+	return strings.Join(b.labels, ".")
+}
+
+// members returns the attributes, blocks and comments of a block in source
+// order. Comments may be children of the block rather than of its body.
+func (b block) members() []*sitter.Node {
+	var members []*sitter.Node
+	for i := 0; i < int(b.node.ChildCount()); i++ {
+		child := b.node.Child(i)
+		switch child.Type() {
+		case "comment":
+			members = append(members, child)
+		case "body":
+			for j := 0; j < int(child.ChildCount()); j++ {
+				members = append(members, child.Child(j))
+			}
+		}
+	}
+	return members
+}
+
+// attribute returns the name and value of an attribute node
+func (p *TreeSitterProcessor) attribute(node *sitter.Node, source []byte) attribute {
+	attr := attribute{node: node}
+	if name := p.childOfType(node, "identifier"); name != nil {
+		attr.name = p.nodeText(name, source)
+	}
+	attr.value = p.childOfType(node, "expression")
+	return attr
+}
+
+// attributes returns the attributes of a block body
+func (p *TreeSitterProcessor) attributes(body *sitter.Node, source []byte) []attribute {
+	if body == nil {
+		return nil
+	}
+	var attrs []attribute
+	for i := 0; i < int(body.ChildCount()); i++ {
+		if child := body.Child(i); child.Type() == "attribute" {
+			if attr := p.attribute(child, source); attr.value != nil {
+				attrs = append(attrs, attr)
+			}
+		}
+	}
+	return attrs
+}
+
+// objectElements returns the keys and values of an object expression, or
+// nil if the expression is not an object
+func (p *TreeSitterProcessor) objectElements(expr *sitter.Node, source []byte) map[string]string {
+	var object *sitter.Node
+	if collection := p.childOfType(expr, "collection_value"); collection != nil {
+		object = p.childOfType(collection, "object")
+	}
+	if object == nil {
+		return nil
+	}
+
+	elems := map[string]string{}
+	for i := 0; i < int(object.ChildCount()); i++ {
+		elem := object.Child(i)
+		if elem.Type() != "object_elem" {
+			continue
+		}
+		var parts []string
+		for j := 0; j < int(elem.ChildCount()); j++ {
+			if child := elem.Child(j); child.Type() == "expression" {
+				parts = append(parts, p.nodeText(child, source))
+			}
+		}
+		if len(parts) == 2 {
+			elems[stringValue(parts[0])] = parts[1]
+		}
+	}
+	return elems
+}
+
+// stringValue returns the value of a quoted string or a heredoc, or the
+// text itself if it is another expression
+func stringValue(text string) string {
+	if strings.HasPrefix(text, `"`) {
+		if value, err := strconv.Unquote(text); err == nil {
+			return value
+		}
+		return strings.Trim(text, `"`)
+	}
+
+	m := heredoc.FindStringSubmatch(text)
+	if m == nil {
+		return text
+	}
+	lines := strings.Split(strings.TrimRight(text[len(m[0]):], " \t\n"), "\n")
+	// The last line holds the delimiter
+	lines = lines[:len(lines)-1]
+	if m[1] == "-" {
+		lines = dedent(lines)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dedent removes the indentation shared by the non-empty lines of an
+// indented heredoc
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	return lines
+}
+
+// oneLine joins the lines of an expression, separating the elements of
+// multi-line objects and tuples with commas, and collapses whitespace
+func oneLine(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			prev := b.String()
+			switch {
+			case strings.HasSuffix(prev, "{"), strings.HasSuffix(prev, "("), strings.HasSuffix(prev, "["):
+				b.WriteString(" ")
+			case strings.HasPrefix(line, "}"), strings.HasPrefix(line, ")"), strings.HasPrefix(line, "]"):
+				b.WriteString(" ")
+			case strings.HasSuffix(prev, ","):
+				b.WriteString(" ")
+			default:
+				b.WriteString(", ")
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// Helper methods
+
+// nodeText extracts the text content of a node
+func (p *TreeSitterProcessor) nodeText(node *sitter.Node, source []byte) string {
+	return string(source[node.StartByte():node.EndByte()])
+}
+
+// childOfType returns the first child of a node with the given type, or nil
+func (p *TreeSitterProcessor) childOfType(node *sitter.Node, typ string) *sitter.Node {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if child := node.Child(i); child.Type() == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// nodeLocation creates a Location from a node
+func (p *TreeSitterProcessor) nodeLocation(node *sitter.Node) ir.Location {
+	return ir.Location{
+		StartLine:   int(node.StartPoint().Row) + 1,
+		EndLine:     int(node.EndPoint().Row) + 1,
+		StartColumn: int(node.StartPoint().Column) + 1,
+		EndColumn:   int(node.EndPoint().Column) + 1,
+	}
+}
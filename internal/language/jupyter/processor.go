@@ -0,0 +1,269 @@
+package jupyter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/python"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/stripper"
+)
+
+// pythonCellMagics are the cell magics whose cell body is still Python
+var pythonCellMagics = map[string]bool{
+	"time":    true,
+	"timeit":  true,
+	"capture": true,
+	"prun":    true,
+	"debug":   true,
+}
+
+// Processor handles Jupyter notebooks. Code cells are distilled by the
+// Python processor and markdown cells become documentation comments.
+type Processor struct {
+	processor.BaseProcessor
+}
+
+// NewProcessor creates a new Jupyter notebook processor
+func NewProcessor() *Processor {
+	return &Processor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"jupyter",
+			"1.0.0",
+			[]string{".ipynb"},
+		),
+	}
+}
+
+// notebook is the JSON document of a notebook. Outputs are not decoded.
+type notebook struct {
+	Cells    []cell `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	// Cells of nbformat 3 notebooks
+	Worksheets []struct {
+		Cells []cell `json:"cells"`
+	} `json:"worksheets"`
+}
+
+// cell is a cell of a notebook
+type cell struct {
+	CellType string `json:"cell_type"`
+	Source   source `json:"source"`
+	// Source of code cells in nbformat 3 notebooks
+	Input source `json:"input"`
+}
+
+// source is the text of a cell, stored as a string or as a list of lines
+type source string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *source) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = source(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*s = source(text)
+	return nil
+}
+
+// text returns the source of a cell
+func (c cell) text() string {
+	if c.Source == "" {
+		return string(c.Input)
+	}
+	return string(c.Source)
+}
+
+// language returns the language of the notebook kernel, or an empty string
+// if the notebook does not declare it
+func (nb *notebook) language() string {
+	if lang := nb.Metadata.Kernelspec.Language; lang != "" {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(nb.Metadata.LanguageInfo.Name)
+}
+
+// Process implements processor.LanguageProcessor
+func (p *Processor) Process(ctx context.Context, reader io.Reader, filename string) (*ir.DistilledFile, error) {
+	// Read source code
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, fmt.Errorf("failed to parse notebook: %w", err)
+	}
+	cells := nb.Cells
+	for _, sheet := range nb.Worksheets {
+		cells = append(cells, sheet.Cells...)
+	}
+
+	file := &ir.DistilledFile{
+		BaseNode: ir.BaseNode{
+			Location: ir.Location{StartLine: 1, EndLine: strings.Count(string(data), "\n") + 1},
+		},
+		Path:     filename,
+		Language: "python",
+		Version:  "3",
+		Children: []ir.DistilledNode{},
+	}
+
+	supported := true
+	if lang := nb.language(); lang != "" && lang != "python" {
+		supported = false
+		file.Errors = append(file.Errors, ir.DistilledError{
+			BaseNode: ir.BaseNode{Location: ir.Location{StartLine: 1}},
+			Message:  fmt.Sprintf("code cells of %s notebooks are not supported", lang),
+			Severity: "warning",
+		})
+	}
+
+	// Concatenate the code cells, remembering the first line of each
+	var code strings.Builder
+	starts := make([]int, len(cells))
+	line := 1
+	for i, c := range cells {
+		if c.CellType != "code" || !supported {
+			continue
+		}
+		text := pythonSource(c.text())
+		starts[i] = line
+		code.WriteString(text)
+		code.WriteString("\n\n")
+		line += strings.Count(text, "\n") + 2
+	}
+
+	var nodes []ir.DistilledNode
+	if code.Len() > 0 {
+		result, err := python.NewProcessor().Process(ctx, strings.NewReader(code.String()), filename)
+		if err != nil {
+			return nil, err
+		}
+		nodes = result.Children
+		file.Errors = append(file.Errors, result.Errors...)
+	}
+
+	// Merge markdown cells and the nodes of code cells in cell order, with
+	// locations counted from the start of each cell
+	for i, c := range cells {
+		switch c.CellType {
+		case "markdown":
+			text := strings.TrimSpace(c.text())
+			if text == "" {
+				continue
+			}
+			file.Children = append(file.Children, &ir.DistilledComment{
+				BaseNode: ir.BaseNode{Location: ir.Location{
+					StartLine: 1,
+					EndLine:   strings.Count(text, "\n") + 1,
+					Cell:      i + 1,
+				}},
+				Text:   text,
+				Format: "doc",
+			})
+		case "code":
+			if !supported {
+				continue
+			}
+			end := line
+			if next := nextStart(starts, i); next > 0 {
+				end = next
+			}
+			for len(nodes) > 0 && nodes[0].GetLocation().StartLine < end {
+				relocate(nodes[0], starts[i], i+1)
+				file.Children = append(file.Children, nodes[0])
+				nodes = nodes[1:]
+			}
+		}
+	}
+
+	return file, nil
+}
+
+// ProcessWithOptions implements processor.LanguageProcessor
+func (p *Processor) ProcessWithOptions(ctx context.Context, reader io.Reader, filename string, opts processor.ProcessOptions) (*ir.DistilledFile, error) {
+	file, err := p.Process(ctx, reader, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply stripper if any options are set
+	stripperOpts := opts.ToStripperOptions()
+	if stripperOpts.HasAnyOption() {
+		s := stripper.New(stripperOpts)
+		stripped := file.Accept(s)
+		if strippedFile, ok := stripped.(*ir.DistilledFile); ok {
+			return strippedFile, nil
+		}
+	}
+
+	return file, nil
+}
+
+// pythonSource returns the source of a code cell with IPython magics and
+// shell commands replaced by pass statements, keeping the line count
+func pythonSource(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if first := strings.TrimSpace(lines[0]); strings.HasPrefix(first, "%%") {
+		name := strings.Fields(first[2:])
+		if len(name) == 0 || !pythonCellMagics[name[0]] {
+			// The cell body is not Python, e.g. %%bash or %%html
+			return strings.Repeat("\n", len(lines)-1)
+		}
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "%") || strings.HasPrefix(trimmed, "!") {
+			lines[i] = line[:len(line)-len(trimmed)] + "pass"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nextStart returns the first line of the next code cell after cell i, or 0
+func nextStart(starts []int, i int) int {
+	for _, start := range starts[i+1:] {
+		if start > 0 {
+			return start
+		}
+	}
+	return 0
+}
+
+// relocate moves the locations of a node and its descendants from the
+// concatenated code to the cell starting at the given line
+func relocate(node ir.DistilledNode, start, cell int) {
+	ir.Walk(node, func(n ir.DistilledNode) bool {
+		located, ok := n.(interface{ SetLocation(ir.Location) })
+		if !ok {
+			return true
+		}
+		loc := n.GetLocation()
+		located.SetLocation(ir.Location{
+			StartLine:   loc.StartLine - start + 1,
+			StartColumn: loc.StartColumn,
+			EndLine:     loc.EndLine - start + 1,
+			EndColumn:   loc.EndColumn,
+			Cell:        cell,
+		})
+		return true
+	})
+}
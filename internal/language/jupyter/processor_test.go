@@ -0,0 +1,194 @@
+package jupyter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const notebookSource = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# Sales analysis\n", "\n", "Loads the sales data."]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [{"output_type": "stream", "name": "stdout", "text": ["secret output\n"]}],
+   "source": ["%matplotlib inline\n", "!pip install pandas\n", "import pandas as pd"]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 2,
+   "metadata": {},
+   "outputs": [{"output_type": "display_data", "data": {"image/png": "iVBORw0KGgo="}}],
+   "source": "THRESHOLD = 0.5\n\ndef load(path: str) -> pd.DataFrame:\n    return pd.read_csv(path)\n"
+  },
+  {
+   "cell_type": "code",
+   "metadata": {},
+   "outputs": [],
+   "source": ["%%bash\n", "def not_python():\n", "    ls -la\n"]
+  },
+  {
+   "cell_type": "raw",
+   "metadata": {},
+   "source": ["def raw(): pass"]
+  },
+  {
+   "cell_type": "code",
+   "metadata": {},
+   "outputs": [],
+   "source": ["%%time\n", "class Model:\n", "    def fit(self, df):\n", "        !echo fitting\n", "        return self"]
+  }
+ ],
+ "metadata": {
+  "kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"}
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestProcessCellLocations(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), notebookSource, "analysis.ipynb")
+	assert.Equal(t, "python", file.Language)
+	assert.Empty(t, file.Errors)
+
+	fields := langtest.Nodes[*ir.DistilledField](file.Children)
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	classes := langtest.Nodes[*ir.DistilledClass](file.Children)
+	require.Len(t, fields, 1)
+	require.Len(t, functions, 1)
+	require.Len(t, classes, 1)
+	methods := langtest.Nodes[*ir.DistilledFunction](classes[0].Children)
+	require.Len(t, methods, 1)
+
+	tests := []struct {
+		name       string
+		node       ir.DistilledNode
+		cell       int
+		start, end int
+	}{
+		{"THRESHOLD", fields[0], 3, 1, 1},
+		{"load", functions[0], 3, 3, 4},
+		{"Model", classes[0], 6, 2, 5},
+		{"fit", methods[0], 6, 3, 5},
+	}
+	assert.Equal(t, "THRESHOLD", fields[0].Name)
+	assert.Equal(t, "load", functions[0].Name)
+	assert.Equal(t, "Model", classes[0].Name)
+	assert.Equal(t, "fit", methods[0].Name)
+	for _, tt := range tests {
+		loc := tt.node.GetLocation()
+		assert.Equal(t, tt.cell, loc.Cell, tt.name)
+		assert.Equal(t, tt.start, loc.StartLine, tt.name)
+		assert.Equal(t, tt.end, loc.EndLine, tt.name)
+	}
+
+	imports := 0
+	for _, child := range file.Children {
+		if imp, ok := child.(*ir.DistilledImport); ok {
+			imports++
+			assert.Equal(t, "pandas", imp.Module)
+			assert.Equal(t, 2, imp.Location.Cell)
+			assert.Equal(t, 3, imp.Location.StartLine)
+		}
+	}
+	assert.Equal(t, 1, imports)
+}
+
+func TestProcessMarkdownCells(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), notebookSource, "analysis.ipynb")
+	require.NotEmpty(t, file.Children)
+
+	comment, ok := file.Children[0].(*ir.DistilledComment)
+	require.True(t, ok)
+	assert.Equal(t, "doc", comment.Format)
+	assert.Equal(t, "# Sales analysis\n\nLoads the sales data.", comment.Text)
+	assert.Equal(t, ir.Location{StartLine: 1, EndLine: 3, Cell: 1}, comment.Location)
+}
+
+func TestProcessSkipsNonPythonCode(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), notebookSource, "analysis.ipynb")
+	ir.Walk(file, func(n ir.DistilledNode) bool {
+		if fn, ok := n.(*ir.DistilledFunction); ok {
+			assert.NotEqual(t, "not_python", fn.Name)
+			assert.NotEqual(t, "raw", fn.Name)
+		}
+		return true
+	})
+}
+
+func TestProcessOtherKernel(t *testing.T) {
+	source := `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": "Plots"},
+  {"cell_type": "code", "metadata": {}, "outputs": [], "source": "plot(x, y)"}
+ ],
+ "metadata": {"kernelspec": {"language": "R", "name": "ir"}},
+ "nbformat": 4,
+ "nbformat_minor": 2
+}`
+	file := langtest.Process(t, NewProcessor(), source, "analysis.ipynb")
+	require.Len(t, file.Errors, 1)
+	assert.Equal(t, "warning", file.Errors[0].Severity)
+	assert.Contains(t, file.Errors[0].Message, "r notebooks")
+	require.Len(t, file.Children, 1)
+	assert.IsType(t, &ir.DistilledComment{}, file.Children[0])
+}
+
+func TestProcessNbformat3(t *testing.T) {
+	source := `{
+ "worksheets": [{"cells": [
+  {"cell_type": "code", "input": ["def first():\n", "    pass"], "outputs": []},
+  {"cell_type": "code", "input": ["def second():\n", "    pass"], "outputs": []}
+ ]}],
+ "metadata": {},
+ "nbformat": 3,
+ "nbformat_minor": 0
+}`
+	file := langtest.Process(t, NewProcessor(), source, "analysis.ipynb")
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, functions, 2)
+	assert.Equal(t, "second", functions[1].Name)
+	second := functions[1].Location
+	assert.Equal(t, 2, second.Cell)
+	assert.Equal(t, 1, second.StartLine)
+}
+
+func TestProcessInvalidNotebook(t *testing.T) {
+	_, err := NewProcessor().Process(context.Background(), strings.NewReader("not json"), "broken.ipynb")
+	assert.Error(t, err)
+}
+
+func TestPythonSource(t *testing.T) {
+	assert.Equal(t, "pass\n  pass\nx = 1", pythonSource("%load_ext autoreload\n  !ls\nx = 1\n"))
+	assert.Equal(t, "\n\n", pythonSource("%%html\n<b>bold</b>\n<i>it</i>"))
+	assert.Equal(t, "pass\nx = 1", pythonSource("%%timeit\nx = 1"))
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	opts.IncludeImplementation = false
+
+	output := langtest.FormatText(t, NewProcessor(), notebookSource, "analysis.ipynb", opts)
+
+	assert.Contains(t, output, "# # Sales analysis\n#\n# Loads the sales data.\n")
+	assert.Contains(t, output, "import pandas as pd\n")
+	assert.Contains(t, output, "load(path: str) -> pd.DataFrame")
+	assert.Contains(t, output, "class Model:")
+	assert.NotContains(t, output, "secret output")
+	assert.NotContains(t, output, "iVBORw0KGgo")
+	assert.NotContains(t, output, "matplotlib")
+}
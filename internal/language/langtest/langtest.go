@@ -0,0 +1,46 @@
+// Package langtest holds helpers shared by the tests of language processors
+package langtest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/formatter"
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/require"
+)
+
+// Process parses source with the processor's default options
+func Process(t testing.TB, p processor.LanguageProcessor, source, filename string) *ir.DistilledFile {
+	t.Helper()
+	file, err := p.Process(context.Background(), strings.NewReader(source), filename)
+	require.NoError(t, err)
+	return file
+}
+
+// FormatText parses source with opts and renders it with the language-aware
+// text formatter
+func FormatText(t testing.TB, p processor.LanguageProcessor, source, filename string, opts processor.ProcessOptions) string {
+	t.Helper()
+	file, err := p.ProcessWithOptions(context.Background(), strings.NewReader(source), filename, opts)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, formatter.NewLanguageAwareTextFormatter(formatter.Options{}).Format(&buf, file))
+	return buf.String()
+}
+
+// Nodes returns the children of type T in order; other children, such as
+// comments when T is not *ir.DistilledComment, are left out
+func Nodes[T ir.DistilledNode](children []ir.DistilledNode) []T {
+	var result []T
+	for _, child := range children {
+		if node, ok := child.(T); ok {
+			result = append(result, node)
+		}
+	}
+	return result
+}
@@ -16,6 +16,7 @@ import (
 func RegisterTreeSitterProcessors() {
 	// Register stub processors that return errors
 	stubLanguages := []string{
+		"c",
 		"cpp", "c++",
 		"csharp", "c#",
 		"java",
@@ -27,6 +28,10 @@ func RegisterTreeSitterProcessors() {
 		"rust", "rs",
 		"swift",
 		"typescript", "ts",
+		"vue", "svelte", "astro",
+		"jupyter", "hcl",
+		"scala", "dart",
+		"shell",
 	}
 
 	for _, lang := range stubLanguages {
@@ -59,8 +64,10 @@ func (p *stubProcessor) SupportedExtensions() []string {
 		return []string{".go"}
 	case "java":
 		return []string{".java"}
+	case "c":
+		return []string{".c"}
 	case "cpp", "c++":
-		return []string{".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx", ".c", ".h"}
+		return []string{".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx", ".h"}
 	case "csharp", "c#":
 		return []string{".cs"}
 	case "ruby", "rb":
@@ -73,6 +80,18 @@ func (p *stubProcessor) SupportedExtensions() []string {
 		return []string{".kt", ".kts"}
 	case "rust", "rs":
 		return []string{".rs"}
+	case "hcl":
+		return []string{".tf", ".tfvars", ".hcl"}
+	case "jupyter":
+		return []string{".ipynb"}
+	case "scala":
+		return []string{".scala", ".sc"}
+	case "dart":
+		return []string{".dart"}
+	case "shell":
+		return []string{".sh", ".bash", ".zsh"}
+	case "vue", "svelte", "astro":
+		return []string{"." + p.language}
 	default:
 		return []string{}
 	}
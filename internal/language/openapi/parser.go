@@ -0,0 +1,485 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"gopkg.in/yaml.v3"
+)
+
+// methods are the operations of a path item
+var methods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// document builds the IR of an API specification or a JSON Schema. Schemas
+// are referenced by name; parameters, request bodies and responses defined
+// elsewhere in the document are resolved into the operations using them.
+type document struct {
+	root    *yaml.Node
+	file    *ir.DistilledFile
+	swagger bool
+	// rootName is the name of the root schema of a JSON Schema document
+	rootName string
+}
+
+// Parse parses an OpenAPI 3.x, Swagger 2.0 or JSON Schema document in JSON
+// or YAML into a distilled file
+func Parse(src []byte, filename string) *ir.DistilledFile {
+	file := &ir.DistilledFile{
+		Path:     filename,
+		Language: "openapi",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		file.Errors = append(file.Errors, ir.DistilledError{Message: err.Error(), Severity: "error"})
+		return file
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		file.Errors = append(file.Errors, ir.DistilledError{Message: "document is not an object", Severity: "error"})
+		return file
+	}
+
+	root := doc.Content[0]
+	d := &document{root: root, file: file, swagger: get(root, "swagger") != nil}
+	file.Location = ir.Location{StartLine: 1, EndLine: endLine(root)}
+	if d.swagger || get(root, "openapi") != nil {
+		d.api()
+	} else {
+		d.jsonSchema(filename)
+	}
+	return file
+}
+
+// api adds the info, operations and schemas of an API specification
+func (d *document) api() {
+	if info := get(d.root, "info"); info != nil {
+		title := strings.TrimSpace(scalar(get(info, "title")) + " " + scalar(get(info, "version")))
+		docs := []string{title, scalar(get(info, "description"))}
+		if servers := d.servers(); len(servers) > 0 {
+			docs = append(docs, "Servers: "+strings.Join(servers, ", "))
+		}
+		d.add(commentNodes(location(info), docs, d.notes(info))...)
+	}
+
+	d.operations(get(d.root, "paths"), "operation")
+	d.operations(get(d.root, "webhooks"), "webhook")
+
+	schemas := get(get(d.root, "components"), "schemas")
+	if d.swagger {
+		schemas = get(d.root, "definitions")
+	}
+	for _, pair := range pairs(schemas) {
+		d.add(d.schema(pair[0], pair[0].Value, pair[1])...)
+	}
+}
+
+// servers returns the base URLs of the API
+func (d *document) servers() []string {
+	if d.swagger {
+		host := scalar(get(d.root, "host"))
+		if host == "" {
+			return nil
+		}
+		scheme := "https"
+		if schemes := get(d.root, "schemes"); schemes != nil && len(schemes.Content) > 0 {
+			scheme = schemes.Content[0].Value
+		}
+		return []string{scheme + "://" + host + scalar(get(d.root, "basePath"))}
+	}
+
+	var urls []string
+	for _, server := range items(get(d.root, "servers")) {
+		if url := scalar(get(server, "url")); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// jsonSchema adds the root schema and the definitions of a JSON Schema
+// document. The root schema is named by its title or the file name.
+func (d *document) jsonSchema(filename string) {
+	d.rootName = scalar(get(d.root, "title"))
+	if d.rootName == "" {
+		d.rootName = schemaFileName(filename)
+	}
+	d.add(d.schema(d.root, d.rootName, d.root)...)
+
+	for _, key := range []string{"$defs", "definitions"} {
+		for _, pair := range pairs(get(d.root, key)) {
+			d.add(d.schema(pair[0], pair[0].Value, pair[1])...)
+		}
+	}
+}
+
+// operations adds the operations of the paths or webhooks of an API
+func (d *document) operations(paths *yaml.Node, kind string) {
+	for _, pair := range pairs(paths) {
+		item := d.resolve(pair[1])
+		common := items(get(item, "parameters"))
+		for _, op := range pairs(item) {
+			if methods[op[0].Value] {
+				d.add(d.operation(kind, pair[0].Value, op[0], op[1], common)...)
+			}
+		}
+	}
+}
+
+// operation returns an operation as a function with its documentation.
+// Parameter descriptions are added to the documentation as @param lines.
+func (d *document) operation(kind, route string, key, op *yaml.Node, common []*yaml.Node) []ir.DistilledNode {
+	method := strings.ToUpper(key.Value)
+	ext := &ir.OpenAPIExtensions{
+		Kind:        kind,
+		Method:      method,
+		Path:        route,
+		OperationID: scalar(get(op, "operationId")),
+	}
+	for _, tag := range items(get(op, "tags")) {
+		ext.Tags = append(ext.Tags, tag.Value)
+	}
+
+	fn := &ir.DistilledFunction{
+		BaseNode:   ir.BaseNode{Location: ir.Location{StartLine: key.Line, EndLine: endLine(op)}, Extensions: &ir.NodeExtensions{OpenAPI: ext}},
+		Name:       ext.OperationID,
+		Visibility: ir.VisibilityPublic,
+		Parameters: []ir.Parameter{},
+	}
+	if fn.Name == "" {
+		fn.Name = method + " " + route
+	}
+
+	docs := []string{scalar(get(op, "summary")), scalar(get(op, "description"))}
+	var params []string
+	notes := d.notes(op)
+
+	for _, param := range d.parameters(common, items(get(op, "parameters"))) {
+		in := scalar(get(param, "in"))
+		schema := get(param, "schema")
+		if schema == nil && d.swagger {
+			// Swagger 2.0 parameters other than body are schemas themselves
+			schema = param
+		}
+		if content := get(param, "content"); content != nil {
+			schema = mediaSchema(content)
+		}
+
+		p := ir.Parameter{
+			Name:         scalar(get(param, "name")),
+			Type:         ir.TypeRef{Name: d.typeName(schema, 0)},
+			DefaultValue: literal(get(schema, "default")),
+			IsOptional:   scalar(get(param, "required")) != "true",
+			Decorators:   []string{in},
+		}
+		if in == "body" {
+			p.Name = ""
+		}
+		if scalar(get(param, "deprecated")) == "true" {
+			p.Decorators = append(p.Decorators, "@deprecated")
+		}
+		fn.Parameters = append(fn.Parameters, p)
+
+		label := p.Name
+		if label == "" {
+			label = in
+		}
+		if desc := scalar(get(param, "description")); desc != "" {
+			params = append(params, "@param "+label+" "+desc)
+		}
+		notes = append(notes, examples(label, param)...)
+		notes = append(notes, contentExamples(label, get(param, "content"))...)
+	}
+
+	if body := d.resolve(get(op, "requestBody")); body != nil {
+		fn.Parameters = append(fn.Parameters, ir.Parameter{
+			Type:       ir.TypeRef{Name: d.typeName(mediaSchema(get(body, "content")), 0)},
+			IsOptional: scalar(get(body, "required")) != "true",
+			Decorators: []string{"body"},
+		})
+		if desc := scalar(get(body, "description")); desc != "" {
+			params = append(params, "@param body "+desc)
+		}
+		notes = append(notes, contentExamples("body", get(body, "content"))...)
+	}
+
+	var responses []string
+	for _, pair := range pairs(get(op, "responses")) {
+		code := pair[0].Value
+		if strings.HasPrefix(code, "x-") {
+			continue
+		}
+		response := d.resolve(pair[1])
+		schema := mediaSchema(get(response, "content"))
+		if d.swagger {
+			schema = get(response, "schema")
+		}
+		if schema != nil {
+			responses = append(responses, code+": "+d.typeName(schema, 0))
+		} else {
+			responses = append(responses, code)
+		}
+		notes = append(notes, contentExamples(code, get(response, "content"))...)
+		notes = append(notes, examples(code, response)...)
+	}
+	if len(responses) > 0 {
+		fn.Returns = &ir.TypeRef{Name: strings.Join(responses, ", ")}
+	}
+
+	if scalar(get(op, "deprecated")) == "true" {
+		fn.Decorators = append(fn.Decorators, "@deprecated")
+	}
+	if security := get(op, "security"); security != nil {
+		var schemes []string
+		for _, requirement := range items(security) {
+			for _, pair := range pairs(requirement) {
+				schemes = append(schemes, pair[0].Value)
+			}
+		}
+		if len(schemes) > 0 {
+			fn.Decorators = append(fn.Decorators, "@security("+strings.Join(schemes, ", ")+")")
+		}
+	}
+
+	if len(params) > 0 {
+		docs = append(docs, strings.Join(params, "\n"))
+	}
+	return append(commentNodes(fn.Location, docs, notes), fn)
+}
+
+// parameters returns the parameters of an operation: the parameters of its
+// path item, overridden by its own parameters with the same name and location
+func (d *document) parameters(common, own []*yaml.Node) []*yaml.Node {
+	var params []*yaml.Node
+	index := map[string]int{}
+	for _, list := range [][]*yaml.Node{common, own} {
+		for _, param := range list {
+			param = d.resolve(param)
+			key := scalar(get(param, "in")) + " " + scalar(get(param, "name"))
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// resolve follows a $ref to a node of the same document. References to
+// other documents are returned as they are.
+func (d *document) resolve(node *yaml.Node) *yaml.Node {
+	for i := 0; i < 16 && node != nil; i++ {
+		ref := scalar(get(node, "$ref"))
+		if !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		target := d.root
+		for _, segment := range strings.Split(ref[2:], "/") {
+			target = get(target, unescapePointer(segment))
+		}
+		if target == nil {
+			return node
+		}
+		node = target
+	}
+	return node
+}
+
+// notes returns the vendor extensions of a node as comment lines
+func (d *document) notes(node *yaml.Node) []string {
+	var notes []string
+	for _, pair := range pairs(node) {
+		if strings.HasPrefix(pair[0].Value, "x-") {
+			notes = append(notes, pair[0].Value+": "+literal(pair[1]))
+		}
+	}
+	return notes
+}
+
+func (d *document) add(nodes ...ir.DistilledNode) {
+	d.file.Children = append(d.file.Children, nodes...)
+}
+
+// examples returns the example and examples of a parameter, response or
+// media type as comment lines
+func examples(label string, node *yaml.Node) []string {
+	var notes []string
+	if example := get(node, "example"); example != nil {
+		notes = append(notes, "example "+label+": "+literal(example))
+	}
+	for _, pair := range pairs(get(node, "examples")) {
+		// Example objects wrap the value, while Swagger 2.0 examples map
+		// media types to values
+		value := pair[1]
+		if v := get(value, "value"); v != nil && !strings.Contains(pair[0].Value, "/") {
+			value = v
+		}
+		notes = append(notes, "example "+label+" ("+pair[0].Value+"): "+literal(value))
+	}
+	return notes
+}
+
+// contentExamples returns the examples of the media types of a content map
+func contentExamples(label string, content *yaml.Node) []string {
+	var notes []string
+	for _, pair := range pairs(content) {
+		notes = append(notes, examples(label, pair[1])...)
+	}
+	return notes
+}
+
+// mediaSchema returns the schema of the JSON media type of a content map, or
+// of the first media type
+func mediaSchema(content *yaml.Node) *yaml.Node {
+	var first *yaml.Node
+	for _, pair := range pairs(content) {
+		schema := get(pair[1], "schema")
+		if schema == nil {
+			continue
+		}
+		if strings.Contains(pair[0].Value, "json") {
+			return schema
+		}
+		if first == nil {
+			first = schema
+		}
+	}
+	return first
+}
+
+// commentNodes returns the notes as line comments and the non-empty
+// paragraphs of docs as a doc comment
+func commentNodes(loc ir.Location, docs, notes []string) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, note := range notes {
+		nodes = append(nodes, &ir.DistilledComment{
+			BaseNode: ir.BaseNode{Location: loc},
+			Text:     note,
+			Format:   "line",
+		})
+	}
+
+	var paragraphs []string
+	for _, doc := range docs {
+		if doc = strings.TrimSpace(doc); doc != "" {
+			paragraphs = append(paragraphs, doc)
+		}
+	}
+	if len(paragraphs) > 0 {
+		nodes = append(nodes, &ir.DistilledComment{
+			BaseNode: ir.BaseNode{Location: loc},
+			Text:     strings.Join(paragraphs, "\n\n"),
+			Format:   "doc",
+		})
+	}
+	return nodes
+}
+
+// get returns the value of a key of a mapping node
+func get(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == yaml.SequenceNode {
+		for i, item := range node.Content {
+			if key == strconv.Itoa(i) {
+				return item
+			}
+		}
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// pairs returns the keys and values of a mapping node in document order
+func pairs(node *yaml.Node) [][2]*yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	result := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		result = append(result, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	return result
+}
+
+// items returns the items of a sequence node
+func items(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// scalar returns the value of a scalar node
+func scalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// literal returns a value as compact JSON
+func literal(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag != "!!str" {
+		return node.Value
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return node.Value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return node.Value
+	}
+	return string(data)
+}
+
+// location returns the lines a node spans
+func location(node *yaml.Node) ir.Location {
+	return ir.Location{StartLine: node.Line, EndLine: endLine(node)}
+}
+
+// endLine returns the last line of a node
+func endLine(node *yaml.Node) int {
+	line := node.Line
+	for _, child := range node.Content {
+		line = max(line, endLine(child))
+	}
+	return line
+}
+
+// schemaFileName returns the name of the root schema of a JSON Schema file,
+// e.g. user for user.schema.json
+func schemaFileName(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	return strings.TrimSuffix(name, ".schema")
+}
+
+func unescapePointer(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+}
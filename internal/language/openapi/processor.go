@@ -0,0 +1,74 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/stripper"
+)
+
+// specPattern matches the version field of OpenAPI and Swagger documents and
+// the $schema keyword of JSON Schema documents, in JSON or YAML
+var specPattern = regexp.MustCompile(`(?m)(^|[{,\s])"(openapi|swagger)"\s*:\s*"[23]\.|` +
+	`^["']?(openapi|swagger)["']?\s*:\s*["']?[23]\.|` +
+	`(^|[{,\s])"\$schema"\s*:\s*"https?://json-schema\.org/|` +
+	`^["']?\$schema["']?\s*:\s*["']?https?://json-schema\.org/`)
+
+// Processor handles OpenAPI, Swagger and JSON Schema documents
+type Processor struct {
+	processor.BaseProcessor
+}
+
+// NewProcessor creates a new OpenAPI processor
+func NewProcessor() *Processor {
+	return &Processor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"openapi",
+			"1.0.0",
+			[]string{".json", ".yaml", ".yml"},
+		),
+	}
+}
+
+// DetectContent implements processor.ContentDetector, so that other JSON
+// and YAML files are not taken for API specifications
+func (p *Processor) DetectContent(head []byte) bool {
+	return specPattern.Match(head)
+}
+
+// Process implements processor.LanguageProcessor
+func (p *Processor) Process(ctx context.Context, reader io.Reader, filename string) (*ir.DistilledFile, error) {
+	// Read source code
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+
+	return Parse(source, filename), nil
+}
+
+// ProcessWithOptions implements processor.LanguageProcessor
+func (p *Processor) ProcessWithOptions(ctx context.Context, reader io.Reader, filename string, opts processor.ProcessOptions) (*ir.DistilledFile, error) {
+	file, err := p.Process(ctx, reader, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply stripper if any options are set
+	stripperOpts := opts.ToStripperOptions()
+
+	// Only strip if there's something to strip
+	if stripperOpts.HasAnyOption() {
+		s := stripper.New(stripperOpts)
+		stripped := file.Accept(s)
+		if strippedFile, ok := stripped.(*ir.DistilledFile); ok {
+			return strippedFile, nil
+		}
+	}
+
+	return file, nil
+}
@@ -0,0 +1,337 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openAPISource = `openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+  description: A sample pet store.
+servers:
+  - url: https://petstore.example.com/v1
+paths:
+  /pets:
+    parameters:
+      - $ref: '#/components/parameters/TraceId'
+    get:
+      summary: List all pets
+      operationId: listPets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          description: How many items to return
+          example: 20
+          schema:
+            type: integer
+            format: int32
+            default: 20
+      responses:
+        '200':
+          description: A paged array of pets
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pets"
+        default:
+          $ref: '#/components/responses/Error'
+    post:
+      operationId: createPets
+      security:
+        - api_key: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+      responses:
+        '201':
+          description: Created
+      x-rate-limit: 10
+  /pets/{petId}:
+    get:
+      deprecated: true
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: The pet
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [pet]
+                properties:
+                  pet: { $ref: '#/components/schemas/Pet' }
+                  links: { type: array, items: { type: string, format: uri } }
+components:
+  parameters:
+    TraceId:
+      name: X-Trace-Id
+      in: header
+      schema: { type: string }
+  responses:
+    Error:
+      description: Unexpected error
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+  schemas:
+    Pet:
+      description: A pet in the store
+      allOf:
+        - $ref: '#/components/schemas/NewPet'
+        - type: object
+          required: [id]
+          properties:
+            id:
+              type: integer
+              format: int64
+              readOnly: true
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          description: Name of the pet
+          maxLength: 100
+          example: Rex
+        tag:
+          type: string
+          nullable: true
+        status:
+          type: string
+          enum: [available, sold]
+          default: available
+        attributes:
+          type: object
+          additionalProperties: { type: string }
+          x-internal: true
+    Pets:
+      type: array
+      items:
+        $ref: "#/components/schemas/Pet"
+    Error:
+      type: object
+      properties:
+        message: { type: string }
+`
+
+const swaggerSource = `{
+  "swagger": "2.0",
+  "info": {"title": "Users", "version": "2"},
+  "host": "api.example.com",
+  "basePath": "/v2",
+  "paths": {
+    "/users/{id}": {
+      "put": {
+        "operationId": "updateUser",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer", "format": "int64"},
+          {"name": "user", "in": "body", "required": true, "schema": {"$ref": "#/definitions/User"}},
+          {"name": "tags", "in": "query", "type": "array", "items": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "OK", "schema": {"$ref": "#/definitions/User"}, "examples": {"application/json": {"id": 1}}},
+          "404": {"description": "Not found"}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "User": {"type": "object", "properties": {"id": {"type": "integer"}, "roles": {"type": "array", "items": {"$ref": "#/definitions/Role"}}}},
+    "Role": {"type": "string", "enum": ["admin", "user"]}
+  }
+}
+`
+
+const jsonSchemaSource = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"description": "A postal address",
+	"type": "object",
+	"required": ["street"],
+	"properties": {
+		"street": {"type": "string"},
+		"zip": {"type": ["string", "null"], "pattern": "^[0-9]{5}$"},
+		"country": {"$ref": "#/$defs/country"},
+		"parent": {"$ref": "#"}
+	},
+	"$defs": {
+		"country": {"type": "string", "minLength": 2}
+	}
+}
+`
+
+func TestDetectContent(t *testing.T) {
+	p := NewProcessor()
+	assert.True(t, p.DetectContent([]byte(openAPISource)))
+	assert.True(t, p.DetectContent([]byte(swaggerSource)))
+	assert.True(t, p.DetectContent([]byte(jsonSchemaSource)))
+	assert.True(t, p.DetectContent([]byte(`{"openapi":"3.1.0","info":{}}`)))
+	assert.False(t, p.DetectContent([]byte(`{"name": "app", "version": "3.0.0"}`)))
+	assert.False(t, p.DetectContent([]byte("services:\n  web:\n    image: nginx\n")))
+	assert.False(t, p.DetectContent([]byte(`{"$schema": "https://json.schemastore.org/tsconfig"}`)))
+}
+
+func TestProcessOperations(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), openAPISource, "petstore.yaml")
+	assert.Empty(t, file.Errors)
+	assert.Equal(t, "openapi", file.Language)
+
+	operations := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, operations, 3)
+	list, create, show := operations[0], operations[1], operations[2]
+
+	assert.Equal(t, "listPets", list.Name)
+	assert.Equal(t, &ir.OpenAPIExtensions{Kind: "operation", Method: "GET", Path: "/pets", OperationID: "listPets", Tags: []string{"pets"}}, list.Extensions.OpenAPI)
+	assert.Equal(t, []ir.Parameter{
+		{Name: "X-Trace-Id", Type: ir.TypeRef{Name: "string"}, IsOptional: true, Decorators: []string{"header"}},
+		{Name: "limit", Type: ir.TypeRef{Name: "integer(int32)"}, DefaultValue: "20", IsOptional: true, Decorators: []string{"query"}},
+	}, list.Parameters)
+	assert.Equal(t, "200: Pets, default: Error", list.Returns.Name)
+
+	require.Len(t, create.Parameters, 2)
+	assert.Equal(t, ir.Parameter{Type: ir.TypeRef{Name: "NewPet"}, Decorators: []string{"body"}}, create.Parameters[1])
+	assert.Equal(t, "201", create.Returns.Name)
+	assert.Equal(t, []string{"@security(api_key)"}, create.Decorators)
+
+	// Operations without operationId are named by method and path
+	assert.Equal(t, "GET /pets/{petId}", show.Name)
+	assert.False(t, show.Parameters[0].IsOptional)
+	assert.Equal(t, "200: { pet: Pet, links?: string(uri)[] }", show.Returns.Name)
+	assert.Equal(t, []string{"@deprecated"}, show.Decorators)
+}
+
+func TestProcessComments(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), openAPISource, "petstore.yaml")
+
+	comments := langtest.Nodes[*ir.DistilledComment](file.Children)
+	require.Len(t, comments, 5)
+	assert.Equal(t, "Petstore 1.0.0\n\nA sample pet store.\n\nServers: https://petstore.example.com/v1", comments[0].Text)
+	assert.Equal(t, "doc", comments[0].Format)
+	assert.Equal(t, "example limit: 20", comments[1].Text)
+	assert.Equal(t, "line", comments[1].Format)
+	assert.Equal(t, "List all pets\n\n@param limit How many items to return", comments[2].Text)
+	assert.Equal(t, "x-rate-limit: 10", comments[3].Text)
+	assert.Equal(t, "A pet in the store", comments[4].Text)
+}
+
+func TestProcessSchemas(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), openAPISource, "petstore.yaml")
+
+	structs := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, structs, 3)
+	pet, newPet := structs[0], structs[1]
+
+	assert.Equal(t, []string{"NewPet"}, pet.Extensions.OpenAPI.AllOf)
+	petFields := langtest.Nodes[*ir.DistilledField](pet.Children)
+	require.Len(t, petFields, 1)
+	assert.True(t, petFields[0].Extensions.OpenAPI.Required)
+	assert.Equal(t, []string{"@readOnly"}, petFields[0].Decorators)
+
+	fields := langtest.Nodes[*ir.DistilledField](newPet.Children)
+	require.Len(t, fields, 4)
+	assert.Equal(t, "string", fields[0].Type.Name)
+	assert.True(t, fields[0].Extensions.OpenAPI.Required)
+	assert.Equal(t, []string{"@maxLength(100)"}, fields[0].Decorators)
+	assert.Equal(t, "string | null", fields[1].Type.Name)
+	assert.False(t, fields[1].Extensions.OpenAPI.Required)
+	assert.Equal(t, `"available" | "sold"`, fields[2].Type.Name)
+	assert.Equal(t, `"available"`, fields[2].DefaultValue)
+	assert.Equal(t, "Record<string, string>", fields[3].Type.Name)
+
+	comments := langtest.Nodes[*ir.DistilledComment](newPet.Children)
+	require.Len(t, comments, 3)
+	assert.Equal(t, "example name: \"Rex\"", comments[0].Text)
+	assert.Equal(t, "Name of the pet", comments[1].Text)
+	assert.Equal(t, "x-internal: true", comments[2].Text)
+
+	aliases := langtest.Nodes[*ir.DistilledTypeAlias](file.Children)
+	require.Len(t, aliases, 1)
+	assert.Equal(t, "Pets", aliases[0].Name)
+	assert.Equal(t, "Pet[]", aliases[0].Type.Name)
+}
+
+func TestProcessSwagger(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), swaggerSource, "users.json")
+	assert.Empty(t, file.Errors)
+
+	operations := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, operations, 1)
+	assert.Equal(t, []ir.Parameter{
+		{Name: "id", Type: ir.TypeRef{Name: "integer(int64)"}, Decorators: []string{"path"}},
+		{Type: ir.TypeRef{Name: "User"}, Decorators: []string{"body"}},
+		{Name: "tags", Type: ir.TypeRef{Name: "string[]"}, IsOptional: true, Decorators: []string{"query"}},
+	}, operations[0].Parameters)
+	assert.Equal(t, "200: User, 404", operations[0].Returns.Name)
+
+	comments := langtest.Nodes[*ir.DistilledComment](file.Children)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "Users 2\n\nServers: https://api.example.com/v2", comments[0].Text)
+	assert.Equal(t, `example 200 (application/json): {"id":1}`, comments[1].Text)
+
+	aliases := langtest.Nodes[*ir.DistilledTypeAlias](file.Children)
+	require.Len(t, aliases, 1)
+	assert.Equal(t, `"admin" | "user"`, aliases[0].Type.Name)
+}
+
+func TestProcessJSONSchema(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), jsonSchemaSource, "schemas/address.schema.json")
+	assert.Empty(t, file.Errors)
+
+	structs := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, structs, 1)
+	assert.Equal(t, "address", structs[0].Name)
+	fields := langtest.Nodes[*ir.DistilledField](structs[0].Children)
+	require.Len(t, fields, 4)
+	assert.Equal(t, "string | null", fields[1].Type.Name)
+	assert.Equal(t, []string{`@pattern("^[0-9]{5}$")`}, fields[1].Decorators)
+	assert.Equal(t, "country", fields[2].Type.Name)
+	assert.Equal(t, "address", fields[3].Type.Name)
+
+	aliases := langtest.Nodes[*ir.DistilledTypeAlias](file.Children)
+	require.Len(t, aliases, 1)
+	assert.Equal(t, "country", aliases[0].Name)
+}
+
+func TestProcessErrors(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), "openapi: [3.0\n", "broken.yaml")
+	require.Len(t, file.Errors, 1)
+	assert.Empty(t, file.Children)
+
+	file = langtest.Process(t, NewProcessor(), "- openapi\n", "list.yaml")
+	require.Len(t, file.Errors, 1)
+	assert.Equal(t, "document is not an object", file.Errors[0].Message)
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	output := langtest.FormatText(t, NewProcessor(), openAPISource, "petstore.yaml", opts)
+
+	assert.NotContains(t, output, "example limit")
+	assert.NotContains(t, output, "x-rate-limit")
+	assert.Contains(t, output, "# Petstore 1.0.0\n#\n# A sample pet store.\n")
+	assert.Contains(t, output, "GET /pets listPets(header X-Trace-Id?: string, query limit?: integer(int32) = 20) -> 200: Pets, default: Error\n")
+	assert.Contains(t, output, "POST /pets createPets(header X-Trace-Id?: string, body: NewPet) -> 201 @security(api_key)\n")
+	assert.Contains(t, output, "GET /pets/{petId}(path petId: string) -> 200: { pet: Pet, links?: string(uri)[] } @deprecated\n")
+	assert.Contains(t, output, "# A pet in the store\nschema Pet extends NewPet {\n    id: integer(int64) @readOnly\n}\n")
+	assert.Contains(t, output, "    # Name of the pet\n    name: string @maxLength(100)\n    tag?: string | null\n")
+	assert.Contains(t, output, "    status?: \"available\" | \"sold\" = \"available\"\n")
+	assert.Contains(t, output, "schema Pets = Pet[]\n")
+}
@@ -0,0 +1,240 @@
+package openapi
+
+import (
+	"path"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"gopkg.in/yaml.v3"
+)
+
+// maxInlineDepth limits how deeply inline object schemas are written out in
+// type names
+const maxInlineDepth = 3
+
+// constraintKeywords are the validation keywords kept as decorators of a
+// property
+var constraintKeywords = []string{
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems",
+	"minProperties", "maxProperties",
+}
+
+// schema returns a named schema with its documentation. Object schemas
+// become structs with a field for each property; other schemas become type
+// aliases.
+func (d *document) schema(key *yaml.Node, name string, schema *yaml.Node) []ir.DistilledNode {
+	loc := ir.Location{StartLine: key.Line, EndLine: endLine(schema)}
+	docs := []string{scalar(get(schema, "description"))}
+	if title := scalar(get(schema, "title")); title != name {
+		docs = append([]string{title}, docs...)
+	}
+	notes := append(examples(name, schema), d.notes(schema)...)
+	ext := &ir.OpenAPIExtensions{Kind: "schema"}
+	base := ir.BaseNode{Location: loc, Extensions: &ir.NodeExtensions{OpenAPI: ext}}
+
+	parts := []*yaml.Node{schema}
+	for _, part := range items(get(schema, "allOf")) {
+		if ref := scalar(get(part, "$ref")); ref != "" {
+			ext.AllOf = append(ext.AllOf, d.refName(ref))
+		} else {
+			parts = append(parts, part)
+		}
+	}
+
+	var properties [][2]*yaml.Node
+	required := map[string]bool{}
+	for _, part := range parts {
+		properties = append(properties, pairs(get(part, "properties"))...)
+		for _, item := range items(get(part, "required")) {
+			required[item.Value] = true
+		}
+	}
+
+	if len(properties) == 0 && (len(ext.AllOf) == 0 || len(parts) > 1) && !isObject(schema) {
+		alias := &ir.DistilledTypeAlias{
+			BaseNode:   base,
+			Name:       name,
+			Visibility: ir.VisibilityPublic,
+			Type:       ir.TypeRef{Name: d.typeName(schema, 0)},
+		}
+		return append(commentNodes(loc, docs, notes), alias)
+	}
+
+	st := &ir.DistilledStruct{
+		BaseNode:   base,
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+		Children:   []ir.DistilledNode{},
+	}
+	for _, pair := range properties {
+		prop := pair[1]
+		field := &ir.DistilledField{
+			BaseNode: ir.BaseNode{
+				Location:   location(pair[0]),
+				Extensions: &ir.NodeExtensions{OpenAPI: &ir.OpenAPIExtensions{Required: required[pair[0].Value]}},
+			},
+			Name:         pair[0].Value,
+			Visibility:   ir.VisibilityPublic,
+			Type:         &ir.TypeRef{Name: d.typeName(prop, 1)},
+			DefaultValue: literal(get(prop, "default")),
+			Decorators:   constraints(prop),
+		}
+		field.Location.EndLine = endLine(prop)
+		fieldDocs := []string{scalar(get(prop, "title")), scalar(get(prop, "description"))}
+		fieldNotes := append(examples(pair[0].Value, prop), d.notes(prop)...)
+		st.Children = append(st.Children, commentNodes(field.Location, fieldDocs, fieldNotes)...)
+		st.Children = append(st.Children, field)
+	}
+	return append(commentNodes(loc, docs, notes), st)
+}
+
+// typeName returns a compact type expression for a schema, such as
+// Pet[], string(date-time), "asc" | "desc" or { id: integer, name?: string }
+func (d *document) typeName(schema *yaml.Node, depth int) string {
+	if schema == nil {
+		return "any"
+	}
+	if schema.Kind == yaml.ScalarNode {
+		// Boolean schemas accept everything or nothing
+		if schema.Value == "false" {
+			return "never"
+		}
+		return "any"
+	}
+	if ref := scalar(get(schema, "$ref")); ref != "" {
+		return d.refName(ref)
+	}
+
+	var name string
+	switch {
+	case get(schema, "const") != nil:
+		name = literal(get(schema, "const"))
+	case get(schema, "enum") != nil:
+		var values []string
+		for _, value := range items(get(schema, "enum")) {
+			values = append(values, literal(value))
+		}
+		name = strings.Join(values, " | ")
+	case get(schema, "oneOf") != nil:
+		name = d.combined(get(schema, "oneOf"), " | ", depth)
+	case get(schema, "anyOf") != nil:
+		name = d.combined(get(schema, "anyOf"), " | ", depth)
+	case get(schema, "allOf") != nil && get(schema, "properties") == nil:
+		name = d.combined(get(schema, "allOf"), " & ", depth)
+	default:
+		var types []string
+		if typ := get(schema, "type"); typ != nil && typ.Kind == yaml.SequenceNode {
+			for _, item := range typ.Content {
+				types = append(types, d.primitive(item.Value, schema, depth))
+			}
+		} else {
+			types = append(types, d.primitive(scalar(typ), schema, depth))
+		}
+		name = strings.Join(types, " | ")
+	}
+
+	if scalar(get(schema, "nullable")) == "true" || scalar(get(schema, "x-nullable")) == "true" {
+		name += " | null"
+	}
+	return name
+}
+
+// primitive returns the type expression for one of the types of a schema
+func (d *document) primitive(typ string, schema *yaml.Node, depth int) string {
+	if typ == "" {
+		switch {
+		case get(schema, "properties") != nil || get(schema, "additionalProperties") != nil:
+			typ = "object"
+		case get(schema, "items") != nil:
+			typ = "array"
+		default:
+			return "any"
+		}
+	}
+
+	switch typ {
+	case "array":
+		item := d.typeName(get(schema, "items"), depth+1)
+		if strings.Contains(item, " | ") || strings.Contains(item, " & ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		properties := pairs(get(schema, "properties"))
+		if len(properties) > 0 {
+			if depth >= maxInlineDepth {
+				return "object"
+			}
+			required := map[string]bool{}
+			for _, item := range items(get(schema, "required")) {
+				required[item.Value] = true
+			}
+			fields := make([]string, len(properties))
+			for i, pair := range properties {
+				fields[i] = pair[0].Value
+				if !required[pair[0].Value] {
+					fields[i] += "?"
+				}
+				fields[i] += ": " + d.typeName(pair[1], depth+1)
+			}
+			return "{ " + strings.Join(fields, ", ") + " }"
+		}
+		if additional := get(schema, "additionalProperties"); additional != nil && additional.Kind == yaml.MappingNode {
+			return "Record<string, " + d.typeName(additional, depth+1) + ">"
+		}
+		return "object"
+	}
+
+	if format := scalar(get(schema, "format")); format != "" {
+		return typ + "(" + format + ")"
+	}
+	return typ
+}
+
+// combined returns the type expressions of the schemas of oneOf, anyOf or
+// allOf joined with a separator
+func (d *document) combined(schemas *yaml.Node, sep string, depth int) string {
+	var names []string
+	for _, schema := range items(schemas) {
+		names = append(names, d.typeName(schema, depth))
+	}
+	if len(names) == 0 {
+		return "any"
+	}
+	return strings.Join(names, sep)
+}
+
+// refName returns the name of a referenced schema: the last segment of a
+// JSON pointer, or the name of a referenced schema file
+func (d *document) refName(ref string) string {
+	if ref == "#" {
+		return d.rootName
+	}
+	if i := strings.Index(ref, "#/"); i >= 0 {
+		return unescapePointer(path.Base(ref[i:]))
+	}
+	return schemaFileName(strings.TrimSuffix(ref, "#"))
+}
+
+// isObject reports whether a schema without properties describes an object
+func isObject(schema *yaml.Node) bool {
+	return scalar(get(schema, "type")) == "object" && get(schema, "additionalProperties") == nil
+}
+
+// constraints returns the validation keywords and access modifiers of a
+// property as decorators
+func constraints(schema *yaml.Node) []string {
+	var decorators []string
+	for _, key := range []string{"readOnly", "writeOnly", "deprecated"} {
+		if scalar(get(schema, key)) == "true" {
+			decorators = append(decorators, "@"+key)
+		}
+	}
+	for _, key := range constraintKeywords {
+		if value := get(schema, key); value != nil {
+			decorators = append(decorators, "@"+key+"("+literal(value)+")")
+		}
+	}
+	return decorators
+}
@@ -0,0 +1,147 @@
+package protobuf
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+// token is a lexical token with the comments around it
+type token struct {
+	kind  tokenKind
+	text  string
+	line  int
+	start int
+	end   int
+
+	// leading are the comments between the previous token and this one
+	leading []comment
+	// trailing is a comment on the same line after this token
+	trailing *comment
+}
+
+// comment is a // or /* */ comment with its markers removed
+type comment struct {
+	text    string
+	block   bool
+	line    int
+	endLine int
+}
+
+// tokenize splits proto source into tokens. Comments are attached to the
+// surrounding tokens instead of being returned as tokens.
+func tokenize(src []byte) []token {
+	var tokens []token
+	var pending []comment
+	line := 1
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c == '/' && i+1 < len(src) && (src[i+1] == '/' || src[i+1] == '*'):
+			cm := comment{line: line}
+			if src[i+1] == '/' {
+				end := i
+				for end < len(src) && src[end] != '\n' {
+					end++
+				}
+				cm.text = strings.TrimSpace(string(src[i+2 : end]))
+				i = end
+			} else {
+				cm.block = true
+				end := i + 2
+				for end < len(src) && !(src[end] == '*' && end+1 < len(src) && src[end+1] == '/') {
+					end++
+				}
+				body := string(src[i+2 : min(end, len(src))])
+				line += strings.Count(body, "\n")
+				cm.text = blockCommentText(body)
+				i = min(end+2, len(src))
+			}
+			cm.endLine = line
+
+			// A comment on the line of the previous token trails it
+			if n := len(tokens); n > 0 && len(pending) == 0 && tokens[n-1].line == cm.line && tokens[n-1].trailing == nil {
+				tokens[n-1].trailing = &cm
+			} else {
+				pending = append(pending, cm)
+			}
+		default:
+			tok := token{line: line, start: i, leading: pending}
+			pending = nil
+			switch {
+			case isIdentStart(c) || (c == '.' && i+1 < len(src) && isIdentStart(src[i+1])):
+				tok.kind = tokenIdent
+				i++
+				for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '.') {
+					i++
+				}
+			case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+				tok.kind = tokenNumber
+				i++
+				for i < len(src) {
+					if isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '.' {
+						i++
+					} else if (src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E') {
+						i++
+					} else {
+						break
+					}
+				}
+			case c == '"' || c == '\'':
+				tok.kind = tokenString
+				i++
+				for i < len(src) && src[i] != c && src[i] != '\n' {
+					if src[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				if i < len(src) && src[i] == c {
+					i++
+				}
+			default:
+				tok.kind = tokenSymbol
+				i++
+			}
+			tok.end = min(i, len(src))
+			tok.text = string(src[tok.start:tok.end])
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, line: line, start: len(src), end: len(src), leading: pending})
+}
+
+// blockCommentText removes the leading * of each line of a block comment
+func blockCommentText(body string) string {
+	lines := strings.Split(body, "\n")
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "*")
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,967 @@
+package protobuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// parser is a recursive descent parser for proto2, proto3 and editions files.
+// It is lenient: a statement it cannot parse is recorded as an error and
+// skipped.
+type parser struct {
+	src    []byte
+	toks   []token
+	pos    int
+	lines  []int
+	file   *ir.DistilledFile
+	trails []comment
+}
+
+// parseError is a syntax error at a token
+type parseError struct {
+	tok token
+	msg string
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.tok.line, e.msg)
+}
+
+// Parse parses proto source into a distilled file
+func Parse(src []byte, filename string) *ir.DistilledFile {
+	p := &parser{
+		src:  src,
+		toks: tokenize(src),
+		file: &ir.DistilledFile{
+			Path:     filename,
+			Language: "protobuf",
+			Version:  "proto2",
+			Children: []ir.DistilledNode{},
+			Errors:   []ir.DistilledError{},
+		},
+	}
+	p.lines = []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+
+	p.file.Children = p.parseBody(p.parseTopLevel)
+	if len(p.toks) > 1 {
+		p.file.Location = p.location(p.toks[0], p.toks[len(p.toks)-2])
+	}
+	return p.file
+}
+
+// parseBody parses statements with parseStatement until a closing brace or
+// the end of the file. Comments before the end become standalone comments.
+func (p *parser) parseBody(parseStatement func() ([]ir.DistilledNode, error)) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.text == "}" {
+			for _, c := range tok.leading {
+				nodes = append(nodes, p.commentNode(c))
+			}
+			p.toks[p.pos].leading = nil
+			return nodes
+		}
+
+		start := p.pos
+		p.trails = nil
+		stmt, err := parseStatement()
+		if err != nil {
+			p.addError(err)
+			p.pos = start
+			nodes = append(nodes, p.detached(p.peek())...)
+			p.skipStatement()
+			continue
+		}
+		nodes = append(nodes, stmt...)
+	}
+}
+
+func (p *parser) parseTopLevel() ([]ir.DistilledNode, error) {
+	start := p.peek()
+	switch start.text {
+	case "syntax", "edition":
+		p.next()
+		if _, err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.expectKind(tokenString, "string")
+		if err != nil {
+			return nil, err
+		}
+		end, err := p.expect(";")
+		if err != nil {
+			return nil, err
+		}
+		p.file.Version = unquote(value.text)
+		if start.text == "edition" {
+			p.file.Version = "edition " + p.file.Version
+		}
+
+		// Comments before the syntax statement are file headers such as
+		// licenses, so they stay plain comments rather than documentation
+		var nodes []ir.DistilledNode
+		for _, c := range start.leading {
+			nodes = append(nodes, p.commentNode(c))
+		}
+		return append(nodes, &ir.DistilledField{
+			BaseNode: ir.BaseNode{
+				Location:   p.location(start, end),
+				Extensions: &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{IsSyntax: true}},
+			},
+			Name:         start.text,
+			Visibility:   ir.VisibilityPublic,
+			DefaultValue: value.text,
+		}), nil
+
+	case "package":
+		p.next()
+		name, err := p.expectKind(tokenIdent, "package name")
+		if err != nil {
+			return nil, err
+		}
+		end, err := p.expect(";")
+		if err != nil {
+			return nil, err
+		}
+		return p.withComments(start, &ir.DistilledPackage{
+			BaseNode: ir.BaseNode{Location: p.location(start, end)},
+			Name:     name.text,
+		}), nil
+
+	case "import":
+		p.next()
+		importType := "import"
+		if next := p.peek(); next.text == "public" || next.text == "weak" {
+			p.next()
+			importType += " " + next.text
+		}
+		module, err := p.expectKind(tokenString, "import path")
+		if err != nil {
+			return nil, err
+		}
+		end, err := p.expect(";")
+		if err != nil {
+			return nil, err
+		}
+		return p.withComments(start, &ir.DistilledImport{
+			BaseNode:   ir.BaseNode{Location: p.location(start, end)},
+			ImportType: importType,
+			Module:     unquote(module.text),
+		}), nil
+	}
+
+	return p.parseDefinition()
+}
+
+// parseDefinition parses the statements allowed both at the top level and in
+// messages
+func (p *parser) parseDefinition() ([]ir.DistilledNode, error) {
+	switch start := p.peek(); {
+	case start.text == ";":
+		p.next()
+		return nil, nil
+	case start.text == "option":
+		return p.parseOption()
+	case p.isKeyword("message"):
+		return p.parseMessage()
+	case p.isKeyword("enum"):
+		return p.parseEnum()
+	case p.isKeyword("service"):
+		return p.parseService()
+	case p.isKeyword("extend"):
+		return p.parseExtend()
+	default:
+		return nil, p.errorf(start, "unexpected %q", start.text)
+	}
+}
+
+// parseOption parses "option name = value;" into a field
+func (p *parser) parseOption() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.optionName()
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.value(";")
+	if err != nil {
+		return nil, err
+	}
+	end, err := p.expect(";")
+	if err != nil {
+		return nil, err
+	}
+	return p.withComments(start, &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location:   p.location(start, end),
+			Extensions: &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{IsOption: true}},
+		},
+		Name:         name,
+		Visibility:   ir.VisibilityPublic,
+		DefaultValue: value,
+	}), nil
+}
+
+func (p *parser) parseMessage() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.expectKind(tokenIdent, "message name")
+	if err != nil {
+		return nil, err
+	}
+	msg := &ir.DistilledStruct{
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if err := p.parseBlock(start, &msg.BaseNode, &msg.Children, p.parseMessageStatement); err != nil {
+		return nil, err
+	}
+	return p.blockWithComments(start, msg), nil
+}
+
+func (p *parser) parseExtend() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.expectKind(tokenIdent, "extended message name")
+	if err != nil {
+		return nil, err
+	}
+	ext := &ir.DistilledStruct{
+		BaseNode: ir.BaseNode{
+			Extensions: &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{IsExtend: true}},
+		},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if err := p.parseBlock(start, &ext.BaseNode, &ext.Children, p.parseFieldStatement); err != nil {
+		return nil, err
+	}
+	return p.blockWithComments(start, ext), nil
+}
+
+// parseBlock parses "{ statements }" into children and sets the location of
+// the declaration starting at start
+func (p *parser) parseBlock(start token, base *ir.BaseNode, children *[]ir.DistilledNode, parseStatement func() ([]ir.DistilledNode, error)) error {
+	if _, err := p.expect("{"); err != nil {
+		return err
+	}
+	header := p.trails
+	*children = p.parseBody(parseStatement)
+	end, err := p.expect("}")
+	if err != nil {
+		return err
+	}
+	p.trails = header
+	base.Location = p.location(start, end)
+	return nil
+}
+
+func (p *parser) parseMessageStatement() ([]ir.DistilledNode, error) {
+	start := p.peek()
+	switch {
+	case start.text == "reserved" || start.text == "extensions":
+		return p.parseRanges()
+	case p.isKeyword("oneof"):
+		return p.parseOneof()
+	case start.text == ";" || start.text == "option" || p.isKeyword("message") || p.isKeyword("enum") || p.isKeyword("extend"):
+		return p.parseDefinition()
+	}
+	return p.parseFieldStatement()
+}
+
+// parseRanges parses reserved and extensions statements and records them on
+// the enclosing message or enum
+func (p *parser) parseRanges() ([]ir.DistilledNode, error) {
+	start := p.next()
+	var ranges []string
+	for {
+		value, err := p.value(",", ";", "[")
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, value)
+		if p.peek().text != "," {
+			break
+		}
+		p.next()
+	}
+	if p.peek().text == "[" {
+		if _, err := p.fieldOptions(); err != nil {
+			return nil, err
+		}
+	}
+	end, err := p.expect(";")
+	if err != nil {
+		return nil, err
+	}
+
+	node := &ir.DistilledField{
+		BaseNode: ir.BaseNode{Location: p.location(start, end)},
+		Name:     start.text,
+	}
+	node.Extensions = &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{}}
+	if start.text == "reserved" {
+		node.Extensions.Protobuf.Reserved = ranges
+	} else {
+		node.Extensions.Protobuf.ExtensionRanges = ranges
+	}
+	return p.withComments(start, &rangesNode{node}), nil
+}
+
+// rangesNode marks reserved and extensions statements until the enclosing
+// declaration collects them
+type rangesNode struct {
+	*ir.DistilledField
+}
+
+// collectRanges moves reserved and extensions statements of children to ext
+func collectRanges(base *ir.BaseNode, children []ir.DistilledNode) []ir.DistilledNode {
+	var kept []ir.DistilledNode
+	for _, child := range children {
+		r, ok := child.(*rangesNode)
+		if !ok {
+			kept = append(kept, child)
+			continue
+		}
+		if base.Extensions == nil {
+			base.Extensions = &ir.NodeExtensions{}
+		}
+		if base.Extensions.Protobuf == nil {
+			base.Extensions.Protobuf = &ir.ProtobufExtensions{}
+		}
+		ext := base.Extensions.Protobuf
+		ext.Reserved = append(ext.Reserved, r.Extensions.Protobuf.Reserved...)
+		ext.ExtensionRanges = append(ext.ExtensionRanges, r.Extensions.Protobuf.ExtensionRanges...)
+	}
+	return kept
+}
+
+func (p *parser) parseOneof() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.expectKind(tokenIdent, "oneof name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	header := p.trails
+	docs := p.docComments(start)
+
+	fields := p.parseBody(func() ([]ir.DistilledNode, error) {
+		if p.peek().text == "option" || p.peek().text == ";" {
+			// Options of a oneof have no place in the flattened fields
+			_, err := p.parseDefinition()
+			return nil, err
+		}
+		return p.parseField(p.peek(), "")
+	})
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+
+	// The fields of a oneof are stored in the message, marked with the oneof
+	// name. Comments of the oneof itself document its first field.
+	var nodes []ir.DistilledNode
+	for _, node := range fields {
+		if field, ok := node.(*ir.DistilledField); ok && field.Extensions != nil && field.Extensions.Protobuf != nil {
+			field.Extensions.Protobuf.Oneof = name.text
+		}
+		if docs != nil {
+			nodes = append(nodes, docs...)
+			nodes = append(nodes, p.trailingDocs(header)...)
+			docs, header = nil, nil
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// parseFieldStatement parses a field or group of a message or extend block
+func (p *parser) parseFieldStatement() ([]ir.DistilledNode, error) {
+	start := p.peek()
+	if start.text == ";" {
+		p.next()
+		return nil, nil
+	}
+	if start.text == "option" {
+		return p.parseOption()
+	}
+	label := ""
+	if start.text == "repeated" || start.text == "optional" || start.text == "required" {
+		label = start.text
+		p.next()
+	}
+	return p.parseField(start, label)
+}
+
+// parseField parses a field, map field or group after its label
+func (p *parser) parseField(start token, label string) ([]ir.DistilledNode, error) {
+	if p.peek().text == "group" && p.peekAt(1).kind == tokenIdent && p.peekAt(2).text == "=" && (p.peekAt(4).text == "{" || p.peekAt(4).text == "[") {
+		return p.parseGroup(start, label)
+	}
+
+	var typ *ir.TypeRef
+	if p.peek().text == "map" && p.peekAt(1).text == "<" {
+		p.next()
+		p.next()
+		key, err := p.expectKind(tokenIdent, "map key type")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(","); err != nil {
+			return nil, err
+		}
+		value, err := p.expectKind(tokenIdent, "map value type")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		typ = &ir.TypeRef{Name: "map", TypeArgs: []ir.TypeRef{{Name: key.text}, {Name: value.text}}}
+	} else {
+		name, err := p.expectKind(tokenIdent, "field type")
+		if err != nil {
+			return nil, err
+		}
+		typ = &ir.TypeRef{Name: name.text, IsArray: label == "repeated"}
+	}
+
+	name, err := p.expectKind(tokenIdent, "field name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("="); err != nil {
+		return nil, err
+	}
+	number, err := p.expectKind(tokenNumber, "field number")
+	if err != nil {
+		return nil, err
+	}
+	var options []string
+	if p.peek().text == "[" {
+		if options, err = p.fieldOptions(); err != nil {
+			return nil, err
+		}
+	}
+	end, err := p.expect(";")
+	if err != nil {
+		return nil, err
+	}
+
+	return p.withComments(start, &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location: p.location(start, end),
+			Extensions: &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{
+				Label:  label,
+				Number: number.text,
+			}},
+		},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+		Type:       typ,
+		Decorators: options,
+	}), nil
+}
+
+// parseGroup parses a proto2 group into a nested message
+func (p *parser) parseGroup(start token, label string) ([]ir.DistilledNode, error) {
+	p.next()
+	name, err := p.expectKind(tokenIdent, "group name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("="); err != nil {
+		return nil, err
+	}
+	number, err := p.expectKind(tokenNumber, "group number")
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().text == "[" {
+		if _, err := p.fieldOptions(); err != nil {
+			return nil, err
+		}
+	}
+
+	group := &ir.DistilledStruct{
+		BaseNode: ir.BaseNode{
+			Extensions: &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{
+				Label:   label,
+				Number:  number.text,
+				IsGroup: true,
+			}},
+		},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if err := p.parseBlock(start, &group.BaseNode, &group.Children, p.parseMessageStatement); err != nil {
+		return nil, err
+	}
+	return p.blockWithComments(start, group), nil
+}
+
+func (p *parser) parseEnum() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.expectKind(tokenIdent, "enum name")
+	if err != nil {
+		return nil, err
+	}
+	enum := &ir.DistilledEnum{
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if err := p.parseBlock(start, &enum.BaseNode, &enum.Children, p.parseEnumStatement); err != nil {
+		return nil, err
+	}
+	return p.blockWithComments(start, enum), nil
+}
+
+func (p *parser) parseEnumStatement() ([]ir.DistilledNode, error) {
+	start := p.peek()
+	switch start.text {
+	case ";":
+		p.next()
+		return nil, nil
+	case "option":
+		return p.parseOption()
+	case "reserved":
+		return p.parseRanges()
+	}
+
+	name, err := p.expectKind(tokenIdent, "enum value name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("="); err != nil {
+		return nil, err
+	}
+	number := ""
+	if p.peek().text == "-" {
+		p.next()
+		number = "-"
+	}
+	value, err := p.expectKind(tokenNumber, "enum value number")
+	if err != nil {
+		return nil, err
+	}
+	var options []string
+	if p.peek().text == "[" {
+		if options, err = p.fieldOptions(); err != nil {
+			return nil, err
+		}
+	}
+	end, err := p.expect(";")
+	if err != nil {
+		return nil, err
+	}
+
+	return p.withComments(start, &ir.DistilledField{
+		BaseNode:     ir.BaseNode{Location: p.location(start, end)},
+		Name:         name.text,
+		Visibility:   ir.VisibilityPublic,
+		DefaultValue: number + value.text,
+		Decorators:   options,
+	}), nil
+}
+
+func (p *parser) parseService() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.expectKind(tokenIdent, "service name")
+	if err != nil {
+		return nil, err
+	}
+	service := &ir.DistilledInterface{
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+	}
+	if err := p.parseBlock(start, &service.BaseNode, &service.Children, p.parseServiceStatement); err != nil {
+		return nil, err
+	}
+	return p.blockWithComments(start, service), nil
+}
+
+func (p *parser) parseServiceStatement() ([]ir.DistilledNode, error) {
+	start := p.peek()
+	switch start.text {
+	case ";":
+		p.next()
+		return nil, nil
+	case "option":
+		return p.parseOption()
+	case "rpc":
+		return p.parseRPC()
+	}
+	return nil, p.errorf(start, "unexpected %q in service", start.text)
+}
+
+func (p *parser) parseRPC() ([]ir.DistilledNode, error) {
+	start := p.next()
+	name, err := p.expectKind(tokenIdent, "rpc name")
+	if err != nil {
+		return nil, err
+	}
+	request, clientStreaming, err := p.rpcType()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("returns"); err != nil {
+		return nil, err
+	}
+	response, serverStreaming, err := p.rpcType()
+	if err != nil {
+		return nil, err
+	}
+
+	rpc := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Extensions: &ir.NodeExtensions{Protobuf: &ir.ProtobufExtensions{
+				ClientStreaming: clientStreaming,
+				ServerStreaming: serverStreaming,
+			}},
+		},
+		Name:       name.text,
+		Visibility: ir.VisibilityPublic,
+		Parameters: []ir.Parameter{{Name: "request", Type: ir.TypeRef{Name: request}}},
+		Returns:    &ir.TypeRef{Name: response},
+	}
+
+	end := p.peek()
+	if end.text == "{" {
+		// Options in the body of an rpc become its decorators
+		p.next()
+		header := p.trails
+		p.parseBody(func() ([]ir.DistilledNode, error) {
+			if p.peek().text == ";" {
+				p.next()
+				return nil, nil
+			}
+			if p.peek().text != "option" {
+				return nil, p.errorf(p.peek(), "unexpected %q in rpc", p.peek().text)
+			}
+			nodes, err := p.parseOption()
+			for _, node := range nodes {
+				if field, ok := node.(*ir.DistilledField); ok {
+					rpc.Decorators = append(rpc.Decorators, field.Name+" = "+field.DefaultValue)
+				}
+			}
+			return nil, err
+		})
+		if end, err = p.expect("}"); err != nil {
+			return nil, err
+		}
+		p.trails = header
+	} else if end, err = p.expect(";"); err != nil {
+		return nil, err
+	}
+	rpc.Location = p.location(start, end)
+	return p.withComments(start, rpc), nil
+}
+
+// rpcType parses "( [stream] Type )"
+func (p *parser) rpcType() (string, bool, error) {
+	if _, err := p.expect("("); err != nil {
+		return "", false, err
+	}
+	streaming := false
+	if p.peek().text == "stream" && p.peekAt(1).kind == tokenIdent {
+		p.next()
+		streaming = true
+	}
+	typ, err := p.expectKind(tokenIdent, "message type")
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return "", false, err
+	}
+	return typ.text, streaming, nil
+}
+
+// fieldOptions parses "[name = value, ...]" into "name = value" strings
+func (p *parser) fieldOptions() ([]string, error) {
+	p.next()
+	var options []string
+	for {
+		name, err := p.optionName()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.value(",", "]")
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, name+" = "+value)
+		if p.peek().text != "," {
+			break
+		}
+		p.next()
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// optionName reads an option name such as "java_package" or
+// "(my.ext).field" up to and including the "="
+func (p *parser) optionName() (string, error) {
+	first := p.peek()
+	last := first
+	for p.peek().text != "=" {
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.text == ";" || tok.text == "{" || tok.text == "}" {
+			return "", p.errorf(tok, "expected \"=\", found %q", tok.text)
+		}
+		last = p.next()
+	}
+	if last.start == first.start && first.text == "=" {
+		return "", p.errorf(first, "expected option name")
+	}
+	p.next()
+	return string(p.src[first.start:last.end]), nil
+}
+
+// value reads a constant up to one of the terminators outside of brackets
+// and returns its source text. Aggregate values in braces are kept as
+// written.
+func (p *parser) value(terminators ...string) (string, error) {
+	first := p.peek()
+	last := first
+	depth := 0
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return "", p.errorf(tok, "unexpected end of file")
+		}
+		if depth == 0 {
+			for _, t := range terminators {
+				if tok.text == t {
+					if tok.start == first.start {
+						return "", p.errorf(tok, "expected value, found %q", tok.text)
+					}
+					return string(p.src[first.start:last.end]), nil
+				}
+			}
+			if tok.text == ";" || tok.text == "}" {
+				return "", p.errorf(tok, "unexpected %q", tok.text)
+			}
+		}
+		switch tok.text {
+		case "{", "[", "(", "<":
+			depth++
+		case "}", "]", ")", ">":
+			depth--
+		}
+		last = p.next()
+	}
+}
+
+// skipStatement skips to the end of the current statement, including a
+// block it opens, or to the closing brace of the enclosing block
+func (p *parser) skipStatement() {
+	depth := 0
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return
+		case tok.text == "{":
+			depth++
+		case tok.text == "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case tok.text == ";" && depth == 0:
+			p.next()
+			return
+		}
+		p.next()
+	}
+}
+
+// withComments returns node preceded by the comments before start. Comments
+// directly above the statement and at the end of its lines document it.
+func (p *parser) withComments(start token, node ir.DistilledNode) []ir.DistilledNode {
+	nodes := p.detached(start)
+	nodes = append(nodes, p.docComments(start)...)
+	nodes = append(nodes, p.trailingDocs(p.trails)...)
+	p.trails = nil
+	if node != nil {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// blockWithComments is withComments for a declaration with a body. It moves
+// reserved and extensions statements of the body to the declaration.
+func (p *parser) blockWithComments(start token, node ir.DistilledNode) []ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledStruct:
+		n.Children = collectRanges(&n.BaseNode, n.Children)
+	case *ir.DistilledEnum:
+		n.Children = collectRanges(&n.BaseNode, n.Children)
+	}
+	return p.withComments(start, node)
+}
+
+// detached returns the comments before start that are separated from it by
+// a blank line
+func (p *parser) detached(start token) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, c := range start.leading[:p.docStart(start)] {
+		nodes = append(nodes, p.commentNode(c))
+	}
+	return nodes
+}
+
+// docComments returns the comments directly above start as doc comments
+func (p *parser) docComments(start token) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, c := range start.leading[p.docStart(start):] {
+		nodes = append(nodes, p.docNodes(c)...)
+	}
+	return nodes
+}
+
+// docStart returns the index of the first leading comment of start that is
+// not separated from it by a blank line
+func (p *parser) docStart(start token) int {
+	i := len(start.leading)
+	line := start.line
+	for i > 0 && start.leading[i-1].endLine >= line-1 {
+		i--
+		line = start.leading[i].line
+	}
+	return i
+}
+
+func (p *parser) trailingDocs(trails []comment) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, c := range trails {
+		nodes = append(nodes, p.docNodes(c)...)
+	}
+	return nodes
+}
+
+// docNodes returns one doc comment node per line of c
+func (p *parser) docNodes(c comment) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for i, line := range strings.Split(c.text, "\n") {
+		nodes = append(nodes, &ir.DistilledComment{
+			BaseNode: ir.BaseNode{Location: ir.Location{StartLine: c.line + i, EndLine: c.line + i}},
+			Text:     line,
+			Format:   "doc",
+		})
+	}
+	return nodes
+}
+
+func (p *parser) commentNode(c comment) ir.DistilledNode {
+	format := "line"
+	if c.block {
+		format = "block"
+	}
+	return &ir.DistilledComment{
+		BaseNode: ir.BaseNode{Location: ir.Location{StartLine: c.line, EndLine: c.endLine}},
+		Text:     c.text,
+		Format:   format,
+	}
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	if tok.trailing != nil {
+		p.trails = append(p.trails, *tok.trailing)
+	}
+	return tok
+}
+
+// isKeyword reports whether the next token is keyword starting a
+// declaration rather than a field of a type with the same name
+func (p *parser) isKeyword(keyword string) bool {
+	return p.peek().text == keyword && p.peekAt(1).kind == tokenIdent && p.peekAt(2).text != "="
+}
+
+func (p *parser) expect(text string) (token, error) {
+	tok := p.peek()
+	if tok.text != text || tok.kind == tokenString {
+		return tok, p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) expectKind(kind tokenKind, what string) (token, error) {
+	tok := p.peek()
+	if tok.kind != kind {
+		return tok, p.errorf(tok, "expected %s, found %q", what, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	if tok.kind == tokenEOF {
+		tok.text = "end of file"
+	}
+	return &parseError{tok: tok, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) addError(err error) {
+	perr, ok := err.(*parseError)
+	if !ok {
+		perr = &parseError{tok: p.peek(), msg: err.Error()}
+	}
+	p.file.Errors = append(p.file.Errors, ir.DistilledError{
+		BaseNode: ir.BaseNode{Location: p.location(perr.tok, perr.tok)},
+		Message:  perr.msg,
+		Severity: "error",
+	})
+}
+
+func (p *parser) location(start, end token) ir.Location {
+	return ir.Location{
+		StartLine:   start.line,
+		StartColumn: start.start - p.lines[start.line-1] + 1,
+		EndLine:     end.line,
+		EndColumn:   end.end - p.lines[end.line-1] + 1,
+		StartByte:   start.start,
+		EndByte:     end.end,
+	}
+}
+
+// unquote returns the contents of a string literal
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' {
+		s = `"` + strings.ReplaceAll(s[1:len(s)-1], `"`, `\"`) + `"`
+	}
+	if value, err := strconv.Unquote(s); err == nil {
+		return value
+	}
+	return strings.Trim(s, `"'`)
+}
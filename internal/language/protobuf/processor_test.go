@@ -0,0 +1,229 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const proto3Source = `// Copyright notice
+
+syntax = "proto3";
+
+package acme.users.v1;
+
+import "google/protobuf/timestamp.proto";
+import public "other.proto";
+
+option go_package = "github.com/acme/users/v1;usersv1";
+
+// User is a registered account.
+message User {
+  // Unique identifier.
+  string id = 1;
+  repeated string tags = 2 [deprecated = true];
+  map<string, int32> scores = 3;
+  google.protobuf.Timestamp created_at = 4; // Creation time.
+  oneof contact {
+    string email = 5;
+    string phone = 6;
+  }
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    ACTIVE = 1;
+  }
+  reserved 7, 8, "legacy";
+}
+
+/* UserService manages users. */
+service UserService {
+  option (acme.auth) = { scope: "users" };
+  // Get a user.
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc Watch(stream WatchRequest) returns (stream User) {
+    option deprecated = true;
+  }
+}
+`
+
+const proto2Source = `syntax = "proto2";
+package legacy;
+
+message Request {
+  required string name = 1;
+  optional int32 limit = 2 [default = 10];
+  repeated group Result = 3 {
+    required string url = 4;
+  }
+  extensions 100 to 199, 500 to max;
+}
+
+extend Request {
+  optional string note = 100;
+}
+
+enum Kind {
+  option allow_alias = true;
+  A = 0;
+  B = 1 [deprecated = true];
+  NEGATIVE = -1;
+  reserved 5 to 9;
+}
+`
+
+func TestProcessProto3(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), proto3Source, "test.proto")
+	assert.Empty(t, file.Errors)
+	assert.Equal(t, "protobuf", file.Language)
+	assert.Equal(t, "proto3", file.Version)
+
+	pkgs := langtest.Nodes[*ir.DistilledPackage](file.Children)
+	require.Len(t, pkgs, 1)
+	assert.Equal(t, "acme.users.v1", pkgs[0].Name)
+
+	imports := langtest.Nodes[*ir.DistilledImport](file.Children)
+	require.Len(t, imports, 2)
+	assert.Equal(t, "google/protobuf/timestamp.proto", imports[0].Module)
+	assert.Equal(t, "import public", imports[1].ImportType)
+
+	options := langtest.Nodes[*ir.DistilledField](file.Children)
+	require.Len(t, options, 2)
+	assert.Equal(t, "syntax", options[0].Name)
+	assert.Equal(t, `"proto3"`, options[0].DefaultValue)
+	assert.True(t, options[0].Extensions.Protobuf.IsSyntax)
+	assert.Equal(t, "go_package", options[1].Name)
+	assert.Equal(t, `"github.com/acme/users/v1;usersv1"`, options[1].DefaultValue)
+	assert.True(t, options[1].Extensions.Protobuf.IsOption)
+
+	messages := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, messages, 1)
+	user := messages[0]
+	assert.Equal(t, "User", user.Name)
+	assert.Equal(t, []string{"7", "8", `"legacy"`}, user.Extensions.Protobuf.Reserved)
+
+	fields := langtest.Nodes[*ir.DistilledField](user.Children)
+	require.Len(t, fields, 6)
+	assert.Equal(t, "id", fields[0].Name)
+	assert.Equal(t, "1", fields[0].Extensions.Protobuf.Number)
+	assert.Equal(t, "repeated", fields[1].Extensions.Protobuf.Label)
+	assert.True(t, fields[1].Type.IsArray)
+	assert.Equal(t, []string{"deprecated = true"}, fields[1].Decorators)
+	assert.Equal(t, "map", fields[2].Type.Name)
+	assert.Equal(t, []ir.TypeRef{{Name: "string"}, {Name: "int32"}}, fields[2].Type.TypeArgs)
+	assert.Equal(t, "google.protobuf.Timestamp", fields[3].Type.Name)
+	assert.Equal(t, "contact", fields[4].Extensions.Protobuf.Oneof)
+	assert.Equal(t, "contact", fields[5].Extensions.Protobuf.Oneof)
+
+	enums := langtest.Nodes[*ir.DistilledEnum](user.Children)
+	require.Len(t, enums, 1)
+	assert.Equal(t, "Status", enums[0].Name)
+	assert.Len(t, langtest.Nodes[*ir.DistilledField](enums[0].Children), 2)
+
+	services := langtest.Nodes[*ir.DistilledInterface](file.Children)
+	require.Len(t, services, 1)
+	rpcs := langtest.Nodes[*ir.DistilledFunction](services[0].Children)
+	require.Len(t, rpcs, 2)
+	assert.Equal(t, "GetUser", rpcs[0].Name)
+	assert.Equal(t, "GetUserRequest", rpcs[0].Parameters[0].Type.Name)
+	assert.Equal(t, "User", rpcs[0].Returns.Name)
+	assert.True(t, rpcs[1].Extensions.Protobuf.ClientStreaming)
+	assert.True(t, rpcs[1].Extensions.Protobuf.ServerStreaming)
+	assert.Equal(t, []string{"deprecated = true"}, rpcs[1].Decorators)
+	serviceOptions := langtest.Nodes[*ir.DistilledField](services[0].Children)
+	require.Len(t, serviceOptions, 1)
+	assert.Equal(t, `{ scope: "users" }`, serviceOptions[0].DefaultValue)
+}
+
+func TestProcessComments(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), proto3Source, "test.proto")
+
+	comments := langtest.Nodes[*ir.DistilledComment](file.Children)
+	require.Len(t, comments, 3)
+	assert.Equal(t, "Copyright notice", comments[0].Text)
+	assert.Equal(t, "line", comments[0].Format)
+	assert.Equal(t, "User is a registered account.", comments[1].Text)
+	assert.Equal(t, "doc", comments[1].Format)
+	assert.Equal(t, "UserService manages users.", comments[2].Text)
+	assert.Equal(t, "doc", comments[2].Format)
+
+	user := langtest.Nodes[*ir.DistilledStruct](file.Children)[0]
+	var docs []string
+	for _, comment := range langtest.Nodes[*ir.DistilledComment](user.Children) {
+		docs = append(docs, comment.Text)
+	}
+	assert.Equal(t, []string{"Unique identifier.", "Creation time."}, docs)
+}
+
+func TestProcessProto2(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), proto2Source, "test.proto")
+	assert.Empty(t, file.Errors)
+	assert.Equal(t, "proto2", file.Version)
+
+	messages := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, messages, 2)
+	request := messages[0]
+	assert.Equal(t, []string{"100 to 199", "500 to max"}, request.Extensions.Protobuf.ExtensionRanges)
+
+	fields := langtest.Nodes[*ir.DistilledField](request.Children)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "required", fields[0].Extensions.Protobuf.Label)
+	assert.Equal(t, []string{"default = 10"}, fields[1].Decorators)
+
+	groups := langtest.Nodes[*ir.DistilledStruct](request.Children)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Result", groups[0].Name)
+	assert.True(t, groups[0].Extensions.Protobuf.IsGroup)
+	assert.Equal(t, "3", groups[0].Extensions.Protobuf.Number)
+
+	extend := messages[1]
+	assert.Equal(t, "Request", extend.Name)
+	assert.True(t, extend.Extensions.Protobuf.IsExtend)
+
+	enums := langtest.Nodes[*ir.DistilledEnum](file.Children)
+	require.Len(t, enums, 1)
+	values := langtest.Nodes[*ir.DistilledField](enums[0].Children)
+	require.Len(t, values, 4)
+	assert.True(t, values[0].Extensions.Protobuf.IsOption)
+	assert.Equal(t, "-1", values[3].DefaultValue)
+	assert.Equal(t, []string{"5 to 9"}, enums[0].Extensions.Protobuf.Reserved)
+}
+
+func TestProcessErrors(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `syntax = "proto3";
+message A {
+  string broken = ;
+  int32 ok = 2;
+}
+edition = "2023";
+message B { int32 z = 1; }
+`, "test.proto")
+	require.Len(t, file.Errors, 1)
+	assert.Equal(t, 3, file.Errors[0].Location.StartLine)
+	assert.Equal(t, "edition 2023", file.Version)
+
+	messages := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, messages, 2)
+	fields := langtest.Nodes[*ir.DistilledField](messages[0].Children)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "ok", fields[0].Name)
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	output := langtest.FormatText(t, NewProcessor(), proto3Source, "users.proto", opts)
+
+	assert.NotContains(t, output, "Copyright notice")
+	assert.Contains(t, output, "<file path=\"users.proto\">\nsyntax = \"proto3\";\npackage acme.users.v1;\n")
+	assert.Contains(t, output, "// User is a registered account.\nmessage User {\n")
+	assert.Contains(t, output, "    repeated string tags = 2 [deprecated = true];\n")
+	assert.Contains(t, output, "    map<string, int32> scores = 3;\n")
+	assert.Contains(t, output, "    oneof contact {\n        string email = 5;\n        string phone = 6;\n    }\n")
+	assert.Contains(t, output, "    reserved 7, 8, \"legacy\";\n}\n")
+	assert.Contains(t, output, "    rpc GetUser(GetUserRequest) returns (User);\n")
+	assert.Contains(t, output, "    rpc Watch(stream WatchRequest) returns (stream User) {\n        option deprecated = true;\n    }\n")
+}
@@ -4,16 +4,27 @@
 package language
 
 import (
+	"github.com/janreges/ai-distiller/internal/language/c"
 	"github.com/janreges/ai-distiller/internal/language/cpp"
 	"github.com/janreges/ai-distiller/internal/language/csharp"
+	"github.com/janreges/ai-distiller/internal/language/dart"
 	"github.com/janreges/ai-distiller/internal/language/golang"
+	"github.com/janreges/ai-distiller/internal/language/graphql"
+	"github.com/janreges/ai-distiller/internal/language/hcl"
 	"github.com/janreges/ai-distiller/internal/language/java"
+	"github.com/janreges/ai-distiller/internal/language/jupyter"
 	"github.com/janreges/ai-distiller/internal/language/javascript"
 	"github.com/janreges/ai-distiller/internal/language/kotlin"
+	"github.com/janreges/ai-distiller/internal/language/openapi"
 	"github.com/janreges/ai-distiller/internal/language/php"
+	"github.com/janreges/ai-distiller/internal/language/protobuf"
 	"github.com/janreges/ai-distiller/internal/language/python"
 	"github.com/janreges/ai-distiller/internal/language/ruby"
 	"github.com/janreges/ai-distiller/internal/language/rust"
+	"github.com/janreges/ai-distiller/internal/language/scala"
+	"github.com/janreges/ai-distiller/internal/language/sfc"
+	"github.com/janreges/ai-distiller/internal/language/shell"
+	"github.com/janreges/ai-distiller/internal/language/sql"
 	"github.com/janreges/ai-distiller/internal/language/swift"
 	"github.com/janreges/ai-distiller/internal/language/typescript"
 	"github.com/janreges/ai-distiller/internal/processor"
@@ -93,5 +104,72 @@ func RegisterAll() error {
 		return err
 	}
 
+	// Register C processor
+	cProc := c.NewProcessor()
+	if err := processor.Register(cProc); err != nil {
+		return err
+	}
+
+	// Register Protocol Buffers processor
+	protobufProc := protobuf.NewProcessor()
+	if err := processor.Register(protobufProc); err != nil {
+		return err
+	}
+
+	// Register GraphQL processor
+	graphqlProc := graphql.NewProcessor()
+	if err := processor.Register(graphqlProc); err != nil {
+		return err
+	}
+
+	// Register SQL processor
+	sqlProc := sql.NewProcessor()
+	if err := processor.Register(sqlProc); err != nil {
+		return err
+	}
+
+	// Register OpenAPI processor
+	openapiProc := openapi.NewProcessor()
+	if err := processor.Register(openapiProc); err != nil {
+		return err
+	}
+
+	// Register Terraform and HCL processor
+	hclProc := hcl.NewProcessor()
+	if err := processor.Register(hclProc); err != nil {
+		return err
+	}
+
+	// Register Jupyter notebook processor
+	jupyterProc := jupyter.NewProcessor()
+	if err := processor.Register(jupyterProc); err != nil {
+		return err
+	}
+
+	// Register Scala processor
+	scalaProc := scala.NewProcessor()
+	if err := processor.Register(scalaProc); err != nil {
+		return err
+	}
+
+	// Register Dart processor
+	dartProc := dart.NewProcessor()
+	if err := processor.Register(dartProc); err != nil {
+		return err
+	}
+
+	// Register shell script processor
+	shellProc := shell.NewProcessor()
+	if err := processor.Register(shellProc); err != nil {
+		return err
+	}
+
+	// Register Vue, Svelte and Astro processors
+	for _, sfcProc := range []*sfc.Processor{sfc.NewVueProcessor(), sfc.NewSvelteProcessor(), sfc.NewAstroProcessor()} {
+		if err := processor.Register(sfcProc); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
\ No newline at end of file
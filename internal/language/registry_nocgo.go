@@ -5,6 +5,10 @@ package language
 
 import (
 	"github.com/janreges/ai-distiller/internal/language/golang"
+	"github.com/janreges/ai-distiller/internal/language/graphql"
+	"github.com/janreges/ai-distiller/internal/language/openapi"
+	"github.com/janreges/ai-distiller/internal/language/protobuf"
+	"github.com/janreges/ai-distiller/internal/language/sql"
 	"github.com/janreges/ai-distiller/internal/processor"
 )
 
@@ -16,6 +20,24 @@ func RegisterAll() error {
 		return err
 	}
 
+	// Protocol Buffers, GraphQL, SQL and OpenAPI processors are pure Go as well
+	protobufProc := protobuf.NewProcessor()
+	if err := processor.Register(protobufProc); err != nil {
+		return err
+	}
+	graphqlProc := graphql.NewProcessor()
+	if err := processor.Register(graphqlProc); err != nil {
+		return err
+	}
+	sqlProc := sql.NewProcessor()
+	if err := processor.Register(sqlProc); err != nil {
+		return err
+	}
+	openapiProc := openapi.NewProcessor()
+	if err := processor.Register(openapiProc); err != nil {
+		return err
+	}
+
 	// Register stub processors for other languages
 	RegisterTreeSitterProcessors()
 
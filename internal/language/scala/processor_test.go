@@ -0,0 +1,302 @@
+package scala
+
+import (
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessImports(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `package com.example
+
+import scala.collection.mutable
+import scala.concurrent.{Future, ExecutionContext => EC}
+import java.util._
+`, "Test.scala")
+	require.Len(t, file.Children, 4)
+
+	pkg, ok := file.Children[0].(*ir.DistilledPackage)
+	require.True(t, ok)
+	assert.Equal(t, "com.example", pkg.Name)
+
+	simple := file.Children[1].(*ir.DistilledImport)
+	assert.Equal(t, "scala.collection.mutable", simple.Module)
+	assert.Equal(t, []ir.ImportedSymbol{{Name: "mutable"}}, simple.Symbols)
+
+	selectors := file.Children[2].(*ir.DistilledImport)
+	assert.Equal(t, "scala.concurrent", selectors.Module)
+	assert.Equal(t, []ir.ImportedSymbol{{Name: "Future"}, {Name: "ExecutionContext", Alias: "EC"}}, selectors.Symbols)
+
+	wildcard := file.Children[3].(*ir.DistilledImport)
+	assert.Equal(t, "java.util", wildcard.Module)
+	assert.Equal(t, []ir.ImportedSymbol{{Name: "_"}}, wildcard.Symbols)
+}
+
+func TestProcessClasses(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+case class Point(x: Int, y: Int = 0)
+
+sealed abstract class Animal(val name: String, private var age: Int)(implicit ec: EC)
+    extends Comparable[Animal] with Serializable {
+  def this(name: String) = this(name, 0)(EC.global)
+}
+
+object Animal {
+  final val Default = "cat"
+}
+`, "Test.scala")
+	require.Len(t, file.Children, 3)
+
+	point := file.Children[0].(*ir.DistilledClass)
+	assert.Equal(t, "Point", point.Name)
+	assert.Contains(t, point.Modifiers, ir.ModifierData)
+	require.Len(t, point.Children, 3)
+	constructor := point.Children[0].(*ir.DistilledFunction)
+	assert.Equal(t, "constructor", constructor.Name)
+	assert.Equal(t, []string{"@Primary"}, constructor.Decorators)
+	assert.Equal(t, "0", constructor.Parameters[1].DefaultValue)
+	// All parameters of case classes are fields
+	x := point.Children[1].(*ir.DistilledField)
+	assert.Equal(t, "x", x.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierFinal}, x.Modifiers)
+
+	animal := file.Children[1].(*ir.DistilledClass)
+	assert.Equal(t, []ir.Modifier{ir.ModifierSealed, ir.ModifierAbstract}, animal.Modifiers)
+	assert.Equal(t, []ir.TypeRef{{Name: "Comparable[Animal]"}}, animal.Extends)
+	assert.Equal(t, []ir.TypeRef{{Name: "Serializable"}}, animal.Mixins)
+	require.Len(t, animal.Children, 4)
+	primary := animal.Children[0].(*ir.DistilledFunction)
+	require.Len(t, primary.Parameters, 3)
+	assert.Equal(t, []string{"implicit"}, primary.Parameters[2].Decorators)
+	assert.Equal(t, []int{2, 1}, primary.Extensions.Scala.ParameterLists)
+	// Only val and var parameters of classes are fields
+	age := animal.Children[2].(*ir.DistilledField)
+	assert.Equal(t, "age", age.Name)
+	assert.Equal(t, ir.VisibilityPrivate, age.Visibility)
+	assert.Empty(t, age.Modifiers)
+	secondary := animal.Children[3].(*ir.DistilledFunction)
+	assert.Equal(t, "constructor", secondary.Name)
+	assert.Empty(t, secondary.Decorators)
+
+	object := file.Children[2].(*ir.DistilledClass)
+	assert.Equal(t, []ir.Modifier{ir.ModifierStatic}, object.Modifiers)
+	constant := object.Children[0].(*ir.DistilledField)
+	assert.Equal(t, `"cat"`, constant.DefaultValue)
+	assert.Equal(t, []ir.Modifier{ir.ModifierFinal, ir.ModifierFinal}, constant.Modifiers)
+}
+
+func TestProcessTraits(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+/** A shape. */
+trait Shape[+A <: AnyRef] extends Serializable with Product {
+  def area: Double
+  def describe(): String = "shape"
+  protected def scale(factor: Double): Shape[A]
+}
+`, "Test.scala")
+	require.Len(t, file.Children, 2)
+	doc := file.Children[0].(*ir.DistilledComment)
+	assert.Equal(t, "doc", doc.Format)
+
+	trait := file.Children[1].(*ir.DistilledInterface)
+	assert.Equal(t, "Shape", trait.Name)
+	assert.Equal(t, []ir.TypeParam{{Name: "+A", Constraints: []ir.TypeRef{{Name: "AnyRef"}}}}, trait.TypeParams)
+	assert.Equal(t, []ir.TypeRef{{Name: "Serializable"}, {Name: "Product"}}, trait.Extends)
+	require.Len(t, trait.Children, 3)
+
+	area := trait.Children[0].(*ir.DistilledFunction)
+	assert.Equal(t, "Double", area.Returns.Name)
+	assert.Nil(t, area.Extensions)
+	assert.Empty(t, area.Implementation)
+
+	describe := trait.Children[1].(*ir.DistilledFunction)
+	assert.Equal(t, []int{0}, describe.Extensions.Scala.ParameterLists)
+	assert.Equal(t, `"shape"`, describe.Implementation)
+
+	scale := trait.Children[2].(*ir.DistilledFunction)
+	assert.Equal(t, ir.VisibilityProtected, scale.Visibility)
+}
+
+func TestProcessVisibility(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+class Cache {
+  private[this] val secret = 42
+  private[util] lazy val entries: Map[String, Int] = Map.empty
+  protected[util] def grow(): Unit = ()
+  override def toString: String = "cache"
+}
+`, "Test.scala")
+	class := file.Children[0].(*ir.DistilledClass)
+	require.Len(t, class.Children, 4)
+
+	secret := class.Children[0].(*ir.DistilledField)
+	assert.Equal(t, ir.VisibilityPrivate, secret.Visibility)
+	assert.Nil(t, secret.Extensions)
+
+	entries := class.Children[1].(*ir.DistilledField)
+	assert.Equal(t, ir.VisibilityInternal, entries.Visibility)
+	assert.Equal(t, "util", entries.Extensions.Scala.Qualifier)
+	assert.Equal(t, []string{"lazy"}, entries.Extensions.Scala.Keywords)
+
+	grow := class.Children[2].(*ir.DistilledFunction)
+	assert.Equal(t, ir.VisibilityProtected, grow.Visibility)
+	assert.Equal(t, "util", grow.Extensions.Scala.Qualifier)
+
+	toString := class.Children[3].(*ir.DistilledFunction)
+	assert.Equal(t, []ir.Modifier{ir.ModifierOverride}, toString.Modifiers)
+}
+
+func TestProcessEnums(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+enum Tree[+T] {
+  case Leaf
+  case Node(left: Tree[T], value: T, right: Tree[T])
+}
+
+enum Color(val rgb: Int) {
+  case Red extends Color(0xff0000)
+}
+`, "Test.scala")
+	tree := file.Children[0].(*ir.DistilledClass)
+	assert.Contains(t, tree.Modifiers, ir.ModifierEnum)
+	require.Len(t, tree.Children, 2)
+
+	leaf := tree.Children[0].(*ir.DistilledField)
+	assert.Equal(t, "Leaf", leaf.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierStatic, ir.ModifierFinal}, leaf.Modifiers)
+	assert.Equal(t, "Tree", leaf.Type.Name)
+
+	node := tree.Children[1].(*ir.DistilledClass)
+	assert.Equal(t, "Node", node.Name)
+	assert.Equal(t, []ir.Modifier{ir.ModifierData}, node.Modifiers)
+	require.Len(t, node.Children, 4)
+
+	color := file.Children[1].(*ir.DistilledClass)
+	red := color.Children[2].(*ir.DistilledField)
+	assert.Equal(t, "extends Color(0xff0000)", red.DefaultValue)
+}
+
+func TestProcessExtensionsAndGivens(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+extension (s: String)
+  def shout: String = s.toUpperCase
+
+extension [T](xs: List[T]) {
+  def second: T = xs(1)
+}
+
+given intOrdering: Ordering[Int] = Ordering.Int
+
+given Show[Point] with
+  def show(p: Point): String = p.toString
+
+opaque type Meters = Double
+`, "Test.scala")
+	require.Len(t, file.Children, 5)
+
+	shout := file.Children[0].(*ir.DistilledFunction)
+	assert.Equal(t, "String.shout", shout.Name)
+	assert.Equal(t, []string{"@Extension"}, shout.Decorators)
+	assert.Equal(t, "(s: String)", shout.Extensions.Scala.Receiver)
+
+	second := file.Children[1].(*ir.DistilledFunction)
+	assert.Equal(t, "List[T].second", second.Name)
+	assert.Equal(t, "[T](xs: List[T])", second.Extensions.Scala.Receiver)
+
+	alias := file.Children[2].(*ir.DistilledField)
+	assert.Equal(t, "intOrdering", alias.Name)
+	assert.Equal(t, "Ordering[Int]", alias.Type.Name)
+	assert.Equal(t, []string{"given"}, alias.Extensions.Scala.Keywords)
+
+	instance := file.Children[3].(*ir.DistilledClass)
+	assert.Equal(t, "given_Show_Point", instance.Name)
+	assert.Equal(t, []ir.TypeRef{{Name: "Show[Point]"}}, instance.Implements)
+	require.Len(t, instance.Children, 1)
+
+	meters := file.Children[4].(*ir.DistilledTypeAlias)
+	assert.Equal(t, "Double", meters.Type.Name)
+	assert.Equal(t, []string{"opaque"}, meters.Extensions.Scala.Keywords)
+}
+
+func TestProcessIndentedComments(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+enum Color:
+  case Red, Green
+
+/** A point */
+case class Point(x: Int)
+
+extension (p: Point)
+  /** Moves the point */
+  def move(dx: Int): Point = Point(p.x + dx)
+`, "Test.scala")
+	require.Len(t, file.Children, 5)
+
+	// The comment ending the enum block documents the class after it
+	color := file.Children[0].(*ir.DistilledClass)
+	for _, child := range color.Children {
+		assert.IsType(t, &ir.DistilledField{}, child)
+	}
+	pointDoc := file.Children[1].(*ir.DistilledComment)
+	assert.Equal(t, "/** A point */", pointDoc.Text)
+	assert.IsType(t, &ir.DistilledClass{}, file.Children[2])
+
+	moveDoc := file.Children[3].(*ir.DistilledComment)
+	assert.Equal(t, "/** Moves the point */", moveDoc.Text)
+	move := file.Children[4].(*ir.DistilledFunction)
+	assert.Equal(t, "Point.move", move.Name)
+	assert.Equal(t, "(p: Point)", move.Extensions.Scala.Receiver)
+}
+
+func TestFormatText(t *testing.T) {
+	source := `package shapes
+
+/** A point. */
+case class Point(x: Int, y: Int = 0)
+
+abstract class Animal(val name: String)(using ec: EC) extends Base with Named {
+  private val secret = 42
+  def sum[T <: Number : Ordering](xs: T*): T = {
+    xs.head
+  }
+}
+
+enum Color {
+  case Red, Green
+}
+
+extension (s: String)
+  def shout: String = s.toUpperCase
+`
+	format := func(opts processor.ProcessOptions) string {
+		return langtest.FormatText(t, NewProcessor(), source, "Shapes.scala", opts)
+	}
+
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeImplementation = false
+	opts.IncludePrivate = false
+	assert.Equal(t, `<file path="Shapes.scala">
+package shapes
+/** A point. */
+case class Point(x: Int, y: Int = 0)
+abstract class Animal(val name: String)(using ec: EC) extends Base with Named {
+    def sum[T <: Number : Ordering](xs: T*): T
+}
+enum Color {
+    case Red
+    case Green
+}
+extension (s: String) def shout: String
+</file>
+`, format(opts))
+
+	opts.IncludeImplementation = true
+	opts.IncludePrivate = true
+	output := format(opts)
+	assert.Contains(t, output, "    private val secret = 42\n")
+	assert.Contains(t, output, "    def sum[T <: Number : Ordering](xs: T*): T = {\n      xs.head\n    }\n")
+}
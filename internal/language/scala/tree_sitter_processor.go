@@ -0,0 +1,876 @@
+package scala
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	sitter "github.com/smacker/go-tree-sitter"
+	tree_sitter_scala "github.com/smacker/go-tree-sitter/scala"
+)
+
+// nonWord matches the characters that are left out of the names of
+// anonymous givens
+var nonWord = regexp.MustCompile(`\W+`)
+
+// TreeSitterProcessor uses tree-sitter for Scala parsing
+type TreeSitterProcessor struct {
+	parser *sitter.Parser
+}
+
+// NewTreeSitterProcessor creates a new tree-sitter based processor
+func NewTreeSitterProcessor() *TreeSitterProcessor {
+	parser := sitter.NewParser()
+	parser.SetLanguage(tree_sitter_scala.GetLanguage())
+
+	return &TreeSitterProcessor{
+		parser: parser,
+	}
+}
+
+// declaration holds the annotations and modifiers of a definition
+type declaration struct {
+	visibility ir.Visibility
+	modifiers  []ir.Modifier
+	decorators []string
+	scala      ir.ScalaExtensions
+	// Indicates a case class or case object
+	isCase bool
+}
+
+// ProcessSource processes Scala source code using tree-sitter
+func (p *TreeSitterProcessor) ProcessSource(ctx context.Context, source []byte, filename string) (*ir.DistilledFile, error) {
+	// Parse the source code
+	tree, err := p.parser.ParseCtx(ctx, nil, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Scala code: %w", err)
+	}
+	defer tree.Close()
+
+	// Create distilled file
+	file := &ir.DistilledFile{
+		BaseNode: ir.BaseNode{
+			Location: ir.Location{
+				StartLine: 1,
+				EndLine:   int(tree.RootNode().EndPoint().Row) + 1,
+			},
+		},
+		Path:     filename,
+		Language: "scala",
+		Version:  "1.0",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+
+	file.Children = p.processChildren(tree.RootNode(), source, "")
+	return file, nil
+}
+
+// processChildren processes the definitions among the children of a node.
+// owner is the name of the enclosing enum, whose simple cases have its type.
+func (p *TreeSitterProcessor) processChildren(node *sitter.Node, source []byte, owner string) []ir.DistilledNode {
+	end := int(node.ChildCount())
+	if node.Parent() != nil {
+		// Comments ending an indented block belong to what follows it
+		end -= len(p.trailingComments(node))
+	}
+	var result []ir.DistilledNode
+	for i := 0; i < end; i++ {
+		child := node.Child(i)
+		result = append(result, p.processNode(child, source, owner)...)
+		result = append(result, p.hoistedComments(child, source)...)
+	}
+	return result
+}
+
+// hoistedComments returns the comments that end the last block nested in a
+// node. With the Scala 3 indentation syntax the block ends at the next
+// definition, so tree-sitter puts the comments of that definition inside
+// the block.
+func (p *TreeSitterProcessor) hoistedComments(node *sitter.Node, source []byte) []ir.DistilledNode {
+	for node.ChildCount() > 0 {
+		if comments := p.trailingComments(node); len(comments) > 0 {
+			var result []ir.DistilledNode
+			for _, comment := range comments {
+				result = append(result, p.processComment(comment, source))
+			}
+			return result
+		}
+		node = node.Child(int(node.ChildCount()) - 1)
+	}
+	return nil
+}
+
+// trailingComments returns the comments after the last other child of a node
+func (p *TreeSitterProcessor) trailingComments(node *sitter.Node) []*sitter.Node {
+	start := int(node.ChildCount())
+	for start > 0 && isComment(node.Child(start-1)) {
+		start--
+	}
+	var comments []*sitter.Node
+	for i := start; i < int(node.ChildCount()); i++ {
+		comments = append(comments, node.Child(i))
+	}
+	return comments
+}
+
+// processNode processes a definition
+func (p *TreeSitterProcessor) processNode(node *sitter.Node, source []byte, owner string) []ir.DistilledNode {
+	switch node.Type() {
+	case "package_clause":
+		return p.processPackage(node, source)
+	case "import_declaration":
+		return []ir.DistilledNode{p.processImport(node, source)}
+	case "comment", "block_comment":
+		return []ir.DistilledNode{p.processComment(node, source)}
+	case "class_definition", "object_definition", "package_object":
+		return []ir.DistilledNode{p.processClass(node, source)}
+	case "trait_definition":
+		return []ir.DistilledNode{p.processTrait(node, source)}
+	case "enum_definition":
+		return []ir.DistilledNode{p.processEnum(node, source)}
+	case "enum_case_definitions":
+		return p.processEnumCases(node, source, owner)
+	case "function_definition", "function_declaration":
+		return []ir.DistilledNode{p.processFunction(node, source)}
+	case "val_definition", "val_declaration", "var_definition", "var_declaration":
+		return []ir.DistilledNode{p.processField(node, source)}
+	case "type_definition":
+		return []ir.DistilledNode{p.processTypeAlias(node, source)}
+	case "given_definition":
+		return []ir.DistilledNode{p.processGiven(node, source)}
+	case "extension_definition":
+		return p.processExtension(node, source)
+	case "compilation_unit", "template_body", "with_template_body", "enum_body", "ERROR":
+		// Definitions in unparsed code, e.g. of braces after given ... with,
+		// are kept
+		return p.processChildren(node, source, owner)
+	}
+	// Expressions and statements, e.g. of scripts
+	return nil
+}
+
+// processPackage handles package clauses, including packagings with a body
+func (p *TreeSitterProcessor) processPackage(node *sitter.Node, source []byte) []ir.DistilledNode {
+	result := []ir.DistilledNode{&ir.DistilledPackage{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Name: p.fieldText(node, "name", source),
+	}}
+	if body := node.ChildByFieldName("body"); body != nil {
+		result = append(result, p.processChildren(body, source, "")...)
+	}
+	return result
+}
+
+// processImport handles import declarations. A simple import keeps the
+// whole path as the module; selectors and wildcards are symbols of the
+// path before them.
+func (p *TreeSitterProcessor) processImport(node *sitter.Node, source []byte) *ir.DistilledImport {
+	imp := &ir.DistilledImport{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		ImportType: "import",
+		Symbols:    []ir.ImportedSymbol{},
+	}
+
+	var path []string
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch {
+		case node.FieldNameForChild(i) == "path" && child.Type() != ".":
+			path = append(path, p.nodeText(child, source))
+		case child.Type() == "namespace_selectors":
+			for j := 0; j < int(child.ChildCount()); j++ {
+				if symbol, ok := p.importSelector(child.Child(j), source); ok {
+					imp.Symbols = append(imp.Symbols, symbol)
+				}
+			}
+		case child.Type() == "namespace_wildcard", child.Type() == "as_renamed_identifier", child.Type() == "arrow_renamed_identifier":
+			if symbol, ok := p.importSelector(child, source); ok {
+				imp.Symbols = append(imp.Symbols, symbol)
+			}
+		}
+	}
+
+	imp.Module = strings.Join(path, ".")
+	if len(imp.Symbols) == 0 && len(path) > 0 {
+		imp.Symbols = append(imp.Symbols, ir.ImportedSymbol{Name: path[len(path)-1]})
+	}
+	return imp
+}
+
+// importSelector returns the symbol of an import selector
+func (p *TreeSitterProcessor) importSelector(node *sitter.Node, source []byte) (ir.ImportedSymbol, bool) {
+	switch node.Type() {
+	case "identifier":
+		return ir.ImportedSymbol{Name: p.nodeText(node, source)}, true
+	case "namespace_wildcard":
+		return ir.ImportedSymbol{Name: strings.TrimSpace(p.nodeText(node, source))}, true
+	case "as_renamed_identifier", "arrow_renamed_identifier":
+		return ir.ImportedSymbol{
+			Name:  p.fieldText(node, "name", source),
+			Alias: p.fieldText(node, "alias", source),
+		}, true
+	}
+	return ir.ImportedSymbol{}, false
+}
+
+// processComment handles line, block and Scaladoc comments
+func (p *TreeSitterProcessor) processComment(node *sitter.Node, source []byte) *ir.DistilledComment {
+	text := p.nodeText(node, source)
+	format := "line"
+	switch {
+	case strings.HasPrefix(text, "/**"):
+		format = "doc"
+	case strings.HasPrefix(text, "/*"):
+		format = "block"
+	}
+
+	return &ir.DistilledComment{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Text:   text,
+		Format: format,
+	}
+}
+
+// processClass handles classes, case classes, objects and package objects.
+// Objects are classes with the static modifier, and case classes and case
+// objects have the data modifier, as in Kotlin.
+func (p *TreeSitterProcessor) processClass(node *sitter.Node, source []byte) *ir.DistilledClass {
+	decl := p.declaration(node, source)
+	// Parameterized enum cases are case classes
+	decl.isCase = decl.isCase || node.Type() == "full_enum_case"
+	class := &ir.DistilledClass{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Name:       p.fieldText(node, "name", source),
+		Visibility: decl.visibility,
+		Modifiers:  decl.modifiers,
+		Decorators: decl.decorators,
+		TypeParams: p.typeParams(node.ChildByFieldName("type_parameters"), source),
+		Children:   []ir.DistilledNode{},
+	}
+	switch node.Type() {
+	case "object_definition":
+		class.Modifiers = append(class.Modifiers, ir.ModifierStatic)
+	case "package_object":
+		class.Modifiers = append(class.Modifiers, ir.ModifierStatic)
+		decl.scala.Keywords = append([]string{"package"}, decl.scala.Keywords...)
+	}
+	if decl.isCase {
+		class.Modifiers = append(class.Modifiers, ir.ModifierData)
+	}
+	class.Extensions = scalaExtensions(decl.scala)
+
+	extends := p.extends(node, source)
+	if len(extends) > 0 {
+		class.Extends = extends[:1]
+		class.Mixins = extends[1:]
+	}
+
+	class.Children = append(class.Children, p.primaryConstructor(node, source, decl.isCase)...)
+	if body := node.ChildByFieldName("body"); body != nil {
+		class.Children = append(class.Children, p.processChildren(body, source, class.Name)...)
+	}
+	return class
+}
+
+// processTrait handles traits, which have the shape of interfaces with
+// default methods
+func (p *TreeSitterProcessor) processTrait(node *sitter.Node, source []byte) *ir.DistilledInterface {
+	decl := p.declaration(node, source)
+	trait := &ir.DistilledInterface{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: scalaExtensions(decl.scala),
+		},
+		Name:       p.fieldText(node, "name", source),
+		Visibility: decl.visibility,
+		Modifiers:  decl.modifiers,
+		TypeParams: p.typeParams(node.ChildByFieldName("type_parameters"), source),
+		Extends:    p.extends(node, source),
+		Children:   []ir.DistilledNode{},
+	}
+	if body := node.ChildByFieldName("body"); body != nil {
+		trait.Children = p.processChildren(body, source, "")
+	}
+	return trait
+}
+
+// processEnum handles Scala 3 enums as classes with the enum modifier,
+// which keep their type parameters and constructor
+func (p *TreeSitterProcessor) processEnum(node *sitter.Node, source []byte) *ir.DistilledClass {
+	class := p.processClass(node, source)
+	class.Modifiers = append(class.Modifiers, ir.ModifierEnum)
+	return class
+}
+
+// processEnumCases handles the cases of an enum. Simple cases are constants
+// of the enum type; cases with parameters are case classes.
+func (p *TreeSitterProcessor) processEnumCases(node *sitter.Node, source []byte, owner string) []ir.DistilledNode {
+	var result []ir.DistilledNode
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch child.Type() {
+		case "simple_enum_case":
+			result = append(result, &ir.DistilledField{
+				BaseNode: ir.BaseNode{
+					Location: p.nodeLocation(child),
+				},
+				Name:       p.fieldText(child, "name", source),
+				Visibility: ir.VisibilityPublic,
+				Modifiers:  []ir.Modifier{ir.ModifierStatic, ir.ModifierFinal},
+				Type:       &ir.TypeRef{Name: owner},
+				// The parent constructor call, e.g. extends Color(0xff0000)
+				DefaultValue: p.fieldText(child, "extend", source),
+			})
+		case "full_enum_case":
+			result = append(result, p.processClass(child, source))
+		}
+	}
+	return result
+}
+
+// primaryConstructor returns the constructor of a class with parameters,
+// followed by the fields its val and var parameters declare. All
+// parameters of case classes are fields.
+func (p *TreeSitterProcessor) primaryConstructor(node *sitter.Node, source []byte, isCase bool) []ir.DistilledNode {
+	var lists []*sitter.Node
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.FieldNameForChild(i) == "class_parameters" {
+			lists = append(lists, node.Child(i))
+		}
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+
+	constructor := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(lists[0]),
+		},
+		Name:       "constructor",
+		Visibility: ir.VisibilityPublic,
+		Decorators: []string{"@Primary"},
+		Parameters: []ir.Parameter{},
+	}
+	result := []ir.DistilledNode{constructor}
+
+	var sizes []int
+	for n, list := range lists {
+		keyword := p.listKeyword(list)
+		count := 0
+		for i := 0; i < int(list.ChildCount()); i++ {
+			param := list.Child(i)
+			if param.Type() != "class_parameter" {
+				continue
+			}
+			count++
+			constructor.Parameters = append(constructor.Parameters, p.parameter(param, source, keyword))
+			if field := p.parameterField(param, source, isCase && n == 0); field != nil {
+				result = append(result, field)
+			}
+		}
+		sizes = append(sizes, count)
+	}
+	constructor.Extensions = scalaExtensions(ir.ScalaExtensions{ParameterLists: parameterLists(sizes)})
+	return result
+}
+
+// parameterField returns the field declared by a class parameter, or nil
+// if the parameter is not a field
+func (p *TreeSitterProcessor) parameterField(node *sitter.Node, source []byte, isCase bool) *ir.DistilledField {
+	decl := p.declaration(node, source)
+	mutable := p.childOfType(node, "var") != nil
+	if !mutable && p.childOfType(node, "val") == nil && !isCase {
+		return nil
+	}
+
+	field := &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: scalaExtensions(decl.scala),
+		},
+		Name:       p.fieldText(node, "name", source),
+		Visibility: decl.visibility,
+		Modifiers:  decl.modifiers,
+		Decorators: decl.decorators,
+	}
+	if !mutable {
+		field.Modifiers = append(field.Modifiers, ir.ModifierFinal)
+	}
+	if typ := node.ChildByFieldName("type"); typ != nil {
+		field.Type = &ir.TypeRef{Name: p.typeText(typ, source)}
+	}
+	return field
+}
+
+// processFunction handles methods, abstract methods and secondary
+// constructors, which are named constructor
+func (p *TreeSitterProcessor) processFunction(node *sitter.Node, source []byte) *ir.DistilledFunction {
+	decl := p.declaration(node, source)
+	fn := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Name:       p.fieldText(node, "name", source),
+		Visibility: decl.visibility,
+		Modifiers:  decl.modifiers,
+		Decorators: decl.decorators,
+		TypeParams: p.typeParams(node.ChildByFieldName("type_parameters"), source),
+		Parameters: []ir.Parameter{},
+	}
+	if fn.Name == "this" {
+		fn.Name = "constructor"
+	}
+
+	var sizes []int
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch node.FieldNameForChild(i) {
+		case "parameters":
+			keyword := p.listKeyword(child)
+			count := 0
+			for j := 0; j < int(child.ChildCount()); j++ {
+				if param := child.Child(j); param.Type() == "parameter" {
+					fn.Parameters = append(fn.Parameters, p.parameter(param, source, keyword))
+					count++
+				}
+			}
+			sizes = append(sizes, count)
+		case "return_type":
+			if fn.Returns == nil {
+				fn.Returns = &ir.TypeRef{Name: p.typeText(child, source)}
+			}
+		case "body":
+			fn.Implementation = p.bodyText(child, source)
+		}
+	}
+
+	decl.scala.ParameterLists = parameterLists(sizes)
+	fn.Extensions = scalaExtensions(decl.scala)
+	return fn
+}
+
+// processField handles val and var definitions and declarations. Values
+// are kept when they fit on one line.
+func (p *TreeSitterProcessor) processField(node *sitter.Node, source []byte) *ir.DistilledField {
+	decl := p.declaration(node, source)
+	field := &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: scalaExtensions(decl.scala),
+		},
+		Visibility: decl.visibility,
+		Modifiers:  decl.modifiers,
+		Decorators: decl.decorators,
+	}
+	if strings.HasPrefix(node.Type(), "val_") {
+		field.Modifiers = append(field.Modifiers, ir.ModifierFinal)
+	}
+
+	field.Name = p.fieldText(node, "name", source)
+	if field.Name == "" {
+		field.Name = p.fieldText(node, "pattern", source)
+	}
+	if typ := node.ChildByFieldName("type"); typ != nil {
+		field.Type = &ir.TypeRef{Name: p.typeText(typ, source)}
+	}
+	if value := p.fieldText(node, "value", source); !strings.Contains(value, "\n") {
+		field.DefaultValue = value
+	}
+	return field
+}
+
+// processTypeAlias handles type definitions. Abstract type members keep
+// their bounds as the type, e.g. <: AnyRef.
+func (p *TreeSitterProcessor) processTypeAlias(node *sitter.Node, source []byte) *ir.DistilledTypeAlias {
+	decl := p.declaration(node, source)
+	alias := &ir.DistilledTypeAlias{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: scalaExtensions(decl.scala),
+		},
+		Name:       p.fieldText(node, "name", source),
+		Visibility: decl.visibility,
+		Modifiers:  decl.modifiers,
+		TypeParams: p.typeParams(node.ChildByFieldName("type_parameters"), source),
+	}
+
+	var bounds []string
+	for i := 0; i < int(node.ChildCount()); i++ {
+		switch node.FieldNameForChild(i) {
+		case "type":
+			alias.Type = ir.TypeRef{Name: p.typeText(node.Child(i), source)}
+		case "bound":
+			bounds = append(bounds, p.typeText(node.Child(i), source))
+		}
+	}
+	if alias.Type.Name == "" {
+		alias.Type.Name = strings.Join(bounds, " ")
+	}
+	return alias
+}
+
+// processGiven handles given instances. A given with a body is an object
+// implementing its type; an alias given is a field.
+func (p *TreeSitterProcessor) processGiven(node *sitter.Node, source []byte) ir.DistilledNode {
+	decl := p.declaration(node, source)
+	decl.scala.Keywords = append([]string{"given"}, decl.scala.Keywords...)
+
+	var typ *sitter.Node
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.FieldNameForChild(i) == "return_type" && node.Child(i).Type() != "with" {
+			typ = node.Child(i)
+			break
+		}
+	}
+	typeName := ""
+	if typ != nil {
+		typeName = p.typeText(typ, source)
+	}
+	name := p.fieldText(node, "name", source)
+	if name == "" {
+		// Anonymous givens are named after their type by the compiler
+		name = "given_" + strings.Trim(nonWord.ReplaceAllString(typeName, "_"), "_")
+	}
+
+	body := node.ChildByFieldName("body")
+	if body != nil && body.Type() == "with_template_body" {
+		class := &ir.DistilledClass{
+			BaseNode: ir.BaseNode{
+				Location:   p.nodeLocation(node),
+				Extensions: scalaExtensions(decl.scala),
+			},
+			Name:       name,
+			Visibility: decl.visibility,
+			Modifiers:  append(decl.modifiers, ir.ModifierStatic),
+			Decorators: decl.decorators,
+			TypeParams: p.typeParams(node.ChildByFieldName("type_parameters"), source),
+			Children:   p.processChildren(body, source, ""),
+		}
+		if typeName != "" {
+			class.Implements = []ir.TypeRef{{Name: typeName}}
+		}
+		return class
+	}
+
+	field := &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location:   p.nodeLocation(node),
+			Extensions: scalaExtensions(decl.scala),
+		},
+		Name:       name,
+		Visibility: decl.visibility,
+		Modifiers:  append(decl.modifiers, ir.ModifierFinal),
+		Decorators: decl.decorators,
+	}
+	if typeName != "" {
+		field.Type = &ir.TypeRef{Name: typeName}
+	}
+	if body != nil && !strings.Contains(p.nodeText(body, source), "\n") {
+		field.DefaultValue = p.nodeText(body, source)
+	}
+	return field
+}
+
+// processExtension handles Scala 3 extension methods. As in Kotlin, each
+// method is named after its receiver type, e.g. String.shout.
+func (p *TreeSitterProcessor) processExtension(node *sitter.Node, source []byte) []ir.DistilledNode {
+	var receiver, receiverType []string
+	var methods []*sitter.Node
+	var result []ir.DistilledNode
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		if isComment(child) {
+			// go-tree-sitter reports the field of the previous child for comments
+			result = append(result, p.processComment(child, source))
+			continue
+		}
+		switch node.FieldNameForChild(i) {
+		case "type_parameters", "parameters":
+			receiver = append(receiver, p.typeText(child, source))
+			if child.Type() == "parameters" && receiverType == nil {
+				if param := p.childOfType(child, "parameter"); param != nil {
+					receiverType = append(receiverType, p.fieldText(param, "type", source))
+				}
+			}
+		case "body":
+			methods = append(methods, child)
+		}
+	}
+
+	for _, method := range methods {
+		var nodes []ir.DistilledNode
+		if method.Type() == "function_definition" || method.Type() == "function_declaration" {
+			// Methods follow the extension clause directly, or are indented
+			// below it
+			nodes = append([]ir.DistilledNode{p.processFunction(method, source)}, p.hoistedComments(method, source)...)
+		} else {
+			nodes = p.processChildren(method, source, "")
+		}
+		for _, node := range nodes {
+			if fn, ok := node.(*ir.DistilledFunction); ok {
+				if len(receiverType) > 0 {
+					fn.Name = receiverType[0] + "." + fn.Name
+				}
+				fn.Decorators = append(fn.Decorators, "@Extension")
+				if fn.Extensions == nil {
+					fn.Extensions = &ir.NodeExtensions{Scala: &ir.ScalaExtensions{}}
+				}
+				fn.Extensions.Scala.Receiver = strings.Join(receiver, "")
+			}
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// declaration collects the annotations and modifiers of a definition
+func (p *TreeSitterProcessor) declaration(node *sitter.Node, source []byte) declaration {
+	decl := declaration{visibility: ir.VisibilityPublic}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch child.Type() {
+		case "annotation":
+			decl.decorators = append(decl.decorators, p.nodeText(child, source))
+		case "case":
+			decl.isCase = true
+		case "modifiers":
+			for j := 0; j < int(child.ChildCount()); j++ {
+				p.modifier(child.Child(j), source, &decl)
+			}
+		default:
+			if strings.HasSuffix(child.Type(), "_modifier") {
+				p.modifier(child, source, &decl)
+			}
+		}
+	}
+	return decl
+}
+
+// modifier adds a modifier to a declaration
+func (p *TreeSitterProcessor) modifier(node *sitter.Node, source []byte, decl *declaration) {
+	if node.Type() == "access_modifier" {
+		qualifier := ""
+		if q := p.childOfType(node, "access_qualifier"); q != nil {
+			qualifier = strings.TrimSpace(strings.Trim(p.nodeText(q, source), "[]"))
+		}
+		switch {
+		case strings.HasPrefix(p.nodeText(node, source), "protected"):
+			decl.visibility = ir.VisibilityProtected
+		case qualifier != "" && qualifier != "this":
+			// Visible in the enclosing package or class
+			decl.visibility = ir.VisibilityInternal
+		default:
+			decl.visibility = ir.VisibilityPrivate
+		}
+		if qualifier != "this" {
+			decl.scala.Qualifier = qualifier
+		}
+		return
+	}
+
+	switch text := p.nodeText(node, source); text {
+	case "abstract":
+		decl.modifiers = append(decl.modifiers, ir.ModifierAbstract)
+	case "final":
+		decl.modifiers = append(decl.modifiers, ir.ModifierFinal)
+	case "sealed":
+		decl.modifiers = append(decl.modifiers, ir.ModifierSealed)
+	case "override":
+		decl.modifiers = append(decl.modifiers, ir.ModifierOverride)
+	case "inline":
+		decl.modifiers = append(decl.modifiers, ir.ModifierInline)
+	default:
+		decl.scala.Keywords = append(decl.scala.Keywords, text)
+	}
+}
+
+// parameter creates a parameter. Annotations, modifiers, val and var of
+// class parameters and the using or implicit keyword of the parameter list
+// are its decorators.
+func (p *TreeSitterProcessor) parameter(node *sitter.Node, source []byte, keyword string) ir.Parameter {
+	param := ir.Parameter{
+		Name:         p.fieldText(node, "name", source),
+		DefaultValue: p.fieldText(node, "default_value", source),
+	}
+	if keyword != "" {
+		param.Decorators = append(param.Decorators, keyword)
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch child.Type() {
+		case "annotation", "val", "var":
+			param.Decorators = append(param.Decorators, p.nodeText(child, source))
+		case "modifiers":
+			for j := 0; j < int(child.ChildCount()); j++ {
+				param.Decorators = append(param.Decorators, p.nodeText(child.Child(j), source))
+			}
+		}
+	}
+	if typ := node.ChildByFieldName("type"); typ != nil {
+		if typ.Type() == "repeated_parameter_type" {
+			param.IsVariadic = true
+			typ = typ.ChildByFieldName("type")
+		}
+		if typ != nil {
+			param.Type = ir.TypeRef{Name: p.typeText(typ, source)}
+		}
+	}
+	return param
+}
+
+// listKeyword returns using or implicit for a context parameter list, or an
+// empty string
+func (p *TreeSitterProcessor) listKeyword(list *sitter.Node) string {
+	for i := 0; i < int(list.ChildCount()); i++ {
+		switch list.Child(i).Type() {
+		case "using", "implicit":
+			return list.Child(i).Type()
+		}
+	}
+	return ""
+}
+
+// typeParams returns the type parameters of a definition. Variance, type
+// parameters of higher-kinded types and lower and context bounds are part
+// of the name; the upper bound is the constraint.
+func (p *TreeSitterProcessor) typeParams(node *sitter.Node, source []byte) []ir.TypeParam {
+	if node == nil {
+		return nil
+	}
+	var params []ir.TypeParam
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch {
+		case child.Type() == "covariant_type_parameter", child.Type() == "contravariant_type_parameter":
+			// The variance wraps the name and bounds of the parameter
+			variance := p.nodeText(child.Child(0), source)
+			for _, param := range p.typeParams(child, source) {
+				param.Name = variance + param.Name
+				params = append(params, param)
+			}
+		case node.FieldNameForChild(i) == "name":
+			params = append(params, ir.TypeParam{Name: p.nodeText(child, source)})
+		case len(params) == 0:
+		case node.FieldNameForChild(i) == "type_parameters":
+			params[len(params)-1].Name += p.typeText(child, source)
+		case node.FieldNameForChild(i) == "bound":
+			last := &params[len(params)-1]
+			if child.Type() == "upper_bound" {
+				if typ := child.ChildByFieldName("type"); typ != nil {
+					last.Constraints = append(last.Constraints, ir.TypeRef{Name: p.typeText(typ, source)})
+				}
+			} else if child.Type() == "context_bound" {
+				last.Name += p.typeText(child, source)
+			} else {
+				last.Name += " " + p.typeText(child, source)
+			}
+		}
+	}
+	return params
+}
+
+// extends returns the parent types of a class, object or trait
+func (p *TreeSitterProcessor) extends(node *sitter.Node, source []byte) []ir.TypeRef {
+	clause := node.ChildByFieldName("extend")
+	if clause == nil {
+		return nil
+	}
+	var types []ir.TypeRef
+	for i := 0; i < int(clause.ChildCount()); i++ {
+		child := clause.Child(i)
+		if clause.FieldNameForChild(i) == "type" && child.Type() != "with" {
+			types = append(types, ir.TypeRef{Name: p.typeText(child, source)})
+		}
+	}
+	return types
+}
+
+// parameterLists returns the sizes of parameter lists to record, when
+// there are several lists or a single empty list
+func parameterLists(sizes []int) []int {
+	if len(sizes) > 1 || (len(sizes) == 1 && sizes[0] == 0) {
+		return sizes
+	}
+	return nil
+}
+
+// scalaExtensions returns the extensions of a node, or nil if they hold no
+// metadata
+func scalaExtensions(ext ir.ScalaExtensions) *ir.NodeExtensions {
+	if len(ext.Keywords) == 0 && ext.Qualifier == "" && ext.ParameterLists == nil && ext.Receiver == "" {
+		return nil
+	}
+	return &ir.NodeExtensions{Scala: &ext}
+}
+
+// Helper methods
+
+// isComment reports whether a node is a line or block comment
+func isComment(node *sitter.Node) bool {
+	return node.Type() == "comment" || node.Type() == "block_comment"
+}
+
+// nodeText extracts the text content of a node
+func (p *TreeSitterProcessor) nodeText(node *sitter.Node, source []byte) string {
+	return string(source[node.StartByte():node.EndByte()])
+}
+
+// bodyText extracts the text of a method body. A body that starts on its
+// own line, e.g. an indented block, keeps the line break and indentation
+// before it.
+func (p *TreeSitterProcessor) bodyText(node *sitter.Node, source []byte) string {
+	start := int(node.StartByte())
+	lineStart := start
+	for lineStart > 0 && (source[lineStart-1] == ' ' || source[lineStart-1] == '\t') {
+		lineStart--
+	}
+	if lineStart > 0 && source[lineStart-1] == '\n' {
+		start = lineStart - 1
+	}
+	return strings.TrimRight(string(source[start:node.EndByte()]), " \t\r\n")
+}
+
+// typeText extracts the text of a type with its whitespace collapsed
+func (p *TreeSitterProcessor) typeText(node *sitter.Node, source []byte) string {
+	return strings.Join(strings.Fields(p.nodeText(node, source)), " ")
+}
+
+// fieldText extracts the text content of a field of a node, or an empty
+// string if the node does not have it
+func (p *TreeSitterProcessor) fieldText(node *sitter.Node, field string, source []byte) string {
+	if child := node.ChildByFieldName(field); child != nil {
+		return p.nodeText(child, source)
+	}
+	return ""
+}
+
+// childOfType returns the first child of a node with the given type, or nil
+func (p *TreeSitterProcessor) childOfType(node *sitter.Node, typ string) *sitter.Node {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if child := node.Child(i); child.Type() == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// nodeLocation creates a Location from a node
+func (p *TreeSitterProcessor) nodeLocation(node *sitter.Node) ir.Location {
+	return ir.Location{
+		StartLine:   int(node.StartPoint().Row) + 1,
+		EndLine:     int(node.EndPoint().Row) + 1,
+		StartColumn: int(node.StartPoint().Column) + 1,
+		EndColumn:   int(node.EndPoint().Column) + 1,
+	}
+}
@@ -0,0 +1,176 @@
+package sfc
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// scriptTag matches the opening tag of a script or style block
+	scriptTag = regexp.MustCompile(`(?i)<(script|style)\b((?:[^>"']|"[^"]*"|'[^']*')*)>`)
+	// attribute matches an attribute of a tag, with an optional value
+	attribute = regexp.MustCompile(`([\w:@.-]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s>]+))?`)
+	// frontmatter matches the fenced code of an Astro component
+	frontmatter = regexp.MustCompile(`^\s*---[ \t]*\r?\n`)
+	// fence matches the line closing the fenced code of an Astro component
+	fence = regexp.MustCompile(`(?m)^---[ \t]*\r?$`)
+	// slotTag matches a slot element of a template
+	slotTag = regexp.MustCompile(`<slot\b((?:[^>"'{]|"[^"]*"|'[^']*'|\{[^}]*\})*)>`)
+	// slotAttribute matches an attribute of a slot element: name="x",
+	// :item="item", v-bind:item="item", item={item} or {item}
+	slotAttribute = regexp.MustCompile(`(?:v-bind)?:?([\w-]+)(?:\s*=\s*(?:"[^"]*"|'[^']*'|\{[^}]*\}))?|\{([\w$]+)\}`)
+)
+
+// block is a script block of a component
+type block struct {
+	// Offsets of the script inside the component
+	start, end int
+	// Script language: js, ts, jsx or tsx
+	lang  string
+	attrs map[string]string
+}
+
+// scriptBlocks returns the script blocks of a Vue or Svelte component.
+// Scripts loaded with src are skipped.
+func scriptBlocks(source string) []block {
+	var blocks []block
+	for _, loc := range scriptTag.FindAllStringSubmatchIndex(source, -1) {
+		if !strings.EqualFold(source[loc[2]:loc[3]], "script") {
+			continue
+		}
+		attrs := attributes(source[loc[4]:loc[5]])
+		if _, ok := attrs["src"]; ok {
+			continue
+		}
+		end := strings.Index(strings.ToLower(source[loc[1]:]), "</script")
+		if end < 0 {
+			continue
+		}
+		blocks = append(blocks, block{
+			start: loc[1],
+			end:   loc[1] + end,
+			lang:  scriptLang(attrs),
+			attrs: attrs,
+		})
+	}
+	return blocks
+}
+
+// frontmatterBlock returns the fenced code at the start of an Astro
+// component, which is always TypeScript
+func frontmatterBlock(source string) (block, bool) {
+	loc := frontmatter.FindStringIndex(source)
+	if loc == nil {
+		return block{}, false
+	}
+	end := fence.FindStringIndex(source[loc[1]:])
+	if end == nil {
+		return block{}, false
+	}
+	return block{start: loc[1], end: loc[1] + end[0], lang: "ts", attrs: map[string]string{}}, true
+}
+
+// attributes parses the attributes of a tag. Attributes without a value
+// have an empty value.
+func attributes(text string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range attribute.FindAllStringSubmatch(text, -1) {
+		attrs[strings.ToLower(m[1])] = unquote(m[2])
+	}
+	return attrs
+}
+
+// scriptLang returns the language of a script block from its lang or type
+// attribute
+func scriptLang(attrs map[string]string) string {
+	lang := strings.ToLower(attrs["lang"])
+	if lang == "" && strings.Contains(strings.ToLower(attrs["type"]), "typescript") {
+		lang = "ts"
+	}
+	switch lang {
+	case "ts", "typescript":
+		return "ts"
+	case "tsx", "jsx":
+		return lang
+	}
+	return "js"
+}
+
+// isTypeScript reports whether a script language is TypeScript
+func isTypeScript(lang string) bool {
+	return lang == "ts" || lang == "tsx"
+}
+
+// markup returns the source outside of the script and style blocks of a
+// component, and outside of Astro frontmatter
+func markup(source string) string {
+	out := []byte(source)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	if fm, ok := frontmatterBlock(source); ok {
+		blank(0, fm.end)
+	}
+	for _, loc := range scriptTag.FindAllStringSubmatchIndex(source, -1) {
+		tag := strings.ToLower(source[loc[2]:loc[3]])
+		end := strings.Index(strings.ToLower(source[loc[1]:]), "</"+tag)
+		if end < 0 {
+			end = len(source) - loc[1]
+		}
+		blank(loc[0], loc[1]+end)
+	}
+	return string(out)
+}
+
+// isolate returns the source with everything outside of a block replaced
+// by spaces, so that positions in the block are positions in the component
+func isolate(source string, b block) []byte {
+	out := []byte(source)
+	for i := range out {
+		if (i < b.start || i >= b.end) && out[i] != '\n' && out[i] != '\r' {
+			out[i] = ' '
+		}
+	}
+	return out
+}
+
+// slot is a slot element of a template
+type slot struct {
+	name string
+	// Props that a slot passes to its content
+	params []parameter
+	line   int
+}
+
+// templateSlots returns the slots of a template in order of appearance,
+// each slot once
+func templateSlots(template string) []slot {
+	var slots []slot
+	seen := map[string]bool{}
+	for _, loc := range slotTag.FindAllStringSubmatchIndex(template, -1) {
+		s := slot{name: "default", line: strings.Count(template[:loc[0]], "\n") + 1}
+		for _, m := range slotAttribute.FindAllStringSubmatch(template[loc[2]:loc[3]], -1) {
+			name := m[1]
+			if m[2] != "" {
+				name = m[2]
+			}
+			switch {
+			case name == "name":
+				if eq := strings.IndexByte(m[0], '='); eq >= 0 {
+					s.name = unquote(m[0][eq+1:])
+				}
+			case name != "" && name != "/" && !strings.HasPrefix(m[0], "v-") || strings.HasPrefix(m[0], "v-bind:"):
+				s.params = append(s.params, parameter{name: name})
+			}
+		}
+		if !seen[s.name] {
+			seen[s.name] = true
+			slots = append(slots, s)
+		}
+	}
+	return slots
+}
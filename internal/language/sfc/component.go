@@ -0,0 +1,760 @@
+package sfc
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// destructuringStart matches the start of a destructuring declaration
+	destructuringStart = regexp.MustCompile(`\b(?:const|let|var)\s*\{`)
+	// exportLet matches a Svelte prop declaration
+	exportLet = regexp.MustCompile(`\bexport\s+let\s+`)
+	// exportDeclaration matches other exports of a Svelte instance script
+	exportDeclaration = regexp.MustCompile(`\bexport\s+(?:async\s+)?(const|function\*?|class)\s+([\w$]+)`)
+	// exportList matches an export list such as export { a, b as c }
+	exportList = regexp.MustCompile(`\bexport\s*\{([^}]*)\}`)
+	// propsVariable matches props assigned to a variable, e.g.
+	// let props: Props = $props()
+	propsVariable = regexp.MustCompile(`\b(?:const|let|var)\s+[\w$]+\s*:\s*([^=]+?)\s*=\s*$`)
+	// dispatcher matches the variable of a Svelte event dispatcher
+	dispatcher = regexp.MustCompile(`\b(?:const|let|var)\s+([\w$]+)\s*=\s*createEventDispatcher\b`)
+	// optionsObject matches the component options of the Vue Options API
+	optionsObject = regexp.MustCompile(`\bexport\s+default\s+(?:defineComponent\s*\(\s*)?\{`)
+)
+
+// runtimeTypes maps the constructors of Vue runtime prop declarations to
+// TypeScript types
+var runtimeTypes = map[string]string{
+	"String":   "string",
+	"Number":   "number",
+	"Boolean":  "boolean",
+	"Array":    "any[]",
+	"Object":   "object",
+	"Function": "Function",
+	"Symbol":   "symbol",
+	"BigInt":   "bigint",
+}
+
+// component is the public API of a component
+type component struct {
+	name       string
+	typeParams string
+	// Type of the props when their members are not declared in the
+	// component
+	propsType string
+	props     []prop
+	events    []event
+	slots     []slot
+	exposed   []exposed
+}
+
+// prop is a property of a component
+type prop struct {
+	name, typ, def string
+	required       bool
+	bindable       bool
+	line           int
+}
+
+// event is an event emitted by a component
+type event struct {
+	name   string
+	params []parameter
+	line   int
+}
+
+// exposed is a member of a component that its parent can access
+type exposed struct {
+	name string
+	// Parameters of an exposed function
+	params   []parameter
+	function bool
+	line     int
+}
+
+// script is a script block prepared for extraction: comments are blanked
+// and offsets are offsets in the component
+type script struct {
+	block
+	code string
+}
+
+// line returns the line of an offset in the component
+func (s script) line(offset int) int {
+	return strings.Count(s.code[:offset], "\n") + 1
+}
+
+// addProp adds a prop or updates the prop with the same name
+func (c *component) addProp(p prop) {
+	for i := range c.props {
+		if c.props[i].name == p.name {
+			existing := &c.props[i]
+			if p.typ != "" {
+				existing.typ = p.typ
+			}
+			if p.def != "" {
+				existing.def = p.def
+				existing.required = false
+			}
+			existing.bindable = existing.bindable || p.bindable
+			return
+		}
+	}
+	c.props = append(c.props, p)
+}
+
+// addEvent adds an event that was not declared yet
+func (c *component) addEvent(e event) {
+	for _, existing := range c.events {
+		if existing.name == e.name {
+			return
+		}
+	}
+	c.events = append(c.events, e)
+}
+
+// addSlot adds a slot that was not declared yet
+func (c *component) addSlot(s slot) {
+	for _, existing := range c.slots {
+		if existing.name == s.name {
+			return
+		}
+	}
+	c.slots = append(c.slots, s)
+}
+
+// setDefaults sets the default values of props, which makes them optional
+func (c *component) setDefaults(defaults []member) {
+	for _, d := range defaults {
+		if d.method || d.value == "" {
+			continue
+		}
+		for i := range c.props {
+			if c.props[i].name == d.name {
+				c.props[i].def = shortValue(d.value)
+				c.props[i].required = false
+			}
+		}
+	}
+}
+
+// typedProps adds the props declared by a type: a type literal, or an
+// interface or type alias declared in the script
+func (c *component) typedProps(s script, typ string, line int, defaults map[string]string) {
+	members, ok := declaredMembers(s.code, typ)
+	if !ok {
+		c.propsType = collapse(typ)
+		return
+	}
+	for _, m := range members {
+		p := prop{name: m.name, typ: memberType(m), required: !m.optional, line: line}
+		if def, ok := defaults[m.name]; ok {
+			p.def = def
+			p.required = false
+		}
+		c.addProp(p)
+	}
+}
+
+// vue extracts the API of a Vue component from a script block
+func (c *component) vue(s script) {
+	code := s.code
+	if generic, ok := s.attrs["generic"]; ok {
+		c.typeParams = collapse(generic)
+	}
+
+	for _, call := range findCalls(code, "defineProps") {
+		line := s.line(call.start)
+		defaults := map[string]string{}
+		if pattern, _, ok := destructuring(code, call.start); ok {
+			defaults = bindingDefaults(pattern)
+		}
+		switch {
+		case call.typeArgs != "":
+			c.typedProps(s, call.typeArgs, line, defaults)
+		case len(call.args) > 0:
+			c.runtimeProps(call.args[0], line)
+			c.setDefaults(defaultMembers(defaults))
+		}
+	}
+	for _, call := range findCalls(code, "withDefaults") {
+		if len(call.args) > 1 {
+			if body, ok := objectBody(call.args[1]); ok {
+				c.setDefaults(objectMembers(body))
+			}
+		}
+	}
+
+	for _, call := range findCalls(code, "defineModel") {
+		c.model(call, s.line(call.start))
+	}
+
+	for _, call := range findCalls(code, "defineEmits") {
+		line := s.line(call.start)
+		switch {
+		case call.typeArgs != "":
+			c.typedEvents(s, call.typeArgs, line)
+		case len(call.args) > 0:
+			c.runtimeEvents(call.args[0], line)
+		}
+	}
+
+	for _, call := range findCalls(code, "defineSlots") {
+		members, _ := declaredMembers(code, call.typeArgs)
+		for _, m := range members {
+			c.addSlot(slot{name: m.name, params: parameters(m.params), line: s.line(call.start)})
+		}
+	}
+
+	for _, call := range findCalls(code, "defineExpose") {
+		if len(call.args) == 0 {
+			continue
+		}
+		body, ok := objectBody(call.args[0])
+		if !ok {
+			continue
+		}
+		for _, m := range objectMembers(body) {
+			e := exposed{
+				name:     m.name,
+				params:   parameters(m.params),
+				function: m.method || isFunction(m.value),
+				line:     s.line(call.start),
+			}
+			if m.value == m.name {
+				// Shorthand for a function or variable of the script
+				e.params, e.function = functionParameters(code, m.name)
+			}
+			c.exposed = append(c.exposed, e)
+		}
+	}
+
+	for _, call := range findCalls(code, "defineOptions") {
+		if len(call.args) > 0 {
+			c.options(call.args[0], s)
+		}
+	}
+	if loc := optionsObject.FindStringIndex(code); loc != nil {
+		if end := matching(code, loc[1]-1); end > 0 {
+			c.options(code[loc[1]-1:end+1], s)
+		}
+	}
+}
+
+// options adds the props, emits, exposed members and name declared by
+// component options, e.g. export default { props: [...], emits: [...] }
+func (c *component) options(object string, s script) {
+	body, ok := objectBody(object)
+	if !ok {
+		return
+	}
+	line := s.line(strings.Index(s.code, object))
+	for _, m := range objectMembers(body) {
+		switch m.name {
+		case "name":
+			if isString(m.value) {
+				c.name = unquote(m.value)
+			}
+		case "props":
+			c.runtimeProps(m.value, line)
+		case "emits":
+			c.runtimeEvents(m.value, line)
+		case "expose":
+			for _, name := range stringItems(m.value) {
+				c.exposed = append(c.exposed, exposed{name: name, line: line})
+			}
+		}
+	}
+}
+
+// runtimeProps adds the props of a Vue runtime declaration: an array of
+// names or an object of types and prop options
+func (c *component) runtimeProps(declaration string, line int) {
+	if names := stringItems(declaration); names != nil {
+		for _, name := range names {
+			c.addProp(prop{name: name, line: line})
+		}
+		return
+	}
+	body, ok := objectBody(declaration)
+	if !ok {
+		return
+	}
+	for _, m := range objectMembers(body) {
+		if m.method {
+			continue
+		}
+		p := prop{name: m.name, line: line}
+		if options, ok := objectBody(m.value); ok {
+			for _, option := range objectMembers(options) {
+				switch option.name {
+				case "type":
+					p.typ = runtimeType(option.value)
+				case "required":
+					p.required = option.value == "true"
+				case "default":
+					if option.method {
+						continue
+					}
+					p.def = shortValue(option.value)
+				}
+			}
+		} else {
+			p.typ = runtimeType(m.value)
+		}
+		c.addProp(p)
+	}
+}
+
+// model adds the prop and the update event of a Vue defineModel call
+func (c *component) model(call call, line int) {
+	p := prop{name: "modelValue", typ: call.typeArgs, bindable: true, line: line}
+	var options string
+	for i, arg := range call.args {
+		if i == 0 && isString(arg) {
+			p.name = unquote(arg)
+		} else {
+			options = arg
+		}
+	}
+	if body, ok := objectBody(options); ok {
+		for _, option := range objectMembers(body) {
+			switch option.name {
+			case "type":
+				if p.typ == "" {
+					p.typ = runtimeType(option.value)
+				}
+			case "required":
+				p.required = option.value == "true"
+			case "default":
+				p.def = shortValue(option.value)
+			}
+		}
+	}
+	c.addProp(p)
+
+	var params []parameter
+	if p.typ != "" {
+		params = []parameter{{name: "value", typ: p.typ}}
+	}
+	c.addEvent(event{name: "update:" + p.name, params: params, line: line})
+}
+
+// typedEvents adds the events of a Vue type declaration: call signatures
+// such as (e: 'change', id: number): void, or named tuples such as
+// change: [id: number]
+func (c *component) typedEvents(s script, typ string, line int) {
+	members, ok := declaredMembers(s.code, typ)
+	if !ok {
+		return
+	}
+	for _, m := range members {
+		if !m.method {
+			c.addEvent(event{name: m.name, params: tupleElements(m.value), line: line})
+			continue
+		}
+		params := parameters(m.params)
+		if len(params) == 0 {
+			continue
+		}
+		for _, name := range strings.Split(params[0].typ, "|") {
+			if isString(name) {
+				c.addEvent(event{name: unquote(name), params: params[1:], line: line})
+			}
+		}
+	}
+}
+
+// runtimeEvents adds the events of a Vue runtime declaration: an array of
+// names or an object of validators
+func (c *component) runtimeEvents(declaration string, line int) {
+	if names := stringItems(declaration); names != nil {
+		for _, name := range names {
+			c.addEvent(event{name: name, line: line})
+		}
+		return
+	}
+	body, ok := objectBody(declaration)
+	if !ok {
+		return
+	}
+	for _, m := range objectMembers(body) {
+		e := event{name: m.name, line: line}
+		switch {
+		case m.method:
+			e.params = parameters(m.params)
+		case strings.HasPrefix(m.value, "("):
+			if end := matching(m.value, 0); end > 0 {
+				e.params = parameters(m.value[1:end])
+			}
+		}
+		c.addEvent(e)
+	}
+}
+
+// svelte extracts the API of a Svelte component from its instance script
+func (c *component) svelte(s script) {
+	code := s.code
+	if generics, ok := s.attrs["generics"]; ok {
+		c.typeParams = collapse(generics)
+	}
+
+	// Svelte 4 props: export let name: Type = value
+	for _, loc := range exportLet.FindAllStringIndex(code, -1) {
+		statement := code[loc[1]:statementEnd(code, loc[1])]
+		for _, declarator := range splitTop(statement, ",") {
+			p := prop{line: s.line(loc[0])}
+			text := collapse(declarator)
+			if eq := topIndex(text, '='); eq >= 0 {
+				p.def = shortValue(text[eq+1:])
+				text = strings.TrimSpace(text[:eq])
+			}
+			if colon := topIndex(text, ':'); colon >= 0 {
+				p.typ = strings.TrimSpace(text[colon+1:])
+				text = strings.TrimSpace(text[:colon])
+			}
+			p.name = text
+			p.required = p.def == "" && !strings.Contains(p.typ, "undefined")
+			c.addProp(p)
+		}
+	}
+
+	// Svelte 5 props: let { name = value }: Props = $props()
+	for _, call := range findCalls(code, "$props") {
+		line := s.line(call.start)
+		pattern, annotation, ok := destructuring(code, call.start)
+		if !ok {
+			if m := propsVariable.FindStringSubmatch(code[:call.start]); m != nil {
+				c.typedProps(s, m[1], line, nil)
+			}
+			continue
+		}
+		c.destructuredProps(s, pattern, annotation, line)
+	}
+
+	// Exported functions and constants can be accessed through bind:this
+	for _, loc := range exportDeclaration.FindAllStringSubmatchIndex(code, -1) {
+		e := exposed{name: code[loc[4]:loc[5]], line: s.line(loc[0])}
+		if strings.HasPrefix(code[loc[2]:loc[3]], "function") {
+			e.function = true
+			if open := strings.IndexByte(code[loc[5]:], '('); open >= 0 {
+				if end := matching(code, loc[5]+open); end > 0 {
+					e.params = parameters(code[loc[5]+open+1 : end])
+				}
+			}
+		}
+		c.exposed = append(c.exposed, e)
+	}
+	for _, m := range exportList.FindAllStringSubmatchIndex(code, -1) {
+		for _, name := range splitTop(code[m[2]:m[3]], ",") {
+			fields := strings.Fields(name)
+			c.exposed = append(c.exposed, exposed{name: fields[len(fields)-1], line: s.line(m[0])})
+		}
+	}
+
+	c.dispatchedEvents(s)
+}
+
+// destructuredProps adds the props of a destructuring pattern, with the
+// types of its annotation when they are declared in the script
+func (c *component) destructuredProps(s script, pattern, annotation string, line int) {
+	var members []member
+	typed := false
+	if annotation != "" {
+		if members, typed = declaredMembers(s.code, annotation); !typed {
+			c.propsType = collapse(annotation)
+		}
+	}
+	types := map[string]member{}
+	for _, m := range members {
+		types[m.name] = m
+	}
+
+	for _, b := range bindings(pattern) {
+		p := prop{name: b.name, def: b.def, line: line}
+		if m, ok := types[b.name]; ok {
+			p.typ = memberType(m)
+			p.required = !m.optional && b.def == ""
+		}
+		if call := findCalls(b.def, "$bindable"); len(call) > 0 {
+			p.bindable = true
+			p.def = ""
+			if len(call[0].args) > 0 {
+				p.def = shortValue(call[0].args[0])
+			}
+			p.required = p.required && p.def == ""
+		}
+		c.addProp(p)
+	}
+	if typed {
+		// Members that are not destructured are props too
+		for _, m := range members {
+			c.addProp(prop{name: m.name, typ: memberType(m), required: !m.optional, line: line})
+		}
+	}
+}
+
+// dispatchedEvents adds the events of a Svelte event dispatcher, declared
+// by its type arguments or found in its calls
+func (c *component) dispatchedEvents(s script) {
+	for _, call := range findCalls(s.code, "createEventDispatcher") {
+		members, _ := declaredMembers(s.code, call.typeArgs)
+		for _, m := range members {
+			e := event{name: m.name, line: s.line(call.start)}
+			if typ := memberType(m); typ != "null" && typ != "undefined" && typ != "void" && typ != "never" {
+				e.params = []parameter{{name: "detail", typ: typ}}
+			}
+			c.addEvent(e)
+		}
+	}
+	for _, m := range dispatcher.FindAllStringSubmatch(s.code, -1) {
+		for _, call := range findCalls(s.code, m[1]) {
+			if len(call.args) > 0 && isString(call.args[0]) {
+				c.addEvent(event{name: unquote(call.args[0]), line: s.line(call.start)})
+			}
+		}
+	}
+}
+
+// astro extracts the props of an Astro component from its frontmatter
+func (c *component) astro(s script) {
+	if members, ok := declaredMembers(s.code, "Props"); ok {
+		line := s.line(strings.Index(s.code, "Props"))
+		for _, m := range members {
+			c.addProp(prop{name: m.name, typ: memberType(m), required: !m.optional, line: line})
+		}
+	}
+
+	if i := indexIdent(s.code, "Astro.props"); i >= 0 {
+		if pattern, _, ok := destructuring(s.code, i); ok {
+			for _, b := range bindings(pattern) {
+				c.addProp(prop{name: b.name, def: b.def, line: s.line(i)})
+			}
+		}
+	}
+}
+
+// declaredMembers returns the members of a type literal, or of an interface
+// or object type alias declared in the script
+func declaredMembers(code, typ string) ([]member, bool) {
+	typ = strings.TrimSpace(typ)
+	if body, ok := objectBody(typ); ok {
+		return typeMembers(body), true
+	}
+	if !regexp.MustCompile(`^[\w$]+$`).MatchString(typ) {
+		return nil, false
+	}
+	body, ok := typeBody(code, typ)
+	if !ok {
+		return nil, false
+	}
+	return typeMembers(body), true
+}
+
+// memberType returns the type of a member, or the function type of a
+// method
+func memberType(m member) string {
+	if !m.method {
+		return collapse(m.value)
+	}
+	returns := m.value
+	if returns == "" {
+		returns = "void"
+	}
+	return "(" + collapse(m.params) + ") => " + collapse(returns)
+}
+
+// binding is a name bound by a destructuring pattern
+type binding struct {
+	name, def string
+}
+
+// bindings parses a destructuring pattern without the braces, e.g.
+// a, b = 1, c: alias, ...rest. Rest elements are left out.
+func bindings(pattern string) []binding {
+	var result []binding
+	for _, part := range splitTop(pattern, ",") {
+		text := collapse(part)
+		if strings.HasPrefix(text, "...") {
+			continue
+		}
+		var b binding
+		if eq := topIndex(text, '='); eq >= 0 {
+			b.def = strings.TrimSpace(text[eq+1:])
+			text = strings.TrimSpace(text[:eq])
+		}
+		if colon := topIndex(text, ':'); colon >= 0 {
+			text = strings.TrimSpace(text[:colon])
+		}
+		b.name = unquote(text)
+		result = append(result, b)
+	}
+	return result
+}
+
+// bindingDefaults returns the default values of a destructuring pattern
+func bindingDefaults(pattern string) map[string]string {
+	defaults := map[string]string{}
+	for _, b := range bindings(pattern) {
+		if b.def != "" {
+			defaults[b.name] = shortValue(b.def)
+		}
+	}
+	return defaults
+}
+
+// defaultMembers converts default values to object members
+func defaultMembers(defaults map[string]string) []member {
+	var members []member
+	for name, value := range defaults {
+		members = append(members, member{name: name, value: value})
+	}
+	return members
+}
+
+// destructuring returns the object pattern and type annotation of the
+// declaration whose value starts at an offset, e.g. a, b = 1 and Props in
+// const { a, b = 1 }: Props = $props()
+func destructuring(code string, at int) (pattern, annotation string, ok bool) {
+	for _, loc := range destructuringStart.FindAllStringIndex(code[:at], -1) {
+		open := loc[1] - 1
+		end := matching(code, open)
+		if end < 0 || end > at {
+			continue
+		}
+		rest := strings.TrimSpace(code[end+1 : at])
+		if !strings.HasSuffix(rest, "=") {
+			continue
+		}
+		rest = strings.TrimSpace(strings.TrimSuffix(rest, "="))
+		if rest != "" && !strings.HasPrefix(rest, ":") {
+			continue
+		}
+		pattern = code[open+1 : end]
+		annotation = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+		ok = true
+	}
+	return pattern, annotation, ok
+}
+
+// statementEnd returns the offset of the end of the statement starting at
+// an offset: a semicolon or the end of a line outside of brackets
+func statementEnd(code string, start int) int {
+	end := len(code)
+	eachTop(code[start:], func(i int) bool {
+		if code[start+i] == ';' || code[start+i] == '\n' {
+			end = start + i
+			return false
+		}
+		return true
+	})
+	return end
+}
+
+// runtimeType returns the TypeScript type of a Vue runtime prop type, e.g.
+// String, [String, Number] or Object as PropType<User>
+func runtimeType(value string) string {
+	value = collapse(value)
+	if i := strings.Index(value, " as "); i >= 0 {
+		asType := strings.TrimSpace(value[i+4:])
+		if strings.HasPrefix(asType, "PropType<") && strings.HasSuffix(asType, ">") {
+			return asType[len("PropType<") : len(asType)-1]
+		}
+		return asType
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		var types []string
+		for _, item := range splitTop(value[1:len(value)-1], ",") {
+			types = append(types, runtimeType(item))
+		}
+		return strings.Join(types, " | ")
+	}
+	if typ, ok := runtimeTypes[value]; ok {
+		return typ
+	}
+	if value == "null" {
+		return "any"
+	}
+	return value
+}
+
+// tupleElements returns the elements of a named tuple type, such as
+// [id: number, name?: string]
+func tupleElements(tuple string) []parameter {
+	tuple = strings.TrimSpace(tuple)
+	if !strings.HasPrefix(tuple, "[") || !strings.HasSuffix(tuple, "]") {
+		return nil
+	}
+	params := parameters(tuple[1 : len(tuple)-1])
+	for i := range params {
+		if params[i].typ == "" {
+			// Unnamed element
+			params[i].typ, params[i].name = params[i].name, ""
+		}
+	}
+	return params
+}
+
+// objectBody returns the body of an object literal or type literal,
+// without the braces
+func objectBody(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") || matching(text, 0) != len(text)-1 {
+		return "", false
+	}
+	return text[1 : len(text)-1], true
+}
+
+// stringItems returns the items of an array of string literals, or nil if
+// text is not one
+func stringItems(text string) []string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "[") || matching(text, 0) != len(text)-1 {
+		return nil
+	}
+	items := []string{}
+	for _, item := range splitTop(text[1:len(text)-1], ",") {
+		if !isString(item) {
+			return nil
+		}
+		items = append(items, unquote(item))
+	}
+	return items
+}
+
+// functionParameters returns the parameters of a function declared in a
+// script, and whether it is declared
+func functionParameters(code, name string) ([]parameter, bool) {
+	pattern := regexp.MustCompile(`\bfunction\s*\*?\s*` + regexp.QuoteMeta(name) + `\s*(?:<[^(]*>\s*)?\(|` +
+		`\b(?:const|let|var)\s+` + regexp.QuoteMeta(name) + `\s*=\s*(?:async\s*)?\(`)
+	loc := pattern.FindStringIndex(code)
+	if loc == nil {
+		return nil, false
+	}
+	end := matching(code, loc[1]-1)
+	if end < 0 {
+		return nil, false
+	}
+	if strings.HasPrefix(code[loc[0]:], "function") || strings.HasPrefix(strings.TrimSpace(code[end+1:]), "=>") ||
+		regexp.MustCompile(`^\s*:[^=]*=>`).MatchString(code[end+1:]) {
+		return parameters(code[loc[1]:end]), true
+	}
+	return nil, false
+}
+
+// isFunction reports whether a value is a function expression
+func isFunction(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "function") || strings.HasPrefix(value, "async ") ||
+		strings.HasPrefix(value, "(") && strings.Contains(value, "=>")
+}
+
+// shortValue returns a value that fits on one line, or an empty string
+func shortValue(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "\n") {
+		return ""
+	}
+	return value
+}
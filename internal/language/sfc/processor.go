@@ -0,0 +1,272 @@
+package sfc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/javascript"
+	"github.com/janreges/ai-distiller/internal/language/typescript"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/janreges/ai-distiller/internal/stripper"
+)
+
+// Processor handles Vue, Svelte and Astro single-file components. Script
+// blocks are distilled by the JavaScript and TypeScript processors, and the
+// component API is distilled into a class-like node.
+type Processor struct {
+	processor.BaseProcessor
+	framework string
+}
+
+// NewVueProcessor creates a new Vue single-file component processor
+func NewVueProcessor() *Processor {
+	return newProcessor("vue", ".vue")
+}
+
+// NewSvelteProcessor creates a new Svelte component processor
+func NewSvelteProcessor() *Processor {
+	return newProcessor("svelte", ".svelte")
+}
+
+// NewAstroProcessor creates a new Astro component processor
+func NewAstroProcessor() *Processor {
+	return newProcessor("astro", ".astro")
+}
+
+func newProcessor(framework, extension string) *Processor {
+	return &Processor{
+		BaseProcessor: processor.NewBaseProcessor(
+			framework,
+			"1.0.0",
+			[]string{extension},
+		),
+		framework: framework,
+	}
+}
+
+// Process implements processor.LanguageProcessor
+func (p *Processor) Process(ctx context.Context, reader io.Reader, filename string) (*ir.DistilledFile, error) {
+	// Read source code
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+	text := string(source)
+
+	var blocks []block
+	if p.framework == "astro" {
+		if fm, ok := frontmatterBlock(text); ok {
+			blocks = append(blocks, fm)
+		}
+	} else {
+		blocks = scriptBlocks(text)
+	}
+
+	lines := strings.Count(text, "\n") + 1
+	file := &ir.DistilledFile{
+		BaseNode: ir.BaseNode{
+			Location: ir.Location{StartLine: 1, EndLine: lines},
+		},
+		Path:     filename,
+		Language: p.framework,
+		Version:  "1.0",
+		Children: []ir.DistilledNode{},
+	}
+
+	c := &component{name: componentName(filename)}
+	lang := "js"
+	for _, b := range blocks {
+		if isTypeScript(b.lang) {
+			lang = "ts"
+		}
+		s := script{block: b, code: stripComments(string(isolate(text, b)))}
+		switch {
+		case p.framework == "vue":
+			c.vue(s)
+		case p.framework == "astro":
+			c.astro(s)
+		case !isModuleScript(b):
+			c.svelte(s)
+		}
+	}
+	for _, s := range templateSlots(markup(text)) {
+		c.addSlot(s)
+	}
+	file.Children = append(file.Children, c.node(p.framework, lang, lines))
+
+	for _, b := range blocks {
+		if err := p.processScript(ctx, file, text, b, filename); err != nil {
+			file.Errors = append(file.Errors, ir.DistilledError{
+				BaseNode: ir.BaseNode{
+					Location: ir.Location{StartLine: strings.Count(text[:b.start], "\n") + 1},
+				},
+				Message:  err.Error(),
+				Severity: "error",
+			})
+		}
+	}
+
+	return file, nil
+}
+
+// processScript distills a script block with the JavaScript or TypeScript
+// processor and adds its nodes to the file. The rest of the component is
+// blanked, so locations are locations in the component.
+func (p *Processor) processScript(ctx context.Context, file *ir.DistilledFile, text string, b block, filename string) error {
+	var delegate processor.LanguageProcessor = javascript.NewProcessor()
+	if isTypeScript(b.lang) {
+		delegate = typescript.NewProcessor()
+	}
+	result, err := delegate.Process(ctx, bytes.NewReader(isolate(text, b)), filename+"."+b.lang)
+	if err != nil {
+		return err
+	}
+	file.Children = append(file.Children, result.Children...)
+	file.Errors = append(file.Errors, result.Errors...)
+	return nil
+}
+
+// ProcessWithOptions implements processor.LanguageProcessor
+func (p *Processor) ProcessWithOptions(ctx context.Context, reader io.Reader, filename string, opts processor.ProcessOptions) (*ir.DistilledFile, error) {
+	file, err := p.Process(ctx, reader, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply stripper if any options are set
+	stripperOpts := opts.ToStripperOptions()
+	if stripperOpts.HasAnyOption() {
+		s := stripper.New(stripperOpts)
+		stripped := file.Accept(s)
+		if strippedFile, ok := stripped.(*ir.DistilledFile); ok {
+			return strippedFile, nil
+		}
+	}
+
+	return file, nil
+}
+
+// isModuleScript reports whether a Svelte script block runs once per module
+// rather than once per component instance
+func isModuleScript(b block) bool {
+	_, module := b.attrs["module"]
+	return module || b.attrs["context"] == "module"
+}
+
+// componentName returns the name of a component from its file name
+func componentName(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// node returns the class-like node of a component, with its props, events,
+// slots and exposed members
+func (c *component) node(framework, lang string, lines int) *ir.DistilledClass {
+	base := func(line int, kind string) ir.BaseNode {
+		return ir.BaseNode{
+			Location: ir.Location{StartLine: line, EndLine: line},
+			Extensions: &ir.NodeExtensions{Component: &ir.ComponentExtensions{
+				Framework: framework,
+				Script:    lang,
+				Kind:      kind,
+			}},
+		}
+	}
+
+	class := &ir.DistilledClass{
+		BaseNode:   base(1, ""),
+		Name:       c.name,
+		Visibility: ir.VisibilityPublic,
+		TypeParams: typeParams(c.typeParams),
+	}
+	class.Location.EndLine = lines
+	class.Extensions.Component.PropsType = c.propsType
+
+	for _, p := range c.props {
+		field := &ir.DistilledField{
+			BaseNode:     base(p.line, "prop"),
+			Name:         p.name,
+			Visibility:   ir.VisibilityPublic,
+			DefaultValue: p.def,
+		}
+		if p.typ != "" {
+			field.Type = &ir.TypeRef{Name: p.typ}
+		}
+		field.Extensions.Component.Required = p.required
+		field.Extensions.Component.Bindable = p.bindable
+		class.Children = append(class.Children, field)
+	}
+	for _, e := range c.events {
+		class.Children = append(class.Children, &ir.DistilledFunction{
+			BaseNode:   base(e.line, "event"),
+			Name:       e.name,
+			Visibility: ir.VisibilityPublic,
+			Parameters: irParameters(e.params),
+		})
+	}
+	for _, s := range c.slots {
+		class.Children = append(class.Children, &ir.DistilledFunction{
+			BaseNode:   base(s.line, "slot"),
+			Name:       s.name,
+			Visibility: ir.VisibilityPublic,
+			Parameters: irParameters(s.params),
+		})
+	}
+	for _, e := range c.exposed {
+		if e.function {
+			class.Children = append(class.Children, &ir.DistilledFunction{
+				BaseNode:   base(e.line, "exposed"),
+				Name:       e.name,
+				Visibility: ir.VisibilityPublic,
+				Parameters: irParameters(e.params),
+			})
+			continue
+		}
+		class.Children = append(class.Children, &ir.DistilledField{
+			BaseNode:   base(e.line, "exposed"),
+			Name:       e.name,
+			Visibility: ir.VisibilityPublic,
+		})
+	}
+	return class
+}
+
+// irParameters converts parameters to IR parameters
+func irParameters(params []parameter) []ir.Parameter {
+	result := make([]ir.Parameter, 0, len(params))
+	for _, p := range params {
+		result = append(result, ir.Parameter{
+			Name:       p.name,
+			Type:       ir.TypeRef{Name: p.typ},
+			IsVariadic: p.rest,
+			IsOptional: p.optional,
+		})
+	}
+	return result
+}
+
+// typeParams parses the type parameters of a generic component, e.g.
+// T extends string, U = number
+func typeParams(text string) []ir.TypeParam {
+	var result []ir.TypeParam
+	for _, part := range splitTop(text, ",") {
+		text := collapse(part)
+		var param ir.TypeParam
+		if eq := topIndex(text, '='); eq >= 0 {
+			param.Default = &ir.TypeRef{Name: strings.TrimSpace(text[eq+1:])}
+			text = strings.TrimSpace(text[:eq])
+		}
+		if i := strings.Index(text, " extends "); i >= 0 {
+			param.Constraints = []ir.TypeRef{{Name: strings.TrimSpace(text[i+9:])}}
+			text = text[:i]
+		}
+		param.Name = text
+		result = append(result, param)
+	}
+	return result
+}
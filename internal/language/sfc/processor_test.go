@@ -0,0 +1,362 @@
+package sfc
+
+import (
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const setupSource = `<script setup lang="ts" generic="T extends string | number">
+import type { Option } from './types'
+
+interface Props {
+  /** Options to choose from */
+  options: Option<T>[]
+  label?: string
+  size?: 'sm' | 'md'
+    | 'lg'
+  format?(value: T): string
+}
+
+const props = withDefaults(defineProps<Props>(), {
+  size: 'md',
+})
+
+const model = defineModel<T>({ required: true })
+
+const emit = defineEmits<{
+  (e: 'select' | 'pick', value: T, index: number): void
+  'update:query': [query: string]
+}>()
+
+defineSlots<{
+  option(props: { option: Option<T>; active: boolean }): any
+}>()
+
+function reset(all?: boolean) {}
+defineExpose({ reset, count: 1 })
+</script>
+
+<template>
+  <ul>
+    <slot name="option" :option="o" :active="true" />
+    <slot name="empty" />
+  </ul>
+</template>
+`
+
+const optionsSource = `<template>
+  <div>
+    <slot name="header" :title="title" />
+    <slot />
+  </div>
+</template>
+
+<script>
+export default {
+  name: 'MyCounter',
+  props: {
+    label: { type: String, required: true },
+    step: { type: Number, default: 1 },
+    user: Object as PropType<User>,
+    items: [String, Array],
+  },
+  emits: ['change', 'reset'],
+}
+</script>
+
+<style scoped>
+.counter { color: red }
+</style>
+`
+
+const svelteSource = `<script context="module">
+  export const VARIANTS = ['primary', 'secondary'];
+</script>
+
+<script>
+  import { createEventDispatcher } from 'svelte';
+  export let label;
+  export let variant = 'primary';
+  const dispatch = createEventDispatcher();
+
+  export function focus(options) {
+    el.focus(options);
+  }
+
+  function click() {
+    dispatch('press', { label });
+  }
+</script>
+
+<button on:click={click}><slot {label} /></button>
+<slot name="icon"></slot>
+`
+
+const runesSource = `<script lang="ts" generics="T">
+  import type { Snippet } from 'svelte';
+
+  interface Props {
+    title: string;
+    items: T[];
+    open?: boolean;
+    children?: Snippet;
+  }
+
+  let { title, open = $bindable(false), ...rest }: Props = $props();
+</script>
+
+<div>{title}</div>
+`
+
+const astroSource = `---
+import Layout from '../layouts/Layout.astro';
+
+export interface Props {
+  title: string;
+  subtitle?: string;
+}
+
+const { title, subtitle = 'Welcome' } = Astro.props;
+---
+<Layout>
+  <h1>{title}</h1>
+  <slot />
+  <slot name="footer" />
+</Layout>
+<script>
+  console.log('client');
+</script>
+`
+
+// members returns the members of a component by kind
+func members(class *ir.DistilledClass, kind string) []ir.DistilledNode {
+	var result []ir.DistilledNode
+	for _, child := range class.Children {
+		var ext *ir.NodeExtensions
+		switch n := child.(type) {
+		case *ir.DistilledField:
+			ext = n.Extensions
+		case *ir.DistilledFunction:
+			ext = n.Extensions
+		}
+		if ext != nil && ext.Component.Kind == kind {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// componentNode returns the component node of a file
+func componentNode(t *testing.T, file *ir.DistilledFile) *ir.DistilledClass {
+	t.Helper()
+	require.NotEmpty(t, file.Children)
+	class, ok := file.Children[0].(*ir.DistilledClass)
+	require.True(t, ok)
+	return class
+}
+
+func TestProcessVueScriptSetup(t *testing.T) {
+	file := langtest.Process(t, NewVueProcessor(), setupSource, "src/Select.vue")
+	assert.Equal(t, "vue", file.Language)
+
+	class := componentNode(t, file)
+	assert.Equal(t, "Select", class.Name)
+	assert.Equal(t, &ir.ComponentExtensions{Framework: "vue", Script: "ts"}, class.Extensions.Component)
+	require.Len(t, class.TypeParams, 1)
+	assert.Equal(t, "T", class.TypeParams[0].Name)
+	assert.Equal(t, "string | number", class.TypeParams[0].Constraints[0].Name)
+
+	props := members(class, "prop")
+	require.Len(t, props, 5)
+	options := props[0].(*ir.DistilledField)
+	assert.Equal(t, "options", options.Name)
+	assert.Equal(t, "Option<T>[]", options.Type.Name)
+	assert.True(t, options.Extensions.Component.Required)
+	assert.Equal(t, 13, options.Location.StartLine)
+	size := props[2].(*ir.DistilledField)
+	assert.Equal(t, "'sm' | 'md' | 'lg'", size.Type.Name)
+	assert.Equal(t, "'md'", size.DefaultValue)
+	assert.False(t, size.Extensions.Component.Required)
+	assert.Equal(t, "(value: T) => string", props[3].(*ir.DistilledField).Type.Name)
+	model := props[4].(*ir.DistilledField)
+	assert.Equal(t, "modelValue", model.Name)
+	assert.True(t, model.Extensions.Component.Bindable)
+	assert.True(t, model.Extensions.Component.Required)
+
+	var events []string
+	for _, e := range members(class, "event") {
+		events = append(events, e.(*ir.DistilledFunction).Name)
+	}
+	assert.Equal(t, []string{"update:modelValue", "select", "pick", "update:query"}, events)
+	pick := members(class, "event")[2].(*ir.DistilledFunction)
+	require.Len(t, pick.Parameters, 2)
+	assert.Equal(t, "index", pick.Parameters[1].Name)
+	assert.Equal(t, "number", pick.Parameters[1].Type.Name)
+
+	slots := members(class, "slot")
+	require.Len(t, slots, 2)
+	assert.Equal(t, "option", slots[0].(*ir.DistilledFunction).Name)
+	assert.Equal(t, "{ option: Option<T>; active: boolean }", slots[0].(*ir.DistilledFunction).Parameters[0].Type.Name)
+	assert.Equal(t, "empty", slots[1].(*ir.DistilledFunction).Name)
+
+	exposed := members(class, "exposed")
+	require.Len(t, exposed, 2)
+	reset := exposed[0].(*ir.DistilledFunction)
+	assert.Equal(t, "reset", reset.Name)
+	assert.True(t, reset.Parameters[0].IsOptional)
+	assert.Equal(t, "count", exposed[1].(*ir.DistilledField).Name)
+}
+
+func TestProcessVueOptionsAPI(t *testing.T) {
+	file := langtest.Process(t, NewVueProcessor(), optionsSource, "Counter.vue")
+
+	class := componentNode(t, file)
+	assert.Equal(t, "MyCounter", class.Name)
+	assert.Equal(t, "js", class.Extensions.Component.Script)
+
+	props := members(class, "prop")
+	require.Len(t, props, 4)
+	label := props[0].(*ir.DistilledField)
+	assert.Equal(t, "string", label.Type.Name)
+	assert.True(t, label.Extensions.Component.Required)
+	step := props[1].(*ir.DistilledField)
+	assert.Equal(t, "number", step.Type.Name)
+	assert.Equal(t, "1", step.DefaultValue)
+	assert.Equal(t, "User", props[2].(*ir.DistilledField).Type.Name)
+	assert.Equal(t, "string | any[]", props[3].(*ir.DistilledField).Type.Name)
+
+	assert.Len(t, members(class, "event"), 2)
+	slots := members(class, "slot")
+	require.Len(t, slots, 2)
+	header := slots[0].(*ir.DistilledFunction)
+	assert.Equal(t, "header", header.Name)
+	assert.Equal(t, "title", header.Parameters[0].Name)
+	assert.Equal(t, 3, header.Location.StartLine)
+	assert.Equal(t, "default", slots[1].(*ir.DistilledFunction).Name)
+}
+
+func TestProcessSvelte(t *testing.T) {
+	file := langtest.Process(t, NewSvelteProcessor(), svelteSource, "Button.svelte")
+	assert.Equal(t, "svelte", file.Language)
+	assert.Empty(t, file.Errors)
+
+	class := componentNode(t, file)
+	props := members(class, "prop")
+	require.Len(t, props, 2)
+	assert.Equal(t, "label", props[0].(*ir.DistilledField).Name)
+	assert.True(t, props[0].(*ir.DistilledField).Extensions.Component.Required)
+	assert.Equal(t, "'primary'", props[1].(*ir.DistilledField).DefaultValue)
+	assert.Equal(t, 7, props[0].(*ir.DistilledField).Location.StartLine)
+
+	events := members(class, "event")
+	require.Len(t, events, 1)
+	assert.Equal(t, "press", events[0].(*ir.DistilledFunction).Name)
+
+	slots := members(class, "slot")
+	require.Len(t, slots, 2)
+	assert.Equal(t, "label", slots[0].(*ir.DistilledFunction).Parameters[0].Name)
+	assert.Equal(t, "icon", slots[1].(*ir.DistilledFunction).Name)
+
+	exposed := members(class, "exposed")
+	require.Len(t, exposed, 1)
+	assert.Equal(t, "focus", exposed[0].(*ir.DistilledFunction).Name)
+
+	// Script nodes keep their lines in the component
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, functions, 2)
+	assert.Equal(t, "focus", functions[0].Name)
+	assert.Equal(t, 11, functions[0].Location.StartLine)
+	assert.Equal(t, "click", functions[1].Name)
+	assert.Equal(t, 15, functions[1].Location.StartLine)
+}
+
+func TestProcessSvelteRunes(t *testing.T) {
+	file := langtest.Process(t, NewSvelteProcessor(), runesSource, "Card.svelte")
+
+	class := componentNode(t, file)
+	assert.Equal(t, "T", class.TypeParams[0].Name)
+
+	var names []string
+	for _, p := range members(class, "prop") {
+		names = append(names, p.(*ir.DistilledField).Name)
+	}
+	assert.Equal(t, []string{"title", "open", "items", "children"}, names)
+	open := members(class, "prop")[1].(*ir.DistilledField)
+	assert.Equal(t, "boolean", open.Type.Name)
+	assert.Equal(t, "false", open.DefaultValue)
+	assert.True(t, open.Extensions.Component.Bindable)
+	assert.False(t, open.Extensions.Component.Required)
+}
+
+func TestProcessAstro(t *testing.T) {
+	file := langtest.Process(t, NewAstroProcessor(), astroSource, "Hero.astro")
+	assert.Equal(t, "astro", file.Language)
+
+	class := componentNode(t, file)
+	assert.Equal(t, "Hero", class.Name)
+	props := members(class, "prop")
+	require.Len(t, props, 2)
+	assert.True(t, props[0].(*ir.DistilledField).Extensions.Component.Required)
+	subtitle := props[1].(*ir.DistilledField)
+	assert.Equal(t, "string", subtitle.Type.Name)
+	assert.Equal(t, "'Welcome'", subtitle.DefaultValue)
+
+	slots := members(class, "slot")
+	require.Len(t, slots, 2)
+	assert.Equal(t, "footer", slots[1].(*ir.DistilledFunction).Name)
+}
+
+func TestPropsType(t *testing.T) {
+	source := `<script setup lang="ts">
+import type { ButtonProps } from './types'
+defineProps<ButtonProps>()
+</script>
+`
+	class := componentNode(t, langtest.Process(t, NewVueProcessor(), source, "Button.vue"))
+	assert.Equal(t, "ButtonProps", class.Extensions.Component.PropsType)
+	assert.Empty(t, members(class, "prop"))
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	opts.IncludeImplementation = false
+	opts.IncludePrivate = false
+
+	format := func(p *Processor, source, filename string) string {
+		return langtest.FormatText(t, p, source, filename, opts)
+	}
+
+	output := format(NewVueProcessor(), setupSource, "Select.vue")
+	assert.Contains(t, output, "component Select<T extends string | number> {\n"+
+		"    prop options: Option<T>[]\n"+
+		"    prop label?: string\n"+
+		"    prop size?: 'sm' | 'md' | 'lg' = 'md'\n"+
+		"    prop format?: (value: T) => string\n"+
+		"    bindable prop modelValue: T\n"+
+		"    event update:modelValue(value: T)\n"+
+		"    event select(value: T, index: number)\n"+
+		"    event pick(value: T, index: number)\n"+
+		"    event update:query(query: string)\n"+
+		"    slot option(props: { option: Option<T>; active: boolean })\n"+
+		"    slot empty\n"+
+		"    expose reset(all?: boolean)\n"+
+		"    expose count\n"+
+		"}\n")
+
+	output = format(NewSvelteProcessor(), svelteSource, "Button.svelte")
+	assert.Contains(t, output, "component Button {\n"+
+		"    prop label\n"+
+		"    prop variant? = 'primary'\n"+
+		"    event press()\n"+
+		"    slot default(label)\n"+
+		"    slot icon\n"+
+		"    expose focus(options)\n"+
+		"}\n")
+	assert.Contains(t, output, "function click()\n")
+}
@@ -0,0 +1,394 @@
+package sfc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// memberStart matches the start of a member of a type literal or interface:
+// a property, method or call signature
+var memberStart = regexp.MustCompile(`^\s*(readonly\s+)?((['"])[^'"]+['"]|[\w$]+)\s*\??\s*[:(<]|^\s*[(<]`)
+
+// stripComments replaces the comments of a script with spaces, keeping
+// strings, line numbers and offsets
+func stripComments(code string) string {
+	out := []byte(code)
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipString(code, i)
+		case c == '/' && i+1 < len(code) && code[i+1] == '/':
+			for ; i < len(code) && code[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(code) && code[i+1] == '*':
+			end := strings.Index(code[i+2:], "*/")
+			if end < 0 {
+				end = len(code)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		}
+	}
+	return string(out)
+}
+
+// skipString returns the index of the closing quote of the string literal
+// starting at i
+func skipString(code string, i int) int {
+	quote := code[i]
+	for i++; i < len(code); i++ {
+		switch code[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		case '\n':
+			if quote != '`' {
+				return i
+			}
+		}
+	}
+	return len(code) - 1
+}
+
+// matching returns the index of the bracket closing the one at i, or -1.
+// Angle brackets are matched only for an opening < and ignore the arrows of
+// function types.
+func matching(code string, i int) int {
+	var stack []byte
+	for j := i; j < len(code); j++ {
+		c := code[j]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j = skipString(code, j)
+			continue
+		case c == '(' || c == '[' || c == '{' || c == '<' && (j == i || code[i] == '<'):
+			stack = append(stack, c)
+			continue
+		case c == '>' && j > 0 && code[j-1] == '=':
+			continue
+		case c != ')' && c != ']' && c != '}' && c != '>':
+			continue
+		}
+		if c == '>' && (len(stack) == 0 || stack[len(stack)-1] != '<') {
+			continue
+		}
+		if len(stack) == 0 {
+			return -1
+		}
+		stack = stack[:len(stack)-1]
+		if len(stack) == 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// eachTop calls fn with the index of each byte of code that is not nested
+// in brackets, generic type arguments such as Record<string, number> or
+// strings, until fn returns false
+func eachTop(code string, fn func(i int) bool) {
+	depth, angle := 0, 0
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipString(code, i)
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == '<' && i > 0 && isIdentByte(code[i-1]):
+			angle++
+		case c == '>' && angle > 0 && code[i-1] != '=':
+			angle--
+		case depth == 0 && angle == 0:
+			if !fn(i) {
+				return
+			}
+		}
+	}
+}
+
+// splitTop splits code at the separators that are not nested in brackets,
+// generic type arguments or strings, leaving out blank parts
+func splitTop(code string, separators string) []string {
+	var parts []string
+	start := 0
+	eachTop(code, func(i int) bool {
+		if strings.IndexByte(separators, code[i]) >= 0 {
+			parts = append(parts, code[start:i])
+			start = i + 1
+		}
+		return true
+	})
+	parts = append(parts, code[start:])
+
+	var result []string
+	for _, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// topIndex returns the index of the first byte c that is not nested in
+// brackets, generic type arguments or strings, or -1
+func topIndex(code string, c byte) int {
+	index := -1
+	eachTop(code, func(i int) bool {
+		if code[i] == c {
+			index = i
+			return false
+		}
+		return true
+	})
+	return index
+}
+
+// call is a call of a function in a script
+type call struct {
+	// Type arguments without the angle brackets
+	typeArgs string
+	// Arguments without the parentheses
+	args []string
+	// Start and end offsets of the call
+	start, end int
+}
+
+// findCalls returns the calls of a function, such as defineProps<Props>()
+func findCalls(code, name string) []call {
+	var calls []call
+	for offset := 0; ; {
+		i := indexIdent(code[offset:], name)
+		if i < 0 {
+			return calls
+		}
+		start := offset + i
+		j := skipSpace(code, start+len(name))
+		offset = start + len(name)
+
+		var c call
+		c.start = start
+		if j < len(code) && code[j] == '<' {
+			end := matching(code, j)
+			if end < 0 {
+				continue
+			}
+			c.typeArgs = strings.TrimSpace(code[j+1 : end])
+			j = skipSpace(code, end+1)
+		}
+		if j >= len(code) || code[j] != '(' {
+			continue
+		}
+		end := matching(code, j)
+		if end < 0 {
+			continue
+		}
+		c.args = splitTop(code[j+1:end], ",")
+		c.end = end + 1
+		calls = append(calls, c)
+		offset = c.end
+	}
+}
+
+// indexIdent returns the index of an identifier that is not part of a
+// longer identifier or a string, or -1
+func indexIdent(code, name string) int {
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if c == '\'' || c == '"' || c == '`' {
+			i = skipString(code, i)
+			continue
+		}
+		if strings.HasPrefix(code[i:], name) &&
+			(i == 0 || !isIdentByte(code[i-1]) && code[i-1] != '.') &&
+			(i+len(name) == len(code) || !isIdentByte(code[i+len(name)])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// typeBody returns the body of an interface or of an object type alias
+// declared in a script, without the braces
+func typeBody(code, name string) (string, bool) {
+	pattern := regexp.MustCompile(`\b(?:interface\s+` + regexp.QuoteMeta(name) + `\b[^{]*|type\s+` + regexp.QuoteMeta(name) + `\s*(?:<[^=]*>)?\s*=\s*)\{`)
+	loc := pattern.FindStringIndex(code)
+	if loc == nil {
+		return "", false
+	}
+	open := loc[1] - 1
+	end := matching(code, open)
+	if end < 0 {
+		return "", false
+	}
+	return code[open+1 : end], true
+}
+
+// member is a member of a type literal or an object literal
+type member struct {
+	name     string
+	optional bool
+	// Type of a property, or the value of an object literal member
+	value string
+	// Parameters of a method or call signature, without the parentheses
+	params string
+	method bool
+}
+
+// typeMembers parses the members of a type literal or interface body.
+// Members are separated by semicolons, commas or new lines; lines that do
+// not start a member continue the previous one.
+func typeMembers(body string) []member {
+	var texts []string
+	for _, part := range splitTop(body, ";,\n") {
+		if memberStart.MatchString(part) || len(texts) == 0 {
+			texts = append(texts, part)
+		} else {
+			texts[len(texts)-1] += " " + part
+		}
+	}
+
+	var members []member
+	for _, text := range texts {
+		text = collapse(text)
+		text = strings.TrimPrefix(text, "readonly ")
+		var m member
+		colon := topIndex(text, ':')
+		if open := strings.IndexAny(text, "(<"); open >= 0 && (colon < 0 || colon > open) {
+			// Method or call signature
+			m.method = true
+			m.name = strings.TrimSpace(text[:open])
+			if text[open] == '<' {
+				// Skip type parameters
+				if end := matching(text, open); end > 0 {
+					open = strings.IndexByte(text[end:], '(') + end
+				}
+			}
+			end := matching(text, open)
+			if end < 0 {
+				continue
+			}
+			m.params = text[open+1 : end]
+			m.value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text[end+1:]), ":"))
+		} else {
+			if colon < 0 {
+				continue
+			}
+			m.name = strings.TrimSpace(text[:colon])
+			m.value = strings.TrimSpace(text[colon+1:])
+		}
+		if strings.HasSuffix(m.name, "?") {
+			m.optional = true
+			m.name = strings.TrimSpace(strings.TrimSuffix(m.name, "?"))
+		}
+		m.name = unquote(m.name)
+		members = append(members, m)
+	}
+	return members
+}
+
+// objectMembers parses the members of an object literal body:
+// key: value, shorthand keys and methods
+func objectMembers(body string) []member {
+	var members []member
+	for _, part := range splitTop(body, ",") {
+		text := strings.TrimSpace(part)
+		if strings.HasPrefix(text, "...") {
+			continue
+		}
+		colon := topIndex(text, ':')
+		open := strings.IndexByte(text, '(')
+		switch {
+		case colon >= 0 && (open < 0 || colon < open):
+			members = append(members, member{
+				name:  unquote(strings.TrimSpace(text[:colon])),
+				value: strings.TrimSpace(text[colon+1:]),
+			})
+		case open >= 0:
+			end := matching(text, open)
+			if end < 0 {
+				continue
+			}
+			name := strings.TrimSpace(strings.TrimPrefix(text[:open], "async "))
+			members = append(members, member{name: unquote(name), params: text[open+1 : end], method: true})
+		default:
+			members = append(members, member{name: unquote(text), value: text})
+		}
+	}
+	return members
+}
+
+// parameter is a parameter of a function or signature
+type parameter struct {
+	name, typ string
+	rest      bool
+	optional  bool
+}
+
+// parameters parses a parameter list without the parentheses
+func parameters(params string) []parameter {
+	var result []parameter
+	for _, part := range splitTop(params, ",") {
+		text := collapse(part)
+		var p parameter
+		if strings.HasPrefix(text, "...") {
+			p.rest = true
+			text = text[3:]
+		}
+		if eq := topIndex(text, '='); eq >= 0 {
+			text = strings.TrimSpace(text[:eq])
+		}
+		if colon := topIndex(text, ':'); colon >= 0 {
+			p.name = strings.TrimSpace(text[:colon])
+			p.typ = strings.TrimSpace(text[colon+1:])
+		} else {
+			p.name = text
+		}
+		if strings.HasSuffix(p.name, "?") {
+			p.optional = true
+			p.name = strings.TrimSuffix(p.name, "?")
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// collapse trims text and replaces runs of white space with single spaces
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// unquote removes the quotes of a string literal
+func unquote(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && strings.ContainsRune(`'"`+"`", rune(text[0])) && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1]
+	}
+	return text
+}
+
+// isString reports whether text is a string literal
+func isString(text string) bool {
+	return unquote(text) != strings.TrimSpace(text)
+}
+
+func skipSpace(code string, i int) int {
+	for i < len(code) && (code[i] == ' ' || code[i] == '\t' || code[i] == '\n' || code[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
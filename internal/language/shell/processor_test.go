@@ -0,0 +1,154 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deploySource = `#!/usr/bin/env bash
+# Deploys the web tier.
+set -euo pipefail
+
+source ./lib/common.sh
+[ -f "$HOME/.env" ] && . "$HOME/.env"
+
+export DEPLOY_ENV="${1:-prod}"
+readonly VERSION=1.2.3
+LOG_DIR=/var/log
+
+# Uploads the build.
+# Arguments: target host
+deploy() {
+  local host="$1"
+  rsync -a build/ "$host:/srv"
+}
+
+function _cleanup {
+  rm -rf "$LOG_DIR/tmp"
+}
+
+# Entry point
+
+main() {
+  deploy "$@"
+}
+
+main "$@"
+`
+
+func TestProcessScript(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), deploySource, "deploy.sh")
+	require.Len(t, file.Children, 11)
+
+	header := file.Children[0].(*ir.DistilledComment)
+	assert.Equal(t, "#!/usr/bin/env bash\n# Deploys the web tier.", header.Text)
+	assert.Equal(t, "doc", header.Format)
+
+	common := file.Children[1].(*ir.DistilledImport)
+	assert.Equal(t, "source", common.ImportType)
+	assert.Equal(t, "./lib/common.sh", common.Module)
+	env := file.Children[2].(*ir.DistilledImport)
+	assert.Equal(t, ".", env.ImportType)
+	assert.Equal(t, `"$HOME/.env"`, env.Module)
+
+	doc := file.Children[6].(*ir.DistilledComment)
+	assert.Equal(t, "doc", doc.Format)
+	deploy := file.Children[7].(*ir.DistilledFunction)
+	assert.Equal(t, "deploy", deploy.Name)
+	assert.Equal(t, ir.VisibilityPublic, deploy.Visibility)
+	assert.True(t, strings.HasPrefix(deploy.Implementation, "{\n  local host"))
+
+	cleanup := file.Children[8].(*ir.DistilledFunction)
+	assert.Equal(t, "_cleanup", cleanup.Name)
+	assert.Equal(t, ir.VisibilityPrivate, cleanup.Visibility)
+
+	// A comment separated by a blank line is not documentation
+	assert.Equal(t, "line", file.Children[9].(*ir.DistilledComment).Format)
+}
+
+func TestProcessVariables(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+NAME=one
+if [[ -n "$CI" ]]; then
+  NAME=two
+fi
+export NAME
+readonly VERSION=1.2.3
+declare -rx API_URL=https://api
+declare -A PORTS=([web]=80)
+PATH+=":$HOME/bin"
+export -f helper
+`, "deploy.sh")
+	require.Len(t, file.Children, 4)
+
+	// The first assignment gives the value and a later export the visibility
+	name := file.Children[0].(*ir.DistilledField)
+	assert.Equal(t, "NAME", name.Name)
+	assert.Equal(t, "one", name.DefaultValue)
+	assert.Equal(t, ir.VisibilityPublic, name.Visibility)
+	assert.Equal(t, "export", name.Extensions.Shell.Declaration)
+
+	version := file.Children[1].(*ir.DistilledField)
+	assert.Equal(t, ir.VisibilityPrivate, version.Visibility)
+	assert.Equal(t, []ir.Modifier{ir.ModifierFinal}, version.Modifiers)
+
+	api := file.Children[2].(*ir.DistilledField)
+	assert.Equal(t, ir.VisibilityPublic, api.Visibility)
+	assert.Equal(t, []ir.Modifier{ir.ModifierFinal}, api.Modifiers)
+	assert.Equal(t, "declare -rx", api.Extensions.Shell.Declaration)
+
+	ports := file.Children[3].(*ir.DistilledField)
+	assert.Equal(t, "([web]=80)", ports.DefaultValue)
+	assert.Equal(t, ir.VisibilityPrivate, ports.Visibility)
+}
+
+func TestProcessConditionalFunctions(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), `
+if command -v gdate >/dev/null; then
+  now() { gdate +%s%N; }
+else
+  now() {
+    date +%s%N
+  }
+fi
+`, "deploy.sh")
+	require.Len(t, file.Children, 1)
+	now := file.Children[0].(*ir.DistilledFunction)
+	assert.Equal(t, "now", now.Name)
+	assert.Equal(t, "{ gdate +%s%N; }", now.Implementation)
+}
+
+func TestFormatText(t *testing.T) {
+	format := func(opts processor.ProcessOptions) string {
+		return langtest.FormatText(t, NewProcessor(), deploySource, "deploy.sh", opts)
+	}
+
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeImplementation = false
+	opts.IncludePrivate = false
+	opts.IncludeComments = false
+	assert.Equal(t, `<file path="deploy.sh">
+#!/usr/bin/env bash
+# Deploys the web tier.
+source ./lib/common.sh
+. "$HOME/.env"
+export DEPLOY_ENV="${1:-prod}"
+# Uploads the build.
+# Arguments: target host
+deploy()
+main()
+</file>
+`, format(opts))
+
+	opts.IncludeImplementation = true
+	opts.IncludePrivate = true
+	output := format(opts)
+	assert.Contains(t, output, "readonly VERSION=1.2.3\nLOG_DIR=/var/log\n")
+	assert.Contains(t, output, "_cleanup() {\n  rm -rf \"$LOG_DIR/tmp\"\n}\n")
+}
@@ -0,0 +1,348 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	sitter "github.com/smacker/go-tree-sitter"
+	tree_sitter_bash "github.com/smacker/go-tree-sitter/bash"
+)
+
+// TreeSitterProcessor uses tree-sitter for shell script parsing
+type TreeSitterProcessor struct {
+	parser *sitter.Parser
+}
+
+// NewTreeSitterProcessor creates a new tree-sitter based processor
+func NewTreeSitterProcessor() *TreeSitterProcessor {
+	parser := sitter.NewParser()
+	parser.SetLanguage(tree_sitter_bash.GetLanguage())
+
+	return &TreeSitterProcessor{
+		parser: parser,
+	}
+}
+
+// scope collects the declarations of a file. Variables are assigned in
+// many places of a script and functions may be defined in each branch of
+// a conditional, so each name becomes a single field or function.
+type scope struct {
+	source    []byte
+	fields    map[string]*ir.DistilledField
+	functions map[string]bool
+	// Whether a declaration has been processed, which ends the header
+	// comment of the file
+	started bool
+}
+
+// ProcessSource processes shell source code using tree-sitter
+func (p *TreeSitterProcessor) ProcessSource(ctx context.Context, source []byte, filename string) (*ir.DistilledFile, error) {
+	// Parse the source code
+	tree, err := p.parser.ParseCtx(ctx, nil, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shell script: %w", err)
+	}
+	defer tree.Close()
+
+	// Create distilled file
+	file := &ir.DistilledFile{
+		BaseNode: ir.BaseNode{
+			Location: ir.Location{
+				StartLine: 1,
+				EndLine:   int(tree.RootNode().EndPoint().Row) + 1,
+			},
+		},
+		Path:     filename,
+		Language: "shell",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+
+	s := &scope{source: source, fields: map[string]*ir.DistilledField{}, functions: map[string]bool{}}
+	file.Children = append(file.Children, p.processStatements(tree.RootNode(), s)...)
+
+	return file, nil
+}
+
+// processStatements processes the functions, variables, sourced files and
+// comments among the children of a node. Conditionals and lists are
+// searched too, e.g. [ -f .env ] && source .env.
+func (p *TreeSitterProcessor) processStatements(node *sitter.Node, s *scope) []ir.DistilledNode {
+	var result []ir.DistilledNode
+	var comments []*sitter.Node
+
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		if child.Type() == "comment" {
+			// A blank line ends a comment block
+			if len(comments) > 0 && child.StartPoint().Row > comments[len(comments)-1].EndPoint().Row+1 {
+				result = append(result, p.processComments(comments, nil, s))
+				comments = nil
+			}
+			comments = append(comments, child)
+			continue
+		}
+
+		var nodes []ir.DistilledNode
+		switch child.Type() {
+		case "function_definition":
+			if fn := p.processFunction(child, s); fn != nil {
+				nodes = append(nodes, fn)
+			}
+		case "declaration_command":
+			nodes = p.processDeclaration(child, s)
+		case "variable_assignment":
+			nodes = p.processAssignment(child, "", s)
+		case "variable_assignments":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if assignment := child.NamedChild(j); assignment.Type() == "variable_assignment" {
+					nodes = append(nodes, p.processAssignment(assignment, "", s)...)
+				}
+			}
+		case "command":
+			if imp := p.processSource(child, s); imp != nil {
+				nodes = append(nodes, imp)
+			}
+		case "list", "if_statement", "elif_clause", "else_clause", "ERROR":
+			nodes = p.processStatements(child, s)
+		}
+
+		if len(comments) > 0 {
+			result = append(result, p.processComments(comments, child, s))
+			comments = nil
+		}
+		if child.IsNamed() {
+			s.started = true
+		}
+		result = append(result, nodes...)
+	}
+
+	if len(comments) > 0 {
+		result = append(result, p.processComments(comments, nil, s))
+	}
+	return result
+}
+
+// processComments creates a comment from a block of line comments. The
+// block directly above a function or variable, and the header of the file,
+// are documentation.
+func (p *TreeSitterProcessor) processComments(comments []*sitter.Node, next *sitter.Node, s *scope) *ir.DistilledComment {
+	var lines []string
+	for _, c := range comments {
+		lines = append(lines, strings.TrimRight(p.nodeText(c, s.source), " \t\r\n"))
+	}
+
+	format := "line"
+	last := comments[len(comments)-1]
+	switch {
+	case !s.started:
+		format = "doc"
+	case next != nil && next.StartPoint().Row == last.EndPoint().Row+1:
+		switch next.Type() {
+		case "function_definition", "declaration_command", "variable_assignment":
+			format = "doc"
+		}
+	}
+
+	location := p.nodeLocation(comments[0])
+	location.EndLine = int(last.EndPoint().Row) + 1
+	location.EndColumn = int(last.EndPoint().Column) + 1
+	return &ir.DistilledComment{
+		BaseNode: ir.BaseNode{Location: location},
+		Text:     strings.Join(lines, "\n"),
+		Format:   format,
+	}
+}
+
+// processFunction processes a function definition, or returns nil for a
+// function that has been defined before. Functions named with a leading
+// underscore are private by convention.
+func (p *TreeSitterProcessor) processFunction(node *sitter.Node, s *scope) *ir.DistilledFunction {
+	name := ""
+	if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+		name = p.nodeText(nameNode, s.source)
+	}
+	if s.functions[name] {
+		return nil
+	}
+	s.functions[name] = true
+
+	fn := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		Name:       name,
+		Visibility: visibility(name),
+		Parameters: []ir.Parameter{},
+	}
+	if body := node.ChildByFieldName("body"); body != nil {
+		fn.Implementation = p.nodeText(body, s.source)
+	}
+	return fn
+}
+
+// processDeclaration processes export, readonly, declare and typeset
+// commands. Local variables of functions are skipped.
+func (p *TreeSitterProcessor) processDeclaration(node *sitter.Node, s *scope) []ir.DistilledNode {
+	keyword := ""
+	var options []string
+	var result []ir.DistilledNode
+
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch {
+		case i == 0:
+			keyword = child.Type()
+			if keyword == "local" {
+				return nil
+			}
+		case child.Type() == "word" && strings.HasPrefix(p.nodeText(child, s.source), "-"):
+			option := p.nodeText(child, s.source)
+			// export -f and declare -f name functions, not variables
+			if strings.Contains(option, "f") {
+				return nil
+			}
+			options = append(options, option)
+		case child.Type() == "variable_assignment":
+			result = append(result, p.processAssignment(child, declaration(keyword, options), s)...)
+		case child.Type() == "variable_name" || child.Type() == "word":
+			result = append(result, p.variable(p.nodeText(child, s.source), child, declaration(keyword, options), "", s)...)
+		}
+	}
+	return result
+}
+
+// processAssignment processes an assignment, declared by the command
+// declaration or plain when it is empty
+func (p *TreeSitterProcessor) processAssignment(node *sitter.Node, declaration string, s *scope) []ir.DistilledNode {
+	nameNode := node.ChildByFieldName("name")
+	if nameNode == nil {
+		return nil
+	}
+	name := p.nodeText(nameNode, s.source)
+	// Elements of arrays, e.g. MAP[key]=value, are not declarations
+	if nameNode.Type() == "subscript" {
+		return nil
+	}
+
+	// Appending, e.g. PATH+=:bin, changes a variable declared elsewhere
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.Child(i).Type() == "+=" {
+			return nil
+		}
+	}
+
+	value := ""
+	if valueNode := node.ChildByFieldName("value"); valueNode != nil {
+		value = p.nodeText(valueNode, s.source)
+	}
+	return p.variable(name, node, declaration, value, s)
+}
+
+// variable creates the field of a variable, or updates the field of a
+// variable that has been assigned before. Exported variables are public;
+// other variables are internal to the script and private.
+func (p *TreeSitterProcessor) variable(name string, node *sitter.Node, declaration, value string, s *scope) []ir.DistilledNode {
+	exported := strings.HasPrefix(declaration, "export") || optionsContain(declaration, "x")
+	readonly := strings.HasPrefix(declaration, "readonly") || optionsContain(declaration, "r")
+
+	field, seen := s.fields[name]
+	if !seen {
+		field = &ir.DistilledField{
+			BaseNode: ir.BaseNode{
+				Location: p.nodeLocation(node),
+			},
+			Name:       name,
+			Visibility: ir.VisibilityPrivate,
+		}
+		s.fields[name] = field
+	}
+
+	// A later export or readonly declaration changes the variable
+	if declaration != "" && (!seen || exported || readonly) {
+		field.Extensions = &ir.NodeExtensions{Shell: &ir.ShellExtensions{Declaration: declaration}}
+	}
+	if exported {
+		field.Visibility = ir.VisibilityPublic
+	}
+	if readonly && len(field.Modifiers) == 0 {
+		field.Modifiers = []ir.Modifier{ir.ModifierFinal}
+	}
+	if field.DefaultValue == "" && !strings.Contains(value, "\n") {
+		field.DefaultValue = value
+	}
+
+	if seen {
+		return nil
+	}
+	return []ir.DistilledNode{field}
+}
+
+// processSource processes a source or . command as an import of the file
+// it reads
+func (p *TreeSitterProcessor) processSource(node *sitter.Node, s *scope) *ir.DistilledImport {
+	nameNode := node.ChildByFieldName("name")
+	if nameNode == nil {
+		return nil
+	}
+	command := p.nodeText(nameNode, s.source)
+	if command != "source" && command != "." {
+		return nil
+	}
+
+	argument := node.ChildByFieldName("argument")
+	if argument == nil {
+		return nil
+	}
+	// The word is kept as written, with its quotes, so that the output
+	// reads like the original command
+	module := p.nodeText(argument, s.source)
+
+	return &ir.DistilledImport{
+		BaseNode: ir.BaseNode{
+			Location: p.nodeLocation(node),
+		},
+		ImportType: command,
+		Module:     module,
+	}
+}
+
+// declaration returns a declaration command with its options, e.g.
+// declare -rx
+func declaration(keyword string, options []string) string {
+	return strings.Join(append([]string{keyword}, options...), " ")
+}
+
+// optionsContain reports whether the options of a declaration command
+// contain a letter, e.g. x in declare -rx
+func optionsContain(declaration, letter string) bool {
+	for _, option := range strings.Fields(declaration) {
+		if strings.HasPrefix(option, "-") && strings.Contains(option, letter) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibility returns the visibility of a function
+func visibility(name string) ir.Visibility {
+	if strings.HasPrefix(name, "_") {
+		return ir.VisibilityPrivate
+	}
+	return ir.VisibilityPublic
+}
+
+func (p *TreeSitterProcessor) nodeText(node *sitter.Node, source []byte) string {
+	return string(source[node.StartByte():node.EndByte()])
+}
+
+func (p *TreeSitterProcessor) nodeLocation(node *sitter.Node) ir.Location {
+	return ir.Location{
+		StartLine:   int(node.StartPoint().Row) + 1,
+		EndLine:     int(node.EndPoint().Row) + 1,
+		StartColumn: int(node.StartPoint().Column) + 1,
+		EndColumn:   int(node.EndPoint().Column) + 1,
+	}
+}
@@ -0,0 +1,262 @@
+package sql
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+	// tokenEnd ends a statement: a ; or a custom delimiter
+	tokenEnd
+)
+
+// token is a lexical token with the comments around it
+type token struct {
+	kind  tokenKind
+	text  string
+	line  int
+	start int
+	end   int
+
+	// leading are the comments between the previous token and this one
+	leading []comment
+	// trailing is a comment on the same line after this token
+	trailing *comment
+}
+
+// is reports whether the token is the unquoted keyword kw, case-insensitively
+func (t token) is(kw string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+// comment is a -- or /* */ comment with its markers removed
+type comment struct {
+	text    string
+	block   bool
+	line    int
+	endLine int
+}
+
+// delimiterPattern matches the DELIMITER command of the MySQL client
+var delimiterPattern = regexp.MustCompile(`(?i)^DELIMITER[ \t]+(\S+)[ \t]*`)
+
+// batchPattern matches the GO batch separator of SQL Server scripts
+var batchPattern = regexp.MustCompile(`(?i)^GO[ \t]*(\r?\n|$)`)
+
+// tokenize splits SQL source into tokens. Comments are attached to the
+// surrounding tokens instead of being returned as tokens.
+func tokenize(src []byte) []token {
+	var tokens []token
+	var pending []comment
+	delimiter := ";"
+	line := 1
+	lineStart := true
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+
+		// The MySQL client command DELIMITER changes the statement delimiter
+		if lineStart {
+			if m := delimiterPattern.FindSubmatch(src[i:]); m != nil {
+				delimiter = string(m[1])
+				tokens = append(tokens, token{kind: tokenEnd, text: delimiter, line: line, start: i, end: i + len(m[0]), leading: pending})
+				pending = nil
+				i += len(m[0])
+				continue
+			}
+			if m := batchPattern.Find(src[i:]); m != nil {
+				tokens = append(tokens, token{kind: tokenEnd, text: "GO", line: line, start: i, end: i + 2, leading: pending})
+				pending = nil
+				i += len(m)
+				if bytes.HasSuffix(m, []byte("\n")) {
+					line++
+				}
+				continue
+			}
+		}
+		if c != ' ' && c != '\t' {
+			lineStart = false
+		}
+
+		switch {
+		case c == '\n':
+			line++
+			i++
+			lineStart = true
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case (c == '-' && i+1 < len(src) && src[i+1] == '-') || (c == '/' && i+1 < len(src) && src[i+1] == '*'):
+			cm := comment{line: line}
+			if c == '-' {
+				end := i
+				for end < len(src) && src[end] != '\n' {
+					end++
+				}
+				cm.text = strings.TrimSpace(strings.TrimLeft(string(src[i:end]), "-"))
+				i = end
+			} else {
+				cm.block = true
+				end := i + 2
+				for end < len(src) && !(src[end] == '*' && end+1 < len(src) && src[end+1] == '/') {
+					end++
+				}
+				body := string(src[i+2 : min(end, len(src))])
+				line += strings.Count(body, "\n")
+				cm.text = blockCommentText(body)
+				i = min(end+2, len(src))
+			}
+			cm.endLine = line
+
+			// A comment on the line of the previous token trails it
+			if n := len(tokens); n > 0 && len(pending) == 0 && tokens[n-1].line == cm.line && tokens[n-1].trailing == nil && tokens[n-1].kind != tokenEnd {
+				tokens[n-1].trailing = &cm
+			} else if n > 0 && len(pending) == 0 && tokens[n-1].line == cm.line && tokens[n-1].kind == tokenEnd && n > 1 && tokens[n-2].trailing == nil {
+				// A comment after the delimiter documents the statement
+				tokens[n-2].trailing = &cm
+			} else {
+				pending = append(pending, cm)
+			}
+		case delimiter != ";" && bytes.HasPrefix(src[i:], []byte(delimiter)):
+			tokens = append(tokens, token{kind: tokenEnd, text: delimiter, line: line, start: i, end: i + len(delimiter), leading: pending})
+			pending = nil
+			i += len(delimiter)
+		default:
+			tok := token{line: line, start: i, leading: pending}
+			pending = nil
+			switch {
+			case c == ';' && delimiter == ";":
+				tok.kind = tokenEnd
+				i++
+			case isIdentStart(c):
+				tok.kind = tokenIdent
+				// String prefixes such as E'...' and N'...'
+				if (c == 'E' || c == 'e' || c == 'N' || c == 'n' || c == 'X' || c == 'x' || c == 'B' || c == 'b') && i+1 < len(src) && src[i+1] == '\'' {
+					tok.kind = tokenString
+					i = scanQuoted(src, i+1, '\'', &line)
+					break
+				}
+				i++
+				for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '$') &&
+					!(delimiter != ";" && bytes.HasPrefix(src[i:], []byte(delimiter))) {
+					i++
+				}
+			case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+				tok.kind = tokenNumber
+				i++
+				for i < len(src) && (isDigit(src[i]) || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+					((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+					i++
+				}
+			case c == '\'':
+				tok.kind = tokenString
+				i = scanQuoted(src, i, '\'', &line)
+			case c == '"' || c == '`':
+				tok.kind = tokenQuotedIdent
+				i = scanQuoted(src, i, c, &line)
+			case c == '[' && isBracketIdent(src[i:]):
+				tok.kind = tokenQuotedIdent
+				i = scanQuoted(src, i, ']', &line)
+			case c == '$':
+				if tag := dollarTag(src[i:]); tag != "" {
+					tok.kind = tokenString
+					end := strings.Index(string(src[i+len(tag):]), tag)
+					if end < 0 {
+						end = len(src) - i - len(tag)
+					} else {
+						end += len(tag)
+					}
+					line += strings.Count(string(src[i:i+len(tag)+end]), "\n")
+					i += len(tag) + end
+					break
+				}
+				tok.kind = tokenSymbol
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			case c == ':' && i+1 < len(src) && src[i+1] == ':':
+				tok.kind = tokenSymbol
+				i += 2
+			default:
+				tok.kind = tokenSymbol
+				i++
+			}
+			tok.end = min(i, len(src))
+			tok.text = string(src[tok.start:tok.end])
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, line: line, start: len(src), end: len(src), leading: pending})
+}
+
+// scanQuoted returns the offset after the quoted text starting at i. A
+// doubled quote escapes the quote, as does a backslash in MySQL strings.
+func scanQuoted(src []byte, i int, quote byte, line *int) int {
+	i++
+	for i < len(src) {
+		switch {
+		case src[i] == '\\' && quote == '\'':
+			i++
+		case src[i] == quote:
+			if i+1 < len(src) && src[i+1] == quote && quote != ']' {
+				i++
+			} else {
+				return i + 1
+			}
+		case src[i] == '\n':
+			*line++
+		}
+		i++
+	}
+	return len(src)
+}
+
+// dollarTag returns the opening tag of a PostgreSQL dollar-quoted string,
+// such as $$ or $body$, or "" if src does not start with one
+func dollarTag(src []byte) string {
+	for i := 1; i < len(src); i++ {
+		if src[i] == '$' {
+			return string(src[:i+1])
+		}
+		if !isIdentStart(src[i]) && !(i > 1 && isDigit(src[i])) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// isBracketIdent reports whether src starts with a SQL Server [identifier]
+func isBracketIdent(src []byte) bool {
+	end := strings.IndexByte(string(src), ']')
+	return end > 1 && !strings.ContainsAny(string(src[1:end]), "\n[,'")
+}
+
+// blockCommentText removes the leading * of each line of a block comment
+func blockCommentText(body string) string {
+	lines := strings.Split(body, "\n")
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "*")
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
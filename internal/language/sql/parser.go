@@ -0,0 +1,1511 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// objectKeywords are the kinds of CREATE statements that are distilled
+var objectKeywords = map[string]bool{
+	"TABLE": true, "INDEX": true, "VIEW": true, "FUNCTION": true,
+	"PROCEDURE": true, "TRIGGER": true, "TYPE": true,
+}
+
+// columnClauses start a column constraint or option
+var columnClauses = map[string]bool{
+	"CONSTRAINT": true, "NOT": true, "NULL": true, "DEFAULT": true, "PRIMARY": true,
+	"UNIQUE": true, "REFERENCES": true, "CHECK": true, "COLLATE": true, "GENERATED": true,
+	"AUTO_INCREMENT": true, "AUTOINCREMENT": true, "IDENTITY": true, "COMMENT": true, "ON": true,
+}
+
+// routineClauses start a characteristic of a function or procedure
+var routineClauses = map[string]bool{
+	"LANGUAGE": true, "IMMUTABLE": true, "STABLE": true, "VOLATILE": true, "STRICT": true,
+	"CALLED": true, "SECURITY": true, "PARALLEL": true, "COST": true, "ROWS": true,
+	"SET": true, "LEAKPROOF": true, "NOT": true, "DETERMINISTIC": true, "NO": true,
+	"READS": true, "MODIFIES": true, "CONTAINS": true, "COMMENT": true, "WINDOW": true,
+	"EXTERNAL": true, "SUPPORT": true, "TRANSFORM": true, "RETURNS": true,
+	"AS": true, "IS": true, "BEGIN": true, "RETURN": true,
+}
+
+// multiwordTypes start type names of several words, so an unnamed parameter
+// of such a type is not mistaken for a named one
+var multiwordTypes = map[string]bool{
+	"DOUBLE": true, "CHARACTER": true, "CHAR": true, "VARCHAR": true, "NATIONAL": true,
+	"NCHAR": true, "TIMESTAMP": true, "TIME": true, "BIT": true, "INTERVAL": true, "LONG": true,
+}
+
+// selectEnd ends the select list of a query
+var selectEnd = map[string]bool{
+	"FROM": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "WHERE": true,
+	"GROUP": true, "ORDER": true, "LIMIT": true, "INTO": true, "WINDOW": true, "HAVING": true,
+}
+
+// parser distills SQL scripts. Each statement is parsed on its own, and
+// the objects it creates or changes are recorded in a schema. Statements
+// other than DDL are skipped.
+type parser struct {
+	src    []byte
+	lines  []int
+	file   *ir.DistilledFile
+	schema *schema
+
+	// toks are the tokens of the current statement
+	toks []token
+}
+
+// parseError is a syntax error at a token
+type parseError struct {
+	tok token
+	msg string
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.tok.line, e.msg)
+}
+
+// statement is the tokens of a statement and the delimiter that ends it
+type statement struct {
+	toks []token
+	end  token
+}
+
+// Parse parses a SQL script into a distilled file. CREATE statements become
+// declarations, and the ALTER, DROP and COMMENT statements after them are
+// applied to those declarations, so the file shows the schema as it is
+// after the script has run.
+func Parse(src []byte, filename string) *ir.DistilledFile {
+	file := &ir.DistilledFile{
+		Path:     filename,
+		Language: "sql",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+	p := newParser(src, file, &schema{})
+	toks := tokenize(src)
+	p.parse(toks)
+
+	file.Children = append(file.Children, p.schema.nodes()...)
+	if len(toks) > 1 {
+		file.Location = p.location(toks[0], toks[len(toks)-2])
+	}
+	return file
+}
+
+func newParser(src []byte, file *ir.DistilledFile, s *schema) *parser {
+	p := &parser{src: src, file: file, schema: s, lines: []int{0}}
+	for i, c := range src {
+		if c == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+	return p
+}
+
+func (p *parser) parse(toks []token) {
+	for _, stmt := range splitStatements(toks) {
+		p.statement(stmt)
+	}
+}
+
+// splitStatements splits tokens at statement delimiters. Delimiters inside
+// the BEGIN ... END body of a routine or trigger do not end the statement.
+func splitStatements(toks []token) []statement {
+	var stmts []statement
+	var cur []token
+	depth := 0
+	for i, tok := range toks {
+		if tok.kind == tokenEOF || (tok.kind == tokenEnd && depth == 0) {
+			stmts = append(stmts, statement{toks: cur, end: tok})
+			cur = nil
+			continue
+		}
+
+		switch {
+		case tok.is("BEGIN") && (depth > 0 || isRoutine(cur)):
+			depth++
+		case tok.is("CASE") && depth > 0 && !cur[len(cur)-1].is("END"):
+			depth++
+		case tok.is("END") && depth > 0:
+			next := toks[i+1]
+			if !next.is("IF") && !next.is("LOOP") && !next.is("WHILE") && !next.is("REPEAT") && !next.is("FOR") {
+				depth--
+			}
+		}
+		cur = append(cur, tok)
+	}
+	return stmts
+}
+
+// isRoutine reports whether a statement creates a function, procedure or
+// trigger, whose body may contain delimiters
+func isRoutine(toks []token) bool {
+	if len(toks) == 0 || !toks[0].is("CREATE") {
+		return false
+	}
+	for _, tok := range toks[1:min(len(toks), 12)] {
+		if tok.is("FUNCTION") || tok.is("PROCEDURE") || tok.is("TRIGGER") || tok.is("EVENT") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) statement(stmt statement) {
+	if len(stmt.toks) == 0 {
+		p.schema.add(p.commentNodes(stmt.end.leading)...)
+		return
+	}
+
+	// Comments directly above a statement and at the end of its first and
+	// last lines document it
+	first, last := stmt.toks[0], stmt.toks[len(stmt.toks)-1]
+	split := docStart(first)
+	p.schema.add(p.commentNodes(first.leading[:split])...)
+	docs := append([]comment{}, first.leading[split:]...)
+	for _, tok := range stmt.toks {
+		if tok.trailing != nil && (tok.line == first.line || tok.start == last.start) {
+			docs = append(docs, *tok.trailing)
+		}
+	}
+
+	p.toks = stmt.toks
+	c := &cursor{toks: stmt.toks}
+	var err error
+	switch {
+	case first.is("CREATE"):
+		err = p.create(c, docs)
+	case first.is("ALTER"):
+		err = p.alter(c, docs)
+	case first.is("DROP"):
+		err = p.drop(c, docs)
+	case first.is("RENAME") && c.peekAt(1).is("TABLE"):
+		err = p.renameTables(c, docs)
+	case first.is("COMMENT") && c.peekAt(1).is("ON"):
+		err = p.commentOn(c, docs)
+	}
+	if err != nil {
+		p.addError(err)
+	}
+	p.schema.add(p.commentNodes(stmt.end.leading)...)
+}
+
+// create distills CREATE statements of the objects in objectKeywords
+func (p *parser) create(c *cursor, docs []comment) error {
+	start := c.next()
+	unique, materialized := false, false
+	for i := 0; i < 12 && !c.done() && !isSymbol(c.peek(), "("); i++ {
+		tok := c.next()
+		switch {
+		case tok.is("UNIQUE"):
+			unique = true
+		case tok.is("MATERIALIZED"):
+			materialized = true
+		case tok.kind == tokenIdent && objectKeywords[strings.ToUpper(tok.text)]:
+			ifNotExists := c.accept("IF", "NOT", "EXISTS")
+			switch strings.ToUpper(tok.text) {
+			case "TABLE":
+				return p.createTable(c, start, docs, ifNotExists)
+			case "INDEX":
+				return p.createIndex(c, start, docs, unique)
+			case "VIEW":
+				return p.createView(c, start, docs, materialized)
+			case "FUNCTION":
+				return p.createRoutine(c, start, docs, "function")
+			case "PROCEDURE":
+				return p.createRoutine(c, start, docs, "procedure")
+			case "TRIGGER":
+				return p.createTrigger(c, start, docs)
+			case "TYPE":
+				return p.createType(c, start, docs)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) createTable(c *cursor, start token, docs []comment, ifNotExists bool) error {
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+	if ifNotExists && p.schema.find("table", name, "") != nil {
+		return nil
+	}
+
+	ext := &ir.SQLExtensions{Kind: "table"}
+	table := &ir.DistilledStruct{
+		BaseNode:   ir.BaseNode{Location: p.location(start, p.last()), Extensions: &ir.NodeExtensions{SQL: ext}},
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+	}
+	if group, ok := c.group(); ok {
+		for _, it := range splitItems(group) {
+			if isTableConstraint(it.toks) {
+				ext.Constraints = append(ext.Constraints, joinTokens(it.toks))
+				continue
+			}
+			table.Children = append(table.Children, p.column(it)...)
+		}
+	}
+
+	// Table options are skipped, except for a MySQL table comment
+	for !c.done() {
+		if c.accept("COMMENT") {
+			c.acceptSymbol("=")
+			if tok := c.peek(); tok.kind == tokenString {
+				docs = append(docs, comment{text: stringValue(tok.text), line: tok.line, endLine: tok.line})
+			}
+			continue
+		}
+		c.next()
+	}
+
+	p.schema.define(&entry{kind: "table", name: name, docs: p.docNodes(docs), node: table})
+	return nil
+}
+
+// column distills a column definition with its doc comments
+func (p *parser) column(it item) []ir.DistilledNode {
+	toks := it.toks
+	docs := it.docs
+	field := &ir.DistilledField{
+		BaseNode:   ir.BaseNode{Location: p.location(toks[0], toks[len(toks)-1])},
+		Name:       identName(toks[0]),
+		Visibility: ir.VisibilityPublic,
+	}
+
+	typ, clauses := columnParts(toks[1:])
+	if len(typ) > 0 {
+		field.Type = &ir.TypeRef{Name: joinTokens(typ)}
+	}
+	constraint := ""
+	for _, clause := range clauses {
+		switch {
+		case clause[0].is("DEFAULT"):
+			field.DefaultValue = joinTokens(clause[1:])
+		case clause[0].is("COMMENT"):
+			if len(clause) > 1 && clause[1].kind == tokenString {
+				docs = append(docs, comment{text: stringValue(clause[1].text), line: clause[1].line, endLine: clause[1].line})
+			}
+		case clause[0].is("CONSTRAINT") && len(clause) <= 2:
+			// A constraint name belongs to the constraint after it
+			constraint = joinTokens(clause) + " "
+		default:
+			field.Decorators = append(field.Decorators, constraint+joinTokens(clause))
+			constraint = ""
+		}
+	}
+
+	return append(p.docNodes(docs), field)
+}
+
+// columnParts splits the tokens after a column name into its type and its
+// constraints and options
+func columnParts(toks []token) (typ []token, clauses [][]token) {
+	depth := 0
+	for i, tok := range toks {
+		if depth == 0 && startsColumnClause(toks, i, clauses) {
+			clauses = append(clauses, nil)
+		}
+		if n := len(clauses); n > 0 {
+			clauses[n-1] = append(clauses[n-1], tok)
+		} else {
+			typ = append(typ, tok)
+		}
+		depth += nesting(tok)
+	}
+	return typ, clauses
+}
+
+func startsColumnClause(toks []token, i int, clauses [][]token) bool {
+	tok := toks[i]
+	if tok.kind != tokenIdent || !columnClauses[strings.ToUpper(tok.text)] {
+		return false
+	}
+	var prev token
+	if i > 0 {
+		prev = toks[i-1]
+	}
+	switch strings.ToUpper(tok.text) {
+	case "NULL":
+		return !prev.is("NOT") && !prev.is("DEFAULT") && !prev.is("SET")
+	case "DEFAULT":
+		return !prev.is("BY") && !prev.is("SET")
+	case "IDENTITY":
+		return !prev.is("AS")
+	case "ON":
+		// MySQL ON UPDATE, but not ON DELETE or ON UPDATE of REFERENCES
+		return i+1 < len(toks) && toks[i+1].is("UPDATE") && (len(clauses) == 0 || !clauses[len(clauses)-1][0].is("REFERENCES"))
+	}
+	return true
+}
+
+// isTableConstraint reports whether an item of a table definition is a
+// table constraint or index rather than a column
+func isTableConstraint(toks []token) bool {
+	first := toks[0]
+	if first.kind != tokenIdent || len(toks) < 2 {
+		return false
+	}
+	switch strings.ToUpper(first.text) {
+	case "CONSTRAINT", "FOREIGN", "EXCLUDE", "FULLTEXT", "SPATIAL":
+		return true
+	case "PRIMARY":
+		return toks[1].is("KEY")
+	case "CHECK", "LIKE":
+		return isSymbol(toks[1], "(") || first.is("LIKE")
+	case "UNIQUE", "KEY", "INDEX":
+		return isSymbol(toks[1], "(") || toks[1].is("KEY") || toks[1].is("INDEX") ||
+			(len(toks) > 2 && isSymbol(toks[2], "("))
+	}
+	return false
+}
+
+func (p *parser) createIndex(c *cursor, start token, docs []comment, unique bool) error {
+	c.accept("CONCURRENTLY")
+	c.accept("IF", "NOT", "EXISTS")
+	name := ""
+	if !c.peek().is("ON") && !c.peek().is("USING") {
+		var err error
+		if name, err = p.name(c); err != nil {
+			return err
+		}
+	}
+	definition := c.rest()
+	dc := &cursor{toks: definition}
+	dc.until(func(c *cursor) bool { return c.peek().is("ON") })
+	if !dc.accept("ON") {
+		return p.errorf(dc.peek(), "expected ON in index definition")
+	}
+	dc.accept("ONLY")
+	table, err := p.name(dc)
+	if err != nil {
+		return err
+	}
+
+	index := &ir.DistilledField{
+		BaseNode: ir.BaseNode{
+			Location: p.location(start, p.last()),
+			Extensions: &ir.NodeExtensions{SQL: &ir.SQLExtensions{
+				Kind:       "index",
+				Table:      table,
+				Unique:     unique,
+				Definition: joinTokens(definition),
+			}},
+		},
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+	}
+	p.schema.attach(table, &entry{kind: "index", name: name, docs: p.docNodes(docs), node: index})
+	return nil
+}
+
+func (p *parser) createView(c *cursor, start token, docs []comment, materialized bool) error {
+	c.accept("IF", "NOT", "EXISTS")
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+
+	kind := "view"
+	if materialized {
+		kind = "materialized_view"
+	}
+	view := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location:   p.location(start, p.last()),
+			Extensions: &ir.NodeExtensions{SQL: &ir.SQLExtensions{Kind: kind}},
+		},
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+		Parameters: []ir.Parameter{},
+	}
+	if group, ok := c.group(); ok {
+		for _, it := range splitItems(group) {
+			view.Parameters = append(view.Parameters, ir.Parameter{Name: identName(it.toks[0])})
+		}
+	}
+
+	c.until(func(c *cursor) bool { return c.peek().is("AS") })
+	if as := c.peek(); c.accept("AS") {
+		query := withoutViewOptions(c.rest())
+		if len(query) > 0 {
+			view.Implementation = p.raw([]token{as, query[len(query)-1]})
+		}
+		if len(view.Parameters) == 0 {
+			for _, column := range selectColumns(query) {
+				view.Parameters = append(view.Parameters, ir.Parameter{Name: column})
+			}
+		}
+	}
+
+	p.schema.define(&entry{kind: "view", name: name, docs: p.docNodes(docs), node: view})
+	return nil
+}
+
+// withoutViewOptions removes the WITH CHECK OPTION and WITH [NO] DATA
+// clauses after the query of a view
+func withoutViewOptions(query []token) []token {
+	depth := 0
+	for i, tok := range query {
+		if depth == 0 && tok.is("WITH") && i+1 < len(query) {
+			next := query[i+1]
+			if next.is("CHECK") || next.is("CASCADED") || next.is("LOCAL") || next.is("DATA") || next.is("NO") {
+				return query[:i]
+			}
+		}
+		depth += nesting(tok)
+	}
+	return query
+}
+
+// selectColumns returns the output column names of a query, as far as they
+// can be told from its select list
+func selectColumns(query []token) []string {
+	depth, i := 0, 0
+	for ; i < len(query); i++ {
+		if depth == 0 && query[i].is("SELECT") {
+			break
+		}
+		depth += nesting(query[i])
+	}
+	if i == len(query) {
+		return nil
+	}
+
+	c := &cursor{toks: query[i+1:]}
+	c.accept("ALL")
+	if c.accept("DISTINCT") && c.accept("ON") {
+		c.group()
+	}
+	if c.accept("TOP") {
+		c.next()
+	}
+	list := c.until(func(c *cursor) bool {
+		tok := c.peek()
+		return tok.kind == tokenIdent && selectEnd[strings.ToUpper(tok.text)]
+	})
+
+	var columns []string
+	for _, it := range splitItems(list) {
+		if name := selectName(it.toks); name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// selectName returns the output name of a select list item, or "" for an
+// expression without an alias
+func selectName(toks []token) string {
+	n := len(toks)
+	last := toks[n-1]
+	switch {
+	case isSymbol(last, "*"):
+		return joinTokens(toks)
+	case last.kind != tokenIdent && last.kind != tokenQuotedIdent:
+		return ""
+	case n == 1, n >= 2 && toks[n-2].is("AS"), n >= 3 && isSymbol(toks[n-2], "."):
+		return identName(last)
+	case last.is("END"):
+		return ""
+	}
+	// An implicit alias follows the expression without AS
+	if prev := toks[n-2]; prev.kind != tokenSymbol || isSymbol(prev, ")") {
+		return identName(last)
+	}
+	return ""
+}
+
+// createRoutine distills CREATE FUNCTION and CREATE PROCEDURE
+func (p *parser) createRoutine(c *cursor, start token, docs []comment, kind string) error {
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+
+	fn := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location:   p.location(start, p.last()),
+			Extensions: &ir.NodeExtensions{SQL: &ir.SQLExtensions{Kind: kind}},
+		},
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+		Parameters: []ir.Parameter{},
+	}
+
+	params, ok := c.group()
+	if !ok {
+		// SQL Server parameters are not parenthesized
+		params = c.until(func(c *cursor) bool {
+			tok := c.peek()
+			return tok.is("AS") || tok.is("WITH") || tok.is("BEGIN") || tok.is("RETURNS")
+		})
+	}
+	for _, it := range splitItems(params) {
+		fn.Parameters = append(fn.Parameters, parameter(it.toks))
+	}
+
+	for !c.done() {
+		tok := c.peek()
+		switch {
+		case tok.is("RETURNS") && fn.Returns == nil:
+			c.next()
+			fn.Returns = &ir.TypeRef{Name: joinTokens(c.until(routineBoundary))}
+		case (tok.is("AS") || tok.is("IS")) && c.peekAt(1).kind == tokenString:
+			// A quoted body, possibly followed by more characteristics
+			c.next()
+			end := c.next()
+			for isSymbol(c.peek(), ",") && c.peekAt(1).kind == tokenString {
+				c.next()
+				end = c.next()
+			}
+			fn.Implementation = p.raw([]token{tok, end})
+		case tok.is("AS") || tok.is("IS") || tok.is("BEGIN") || tok.is("RETURN"):
+			rest := c.rest()
+			fn.Implementation = p.raw([]token{rest[0], rest[len(rest)-1]})
+		case tok.is("COMMENT") && c.peekAt(1).kind == tokenString:
+			c.next()
+			text := c.next()
+			docs = append(docs, comment{text: stringValue(text.text), line: text.line, endLine: text.line})
+		default:
+			clause := append([]token{c.next()}, c.until(routineBoundary)...)
+			fn.Decorators = append(fn.Decorators, joinTokens(clause))
+		}
+	}
+
+	p.schema.define(&entry{kind: kind, name: name, args: signature(fn.Parameters), docs: p.docNodes(docs), node: fn})
+	return nil
+}
+
+// routineBoundary reports whether the next token starts a characteristic
+// or the body of a routine
+func routineBoundary(c *cursor) bool {
+	tok := c.peek()
+	if tok.kind != tokenIdent || !routineClauses[strings.ToUpper(tok.text)] {
+		return (tok.is("SQL") && c.peekAt(1).is("SECURITY")) ||
+			(tok.is("WITH") && !c.peekAt(1).is("TIME") && !c.peekAt(1).is("LOCAL"))
+	}
+	prev := c.peekAt(-1)
+	return !prev.is("NOT") && !prev.is("SQL") && !prev.is("EXTERNAL")
+}
+
+// parameter distills a routine parameter: [mode] [name] type [DEFAULT value]
+func parameter(toks []token) ir.Parameter {
+	var param ir.Parameter
+	c := &cursor{toks: toks}
+	if c.accept("VARIADIC") {
+		param.IsVariadic = true
+	} else if mode := parameterMode(c); mode != "" {
+		param.Decorators = []string{mode}
+	}
+
+	typ := c.until(func(c *cursor) bool { return c.peek().is("DEFAULT") || isSymbol(c.peek(), "=") })
+	if !c.done() {
+		c.next()
+		param.DefaultValue = joinTokens(c.rest())
+	}
+
+	switch {
+	case len(typ) >= 2 && isSymbol(typ[0], "@"):
+		// SQL Server @name
+		param.Name = "@" + typ[1].text
+		typ = typ[2:]
+	case len(typ) >= 2 && (typ[0].kind == tokenQuotedIdent || !multiwordTypes[strings.ToUpper(typ[0].text)]):
+		param.Name = identName(typ[0])
+		typ = typ[1:]
+	}
+	// Oracle puts the mode after the name
+	tc := &cursor{toks: typ}
+	if mode := parameterMode(tc); mode != "" && len(param.Decorators) == 0 && !tc.done() {
+		param.Decorators = []string{mode}
+		typ = tc.rest()
+	}
+	param.Type = ir.TypeRef{Name: joinTokens(typ)}
+	return param
+}
+
+func parameterMode(c *cursor) string {
+	switch tok := c.peek(); {
+	case tok.is("IN"):
+		c.next()
+		if c.accept("OUT") {
+			return "IN OUT"
+		}
+		return "IN"
+	case tok.is("OUT"), tok.is("INOUT"):
+		c.next()
+		return strings.ToUpper(tok.text)
+	}
+	return ""
+}
+
+// signature returns the input parameter types of a routine, which tell
+// overloaded functions apart
+func signature(params []ir.Parameter) string {
+	var types []string
+	for _, param := range params {
+		if len(param.Decorators) == 1 && param.Decorators[0] == "OUT" {
+			continue
+		}
+		types = append(types, strings.ToLower(param.Type.Name))
+	}
+	return strings.Join(types, ",")
+}
+
+func (p *parser) createTrigger(c *cursor, start token, docs []comment) error {
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+
+	definitionStart := c.pos
+	c.until(func(c *cursor) bool { return c.peek().is("ON") })
+	if !c.accept("ON") {
+		return p.errorf(c.peek(), "expected ON in trigger definition")
+	}
+	table, err := p.name(c)
+	if err != nil {
+		return err
+	}
+
+	// The body is BEGIN ... END, or the statement after FOR EACH ROW in MySQL
+	var body []token
+	for !c.done() {
+		tok := c.peek()
+		if tok.is("BEGIN") || tok.is("AS") {
+			body = c.rest()
+			break
+		}
+		if tok.is("EXECUTE") {
+			c.rest()
+			break
+		}
+		if c.accept("FOR", "EACH", "ROW") || c.accept("FOR", "EACH", "STATEMENT") {
+			if c.peek().is("FOLLOWS") || c.peek().is("PRECEDES") {
+				c.next()
+				c.next()
+			}
+			if next := c.peek(); !c.done() && !next.is("WHEN") && !next.is("BEGIN") && !next.is("EXECUTE") {
+				body = c.rest()
+				break
+			}
+			continue
+		}
+		c.next()
+	}
+	definition := c.toks[definitionStart : c.pos-len(body)]
+
+	trigger := &ir.DistilledFunction{
+		BaseNode: ir.BaseNode{
+			Location: p.location(start, p.last()),
+			Extensions: &ir.NodeExtensions{SQL: &ir.SQLExtensions{
+				Kind:       "trigger",
+				Table:      table,
+				Definition: joinTokens(definition),
+			}},
+		},
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+		Parameters: []ir.Parameter{},
+	}
+	if len(body) > 0 {
+		trigger.Implementation = p.raw([]token{body[0], body[len(body)-1]})
+	}
+	p.schema.attach(table, &entry{kind: "trigger", name: name, docs: p.docNodes(docs), node: trigger})
+	return nil
+}
+
+// createType distills enum and composite types
+func (p *parser) createType(c *cursor, start token, docs []comment) error {
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+
+	base := ir.BaseNode{Location: p.location(start, p.last())}
+	var node ir.DistilledNode
+	switch {
+	case c.accept("AS", "ENUM"):
+		base.Extensions = &ir.NodeExtensions{SQL: &ir.SQLExtensions{Kind: "enum"}}
+		enum := &ir.DistilledEnum{BaseNode: base, Name: name, Visibility: ir.VisibilityPublic}
+		group, _ := c.group()
+		for _, it := range splitItems(group) {
+			enum.Children = append(enum.Children, p.enumValue(it.toks[0]))
+		}
+		node = enum
+	case c.accept("AS") && isSymbol(c.peek(), "("):
+		base.Extensions = &ir.NodeExtensions{SQL: &ir.SQLExtensions{Kind: "type"}}
+		typ := &ir.DistilledStruct{BaseNode: base, Name: name, Visibility: ir.VisibilityPublic}
+		group, _ := c.group()
+		for _, it := range splitItems(group) {
+			typ.Children = append(typ.Children, p.column(it)...)
+		}
+		node = typ
+	default:
+		// Base, range and shell types are not distilled
+		return nil
+	}
+
+	p.schema.define(&entry{kind: "type", name: name, docs: p.docNodes(docs), node: node})
+	return nil
+}
+
+func (p *parser) enumValue(tok token) *ir.DistilledField {
+	return &ir.DistilledField{
+		BaseNode:   ir.BaseNode{Location: p.location(tok, tok)},
+		Name:       stringValue(tok.text),
+		Visibility: ir.VisibilityPublic,
+	}
+}
+
+// alter applies ALTER statements to the schema
+func (p *parser) alter(c *cursor, docs []comment) error {
+	c.next()
+	switch {
+	case c.accept("TABLE"):
+		return p.alterTable(c, docs)
+	case c.accept("TYPE"):
+		return p.alterType(c, docs)
+	case c.accept("VIEW"), c.accept("MATERIALIZED", "VIEW"):
+		return p.alterRename(c, "view", docs)
+	case c.accept("INDEX"):
+		return p.alterRename(c, "index", docs)
+	case c.accept("FUNCTION"):
+		return p.alterRename(c, "function", docs)
+	case c.accept("PROCEDURE"):
+		return p.alterRename(c, "procedure", docs)
+	}
+	return nil
+}
+
+func (p *parser) alterTable(c *cursor, docs []comment) error {
+	c.accept("IF", "EXISTS")
+	c.accept("ONLY")
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+	c.acceptSymbol("*")
+
+	e := p.schema.find("table", name, "")
+	if e == nil {
+		p.unresolved("alter", "table", name, docs, p.statementText())
+		return nil
+	}
+
+	actions := splitItems(c.rest())
+	if len(actions) != 1 {
+		docs = nil
+	}
+	for _, action := range actions {
+		if err := p.alterTableAction(e, action, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alterTableAction applies an action of ALTER TABLE. The docs of a
+// statement with a single action document the column it adds.
+func (p *parser) alterTableAction(e *entry, action item, docs []comment) error {
+	table := e.node.(*ir.DistilledStruct)
+	c := &cursor{toks: action.toks}
+	switch {
+	case c.accept("ADD"):
+		column := c.accept("COLUMN")
+		c.accept("IF", "NOT", "EXISTS")
+		if group, ok := c.group(); ok && !column {
+			// MySQL adds several columns in parentheses
+			for _, it := range splitItems(group) {
+				p.addColumn(table, it, token{})
+			}
+			return nil
+		}
+		rest := c.rest()
+		if len(rest) == 0 {
+			return p.errorf(action.toks[0], "expected column or constraint after ADD")
+		}
+		if !column && isTableConstraint(rest) {
+			addConstraint(table, joinTokens(rest))
+			return nil
+		}
+		p.addColumn(table, item{toks: rest, docs: append(docs, action.docs...)}, token{})
+	case c.accept("DROP"):
+		switch {
+		case c.accept("CONSTRAINT"), c.accept("FOREIGN", "KEY"), c.accept("CHECK"), c.accept("INDEX"), c.accept("KEY"):
+			c.accept("IF", "EXISTS")
+			dropConstraint(table, identName(c.next()))
+		case c.accept("PRIMARY", "KEY"):
+			dropPrimaryKey(table)
+		default:
+			c.accept("COLUMN")
+			c.accept("IF", "EXISTS")
+			if i := columnIndex(table, identName(c.next())); i >= 0 {
+				table.Children, _ = detach(table.Children, i)
+			}
+		}
+	case c.accept("ALTER"):
+		c.accept("COLUMN")
+		i := columnIndex(table, identName(c.next()))
+		if i < 0 {
+			return nil
+		}
+		field := *table.Children[i].(*ir.DistilledField)
+		switch {
+		case c.accept("SET", "DATA", "TYPE"), c.accept("TYPE"):
+			typ := c.until(func(c *cursor) bool { return c.peek().is("USING") || c.peek().is("COLLATE") })
+			field.Type = &ir.TypeRef{Name: joinTokens(typ)}
+		case c.accept("SET", "DEFAULT"):
+			field.DefaultValue = joinTokens(c.rest())
+		case c.accept("DROP", "DEFAULT"):
+			field.DefaultValue = ""
+		case c.accept("SET", "NOT", "NULL"):
+			field.Decorators = append(without(field.Decorators, "NULL", "NOT NULL"), "NOT NULL")
+		case c.accept("DROP", "NOT", "NULL"):
+			field.Decorators = without(field.Decorators, "NOT NULL")
+		}
+		table.Children[i] = &field
+	case c.accept("MODIFY"):
+		c.accept("COLUMN")
+		rest := c.rest()
+		if len(rest) > 0 {
+			p.addColumn(table, item{toks: rest, docs: action.docs}, rest[0])
+		}
+	case c.accept("CHANGE"):
+		c.accept("COLUMN")
+		old := c.next()
+		rest := c.rest()
+		if len(rest) > 0 {
+			p.addColumn(table, item{toks: rest, docs: action.docs}, old)
+		}
+	case c.accept("RENAME"):
+		switch {
+		case c.accept("TO"), c.accept("AS"):
+			name, err := p.name(c)
+			if err != nil {
+				return err
+			}
+			p.schema.rename(e, name)
+		case c.accept("CONSTRAINT"), c.accept("INDEX"), c.accept("KEY"):
+			old := identName(c.next())
+			c.accept("TO")
+			renameConstraint(table, old, identName(c.next()))
+		default:
+			c.accept("COLUMN")
+			old := identName(c.next())
+			c.accept("TO")
+			if i := columnIndex(table, old); i >= 0 {
+				field := *table.Children[i].(*ir.DistilledField)
+				field.Name = identName(c.next())
+				table.Children[i] = &field
+			}
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to a table. If replace is set, the column with
+// that name is replaced instead, keeping its position and docs unless the
+// new definition has its own. MySQL FIRST and AFTER positions are honored.
+func (p *parser) addColumn(table *ir.DistilledStruct, it item, replace token) {
+	toks, after, first := columnPosition(it.toks)
+	if len(toks) == 0 {
+		return
+	}
+	nodes := p.column(item{toks: toks, docs: it.docs})
+	field := nodes[len(nodes)-1].(*ir.DistilledField)
+
+	pos := -1
+	if replace.kind != tokenEOF {
+		i := columnIndex(table, identName(replace))
+		if i < 0 {
+			return
+		}
+		var old []ir.DistilledNode
+		table.Children, old = detach(table.Children, i)
+		if len(nodes) == 1 {
+			nodes = append(old[:len(old)-1:len(old)-1], field)
+		}
+		pos = i - len(old) + 1
+	} else if columnIndex(table, field.Name) >= 0 {
+		return
+	}
+
+	switch {
+	case first:
+		pos = 0
+	case after != "":
+		if i := columnIndex(table, after); i >= 0 {
+			pos = i + 1
+		}
+	}
+	if pos < 0 {
+		// New columns go after the last column, before indexes and triggers
+		pos = 0
+		for i, child := range table.Children {
+			if isColumn(child) {
+				pos = i + 1
+			}
+		}
+	}
+	table.Children = append(table.Children[:pos:pos], append(nodes, table.Children[pos:]...)...)
+}
+
+// columnPosition removes a trailing FIRST or AFTER column clause
+func columnPosition(toks []token) ([]token, string, bool) {
+	n := len(toks)
+	switch {
+	case n > 1 && toks[n-1].is("FIRST"):
+		return toks[:n-1], "", true
+	case n > 2 && toks[n-2].is("AFTER"):
+		return toks[:n-2], identName(toks[n-1]), false
+	}
+	return toks, "", false
+}
+
+func (p *parser) alterType(c *cursor, docs []comment) error {
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+	e := p.schema.find("type", name, "")
+	if e == nil {
+		p.unresolved("alter", "type", name, docs, p.statementText())
+		return nil
+	}
+
+	switch {
+	case c.accept("RENAME", "TO"):
+		newName, err := p.name(c)
+		if err != nil {
+			return err
+		}
+		p.schema.rename(e, newName)
+	case c.accept("ADD", "VALUE"):
+		enum, ok := e.node.(*ir.DistilledEnum)
+		if !ok {
+			return nil
+		}
+		c.accept("IF", "NOT", "EXISTS")
+		value := p.enumValue(c.next())
+		pos := len(enum.Children)
+		if c.peek().is("BEFORE") || c.peek().is("AFTER") {
+			offset := 0
+			if c.next().is("AFTER") {
+				offset = 1
+			}
+			if i := fieldIndex(enum.Children, stringValue(c.next().text)); i >= 0 {
+				pos = i + offset
+			}
+		}
+		if fieldIndex(enum.Children, value.Name) < 0 {
+			enum.Children = append(enum.Children[:pos:pos], append([]ir.DistilledNode{value}, enum.Children[pos:]...)...)
+		}
+	case c.accept("RENAME", "VALUE"):
+		enum, ok := e.node.(*ir.DistilledEnum)
+		if !ok {
+			return nil
+		}
+		old := stringValue(c.next().text)
+		c.accept("TO")
+		if i := fieldIndex(enum.Children, old); i >= 0 {
+			value := *enum.Children[i].(*ir.DistilledField)
+			value.Name = stringValue(c.next().text)
+			enum.Children[i] = &value
+		}
+	}
+	return nil
+}
+
+// alterRename applies ALTER ... RENAME TO to views, indexes and routines
+func (p *parser) alterRename(c *cursor, kind string, docs []comment) error {
+	c.accept("IF", "EXISTS")
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+	var args string
+	if group, ok := c.group(); ok {
+		args = signature(parameters(group))
+	}
+	if !c.accept("RENAME", "TO") {
+		return nil
+	}
+	newName, err := p.name(c)
+	if err != nil {
+		return err
+	}
+
+	if e := p.schema.find(kind, name, args); e != nil {
+		p.schema.rename(e, newName)
+	} else if kind != "index" || !p.schema.renameChild(kind, name, newName) {
+		p.unresolved("alter", kind, name, docs, p.statementText())
+	}
+	return nil
+}
+
+// drop removes dropped objects from the schema
+func (p *parser) drop(c *cursor, docs []comment) error {
+	c.next()
+	var kind string
+	switch {
+	case c.accept("TABLE"):
+		kind = "table"
+	case c.accept("VIEW"), c.accept("MATERIALIZED", "VIEW"):
+		kind = "view"
+	case c.accept("INDEX"):
+		kind = "index"
+	case c.accept("FUNCTION"):
+		kind = "function"
+	case c.accept("PROCEDURE"):
+		kind = "procedure"
+	case c.accept("TRIGGER"):
+		kind = "trigger"
+	case c.accept("TYPE"):
+		kind = "type"
+	default:
+		return nil
+	}
+	c.accept("CONCURRENTLY")
+	c.accept("IF", "EXISTS")
+	head := c.toks[:c.pos]
+
+	names := c.until(func(c *cursor) bool {
+		tok := c.peek()
+		return tok.is("ON") || tok.is("CASCADE") || tok.is("RESTRICT")
+	})
+	tail := c.rest()
+	table := ""
+	if tc := (&cursor{toks: tail}); tc.accept("ON") {
+		table, _ = p.name(tc)
+	}
+
+	for _, it := range splitItems(names) {
+		nc := &cursor{toks: it.toks}
+		name, err := p.name(nc)
+		if err != nil {
+			return err
+		}
+		args := ""
+		if group, ok := nc.group(); ok {
+			args = signature(parameters(group))
+		}
+		if !p.schema.drop(kind, name, args, table) {
+			text := joinTokens(head) + " " + joinTokens(it.toks)
+			if len(tail) > 0 {
+				text += " " + joinTokens(tail)
+			}
+			p.unresolved("drop", kind, name, docs, text)
+		}
+	}
+	return nil
+}
+
+func parameters(group []token) []ir.Parameter {
+	var params []ir.Parameter
+	for _, it := range splitItems(group) {
+		params = append(params, parameter(it.toks))
+	}
+	return params
+}
+
+// renameTables applies MySQL RENAME TABLE a TO b, c TO d
+func (p *parser) renameTables(c *cursor, docs []comment) error {
+	c.next()
+	c.next()
+	for _, it := range splitItems(c.rest()) {
+		rc := &cursor{toks: it.toks}
+		old, err := p.name(rc)
+		if err != nil {
+			return err
+		}
+		if !rc.accept("TO") {
+			return p.errorf(rc.peek(), "expected TO in RENAME TABLE")
+		}
+		name, err := p.name(rc)
+		if err != nil {
+			return err
+		}
+		e := p.schema.find("table", old, "")
+		if e == nil {
+			p.unresolved("alter", "table", old, docs, p.statementText())
+			return nil
+		}
+		p.schema.rename(e, name)
+	}
+	return nil
+}
+
+// commentOn applies COMMENT ON ... IS 'text' as doc comments
+func (p *parser) commentOn(c *cursor, docs []comment) error {
+	c.next()
+	c.next()
+	var kind string
+	switch {
+	case c.accept("TABLE"):
+		kind = "table"
+	case c.accept("COLUMN"):
+		kind = "column"
+	case c.accept("VIEW"), c.accept("MATERIALIZED", "VIEW"):
+		kind = "view"
+	case c.accept("FUNCTION"):
+		kind = "function"
+	case c.accept("PROCEDURE"):
+		kind = "procedure"
+	case c.accept("INDEX"):
+		kind = "index"
+	case c.accept("TRIGGER"):
+		kind = "trigger"
+	case c.accept("TYPE"):
+		kind = "type"
+	default:
+		return nil
+	}
+
+	name, err := p.name(c)
+	if err != nil {
+		return err
+	}
+	args := ""
+	if group, ok := c.group(); ok {
+		args = signature(parameters(group))
+	}
+	if c.accept("ON") {
+		// The table of a trigger
+		if _, err := p.name(c); err != nil {
+			return err
+		}
+	}
+	if !c.accept("IS") {
+		return p.errorf(c.peek(), "expected IS in COMMENT ON")
+	}
+	tok := c.next()
+	var doc []ir.DistilledNode
+	if tok.kind == tokenString {
+		doc = p.docNodes([]comment{{text: stringValue(tok.text), line: tok.line, endLine: tok.line}})
+	}
+
+	if !p.schema.comment(kind, name, args, doc) {
+		target := kind
+		if kind == "column" {
+			target = "table"
+		}
+		p.unresolved("comment", target, name, docs, p.statementText())
+	}
+	return nil
+}
+
+// unresolved records a change of an object that the script does not
+// create. It is applied when the script is folded with the scripts before
+// it, see FoldSchema.
+func (p *parser) unresolved(kind, target, name string, docs []comment, statement string) {
+	p.schema.entries = append(p.schema.entries, &entry{
+		kind:   kind,
+		target: target,
+		name:   name,
+		docs:   p.docNodes(docs),
+		node:   p.change(kind, name, statement),
+	})
+}
+
+// change returns a node for a change that is not applied to the schema
+func (p *parser) change(kind, name, statement string) *ir.DistilledStruct {
+	return &ir.DistilledStruct{
+		BaseNode: ir.BaseNode{
+			Location:   p.location(p.toks[0], p.last()),
+			Extensions: &ir.NodeExtensions{SQL: &ir.SQLExtensions{Kind: kind, Statement: statement}},
+		},
+		Name:       name,
+		Visibility: ir.VisibilityPublic,
+	}
+}
+
+// name consumes a possibly qualified name and returns it without quotes
+func (p *parser) name(c *cursor) (string, error) {
+	tok := c.peek()
+	if tok.kind != tokenIdent && tok.kind != tokenQuotedIdent {
+		return "", p.errorf(tok, "expected name, got %q", tok.text)
+	}
+	parts := []string{identName(c.next())}
+	for isSymbol(c.peek(), ".") && (c.peekAt(1).kind == tokenIdent || c.peekAt(1).kind == tokenQuotedIdent) {
+		c.next()
+		parts = append(parts, identName(c.next()))
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// raw returns the source text from the start of the first token to the
+// end of the last one
+func (p *parser) raw(toks []token) string {
+	return string(p.src[toks[0].start:toks[len(toks)-1].end])
+}
+
+// statementText returns the source text of the current statement
+func (p *parser) statementText() string {
+	return p.raw(p.toks)
+}
+
+// last returns the last token of the current statement
+func (p *parser) last() token {
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *parser) commentNodes(comments []comment) []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, c := range comments {
+		nodes = append(nodes, &ir.DistilledComment{
+			BaseNode: ir.BaseNode{Location: ir.Location{StartLine: c.line, EndLine: c.endLine}},
+			Text:     c.text,
+			Format:   "line",
+		})
+	}
+	return nodes
+}
+
+// docNodes returns comments as a single doc comment
+func (p *parser) docNodes(comments []comment) []ir.DistilledNode {
+	var lines []string
+	for _, c := range comments {
+		if c.text != "" {
+			lines = append(lines, c.text)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []ir.DistilledNode{&ir.DistilledComment{
+		BaseNode: ir.BaseNode{Location: ir.Location{StartLine: comments[0].line, EndLine: comments[len(comments)-1].endLine}},
+		Text:     strings.Join(lines, "\n"),
+		Format:   "doc",
+	}}
+}
+
+// docStart returns the index of the first leading comment of start that is
+// not separated from it by a blank line
+func docStart(start token) int {
+	i := len(start.leading)
+	line := start.line
+	for i > 0 && start.leading[i-1].endLine >= line-1 {
+		i--
+		line = start.leading[i].line
+	}
+	return i
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	if tok.kind == tokenEOF {
+		tok = p.last()
+	}
+	return &parseError{tok: tok, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) addError(err error) {
+	perr, ok := err.(*parseError)
+	if !ok {
+		perr = &parseError{tok: p.toks[0], msg: err.Error()}
+	}
+	p.file.Errors = append(p.file.Errors, ir.DistilledError{
+		BaseNode: ir.BaseNode{Location: p.location(perr.tok, perr.tok)},
+		Message:  perr.msg,
+		Severity: "error",
+	})
+}
+
+func (p *parser) location(start, end token) ir.Location {
+	// Strings and bodies span lines, so the end line is looked up by offset
+	endLine := sort.SearchInts(p.lines, end.end+1)
+	return ir.Location{
+		StartLine:   start.line,
+		StartColumn: start.start - p.lines[start.line-1] + 1,
+		EndLine:     endLine,
+		EndColumn:   end.end - p.lines[endLine-1] + 1,
+		StartByte:   start.start,
+		EndByte:     end.end,
+	}
+}
+
+// cursor reads a sequence of tokens. Reading past the end returns EOF
+// tokens.
+type cursor struct {
+	toks []token
+	pos  int
+}
+
+func (c *cursor) peek() token {
+	return c.peekAt(0)
+}
+
+func (c *cursor) peekAt(offset int) token {
+	if i := c.pos + offset; i >= 0 && i < len(c.toks) {
+		return c.toks[i]
+	}
+	return token{kind: tokenEOF}
+}
+
+func (c *cursor) next() token {
+	tok := c.peek()
+	if c.pos < len(c.toks) {
+		c.pos++
+	}
+	return tok
+}
+
+func (c *cursor) done() bool {
+	return c.pos >= len(c.toks)
+}
+
+// accept consumes the keywords if the next tokens are these keywords
+func (c *cursor) accept(keywords ...string) bool {
+	for i, kw := range keywords {
+		if !c.peekAt(i).is(kw) {
+			return false
+		}
+	}
+	c.pos += len(keywords)
+	return true
+}
+
+func (c *cursor) acceptSymbol(symbol string) bool {
+	if isSymbol(c.peek(), symbol) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+// rest consumes and returns the remaining tokens
+func (c *cursor) rest() []token {
+	toks := c.toks[min(c.pos, len(c.toks)):]
+	c.pos = len(c.toks)
+	return toks
+}
+
+// until consumes and returns tokens up to the first token outside of
+// parentheses for which stop returns true
+func (c *cursor) until(stop func(c *cursor) bool) []token {
+	start := c.pos
+	depth := 0
+	for !c.done() && !(depth == 0 && stop(c)) {
+		depth += nesting(c.next())
+	}
+	return c.toks[start:c.pos]
+}
+
+// group consumes a parenthesized group and returns the tokens inside it
+func (c *cursor) group() ([]token, bool) {
+	if !isSymbol(c.peek(), "(") {
+		return nil, false
+	}
+	start := c.pos + 1
+	depth := 0
+	for !c.done() {
+		depth += nesting(c.next())
+		if depth == 0 {
+			return c.toks[start : c.pos-1], true
+		}
+	}
+	return c.toks[start:], true
+}
+
+// item is an element of a comma separated list with its comments
+type item struct {
+	toks []token
+	docs []comment
+}
+
+// splitItems splits tokens at commas outside of parentheses. The comments
+// before an item and at the end of its lines, including the line of the
+// comma after it, document the item.
+func splitItems(toks []token) []item {
+	var items []item
+	var cur item
+	depth := 0
+	for _, tok := range toks {
+		if depth == 0 && isSymbol(tok, ",") {
+			if tok.trailing != nil {
+				cur.docs = append(cur.docs, *tok.trailing)
+			}
+			if len(cur.toks) > 0 {
+				items = append(items, cur)
+			}
+			cur = item{}
+			continue
+		}
+		if len(cur.toks) == 0 {
+			cur.docs = append(cur.docs, tok.leading...)
+		}
+		if tok.trailing != nil {
+			cur.docs = append(cur.docs, *tok.trailing)
+		}
+		cur.toks = append(cur.toks, tok)
+		depth += nesting(tok)
+	}
+	if len(cur.toks) > 0 {
+		items = append(items, cur)
+	}
+	return items
+}
+
+// nesting returns the change of the parenthesis depth by a token
+func nesting(tok token) int {
+	if tok.kind != tokenSymbol {
+		return 0
+	}
+	switch tok.text {
+	case "(", "[":
+		return 1
+	case ")", "]":
+		return -1
+	}
+	return 0
+}
+
+func isSymbol(tok token, symbol string) bool {
+	return tok.kind == tokenSymbol && tok.text == symbol
+}
+
+// joinTokens returns the text of tokens with comments removed and each run
+// of whitespace replaced by a single space
+func joinTokens(toks []token) string {
+	var sb strings.Builder
+	for i, tok := range toks {
+		if i > 0 && tok.start > toks[i-1].end {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(tok.text)
+	}
+	return sb.String()
+}
+
+// identName returns an identifier without its quotes
+func identName(tok token) string {
+	if tok.kind != tokenQuotedIdent || len(tok.text) < 2 {
+		return tok.text
+	}
+	quote := tok.text[:1]
+	inner := tok.text[1 : len(tok.text)-1]
+	if quote == "[" {
+		return inner
+	}
+	return strings.ReplaceAll(inner, quote+quote, quote)
+}
+
+// stringValue returns the value of a string literal
+func stringValue(text string) string {
+	if strings.HasPrefix(text, "$") {
+		if i := strings.Index(text[1:], "$"); i >= 0 {
+			tag := text[:i+2]
+			return strings.TrimSuffix(strings.TrimPrefix(text, tag), tag)
+		}
+	}
+	if i := strings.IndexByte(text, '\''); i >= 0 && strings.HasSuffix(text, "'") && len(text) > i+1 {
+		text = text[i+1 : len(text)-1]
+		return strings.ReplaceAll(text, "''", "'")
+	}
+	return text
+}
@@ -0,0 +1,290 @@
+package sql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/language/langtest"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaSource = `-- Application schema
+
+DROP TABLE IF EXISTS users;
+
+-- Registered users
+CREATE TABLE IF NOT EXISTS public.users (
+    id bigserial PRIMARY KEY,
+    email varchar(255) NOT NULL UNIQUE, -- login email
+    -- Display name
+    name text DEFAULT '' NOT NULL,
+    org_id integer CONSTRAINT fk_org REFERENCES orgs(id) ON DELETE CASCADE,
+    CONSTRAINT users_email_check CHECK (email <> '')
+);
+
+CREATE UNIQUE INDEX idx_users_email ON users USING btree (lower(email)) WHERE deleted_at IS NULL;
+
+CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy');
+
+CREATE OR REPLACE VIEW active_users AS
+SELECT u.id, u.email AS login, count(*) total, now()::date
+FROM users u WHERE u.active;
+
+CREATE OR REPLACE FUNCTION touch_updated_at(tbl regclass, VARIADIC cols text[] DEFAULT '{}', OUT n int)
+RETURNS trigger
+LANGUAGE plpgsql STABLE
+AS $$
+BEGIN
+  NEW.updated_at := now();
+  RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER users_touch BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+ALTER TABLE users ADD COLUMN deleted_at timestamptz;
+ALTER TABLE users ALTER COLUMN name SET DATA TYPE varchar(100), DROP CONSTRAINT users_email_check;
+ALTER TABLE users RENAME COLUMN name TO full_name;
+COMMENT ON COLUMN users.full_name IS 'Full name of the user';
+ALTER TYPE mood ADD VALUE 'meh' BEFORE 'ok';
+
+INSERT INTO users (email) VALUES ('a@b.c');
+
+ALTER TABLE payments ADD COLUMN amount numeric(10,2);
+DROP TABLE legacy;
+`
+
+const mysqlSource = "DELIMITER $$\n" +
+	"CREATE PROCEDURE add_user(IN p_email VARCHAR(255), OUT p_id INT)\n" +
+	"    MODIFIES SQL DATA\n" +
+	"    COMMENT 'Adds a user'\n" +
+	"BEGIN\n" +
+	"  IF p_email IS NULL THEN\n" +
+	"    SET p_id = 0;\n" +
+	"  END IF;\n" +
+	"  SET p_id = LAST_INSERT_ID();\n" +
+	"END$$\n" +
+	"DELIMITER ;\n" +
+	"\n" +
+	"CREATE TABLE `orders` (\n" +
+	"  `id` int unsigned NOT NULL AUTO_INCREMENT COMMENT 'Order id',\n" +
+	"  `user_id` bigint NOT NULL,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `idx_user` (`user_id`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Customer orders';\n"
+
+func TestProcessTables(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), schemaSource, "schema.sql")
+	assert.Empty(t, file.Errors)
+	assert.Equal(t, "sql", file.Language)
+
+	structs := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, structs, 3)
+	users := structs[0]
+	assert.Equal(t, "public.users", users.Name)
+	assert.Equal(t, "table", users.Extensions.SQL.Kind)
+
+	// The check constraint is dropped by a later ALTER TABLE
+	assert.Empty(t, users.Extensions.SQL.Constraints)
+
+	columns := langtest.Nodes[*ir.DistilledField](users.Children)
+	require.Len(t, columns, 6)
+	assert.Equal(t, "id", columns[0].Name)
+	assert.Equal(t, "bigserial", columns[0].Type.Name)
+	assert.Equal(t, []string{"PRIMARY KEY"}, columns[0].Decorators)
+	assert.Equal(t, []string{"NOT NULL", "UNIQUE"}, columns[1].Decorators)
+
+	// Columns are renamed and retyped in place, added columns come last
+	assert.Equal(t, "full_name", columns[2].Name)
+	assert.Equal(t, "varchar(100)", columns[2].Type.Name)
+	assert.Equal(t, "''", columns[2].DefaultValue)
+	assert.Equal(t, []string{"CONSTRAINT fk_org REFERENCES orgs(id) ON DELETE CASCADE"}, columns[3].Decorators)
+	assert.Equal(t, "deleted_at", columns[4].Name)
+
+	// Indexes and triggers belong to their table
+	assert.Equal(t, "idx_users_email", columns[5].Name)
+	assert.True(t, columns[5].Extensions.SQL.Unique)
+	assert.Equal(t, "ON users USING btree (lower(email)) WHERE deleted_at IS NULL", columns[5].Extensions.SQL.Definition)
+	triggers := langtest.Nodes[*ir.DistilledFunction](users.Children)
+	require.Len(t, triggers, 1)
+	assert.Equal(t, "trigger", triggers[0].Extensions.SQL.Kind)
+	assert.Equal(t, "BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION touch_updated_at()", triggers[0].Extensions.SQL.Definition)
+
+	// Changes to unknown objects are kept as statements
+	assert.Equal(t, "alter", structs[1].Extensions.SQL.Kind)
+	assert.Equal(t, "ALTER TABLE payments ADD COLUMN amount numeric(10,2)", structs[1].Extensions.SQL.Statement)
+	assert.Equal(t, "DROP TABLE legacy", structs[2].Extensions.SQL.Statement)
+}
+
+func TestProcessComments(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), schemaSource, "schema.sql")
+
+	comments := langtest.Nodes[*ir.DistilledComment](file.Children)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "Application schema", comments[0].Text)
+	assert.Equal(t, "line", comments[0].Format)
+	assert.Equal(t, "Registered users", comments[1].Text)
+	assert.Equal(t, "doc", comments[1].Format)
+
+	users := langtest.Nodes[*ir.DistilledStruct](file.Children)[0]
+	docs := langtest.Nodes[*ir.DistilledComment](users.Children)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "login email", docs[0].Text)
+	assert.Equal(t, "Full name of the user", docs[1].Text)
+}
+
+func TestProcessTypesAndRoutines(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), schemaSource, "schema.sql")
+
+	enums := langtest.Nodes[*ir.DistilledEnum](file.Children)
+	require.Len(t, enums, 1)
+	var values []string
+	for _, value := range langtest.Nodes[*ir.DistilledField](enums[0].Children) {
+		values = append(values, value.Name)
+	}
+	assert.Equal(t, []string{"sad", "meh", "ok", "happy"}, values)
+
+	functions := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, functions, 2)
+	view, fn := functions[0], functions[1]
+
+	assert.Equal(t, "view", view.Extensions.SQL.Kind)
+	var columns []string
+	for _, param := range view.Parameters {
+		columns = append(columns, param.Name)
+	}
+	assert.Equal(t, []string{"id", "login", "total"}, columns)
+	assert.True(t, strings.HasPrefix(view.Implementation, "AS\nSELECT u.id"))
+
+	assert.Equal(t, "function", fn.Extensions.SQL.Kind)
+	require.Len(t, fn.Parameters, 3)
+	assert.True(t, fn.Parameters[1].IsVariadic)
+	assert.Equal(t, "text[]", fn.Parameters[1].Type.Name)
+	assert.Equal(t, "'{}'", fn.Parameters[1].DefaultValue)
+	assert.Equal(t, []string{"OUT"}, fn.Parameters[2].Decorators)
+	assert.Equal(t, "trigger", fn.Returns.Name)
+	assert.Equal(t, []string{"LANGUAGE plpgsql", "STABLE"}, fn.Decorators)
+	assert.Contains(t, fn.Implementation, "NEW.updated_at := now();")
+}
+
+func TestProcessMySQL(t *testing.T) {
+	file := langtest.Process(t, NewProcessor(), mysqlSource, "schema.sql")
+	assert.Empty(t, file.Errors)
+
+	procedures := langtest.Nodes[*ir.DistilledFunction](file.Children)
+	require.Len(t, procedures, 1)
+	assert.Equal(t, "procedure", procedures[0].Extensions.SQL.Kind)
+	assert.Equal(t, []string{"IN"}, procedures[0].Parameters[0].Decorators)
+	assert.Equal(t, []string{"MODIFIES SQL DATA"}, procedures[0].Decorators)
+	assert.True(t, strings.HasPrefix(procedures[0].Implementation, "BEGIN"))
+	assert.True(t, strings.HasSuffix(procedures[0].Implementation, "END"))
+
+	tables := langtest.Nodes[*ir.DistilledStruct](file.Children)
+	require.Len(t, tables, 1)
+	assert.Equal(t, "orders", tables[0].Name)
+	assert.Equal(t, []string{"PRIMARY KEY (`id`)", "KEY `idx_user` (`user_id`)"}, tables[0].Extensions.SQL.Constraints)
+
+	comments := langtest.Nodes[*ir.DistilledComment](file.Children)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "Adds a user", comments[0].Text)
+	assert.Equal(t, "Customer orders", comments[1].Text)
+	docs := langtest.Nodes[*ir.DistilledComment](tables[0].Children)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "Order id", docs[0].Text)
+}
+
+func TestFoldSchema(t *testing.T) {
+	first := langtest.Process(t, NewProcessor(), `CREATE TABLE users (id int PRIMARY KEY, email text);
+-- Password hashes
+CREATE TABLE secrets (user_id int);`, "schema.sql")
+	second := langtest.Process(t, NewProcessor(), `ALTER TABLE users ADD COLUMN name text;
+ALTER TABLE users DROP COLUMN email;
+DROP TABLE secrets;
+CREATE INDEX idx_users_name ON users (name);`, "schema.sql")
+
+	folded := FoldSchema("migrations/*.sql", []*ir.DistilledFile{first, second})
+	assert.Equal(t, "migrations/*.sql", folded.Path)
+	require.Len(t, folded.Children, 1)
+
+	users := folded.Children[0].(*ir.DistilledStruct)
+	var names []string
+	for _, field := range langtest.Nodes[*ir.DistilledField](users.Children) {
+		names = append(names, field.Name)
+	}
+	assert.Equal(t, []string{"id", "name", "idx_users_name"}, names)
+
+	// The inputs are not changed
+	assert.Len(t, langtest.Nodes[*ir.DistilledField](langtest.Nodes[*ir.DistilledStruct](first.Children)[0].Children), 2)
+}
+
+func TestFoldDirectory(t *testing.T) {
+	file := func(path, source string) *ir.DistilledFile {
+		f := langtest.Process(t, NewProcessor(), source, "schema.sql")
+		f.Path = path
+		return f
+	}
+	dir := &ir.DistilledDirectory{Path: ".", Children: []ir.DistilledNode{
+		&ir.DistilledFile{Path: "main.go", Language: "go"},
+		file("db/V10__drop.sql", "ALTER TABLE users DROP COLUMN email;"),
+		file("db/V2__users.sql", "CREATE TABLE users (id int, email text);"),
+		file("db/V2__users.down.sql", "DROP TABLE users;"),
+		file("db/2024_01_orders/up.sql", "CREATE TABLE orders (id int);"),
+		file("db/2024_01_orders/down.sql", "DROP TABLE orders;"),
+		file("seed/data.sql", "CREATE TABLE seed (id int);"),
+	}}
+
+	result := FoldDirectory(dir)
+	require.Len(t, result.Children, 3)
+	assert.Equal(t, "main.go", result.Children[0].(*ir.DistilledFile).Path)
+	assert.Equal(t, "seed/data.sql", result.Children[2].(*ir.DistilledFile).Path)
+
+	folded := result.Children[1].(*ir.DistilledFile)
+	assert.Equal(t, "db/*.sql", folded.Path)
+	tables := langtest.Nodes[*ir.DistilledStruct](folded.Children)
+	require.Len(t, tables, 2)
+	assert.Equal(t, "orders", tables[0].Name)
+	assert.Equal(t, "users", tables[1].Name)
+	assert.Len(t, langtest.Nodes[*ir.DistilledField](tables[1].Children), 1)
+}
+
+func TestFoldDirectoryOnlyMigrations(t *testing.T) {
+	file := func(path, source string) *ir.DistilledFile {
+		f := langtest.Process(t, NewProcessor(), source, "schema.sql")
+		f.Path = path
+		return f
+	}
+	dir := &ir.DistilledDirectory{Path: ".", Children: []ir.DistilledNode{
+		file("queries/users.sql", "CREATE VIEW active_users AS SELECT * FROM users;"),
+		file("queries/orders.sql", "CREATE VIEW open_orders AS SELECT * FROM orders;"),
+		file("schema/base.sql", "CREATE TABLE users (id int);"),
+		file("schema/extra.sql", "ALTER TABLE users ADD COLUMN email text;"),
+	}}
+
+	// Plain query files keep their paths; unversioned files that alter
+	// tables are still migrations
+	result := FoldDirectory(dir)
+	require.Len(t, result.Children, 3)
+	assert.Equal(t, "queries/users.sql", result.Children[0].(*ir.DistilledFile).Path)
+	assert.Equal(t, "queries/orders.sql", result.Children[1].(*ir.DistilledFile).Path)
+	assert.Equal(t, "schema/*.sql", result.Children[2].(*ir.DistilledFile).Path)
+}
+
+func TestFormatText(t *testing.T) {
+	opts := processor.DefaultProcessOptions()
+	opts.IncludeComments = false
+	opts.IncludeImplementation = false
+	output := langtest.FormatText(t, NewProcessor(), schemaSource, "schema.sql", opts)
+
+	assert.NotContains(t, output, "Application schema")
+	assert.NotContains(t, output, "NEW.updated_at")
+	assert.Contains(t, output, "-- Registered users\nCREATE TABLE public.users (\n    id bigserial PRIMARY KEY,\n")
+	assert.Contains(t, output, "    -- Full name of the user\n    full_name varchar(100) DEFAULT '' NOT NULL,\n")
+	assert.Contains(t, output, "    deleted_at timestamptz\n);\nCREATE UNIQUE INDEX idx_users_email ON users")
+	assert.Contains(t, output, "CREATE TYPE mood AS ENUM ('sad', 'meh', 'ok', 'happy');\n")
+	assert.Contains(t, output, "CREATE VIEW active_users (id, login, total);\n")
+	assert.Contains(t, output, "CREATE FUNCTION touch_updated_at(tbl regclass, VARIADIC cols text[] DEFAULT '{}', OUT n int) RETURNS trigger LANGUAGE plpgsql STABLE;\n")
+	assert.Contains(t, output, "ALTER TABLE payments ADD COLUMN amount numeric(10,2);\n")
+}
@@ -0,0 +1,726 @@
+package sql
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// entry is a top-level object of a schema with its doc comments
+type entry struct {
+	// kind is the object kind: table, view, function, procedure, index,
+	// trigger or type. Changes that could not be applied have the kind
+	// alter, drop or comment, and standalone comments have no kind.
+	kind string
+	// target is the object kind a change that could not be applied is for
+	target string
+	name   string
+	// args are the parameter types of a routine, see signature
+	args string
+	docs []ir.DistilledNode
+	node ir.DistilledNode
+}
+
+// schema is the state of a database after a sequence of statements.
+// Objects keep the position of the statement that created them; indexes
+// and triggers of a known table are kept with the table.
+type schema struct {
+	entries []*entry
+}
+
+// nodes returns the objects of the schema with their comments
+func (s *schema) nodes() []ir.DistilledNode {
+	var nodes []ir.DistilledNode
+	for _, e := range s.entries {
+		nodes = append(nodes, e.docs...)
+		nodes = append(nodes, e.node)
+	}
+	return nodes
+}
+
+// add adds standalone nodes, such as comments
+func (s *schema) add(nodes ...ir.DistilledNode) {
+	for _, node := range nodes {
+		s.entries = append(s.entries, &entry{node: node})
+	}
+}
+
+// define adds an object. An object of the same kind and name, and for
+// routines the same parameter types, is replaced in place.
+func (s *schema) define(e *entry) {
+	s.removeDrops(e)
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		old := s.entries[i]
+		if old.kind == e.kind && sameName(old.name, e.name) && old.args == e.args {
+			old.name = e.name
+			old.node = e.node
+			if len(e.docs) > 0 {
+				old.docs = e.docs
+			}
+			return
+		}
+	}
+	s.entries = append(s.entries, e)
+}
+
+// attach adds an index or trigger to its table, or as a top-level object
+// if the table is not known
+func (s *schema) attach(table string, e *entry) {
+	t := s.find("table", table, "")
+	if t == nil {
+		s.define(e)
+		return
+	}
+	s.removeDrops(e)
+	st := t.node.(*ir.DistilledStruct)
+	if i := childIndex(st.Children, e.kind, e.name); i >= 0 && e.name != "" {
+		st.Children, _ = detach(st.Children, i)
+	}
+	st.Children = append(st.Children[:len(st.Children):len(st.Children)], append(e.docs, e.node)...)
+}
+
+// removeDrops removes earlier drops of an object that could not be
+// applied, which are part of re-creating the object
+func (s *schema) removeDrops(e *entry) {
+	s.remove(func(old *entry) bool {
+		return old.kind == "drop" && old.target == e.kind && sameName(old.name, e.name)
+	})
+}
+
+// find returns the latest object of a kind with the name. Unless args is
+// empty, the parameter types of a routine have to match as well.
+func (s *schema) find(kind, name, args string) *entry {
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		if e.kind == kind && sameName(e.name, name) && (args == "" || e.args == args) {
+			return e
+		}
+	}
+	return nil
+}
+
+// remove removes the entries for which match returns true and reports
+// whether there were any
+func (s *schema) remove(match func(e *entry) bool) bool {
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(kept) < len(s.entries)
+	s.entries = kept
+	return removed
+}
+
+// drop removes an object and reports whether it was found. Without args,
+// all overloads of a routine are dropped.
+func (s *schema) drop(kind, name, args, table string) bool {
+	if kind == "index" || kind == "trigger" {
+		if s.remove(func(e *entry) bool { return e.kind == kind && sameName(e.name, name) }) {
+			return true
+		}
+		for _, e := range s.tables(table) {
+			st := e.node.(*ir.DistilledStruct)
+			if i := childIndex(st.Children, kind, name); i >= 0 {
+				st.Children, _ = detach(st.Children, i)
+				return true
+			}
+		}
+		return false
+	}
+
+	if s.remove(func(e *entry) bool {
+		return e.kind == kind && sameName(e.name, name) && (args == "" || e.args == args)
+	}) {
+		return true
+	}
+	// The parameter types may be spelled differently, e.g. int and integer
+	if args != "" && s.find(kind, name, "") != nil {
+		overloads := 0
+		for _, e := range s.entries {
+			if e.kind == kind && sameName(e.name, name) {
+				overloads++
+			}
+		}
+		if overloads == 1 {
+			return s.remove(func(e *entry) bool { return e.kind == kind && sameName(e.name, name) })
+		}
+	}
+	return false
+}
+
+// rename renames an object. A new name without a schema keeps the schema
+// of the old name.
+func (s *schema) rename(e *entry, name string) {
+	if i := strings.LastIndex(e.name, "."); i >= 0 && !strings.Contains(name, ".") {
+		name = e.name[:i+1] + name
+	}
+	old := e.name
+	e.name = name
+
+	switch n := e.node.(type) {
+	case *ir.DistilledStruct:
+		n.Name = name
+		for i, child := range n.Children {
+			if ext := sqlExtensions(child); ext != nil && (ext.Kind == "index" || ext.Kind == "trigger") {
+				table := *ext
+				table.Table = name
+				table.Definition = strings.Replace(table.Definition, "ON "+old, "ON "+name, 1)
+				n.Children[i] = withExtensions(child, &table)
+			}
+		}
+	case *ir.DistilledEnum:
+		n.Name = name
+	case *ir.DistilledFunction:
+		n.Name = name
+	case *ir.DistilledField:
+		n.Name = name
+	}
+}
+
+// renameChild renames an index or trigger of a table and reports whether
+// it was found
+func (s *schema) renameChild(kind, name, newName string) bool {
+	for _, e := range s.tables("") {
+		st := e.node.(*ir.DistilledStruct)
+		if i := childIndex(st.Children, kind, name); i >= 0 {
+			st.Children[i] = withName(st.Children[i], newName)
+			return true
+		}
+	}
+	return false
+}
+
+// comment replaces the doc comments of an object and reports whether it
+// was found. Columns are named table.column.
+func (s *schema) comment(kind, name, args string, doc []ir.DistilledNode) bool {
+	switch kind {
+	case "column":
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return false
+		}
+		t := s.find("table", name[:i], "")
+		if t == nil {
+			return false
+		}
+		st := t.node.(*ir.DistilledStruct)
+		if j := columnIndex(st, name[i+1:]); j >= 0 {
+			st.Children = setDocs(st.Children, j, doc)
+			return true
+		}
+		return false
+	case "index", "trigger":
+		if e := s.find(kind, name, ""); e != nil {
+			e.docs = doc
+			return true
+		}
+		for _, e := range s.tables("") {
+			st := e.node.(*ir.DistilledStruct)
+			if j := childIndex(st.Children, kind, name); j >= 0 {
+				st.Children = setDocs(st.Children, j, doc)
+				return true
+			}
+		}
+		return false
+	}
+
+	if e := s.find(kind, name, args); e != nil {
+		e.docs = doc
+		return true
+	}
+	return false
+}
+
+// tables returns the tables with the name, or all tables if name is empty
+func (s *schema) tables(name string) []*entry {
+	var tables []*entry
+	for _, e := range s.entries {
+		if e.kind == "table" && (name == "" || sameName(e.name, name)) {
+			tables = append(tables, e)
+		}
+	}
+	return tables
+}
+
+// sameName compares names case-insensitively. An unqualified name matches
+// a qualified one with the same last part.
+func sameName(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	ia, ib := strings.LastIndex(a, "."), strings.LastIndex(b, ".")
+	if (ia < 0) == (ib < 0) {
+		return false
+	}
+	return strings.EqualFold(a[ia+1:], b[ib+1:])
+}
+
+// isColumn reports whether a child of a table is a column rather than an
+// index or trigger
+func isColumn(node ir.DistilledNode) bool {
+	field, ok := node.(*ir.DistilledField)
+	return ok && sqlExtensions(field) == nil
+}
+
+func columnIndex(table *ir.DistilledStruct, name string) int {
+	for i, child := range table.Children {
+		if isColumn(child) && strings.EqualFold(child.(*ir.DistilledField).Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// childIndex returns the index of the index or trigger with the name
+func childIndex(children []ir.DistilledNode, kind, name string) int {
+	for i, child := range children {
+		if ext := sqlExtensions(child); ext != nil && ext.Kind == kind && sameName(nodeName(child), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fieldIndex returns the index of the enum value with the name
+func fieldIndex(children []ir.DistilledNode, name string) int {
+	for i, child := range children {
+		if field, ok := child.(*ir.DistilledField); ok && field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// detach removes a child with the doc comments before it, returning the
+// remaining children and the removed nodes
+func detach(children []ir.DistilledNode, i int) ([]ir.DistilledNode, []ir.DistilledNode) {
+	j := i
+	for j > 0 {
+		if _, ok := children[j-1].(*ir.DistilledComment); !ok {
+			break
+		}
+		j--
+	}
+	removed := append([]ir.DistilledNode{}, children[j:i+1]...)
+	return append(children[:j:j], children[i+1:]...), removed
+}
+
+// setDocs replaces the doc comments before a child
+func setDocs(children []ir.DistilledNode, i int, doc []ir.DistilledNode) []ir.DistilledNode {
+	rest, removed := detach(children, i)
+	j := i - len(removed) + 1
+	nodes := append(doc[:len(doc):len(doc)], removed[len(removed)-1])
+	return append(rest[:j:j], append(nodes, rest[j:]...)...)
+}
+
+func addConstraint(table *ir.DistilledStruct, constraint string) {
+	ext := table.Extensions.SQL
+	ext.Constraints = append(ext.Constraints[:len(ext.Constraints):len(ext.Constraints)], constraint)
+}
+
+// dropConstraint removes a named constraint or index of a table, whether it
+// is a table constraint, a column constraint or an index
+func dropConstraint(table *ir.DistilledStruct, name string) {
+	ext := table.Extensions.SQL
+	var constraints []string
+	for _, constraint := range ext.Constraints {
+		if !strings.EqualFold(constraintName(constraint), name) {
+			constraints = append(constraints, constraint)
+		}
+	}
+	ext.Constraints = constraints
+
+	for i, child := range table.Children {
+		if field, ok := child.(*ir.DistilledField); ok && isColumn(child) {
+			var decorators []string
+			for _, decorator := range field.Decorators {
+				if !strings.EqualFold(constraintName(decorator), name) {
+					decorators = append(decorators, decorator)
+				}
+			}
+			if len(decorators) != len(field.Decorators) {
+				column := *field
+				column.Decorators = decorators
+				table.Children[i] = &column
+			}
+		}
+	}
+	if i := childIndex(table.Children, "index", name); i >= 0 {
+		table.Children, _ = detach(table.Children, i)
+	}
+}
+
+func dropPrimaryKey(table *ir.DistilledStruct) {
+	ext := table.Extensions.SQL
+	var constraints []string
+	for _, constraint := range ext.Constraints {
+		if !strings.Contains(strings.ToUpper(constraint), "PRIMARY KEY") {
+			constraints = append(constraints, constraint)
+		}
+	}
+	ext.Constraints = constraints
+
+	for i, child := range table.Children {
+		if field, ok := child.(*ir.DistilledField); ok && isColumn(child) {
+			var decorators []string
+			for _, decorator := range field.Decorators {
+				if !strings.Contains(strings.ToUpper(decorator), "PRIMARY KEY") {
+					decorators = append(decorators, decorator)
+				}
+			}
+			if len(decorators) != len(field.Decorators) {
+				column := *field
+				column.Decorators = decorators
+				table.Children[i] = &column
+			}
+		}
+	}
+}
+
+func renameConstraint(table *ir.DistilledStruct, old, name string) {
+	ext := table.Extensions.SQL
+	constraints := append([]string{}, ext.Constraints...)
+	for i, constraint := range constraints {
+		if strings.EqualFold(constraintName(constraint), old) {
+			constraints[i] = strings.Replace(constraint, old, name, 1)
+		}
+	}
+	ext.Constraints = constraints
+
+	if i := childIndex(table.Children, "index", old); i >= 0 {
+		table.Children[i] = withName(table.Children[i], name)
+	}
+}
+
+// constraintName returns the name of a constraint or MySQL index
+// definition, or "" if it has none
+func constraintName(constraint string) string {
+	words := strings.Fields(constraint)
+	for i, word := range words {
+		switch strings.ToUpper(word) {
+		case "CONSTRAINT":
+			if i+1 < len(words) {
+				return trimName(words[i+1])
+			}
+			return ""
+		case "UNIQUE", "FULLTEXT", "SPATIAL", "KEY", "INDEX":
+			continue
+		}
+		if i > 0 && !strings.HasPrefix(word, "(") {
+			return trimName(word)
+		}
+		return ""
+	}
+	return ""
+}
+
+func trimName(word string) string {
+	if i := strings.Index(word, "("); i >= 0 {
+		word = word[:i]
+	}
+	return strings.Trim(word, "\"`[]")
+}
+
+// without returns values without the given ones
+func without(values []string, remove ...string) []string {
+	var result []string
+	for _, value := range values {
+		keep := true
+		for _, r := range remove {
+			if strings.EqualFold(value, r) {
+				keep = false
+			}
+		}
+		if keep {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func sqlExtensions(node ir.DistilledNode) *ir.SQLExtensions {
+	var ext *ir.NodeExtensions
+	switch n := node.(type) {
+	case *ir.DistilledStruct:
+		ext = n.Extensions
+	case *ir.DistilledEnum:
+		ext = n.Extensions
+	case *ir.DistilledFunction:
+		ext = n.Extensions
+	case *ir.DistilledField:
+		ext = n.Extensions
+	}
+	if ext == nil {
+		return nil
+	}
+	return ext.SQL
+}
+
+func nodeName(node ir.DistilledNode) string {
+	switch n := node.(type) {
+	case *ir.DistilledStruct:
+		return n.Name
+	case *ir.DistilledEnum:
+		return n.Name
+	case *ir.DistilledFunction:
+		return n.Name
+	case *ir.DistilledField:
+		return n.Name
+	}
+	return ""
+}
+
+// withName returns a renamed copy of an index or trigger
+func withName(node ir.DistilledNode, name string) ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledField:
+		c := *n
+		c.Name = name
+		return &c
+	case *ir.DistilledFunction:
+		c := *n
+		c.Name = name
+		return &c
+	}
+	return node
+}
+
+// withExtensions returns a copy of an index or trigger with other
+// extensions
+func withExtensions(node ir.DistilledNode, ext *ir.SQLExtensions) ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledField:
+		c := *n
+		c.Extensions = &ir.NodeExtensions{SQL: ext}
+		return &c
+	case *ir.DistilledFunction:
+		c := *n
+		c.Extensions = &ir.NodeExtensions{SQL: ext}
+		return &c
+	}
+	return node
+}
+
+// cloneNode copies a top-level object deeply enough that changes applied
+// to it do not modify the original
+func cloneNode(node ir.DistilledNode) ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledStruct:
+		c := *n
+		c.Children = append([]ir.DistilledNode{}, n.Children...)
+		if ext := sqlExtensions(n); ext != nil {
+			sql := *ext
+			sql.Constraints = append([]string(nil), ext.Constraints...)
+			c.Extensions = &ir.NodeExtensions{SQL: &sql}
+		}
+		return &c
+	case *ir.DistilledEnum:
+		c := *n
+		c.Children = append([]ir.DistilledNode{}, n.Children...)
+		return &c
+	case *ir.DistilledFunction:
+		c := *n
+		return &c
+	case *ir.DistilledField:
+		c := *n
+		return &c
+	}
+	return node
+}
+
+// objectKind returns the kind of schema entry for a node kind
+func objectKind(kind string) string {
+	switch kind {
+	case "materialized_view":
+		return "view"
+	case "enum":
+		return "type"
+	}
+	return kind
+}
+
+// FoldSchema folds distilled SQL files into one file with the schema after
+// all of them have run in the given order. Changes a file could not apply
+// itself, such as an ALTER TABLE of a table created by an earlier
+// migration, are applied to the objects of the files before it. Doc
+// comments of objects are kept, other comments are dropped.
+func FoldSchema(path string, files []*ir.DistilledFile) *ir.DistilledFile {
+	folded := &ir.DistilledFile{
+		Path:     path,
+		Language: "sql",
+		Children: []ir.DistilledNode{},
+		Errors:   []ir.DistilledError{},
+	}
+	s := &schema{}
+	for _, file := range files {
+		var docs []ir.DistilledNode
+		for _, child := range file.Children {
+			if comment, ok := child.(*ir.DistilledComment); ok {
+				if comment.Format == "doc" {
+					docs = append(docs, comment)
+				}
+				continue
+			}
+
+			ext := sqlExtensions(child)
+			switch {
+			case ext == nil:
+				s.add(append(docs, child)...)
+			case ext.Statement != "":
+				src := []byte(ext.Statement)
+				newParser(src, folded, s).parse(tokenize(src))
+			case ext.Kind == "index" || ext.Kind == "trigger":
+				s.attach(ext.Table, &entry{kind: ext.Kind, name: nodeName(child), docs: docs, node: child})
+			default:
+				e := &entry{kind: objectKind(ext.Kind), name: nodeName(child), docs: docs, node: cloneNode(child)}
+				if fn, ok := child.(*ir.DistilledFunction); ok {
+					e.args = signature(fn.Parameters)
+				}
+				s.define(e)
+			}
+			docs = nil
+		}
+
+		for _, err := range file.Errors {
+			err.Message = file.Path + ": " + err.Message
+			folded.Errors = append(folded.Errors, err)
+		}
+	}
+	folded.Children = append(folded.Children, s.nodes()...)
+	return folded
+}
+
+// FoldDirectory replaces the SQL files of each directory with a single
+// file with their folded schema, see FoldSchema. The files are folded in
+// the natural order of their names, so V2__users.sql comes before
+// V10__orders.sql. Down migrations are left out, and the up.sql files of
+// migration directories are folded with the other migrations of the
+// parent directory. Only directories that look like migrations are
+// folded, see isMigrations; others, and directories with a single SQL
+// file, are not changed.
+func FoldDirectory(dir *ir.DistilledDirectory) *ir.DistilledDirectory {
+	groups := map[string][]*ir.DistilledFile{}
+	for _, child := range dir.Children {
+		if file, ok := child.(*ir.DistilledFile); ok && file.Language == "sql" {
+			key := migrationDir(file.Path)
+			groups[key] = append(groups[key], file)
+		}
+	}
+	for key, files := range groups {
+		if !isMigrations(files) {
+			delete(groups, key)
+		}
+	}
+
+	result := &ir.DistilledDirectory{BaseNode: dir.BaseNode, Path: dir.Path}
+	done := map[string]bool{}
+	for _, child := range dir.Children {
+		file, ok := child.(*ir.DistilledFile)
+		if !ok || file.Language != "sql" || len(groups[migrationDir(file.Path)]) < 2 {
+			result.Children = append(result.Children, child)
+			continue
+		}
+
+		key := migrationDir(file.Path)
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		var files []*ir.DistilledFile
+		for _, f := range groups[key] {
+			if !isDownMigration(f.Path) {
+				files = append(files, f)
+			}
+		}
+		sort.SliceStable(files, func(i, j int) bool { return naturalLess(files[i].Path, files[j].Path) })
+		result.Children = append(result.Children, FoldSchema(filepath.Join(key, "*.sql"), files))
+	}
+	return result
+}
+
+// versionPrefix matches the version that starts the name of a migration,
+// e.g. V2__users.sql, 001_init.sql or 20240101120000_orders
+var versionPrefix = regexp.MustCompile(`^[vV]?[0-9]`)
+
+// isMigrations reports whether SQL files of a directory are migrations:
+// every name starts with a version, or a file alters existing objects.
+// Other SQL files, such as a folder of queries, are distilled one by one.
+func isMigrations(files []*ir.DistilledFile) bool {
+	if len(files) < 2 {
+		return false
+	}
+	versioned := true
+	for _, file := range files {
+		name := filepath.Base(file.Path)
+		if base := strings.ToLower(name); base == "up.sql" || base == "down.sql" {
+			name = filepath.Base(filepath.Dir(file.Path))
+		}
+		versioned = versioned && versionPrefix.MatchString(name)
+
+		for _, child := range file.Children {
+			if ext := sqlExtensions(child); ext != nil && hasKeyword(ext.Statement, "ALTER") {
+				return true
+			}
+		}
+	}
+	return versioned
+}
+
+// hasKeyword reports whether a statement starts with a keyword
+func hasKeyword(statement, keyword string) bool {
+	return len(statement) > len(keyword) && strings.EqualFold(statement[:len(keyword)], keyword) &&
+		(statement[len(keyword)] == ' ' || statement[len(keyword)] == '\t' || statement[len(keyword)] == '\n')
+}
+
+// migrationDir returns the directory whose SQL files are folded together
+func migrationDir(path string) string {
+	dir := filepath.Dir(path)
+	switch strings.ToLower(filepath.Base(path)) {
+	case "up.sql", "down.sql":
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+func isDownMigration(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return base == "down.sql" || strings.HasSuffix(base, ".down.sql") || strings.HasSuffix(base, "_down.sql")
+}
+
+// naturalLess compares strings with numbers in them by the value of the
+// numbers
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ca, cb := chunk(a), chunk(b)
+		if ca != cb {
+			if isDigit(ca[0]) && isDigit(cb[0]) {
+				na, nb := strings.TrimLeft(ca, "0"), strings.TrimLeft(cb, "0")
+				if len(na) != len(nb) {
+					return len(na) < len(nb)
+				}
+				if na != nb {
+					return na < nb
+				}
+			} else {
+				return ca < cb
+			}
+		}
+		a, b = a[len(ca):], b[len(cb):]
+	}
+	return len(a) < len(b)
+}
+
+// chunk returns the leading run of digits or non-digits of s
+func chunk(s string) string {
+	i := 1
+	for i < len(s) && isDigit(s[i]) == isDigit(s[0]) {
+		i++
+	}
+	return s[:i]
+}
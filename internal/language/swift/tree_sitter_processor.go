@@ -7,7 +7,7 @@ import (
 
 	"github.com/janreges/ai-distiller/internal/ir"
 	sitter "github.com/smacker/go-tree-sitter"
-	swift "tree-sitter-swift"
+	swift "github.com/janreges/ai-distiller/internal/parser/grammars/tree-sitter-swift"
 )
 
 // TreeSitterProcessor processes Swift using tree-sitter
@@ -8,7 +8,7 @@ import (
 
 	"github.com/janreges/ai-distiller/internal/ir"
 	sitter "github.com/smacker/go-tree-sitter"
-	typescript "tree-sitter-typescript"
+	typescript "github.com/janreges/ai-distiller/internal/parser/grammars/tree-sitter-typescript"
 )
 
 // ASTParser provides tree-sitter based TypeScript parsing
@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Arguments holds the decoded arguments of a tool call
+type Arguments map[string]any
+
+// String returns a string argument, or "" when it is missing
+func (a Arguments) String(name string) string {
+	value, _ := a[name].(string)
+	return value
+}
+
+// Bool returns a boolean argument, or defaultValue when it is missing
+func (a Arguments) Bool(name string, defaultValue bool) bool {
+	if value, ok := a[name].(bool); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Has reports whether an argument was provided
+func (a Arguments) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// validate checks required arguments, types and enum values against the schema
+func (s InputSchema) validate(args Arguments) error {
+	for _, name := range s.Required {
+		if !args.Has(name) {
+			return fmt.Errorf("missing required argument %q", name)
+		}
+	}
+
+	for name, value := range args {
+		prop, ok := s.Properties[name]
+		if !ok {
+			continue // Unknown arguments are ignored
+		}
+
+		switch prop.Type {
+		case "string":
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("argument %q must be a string", name)
+			}
+			if len(prop.Enum) > 0 && !containsString(prop.Enum, str) {
+				return fmt.Errorf("argument %q must be one of: %s", name, strings.Join(prop.Enum, ", "))
+			}
+		case "boolean":
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("argument %q must be a boolean", name)
+			}
+		case "number", "integer":
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("argument %q must be a number", name)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
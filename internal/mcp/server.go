@@ -0,0 +1,296 @@
+// Package mcp implements a Model Context Protocol server over stdio.
+//
+// Messages are newline-delimited JSON-RPC 2.0 objects, as required by the
+// MCP stdio transport. Only the tools capability is supported.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+)
+
+// ProtocolVersion is the latest MCP revision implemented by the server
+const ProtocolVersion = "2025-03-26"
+
+// supportedVersions lists the protocol revisions the server can speak
+var supportedVersions = []string{ProtocolVersion, "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// ToolHandler runs a tool call and returns its text result. Returned errors
+// are reported to the client as tool errors rather than protocol errors.
+type ToolHandler func(ctx context.Context, args Arguments) (string, error)
+
+// Property describes a single tool argument
+type Property struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// InputSchema is the JSON Schema of a tool's arguments
+type InputSchema struct {
+	Type       string              `json:"type"`
+	Properties map[string]Property `json:"properties"`
+	Required   []string            `json:"required,omitempty"`
+}
+
+// Tool is a callable tool exposed by the server
+type Tool struct {
+	Name        string      `json:"name"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description"`
+	InputSchema InputSchema `json:"inputSchema"`
+	Handler     ToolHandler `json:"-"`
+}
+
+// Server dispatches MCP requests to registered tools
+type Server struct {
+	name    string
+	version string
+	tools   map[string]*Tool
+
+	writeMu sync.Mutex
+}
+
+// NewServer creates a server that identifies itself with name and version
+func NewServer(name, version string) *Server {
+	return &Server{
+		name:    name,
+		version: version,
+		tools:   make(map[string]*Tool),
+	}
+}
+
+// AddTool registers a tool, replacing any tool with the same name
+func (s *Server) AddTool(tool Tool) {
+	if tool.InputSchema.Type == "" {
+		tool.InputSchema.Type = "object"
+	}
+	if tool.InputSchema.Properties == nil {
+		tool.InputSchema.Properties = map[string]Property{}
+	}
+	s.tools[tool.Name] = &tool
+}
+
+// Tools returns the registered tools sorted by name
+func (s *Server) Tools() []Tool {
+	tools := make([]Tool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, *tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	return tools
+}
+
+// request is an incoming JSON-RPC request or notification
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads requests from r and writes responses to w until r is exhausted
+// or ctx is cancelled. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	dbg := debug.FromContext(ctx).WithSubsystem("mcp")
+	reader := bufio.NewReader(r)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handleMessage(ctx, line); resp != nil {
+				if writeErr := s.write(w, resp); writeErr != nil {
+					return writeErr
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			dbg.Logf(debug.LevelBasic, "Input closed, shutting down")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleMessage processes one raw message and returns the response to send, if any
+func (s *Server) handleMessage(ctx context.Context, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error: " + err.Error()}}
+	}
+
+	// Notifications carry no ID and never get a response
+	isNotification := len(req.ID) == 0 || string(req.ID) == "null"
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if isNotification {
+			return nil
+		}
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{codeInvalidRequest, "invalid request"}}
+	}
+
+	debug.FromContext(ctx).WithSubsystem("mcp").Logf(debug.LevelDetailed, "<- %s", req.Method)
+
+	result, err := s.dispatch(ctx, &req)
+	if isNotification {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{codeInternalError, err.Error()}
+		}
+		resp.Result = nil
+		resp.Error = rpcErr
+	}
+	return resp
+}
+
+// dispatch routes a request to its method handler
+func (s *Server) dispatch(ctx context.Context, req *request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.Tools()}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	default:
+		return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+// initialize negotiates the protocol version and advertises capabilities
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, "invalid initialize params: " + err.Error()}
+		}
+	}
+
+	version := ProtocolVersion
+	for _, supported := range supportedVersions {
+		if p.ProtocolVersion == supported {
+			version = supported
+			break
+		}
+	}
+
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools": map[string]any{"listChanged": false},
+		},
+		"serverInfo": map[string]any{
+			"name":    s.name,
+			"version": s.version,
+		},
+	}, nil
+}
+
+// toolResult is the result of a tools/call request
+type toolResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callTool validates the arguments and runs the requested tool
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{codeInvalidParams, "invalid tools/call params: " + err.Error()}
+	}
+
+	tool, ok := s.tools[p.Name]
+	if !ok {
+		return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool: %s", p.Name)}
+	}
+
+	args := Arguments{}
+	if len(p.Arguments) > 0 && string(p.Arguments) != "null" {
+		if err := json.Unmarshal(p.Arguments, &args); err != nil {
+			return nil, &rpcError{codeInvalidParams, "arguments must be an object: " + err.Error()}
+		}
+	}
+	if err := tool.InputSchema.validate(args); err != nil {
+		return nil, &rpcError{codeInvalidParams, fmt.Sprintf("invalid arguments for %s: %v", p.Name, err)}
+	}
+
+	text, err := tool.Handler(ctx, args)
+	if err != nil {
+		return toolResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	return toolResult{Content: []textContent{{Type: "text", Text: text}}}, nil
+}
+
+// write sends a single response as one line of JSON
+func (s *Server) write(w io.Writer, resp *response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
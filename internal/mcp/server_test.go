@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer() *Server {
+	server := NewServer("test", "1.0.0")
+	server.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echo the message",
+		InputSchema: InputSchema{
+			Properties: map[string]Property{
+				"message": {Type: "string"},
+				"mode":    {Type: "string", Enum: []string{"plain", "upper"}},
+				"fail":    {Type: "boolean"},
+			},
+			Required: []string{"message"},
+		},
+		Handler: func(ctx context.Context, args Arguments) (string, error) {
+			if args.Bool("fail", false) {
+				return "", errors.New("tool failed")
+			}
+			if args.String("mode") == "upper" {
+				return strings.ToUpper(args.String("message")), nil
+			}
+			return args.String("message"), nil
+		},
+	})
+	return server
+}
+
+// serve runs the server over the given input lines and returns decoded responses
+func serve(t *testing.T, server *Server, lines ...string) []map[string]any {
+	t.Helper()
+
+	var out strings.Builder
+	err := server.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")), &out)
+	require.NoError(t, err)
+
+	var responses []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServerInitialize(t *testing.T) {
+	responses := serve(t, newTestServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+	)
+	require.Len(t, responses, 2)
+
+	result := responses[0]["result"].(map[string]any)
+	assert.Equal(t, "2024-11-05", result["protocolVersion"])
+	assert.Equal(t, map[string]any{"name": "test", "version": "1.0.0"}, result["serverInfo"])
+	assert.Contains(t, result["capabilities"], "tools")
+
+	// Unknown versions fall back to the latest supported one
+	assert.Equal(t, ProtocolVersion, responses[1]["result"].(map[string]any)["protocolVersion"])
+}
+
+func TestServerToolsList(t *testing.T) {
+	responses := serve(t, newTestServer(), `{"jsonrpc":"2.0","id":"a","method":"tools/list"}`)
+	require.Len(t, responses, 1)
+	assert.Equal(t, "a", responses[0]["id"])
+
+	tools := responses[0]["result"].(map[string]any)["tools"].([]any)
+	require.Len(t, tools, 1)
+	tool := tools[0].(map[string]any)
+	assert.Equal(t, "echo", tool["name"])
+	assert.Equal(t, "object", tool["inputSchema"].(map[string]any)["type"])
+}
+
+func TestServerToolsCall(t *testing.T) {
+	responses := serve(t, newTestServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi","mode":"upper"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi","fail":true}}}`,
+	)
+	require.Len(t, responses, 2)
+
+	result := responses[0]["result"].(map[string]any)
+	assert.Nil(t, result["isError"])
+	assert.Equal(t, "HI", result["content"].([]any)[0].(map[string]any)["text"])
+
+	// Handler errors are tool results, not protocol errors
+	result = responses[1]["result"].(map[string]any)
+	assert.Equal(t, true, result["isError"])
+	assert.Equal(t, "tool failed", result["content"].([]any)[0].(map[string]any)["text"])
+}
+
+func TestServerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		code float64
+	}{
+		{"parse error", `{not json`, codeParseError},
+		{"invalid request", `{"jsonrpc":"1.0","id":1,"method":"ping"}`, codeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, codeMethodNotFound},
+		{"unknown tool", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, codeInvalidParams},
+		{"missing argument", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{}}}`, codeInvalidParams},
+		{"wrong type", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"message":1}}}`, codeInvalidParams},
+		{"enum violation", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"message":"x","mode":"lower"}}}`, codeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := serve(t, newTestServer(), tt.line)
+			require.Len(t, responses, 1)
+			require.Contains(t, responses[0], "error")
+			assert.Equal(t, tt.code, responses[0]["error"].(map[string]any)["code"])
+			assert.NotContains(t, responses[0], "result")
+		})
+	}
+}
+
+func TestServerNotifications(t *testing.T) {
+	responses := serve(t, newTestServer(),
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","method":"unknown/notification"}`,
+		``,
+		`{"jsonrpc":"2.0","id":7,"method":"ping"}`,
+	)
+	require.Len(t, responses, 1)
+	assert.Equal(t, float64(7), responses[0]["id"])
+	assert.Equal(t, map[string]any{}, responses[0]["result"])
+}
@@ -1,3 +0,0 @@
-module tree-sitter-swift
-
-go 1.23
@@ -1,3 +0,0 @@
-module tree-sitter-typescript
-
-go 1.23
\ No newline at end of file
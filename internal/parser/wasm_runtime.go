@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -128,11 +129,11 @@ func (w *WASMRuntime) Close() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	
-	// Close all modules
+	// Close all modules, collecting errors but continuing with the others
+	var errs []error
 	for name, module := range w.modules {
 		if err := module.Module.Close(w.ctx); err != nil {
-			// Log error but continue closing others
-			fmt.Printf("Error closing module %s: %v\n", name, err)
+			errs = append(errs, fmt.Errorf("error closing module %s: %w", name, err))
 		}
 	}
 	
@@ -140,7 +141,10 @@ func (w *WASMRuntime) Close() error {
 	w.modules = make(map[string]*WASMModule)
 	
 	// Close runtime
-	return w.runtime.Close(w.ctx)
+	if err := w.runtime.Close(w.ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
 // loadFunctions loads function exports from the module
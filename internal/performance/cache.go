@@ -1,12 +1,16 @@
 package performance
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/gob"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,7 +18,28 @@ import (
 	"github.com/janreges/ai-distiller/internal/processor"
 )
 
-// Cache provides intelligent caching for parsed files
+func init() {
+	// Results are stored with gob, which needs every concrete node type
+	// that can appear behind the ir.DistilledNode interface
+	gob.Register(&ir.DistilledFile{})
+	gob.Register(&ir.DistilledDirectory{})
+	gob.Register(&ir.DistilledPackage{})
+	gob.Register(&ir.DistilledImport{})
+	gob.Register(&ir.DistilledClass{})
+	gob.Register(&ir.DistilledInterface{})
+	gob.Register(&ir.DistilledStruct{})
+	gob.Register(&ir.DistilledEnum{})
+	gob.Register(&ir.DistilledTypeAlias{})
+	gob.Register(&ir.DistilledFunction{})
+	gob.Register(&ir.DistilledField{})
+	gob.Register(&ir.DistilledComment{})
+	gob.Register(&ir.DistilledRawContent{})
+	gob.Register(&ir.DistilledError{})
+}
+
+// Cache provides intelligent caching for parsed files.
+// Entries are keyed by file content, language processor version and
+// processing options, so edited files are never served from the cache.
 type Cache struct {
 	dir           string
 	maxSize       int64
@@ -23,6 +48,8 @@ type Cache struct {
 	metrics       *CacheMetrics
 	mutex         sync.RWMutex
 	index         map[string]*CacheEntry
+	version       string
+	dirty         bool
 }
 
 // CacheEntry represents a cached file entry
@@ -31,6 +58,7 @@ type CacheEntry struct {
 	FilePath     string    `json:"file_path"`
 	FileSize     int64     `json:"file_size"`
 	FileModTime  time.Time `json:"file_mod_time"`
+	ContentHash  string    `json:"content_hash"`
 	Language     string    `json:"language"`
 	Version      string    `json:"version"`
 	CachedAt     time.Time `json:"cached_at"`
@@ -84,6 +112,18 @@ func (c *Cache) WithMaxAge(age time.Duration) *Cache {
 	return c
 }
 
+// WithVersion sets the application version that is part of every cache key,
+// so results produced by a different build are never reused
+func (c *Cache) WithVersion(version string) *Cache {
+	c.version = version
+	return c
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
 // WithMetrics enables or disables cache metrics
 func (c *Cache) WithMetrics(enabled bool) *Cache {
 	c.enableMetrics = enabled
@@ -94,7 +134,13 @@ func (c *Cache) WithMetrics(enabled bool) *Cache {
 func (c *Cache) Get(filePath string, opts processor.ProcessOptions) (*ir.DistilledFile, bool) {
 	startTime := time.Now()
 
-	key := c.generateKey(filePath, opts)
+	// The content hash is part of the key, so a modified file simply misses
+	contentHash, err := hashFile(filePath)
+	if err != nil {
+		c.recordMiss()
+		return nil, false
+	}
+	key := c.generateKey(filePath, opts, contentHash)
 
 	c.mutex.RLock()
 	entry, exists := c.index[key]
@@ -105,15 +151,6 @@ func (c *Cache) Get(filePath string, opts processor.ProcessOptions) (*ir.Distill
 		return nil, false
 	}
 
-	// Check if file has been modified
-	info, err := os.Stat(filePath)
-	if err != nil || info.ModTime().After(entry.FileModTime) {
-		// File modified, invalidate cache
-		_ = c.Remove(key)
-		c.recordMiss()
-		return nil, false
-	}
-
 	// Check if cache entry is too old
 	if time.Since(entry.CachedAt) > c.maxAge {
 		_ = c.Remove(key)
@@ -140,16 +177,20 @@ func (c *Cache) Get(filePath string, opts processor.ProcessOptions) (*ir.Distill
 
 // Put stores a result in the cache
 func (c *Cache) Put(filePath string, opts processor.ProcessOptions, result *ir.DistilledFile) error {
-	key := c.generateKey(filePath, opts)
-
 	// Get file info
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to stat file: %w", err)
 	}
 
+	contentHash, err := hashFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+	key := c.generateKey(filePath, opts, contentHash)
+
 	// Store result
-	resultPath := filepath.Join(c.dir, key+".json")
+	resultPath := filepath.Join(c.dir, key+".gob")
 	if err := c.storeCachedResult(result, resultPath); err != nil {
 		return fmt.Errorf("failed to store result: %w", err)
 	}
@@ -166,6 +207,7 @@ func (c *Cache) Put(filePath string, opts processor.ProcessOptions, result *ir.D
 		FilePath:    filePath,
 		FileSize:    info.Size(),
 		FileModTime: info.ModTime(),
+		ContentHash: contentHash,
 		Language:    result.Language,
 		Version:     result.Version,
 		CachedAt:    time.Now(),
@@ -176,13 +218,36 @@ func (c *Cache) Put(filePath string, opts processor.ProcessOptions, result *ir.D
 		ResultSize:  resultInfo.Size(),
 	}
 
+	// Drop results for older contents of the same file; they can never hit again
+	var stale []string
+	c.mutex.RLock()
+	for otherKey, other := range c.index {
+		if otherKey != key && other.FilePath == entry.FilePath && other.Options == entry.Options {
+			stale = append(stale, otherKey)
+		}
+	}
+	c.mutex.RUnlock()
+	for _, staleKey := range stale {
+		_ = c.Remove(staleKey)
+	}
+
 	// Add to index
 	c.mutex.Lock()
+	previous, replaced := c.index[key]
 	c.index[key] = entry
+	c.dirty = true
 	c.mutex.Unlock()
 
-	// Save index
-	c.saveIndex()
+	if c.enableMetrics {
+		c.metrics.mutex.Lock()
+		if replaced {
+			c.metrics.TotalSize -= previous.ResultSize
+		} else {
+			c.metrics.EntryCount++
+		}
+		c.metrics.TotalSize += entry.ResultSize
+		c.metrics.mutex.Unlock()
+	}
 
 	// Check size limits and evict if necessary
 	c.evictIfNecessary()
@@ -190,6 +255,33 @@ func (c *Cache) Put(filePath string, opts processor.ProcessOptions, result *ir.D
 	return nil
 }
 
+// Flush writes the cache index to disk if it changed since the last flush
+func (c *Cache) Flush() error {
+	c.mutex.RLock()
+	dirty := c.dirty
+	c.mutex.RUnlock()
+
+	if !dirty {
+		return nil
+	}
+	return c.saveIndex()
+}
+
+// Entries returns a snapshot of all cache entries, most recently used first
+func (c *Cache) Entries() []CacheEntry {
+	c.mutex.RLock()
+	entries := make([]CacheEntry, 0, len(c.index))
+	for _, entry := range c.index {
+		entries = append(entries, *entry)
+	}
+	c.mutex.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastAccess.After(entries[j].LastAccess)
+	})
+	return entries
+}
+
 // Remove removes an entry from the cache
 func (c *Cache) Remove(key string) error {
 	c.mutex.Lock()
@@ -207,6 +299,7 @@ func (c *Cache) Remove(key string) error {
 
 	// Remove from index
 	delete(c.index, key)
+	c.dirty = true
 
 	// Update metrics
 	if c.enableMetrics {
@@ -231,6 +324,7 @@ func (c *Cache) Clear() error {
 		}
 		delete(c.index, key)
 	}
+	c.dirty = true
 
 	// Reset metrics
 	if c.enableMetrics {
@@ -241,9 +335,7 @@ func (c *Cache) Clear() error {
 	}
 
 	// Save empty index
-	c.saveIndex()
-
-	return nil
+	return c.writeIndex()
 }
 
 // Stats returns cache statistics
@@ -266,45 +358,90 @@ func (c *Cache) Stats() *CacheMetrics {
 	}
 }
 
-// generateKey creates a unique key for the file and options
-func (c *Cache) generateKey(filePath string, opts processor.ProcessOptions) string {
+// generateKey creates a unique key for the file contents, processor and options
+func (c *Cache) generateKey(filePath string, opts processor.ProcessOptions, contentHash string) string {
+	return resultKey(filePath, opts, contentHash, c.version)
+}
+
+// serializeOptions converts options to a string for caching
+func (c *Cache) serializeOptions(opts processor.ProcessOptions) string {
+	return serializeOptions(opts)
+}
+
+// resultKey identifies a processing result by file path and contents, the
+// language processor and its version, the application version and the
+// options. It is shared by the disk and in-memory caches.
+func resultKey(filePath string, opts processor.ProcessOptions, contentHash, appVersion string) string {
+	language, version := "", ""
+	if proc, ok := processor.ForFile(filePath, opts); ok {
+		language, version = proc.Language(), proc.Version()
+	}
+
 	h := sha256.New()
-	h.Write([]byte(filePath))
-	h.Write([]byte(c.serializeOptions(opts)))
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00", filePath, contentHash, language, version, appVersion)
+	h.Write([]byte(serializeOptions(opts)))
 	return hex.EncodeToString(h.Sum(nil))[:16] // Use first 16 chars
 }
 
 // serializeOptions converts options to a string for caching
-func (c *Cache) serializeOptions(opts processor.ProcessOptions) string {
+func serializeOptions(opts processor.ProcessOptions) string {
+	// The number of workers does not change the result
+	opts.Workers = 0
 	data, _ := json.Marshal(opts)
 	return string(data)
 }
 
+// hashFile returns the SHA-256 of a file's contents
+func hashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // loadCachedResult loads a result from disk
 func (c *Cache) loadCachedResult(entry *CacheEntry) (*ir.DistilledFile, error) {
 	data, err := os.ReadFile(entry.ResultPath)
 	if err != nil {
 		return nil, err
 	}
-
-	var result ir.DistilledFile
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return decodeResult(data)
 }
 
 // storeCachedResult stores a result to disk
 func (c *Cache) storeCachedResult(result *ir.DistilledFile, path string) error {
-	data, err := json.MarshalIndent(result, "", "  ")
+	data, err := encodeResult(result)
 	if err != nil {
 		return err
 	}
-
 	return os.WriteFile(path, data, 0644)
 }
 
+// encodeResult serializes a result with gob
+func encodeResult(result *ir.DistilledFile) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(result); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeResult restores a result written by encodeResult
+func decodeResult(data []byte) (*ir.DistilledFile, error) {
+	var result ir.DistilledFile
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // updateAccess updates access statistics for an entry
 func (c *Cache) updateAccess(entry *CacheEntry) {
 	c.mutex.Lock()
@@ -312,6 +449,7 @@ func (c *Cache) updateAccess(entry *CacheEntry) {
 
 	entry.AccessCount++
 	entry.LastAccess = time.Now()
+	c.dirty = true
 }
 
 // recordHit records a cache hit
@@ -394,6 +532,7 @@ func (c *Cache) evictIfNecessary() {
 		
 		// Remove from index
 		delete(c.index, entryWithKey.key)
+		c.dirty = true
 		
 		totalSize -= entry.ResultSize
 		
@@ -406,9 +545,6 @@ func (c *Cache) evictIfNecessary() {
 			c.metrics.mutex.Unlock()
 		}
 	}
-
-	// Save updated index
-	c.saveIndex()
 }
 
 // loadIndex loads the cache index from disk
@@ -447,18 +583,32 @@ func (c *Cache) loadIndex() {
 }
 
 // saveIndex saves the cache index to disk
-func (c *Cache) saveIndex() {
+func (c *Cache) saveIndex() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.writeIndex()
+}
+
+// writeIndex writes the index atomically; the caller must hold the lock
+func (c *Cache) writeIndex() error {
 	indexPath := filepath.Join(c.dir, "index.json")
-	
-	c.mutex.RLock()
+
 	data, err := json.MarshalIndent(c.index, "", "  ")
-	c.mutex.RUnlock()
-	
 	if err != nil {
-		return
+		return err
 	}
 
-	_ = os.WriteFile(indexPath, data, 0644)
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
 }
 
 // GetHitRate returns the cache hit rate as a percentage
@@ -526,6 +676,8 @@ func (p *CachedProcessor) ProcessFile(filePath string, opts processor.ProcessOpt
 	if err := p.cache.Put(filePath, opts, result); err != nil {
 		// Log warning but don't fail the operation
 		fmt.Fprintf(os.Stderr, "Warning: failed to cache result for %s: %v\n", filePath, err)
+	} else if err := p.cache.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save cache index: %v\n", err)
 	}
 
 	return result, nil
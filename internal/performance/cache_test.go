@@ -0,0 +1,109 @@
+package performance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleResult(path string) *ir.DistilledFile {
+	return &ir.DistilledFile{
+		Path:     path,
+		Language: "text",
+		Version:  "1.0.0",
+		Children: []ir.DistilledNode{
+			&ir.DistilledClass{
+				Name:       "Service",
+				Visibility: ir.VisibilityPublic,
+				Children: []ir.DistilledNode{
+					&ir.DistilledFunction{
+						Name:       "Run",
+						Visibility: ir.VisibilityPublic,
+						Parameters: []ir.Parameter{{Name: "ctx", Type: ir.TypeRef{Name: "Context"}}},
+						Returns:    &ir.TypeRef{Name: "error"},
+					},
+				},
+			},
+			&ir.DistilledComment{Text: "note", Format: "line"},
+		},
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("hello"), 0644))
+
+	opts := processor.ProcessOptions{RawMode: true}
+	cache := NewCache(filepath.Join(dir, "cache"))
+
+	_, hit := cache.Get(file, opts)
+	assert.False(t, hit)
+
+	require.NoError(t, cache.Put(file, opts, sampleResult("notes.txt")))
+	require.NoError(t, cache.Flush())
+
+	// A fresh instance must load the persisted index and result
+	reopened := NewCache(filepath.Join(dir, "cache"))
+	result, hit := reopened.Get(file, opts)
+	require.True(t, hit)
+	assert.Equal(t, sampleResult("notes.txt"), result)
+
+	stats := reopened.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.EntryCount)
+}
+
+func TestCacheKeyedByContentAndOptions(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0644))
+
+	opts := processor.ProcessOptions{RawMode: true}
+	cache := NewCache(filepath.Join(dir, "cache"))
+	require.NoError(t, cache.Put(file, opts, sampleResult("notes.txt")))
+
+	// Different options miss
+	otherOpts := opts
+	otherOpts.IncludeComments = true
+	_, hit := cache.Get(file, otherOpts)
+	assert.False(t, hit)
+
+	// The worker count does not affect the result
+	workerOpts := opts
+	workerOpts.Workers = 8
+	_, hit = cache.Get(file, workerOpts)
+	assert.True(t, hit)
+
+	// A different application version misses
+	_, hit = NewCache(filepath.Join(dir, "cache")).WithVersion("other").Get(file, opts)
+	assert.False(t, hit)
+
+	// Changed contents miss, and storing them drops the stale entry
+	require.NoError(t, os.WriteFile(file, []byte("v2"), 0644))
+	_, hit = cache.Get(file, opts)
+	assert.False(t, hit)
+
+	require.NoError(t, cache.Put(file, opts, sampleResult("notes.txt")))
+	assert.Len(t, cache.Entries(), 1)
+}
+
+func TestCacheClear(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("hello"), 0644))
+
+	opts := processor.ProcessOptions{RawMode: true}
+	cache := NewCache(filepath.Join(dir, "cache"))
+	require.NoError(t, cache.Put(file, opts, sampleResult("notes.txt")))
+	require.NoError(t, cache.Clear())
+
+	assert.Empty(t, NewCache(filepath.Join(dir, "cache")).Entries())
+	_, hit := cache.Get(file, opts)
+	assert.False(t, hit)
+}
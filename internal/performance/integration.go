@@ -356,13 +356,13 @@ func (p *PerformanceProcessor) GetMetrics() *PerformanceMetrics {
 
 	// Concurrent processor metrics
 	if concurrentMetrics := p.concurrentProcessor.GetMetrics(); concurrentMetrics != nil {
-		metrics.ConcurrentMetrics = *concurrentMetrics
+		metrics.ConcurrentMetrics = concurrentMetrics
 	}
 
 	// Cache metrics
 	if p.config.CacheEnabled {
 		if cacheStats := p.cachedProcessor.GetCache().Stats(); cacheStats != nil {
-			metrics.CacheMetrics = *cacheStats
+			metrics.CacheMetrics = cacheStats
 		}
 	}
 
@@ -371,16 +371,25 @@ func (p *PerformanceProcessor) GetMetrics() *PerformanceMetrics {
 
 // PerformanceMetrics combines all performance metrics
 type PerformanceMetrics struct {
-	ConcurrentMetrics ProcessingMetrics
-	CacheMetrics      CacheMetrics
+	ConcurrentMetrics *ProcessingMetrics
+	CacheMetrics      *CacheMetrics
 }
 
 // String returns formatted performance metrics
 func (m *PerformanceMetrics) String() string {
+	concurrent := &ProcessingMetrics{}
+	if m.ConcurrentMetrics != nil {
+		concurrent = m.ConcurrentMetrics
+	}
+	cache := &CacheMetrics{}
+	if m.CacheMetrics != nil {
+		cache = m.CacheMetrics
+	}
+
 	return fmt.Sprintf(
 		"=== Performance Metrics ===\n\n%s\n\n%s",
-		m.ConcurrentMetrics.String(),
-		m.CacheMetrics.String(),
+		concurrent.String(),
+		cache.String(),
 	)
 }
 
@@ -0,0 +1,110 @@
+package performance
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+)
+
+// MemoryCache keeps processed files in memory for long-running processes such
+// as the MCP server. Entries use the same key and gob encoding as Cache, so a
+// result is reused only for the same file contents, processor version and
+// options, and every Get returns a deep copy that callers may modify freely.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+	version string
+	hits    int64
+	misses  int64
+}
+
+// memoryCacheEntry holds the latest result for one file and set of options
+type memoryCacheEntry struct {
+	key    string
+	result []byte
+}
+
+// NewMemoryCache creates an empty in-memory result cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryCacheEntry),
+	}
+}
+
+// WithVersion sets the application version that is part of every cache key
+func (c *MemoryCache) WithVersion(version string) *MemoryCache {
+	c.version = version
+	return c
+}
+
+// memorySlot names the entry for a file and options; a newer result for other
+// contents of the same file replaces the older one
+func memorySlot(filePath string, opts processor.ProcessOptions) string {
+	return filePath + "\x00" + serializeOptions(opts)
+}
+
+// Get implements processor.ResultCache
+func (c *MemoryCache) Get(filePath string, opts processor.ProcessOptions) (*ir.DistilledFile, bool) {
+	contentHash, err := hashFile(filePath)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[memorySlot(filePath, opts)]
+	if !ok || err != nil || entry.key != resultKey(filePath, opts, contentHash, c.version) {
+		c.misses++
+		return nil, false
+	}
+
+	result, err := decodeResult(entry.result)
+	if err != nil {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return result, true
+}
+
+// Put implements processor.ResultCache
+func (c *MemoryCache) Put(filePath string, opts processor.ProcessOptions, result *ir.DistilledFile) error {
+	contentHash, err := hashFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	stored, err := encodeResult(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[memorySlot(filePath, opts)] = memoryCacheEntry{
+		key:    resultKey(filePath, opts, contentHash, c.version),
+		result: stored,
+	}
+	return nil
+}
+
+// Len returns the number of cached results
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Stats returns the number of cache hits and misses so far
+func (c *MemoryCache) Stats() (hits, misses int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hits, c.misses
+}
+
+// Clear removes all cached results
+func (c *MemoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]memoryCacheEntry)
+}
@@ -0,0 +1,68 @@
+package performance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	cache := NewMemoryCache().WithVersion("test")
+	opts := processor.ProcessOptions{IncludeComments: true}
+
+	_, ok := cache.Get(path, opts)
+	assert.False(t, ok, "expected miss on empty cache")
+
+	file := sampleResult(path)
+	require.NoError(t, cache.Put(path, opts, file))
+
+	result, ok := cache.Get(path, opts)
+	require.True(t, ok)
+	assert.Equal(t, file, result)
+
+	// Returned results are deep copies, and so is the stored entry
+	result.Path = "changed"
+	result.Children[0].(*ir.DistilledClass).Name = "changed"
+	file.Children[0].(*ir.DistilledClass).Name = "changed"
+	again, ok := cache.Get(path, opts)
+	require.True(t, ok)
+	assert.Equal(t, path, again.Path)
+	assert.Equal(t, "Service", again.Children[0].(*ir.DistilledClass).Name)
+
+	// Worker count does not affect the key, other options do
+	_, ok = cache.Get(path, processor.ProcessOptions{IncludeComments: true, Workers: 4})
+	assert.True(t, ok, "expected hit with different worker count")
+	_, ok = cache.Get(path, processor.ProcessOptions{IncludeComments: true, IncludePrivate: true})
+	assert.False(t, ok, "expected miss with different options")
+
+	// Touching the file keeps the entry, changing its contents does not
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	_, ok = cache.Get(path, opts)
+	assert.True(t, ok, "expected hit after touching the file")
+
+	require.NoError(t, os.WriteFile(path, []byte("world"), 0644))
+	_, ok = cache.Get(path, opts)
+	assert.False(t, ok, "expected miss after changing the contents")
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, int64(4), hits)
+	assert.Equal(t, int64(3), misses)
+
+	// A result for the new contents replaces the old one
+	require.NoError(t, cache.Put(path, opts, sampleResult(path)))
+	assert.Equal(t, 1, cache.Len())
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Len())
+}
@@ -6,11 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
-	"sync"
 
-	"github.com/janreges/ai-distiller/internal/ignore"
 	"github.com/janreges/ai-distiller/internal/ir"
 )
 
@@ -31,166 +28,98 @@ type FileResult struct {
 
 // processDirectoryConcurrent processes directory using multiple workers
 func (p *Processor) processDirectoryConcurrent(dir string, opts ProcessOptions) (*ir.DistilledDirectory, error) {
-	// Create ignore matcher for the directory
-	ignoreMatcher, ignoreErr := ignore.New(dir)
-	if ignoreErr != nil {
-		// Log warning but continue without ignore functionality
-		fmt.Fprintf(os.Stderr, "Warning: failed to create ignore matcher: %v\n", ignoreErr)
-		ignoreMatcher = nil
+	// First, collect all files to process
+	files, err := collectFileTasks(dir, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate number of workers
-	numWorkers := opts.Workers
-	if numWorkers == 0 {
-		// Default to 80% of CPU cores
-		numWorkers = int(float64(runtime.NumCPU()) * 0.8)
-		if numWorkers < 1 {
-			numWorkers = 1
-		}
+	// Build final directory result
+	dirResult := &ir.DistilledDirectory{
+		BaseNode: ir.BaseNode{},
+		Path:     DirectoryDisplayPath(dir, opts),
+		Children: make([]ir.DistilledNode, 0, len(files)),
 	}
 
-	// First, collect all files to process
-	var files []FileTask
-	fileIndex := 0
-	
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Check if path should be ignored
-		if ignoreMatcher != nil && ignoreMatcher.ShouldIgnore(path) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			basename := filepath.Base(path)
-			
-			// Skip .aid directories completely
-			if basename == ".aid" {
-				return filepath.SkipDir
-			}
-			
-			// Skip default ignored directories unless explicitly included in .aidignore
-			// or unless they contain explicitly included files
-			if isDefaultIgnoredDir(basename) && ignoreMatcher != nil {
-				// Check if directory is explicitly included
-				if ignoreMatcher.IsExplicitlyIncluded(path) {
-					return nil // Don't skip, process the directory
-				}
-				// Check if any files within this directory might be explicitly included
-				if !ignoreMatcher.MightContainExplicitIncludes(path) {
-					return filepath.SkipDir
-				}
-			} else if isDefaultIgnoredDir(basename) && ignoreMatcher == nil {
-				// No .aidignore, skip default ignored dirs
-				return filepath.SkipDir
-			}
-			
-			// If not recursive and not the root directory, skip subdirectories
-			if !opts.Recursive && path != dir {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip files containing '.aid.' anywhere in filename
-		basename := filepath.Base(path)
-		if strings.Contains(basename, ".aid.") {
-			return nil
-		}
-
-		// Check include/exclude patterns
-		if !shouldIncludeFile(path, opts.IncludePatterns, opts.ExcludePatterns) {
-			return nil
-		}
-
-		// Check if file is explicitly included via !pattern in .aidignore
-		explicitlyIncluded := ignoreMatcher != nil && ignoreMatcher.IsExplicitlyIncluded(path)
-		
-		// Check if we can process this file
-		if opts.RawMode {
-			// In raw mode, process all files
-			// Skip directories which are already filtered out above
-		} else {
-			// Normal mode - check if we have a processor
-			_, hasProcessor := GetByFilename(path)
-			
-			if !hasProcessor && !explicitlyIncluded {
-				return nil
-			}
+	// Add successful results and report errors
+	err = p.ProcessTasks(files, opts, func(result FileResult) error {
+		if result.Error != nil {
+			// Log error but continue (same behavior as serial processing)
+			fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", files[result.Index].Path, result.Error)
+		} else if result.Result != nil {
+			dirResult.Children = append(dirResult.Children, result.Result)
 		}
-
-		files = append(files, FileTask{
-			Index:           fileIndex,
-			Path:            path,
-			FileInfo:        info,
-			ExplicitInclude: explicitlyIncluded,
-		})
-		fileIndex++
 		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return nil, err
 	}
 
-	// If no files to process, return empty result
-	if len(files) == 0 {
-		return &ir.DistilledDirectory{
-			BaseNode: ir.BaseNode{},
-			Path:     dir,
-			Children: []ir.DistilledNode{},
-		}, nil
+	return dirResult, nil
+}
+
+// ProcessTasks processes files with a pool of opts.Workers workers (default:
+// 80% of CPU cores) and calls handle with each result in task order, with
+// Index set to the task's position. Calls are sequential. An error returned
+// by handle or the cancellation of the processor's context stops processing.
+func (p *Processor) ProcessTasks(tasks []FileTask, opts ProcessOptions, handle func(FileResult) error) error {
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+
+	// Each task gets its own buffered slot, so results can be handed out in
+	// order while later files are still being processed
+	results := make([]chan FileResult, len(tasks))
+	for i := range results {
+		results[i] = make(chan FileResult, 1)
 	}
 
-	// Create channels for task distribution and result collection
+	numWorkers := WorkerCount(opts.Workers)
 	taskChan := make(chan FileTask, numWorkers*2)
-	resultChan := make(chan FileResult, len(files))
-
-	// Create wait group for workers
-	var wg sync.WaitGroup
-
-	// Start workers
-	ctx := context.Background()
+	resultChan := make(chan FileResult, numWorkers*2)
 	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			p.worker(ctx, workerID, taskChan, resultChan, opts)
-		}(i)
+		go p.worker(ctx, i, taskChan, resultChan, opts)
 	}
 
 	// Send tasks to workers
 	go func() {
-		for _, task := range files {
-			taskChan <- task
+		defer close(taskChan)
+		for i, task := range tasks {
+			task.Index = i
+			select {
+			case taskChan <- task:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(taskChan)
 	}()
 
-	// Wait for all workers to complete
+	// Route results to their slots
 	go func() {
-		wg.Wait()
-		close(resultChan)
+		for {
+			select {
+			case result := <-resultChan:
+				results[result.Index] <- result
+			case <-ctx.Done():
+				return
+			}
+		}
 	}()
 
-	// Collect results
-	results := make([]FileResult, 0, len(files))
-	for result := range resultChan {
-		results = append(results, result)
+	for i := range tasks {
+		select {
+		case result := <-results[i]:
+			if err := handle(result); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+	return nil
+}
 
-	// Sort results by index to maintain order
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Index < results[j].Index
-	})
-
-	// Calculate display path for directory
+// DirectoryDisplayPath calculates how a directory path appears in the output
+func DirectoryDisplayPath(dir string, opts ProcessOptions) string {
 	displayPath := dir
 	if opts.FilePathType == "relative" && opts.BasePath != "" {
 		// Try to make path relative to base path
@@ -199,7 +128,7 @@ func (p *Processor) processDirectoryConcurrent(dir string, opts ProcessOptions)
 			relPath, err := filepath.Rel(absBase, dir)
 			if err == nil && !strings.HasPrefix(relPath, "..") {
 				displayPath = relPath
-				
+
 				// Apply prefix if specified
 				if opts.RelativePathPrefix != "" {
 					prefix := opts.RelativePathPrefix
@@ -208,29 +137,77 @@ func (p *Processor) processDirectoryConcurrent(dir string, opts ProcessOptions)
 						prefix += "/"
 					}
 					displayPath = prefix + displayPath
+					// The base directory itself is shown as the bare prefix
+					if relPath == "." {
+						displayPath = strings.TrimRight(prefix, "/"+string(filepath.Separator))
+					}
 				}
 			}
 		}
 	}
+	return displayPath
+}
 
-	// Build final directory result
-	dirResult := &ir.DistilledDirectory{
-		BaseNode: ir.BaseNode{},
-		Path:     displayPath,
-		Children: make([]ir.DistilledNode, 0, len(results)),
-	}
+// collectFileTasks walks dir and returns the files that would be processed,
+// honoring .aidignore, default ignored directories and include/exclude patterns
+func collectFileTasks(dir string, opts ProcessOptions) ([]FileTask, error) {
+	filter := NewFileFilter(dir, opts)
 
-	// Add successful results and report errors
-	for _, result := range results {
-		if result.Error != nil {
-			// Log error but continue (same behavior as serial processing)
-			fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", files[result.Index].Path, result.Error)
-		} else if result.Result != nil {
-			dirResult.Children = append(dirResult.Children, result.Result)
+	var files []FileTask
+	fileIndex := 0
+	
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if filter.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		match, explicitlyIncluded := filter.MatchFile(path)
+		if !match {
+			return nil
 		}
+
+		files = append(files, FileTask{
+			Index:           fileIndex,
+			Path:            path,
+			FileInfo:        info,
+			ExplicitInclude: explicitlyIncluded,
+		})
+		fileIndex++
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
-	return dirResult, nil
+	return files, nil
+}
+
+// CollectFileTasks returns the files ProcessPath would process in dir, in
+// processing order, with the explicit-include flag ProcessFile expects
+func CollectFileTasks(dir string, opts ProcessOptions) ([]FileTask, error) {
+	return collectFileTasks(dir, opts)
+}
+
+// CollectFiles returns the paths of all files ProcessPath would process in dir
+func CollectFiles(dir string, opts ProcessOptions) ([]string, error) {
+	tasks, err := collectFileTasks(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(tasks))
+	for i, task := range tasks {
+		paths[i] = task.Path
+	}
+	return paths, nil
 }
 
 // worker processes files from the task channel
@@ -253,8 +230,9 @@ func (p *Processor) worker(ctx context.Context, workerID int, tasks <-chan FileT
 	}
 }
 
-// calculateWorkers determines the number of workers to use
-func calculateWorkers(requested int) int {
+// WorkerCount returns the number of workers used for a requested count;
+// 0 means 80% of CPU cores
+func WorkerCount(requested int) int {
 	if requested > 0 {
 		return requested
 	}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ignore"
+)
+
+// FileFilter decides which paths under a directory are processed. It applies
+// .aidignore rules, the default ignored directories and include/exclude
+// patterns, exactly like directory processing does.
+type FileFilter struct {
+	dir    string
+	opts   ProcessOptions
+	ignore *ignore.IgnoreMatcher
+}
+
+// NewFileFilter creates a filter for files under dir
+func NewFileFilter(dir string, opts ProcessOptions) *FileFilter {
+	// Create ignore matcher for the directory
+	ignoreMatcher, ignoreErr := ignore.New(dir)
+	if ignoreErr != nil {
+		// Log warning but continue without ignore functionality
+		fmt.Fprintf(os.Stderr, "Warning: failed to create ignore matcher: %v\n", ignoreErr)
+		ignoreMatcher = nil
+	}
+
+	return &FileFilter{
+		dir:    dir,
+		opts:   opts,
+		ignore: ignoreMatcher,
+	}
+}
+
+// SkipDir reports whether a directory and everything below it is skipped
+func (f *FileFilter) SkipDir(path string) bool {
+	// Check if path should be ignored
+	if f.ignore != nil && f.ignore.ShouldIgnore(path) {
+		return true
+	}
+
+	basename := filepath.Base(path)
+
+	// Skip .aid directories completely
+	if basename == ".aid" {
+		return true
+	}
+
+	// Skip default ignored directories unless explicitly included in .aidignore
+	// or unless they contain explicitly included files
+	if isDefaultIgnoredDir(basename) {
+		if f.ignore == nil {
+			return true
+		}
+		if f.ignore.IsExplicitlyIncluded(path) {
+			return false
+		}
+		if !f.ignore.MightContainExplicitIncludes(path) {
+			return true
+		}
+	}
+
+	// If not recursive and not the root directory, skip subdirectories
+	return !f.opts.Recursive && path != f.dir
+}
+
+// MatchFile reports whether a file is processed, and whether it is
+// explicitly included via a !pattern in .aidignore. Parent directories are
+// not checked; see Contains.
+func (f *FileFilter) MatchFile(path string) (match bool, explicit bool) {
+	// Check if path should be ignored
+	if f.ignore != nil && f.ignore.ShouldIgnore(path) {
+		return false, false
+	}
+
+	// Skip files containing '.aid.' anywhere in filename
+	if strings.Contains(filepath.Base(path), ".aid.") {
+		return false, false
+	}
+
+	// Check include/exclude patterns
+	if !shouldIncludeFile(path, f.opts.IncludePatterns, f.opts.ExcludePatterns) {
+		return false, false
+	}
+
+	// Check if file is explicitly included via !pattern in .aidignore
+	explicit = f.ignore != nil && f.ignore.IsExplicitlyIncluded(path)
+
+	// In raw mode, process all files; otherwise we need a processor
+	if !f.opts.RawMode {
+		if _, hasProcessor := GetByFilename(path); !hasProcessor && !explicit {
+			return false, false
+		}
+	}
+
+	return true, explicit
+}
+
+// Contains reports whether a file anywhere below the filter's directory is
+// processed, checking every parent directory on the way
+func (f *FileFilter) Contains(path string) (match bool, explicit bool) {
+	rel, err := filepath.Rel(f.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if f.SkipDir(dir) {
+			return false, false
+		}
+		if dir == f.dir || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	return f.MatchFile(path)
+}
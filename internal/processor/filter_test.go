@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileFilterContains(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"main.py", "ignored.py", "pkg/lib.py", "node_modules/dep.py", "notes.unknown", "gen/out.py"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x = 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ".aidignore"), []byte("ignored.py\ngen/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Raw mode accepts any file, so only ignore rules decide
+	opts := ProcessOptions{Recursive: true, RawMode: true}
+	filter := NewFileFilter(root, opts)
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"main.py", true},
+		{"pkg/lib.py", true},
+		{"ignored.py", false},
+		{"gen/out.py", false},
+		{"node_modules/dep.py", false},
+		{"notes.unknown", true},
+	}
+	for _, tt := range tests {
+		if got, _ := filter.Contains(filepath.Join(root, tt.rel)); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+
+	if got, _ := filter.Contains(filepath.Join(filepath.Dir(root), "outside.py")); got {
+		t.Error("Contains should reject paths outside the directory")
+	}
+
+	// The filter agrees with directory collection
+	files, err := CollectFiles(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		if got, _ := filter.Contains(path); !got {
+			t.Errorf("CollectFiles returned %s, which Contains rejects", path)
+		}
+	}
+	if len(files) != 4 {
+		t.Errorf("CollectFiles returned %v, want .aidignore, main.py, notes.unknown and pkg/lib.py", files)
+	}
+}
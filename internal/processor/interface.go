@@ -32,6 +32,29 @@ type LanguageProcessor interface {
 	ProcessWithOptions(ctx context.Context, reader io.Reader, filename string, opts ProcessOptions) (*ir.DistilledFile, error)
 }
 
+// ContentDetector is implemented by processors that handle only some of the
+// files with their extensions, such as API specs among .json and .yaml
+// files. The registry offers such a processor for a file only when
+// DetectContent accepts the start of its content.
+type ContentDetector interface {
+	DetectContent(head []byte) bool
+}
+
+// ExtensionDetector is implemented by content detectors that need the
+// content only for some of their extensions, such as C for .h headers it
+// shares with C++. The other extensions are registered as usual.
+type ExtensionDetector interface {
+	ContentDetector
+	DetectedExtensions() []string
+}
+
+// DirectoryFolder is implemented by processors that merge files of a
+// directory result into one, such as SQL migrations into their final
+// schema. Files of other languages must be left unchanged.
+type DirectoryFolder interface {
+	FoldDirectory(dir *ir.DistilledDirectory) *ir.DistilledDirectory
+}
+
 // ProcessOptions configures the processing behavior
 type ProcessOptions struct {
 	// IncludeImplementation includes function/method bodies
@@ -97,6 +120,20 @@ type ProcessOptions struct {
 	
 	// ExplicitInclude indicates this file was explicitly included via !pattern
 	ExplicitInclude bool
+
+	// FoldDirectories lets DirectoryFolder processors merge the files of
+	// directory results, e.g. SQL migrations (--sql-schema)
+	FoldDirectories bool
+}
+
+// ResultCache stores processed files so unchanged files are not parsed again.
+// Implementations must be safe for concurrent use.
+type ResultCache interface {
+	// Get returns the cached result for a file, if a valid one exists
+	Get(filePath string, opts ProcessOptions) (*ir.DistilledFile, bool)
+
+	// Put stores the result of processing a file
+	Put(filePath string, opts ProcessOptions, result *ir.DistilledFile) error
 }
 
 // DefaultProcessOptions returns default processing options
@@ -133,6 +170,10 @@ type Registry interface {
 	// GetByFilename returns a processor that can handle the file
 	GetByFilename(filename string) (LanguageProcessor, bool)
 
+	// GetByContent returns a processor that can handle a file with the
+	// given leading content
+	GetByContent(filename string, head []byte) (LanguageProcessor, bool)
+
 	// List returns all registered language identifiers
 	List() []string
 }
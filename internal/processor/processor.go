@@ -1,12 +1,14 @@
 package processor
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/janreges/ai-distiller/internal/debug"
@@ -18,7 +20,8 @@ import (
 
 // Processor processes files and directories
 type Processor struct {
-	ctx context.Context
+	ctx   context.Context
+	cache ResultCache
 }
 
 // New creates a new processor
@@ -35,6 +38,12 @@ func NewWithContext(ctx context.Context) *Processor {
 	}
 }
 
+// WithCache makes the processor reuse cached results for unchanged files
+func (p *Processor) WithCache(cache ResultCache) *Processor {
+	p.cache = cache
+	return p
+}
+
 // ProcessPath processes a file or directory
 func (p *Processor) ProcessPath(path string, opts ProcessOptions) (ir.DistilledNode, error) {
 	info, err := os.Stat(path)
@@ -43,11 +52,91 @@ func (p *Processor) ProcessPath(path string, opts ProcessOptions) (ir.DistilledN
 	}
 
 	if info.IsDir() {
-		return p.processDirectory(path, opts)
+		dir, err := p.processDirectory(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		return FoldDirectory(dir, opts), nil
 	}
 	return p.ProcessFile(path, opts)
 }
 
+// FoldDirectory lets the registered DirectoryFolder processors merge the
+// files of a directory result when opts.FoldDirectories is set. Results
+// built outside ProcessPath and ProcessPaths, such as by watch mode, are
+// folded with it too.
+func FoldDirectory(dir *ir.DistilledDirectory, opts ProcessOptions) *ir.DistilledDirectory {
+	if !opts.FoldDirectories {
+		return dir
+	}
+	languages := List()
+	sort.Strings(languages)
+	for _, language := range languages {
+		proc, _ := Get(language)
+		if folder, ok := proc.(DirectoryFolder); ok {
+			dir = folder.FoldDirectory(dir)
+		}
+	}
+	return dir
+}
+
+// ProcessPaths processes several files and directories into one directory
+// result. A file appears once, even when it is listed more than once or
+// lies inside a listed directory. Listed files are filtered by the include
+// and exclude patterns, and files without a processor are skipped, like
+// the files found in directories.
+func (p *Processor) ProcessPaths(paths []string, opts ProcessOptions) (*ir.DistilledDirectory, error) {
+	result := &ir.DistilledDirectory{
+		BaseNode: ir.BaseNode{},
+		Path:     opts.RelativePathPrefix,
+		Children: []ir.DistilledNode{},
+	}
+
+	seen := make(map[string]bool)
+	add := func(file *ir.DistilledFile) {
+		if file != nil && !seen[file.Path] {
+			seen[file.Path] = true
+			result.Children = append(result.Children, file)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+
+		if info.IsDir() {
+			dir, err := p.processDirectory(path, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range dir.Children {
+				if file, ok := child.(*ir.DistilledFile); ok {
+					add(file)
+				}
+			}
+			continue
+		}
+
+		if !shouldIncludeFile(path, opts.IncludePatterns, opts.ExcludePatterns) {
+			continue
+		}
+		if _, ok := ForFile(path, opts); !ok {
+			continue
+		}
+		file, err := p.ProcessFile(path, opts)
+		if err != nil {
+			// Log error but continue
+			fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", path, err)
+			continue
+		}
+		add(file)
+	}
+
+	return FoldDirectory(result, opts), nil
+}
+
 // ProcessFile processes a single file
 func (p *Processor) ProcessFile(filename string, opts ProcessOptions) (*ir.DistilledFile, error) {
 	dbg := debug.FromContext(p.ctx).WithSubsystem("processor")
@@ -55,7 +144,50 @@ func (p *Processor) ProcessFile(filename string, opts ProcessOptions) (*ir.Disti
 	
 	dbg.Logf(debug.LevelDetailed, "Processing file: %s", filename)
 	
-	// Calculate display path based on options
+	displayPath := fileDisplayPath(filename, opts)
+	proc, ok := ForFile(filename, opts)
+	if !ok {
+		return nil, fmt.Errorf("no processor found for file: %s", filename)
+	}
+	
+	dbg.Logf(debug.LevelDetailed, "Using %s processor for %s", proc.Language(), filename)
+
+	// Reuse a cached result when the file has not changed
+	if p.cache != nil {
+		if cached, hit := p.cache.Get(filename, opts); hit {
+			dbg.Logf(debug.LevelDetailed, "Cache hit for %s", filename)
+			cached.Path = displayPath
+			return cached, nil
+		}
+	}
+
+	result, err := p.processFile(proc, filename, displayPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.cache != nil {
+		if err := p.cache.Put(filename, opts, result); err != nil {
+			dbg.Logf(debug.LevelDetailed, "Failed to cache %s: %v", filename, err)
+		}
+	}
+
+	return result, nil
+}
+
+// ProcessContent processes content that is not read from disk, such as a
+// blob from git history. The filename selects the language processor and
+// determines the display path; results are never cached.
+func (p *Processor) ProcessContent(filename string, content []byte, opts ProcessOptions) (*ir.DistilledFile, error) {
+	proc, ok := ForContent(filename, content, opts)
+	if !ok {
+		return nil, fmt.Errorf("no processor found for file: %s", filename)
+	}
+	return p.processReader(proc, bytes.NewReader(content), filename, fileDisplayPath(filename, opts), opts)
+}
+
+// fileDisplayPath calculates how a file path appears in the output
+func fileDisplayPath(filename string, opts ProcessOptions) string {
 	displayPath := filename
 	if opts.FilePathType == "relative" && opts.BasePath != "" {
 		// Check if basePath is a file or directory
@@ -88,34 +220,46 @@ func (p *Processor) ProcessFile(filename string, opts ProcessOptions) (*ir.Disti
 			}
 		}
 	}
-	var proc LanguageProcessor
-	var ok bool
-	
+	return displayPath
+}
+
+// ForFile returns the language processor that ProcessFile uses for a file
+func ForFile(filename string, opts ProcessOptions) (LanguageProcessor, bool) {
 	// In raw mode, use RawProcessor for all text files
 	if opts.RawMode {
-		rawProc := NewRawProcessor()
-		// In raw mode, always use the raw processor
-		proc = rawProc
-		ok = true
-	} else {
-		// Normal mode - get processor for file
-		proc, ok = GetByFilename(filename)
-		
-		// If no processor found, check if file is explicitly included
-		if !ok && opts.ExplicitInclude {
-			// Use RawProcessor for explicitly included files
-			rawProc := NewRawProcessor()
-			proc = rawProc
-			ok = true
-		}
+		return NewRawProcessor(), true
 	}
-	
-	if !ok && !opts.RawMode {
-		return nil, fmt.Errorf("no processor found for file: %s", filename)
+
+	// Normal mode - get processor for file
+	if proc, ok := GetByFilename(filename); ok {
+		return proc, true
+	}
+
+	// Use RawProcessor for explicitly included files
+	if opts.ExplicitInclude {
+		return NewRawProcessor(), true
 	}
-	
-	dbg.Logf(debug.LevelDetailed, "Using %s processor for %s", proc.Language(), filename)
 
+	return nil, false
+}
+
+// ForContent returns the language processor for a file with the given
+// content, which need not exist on disk
+func ForContent(filename string, content []byte, opts ProcessOptions) (LanguageProcessor, bool) {
+	if opts.RawMode {
+		return NewRawProcessor(), true
+	}
+	if proc, ok := GetByContent(filename, content); ok {
+		return proc, true
+	}
+	if opts.ExplicitInclude {
+		return NewRawProcessor(), true
+	}
+	return nil, false
+}
+
+// processFile opens a file and processes it with processReader
+func (p *Processor) processFile(proc LanguageProcessor, filename, displayPath string, opts ProcessOptions) (*ir.DistilledFile, error) {
 	// Open file
 	file, err := os.Open(filename)
 	if err != nil {
@@ -123,6 +267,13 @@ func (p *Processor) ProcessFile(filename string, opts ProcessOptions) (*ir.Disti
 	}
 	defer file.Close()
 
+	return p.processReader(proc, file, filename, displayPath, opts)
+}
+
+// processReader runs a language processor on content and applies stripping
+func (p *Processor) processReader(proc LanguageProcessor, file io.Reader, filename, displayPath string, opts ProcessOptions) (*ir.DistilledFile, error) {
+	dbg := debug.FromContext(p.ctx).WithSubsystem("processor")
+
 	// Process file using our debug-enabled context
 	// Check if processor supports ProcessWithOptions
 	if procWithOpts, ok := proc.(interface {
@@ -210,27 +361,7 @@ func (p *Processor) processDirectory(dir string, opts ProcessOptions) (*ir.Disti
 	}
 
 	// Calculate display path for directory
-	displayPath := dir
-	if opts.FilePathType == "relative" && opts.BasePath != "" {
-		// Try to make path relative to base path
-		absBase, err := filepath.Abs(opts.BasePath)
-		if err == nil {
-			relPath, err := filepath.Rel(absBase, dir)
-			if err == nil && !strings.HasPrefix(relPath, "..") {
-				displayPath = relPath
-				
-				// Apply prefix if specified
-				if opts.RelativePathPrefix != "" {
-					prefix := opts.RelativePathPrefix
-					// Ensure prefix ends with separator if not empty and doesn't already
-					if !strings.HasSuffix(prefix, "/") && !strings.HasSuffix(prefix, string(filepath.Separator)) {
-						prefix += "/"
-					}
-					displayPath = prefix + displayPath
-				}
-			}
-		}
-	}
+	displayPath := DirectoryDisplayPath(dir, opts)
 
 	// Serial processing (workers == 1)
 	result := &ir.DistilledDirectory{
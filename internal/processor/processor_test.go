@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"docs/a.txt", "docs/b.txt", "notes.txt", "skip.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name+"\n"), 0644))
+	}
+
+	opts := DefaultProcessOptions()
+	opts.RawMode = true
+	opts.Workers = 1
+	opts.BasePath = dir
+	opts.FilePathType = "relative"
+	opts.RelativePathPrefix = "repo"
+	opts.ExcludePatterns = []string{"skip.txt"}
+
+	// docs/a.txt is listed and also inside docs
+	result, err := New().ProcessPaths([]string{
+		filepath.Join(dir, "docs/a.txt"),
+		filepath.Join(dir, "docs"),
+		filepath.Join(dir, "notes.txt"),
+		filepath.Join(dir, "skip.txt"),
+	}, opts)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, child := range result.Children {
+		paths = append(paths, child.(*ir.DistilledFile).Path)
+	}
+	assert.Equal(t, []string{"repo/docs/a.txt", "repo/docs/b.txt", "repo/notes.txt"}, paths)
+
+	_, err = New().ProcessPaths([]string{filepath.Join(dir, "missing.txt")}, opts)
+	assert.Error(t, err)
+}
+
+// foldingProcessor merges all its files of a directory into one
+type foldingProcessor struct {
+	testProcessor
+}
+
+func (f *foldingProcessor) FoldDirectory(dir *ir.DistilledDirectory) *ir.DistilledDirectory {
+	result := &ir.DistilledDirectory{Path: dir.Path}
+	folded := false
+	for _, child := range dir.Children {
+		if file, ok := child.(*ir.DistilledFile); ok && file.Language == f.Language() {
+			if folded {
+				continue
+			}
+			folded = true
+			child = &ir.DistilledFile{Path: "*.fold", Language: f.Language()}
+		}
+		result.Children = append(result.Children, child)
+	}
+	return result
+}
+
+func TestProcessPathFoldsDirectories(t *testing.T) {
+	require.NoError(t, Register(&foldingProcessor{testProcessor{NewBaseProcessor("foldtest", "1.0", []string{".fold"})}}))
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.fold", "b.fold"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	for _, workers := range []int{1, 2} {
+		opts := DefaultProcessOptions()
+		opts.Workers = workers
+		result, err := New().ProcessPath(dir, opts)
+		require.NoError(t, err)
+		assert.Len(t, result.(*ir.DistilledDirectory).Children, 2)
+
+		// Every caller of ProcessPath and ProcessPaths gets folded results
+		opts.FoldDirectories = true
+		result, err = New().ProcessPath(dir, opts)
+		require.NoError(t, err)
+		require.Len(t, result.(*ir.DistilledDirectory).Children, 1)
+		assert.Equal(t, "*.fold", result.(*ir.DistilledDirectory).Children[0].(*ir.DistilledFile).Path)
+
+		paths, err := New().ProcessPaths([]string{dir}, opts)
+		require.NoError(t, err)
+		assert.Len(t, paths.Children, 1)
+	}
+}
+
+func TestProcessTasks(t *testing.T) {
+	dir := t.TempDir()
+	var tasks []FileTask
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%02d.txt", i))
+		require.NoError(t, os.WriteFile(path, []byte("x\n"), 0644))
+		tasks = append(tasks, FileTask{Path: path})
+	}
+	tasks = append(tasks, FileTask{Path: filepath.Join(dir, "missing.txt")})
+
+	opts := DefaultProcessOptions()
+	opts.RawMode = true
+	opts.Workers = 4
+
+	// Results arrive in task order, including failures
+	var indexes []int
+	var failed []int
+	require.NoError(t, New().ProcessTasks(tasks, opts, func(result FileResult) error {
+		indexes = append(indexes, result.Index)
+		if result.Error != nil {
+			failed = append(failed, result.Index)
+		} else {
+			assert.Equal(t, tasks[result.Index].Path, result.Result.Path)
+		}
+		return nil
+	}))
+	require.Len(t, indexes, len(tasks))
+	for i, index := range indexes {
+		assert.Equal(t, i, index)
+	}
+	assert.Equal(t, []int{20}, failed)
+
+	// An error from the handler stops processing
+	stop := errors.New("stop")
+	calls := 0
+	err := New().ProcessTasks(tasks, opts, func(FileResult) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
@@ -2,16 +2,24 @@ package processor
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 )
 
+// detectHeadSize is how much of a file content detectors look at
+const detectHeadSize = 64 * 1024
+
 // registry is the default implementation of Registry
 type registry struct {
 	mu         sync.RWMutex
 	processors map[string]LanguageProcessor
 	byExt      map[string]LanguageProcessor
+	// detecting holds the content detectors of each extension in
+	// registration order
+	detecting map[string][]LanguageProcessor
 }
 
 // NewRegistry creates a new processor registry
@@ -19,6 +27,7 @@ func NewRegistry() Registry {
 	return &registry{
 		processors: make(map[string]LanguageProcessor),
 		byExt:      make(map[string]LanguageProcessor),
+		detecting:  make(map[string][]LanguageProcessor),
 	}
 }
 
@@ -41,15 +50,24 @@ func (r *registry) Register(processor LanguageProcessor) error {
 	r.processors[lang] = processor
 
 	// Register by extensions
+	_, detects := processor.(ContentDetector)
+	var detected map[string]bool
+	if detector, ok := processor.(ExtensionDetector); ok {
+		detected = make(map[string]bool)
+		for _, ext := range detector.DetectedExtensions() {
+			detected[normalizeExt(ext)] = true
+		}
+	}
 	for _, ext := range processor.SupportedExtensions() {
 		if ext == "" {
 			continue
 		}
-		// Normalize extension (ensure it starts with a dot)
-		if !strings.HasPrefix(ext, ".") {
-			ext = "." + ext
+		ext = normalizeExt(ext)
+		if detects && (detected == nil || detected[ext]) {
+			r.detecting[ext] = append(r.detecting[ext], processor)
+		} else {
+			r.byExt[ext] = processor
 		}
-		r.byExt[ext] = processor
 	}
 
 	return nil
@@ -64,16 +82,71 @@ func (r *registry) Get(language string) (LanguageProcessor, bool) {
 	return processor, ok
 }
 
-// GetByFilename returns a processor that can handle the file
+// GetByFilename returns a processor that can handle the file. When content
+// detectors are registered for its extension, the start of the file is read
+// to choose between them.
 func (r *registry) GetByFilename(filename string) (LanguageProcessor, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	r.mu.RLock()
+	detecting := len(r.detecting[ext]) > 0
+	processor, ok := r.byExt[ext]
+	r.mu.RUnlock()
+
+	if !detecting {
+		return processor, ok
+	}
+	head, err := readHead(filename)
+	if err != nil {
+		return processor, ok
+	}
+	return r.GetByContent(filename, head)
+}
+
+// GetByContent returns a processor that can handle the file with the given
+// leading content. Content detectors registered for its extension are asked
+// first; the processor registered for the extension is the fallback.
+func (r *registry) GetByContent(filename string, head []byte) (LanguageProcessor, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	ext := strings.ToLower(filepath.Ext(filename))
+	if len(head) > detectHeadSize {
+		head = head[:detectHeadSize]
+	}
+	for _, processor := range r.detecting[ext] {
+		if processor.(ContentDetector).DetectContent(head) {
+			return processor, true
+		}
+	}
 	processor, ok := r.byExt[ext]
 	return processor, ok
 }
 
+// normalizeExt ensures an extension starts with a dot
+func normalizeExt(ext string) string {
+	if !strings.HasPrefix(ext, ".") {
+		return "." + ext
+	}
+	return ext
+}
+
+// readHead reads the start of a file for content detection
+func readHead(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, detectHeadSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
 // List returns all registered language identifiers
 func (r *registry) List() []string {
 	r.mu.RLock()
@@ -104,6 +177,12 @@ func GetByFilename(filename string) (LanguageProcessor, bool) {
 	return defaultRegistry.GetByFilename(filename)
 }
 
+// GetByContent returns a processor from the default registry for a file
+// with the given leading content
+func GetByContent(filename string, head []byte) (LanguageProcessor, bool) {
+	return defaultRegistry.GetByContent(filename, head)
+}
+
 // List returns all languages in the default registry
 func List() []string {
 	return defaultRegistry.List()
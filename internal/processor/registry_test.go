@@ -1,8 +1,11 @@
 package processor
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/janreges/ai-distiller/internal/ir"
@@ -137,6 +140,87 @@ func TestRegistryGetByFilename(t *testing.T) {
 	}
 }
 
+// detectingProcessor takes only files that start with a marker
+type detectingProcessor struct {
+	testProcessor
+	marker string
+}
+
+func (d *detectingProcessor) DetectContent(head []byte) bool {
+	return bytes.HasPrefix(head, []byte(d.marker))
+}
+
+func TestRegistryGetByContent(t *testing.T) {
+	reg := NewRegistry()
+	require.NoError(t, reg.Register(&testProcessor{
+		BaseProcessor: NewBaseProcessor("cpp", "1.0.0", []string{".h"}),
+	}))
+	require.NoError(t, reg.Register(&detectingProcessor{
+		testProcessor: testProcessor{BaseProcessor: NewBaseProcessor("c", "1.0.0", []string{".h"})},
+		marker:        "/* C */",
+	}))
+	require.NoError(t, reg.Register(&detectingProcessor{
+		testProcessor: testProcessor{BaseProcessor: NewBaseProcessor("openapi", "1.0.0", []string{".yaml"})},
+		marker:        "openapi:",
+	}))
+
+	proc, ok := reg.GetByContent("api.h", []byte("/* C */\nint x;"))
+	require.True(t, ok)
+	assert.Equal(t, "c", proc.Language())
+
+	// The processor registered for the extension is the fallback
+	proc, ok = reg.GetByContent("api.h", []byte("class X {};"))
+	require.True(t, ok)
+	assert.Equal(t, "cpp", proc.Language())
+
+	_, ok = reg.GetByContent("config.yaml", []byte("name: x"))
+	assert.False(t, ok)
+
+	// GetByFilename reads the start of the file
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "api.yaml")
+	require.NoError(t, os.WriteFile(spec, []byte("openapi: 3.1.0\n"), 0o644))
+	proc, ok = reg.GetByFilename(spec)
+	require.True(t, ok)
+	assert.Equal(t, "openapi", proc.Language())
+
+	_, ok = reg.GetByFilename(filepath.Join(dir, "missing.yaml"))
+	assert.False(t, ok)
+}
+
+// headerDetector detects content only for .h files
+type headerDetector struct {
+	detectingProcessor
+}
+
+func (d *headerDetector) DetectedExtensions() []string {
+	return []string{"h"}
+}
+
+func TestRegistryExtensionDetector(t *testing.T) {
+	reg := NewRegistry()
+	require.NoError(t, reg.Register(&testProcessor{
+		BaseProcessor: NewBaseProcessor("cpp", "1.0.0", []string{".cpp", ".h"}),
+	}))
+	require.NoError(t, reg.Register(&headerDetector{detectingProcessor{
+		testProcessor: testProcessor{BaseProcessor: NewBaseProcessor("c", "1.0.0", []string{".c", ".h"})},
+		marker:        "/* C */",
+	}}))
+
+	// Content of other extensions is not consulted
+	proc, ok := reg.GetByContent("main.c", []byte("class X {};"))
+	require.True(t, ok)
+	assert.Equal(t, "c", proc.Language())
+
+	proc, ok = reg.GetByContent("api.h", []byte("/* C */\nint x;"))
+	require.True(t, ok)
+	assert.Equal(t, "c", proc.Language())
+
+	proc, ok = reg.GetByContent("api.h", []byte("class X {};"))
+	require.True(t, ok)
+	assert.Equal(t, "cpp", proc.Language())
+}
+
 func TestDefaultRegistry(t *testing.T) {
 	// Clear default registry for testing
 	defaultRegistry = NewRegistry()
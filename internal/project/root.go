@@ -18,11 +18,14 @@ const (
 	
 	// EnvProjectRoot is the environment variable for overriding project root detection
 	EnvProjectRoot = "AID_PROJECT_ROOT"
+
+	// ConfigFileName is the project configuration file (also the strongest root marker)
+	ConfigFileName = ".aidrc"
 )
 
 // rootMarkers defines project root indicators in priority order
 var rootMarkers = []string{
-	".aidrc",        // AI Distiller specific config (highest priority)
+	ConfigFileName,  // AI Distiller specific config (highest priority)
 	"go.mod",        // Go projects
 	"package.json",  // Node.js projects
 	"Cargo.toml",    // Rust projects
@@ -94,11 +97,32 @@ func findRootUncached() (*RootInfo, error) {
 		return nil, fmt.Errorf("cannot get current working directory: %w", err)
 	}
 
+	return findRootFrom(cwd), nil
+}
+
+// FindRootFrom detects the project root containing path, searching upward
+// from path (or its directory, for a file) the same way FindRoot searches
+// from the working directory. The result is not cached.
+func FindRootFrom(path string) (*RootInfo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %w", path, err)
+	}
+	if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		absPath = filepath.Dir(absPath)
+	}
+
+	return findRootFrom(absPath), nil
+}
+
+// findRootFrom searches upward from startDir, falling back to
+// AID_PROJECT_ROOT and then to startDir itself
+func findRootFrom(startDir string) *RootInfo {
 	// 2. Check if we should stop at certain boundaries
 	homeDir, _ := os.UserHomeDir()
 	
 	// 3. Search upward for project markers
-	currentDir := cwd
+	currentDir := startDir
 	for depth := 0; depth < MaxSearchDepth; depth++ {
 		// Check each marker in priority order
 		for _, marker := range rootMarkers {
@@ -108,7 +132,7 @@ func findRootUncached() (*RootInfo, error) {
 				return &RootInfo{
 					Path:   currentDir,
 					Marker: marker,
-				}, nil
+				}
 			}
 		}
 
@@ -142,17 +166,17 @@ func findRootUncached() (*RootInfo, error) {
 				return &RootInfo{
 					Path:   absRoot,
 					Marker: "AID_PROJECT_ROOT",
-				}, nil
+				}
 			}
 		}
 		log.Printf("WARN: %s is set to '%s', but it is not a valid directory. Ignoring.", EnvProjectRoot, envRoot)
 	}
 
-	// 5. Fallback to current working directory
+	// 5. Fallback to the starting directory
 	return &RootInfo{
-		Path:       cwd,
+		Path:       startDir,
 		IsFallback: true,
-	}, nil
+	}
 }
 
 // GetAidDir returns the path to the .aid directory within the project root
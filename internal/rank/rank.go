@@ -0,0 +1,309 @@
+// Package rank scores the files of a project by importance, so that the
+// most relevant files can be selected when a project does not fit into the
+// context of a model.
+package rank
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/janreges/ai-distiller/internal/deps"
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// Weights of the signals in the combined score; each signal is normalized
+// to 0..1 first
+const (
+	centralityWeight = 0.5
+	fanInWeight      = 0.3
+	churnWeight      = 0.2
+)
+
+// PageRank parameters
+const (
+	damping    = 0.85
+	iterations = 50
+)
+
+// Options configures Rank
+type Options struct {
+	// Churn is the number of recent commits that touched each file, by
+	// path. Files that are missing have no churn.
+	Churn map[string]int
+}
+
+// File is the ranking of one file
+type File struct {
+	Path string `json:"path"`
+
+	// Score combines the signals below, from 0 (least important) to 1
+	Score float64 `json:"score"`
+
+	// Centrality is the PageRank of the file in the import graph, scaled
+	// from 0 (not imported) to 1 (most central)
+	Centrality float64 `json:"centrality"`
+
+	// Dependents is the number of files importing the file
+	Dependents int `json:"dependents"`
+
+	// FanIn is the number of references to the file's declarations from
+	// files that import it or share its directory
+	FanIn int `json:"fan_in"`
+
+	// Churn is the number of recent commits that touched the file
+	Churn int `json:"churn"`
+}
+
+// Rank scores files by their centrality in the import graph, the fan-in of
+// their symbols and their recent git churn, most important first. File
+// paths must be relative to root.
+func Rank(root string, files []*ir.DistilledFile, opts Options) []File {
+	graph := deps.Build(root, files, deps.Options{Level: deps.LevelFile})
+	centrality := pageRank(graph)
+	fanIn := symbolFanIn(files, graph)
+
+	ranked := make([]File, 0, len(files))
+	var maxFanIn, maxChurn int
+	for _, file := range files {
+		id := path.Clean(file.Path)
+		entry := File{
+			Path:       file.Path,
+			Centrality: centrality[id],
+			FanIn:      fanIn[id],
+			Churn:      opts.Churn[file.Path],
+		}
+		if node := graph.Node(id); node != nil {
+			entry.Dependents = node.Dependents
+		}
+		maxFanIn = max(maxFanIn, entry.FanIn)
+		maxChurn = max(maxChurn, entry.Churn)
+		ranked = append(ranked, entry)
+	}
+
+	for i := range ranked {
+		entry := &ranked[i]
+		score := centralityWeight * entry.Centrality
+		if maxFanIn > 0 {
+			score += fanInWeight * float64(entry.FanIn) / float64(maxFanIn)
+		}
+		if maxChurn > 0 {
+			// A few commits matter more than the difference between many
+			score += churnWeight * math.Log1p(float64(entry.Churn)) / math.Log1p(float64(maxChurn))
+		}
+		entry.Score = round(score)
+		entry.Centrality = round(entry.Centrality)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	return ranked
+}
+
+// Scores returns the scores of ranked files by path
+func Scores(ranked []File) map[string]float64 {
+	scores := make(map[string]float64, len(ranked))
+	for _, file := range ranked {
+		scores[file.Path] = file.Score
+	}
+	return scores
+}
+
+// pageRank computes the PageRank of every node, following imports from the
+// importing file to the imported one, scaled to 0..1 so that files nobody
+// imports get 0
+func pageRank(graph *deps.Graph) map[string]float64 {
+	result := make(map[string]float64, len(graph.Nodes))
+	n := len(graph.Nodes)
+	if n == 0 || len(graph.Edges) == 0 {
+		return result
+	}
+
+	outgoing := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+
+	rank := make(map[string]float64, n)
+	for _, node := range graph.Nodes {
+		rank[node.ID] = 1 / float64(n)
+	}
+	for i := 0; i < iterations; i++ {
+		// Files importing nothing spread their rank evenly
+		var dangling float64
+		for _, node := range graph.Nodes {
+			if len(outgoing[node.ID]) == 0 {
+				dangling += rank[node.ID]
+			}
+		}
+
+		next := make(map[string]float64, n)
+		for _, node := range graph.Nodes {
+			next[node.ID] = (1-damping)/float64(n) + damping*dangling/float64(n)
+		}
+		for _, node := range graph.Nodes {
+			targets := outgoing[node.ID]
+			for _, to := range targets {
+				next[to] += damping * rank[node.ID] / float64(len(targets))
+			}
+		}
+		rank = next
+	}
+
+	lowest, highest := math.Inf(1), 0.0
+	for _, value := range rank {
+		lowest = min(lowest, value)
+		highest = max(highest, value)
+	}
+	if highest == lowest {
+		return result
+	}
+	for id, value := range rank {
+		result[id] = (value - lowest) / (highest - lowest)
+	}
+	return result
+}
+
+// identifierPattern matches the identifiers of type names and code
+var identifierPattern = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+// symbolFanIn counts, for every file, the references to its top-level
+// declarations from files that import it or share its directory
+func symbolFanIn(files []*ir.DistilledFile, graph *deps.Graph) map[string]int {
+	imports := make(map[string]map[string]bool)
+	for _, edge := range graph.Edges {
+		if imports[edge.From] == nil {
+			imports[edge.From] = make(map[string]bool)
+		}
+		imports[edge.From][edge.To] = true
+	}
+
+	declared := make(map[string][]string, len(files))
+	used := make(map[string]map[string]bool, len(files))
+	for _, file := range files {
+		id := path.Clean(file.Path)
+		declared[id] = declarations(file)
+		used[id] = references(file)
+	}
+
+	fanIn := make(map[string]int, len(files))
+	for _, file := range files {
+		target := path.Clean(file.Path)
+		if len(declared[target]) == 0 {
+			continue
+		}
+		for _, other := range files {
+			source := path.Clean(other.Path)
+			if source == target || (!imports[source][target] && path.Dir(source) != path.Dir(target)) {
+				continue
+			}
+			for _, name := range declared[target] {
+				if used[source][name] {
+					fanIn[target]++
+				}
+			}
+		}
+	}
+	return fanIn
+}
+
+// declarations returns the names of the top-level types and functions of a
+// file. Go methods, which are top-level functions too, are left out.
+func declarations(file *ir.DistilledFile) []string {
+	var names []string
+	ir.Walk(file, func(node ir.DistilledNode) bool {
+		switch n := node.(type) {
+		case *ir.DistilledFile, *ir.DistilledPackage:
+			return true
+		case *ir.DistilledClass:
+			names = append(names, n.Name)
+		case *ir.DistilledInterface:
+			names = append(names, n.Name)
+		case *ir.DistilledStruct:
+			names = append(names, n.Name)
+		case *ir.DistilledEnum:
+			names = append(names, n.Name)
+		case *ir.DistilledTypeAlias:
+			names = append(names, n.Name)
+		case *ir.DistilledFunction:
+			if !isMethod(file, n) {
+				names = append(names, n.Name)
+			}
+		}
+		return false
+	})
+	return names
+}
+
+// isMethod reports whether a top-level function is a Go method
+func isMethod(file *ir.DistilledFile, fn *ir.DistilledFunction) bool {
+	if file.Language != "go" {
+		return false
+	}
+	for _, modifier := range fn.Modifiers {
+		if modifier == ir.ModifierAbstract {
+			return true
+		}
+	}
+	return false
+}
+
+// references returns the identifiers a file uses in imported symbols, type
+// references and implementations
+func references(file *ir.DistilledFile) map[string]bool {
+	used := make(map[string]bool)
+	add := func(text string) {
+		for _, name := range identifierPattern.FindAllString(text, -1) {
+			used[name] = true
+		}
+	}
+	var addTypes func(types ...ir.TypeRef)
+	addTypes = func(types ...ir.TypeRef) {
+		for _, t := range types {
+			add(t.Name)
+			addTypes(t.TypeArgs...)
+		}
+	}
+
+	ir.Walk(file, func(node ir.DistilledNode) bool {
+		switch n := node.(type) {
+		case *ir.DistilledImport:
+			for _, symbol := range n.Symbols {
+				add(symbol.Name)
+			}
+		case *ir.DistilledClass:
+			addTypes(n.Extends...)
+			addTypes(n.Implements...)
+			addTypes(n.Mixins...)
+		case *ir.DistilledInterface:
+			addTypes(n.Extends...)
+		case *ir.DistilledField:
+			if n.Type != nil {
+				addTypes(*n.Type)
+			}
+		case *ir.DistilledTypeAlias:
+			addTypes(n.Type)
+		case *ir.DistilledFunction:
+			for _, param := range n.Parameters {
+				addTypes(param.Type)
+			}
+			if n.Returns != nil {
+				addTypes(*n.Returns)
+			}
+			add(n.Implementation)
+		}
+		return true
+	})
+	return used
+}
+
+// round keeps three decimals, which is enough to order files and keeps the
+// JSON summary readable
+func round(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
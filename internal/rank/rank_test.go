@@ -0,0 +1,103 @@
+package rank
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+func pythonFiles() []*ir.DistilledFile {
+	return []*ir.DistilledFile{
+		{Path: "app/models.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledClass{Name: "User"},
+			&ir.DistilledClass{Name: "Order"},
+		}},
+		{Path: "app/service.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledImport{ImportType: "from", Module: "app.models", Symbols: []ir.ImportedSymbol{{Name: "User"}, {Name: "Order"}}},
+			&ir.DistilledFunction{Name: "checkout", Parameters: []ir.Parameter{{Name: "user", Type: ir.TypeRef{Name: "User"}}},
+				Returns: &ir.TypeRef{Name: "Order"}},
+		}},
+		{Path: "app/api.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledImport{ImportType: "from", Module: "app.service", Symbols: []ir.ImportedSymbol{{Name: "checkout"}}},
+			&ir.DistilledImport{ImportType: "from", Module: "app.models", Symbols: []ir.ImportedSymbol{{Name: "User"}}},
+			&ir.DistilledFunction{Name: "handle", Implementation: "return checkout(User())"},
+		}},
+		{Path: "scripts/cleanup.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledFunction{Name: "main"},
+		}},
+	}
+}
+
+func paths(ranked []File) []string {
+	result := make([]string, len(ranked))
+	for i, file := range ranked {
+		result[i] = file.Path
+	}
+	return result
+}
+
+func TestRank(t *testing.T) {
+	ranked := Rank(t.TempDir(), pythonFiles(), Options{})
+
+	want := []string{"app/models.py", "app/service.py", "app/api.py", "scripts/cleanup.py"}
+	if got := paths(ranked); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	models := ranked[0]
+	if models.Centrality != 1 || models.Dependents != 2 || models.FanIn != 3 || models.Score != centralityWeight+fanInWeight {
+		t.Errorf("models.py = %+v", models)
+	}
+	if cleanup := ranked[3]; cleanup.Score != 0 || cleanup.FanIn != 0 || cleanup.Dependents != 0 {
+		t.Errorf("cleanup.py = %+v", cleanup)
+	}
+}
+
+func TestRankChurn(t *testing.T) {
+	// Churn lifts a file that is otherwise unimportant
+	files := pythonFiles()
+	ranked := Rank(t.TempDir(), files, Options{Churn: map[string]int{"scripts/cleanup.py": 40, "app/api.py": 1}})
+	var cleanup, api File
+	for _, file := range ranked {
+		switch file.Path {
+		case "scripts/cleanup.py":
+			cleanup = file
+		case "app/api.py":
+			api = file
+		}
+	}
+	if cleanup.Churn != 40 || cleanup.Score != churnWeight {
+		t.Errorf("cleanup.py = %+v", cleanup)
+	}
+	if api.Churn != 1 || api.Score <= 0 {
+		t.Errorf("api.py = %+v", api)
+	}
+
+	scores := Scores(ranked)
+	if len(scores) != len(files) || scores["scripts/cleanup.py"] != cleanup.Score {
+		t.Errorf("scores = %v", scores)
+	}
+}
+
+func TestRankGoPackage(t *testing.T) {
+	// Files of one package reference each other without imports, and Go
+	// methods are not counted as declarations
+	files := []*ir.DistilledFile{
+		{Path: "store/user.go", Language: "go", Children: []ir.DistilledNode{
+			&ir.DistilledStruct{Name: "User"},
+			&ir.DistilledFunction{Name: "Save", Modifiers: []ir.Modifier{ir.ModifierAbstract},
+				Parameters: []ir.Parameter{{Name: "u", Type: ir.TypeRef{Name: "*User"}}}},
+		}},
+		{Path: "store/repo.go", Language: "go", Children: []ir.DistilledNode{
+			&ir.DistilledFunction{Name: "Load", Returns: &ir.TypeRef{Name: "*User"}, Implementation: "u := &User{}\nu.Save()\nreturn u"},
+		}},
+	}
+	ranked := Rank(t.TempDir(), files, Options{})
+	if got := paths(ranked); !reflect.DeepEqual(got, []string{"store/user.go", "store/repo.go"}) {
+		t.Errorf("order = %v", got)
+	}
+	if ranked[0].FanIn != 1 || ranked[1].FanIn != 0 {
+		t.Errorf("fan-in = %d, %d, want 1, 0", ranked[0].FanIn, ranked[1].FanIn)
+	}
+}
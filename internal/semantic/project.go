@@ -0,0 +1,203 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janreges/ai-distiller/internal/debug"
+)
+
+// LanguageForFile returns the semantic analysis language for a file,
+// or an empty string if the file is not supported
+func LanguageForFile(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".py", ".pyw":
+		return "python"
+	case ".ts", ".tsx", ".mts", ".cts":
+		return "typescript"
+	default:
+		return ""
+	}
+}
+
+// callSiteKey identifies a call site for de-duplication
+type callSiteKey struct {
+	caller   SymbolID
+	callee   string
+	location FileLocation
+}
+
+// ProjectAnalyzer builds a SemanticGraph for a whole project by running
+// Pass 1 on every supported file and Pass 2 across all of them
+type ProjectAnalyzer struct {
+	projectRoot string
+	resolver    *Resolver
+	python      *Analyzer
+	typescript  *TypeScriptAnalyzer
+}
+
+// NewProjectAnalyzer creates a project analyzer rooted at projectRoot
+func NewProjectAnalyzer(projectRoot string) (*ProjectAnalyzer, error) {
+	absRoot, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+
+	return &ProjectAnalyzer{
+		projectRoot: absRoot,
+		resolver:    NewResolver(absRoot),
+	}, nil
+}
+
+// AnalyzeFiles analyzes the given files and resolves calls and imports between them.
+// Unsupported files are ignored and files that fail to parse are skipped.
+func (pa *ProjectAnalyzer) AnalyzeFiles(ctx context.Context, files []string) (*SemanticGraph, error) {
+	dbg := debug.FromContext(ctx).WithSubsystem("semantic")
+	start := time.Now()
+
+	graph := NewSemanticGraph(pa.projectRoot)
+	seenCalls := make(map[callSiteKey]bool)
+	for _, file := range files {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		language := LanguageForFile(file)
+		if language == "" {
+			continue
+		}
+
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+
+		analysis, err := pa.analyzeFile(ctx, language, absPath)
+		if err != nil {
+			dbg.Logf(debug.LevelBasic, "Skipping %s: %v", absPath, err)
+			continue
+		}
+
+		// Statistics are recomputed once after resolution
+		graph.FileSymbolTables[absPath] = analysis.SymbolTable
+		for _, dep := range analysis.Dependencies {
+			graph.AddDependency(dep)
+		}
+		for _, call := range analysis.CallSites {
+			// Overlapping queries can report the same call more than once
+			key := callSiteKey{call.CallerID, call.CalleeName, call.Location}
+			if !seenCalls[key] {
+				seenCalls[key] = true
+				graph.AddCallSite(call)
+			}
+		}
+	}
+	dbg.Logf(debug.LevelBasic, "Analyzed %d files in %v", len(graph.FileSymbolTables), time.Since(start))
+
+	if err := pa.resolver.ResolveProject(ctx, graph); err != nil {
+		return nil, err
+	}
+
+	graph.Statistics.AnalysisTime = time.Since(start)
+	dbg.Logf(debug.LevelBasic, "Resolved %d of %d calls", graph.Statistics.ResolvedCalls, graph.Statistics.TotalCallSites)
+
+	return graph, nil
+}
+
+// analyzeFile runs Pass 1 on a single file with the analyzer for its language
+func (pa *ProjectAnalyzer) analyzeFile(ctx context.Context, language, filePath string) (*FileAnalysis, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch language {
+	case "python":
+		if pa.python == nil {
+			if pa.python, err = NewAnalyzer(pa.projectRoot); err != nil {
+				return nil, err
+			}
+		}
+		return pa.python.AnalyzeFile(ctx, file, filePath)
+	case "typescript":
+		if pa.typescript == nil {
+			if pa.typescript, err = NewTypeScriptAnalyzer(); err != nil {
+				return nil, err
+			}
+		}
+		return pa.typescript.AnalyzeFile(ctx, file, filePath)
+	default:
+		return nil, fmt.Errorf("unsupported language: %s", language)
+	}
+}
+
+// AllSymbols returns every symbol in the graph, ordered by file and line
+func (sg *SemanticGraph) AllSymbols() []*Symbol {
+	var symbols []*Symbol
+	for _, table := range sg.FileSymbolTables {
+		for _, symbol := range table.Symbols {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Location.FilePath != symbols[j].Location.FilePath {
+			return symbols[i].Location.FilePath < symbols[j].Location.FilePath
+		}
+		if symbols[i].Location.StartLine != symbols[j].Location.StartLine {
+			return symbols[i].Location.StartLine < symbols[j].Location.StartLine
+		}
+		return symbols[i].ID < symbols[j].ID
+	})
+	return symbols
+}
+
+// FindSymbols returns the symbols matching a query. The query can be a full
+// symbol ID, a plain name ("save") or a scoped name ("UserService.save").
+func (sg *SemanticGraph) FindSymbols(query string) []*Symbol {
+	scope, name := "", query
+	if i := strings.LastIndex(query, "."); i > 0 && !strings.Contains(query, "::") {
+		scope, name = query[:i], query[i+1:]
+	}
+
+	var found []*Symbol
+	for _, symbol := range sg.AllSymbols() {
+		switch {
+		case string(symbol.ID) == query:
+			return []*Symbol{symbol}
+		case symbol.Name == name && (scope == "" || symbol.Scope == scope || strings.HasSuffix(symbol.Scope, "::"+scope)):
+			found = append(found, symbol)
+		}
+	}
+	return found
+}
+
+// CallSitesTo returns the resolved call sites that call the given symbol
+func (sg *SemanticGraph) CallSitesTo(symbolID SymbolID) []CallSite {
+	var sites []CallSite
+	for _, site := range sg.CallSites {
+		if site.IsResolved && site.CalleeID == symbolID {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// CallSitesFrom returns all call sites inside the given symbol, resolved or not
+func (sg *SemanticGraph) CallSitesFrom(symbolID SymbolID) []CallSite {
+	var sites []CallSite
+	for _, site := range sg.CallSites {
+		if site.CallerID == symbolID {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
@@ -0,0 +1,209 @@
+package semantic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProjectFiles(t *testing.T, files map[string]string) (string, []string) {
+	t.Helper()
+	root := t.TempDir()
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return root, paths
+}
+
+func TestProjectAnalyzer_PythonCallGraph(t *testing.T) {
+	root, files := writeProjectFiles(t, map[string]string{
+		"service.py": `class UserService:
+    def save(self, user):
+        self.validate(user)
+        return user
+
+    def validate(self, user):
+        print(user)
+
+def helper():
+    return 1
+`,
+		"main.py": `from service import UserService, helper
+
+def main():
+    svc = UserService()
+    svc.save("x")
+    helper()
+
+main()
+`,
+		"README.md": "# not analyzed\n",
+	})
+
+	analyzer, err := NewProjectAnalyzer(root)
+	if err != nil {
+		t.Fatalf("Failed to create project analyzer: %v", err)
+	}
+	graph, err := analyzer.AnalyzeFiles(context.Background(), files)
+	if err != nil {
+		t.Fatalf("AnalyzeFiles failed: %v", err)
+	}
+
+	if graph.Statistics.TotalFiles != 2 {
+		t.Errorf("Expected 2 analyzed files, got %d", graph.Statistics.TotalFiles)
+	}
+
+	servicePath := filepath.Join(root, "service.py")
+	mainPath := filepath.Join(root, "main.py")
+	if deps := graph.DependencyGraph[mainPath]; len(deps) != 1 || deps[0] != servicePath {
+		t.Errorf("Expected main.py to depend on service.py, got %v", deps)
+	}
+
+	// Methods are scoped to their class
+	save := graph.FindSymbols("UserService.save")
+	if len(save) != 1 {
+		t.Fatalf("Expected one UserService.save, got %d", len(save))
+	}
+	if save[0].Kind != SymbolKindMethod || save[0].ID != GenerateSymbolID(servicePath, "save", "UserService") {
+		t.Errorf("Unexpected symbol for UserService.save: %+v", save[0])
+	}
+
+	// Callers of helper: exactly one call site, no duplicates
+	helper := graph.FindSymbols("helper")
+	if len(helper) != 1 {
+		t.Fatalf("Expected one helper, got %d", len(helper))
+	}
+	callers := graph.CallSitesTo(helper[0].ID)
+	if len(callers) != 1 || callers[0].CallerID != GenerateSymbolID(mainPath, "main", "") {
+		t.Errorf("Expected helper to be called once from main, got %+v", callers)
+	}
+
+	// Callees of main resolve across files
+	callees := make(map[SymbolID]bool)
+	for _, site := range graph.CallSitesFrom(GenerateSymbolID(mainPath, "main", "")) {
+		callees[site.CalleeID] = true
+	}
+	for _, expected := range []SymbolID{
+		GenerateSymbolID(servicePath, "UserService", ""),
+		save[0].ID,
+		helper[0].ID,
+	} {
+		if !callees[expected] {
+			t.Errorf("Expected main to call %s, got %v", expected, callees)
+		}
+	}
+
+	if got := graph.GetCallersOf(GenerateSymbolID(servicePath, "validate", "UserService")); len(got) != 1 || got[0] != save[0].ID {
+		t.Errorf("Expected validate to be called by save, got %v", got)
+	}
+
+	if graph.Statistics.TotalCallSites != len(graph.CallSites) {
+		t.Errorf("Statistics out of date: %d call sites, %d recorded", graph.Statistics.TotalCallSites, len(graph.CallSites))
+	}
+}
+
+func TestSemanticGraph_FindSymbols(t *testing.T) {
+	graph := NewSemanticGraph("/project")
+	table := NewSymbolTable("/project/a.py", "python")
+	table.AddSymbol(&Symbol{ID: "/project/a.py::run", Name: "run", Kind: SymbolKindFunction})
+	table.AddSymbol(&Symbol{ID: "/project/a.py::Job::start", Name: "start", Kind: SymbolKindMethod, Scope: "Job"})
+	graph.AddSymbolTable(table)
+
+	other := NewSymbolTable("/project/b.py", "python")
+	other.AddSymbol(&Symbol{ID: "/project/b.py::Task::start", Name: "start", Kind: SymbolKindMethod, Scope: "Task"})
+	graph.AddSymbolTable(other)
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"run", 1},
+		{"start", 2},
+		{"Job.start", 1},
+		{"/project/b.py::Task::start", 1},
+		{"Missing.start", 0},
+		{"missing", 0},
+	}
+
+	for _, tt := range tests {
+		if got := graph.FindSymbols(tt.query); len(got) != tt.expected {
+			t.Errorf("FindSymbols(%q) returned %d symbols, expected %d", tt.query, len(got), tt.expected)
+		}
+	}
+}
+
+func TestSemanticGraph_MapPaths(t *testing.T) {
+	graph := NewSemanticGraph("/project")
+	table := NewSymbolTable("/project/a.py", "python")
+	table.AddSymbol(&Symbol{ID: "/project/a.py::Job", Name: "Job", Kind: SymbolKindClass,
+		Location: FileLocation{FilePath: "/project/a.py", StartLine: 1}})
+	table.Dependencies = append(table.Dependencies, "/project/b.py")
+	graph.AddSymbolTable(table)
+	graph.AddCallSite(CallSite{CallerID: "/project/a.py::Job", CalleeID: "<builtin>::print",
+		Location: FileLocation{FilePath: "/project/a.py", StartLine: 2}, IsResolved: true})
+	graph.DependencyGraph["/project/a.py"] = []string{"/project/b.py"}
+
+	mapped, err := graph.MapPaths(func(path string) string {
+		if path == "/project" {
+			return "."
+		}
+		return strings.TrimPrefix(path, "/project/")
+	})
+	if err != nil {
+		t.Fatalf("MapPaths failed: %v", err)
+	}
+
+	mappedTable, ok := mapped.FileSymbolTables["a.py"]
+	if !ok || mappedTable.FilePath != "a.py" {
+		t.Fatalf("Expected a symbol table for a.py, got %v", mapped.FileSymbolTables)
+	}
+	if job := mappedTable.Symbols["Job"]; job.ID != "a.py::Job" || job.Location.FilePath != "a.py" {
+		t.Errorf("Symbol not mapped: %+v", job)
+	}
+	if job := mappedTable.NestedScopes["Job"]; job.ID != "a.py::Job" {
+		t.Errorf("Nested scope not mapped: %+v", job)
+	}
+	if site := mapped.CallSites[0]; site.CallerID != "a.py::Job" || site.CalleeID != "<builtin>::print" || site.Location.FilePath != "a.py" {
+		t.Errorf("Call site not mapped: %+v", site)
+	}
+	if callees := mapped.CallGraph["a.py::Job"]; len(callees) != 1 || callees[0] != "<builtin>::print" {
+		t.Errorf("Call graph not mapped: %v", mapped.CallGraph)
+	}
+	if deps := mapped.DependencyGraph["a.py"]; len(deps) != 1 || deps[0] != "b.py" {
+		t.Errorf("Dependency graph not mapped: %v", mapped.DependencyGraph)
+	}
+	if mappedTable.Dependencies[0] != "b.py" || mapped.ProjectRoot != "." {
+		t.Errorf("Paths not mapped: %v, %s", mappedTable.Dependencies, mapped.ProjectRoot)
+	}
+
+	// The original graph is left unchanged
+	if _, ok := graph.FileSymbolTables["/project/a.py"]; !ok || graph.CallSites[0].CallerID != "/project/a.py::Job" {
+		t.Errorf("Original graph was modified")
+	}
+}
+
+func TestLanguageForFile(t *testing.T) {
+	tests := map[string]string{
+		"app.py":    "python",
+		"app.ts":    "typescript",
+		"View.tsx":  "typescript",
+		"main.go":   "",
+		"README.md": "",
+	}
+
+	for file, expected := range tests {
+		if got := LanguageForFile(file); got != expected {
+			t.Errorf("LanguageForFile(%q) = %q, expected %q", file, got, expected)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/janreges/ai-distiller/internal/debug"
 	sitter "github.com/smacker/go-tree-sitter"
 )
 
@@ -73,7 +74,7 @@ func (r *Resolver) RegisterLanguageStrategy(language string, strategy LanguageSt
 // ResolveProject performs Pass 2 analysis on all files in a project
 func (r *Resolver) ResolveProject(ctx context.Context, semanticGraph *SemanticGraph) error {
 	// Phase 2a: Build the File Dependency Graph
-	if err := r.buildFileDependencyGraph(semanticGraph); err != nil {
+	if err := r.buildFileDependencyGraph(ctx, semanticGraph); err != nil {
 		return fmt.Errorf("failed to build dependency graph: %w", err)
 	}
 
@@ -82,11 +83,14 @@ func (r *Resolver) ResolveProject(ctx context.Context, semanticGraph *SemanticGr
 		return fmt.Errorf("failed to resolve symbols: %w", err)
 	}
 
+	semanticGraph.updateStatistics()
 	return nil
 }
 
 // buildFileDependencyGraph builds the complete file dependency graph
-func (r *Resolver) buildFileDependencyGraph(semanticGraph *SemanticGraph) error {
+func (r *Resolver) buildFileDependencyGraph(ctx context.Context, semanticGraph *SemanticGraph) error {
+	dbg := debug.FromContext(ctx).WithSubsystem("semantic")
+
 	for filePath, symbolTable := range semanticGraph.FileSymbolTables {
 		strategy, ok := r.languageStrategies[symbolTable.Language]
 		if !ok {
@@ -99,7 +103,7 @@ func (r *Resolver) buildFileDependencyGraph(semanticGraph *SemanticGraph) error
 			resolved, err := strategy.ResolveImport(depPath, filePath, r.projectRoot)
 			if err != nil {
 				// Log warning but continue - some imports might be external libraries
-				fmt.Printf("Warning: failed to resolve import '%s' in %s: %v\n", depPath, filePath, err)
+				dbg.Logf(debug.LevelDetailed, "Unresolved import '%s' in %s: %v", depPath, filePath, err)
 				continue
 			}
 			resolvedDeps = append(resolvedDeps, resolved)
@@ -130,7 +134,7 @@ func (r *Resolver) resolveSymbols(ctx context.Context, semanticGraph *SemanticGr
 		resolutionCtx := r.createResolutionContext(filePath, semanticGraph)
 
 		// Resolve call sites for this file
-		if err := r.resolveCallSites(filePath, strategy, resolutionCtx, semanticGraph); err != nil {
+		if err := r.resolveCallSites(ctx, filePath, strategy, resolutionCtx, semanticGraph); err != nil {
 			return fmt.Errorf("failed to resolve call sites for %s: %w", filePath, err)
 		}
 	}
@@ -164,7 +168,9 @@ func (r *Resolver) createResolutionContext(filePath string, semanticGraph *Seman
 }
 
 // resolveCallSites resolves call sites for a specific file
-func (r *Resolver) resolveCallSites(filePath string, strategy LanguageStrategy, resCtx *ResolutionContext, semanticGraph *SemanticGraph) error {
+func (r *Resolver) resolveCallSites(ctx context.Context, filePath string, strategy LanguageStrategy, resCtx *ResolutionContext, semanticGraph *SemanticGraph) error {
+	dbg := debug.FromContext(ctx).WithSubsystem("semantic")
+
 	// Find call sites that originated from this file
 	var callSitesToResolve []int
 	for i, callSite := range semanticGraph.CallSites {
@@ -180,7 +186,7 @@ func (r *Resolver) resolveCallSites(filePath string, strategy LanguageStrategy,
 		resolvedCalleeID, err := r.resolveCallSite(callSite, strategy, resCtx)
 		if err != nil {
 			// Log warning but continue - some calls might be to external libraries
-			fmt.Printf("Warning: failed to resolve call to '%s' at %s:%d: %v\n", 
+			dbg.Logf(debug.LevelTrace, "Unresolved call to '%s' at %s:%d: %v",
 				callSite.CalleeName, callSite.Location.FilePath, callSite.Location.StartLine, err)
 			continue
 		}
@@ -189,8 +195,8 @@ func (r *Resolver) resolveCallSites(filePath string, strategy LanguageStrategy,
 		callSite.CalleeID = resolvedCalleeID
 		callSite.IsResolved = true
 
-		// Add to call graph
-		semanticGraph.AddCallSite(*callSite)
+		// Add to call graph (the call site itself is already recorded)
+		semanticGraph.addCallEdge(callSite.CallerID, resolvedCalleeID)
 	}
 
 	return nil
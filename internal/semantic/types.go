@@ -2,6 +2,7 @@ package semantic
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -205,12 +206,18 @@ func (sg *SemanticGraph) AddCallSite(callSite CallSite) {
 	
 	// Update call graph if call is resolved
 	if callSite.IsResolved {
-		if callees, exists := sg.CallGraph[callSite.CallerID]; exists {
-			sg.CallGraph[callSite.CallerID] = append(callees, callSite.CalleeID)
-		} else {
-			sg.CallGraph[callSite.CallerID] = []SymbolID{callSite.CalleeID}
+		sg.addCallEdge(callSite.CallerID, callSite.CalleeID)
+	}
+}
+
+// addCallEdge records a caller -> callee edge in the call graph, once per pair
+func (sg *SemanticGraph) addCallEdge(caller, callee SymbolID) {
+	for _, existing := range sg.CallGraph[caller] {
+		if existing == callee {
+			return
 		}
 	}
+	sg.CallGraph[caller] = append(sg.CallGraph[caller], callee)
 }
 
 // AddDependency adds a dependency relationship
@@ -299,6 +306,87 @@ func FromJSON(data []byte) (*SemanticGraph, error) {
 	return &sg, err
 }
 
+// MapPaths returns a copy of the graph with every file path, including the
+// file part of symbol IDs, replaced by mapPath. It is used to write the graph
+// with relative or prefixed paths.
+func (sg *SemanticGraph) MapPaths(mapPath func(string) string) (*SemanticGraph, error) {
+	data, err := json.Marshal(sg)
+	if err != nil {
+		return nil, err
+	}
+	mapped, err := FromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mapID := func(id SymbolID) SymbolID {
+		return id.MapPath(mapPath)
+	}
+	mapLocation := func(loc *FileLocation) {
+		loc.FilePath = mapPath(loc.FilePath)
+	}
+	mapSymbols := func(symbols map[string]*Symbol) {
+		for _, symbol := range symbols {
+			symbol.ID = mapID(symbol.ID)
+			mapLocation(&symbol.Location)
+		}
+	}
+
+	tables := make(map[string]*SymbolTable, len(mapped.FileSymbolTables))
+	for path, table := range mapped.FileSymbolTables {
+		table.FilePath = mapPath(table.FilePath)
+		mapSymbols(table.Symbols)
+		mapSymbols(table.NestedScopes)
+		for i, dep := range table.Dependencies {
+			table.Dependencies[i] = mapPath(dep)
+		}
+		tables[mapPath(path)] = table
+	}
+	mapped.FileSymbolTables = tables
+
+	for i := range mapped.CallSites {
+		site := &mapped.CallSites[i]
+		site.CallerID = mapID(site.CallerID)
+		site.CalleeID = mapID(site.CalleeID)
+		mapLocation(&site.Location)
+	}
+	for i := range mapped.Dependencies {
+		dep := &mapped.Dependencies[i]
+		dep.SourceFile = mapPath(dep.SourceFile)
+		mapLocation(&dep.Location)
+	}
+
+	callGraph := make(map[SymbolID][]SymbolID, len(mapped.CallGraph))
+	for caller, callees := range mapped.CallGraph {
+		for i, callee := range callees {
+			callees[i] = mapID(callee)
+		}
+		callGraph[mapID(caller)] = callees
+	}
+	mapped.CallGraph = callGraph
+
+	dependencyGraph := make(map[string][]string, len(mapped.DependencyGraph))
+	for file, deps := range mapped.DependencyGraph {
+		for i, dep := range deps {
+			deps[i] = mapPath(dep)
+		}
+		dependencyGraph[mapPath(file)] = deps
+	}
+	mapped.DependencyGraph = dependencyGraph
+
+	mapped.ProjectRoot = mapPath(mapped.ProjectRoot)
+	return mapped, nil
+}
+
+// MapPath returns the ID with its file part replaced by mapPath
+func (id SymbolID) MapPath(mapPath func(string) string) SymbolID {
+	file, rest, ok := strings.Cut(string(id), "::")
+	if !ok {
+		return id
+	}
+	return SymbolID(mapPath(file) + "::" + rest)
+}
+
 // GenerateSymbolID creates a unique identifier for a symbol
 func GenerateSymbolID(filePath, symbolName, scope string) SymbolID {
 	if scope != "" {
@@ -7,7 +7,7 @@ import (
 	"strings"
 
 	sitter "github.com/smacker/go-tree-sitter"
-	typescript "tree-sitter-typescript"
+	typescript "github.com/janreges/ai-distiller/internal/parser/grammars/tree-sitter-typescript"
 )
 
 // TypeScriptAnalyzer performs semantic analysis on TypeScript/JavaScript code using tree-sitter
@@ -482,9 +482,11 @@ func (tsa *TypeScriptAnalyzer) extractCallSites(tree *sitter.Tree, content []byt
 			
 			// Determine caller context
 			callerScope := tsa.strategy.DetermineScope(node, tree, content)
-			var callerID SymbolID
+			// The scope already includes the enclosing class, so it maps
+			// directly onto the ID of the calling symbol
+			callerID := GenerateSymbolID(analysis.FilePath, "<module>", "")
 			if callerScope != "" {
-				callerID = GenerateSymbolID(analysis.FilePath, "", callerScope)
+				callerID = SymbolID(analysis.FilePath + "::" + callerScope)
 			}
 			
 			callSite := CallSite{
@@ -529,6 +531,7 @@ func (tsa *TypeScriptAnalyzer) extractDependencies(tree *sitter.Tree, content []
 					Location:     nodeToLocation(node, analysis.FilePath),
 				}
 				analysis.Dependencies = append(analysis.Dependencies, dep)
+				analysis.SymbolTable.Dependencies = append(analysis.SymbolTable.Dependencies, importPath)
 			}
 		}
 	}
@@ -0,0 +1,360 @@
+// Package split divides distilled output into parts of a bounded token size
+// without splitting a file, or optionally a top-level declaration, across
+// parts.
+package split
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+	"github.com/janreges/ai-distiller/internal/summary"
+)
+
+// Unit is the smallest piece of output that is never split across parts
+type Unit string
+
+const (
+	// UnitFile keeps every file in one part
+	UnitFile Unit = "file"
+	// UnitDeclaration divides files larger than a part between their
+	// top-level declarations, such as classes and functions
+	UnitDeclaration Unit = "declaration"
+)
+
+// IndexName is the name of the index file written next to the parts
+const IndexName = "index.json"
+
+// RenderFunc renders files with the output formatter
+type RenderFunc func(files []*ir.DistilledFile) (string, error)
+
+// Options configures Split
+type Options struct {
+	// MaxTokens is the size limit of a part, including its header
+	MaxTokens int
+
+	// Unit is what may not be split (default: UnitFile)
+	Unit Unit
+
+	// Extension is the file extension of the output format, such as ".md"
+	// or "txt"; it selects the syntax of the part headers
+	Extension string
+}
+
+// Part is one output file
+type Part struct {
+	Name   string   `json:"name"`
+	Tokens int64    `json:"tokens"`
+	Files  []string `json:"files"`
+
+	// Content is the rendered part including its header
+	Content string `json:"-"`
+
+	// Oversized is set when a single file or declaration exceeds the limit
+	Oversized bool `json:"oversized,omitempty"`
+
+	// paths lists the file paths of Files, without piece numbers
+	paths []string
+}
+
+// piece is a file or, with UnitDeclaration, a subset of its declarations
+type piece struct {
+	file   *ir.DistilledFile
+	label  string
+	tokens int64
+}
+
+// Split renders files into parts of at most opts.MaxTokens tokens each, in
+// the order of files. A file or declaration that is larger than a part on
+// its own gets a part of its own.
+func Split(files []*ir.DistilledFile, render RenderFunc, opts Options) ([]*Part, error) {
+	if opts.MaxTokens <= 0 {
+		return nil, fmt.Errorf("invalid token limit: %d", opts.MaxTokens)
+	}
+	ext := extension(opts.Extension)
+
+	var pieces []piece
+	for _, file := range files {
+		filePieces, err := divide(file, render, opts)
+		if err != nil {
+			return nil, err
+		}
+		pieces = append(pieces, filePieces...)
+	}
+
+	// Pack by estimated size first
+	var groups [][]piece
+	var current []piece
+	var tokens int64
+	for _, p := range pieces {
+		if len(current) > 0 && tokens+p.tokens > int64(opts.MaxTokens) {
+			groups = append(groups, current)
+			current, tokens = nil, 0
+		}
+		current = append(current, p)
+		tokens += p.tokens
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	// Then render every part, moving pieces to the next part while the
+	// rendered output with its header is too large
+	var parts []*Part
+	for i := 0; i < len(groups); i++ {
+		group := groups[i]
+		for {
+			part, err := renderPart(group, render)
+			if err != nil {
+				return nil, err
+			}
+			// The header is measured with the part count known so far
+			size := summary.EstimateTokens(int64(len(withHeader(ext, len(groups), len(groups), part))))
+			if size <= int64(opts.MaxTokens) || len(group) == 1 {
+				part.Oversized = size > int64(opts.MaxTokens)
+				parts = append(parts, part)
+				break
+			}
+			last := group[len(group)-1]
+			group = group[:len(group)-1]
+			if i+1 == len(groups) {
+				groups = append(groups, nil)
+			}
+			groups[i+1] = append([]piece{last}, groups[i+1]...)
+		}
+	}
+
+	for i, part := range parts {
+		part.Name = fmt.Sprintf("part-%03d%s", i+1, ext)
+		part.Content = withHeader(ext, i+1, len(parts), part)
+		part.Tokens = summary.EstimateTokens(int64(len(part.Content)))
+	}
+	return parts, nil
+}
+
+// divide returns a file as one piece or, when it is too large and
+// declarations may be separated, as several pieces
+func divide(file *ir.DistilledFile, render RenderFunc, opts Options) ([]piece, error) {
+	tokens, err := measure(render, file)
+	if err != nil {
+		return nil, err
+	}
+	whole := []piece{{file: file, label: file.Path, tokens: tokens}}
+	if opts.Unit != UnitDeclaration || tokens <= int64(opts.MaxTokens) {
+		return whole, nil
+	}
+
+	// Package clauses and imports are context that every piece repeats
+	var preamble, declarations []ir.DistilledNode
+	for _, child := range file.Children {
+		switch child.(type) {
+		case *ir.DistilledPackage, *ir.DistilledImport:
+			if len(declarations) == 0 {
+				preamble = append(preamble, child)
+				continue
+			}
+		}
+		declarations = append(declarations, child)
+	}
+	if len(declarations) < 2 {
+		return whole, nil
+	}
+
+	var pieces []piece
+	var current []ir.DistilledNode
+	var currentTokens int64
+	flush := func() error {
+		if len(current) == 0 {
+			return nil
+		}
+		copied := *file
+		copied.Children = append(append([]ir.DistilledNode{}, preamble...), current...)
+		tokens, err := measure(render, &copied)
+		if err != nil {
+			return err
+		}
+		pieces = append(pieces, piece{file: &copied, tokens: tokens})
+		current, currentTokens = nil, 0
+		return nil
+	}
+	for _, declaration := range declarations {
+		copied := *file
+		copied.Children = append(append([]ir.DistilledNode{}, preamble...), declaration)
+		tokens, err := measure(render, &copied)
+		if err != nil {
+			return nil, err
+		}
+		if len(current) > 0 && currentTokens+tokens > int64(opts.MaxTokens) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		current = append(current, declaration)
+		currentTokens += tokens
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	for i := range pieces {
+		pieces[i].label = fmt.Sprintf("%s (%d/%d)", file.Path, i+1, len(pieces))
+	}
+	return pieces, nil
+}
+
+// renderPart renders the pieces of one part without its header
+func renderPart(pieces []piece, render RenderFunc) (*Part, error) {
+	files := make([]*ir.DistilledFile, len(pieces))
+	part := &Part{Files: make([]string, len(pieces)), paths: make([]string, len(pieces))}
+	for i, p := range pieces {
+		files[i] = p.file
+		part.Files[i] = p.label
+		part.paths[i] = p.file.Path
+	}
+	content, err := render(files)
+	if err != nil {
+		return nil, err
+	}
+	part.Content = content
+	part.Tokens = summary.EstimateTokens(int64(len(content)))
+	return part, nil
+}
+
+func measure(render RenderFunc, file *ir.DistilledFile) (int64, error) {
+	output, err := render([]*ir.DistilledFile{file})
+	if err != nil {
+		return 0, err
+	}
+	return summary.EstimateTokens(int64(len(output))), nil
+}
+
+// extension normalizes a formatter extension to start with a dot
+func extension(ext string) string {
+	if ext == "" {
+		return ".txt"
+	}
+	return "." + strings.TrimPrefix(ext, ".")
+}
+
+// withHeader returns the content of a part introduced by a header in the
+// syntax of the output format, so that every part remains a valid document
+func withHeader(ext string, number, total int, part *Part) string {
+	title := fmt.Sprintf("Part %d of %d, %d file%s", number, total, len(part.Files), plural(len(part.Files)))
+
+	switch ext {
+	case ".md":
+		var b strings.Builder
+		fmt.Fprintf(&b, "# %s\n\n", title)
+		for _, file := range part.Files {
+			fmt.Fprintf(&b, "- `%s`\n", file)
+		}
+		b.WriteString("\n")
+		return b.String() + part.Content
+
+	case ".jsonl":
+		data, _ := json.Marshal(struct {
+			Type  string   `json:"type"`
+			Part  int      `json:"part"`
+			Parts int      `json:"parts"`
+			Files []string `json:"files"`
+		}{"part", number, total, part.Files})
+		return string(data) + "\n" + part.Content
+
+	case ".json":
+		// A "part" member is added to the top-level object
+		rest, ok := strings.CutPrefix(part.Content, "{")
+		if !ok {
+			return part.Content
+		}
+		data, _ := json.Marshal(struct {
+			Number int      `json:"number"`
+			Of     int      `json:"of"`
+			Files  []string `json:"files"`
+		}{number, total, part.Files})
+		return fmt.Sprintf("{\n  \"part\": %s,", data) + rest
+
+	case ".xml":
+		var b strings.Builder
+		rest := part.Content
+		if declaration, after, ok := strings.Cut(rest, "\n"); ok && strings.HasPrefix(declaration, "<?xml") {
+			b.WriteString(declaration + "\n")
+			rest = after
+		}
+		fmt.Fprintf(&b, "<!-- %s:\n", title)
+		for _, file := range part.Files {
+			fmt.Fprintf(&b, "     %s\n", strings.ReplaceAll(file, "--", "- -"))
+		}
+		b.WriteString("-->\n")
+		return b.String() + rest
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:\n", title)
+	for _, file := range part.Files {
+		fmt.Fprintf(&b, "  %s\n", file)
+	}
+	b.WriteString("\n")
+	return b.String() + part.Content
+}
+
+// Index maps the files of split output to the parts containing them
+type Index struct {
+	MaxTokens int                 `json:"max_tokens"`
+	Parts     []*Part             `json:"parts"`
+	Files     map[string][]string `json:"files"`
+}
+
+// NewIndex builds the index of parts. Files divided between declarations
+// map to every part holding a piece of them.
+func NewIndex(parts []*Part, maxTokens int) *Index {
+	if parts == nil {
+		// Output without files still writes "parts": [] rather than null
+		parts = []*Part{}
+	}
+	index := &Index{MaxTokens: maxTokens, Parts: parts, Files: make(map[string][]string)}
+	for _, part := range parts {
+		for _, path := range part.paths {
+			names := index.Files[path]
+			if len(names) == 0 || names[len(names)-1] != part.Name {
+				index.Files[path] = append(names, part.Name)
+			}
+		}
+	}
+	return index
+}
+
+// Write writes the parts and the index into dir, replacing the parts of an
+// earlier run
+func Write(dir string, index *Index) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	stale, _ := filepath.Glob(filepath.Join(dir, "part-[0-9][0-9][0-9]*"))
+	for _, name := range stale {
+		os.Remove(name)
+	}
+
+	for _, part := range index.Parts {
+		if err := os.WriteFile(filepath.Join(dir, part.Name), []byte(part.Content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.Name, err)
+		}
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, IndexName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", IndexName, err)
+	}
+	return nil
+}
+
+func plural(count int) string {
+	if count == 1 {
+		return ""
+	}
+	return "s"
+}
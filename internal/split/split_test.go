@@ -0,0 +1,197 @@
+package split
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// render prints every file as a block whose size follows its declarations
+func render(files []*ir.DistilledFile) (string, error) {
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "<file path=%q>\n", file.Path)
+		for _, child := range file.Children {
+			switch n := child.(type) {
+			case *ir.DistilledImport:
+				fmt.Fprintf(&b, "import %s\n", n.Module)
+			case *ir.DistilledClass:
+				fmt.Fprintf(&b, "class %s %s\n", n.Name, strings.Repeat("x", 200))
+			}
+		}
+		b.WriteString("</file>\n")
+	}
+	return b.String(), nil
+}
+
+// classes builds a file with n classes of about 50 tokens each
+func classes(path string, n int) *ir.DistilledFile {
+	file := &ir.DistilledFile{Path: path, Children: []ir.DistilledNode{&ir.DistilledImport{Module: "os"}}}
+	for i := 0; i < n; i++ {
+		file.Children = append(file.Children, &ir.DistilledClass{Name: fmt.Sprintf("C%d", i)})
+	}
+	return file
+}
+
+func TestSplit(t *testing.T) {
+	files := []*ir.DistilledFile{classes("a.py", 2), classes("b.py", 2), classes("c.py", 1), classes("big.py", 6)}
+	parts, err := Split(files, render, Options{MaxTokens: 250, Extension: "txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, part := range parts {
+		got = append(got, part.Files)
+	}
+	want := [][]string{{"a.py", "b.py"}, {"c.py"}, {"big.py"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parts = %v, want %v", got, want)
+	}
+	for i, part := range parts {
+		if name := fmt.Sprintf("part-%03d.txt", i+1); part.Name != name {
+			t.Errorf("part %d is named %s", i+1, part.Name)
+		}
+		if part.Oversized != (i == 2) {
+			t.Errorf("%s: oversized = %v", part.Name, part.Oversized)
+		}
+		if !part.Oversized && part.Tokens > 250 {
+			t.Errorf("%s has %d tokens", part.Name, part.Tokens)
+		}
+	}
+	if !strings.HasPrefix(parts[0].Content, "Part 1 of 3, 2 files:\n  a.py\n  b.py\n\n<file") {
+		t.Errorf("unexpected header:\n%s", parts[0].Content)
+	}
+}
+
+func TestSplitDeclarations(t *testing.T) {
+	files := []*ir.DistilledFile{classes("small.py", 1), classes("big.py", 6)}
+	parts, err := Split(files, render, Options{MaxTokens: 250, Unit: UnitDeclaration})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var labels []string
+	for _, part := range parts {
+		if part.Oversized {
+			t.Errorf("%s is oversized: %v", part.Name, part.Files)
+		}
+		labels = append(labels, part.Files...)
+		// Every piece repeats the imports of its file
+		if strings.Count(part.Content, "import os") != len(part.Files) {
+			t.Errorf("%s lacks imports:\n%s", part.Name, part.Content)
+		}
+	}
+	want := []string{"small.py", "big.py (1/2)", "big.py (2/2)"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("pieces = %v, want %v", labels, want)
+	}
+	if total := strings.Count(strings.Join(contents(parts), ""), "class C"); total != 7 {
+		t.Errorf("%d classes in the parts, want 7", total)
+	}
+
+	index := NewIndex(parts, 250)
+	if got := index.Files["big.py"]; len(got) != 2 {
+		t.Errorf("big.py is in parts %v", got)
+	}
+}
+
+func contents(parts []*Part) []string {
+	result := make([]string, len(parts))
+	for i, part := range parts {
+		result[i] = part.Content
+	}
+	return result
+}
+
+func TestHeaders(t *testing.T) {
+	part := func(content string) *Part {
+		return &Part{Files: []string{"a.py"}, Content: content}
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(withHeader(".json", 1, 2, part("{\n  \"files\": []\n}\n"))), &object); err != nil {
+		t.Errorf("JSON part is invalid: %v", err)
+	} else if object["part"] == nil || object["files"] == nil {
+		t.Errorf("JSON part = %v", object)
+	}
+
+	lines := strings.Split(withHeader(".jsonl", 1, 2, part("{\"type\":\"file\"}\n")), "\n")
+	if lines[0] != `{"type":"part","part":1,"parts":2,"files":["a.py"]}` {
+		t.Errorf("JSONL header = %s", lines[0])
+	}
+
+	xml := withHeader(".xml", 1, 2, part("<?xml version=\"1.0\"?>\n<distilled>\n</distilled>\n"))
+	if !strings.HasPrefix(xml, "<?xml version=\"1.0\"?>\n<!-- Part 1 of 2, 1 file:\n     a.py\n-->\n<distilled>") {
+		t.Errorf("XML part:\n%s", xml)
+	}
+
+	if md := withHeader(".md", 2, 2, part("body")); !strings.HasPrefix(md, "# Part 2 of 2, 1 file\n\n- `a.py`\n\nbody") {
+		t.Errorf("Markdown part:\n%s", md)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Parts of an earlier run are replaced
+	if err := os.WriteFile(filepath.Join(dir, "part-009.txt"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parts, err := Split([]*ir.DistilledFile{classes("a.py", 1), classes("b.py", 1)}, render, Options{MaxTokens: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(dir, NewIndex(parts, 80)); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"index.json", "part-001.txt", "part-002.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files = %v, want %v", names, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, IndexName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"a.py": {"part-001.txt"}, "b.py": {"part-002.txt"}}; !reflect.DeepEqual(index.Files, want) {
+		t.Errorf("index files = %v, want %v", index.Files, want)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	dir := t.TempDir()
+	parts, err := Split(nil, render, Options{MaxTokens: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(dir, NewIndex(parts, 80)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, IndexName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"parts": []`) || !strings.Contains(string(data), `"files": {}`) {
+		t.Errorf("index without files:\n%s", data)
+	}
+}
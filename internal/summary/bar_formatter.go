@@ -80,6 +80,15 @@ func (f *BarFormatter) Format(w io.Writer, stats Stats) error {
 		)
 	}
 	
+	// Add cache usage if the cache was enabled
+	if stats.CacheEnabled {
+		cacheEmoji := "⚡"
+		if f.NoEmoji {
+			cacheEmoji = "|"
+		}
+		fmt.Fprintf(w, " %s %s", cacheEmoji, formatCacheStats(stats))
+	}
+	
 	fmt.Fprintln(w)
 	
 	// Add output path if not stdout on a new line
@@ -95,7 +104,7 @@ func (f *BarFormatter) Format(w io.Writer, stats Stats) error {
 		if f.NoEmoji {
 			aiEmoji = "→"
 		}
-		fmt.Fprintf(w, "%s If you're an AI agent, load this ENTIRE file into your context (don't use grep/tail/head) for a comprehensive code overview.\n", aiEmoji)
+		fmt.Fprintf(w, "%s %s\n", aiEmoji, agentHint(stats))
 	}
 	return nil
 }
@@ -35,6 +35,11 @@ func (f *CIFormatter) Format(w io.Writer, stats Stats) error {
 		)
 	}
 	
+	// Add cache usage if the cache was enabled
+	if stats.CacheEnabled {
+		fmt.Fprintf(w, " | %s", formatCacheStats(stats))
+	}
+	
 	// Add output path if not stdout
 	if !stats.IsStdout && stats.OutputPath != "" {
 		fmt.Fprintf(w, " | saved to: %s", stats.OutputPath)
@@ -44,7 +49,11 @@ func (f *CIFormatter) Format(w io.Writer, stats Stats) error {
 	
 	// Add AI agent recommendation for non-stdout output
 	if !stats.IsStdout && stats.OutputPath != "" {
-		fmt.Fprintln(w, "AI agent recommendation: Load the ENTIRE output file into your context (don't use grep/tail/head) for a comprehensive code overview.")
+		if stats.SplitParts > 0 {
+			fmt.Fprintf(w, "AI agent recommendation: Read the index first; it lists the %d part%s and the files in each. Then load each part you need ENTIRE (don't use grep/tail/head).\n", stats.SplitParts, partsSuffix(stats.SplitParts))
+		} else {
+			fmt.Fprintln(w, "AI agent recommendation: Load the ENTIRE output file into your context (don't use grep/tail/head) for a comprehensive code overview.")
+		}
 	}
 	
 	return nil
@@ -57,6 +57,17 @@ func (f *DashboardFormatter) Format(w io.Writer, stats Stats) error {
 		fmt.Fprintf(w, "║ Tokens saved: ~%-8s ║\n", formatTokenCount(tokensSaved))
 	}
 	
+	// Add cache usage if the cache was enabled
+	if stats.CacheEnabled {
+		cacheBar := buildProgressBar(stats.CacheHitRate(), 10)
+		fmt.Fprintf(w, "║ Cache: %s %3.0f%% ║ %d/%d files\n",
+			cacheBar,
+			stats.CacheHitRate(),
+			stats.CacheHits,
+			stats.CacheHits+stats.CacheMisses,
+		)
+	}
+	
 	fmt.Fprintln(w, "╚═════════════════════╝")
 	
 	// Add output path if not stdout below the box
@@ -72,7 +83,7 @@ func (f *DashboardFormatter) Format(w io.Writer, stats Stats) error {
 		if f.NoEmoji {
 			aiEmoji = "→"
 		}
-		fmt.Fprintf(w, "%s If you're an AI agent, load this ENTIRE file into your context (don't use grep/tail/head) for a comprehensive code overview.\n", aiEmoji)
+		fmt.Fprintf(w, "%s %s\n", aiEmoji, agentHint(stats))
 	}
 	
 	return nil
@@ -17,6 +17,39 @@ type Stats struct {
 	FileCount       int
 	OutputPath      string
 	IsStdout        bool
+
+	// SplitParts is the number of parts written by --split-tokens;
+	// OutputPath is then their index
+	SplitParts int
+
+	// CacheEnabled is set when results were looked up in the distillation cache
+	CacheEnabled bool
+	CacheHits    int64
+	CacheMisses  int64
+
+	// Ranking lists the files by importance when --rank or --top-files
+	// was used, most important first
+	Ranking []RankedFile
+}
+
+// RankedFile is the ranking of one file and whether it is in the output
+type RankedFile struct {
+	Path       string  `json:"path"`
+	Score      float64 `json:"score"`
+	Centrality float64 `json:"centrality"`
+	Dependents int     `json:"dependents"`
+	FanIn      int     `json:"fan_in"`
+	Churn      int     `json:"churn"`
+	Included   bool    `json:"included"`
+}
+
+// CacheHitRate returns the percentage of files served from the cache
+func (s Stats) CacheHitRate() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total) * 100
 }
 
 // Formatter defines the interface for summary formatters
@@ -32,6 +65,31 @@ func getCompressionRatio(original, distilled int64) float64 {
 	return (1 - float64(distilled)/float64(original)) * 100
 }
 
+// formatCacheStats formats cache usage as "12/15 cached (80%)"
+func formatCacheStats(stats Stats) string {
+	return fmt.Sprintf("%d/%d cached (%.0f%%)",
+		stats.CacheHits,
+		stats.CacheHits+stats.CacheMisses,
+		stats.CacheHitRate(),
+	)
+}
+
+// agentHint tells AI agents how to read the saved output
+func agentHint(stats Stats) string {
+	if stats.SplitParts > 0 {
+		return fmt.Sprintf("If you're an AI agent, read this index first: it lists the %d part%s and the files in each. Then load each part you need ENTIRE (don't use grep/tail/head).", stats.SplitParts, partsSuffix(stats.SplitParts))
+	}
+	return "If you're an AI agent, load this ENTIRE file into your context (don't use grep/tail/head) for a comprehensive code overview."
+}
+
+// partsSuffix returns the plural suffix for a number of parts
+func partsSuffix(count int) string {
+	if count == 1 {
+		return ""
+	}
+	return "s"
+}
+
 // getEmoji returns an appropriate emoji based on compression ratio
 func getEmoji(ratio float64) string {
 	switch {
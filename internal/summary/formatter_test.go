@@ -0,0 +1,141 @@
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheHitRate(t *testing.T) {
+	if rate := (Stats{}).CacheHitRate(); rate != 0 {
+		t.Errorf("expected 0%% without lookups, got %.1f", rate)
+	}
+	if rate := (Stats{CacheHits: 3, CacheMisses: 1}).CacheHitRate(); rate != 75 {
+		t.Errorf("expected 75%%, got %.1f", rate)
+	}
+}
+
+func TestFormattersShowCacheStats(t *testing.T) {
+	stats := Stats{
+		OriginalBytes:   10000,
+		DistilledBytes:  1000,
+		OriginalTokens:  2500,
+		DistilledTokens: 250,
+		Duration:        50 * time.Millisecond,
+		FileCount:       4,
+		IsStdout:        true,
+		CacheEnabled:    true,
+		CacheHits:       3,
+		CacheMisses:     1,
+	}
+
+	formats := map[string]string{
+		"ci-friendly":           "3/4 cached (75%)",
+		"visual-progress-bar":   "3/4 cached (75%)",
+		"minimalist-sparkline":  "3/4 cached (75%)",
+		"stock-ticker":          "CACHE: 3/4 (75%)",
+		"speedometer-dashboard": "3/4 files",
+	}
+
+	for format, expected := range formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Print(&buf, stats, Options{Format: format, NoColor: true}); err != nil {
+				t.Fatalf("Print failed: %v", err)
+			}
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("expected output to contain %q, got:\n%s", expected, buf.String())
+			}
+
+			// Without the cache there must be no cache line
+			buf.Reset()
+			noCache := stats
+			noCache.CacheEnabled = false
+			if err := Print(&buf, noCache, Options{Format: format, NoColor: true}); err != nil {
+				t.Fatalf("Print failed: %v", err)
+			}
+			if strings.Contains(strings.ToLower(buf.String()), "cache") {
+				t.Errorf("unexpected cache info without cache:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestFormattersPointToSplitIndex(t *testing.T) {
+	stats := Stats{
+		OriginalBytes:   10000,
+		DistilledBytes:  1000,
+		OriginalTokens:  2500,
+		DistilledTokens: 250,
+		FileCount:       4,
+		OutputPath:      ".aid/context/index.json",
+		SplitParts:      3,
+	}
+
+	for _, format := range []string{"ci-friendly", "visual-progress-bar", "minimalist-sparkline", "stock-ticker", "speedometer-dashboard"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Print(&buf, stats, Options{Format: format, NoColor: true}); err != nil {
+				t.Fatalf("Print failed: %v", err)
+			}
+			output := buf.String()
+			if !strings.Contains(output, ".aid/context/index.json") || !strings.Contains(output, "3 parts") {
+				t.Errorf("expected output to point to the index of 3 parts, got:\n%s", output)
+			}
+			if strings.Contains(output, "ENTIRE file") || strings.Contains(output, "ENTIRE output file") {
+				t.Errorf("split output is not one file:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestJSONFormatterCacheStats(t *testing.T) {
+	var buf bytes.Buffer
+	stats := Stats{CacheEnabled: true, CacheHits: 1, CacheMisses: 1}
+	if err := NewJSONFormatter().Format(&buf, stats); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if output.Cache == nil {
+		t.Fatal("expected cache section in JSON output")
+	}
+	if output.Cache.Hits != 1 || output.Cache.Misses != 1 || output.Cache.HitRatePct != 50 {
+		t.Errorf("unexpected cache section: %+v", *output.Cache)
+	}
+}
+
+func TestJSONFormatterRanking(t *testing.T) {
+	var buf bytes.Buffer
+	stats := Stats{Ranking: []RankedFile{
+		{Path: "core.py", Score: 0.9, Dependents: 3, Included: true},
+		{Path: "util.py", Score: 0.1},
+	}}
+	if err := NewJSONFormatter().Format(&buf, stats); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"included": false`) {
+		t.Errorf("expected excluded files in the ranking:\n%s", buf.String())
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(output.Ranking) != 2 || output.Ranking[0].Path != "core.py" || !output.Ranking[0].Included {
+		t.Errorf("unexpected ranking: %+v", output.Ranking)
+	}
+
+	buf.Reset()
+	if err := NewJSONFormatter().Format(&buf, Stats{}); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "ranking") {
+		t.Errorf("unexpected ranking without --rank:\n%s", buf.String())
+	}
+}
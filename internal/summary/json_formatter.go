@@ -15,17 +15,27 @@ func NewJSONFormatter() *JSONFormatter {
 
 // JSONOutput represents the JSON structure for summary output
 type JSONOutput struct {
-	OriginalBytes   int64   `json:"original_bytes"`
-	DistilledBytes  int64   `json:"distilled_bytes"`
-	SavingsPercent  float64 `json:"savings_pct"`
-	DurationMS      int64   `json:"duration_ms"`
-	TokensBefore    int64   `json:"tokens_before,omitempty"`
-	TokensAfter     int64   `json:"tokens_after,omitempty"`
-	TokensSaved     int64   `json:"tokens_saved,omitempty"`
-	TokenSavingsPct float64 `json:"token_savings_pct,omitempty"`
-	FileCount       int     `json:"file_count"`
-	OutputPath      string  `json:"output_path,omitempty"`
-	Tokenizer       string  `json:"tokenizer,omitempty"`
+	OriginalBytes   int64            `json:"original_bytes"`
+	DistilledBytes  int64            `json:"distilled_bytes"`
+	SavingsPercent  float64          `json:"savings_pct"`
+	DurationMS      int64            `json:"duration_ms"`
+	TokensBefore    int64            `json:"tokens_before,omitempty"`
+	TokensAfter     int64            `json:"tokens_after,omitempty"`
+	TokensSaved     int64            `json:"tokens_saved,omitempty"`
+	TokenSavingsPct float64          `json:"token_savings_pct,omitempty"`
+	FileCount       int              `json:"file_count"`
+	OutputPath      string           `json:"output_path,omitempty"`
+	SplitParts      int              `json:"split_parts,omitempty"`
+	Tokenizer       string           `json:"tokenizer,omitempty"`
+	Cache           *JSONCacheOutput `json:"cache,omitempty"`
+	Ranking         []RankedFile     `json:"ranking,omitempty"`
+}
+
+// JSONCacheOutput represents distillation cache usage
+type JSONCacheOutput struct {
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	HitRatePct float64 `json:"hit_rate_pct"`
 }
 
 // Format outputs the summary as JSON
@@ -37,8 +47,10 @@ func (f *JSONFormatter) Format(w io.Writer, stats Stats) error {
 		DurationMS:     stats.Duration.Milliseconds(),
 		FileCount:      stats.FileCount,
 		OutputPath:     stats.OutputPath,
+		SplitParts:     stats.SplitParts,
+		Ranking:        stats.Ranking,
 	}
-	
+
 	if stats.OriginalTokens > 0 && stats.DistilledTokens > 0 {
 		output.TokensBefore = stats.OriginalTokens
 		output.TokensAfter = stats.DistilledTokens
@@ -46,8 +58,16 @@ func (f *JSONFormatter) Format(w io.Writer, stats Stats) error {
 		output.TokenSavingsPct = getCompressionRatio(stats.OriginalTokens, stats.DistilledTokens)
 		output.Tokenizer = "cl100k_base" // GPT-4 tokenizer
 	}
-	
+
+	if stats.CacheEnabled {
+		output.Cache = &JSONCacheOutput{
+			Hits:       stats.CacheHits,
+			Misses:     stats.CacheMisses,
+			HitRatePct: stats.CacheHitRate(),
+		}
+	}
+
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(output)
-}
\ No newline at end of file
+}
@@ -53,16 +53,25 @@ func (f *SparklineFormatter) Format(w io.Writer, stats Stats) error {
 		)
 	}
 	
+	// Add cache usage if the cache was enabled
+	if stats.CacheEnabled {
+		cacheEmoji := "⚡"
+		if f.NoEmoji {
+			cacheEmoji = ""
+		}
+		fmt.Fprintf(w, "%s Cache: %s. ", cacheEmoji, formatCacheStats(stats))
+	}
+	
 	fmt.Fprintln(w)
 	
 	// Add output path if not stdout on a new line
 	if !stats.IsStdout && stats.OutputPath != "" {
 		if f.NoEmoji {
 			fmt.Fprintf(w, "→ Distilled output saved to: %s\n", stats.OutputPath)
-			fmt.Fprintf(w, "→ If you're an AI agent, load this ENTIRE file into your context (don't use grep/tail/head) for a comprehensive code overview.\n")
+			fmt.Fprintf(w, "→ %s\n", agentHint(stats))
 		} else {
 			fmt.Fprintf(w, "💾 Distilled output saved to: %s\n", stats.OutputPath)
-			fmt.Fprintf(w, "🤖 If you're an AI agent, load this ENTIRE file into your context (don't use grep/tail/head) for a comprehensive code overview.\n")
+			fmt.Fprintf(w, "🤖 %s\n", agentHint(stats))
 		}
 	}
 	return nil
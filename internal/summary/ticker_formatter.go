@@ -49,6 +49,15 @@ func (f *TickerFormatter) Format(w io.Writer, stats Stats) error {
 		)
 	}
 	
+	// Add cache usage if the cache was enabled
+	if stats.CacheEnabled {
+		fmt.Fprintf(w, " │ CACHE: %d/%d (%.0f%%)",
+			stats.CacheHits,
+			stats.CacheHits+stats.CacheMisses,
+			stats.CacheHitRate(),
+		)
+	}
+	
 	fmt.Fprintln(w)
 	
 	// Add output path if not stdout on a new line
@@ -64,7 +73,7 @@ func (f *TickerFormatter) Format(w io.Writer, stats Stats) error {
 		if f.NoEmoji {
 			aiEmoji = "→"
 		}
-		fmt.Fprintf(w, "%s If you're an AI agent, load this ENTIRE file into your context (don't use grep/tail/head) for a comprehensive code overview.\n", aiEmoji)
+		fmt.Fprintf(w, "%s %s\n", aiEmoji, agentHint(stats))
 	}
 	return nil
 }
\ No newline at end of file
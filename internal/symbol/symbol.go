@@ -0,0 +1,533 @@
+// Package symbol extracts a declaration together with the declarations it
+// depends on: the types named in its signature and the types, functions and
+// members its body refers to, followed transitively up to a depth limit.
+package symbol
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// Options configures Extract
+type Options struct {
+	// Depth is the number of reference levels followed from the matched
+	// declarations; 0 keeps only the declarations themselves
+	Depth int
+}
+
+// Result is the outcome of Extract
+type Result struct {
+	// Files holds the matched declarations and their dependencies, with
+	// enclosing types reduced to the selected members
+	Files []*ir.DistilledFile
+
+	// Matches lists the declarations matching the query as "name (path)"
+	Matches []string
+
+	// Dependencies is the number of declarations added by following
+	// references, not counting members of selected types
+	Dependencies int
+}
+
+// decl is one named declaration in the index
+type decl struct {
+	name      string
+	qualified string
+	node      ir.DistilledNode
+	file      *ir.DistilledFile
+	// owner is the qualified name of the type this declaration is a member
+	// of; for Go methods this is the receiver type
+	owner string
+}
+
+// isType reports whether the declaration is a type
+func (d *decl) isType() bool {
+	switch d.node.(type) {
+	case *ir.DistilledClass, *ir.DistilledInterface, *ir.DistilledStruct, *ir.DistilledEnum, *ir.DistilledTypeAlias:
+		return true
+	}
+	return false
+}
+
+// index finds declarations by name
+type index struct {
+	decls []*decl
+	// types maps simple names to type declarations
+	types map[string][]*decl
+	// functions maps simple names to functions that are not members
+	functions map[string][]*decl
+	// members maps qualified type names to their members
+	members map[string][]*decl
+}
+
+var (
+	identifierPattern = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+	callPattern       = regexp.MustCompile(`([A-Za-z_$][A-Za-z0-9_$]*)\s*\(`)
+	memberPattern     = regexp.MustCompile(`([A-Za-z_$][A-Za-z0-9_$]*)\s*(?:\.|::|->)\s*([A-Za-z_$][A-Za-z0-9_$]*)`)
+	methodCallPattern = regexp.MustCompile(`(?:\.|::|->)\s*([A-Za-z_$][A-Za-z0-9_$]*)\s*\(`)
+)
+
+// Extract selects the declarations matching query and everything they
+// reference up to opts.Depth levels. The query is a qualified name such as
+// "UserService.create"; "::" and "#" may be used as separators and a suffix
+// of the qualified name is enough. All matching declarations are selected.
+// The input files are not modified. Files should be distilled with
+// implementations, otherwise only signatures are followed.
+func Extract(files []*ir.DistilledFile, query string, opts Options) (*Result, error) {
+	idx := newIndex(files)
+
+	matches := idx.match(query)
+	if len(matches) == 0 {
+		if suggestions := idx.suggest(query); len(suggestions) > 0 {
+			return nil, fmt.Errorf("symbol not found: %s (did you mean %s?)", query, strings.Join(suggestions, ", "))
+		}
+		return nil, fmt.Errorf("symbol not found: %s", query)
+	}
+
+	result := &Result{}
+	selected := make(map[*decl]bool)
+	var frontier []*decl
+	for _, d := range matches {
+		result.Matches = append(result.Matches, fmt.Sprintf("%s (%s)", d.qualified, d.file.Path))
+		frontier = append(frontier, idx.expand(d, selected)...)
+	}
+
+	for depth := 0; depth < opts.Depth && len(frontier) > 0; depth++ {
+		var next []*decl
+		for _, d := range frontier {
+			for _, ref := range idx.references(d) {
+				if selected[ref] {
+					continue
+				}
+				result.Dependencies++
+				next = append(next, idx.expand(ref, selected)...)
+			}
+		}
+		frontier = next
+	}
+
+	keep := make(map[ir.DistilledNode]bool, len(selected))
+	for d := range selected {
+		keep[d.node] = true
+	}
+	for _, file := range files {
+		children, found := filterNodes(file.Children, keep, true)
+		if !found {
+			continue
+		}
+		copied := *file
+		copied.Children = children
+		result.Files = append(result.Files, &copied)
+	}
+	return result, nil
+}
+
+// newIndex collects the declarations of all files
+func newIndex(files []*ir.DistilledFile) *index {
+	idx := &index{
+		types:     make(map[string][]*decl),
+		functions: make(map[string][]*decl),
+		members:   make(map[string][]*decl),
+	}
+	for _, file := range files {
+		idx.add(file, file.Children, "")
+	}
+	return idx
+}
+
+// add indexes nodes declared in scope, the qualified name of the enclosing
+// type or "" at file level
+func (idx *index) add(file *ir.DistilledFile, nodes []ir.DistilledNode, scope string) {
+	for _, node := range nodes {
+		if pkg, ok := node.(*ir.DistilledPackage); ok {
+			idx.add(file, pkg.Children, scope)
+			continue
+		}
+		name := declarationName(node)
+		if name == "" {
+			continue
+		}
+
+		d := &decl{name: name, qualified: qualify(scope, name), node: node, file: file, owner: scope}
+		if receiver := goReceiver(node, file.Language); receiver != "" {
+			d.qualified = receiver + "." + name
+			d.owner = receiver
+		}
+		idx.decls = append(idx.decls, d)
+
+		switch {
+		case d.isType():
+			idx.types[name] = append(idx.types[name], d)
+		case d.owner == "":
+			if _, ok := node.(*ir.DistilledFunction); ok {
+				idx.functions[name] = append(idx.functions[name], d)
+			}
+		}
+		if d.owner != "" {
+			idx.members[d.owner] = append(idx.members[d.owner], d)
+		}
+
+		if children := containerChildren(node); len(children) > 0 {
+			idx.add(file, children, d.qualified)
+		}
+	}
+}
+
+// match returns the declarations whose qualified name is the query or ends
+// with it
+func (idx *index) match(query string) []*decl {
+	query = normalizeQuery(query)
+	var matches []*decl
+	for _, d := range idx.decls {
+		if d.qualified == query || strings.HasSuffix(d.qualified, "."+query) {
+			matches = append(matches, d)
+		}
+	}
+	return matches
+}
+
+// suggest lists declarations whose name contains the last part of the
+// query, ignoring case
+func (idx *index) suggest(query string) []string {
+	query = strings.ToLower(normalizeQuery(query))
+	if i := strings.LastIndex(query, "."); i >= 0 {
+		query = query[i+1:]
+	}
+	seen := make(map[string]bool)
+	var suggestions []string
+	for _, d := range idx.decls {
+		if strings.Contains(strings.ToLower(d.name), query) && !seen[d.qualified] {
+			seen[d.qualified] = true
+			suggestions = append(suggestions, d.qualified)
+		}
+	}
+	sort.Strings(suggestions)
+	if len(suggestions) > 5 {
+		suggestions = suggestions[:5]
+	}
+	return suggestions
+}
+
+// expand selects a declaration and, for types, all their members. It
+// returns the newly selected declarations.
+func (idx *index) expand(d *decl, selected map[*decl]bool) []*decl {
+	if selected[d] {
+		return nil
+	}
+	selected[d] = true
+	added := []*decl{d}
+	if d.isType() {
+		for _, member := range idx.members[d.qualified] {
+			added = append(added, idx.expand(member, selected)...)
+		}
+	}
+	return added
+}
+
+// references returns the declarations a declaration refers to
+func (idx *index) references(d *decl) []*decl {
+	var refs []*decl
+	seen := map[*decl]bool{d: true}
+	add := func(candidates []*decl) {
+		for _, c := range preferFile(candidates, d.file) {
+			if !seen[c] {
+				seen[c] = true
+				refs = append(refs, c)
+			}
+		}
+	}
+
+	// Types named in the signature
+	for _, t := range signatureTypes(d.node, goReceiver(d.node, d.file.Language) != "") {
+		for _, name := range identifierPattern.FindAllString(t.Name, -1) {
+			add(idx.types[name])
+		}
+	}
+
+	body := bodyOf(d.node)
+	if body == "" {
+		return refs
+	}
+	for _, name := range identifierPattern.FindAllString(body, -1) {
+		add(idx.types[name])
+		if d.owner != "" {
+			add(idx.membersNamed(d.owner, name))
+		}
+	}
+	for _, call := range callPattern.FindAllStringSubmatch(body, -1) {
+		add(idx.functions[call[1]])
+	}
+	for _, access := range memberPattern.FindAllStringSubmatch(body, -1) {
+		for _, t := range idx.types[access[1]] {
+			add(idx.membersNamed(t.qualified, access[2]))
+		}
+	}
+	// The type of other receivers is unknown, so a method call is only
+	// followed when a single type has a method of that name
+	for _, call := range methodCallPattern.FindAllStringSubmatch(body, -1) {
+		if methods := idx.methodsNamed(call[1]); len(methods) == 1 {
+			add(methods)
+		}
+	}
+	return refs
+}
+
+// membersNamed returns the members of a type with the given name
+func (idx *index) membersNamed(owner, name string) []*decl {
+	var result []*decl
+	for _, member := range idx.members[owner] {
+		if member.name == name {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+// methodsNamed returns the methods with the given name in all types
+func (idx *index) methodsNamed(name string) []*decl {
+	var result []*decl
+	for _, d := range idx.decls {
+		if _, ok := d.node.(*ir.DistilledFunction); ok && d.owner != "" && d.name == name {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// preferFile narrows candidates to those declared in file, if there are any
+func preferFile(candidates []*decl, file *ir.DistilledFile) []*decl {
+	var local []*decl
+	for _, c := range candidates {
+		if c.file == file {
+			local = append(local, c)
+		}
+	}
+	if len(local) > 0 {
+		return local
+	}
+	return candidates
+}
+
+// signatureTypes returns the type references in a declaration's signature.
+// The receiver of a Go method is skipped, like the enclosing class of a
+// method in other languages.
+func signatureTypes(node ir.DistilledNode, skipReceiver bool) []ir.TypeRef {
+	var types []ir.TypeRef
+	addParams := func(params []ir.TypeParam) {
+		for _, p := range params {
+			types = append(types, p.Constraints...)
+			if p.Default != nil {
+				types = append(types, *p.Default)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *ir.DistilledFunction:
+		addParams(n.TypeParams)
+		params := n.Parameters
+		if skipReceiver {
+			params = params[1:]
+		}
+		for _, p := range params {
+			types = append(types, p.Type)
+		}
+		if n.Returns != nil {
+			types = append(types, *n.Returns)
+		}
+		types = append(types, n.Throws...)
+	case *ir.DistilledField:
+		if n.Type != nil {
+			types = append(types, *n.Type)
+		}
+	case *ir.DistilledClass:
+		addParams(n.TypeParams)
+		types = append(types, n.Extends...)
+		types = append(types, n.Implements...)
+		types = append(types, n.Mixins...)
+	case *ir.DistilledInterface:
+		addParams(n.TypeParams)
+		types = append(types, n.Extends...)
+	case *ir.DistilledStruct:
+		addParams(n.TypeParams)
+	case *ir.DistilledEnum:
+		if n.Type != nil {
+			types = append(types, *n.Type)
+		}
+	case *ir.DistilledTypeAlias:
+		addParams(n.TypeParams)
+		types = append(types, n.Type)
+	}
+
+	// Type arguments such as List<User> are references too
+	for i := 0; i < len(types); i++ {
+		types = append(types, types[i].TypeArgs...)
+	}
+	return types
+}
+
+// bodyOf returns the source text whose identifiers count as references
+func bodyOf(node ir.DistilledNode) string {
+	switch n := node.(type) {
+	case *ir.DistilledFunction:
+		return n.Implementation
+	case *ir.DistilledField:
+		return n.DefaultValue
+	}
+	return ""
+}
+
+// filterNodes keeps the selected nodes, the enclosing declarations of
+// selected members with only those members, and comments directly above
+// kept nodes. At file level, imports and package clauses are kept too.
+// It reports whether anything was selected.
+func filterNodes(nodes []ir.DistilledNode, keep map[ir.DistilledNode]bool, fileLevel bool) ([]ir.DistilledNode, bool) {
+	kept := make([]ir.DistilledNode, len(nodes))
+	found := false
+	for i, node := range nodes {
+		if keep[node] {
+			kept[i] = node
+			found = true
+			continue
+		}
+		if children := containerChildren(node); len(children) > 0 {
+			if filtered, ok := filterNodes(children, keep, false); ok {
+				kept[i] = withChildren(node, filtered)
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	// Walk backwards so that a block of comments is kept as a whole
+	for i := len(nodes) - 2; i >= 0; i-- {
+		comment, ok := nodes[i].(*ir.DistilledComment)
+		if ok && kept[i+1] != nil && adjacent(comment, nodes[i+1]) {
+			kept[i] = comment
+		}
+	}
+
+	var result []ir.DistilledNode
+	for i, node := range kept {
+		if node == nil && fileLevel && isFileHeader(nodes[i]) {
+			node = nodes[i]
+		}
+		if node != nil {
+			result = append(result, node)
+		}
+	}
+	return result, true
+}
+
+// adjacent reports whether a comment ends on the line before the node
+func adjacent(comment *ir.DistilledComment, node ir.DistilledNode) bool {
+	end := comment.GetLocation().EndLine
+	start := node.GetLocation().StartLine
+	return end == 0 || start == 0 || start-end <= 1
+}
+
+// isFileHeader reports whether a node is an import or a package clause
+func isFileHeader(node ir.DistilledNode) bool {
+	switch n := node.(type) {
+	case *ir.DistilledImport:
+		return true
+	case *ir.DistilledPackage:
+		return len(n.Children) == 0
+	}
+	return false
+}
+
+// normalizeQuery converts other member separators to "."
+func normalizeQuery(query string) string {
+	return strings.NewReplacer("::", ".", "#", ".", "->", ".").Replace(strings.TrimSpace(query))
+}
+
+// declarationName returns the name of a declaration, or "" for other nodes
+func declarationName(node ir.DistilledNode) string {
+	switch n := node.(type) {
+	case *ir.DistilledFunction:
+		return n.Name
+	case *ir.DistilledField:
+		return n.Name
+	case *ir.DistilledClass:
+		return n.Name
+	case *ir.DistilledInterface:
+		return n.Name
+	case *ir.DistilledStruct:
+		return n.Name
+	case *ir.DistilledEnum:
+		return n.Name
+	case *ir.DistilledTypeAlias:
+		return n.Name
+	}
+	return ""
+}
+
+// containerChildren returns the members of container declarations
+func containerChildren(node ir.DistilledNode) []ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledClass, *ir.DistilledInterface, *ir.DistilledStruct, *ir.DistilledEnum, *ir.DistilledPackage:
+		return n.GetChildren()
+	}
+	return nil
+}
+
+// withChildren returns a shallow copy of a container with the given members
+func withChildren(node ir.DistilledNode, children []ir.DistilledNode) ir.DistilledNode {
+	switch n := node.(type) {
+	case *ir.DistilledClass:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledInterface:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledStruct:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledEnum:
+		c := *n
+		c.Children = children
+		return &c
+	case *ir.DistilledPackage:
+		c := *n
+		c.Children = children
+		return &c
+	}
+	return node
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// goReceiver returns the receiver type of a Go method, or "". The Go parser
+// represents methods as functions marked abstract whose first parameter is
+// the receiver.
+func goReceiver(node ir.DistilledNode, language string) string {
+	fn, ok := node.(*ir.DistilledFunction)
+	if !ok || language != "go" || len(fn.Parameters) == 0 {
+		return ""
+	}
+	for _, modifier := range fn.Modifiers {
+		if modifier == ir.ModifierAbstract {
+			receiver := strings.TrimLeft(fn.Parameters[0].Type.Name, "*")
+			if i := strings.IndexByte(receiver, '['); i >= 0 {
+				receiver = receiver[:i]
+			}
+			return receiver
+		}
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package symbol
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// outline lists the kept declarations of each file by qualified name
+func outline(files []*ir.DistilledFile) []string {
+	var lines []string
+	var walk func(path, scope string, nodes []ir.DistilledNode)
+	walk = func(path, scope string, nodes []ir.DistilledNode) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *ir.DistilledComment:
+				lines = append(lines, fmt.Sprintf("%s: # %s", path, n.Text))
+			case *ir.DistilledImport:
+				lines = append(lines, fmt.Sprintf("%s: import %s", path, n.Module))
+			default:
+				name := qualify(scope, declarationName(node))
+				lines = append(lines, fmt.Sprintf("%s: %s", path, name))
+				walk(path, name, node.GetChildren())
+			}
+		}
+	}
+	for _, file := range files {
+		walk(file.Path, "", file.Children)
+	}
+	return lines
+}
+
+func pythonFiles() []*ir.DistilledFile {
+	return []*ir.DistilledFile{
+		{Path: "models.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledClass{Name: "Address", Children: []ir.DistilledNode{
+				&ir.DistilledField{Name: "city", Type: &ir.TypeRef{Name: "str"}},
+			}},
+			&ir.DistilledClass{Name: "User", Children: []ir.DistilledNode{
+				&ir.DistilledFunction{Name: "__init__", Parameters: []ir.Parameter{
+					{Name: "self"}, {Name: "address", Type: ir.TypeRef{Name: "Address"}},
+				}},
+			}},
+			&ir.DistilledClass{Name: "Order"},
+		}},
+		{Path: "service.py", Language: "python", Children: []ir.DistilledNode{
+			&ir.DistilledImport{Module: "models"},
+			&ir.DistilledFunction{Name: "validate", Implementation: "return bool(name)"},
+			&ir.DistilledFunction{Name: "unrelated", Implementation: "return Order()"},
+			&ir.DistilledClass{Name: "Repository", Children: []ir.DistilledNode{
+				&ir.DistilledFunction{Name: "save", Parameters: []ir.Parameter{{Name: "user", Type: ir.TypeRef{Name: "User"}}}},
+			}},
+			&ir.DistilledClass{Name: "UserService", Children: []ir.DistilledNode{
+				&ir.DistilledFunction{Name: "__init__"},
+				&ir.DistilledComment{Text: "Creates a user", Format: "line", BaseNode: ir.BaseNode{Location: ir.Location{StartLine: 9, EndLine: 9}}},
+				&ir.DistilledFunction{Name: "create", BaseNode: ir.BaseNode{Location: ir.Location{StartLine: 10, EndLine: 14}},
+					Returns:        &ir.TypeRef{Name: "Optional", TypeArgs: []ir.TypeRef{{Name: "User"}}},
+					Implementation: "if not validate(name):\n    return None\nself._repo.save(User(name))\nself.audit()",
+				},
+				&ir.DistilledFunction{Name: "audit", Implementation: "pass"},
+				&ir.DistilledFunction{Name: "delete"},
+			}},
+		}},
+	}
+}
+
+func TestExtractDepth(t *testing.T) {
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{
+			"service.py: import models",
+			"service.py: UserService",
+			"service.py: # Creates a user",
+			"service.py: UserService.create",
+		}},
+		{1, []string{
+			"models.py: User",
+			"models.py: User.__init__",
+			"service.py: import models",
+			"service.py: validate",
+			"service.py: Repository",
+			"service.py: Repository.save",
+			"service.py: UserService",
+			"service.py: # Creates a user",
+			"service.py: UserService.create",
+			"service.py: UserService.audit",
+		}},
+		{2, []string{
+			"models.py: Address",
+			"models.py: Address.city",
+			"models.py: User",
+			"models.py: User.__init__",
+			"service.py: import models",
+			"service.py: validate",
+			"service.py: Repository",
+			"service.py: Repository.save",
+			"service.py: UserService",
+			"service.py: # Creates a user",
+			"service.py: UserService.create",
+			"service.py: UserService.audit",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("depth %d", tt.depth), func(t *testing.T) {
+			files := pythonFiles()
+			result, err := Extract(files, "UserService.create", Options{Depth: tt.depth})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(result.Files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if !reflect.DeepEqual(result.Matches, []string{"UserService.create (service.py)"}) {
+				t.Errorf("matches = %v", result.Matches)
+			}
+			// The input is not modified
+			if n := len(files[1].Children[4].GetChildren()); n != 5 {
+				t.Errorf("UserService has %d members after extraction, want 5", n)
+			}
+		})
+	}
+}
+
+func TestExtractGoMethod(t *testing.T) {
+	files := []*ir.DistilledFile{{Path: "service.go", Language: "go", Children: []ir.DistilledNode{
+		&ir.DistilledPackage{Name: "store"},
+		&ir.DistilledStruct{Name: "User"},
+		&ir.DistilledStruct{Name: "Service", Children: []ir.DistilledNode{
+			&ir.DistilledField{Name: "repo", Type: &ir.TypeRef{Name: "Repository"}},
+			&ir.DistilledField{Name: "limit", Type: &ir.TypeRef{Name: "int"}},
+		}},
+		&ir.DistilledInterface{Name: "Repository"},
+		&ir.DistilledFunction{Name: "Create", Modifiers: []ir.Modifier{ir.ModifierAbstract},
+			Parameters:     []ir.Parameter{{Name: "s", Type: ir.TypeRef{Name: "*Service"}}, {Name: "u", Type: ir.TypeRef{Name: "*User"}}},
+			Implementation: "return s.repo.Save(u)",
+		},
+		&ir.DistilledFunction{Name: "Delete", Modifiers: []ir.Modifier{ir.ModifierAbstract},
+			Parameters: []ir.Parameter{{Name: "s", Type: ir.TypeRef{Name: "*Service"}}},
+		},
+	}}}
+
+	result, err := Extract(files, "Service.Create", Options{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"service.go: ",
+		"service.go: User",
+		"service.go: Service",
+		"service.go: Service.repo",
+		"service.go: Create",
+	}
+	if got := outline(result.Files); !reflect.DeepEqual(got, want) {
+		t.Errorf("outline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Selecting the type brings its methods
+	result, err = Extract(files, "Service", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outline(result.Files); len(got) != 6 {
+		t.Errorf("outline of Service:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestExtractQuery(t *testing.T) {
+	files := pythonFiles()
+
+	// A suffix matches every declaration with that name
+	result, err := Extract(files, "__init__", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"User.__init__ (models.py)", "UserService.__init__ (service.py)"}
+	if !reflect.DeepEqual(result.Matches, want) {
+		t.Errorf("matches = %v, want %v", result.Matches, want)
+	}
+
+	if _, err := Extract(files, "UserService::create", Options{}); err != nil {
+		t.Errorf("'::' separator: %v", err)
+	}
+
+	_, err = Extract(files, "Service.creat", Options{})
+	if err == nil || !strings.Contains(err.Error(), "did you mean UserService.create?") {
+		t.Errorf("error = %v, want a suggestion", err)
+	}
+}
@@ -208,6 +208,8 @@ func findSourceFile(dir, language string) (string, error) {
 		"kotlin":     {".kt"},
 		"php":        {".php"},
 		"ruby":       {".rb"},
+		"scala":      {".scala"},
+		"dart":       {".dart"},
 	}
 	
 	exts, ok := extensions[language]
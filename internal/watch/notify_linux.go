@@ -0,0 +1,153 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// notifyMask selects the inotify events that can change distilled output
+const notifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// notifyBackend watches every directory of the tree with inotify
+type notifyBackend struct {
+	w       *Watcher
+	skipDir func(string) bool
+	fd      int
+	file    *os.File
+
+	mu      sync.Mutex
+	watches map[int32]string
+
+	stopped chan struct{}
+}
+
+func newNotifyBackend(w *Watcher, skipDir func(string) bool) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+
+	n := &notifyBackend{
+		w:       w,
+		skipDir: skipDir,
+		fd:      fd,
+		// A non-blocking descriptor is served by the runtime poller, so
+		// closing the file interrupts a pending Read
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		watches: make(map[int32]string),
+		stopped: make(chan struct{}),
+	}
+
+	if err := n.addTree(w.root); err != nil {
+		n.file.Close()
+		return nil, err
+	}
+
+	go n.readLoop()
+	return n, nil
+}
+
+// addTree adds watches for dir and all its subdirectories
+func (n *notifyBackend) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Directories may disappear while walking
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != n.w.root && n.skipDir(path) {
+			return filepath.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(n.fd, path, notifyMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOSPC) {
+				return fmt.Errorf("inotify watch limit reached at %s (raise fs.inotify.max_user_watches)", path)
+			}
+			return nil // Unreadable directories are skipped
+		}
+
+		n.mu.Lock()
+		n.watches[int32(wd)] = path
+		n.mu.Unlock()
+		return nil
+	})
+}
+
+// readLoop decodes inotify events until the descriptor is closed
+func (n *notifyBackend) readLoop() {
+	defer close(n.stopped)
+
+	buf := make([]byte, 64*1024)
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				n.w.reportError(fmt.Errorf("inotify read: %w", err))
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			n.handle(event.Wd, event.Mask, name)
+		}
+	}
+}
+
+// handle turns one inotify event into a change notification
+func (n *notifyBackend) handle(wd int32, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Events were lost, ask for a rescan of everything
+		n.w.notify(n.w.root)
+		return
+	}
+
+	n.mu.Lock()
+	dir, ok := n.watches[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(n.watches, wd)
+	}
+	n.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+
+	// New directories need watches of their own; report them so that any
+	// files created before the watch was added are picked up by a rescan
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		if n.skipDir(path) {
+			return
+		}
+		if err := n.addTree(path); err != nil {
+			n.w.reportError(err)
+		}
+	}
+
+	n.w.notify(path)
+}
+
+func (n *notifyBackend) close() error {
+	err := n.file.Close()
+	<-n.stopped
+	return err
+}
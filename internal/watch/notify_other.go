@@ -0,0 +1,118 @@
+//go:build !linux
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// notifyBackend watches every directory of the tree with fsnotify, which
+// uses kqueue on macOS and the BSDs and ReadDirectoryChangesW on Windows
+type notifyBackend struct {
+	w       *Watcher
+	skipDir func(string) bool
+	watcher *fsnotify.Watcher
+
+	stopped chan struct{}
+}
+
+func newNotifyBackend(w *Watcher, skipDir func(string) bool) (backend, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("fsnotify init: %w", err)
+	}
+
+	n := &notifyBackend{
+		w:       w,
+		skipDir: skipDir,
+		watcher: watcher,
+		stopped: make(chan struct{}),
+	}
+
+	if err := n.addTree(w.root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go n.readLoop()
+	return n, nil
+}
+
+// addTree adds watches for dir and all its subdirectories
+func (n *notifyBackend) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Directories may disappear while walking
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != n.w.root && n.skipDir(path) {
+			return filepath.SkipDir
+		}
+
+		if err := n.watcher.Add(path); err != nil {
+			if path == n.w.root {
+				return fmt.Errorf("fsnotify watch %s: %w", path, err)
+			}
+			return nil // Unreadable directories are skipped
+		}
+		return nil
+	})
+}
+
+// readLoop forwards fsnotify events until the watcher is closed
+func (n *notifyBackend) readLoop() {
+	defer close(n.stopped)
+
+	for {
+		select {
+		case event, ok := <-n.watcher.Events:
+			if !ok {
+				return
+			}
+			n.handle(event)
+		case err, ok := <-n.watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were lost, ask for a rescan of everything
+				n.w.notify(n.w.root)
+				continue
+			}
+			n.w.reportError(fmt.Errorf("fsnotify: %w", err))
+		}
+	}
+}
+
+// handle turns one fsnotify event into a change notification
+func (n *notifyBackend) handle(event fsnotify.Event) {
+	path := filepath.Clean(event.Name)
+
+	// New directories need watches of their own; report them so that any
+	// files created before the watch was added are picked up by a rescan
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if n.skipDir(path) {
+				return
+			}
+			if err := n.addTree(path); err != nil {
+				n.w.reportError(err)
+			}
+		}
+	}
+
+	n.w.notify(path)
+}
+
+func (n *notifyBackend) close() error {
+	err := n.watcher.Close()
+	<-n.stopped
+	return err
+}
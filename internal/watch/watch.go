@@ -0,0 +1,254 @@
+// Package watch reports changes below a directory tree. It uses inotify on
+// Linux and fsnotify (kqueue, ReadDirectoryChangesW) elsewhere, and falls
+// back to periodic scanning where filesystem notifications are not available.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watch methods reported by Watcher.Method
+const (
+	MethodNotify = "notify"
+	MethodPoll   = "poll"
+)
+
+// Options configures a Watcher
+type Options struct {
+	// SkipDir excludes a directory and everything below it
+	SkipDir func(path string) bool
+
+	// Debounce groups changes arriving within this interval (default: 100ms)
+	Debounce time.Duration
+
+	// PollInterval is the scan interval when polling (default: 1s)
+	PollInterval time.Duration
+
+	// ForcePoll disables filesystem notifications
+	ForcePoll bool
+}
+
+// backend produces raw change notifications
+type backend interface {
+	close() error
+}
+
+// Watcher reports batches of changed paths. A batch may contain files and
+// directories; deleted paths are reported too. A changed directory means
+// its contents should be rescanned.
+type Watcher struct {
+	root    string
+	method  string
+	backend backend
+
+	raw     chan string
+	changes chan []string
+	errors  chan error
+	done    chan struct{}
+
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// New starts watching root
+func New(root string, opts Options) (*Watcher, error) {
+	if opts.SkipDir == nil {
+		opts.SkipDir = func(string) bool { return false }
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 100 * time.Millisecond
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		root:    absRoot,
+		raw:     make(chan string, 256),
+		changes: make(chan []string),
+		errors:  make(chan error, 16),
+		done:    make(chan struct{}),
+	}
+
+	if !opts.ForcePoll {
+		w.backend, err = newNotifyBackend(w, opts.SkipDir)
+		if err == nil {
+			w.method = MethodNotify
+		}
+	}
+	if w.backend == nil {
+		w.backend = newPollBackend(w, opts.SkipDir, opts.PollInterval)
+		w.method = MethodPoll
+	}
+
+	w.wg.Add(1)
+	go w.debounce(opts.Debounce)
+	return w, nil
+}
+
+// Method returns how changes are detected: MethodNotify or MethodPoll
+func (w *Watcher) Method() string {
+	return w.method
+}
+
+// Changes returns the channel of sorted, de-duplicated change batches
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Errors returns non-fatal errors encountered while watching
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops watching and closes the Changes channel
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.backend.close()
+		w.wg.Wait()
+		close(w.changes)
+	})
+	return err
+}
+
+// notify queues a raw change from a backend
+func (w *Watcher) notify(path string) {
+	select {
+	case w.raw <- path:
+	case <-w.done:
+	}
+}
+
+// reportError passes an error to Errors without blocking
+func (w *Watcher) reportError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// debounce collects raw changes and emits them as batches once they settle
+func (w *Watcher) debounce(interval time.Duration) {
+	defer w.wg.Done()
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(interval)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case path := <-w.raw:
+			pending[path] = true
+			timer.Reset(interval)
+		case <-timer.C:
+			batch := make([]string, 0, len(pending))
+			for path := range pending {
+				batch = append(batch, path)
+			}
+			sort.Strings(batch)
+			pending = make(map[string]bool)
+
+			select {
+			case w.changes <- batch:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// fileState is what the poller compares between scans
+type fileState struct {
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// pollBackend detects changes by scanning the tree periodically
+type pollBackend struct {
+	w       *Watcher
+	skipDir func(string) bool
+	state   map[string]fileState
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newPollBackend(w *Watcher, skipDir func(string) bool, interval time.Duration) *pollBackend {
+	p := &pollBackend{
+		w:       w,
+		skipDir: skipDir,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	p.state = p.scan()
+
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.poll()
+			}
+		}
+	}()
+	return p
+}
+
+// scan records the state of every file and directory below the root
+func (p *pollBackend) scan() map[string]fileState {
+	state := make(map[string]fileState)
+	filepath.Walk(p.w.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Files may disappear while scanning
+		}
+		if info.IsDir() && path != p.w.root && p.skipDir(path) {
+			return filepath.SkipDir
+		}
+		state[path] = fileState{size: info.Size(), modTime: info.ModTime(), isDir: info.IsDir()}
+		return nil
+	})
+	return state
+}
+
+// poll compares a fresh scan with the previous one and reports differences
+func (p *pollBackend) poll() {
+	current := p.scan()
+	for path, now := range current {
+		before, existed := p.state[path]
+		if !existed {
+			p.w.notify(path)
+		} else if !now.isDir && (now.size != before.size || !now.modTime.Equal(before.modTime)) {
+			p.w.notify(path)
+		}
+	}
+	for path := range p.state {
+		if _, exists := current[path]; !exists {
+			p.w.notify(path)
+		}
+	}
+	p.state = current
+}
+
+func (p *pollBackend) close() error {
+	close(p.stop)
+	<-p.stopped
+	return nil
+}
@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor reads batches until one contains want or the timeout expires
+func waitFor(t *testing.T, w *Watcher, want string) []string {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case batch := <-w.Changes():
+			for _, path := range batch {
+				if path == want {
+					return batch
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no change reported for %s", want)
+			return nil
+		}
+	}
+}
+
+func testWatcher(t *testing.T, forcePoll bool) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "skipped"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(root, Options{
+		SkipDir:      func(path string) bool { return strings.HasSuffix(path, "skipped") },
+		Debounce:     20 * time.Millisecond,
+		PollInterval: 20 * time.Millisecond,
+		ForcePoll:    forcePoll,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if forcePoll && w.Method() != MethodPoll {
+		t.Errorf("expected poll method, got %s", w.Method())
+	}
+
+	// Created file
+	file := filepath.Join(root, "a.py")
+	if err := os.WriteFile(file, []byte("x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, w, file)
+
+	// New directory and a file inside it
+	sub := filepath.Join(root, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, w, sub)
+	nested := filepath.Join(sub, "b.py")
+	if err := os.WriteFile(nested, []byte("y = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, w, nested)
+
+	// Changes in skipped directories are not reported
+	if err := os.WriteFile(filepath.Join(root, "skipped", "c.py"), []byte("z = 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleted file
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	batch := waitFor(t, w, file)
+	for _, path := range batch {
+		if strings.Contains(path, "skipped") {
+			t.Errorf("change in skipped directory reported: %s", path)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if _, ok := <-w.Changes(); ok {
+		t.Error("Changes channel not closed after Close")
+	}
+}
+
+func TestWatcherNotify(t *testing.T) {
+	testWatcher(t, false)
+}
+
+func TestWatcherPoll(t *testing.T) {
+	testWatcher(t, true)
+}
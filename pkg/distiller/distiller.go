@@ -0,0 +1,298 @@
+// Package distiller is the Go API of AI Distiller. It distills source files
+// and directories into the intermediate representation (IR) used by the aid
+// command and formats it in any of the aid output formats.
+//
+//	opts := distiller.DefaultOptions()
+//	opts.Private = true
+//	result, err := distiller.Distill(ctx, "./src", opts)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Print(result.Output)
+package distiller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/janreges/ai-distiller/internal/distill"
+	"github.com/janreges/ai-distiller/internal/formatter"
+	_ "github.com/janreges/ai-distiller/internal/language" // Register language processors
+	"github.com/janreges/ai-distiller/internal/processor"
+)
+
+// Options mirrors the filtering flags of the aid command. Start from
+// DefaultOptions: the zero value includes nothing.
+type Options struct {
+	// Visibility (--public, --protected, --internal, --private)
+	Public    bool
+	Protected bool
+	Internal  bool
+	Private   bool
+
+	// Content (--comments, --docstrings, --implementation, --imports, --annotations)
+	Comments       bool
+	Docstrings     bool
+	Implementation bool
+	Imports        bool
+	Annotations    bool
+
+	// Include and Exclude are file patterns such as "*.go" or "*test*"
+	// (--include, --exclude)
+	Include []string
+	Exclude []string
+
+	// Recursive processes subdirectories (--recursive)
+	Recursive bool
+
+	// Raw processes all text files without parsing (--raw)
+	Raw bool
+
+	// Workers is the number of parallel workers; 0 uses 80% of CPU cores
+	// (--workers)
+	Workers int
+
+	// SQLSchema folds the SQL migrations of a directory into one file with
+	// their final schema (--sql-schema). OnFile still receives the
+	// migration files one by one.
+	SQLSchema bool
+
+	// AbsolutePaths puts absolute instead of relative paths into the IR and
+	// output (--file-path-type)
+	AbsolutePaths bool
+
+	// RelativePathPrefix is prepended to relative paths (--relative-path-prefix)
+	RelativePathPrefix string
+
+	// Format is the output format of Result.Output and FileResult.Output:
+	// text, md, jsonl, json-structured, xml or ir. Empty skips formatting.
+	Format string
+
+	// OnFile, if set, is called for each file as soon as it and all files
+	// before it are distilled, in the order of Result.Files. Calls are
+	// sequential. Returning an error stops Distill with that error.
+	OnFile func(*FileResult) error
+}
+
+// DefaultOptions returns the defaults of the aid command: public API with
+// docstrings, imports and annotations, in text format
+func DefaultOptions() Options {
+	defaults := distill.DefaultFilter()
+	return Options{
+		Public:         defaults.Public,
+		Protected:      defaults.Protected,
+		Internal:       defaults.Internal,
+		Private:        defaults.Private,
+		Comments:       defaults.Comments,
+		Docstrings:     defaults.Docstrings,
+		Implementation: defaults.Implementation,
+		Imports:        defaults.Imports,
+		Annotations:    defaults.Annotations,
+		Recursive:      defaults.Recursive,
+		SQLSchema:      defaults.SQLSchema,
+		Format:         "text",
+	}
+}
+
+// FileResult is a single distilled file, passed to Options.OnFile
+type FileResult struct {
+	// Path is the file on disk
+	Path string
+
+	// File is the distilled IR
+	File *File
+
+	// Output is File formatted in Options.Format
+	Output string
+}
+
+// FileError describes a file that could not be distilled
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Result is the outcome of Distill
+type Result struct {
+	// Root is a *File for a file path and a *Directory for a directory
+	Root Node
+
+	// Files are the distilled files of Root in processing order
+	Files []*File
+
+	// Output is the whole result formatted in Options.Format
+	Output string
+
+	// Errors lists files that were skipped because they failed to parse
+	Errors []*FileError
+}
+
+// Render formats the result in another output format
+func (r *Result) Render(format string) (string, error) {
+	_, single := r.Root.(*File)
+	return render(r.Files, single, format)
+}
+
+// Formats returns the names of the available output formats
+func Formats() []string {
+	names := formatter.List()
+	sort.Strings(names)
+	return names
+}
+
+// Distill distills a file or directory
+func Distill(ctx context.Context, path string, opts Options) (*Result, error) {
+	if opts.Format != "" {
+		if _, err := formatter.Get(opts.Format, formatter.Options{}); err != nil {
+			return nil, fmt.Errorf("invalid output format: %s (valid: %s)", opts.Format, strings.Join(Formats(), ", "))
+		}
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	procOpts := opts.processOptions(absPath)
+	tasks := []processor.FileTask{{Path: absPath}}
+	if info.IsDir() {
+		if tasks, err = processor.CollectFileTasks(absPath, procOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Result{}
+	if err := distillFiles(processor.NewWithContext(ctx), tasks, procOpts, opts, result); err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		dir := &Directory{
+			Path:     processor.DirectoryDisplayPath(absPath, procOpts),
+			Children: make([]Node, len(result.Files)),
+		}
+		for i, file := range result.Files {
+			dir.Children[i] = file
+		}
+		// Fold SQL migrations like the aid command does
+		folded := processor.FoldDirectory(dir, procOpts)
+		files := make([]*File, 0, len(folded.Children))
+		for _, child := range folded.Children {
+			if file, ok := child.(*File); ok {
+				files = append(files, file)
+			}
+		}
+		result.Root = folded
+		result.Files = files
+	} else if len(result.Files) == 1 {
+		result.Root = result.Files[0]
+	} else {
+		return nil, result.Errors[0]
+	}
+
+	if opts.Format != "" {
+		if result.Output, err = result.Render(opts.Format); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// distillFiles processes tasks with the processor's worker pool and hands
+// the files to OnFile in task order
+func distillFiles(proc *processor.Processor, tasks []processor.FileTask, procOpts processor.ProcessOptions, opts Options, result *Result) error {
+	return proc.ProcessTasks(tasks, procOpts, func(out processor.FileResult) error {
+		task := tasks[out.Index]
+		if out.Error != nil {
+			result.Errors = append(result.Errors, &FileError{Path: task.Path, Err: out.Error})
+			return nil
+		}
+		result.Files = append(result.Files, out.Result)
+
+		if opts.OnFile == nil {
+			return nil
+		}
+		fileResult := &FileResult{Path: task.Path, File: out.Result}
+		if opts.Format != "" {
+			output, err := render([]*File{out.Result}, true, opts.Format)
+			if err != nil {
+				return err
+			}
+			fileResult.Output = output
+		}
+		return opts.OnFile(fileResult)
+	})
+}
+
+// filter converts the options to the flags of the aid command
+func (o Options) filter() distill.Filter {
+	return distill.Filter{
+		Public:         o.Public,
+		Protected:      o.Protected,
+		Internal:       o.Internal,
+		Private:        o.Private,
+		Comments:       o.Comments,
+		Docstrings:     o.Docstrings,
+		Implementation: o.Implementation,
+		Imports:        o.Imports,
+		Annotations:    o.Annotations,
+		Include:        o.Include,
+		Exclude:        o.Exclude,
+		Recursive:      o.Recursive,
+		Raw:            o.Raw,
+		Workers:        o.Workers,
+		SQLSchema:      o.SQLSchema,
+	}
+}
+
+// processOptions converts the options to processor options
+func (o Options) processOptions(basePath string) processor.ProcessOptions {
+	opts := o.filter().ProcessOptions()
+	opts.BasePath = basePath
+	opts.RelativePathPrefix = o.RelativePathPrefix
+	opts.FilePathType = "relative"
+	if o.AbsolutePaths {
+		opts.FilePathType = "absolute"
+	}
+	return opts
+}
+
+// render formats files like the aid command does: a file path as a single
+// file, a directory as multiple files
+func render(files []*File, single bool, format string) (string, error) {
+	f, err := formatter.Get(format, formatter.Options{})
+	if err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	if single && len(files) == 1 {
+		err = f.Format(&output, files[0])
+	} else {
+		err = f.FormatMultiple(&output, files)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if format == "text" {
+		return distill.RemoveEmptyFiles(output.String()), nil
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,161 @@
+package distiller_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/janreges/ai-distiller/pkg/distiller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestDistillDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.py", "class Service:\n    def start(self):\n        return 1\n\n    def _boot(self):\n        pass\n")
+	writeFile(t, dir, "pkg/b.py", "def helper(x):\n    return x\n")
+	writeFile(t, dir, "notes.txt", "not code\n")
+
+	var streamed []string
+	opts := distiller.DefaultOptions()
+	opts.Workers = 2
+	opts.OnFile = func(file *distiller.FileResult) error {
+		streamed = append(streamed, file.File.Path)
+		assert.Contains(t, file.Output, "<file path=\""+file.File.Path+"\">")
+		return nil
+	}
+
+	result, err := distiller.Distill(context.Background(), dir, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a.py", filepath.Join("pkg", "b.py")}, streamed)
+	require.Len(t, result.Files, 2)
+	root, ok := result.Root.(*distiller.Directory)
+	require.True(t, ok)
+	assert.Equal(t, ".", root.Path)
+	assert.Len(t, root.Children, 2)
+
+	assert.Contains(t, result.Output, "start")
+	assert.Contains(t, result.Output, "helper")
+	assert.NotContains(t, result.Output, "_boot")
+	assert.NotContains(t, result.Output, "return 1")
+
+	var functions []string
+	distiller.Walk(result.Root, func(node distiller.Node) bool {
+		if fn, ok := node.(*distiller.Function); ok {
+			functions = append(functions, fn.Name)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"start", "helper"}, functions)
+
+	md, err := result.Render("md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "```python")
+}
+
+func TestDistillDirectoryPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "pkg/b.py", "def helper(x):\n    return x\n")
+
+	opts := distiller.DefaultOptions()
+	opts.RelativePathPrefix = "src"
+	result, err := distiller.Distill(context.Background(), filepath.Join(dir, "pkg"), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "src", result.Root.(*distiller.Directory).Path)
+	assert.Equal(t, "src/b.py", result.Files[0].Path)
+
+	opts = distiller.DefaultOptions()
+	opts.AbsolutePaths = true
+	result, err = distiller.Distill(context.Background(), filepath.Join(dir, "pkg", "..", "pkg"), opts)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "pkg"), result.Root.(*distiller.Directory).Path)
+	assert.Equal(t, filepath.Join(dir, "pkg", "b.py"), result.Files[0].Path)
+}
+
+func TestDistillFileOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "a.py", "class Service:\n    def start(self):\n        return 1\n\n    def _boot(self):\n        pass\n")
+
+	opts := distiller.DefaultOptions()
+	opts.Private = true
+	opts.Implementation = true
+	opts.Format = "ir"
+
+	result, err := distiller.Distill(context.Background(), path, opts)
+	require.NoError(t, err)
+	file, ok := result.Root.(*distiller.File)
+	require.True(t, ok)
+	assert.Equal(t, "a.py", file.Path)
+
+	snapshot, err := distiller.ReadSnapshot(bytes.NewReader([]byte(result.Output)))
+	require.NoError(t, err)
+	var rewritten bytes.Buffer
+	require.NoError(t, distiller.WriteSnapshot(&rewritten, snapshot))
+	assert.Equal(t, result.Output, rewritten.String())
+
+	text, err := result.Render("text")
+	require.NoError(t, err)
+	assert.Contains(t, text, "_boot")
+	assert.Contains(t, text, "return 1")
+}
+
+func TestDistillSQLMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "migrations/V1__users.sql", "CREATE TABLE users (id INT PRIMARY KEY);\n")
+	writeFile(t, dir, "migrations/V2__email.sql", "ALTER TABLE users ADD COLUMN email TEXT;\n")
+
+	// Folded by default, like the aid command
+	result, err := distiller.Distill(context.Background(), dir, distiller.DefaultOptions())
+	require.NoError(t, err)
+	require.Len(t, result.Files, 1)
+	assert.Equal(t, filepath.Join("migrations", "*.sql"), result.Files[0].Path)
+	assert.Contains(t, result.Output, "email")
+
+	opts := distiller.DefaultOptions()
+	opts.SQLSchema = false
+	result, err = distiller.Distill(context.Background(), dir, opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Files, 2)
+}
+
+func TestDistillErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.py", "def a():\n    pass\n")
+	writeFile(t, dir, "b.py", "def b():\n    pass\n")
+
+	stop := errors.New("stop")
+	opts := distiller.DefaultOptions()
+	calls := 0
+	opts.OnFile = func(*distiller.FileResult) error {
+		calls++
+		return stop
+	}
+	_, err := distiller.Distill(context.Background(), dir, opts)
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = distiller.Distill(ctx, dir, distiller.DefaultOptions())
+	assert.ErrorIs(t, err, context.Canceled)
+
+	opts = distiller.DefaultOptions()
+	opts.Format = "pdf"
+	_, err = distiller.Distill(context.Background(), dir, opts)
+	assert.ErrorContains(t, err, "invalid output format: pdf")
+
+	_, err = distiller.Distill(context.Background(), filepath.Join(dir, "missing"), distiller.DefaultOptions())
+	assert.Error(t, err)
+}
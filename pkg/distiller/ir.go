@@ -0,0 +1,52 @@
+package distiller
+
+import (
+	"io"
+
+	"github.com/janreges/ai-distiller/internal/ir"
+)
+
+// The IR types are aliases, so values can be passed between this package
+// and code that works with the IR directly.
+type (
+	Node       = ir.DistilledNode
+	NodeKind   = ir.NodeKind
+	Directory  = ir.DistilledDirectory
+	File       = ir.DistilledFile
+	Package    = ir.DistilledPackage
+	Import     = ir.DistilledImport
+	Class      = ir.DistilledClass
+	Interface  = ir.DistilledInterface
+	Struct     = ir.DistilledStruct
+	Enum       = ir.DistilledEnum
+	TypeAlias  = ir.DistilledTypeAlias
+	Function   = ir.DistilledFunction
+	Field      = ir.DistilledField
+	Comment    = ir.DistilledComment
+	RawContent = ir.DistilledRawContent
+	Error      = ir.DistilledError
+	Location   = ir.Location
+	TypeRef    = ir.TypeRef
+	TypeParam  = ir.TypeParam
+	Parameter  = ir.Parameter
+	Visibility = ir.Visibility
+	Modifier   = ir.Modifier
+)
+
+// Walk calls fn for node and its descendants, depth first. Returning false
+// skips the children of a node.
+func Walk(node Node, fn func(Node) bool) {
+	ir.Walk(node, fn)
+}
+
+// WriteSnapshot writes root as a versioned IR document, the format of
+// aid --format ir
+func WriteSnapshot(w io.Writer, root Node) error {
+	return ir.EncodeDocument(w, root, true)
+}
+
+// ReadSnapshot reads an IR document written by WriteSnapshot or
+// aid --format ir
+func ReadSnapshot(r io.Reader) (Node, error) {
+	return ir.DecodeDocument(r)
+}
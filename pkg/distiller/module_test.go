@@ -0,0 +1,62 @@
+package distiller_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportFromOtherModule builds and runs a program in a separate module
+// that imports this package with nothing but a require of the main module
+func TestImportFromOtherModule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a separate module")
+	}
+	goBin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if _, err := os.Stat(goBin); err != nil {
+		t.Skip("go command not available")
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	require.NoError(t, err)
+	sums, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	require.NoError(t, err)
+
+	// The replace points at this checkout; a published version needs none
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/embed\n\ngo 1.23.0\n\n"+
+		"require github.com/janreges/ai-distiller v0.0.0\n\n"+
+		"replace github.com/janreges/ai-distiller => "+filepath.ToSlash(root)+"\n")
+	writeFile(t, dir, "go.sum", string(sums))
+	writeFile(t, dir, "main.go", `package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/janreges/ai-distiller/pkg/distiller"
+)
+
+func main() {
+	result, err := distiller.Distill(context.Background(), os.Args[1], distiller.DefaultOptions())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Print(result.Output)
+}
+`)
+	source := writeFile(t, dir, "testdata/app.py", "def serve(port):\n    return port\n")
+
+	cmd := exec.Command(goBin, "run", "-mod=mod", ".", source)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "%s", output)
+	require.True(t, strings.Contains(string(output), "serve"), "unexpected output:\n%s", output)
+}
@@ -0,0 +1,87 @@
+library basic;
+
+import 'dart:math';
+
+/// Basic Dart features: classes, constructors, getters, private members
+/// and top-level functions.
+
+/// Maximum length of a user name.
+const int maxNameLength = 50;
+
+int _nextId = 1;
+
+/// A registered user.
+class User {
+  /// Creates a user with a generated identifier.
+  User(this.name, {this.email}) : id = _nextId++;
+
+  /// Creates a guest user.
+  User.guest() : this('guest');
+
+  final int id;
+  final String name;
+  final String? email;
+  bool _verified = false;
+
+  /// Name shown in the user interface.
+  String get displayName => name.trim().isEmpty ? 'Anonymous' : name;
+
+  /// Whether the email address was confirmed.
+  bool get isVerified => _verified;
+
+  /// Marks the email address as confirmed.
+  void verify() {
+    if (email != null && _hasValidEmail()) {
+      _verified = true;
+    }
+  }
+
+  // Very small sanity check only.
+  bool _hasValidEmail() => email!.contains('@');
+
+  @override
+  String toString() => 'User($id, $name)';
+}
+
+/// Keeps users in memory.
+class UserService {
+  final Map<int, User> _users = {};
+  DateTime lastAccess = DateTime.now();
+
+  /// Adds a user unless the identifier is already taken.
+  bool add(User user) {
+    lastAccess = DateTime.now();
+    if (_users.containsKey(user.id)) {
+      return false;
+    }
+    _users[user.id] = user;
+    return true;
+  }
+
+  /// Finds a user by identifier.
+  User? find(int id) => _users[id];
+
+  /// Number of stored users.
+  int get count => _users.length;
+
+  /// Picks a random user, if any.
+  User? random() {
+    if (_users.isEmpty) return null;
+    final values = _users.values.toList();
+    return values[Random().nextInt(values.length)];
+  }
+}
+
+/// Greets a user by name.
+String greet(User user) => 'Hello, ${user.displayName}';
+
+void _debug(String message) {
+  print('[debug] $message');
+}
+
+void main() {
+  final service = UserService();
+  final user = User('John Doe', email: 'john@example.com');
+  service.add(user);
+  _debug(greet(user));
+}
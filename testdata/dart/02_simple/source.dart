@@ -0,0 +1,99 @@
+library shop;
+
+import 'dart:collection';
+import 'package:meta/meta.dart';
+
+/// Inheritance, abstract classes, interfaces, mixins and enums.
+
+/// Base of every persisted entity.
+abstract class Entity {
+  Entity(this.id);
+
+  final String id;
+
+  /// Kind of the entity, used in logs.
+  String get kind;
+
+  @protected
+  bool validate();
+
+  /// Validates the entity and throws when it is invalid.
+  void check() {
+    if (!validate()) {
+      throw StateError('invalid $kind $id');
+    }
+  }
+}
+
+/// Adds change tracking to an entity.
+mixin Auditable on Entity {
+  DateTime? lastModified;
+  String? modifiedBy;
+
+  /// Records who changed the entity.
+  void markModified(String by) {
+    lastModified = DateTime.now();
+    modifiedBy = by;
+  }
+}
+
+/// Categories of products.
+enum Category { food, clothing, electronics }
+
+/// A product sold in the shop.
+class Product extends Entity with Auditable implements Comparable<Product> {
+  Product(super.id, this.name, this._price, {this.category = Category.food});
+
+  final String name;
+  final Category category;
+  double _price;
+
+  @override
+  String get kind => 'product';
+
+  /// Current price.
+  double get price => _price;
+
+  set price(double value) {
+    if (value < 0) throw ArgumentError.value(value, 'price');
+    _price = value;
+  }
+
+  @override
+  @protected
+  bool validate() => name.isNotEmpty && _price >= 0;
+
+  /// Applies a discount in percent.
+  void discount(int percent) {
+    assert(percent >= 0 && percent <= 100);
+    _price = _price * (100 - percent) / 100;
+  }
+
+  @override
+  int compareTo(Product other) => _price.compareTo(other._price);
+}
+
+/// Stores entities by identifier.
+abstract interface class Repository<T extends Entity> {
+  void save(T entity);
+  T? findById(String id);
+  Iterable<T> get all;
+}
+
+/// Keeps products sorted by price.
+class ProductRepository implements Repository<Product> {
+  final _items = SplayTreeMap<String, Product>();
+
+  @override
+  void save(Product entity) => _items[entity.id] = entity;
+
+  @override
+  Product? findById(String id) => _items[id];
+
+  @override
+  Iterable<Product> get all => _items.values;
+
+  /// Products of one category.
+  List<Product> inCategory(Category category) =>
+      all.where((p) => p.category == category).toList();
+}
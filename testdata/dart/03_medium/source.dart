@@ -0,0 +1,123 @@
+library loader;
+
+import 'dart:async';
+import 'dart:convert' show jsonDecode, jsonEncode;
+import 'package:http/http.dart' as http hide Client;
+
+/// Generics, async code, streams, factories, typedefs and extensions.
+
+/// Converts a JSON map into a value.
+typedef FromJson<T> = T Function(Map<String, dynamic> json);
+
+/// Old style callback.
+typedef void ProgressCallback(int done, int total);
+
+/// Result of an operation that may fail.
+sealed class Result<T> {
+  const Result();
+
+  /// Wraps a successful value.
+  const factory Result.ok(T value) = Ok<T>;
+
+  /// Wraps an error.
+  const factory Result.error(Object error) = Err<T>;
+}
+
+final class Ok<T> extends Result<T> {
+  const Ok(this.value);
+  final T value;
+}
+
+final class Err<T> extends Result<T> {
+  const Err(this.error);
+  final Object error;
+}
+
+/// Caches computed values by key.
+class Cache<K, V> {
+  Cache({this.capacity = 128});
+
+  final int capacity;
+  final _entries = <K, V>{};
+  int _hits = 0;
+  int _misses = 0;
+
+  /// Returns the cached value or computes and stores it.
+  Future<V> getOrCompute(K key, Future<V> Function() compute) async {
+    final cached = _entries[key];
+    if (cached != null) {
+      _hits++;
+      return cached;
+    }
+    _misses++;
+    final value = await compute();
+    _store(key, value);
+    return value;
+  }
+
+  void _store(K key, V value) {
+    _entries[key] = value;
+    if (_entries.length > capacity) {
+      _entries.remove(_entries.keys.first);
+    }
+  }
+
+  /// Fraction of lookups served from the cache.
+  double get hitRatio => _hits + _misses == 0 ? 0 : _hits / (_hits + _misses);
+}
+
+/// Loads remote documents.
+class DocumentLoader<T> {
+  DocumentLoader(this.baseUrl, this.fromJson, [http.Client? client])
+      : _client = client ?? http.Client();
+
+  final String baseUrl;
+  final FromJson<T> fromJson;
+  final http.Client _client;
+  late final Cache<String, T> _cache = Cache();
+
+  /// Loads and decodes one document.
+  Future<Result<T>> load(String path) async {
+    try {
+      final value = await _cache.getOrCompute(path, () => _fetch(path));
+      return Result.ok(value);
+    } catch (e) {
+      return Result.error(e);
+    }
+  }
+
+  /// Loads documents one by one, reporting progress.
+  Stream<Result<T>> loadAll(List<String> paths, {ProgressCallback? onProgress}) async* {
+    for (var i = 0; i < paths.length; i++) {
+      yield await load(paths[i]);
+      onProgress?.call(i + 1, paths.length);
+    }
+  }
+
+  Future<T> _fetch(String path) async {
+    final response = await _client.get(Uri.parse('$baseUrl/$path'));
+    return fromJson(jsonDecode(response.body) as Map<String, dynamic>);
+  }
+}
+
+/// Helpers on results.
+extension ResultX<T> on Result<T> {
+  /// The value, or null for errors.
+  T? get valueOrNull => switch (this) {
+        Ok(:final value) => value,
+        Err() => null,
+      };
+
+  bool get isOk => this is Ok<T>;
+}
+
+/// Encodes any map as pretty JSON.
+String prettyJson(Map<String, Object?> value) => jsonEncode(value);
+
+/// Numbers from start, lazily.
+Iterable<int> naturals(int start) sync* {
+  var n = start;
+  while (true) {
+    yield n++;
+  }
+}
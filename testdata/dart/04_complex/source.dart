@@ -0,0 +1,122 @@
+library ledger;
+
+import 'dart:collection';
+import 'package:meta/meta.dart';
+
+part 'ledger_events.dart';
+
+/// Dart 3 features: records, patterns, class modifiers, enhanced enums
+/// and extension types.
+
+/// Strongly typed account identifier.
+extension type const AccountId(String value) {
+  /// Whether the identifier has the expected format.
+  bool get isValid => value.startsWith('ACC-');
+}
+
+/// Supported currencies.
+enum Currency implements Comparable<Currency> {
+  eur('€', 2),
+  usd('\$', 2),
+  czk('Kč', 0);
+
+  const Currency(this.symbol, this.decimals);
+
+  final String symbol;
+  final int decimals;
+
+  /// Formats an amount in this currency.
+  String format(num amount) => '${amount.toStringAsFixed(decimals)} $symbol';
+
+  @override
+  int compareTo(Currency other) => index - other.index;
+}
+
+/// A monetary amount.
+@immutable
+final class Money {
+  const Money(this.amount, this.currency);
+
+  const Money.zero(this.currency) : amount = 0;
+
+  final num amount;
+  final Currency currency;
+
+  Money operator +(Money other) {
+    if (currency != other.currency) {
+      throw ArgumentError('currency mismatch');
+    }
+    return Money(amount + other.amount, currency);
+  }
+
+  Money operator -() => Money(-amount, currency);
+
+  bool get isNegative => amount < 0;
+
+  @override
+  bool operator ==(Object other) =>
+      other is Money && other.amount == amount && other.currency == currency;
+
+  @override
+  int get hashCode => Object.hash(amount, currency);
+}
+
+/// Errors returned by the ledger.
+enum LedgerError { insufficientFunds, accountClosed, unknownAccount }
+
+/// In-memory ledger applying events to balances.
+base class Ledger {
+  Ledger({DateTime Function()? clock}) : _clock = clock ?? DateTime.now;
+
+  final DateTime Function() _clock;
+  final _balances = HashMap<AccountId, Money>();
+  final _closed = <AccountId>{};
+
+  @protected
+  final Duration retention = const Duration(days: 30);
+
+  /// Applies an event and returns the new balance or an error.
+  (Money?, LedgerError?) apply(LedgerEvent event) => switch (event) {
+        Deposited(:final account, :final money) => _update(account, money),
+        Withdrawn(:final account, :final money) => _withdraw(account, money),
+        Closed(:final account) => _close(account),
+      };
+
+  /// Current balance of an account.
+  Money? balance(AccountId account) => _balances[account];
+
+  /// Balances with the time they were read.
+  ({DateTime at, Map<AccountId, Money> balances}) snapshot() =>
+      (at: _clock(), balances: Map.unmodifiable(_balances));
+
+  (Money?, LedgerError?) _withdraw(AccountId account, Money money) {
+    final current = _balances[account];
+    if (current == null) return (null, LedgerError.unknownAccount);
+    if ((current + -money).isNegative) return (null, LedgerError.insufficientFunds);
+    return _update(account, -money);
+  }
+
+  (Money?, LedgerError?) _close(AccountId account) {
+    _closed.add(account);
+    return (_balances[account], null);
+  }
+
+  (Money?, LedgerError?) _update(AccountId account, Money delta) {
+    if (_closed.contains(account)) return (null, LedgerError.accountClosed);
+    final next = (_balances[account] ?? Money.zero(delta.currency)) + delta;
+    _balances[account] = next;
+    return (next, null);
+  }
+}
+
+/// Formatting helpers for money.
+extension MoneyFormat on Money {
+  /// Formats the amount with its currency symbol.
+  String get show => currency.format(amount);
+}
+
+/// Parses user input into an amount, or describes what was wrong.
+(num?, String?) parseAmount(String input) {
+  final value = num.tryParse(input);
+  return value == null ? (null, 'not a number: $input') : (value, null);
+}
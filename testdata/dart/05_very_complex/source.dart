@@ -0,0 +1,186 @@
+library game;
+
+import 'dart:async';
+import 'dart:isolate';
+import 'package:flutter/widgets.dart';
+import 'package:meta/meta.dart' show protected, visibleForTesting;
+export 'src/scores.dart' show Score;
+
+/// Flutter widgets, isolates, generic constraints, abstract mixins,
+/// operators, static members and deferred loading patterns.
+
+/// Something that can be ranked against its own type.
+abstract interface class Ranked<T extends Ranked<T>> implements Comparable<T> {
+  int get rank;
+
+  @override
+  int compareTo(T other) => rank.compareTo(other.rank);
+}
+
+/// Logging for any class.
+mixin class Logging {
+  static final List<String> history = [];
+
+  @protected
+  void log(String message) {
+    history.add('[$runtimeType] $message');
+  }
+}
+
+/// Something that can be shut down.
+abstract mixin class Lifecycle {
+  bool _running = true;
+
+  bool get isRunning => _running;
+
+  /// Stops the component.
+  @mustCallSuper
+  void shutdown() {
+    _running = false;
+  }
+}
+
+/// Message delivered to a worker.
+sealed class Message {
+  const Message();
+}
+
+final class Ask<R> extends Message {
+  Ask(this.question) : reply = Completer<R>();
+
+  final String question;
+  final Completer<R> reply;
+}
+
+final class Tell<P> extends Message {
+  const Tell(this.payload);
+  final P payload;
+}
+
+/// Player with a score.
+class Player implements Ranked<Player> {
+  const Player(this.name, this.score);
+
+  final String name;
+  final int score;
+
+  @override
+  int get rank => score;
+
+  @override
+  int compareTo(Player other) => rank - other.rank;
+
+  bool operator >(Player other) => compareTo(other) > 0;
+
+  Player copyWith({String? name, int? score}) =>
+      Player(name ?? this.name, score ?? this.score);
+}
+
+/// Processes messages one at a time.
+abstract class Worker<S> with Logging, Lifecycle {
+  Worker(this._state);
+
+  S _state;
+  final _mailbox = StreamController<Message>();
+  StreamSubscription<Message>? _subscription;
+
+  @visibleForTesting
+  int processed = 0;
+
+  /// Handles a message and returns the next state.
+  @protected
+  S receive(S state, Message message);
+
+  /// Starts processing the mailbox.
+  void start() {
+    _subscription ??= _mailbox.stream.listen((message) {
+      if (!isRunning) return;
+      _state = receive(_state, message);
+      processed++;
+    });
+  }
+
+  /// Enqueues a message.
+  void send(Message message) => _mailbox.add(message);
+
+  /// Asks a question and waits for the answer.
+  Future<R> ask<R>(String question) {
+    final message = Ask<R>(question);
+    send(message);
+    return message.reply.future;
+  }
+
+  @override
+  void shutdown() {
+    log('shutting down');
+    _subscription?.cancel();
+    super.shutdown();
+  }
+}
+
+/// Keeps the leaderboard of a game.
+class Leaderboard extends Worker<Map<String, Player>> {
+  Leaderboard() : super({});
+
+  static const int defaultTop = 3;
+
+  @override
+  Map<String, Player> receive(Map<String, Player> state, Message message) {
+    switch (message) {
+      case Tell(payload: Player player):
+        final best = state[player.name];
+        return {...state, player.name: best != null && best > player ? best : player};
+      case Ask(question: 'top', :final reply):
+        reply.complete(_top(state, defaultTop));
+        return state;
+      default:
+        log('unhandled $message');
+        return state;
+    }
+  }
+
+  List<Player> _top(Map<String, Player> state, int count) =>
+      (state.values.toList()..sort((a, b) => b.compareTo(a))).take(count).toList();
+}
+
+/// Shows the best players.
+class LeaderboardView extends StatefulWidget {
+  const LeaderboardView({super.key, required this.board, this.refresh = const Duration(seconds: 5)});
+
+  final Leaderboard board;
+  final Duration refresh;
+
+  @override
+  State<LeaderboardView> createState() => _LeaderboardViewState();
+}
+
+class _LeaderboardViewState extends State<LeaderboardView> {
+  List<Player> _players = const [];
+  Timer? _timer;
+
+  @override
+  void initState() {
+    super.initState();
+    _timer = Timer.periodic(widget.refresh, (_) => _reload());
+  }
+
+  Future<void> _reload() async {
+    final players = await widget.board.ask<List<Player>>('top');
+    if (mounted) setState(() => _players = players);
+  }
+
+  @override
+  void dispose() {
+    _timer?.cancel();
+    super.dispose();
+  }
+
+  @override
+  Widget build(BuildContext context) => Column(
+        children: [for (final p in _players) Text('${p.name}: ${p.score}')],
+      );
+}
+
+/// Computes scores on a background isolate.
+Future<List<int>> computeScores(List<int> raw) =>
+    Isolate.run(() => [for (final r in raw) r * 10]);
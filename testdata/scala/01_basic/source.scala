@@ -0,0 +1,79 @@
+package com.example.basic
+
+import java.time.Instant
+import scala.collection.mutable
+
+/**
+ * Basic Scala features: case classes, companion objects, traits,
+ * pattern matching and visibility modifiers
+ */
+
+/** A registered user of the system */
+case class User(id: Long, name: String, email: Option[String] = None) {
+
+  /** Name shown in the user interface */
+  def displayName: String = if (name.trim.nonEmpty) name else "Anonymous"
+
+  // Internal check used by the service only
+  private def hasValidEmail: Boolean = email.exists(_.contains("@"))
+}
+
+/** Factory methods for users */
+object User {
+  /** Creates a user with a generated identifier */
+  def create(name: String, email: Option[String] = None): User =
+    User(System.currentTimeMillis(), name, email)
+
+  val Guest: User = User(0L, "guest")
+}
+
+/** Something that has a greeting */
+trait Greeter {
+  def greet(user: User): String
+
+  /** Default farewell message */
+  def farewell(user: User): String = s"Goodbye, ${user.displayName}"
+}
+
+/** Keeps users in memory */
+class UserService extends Greeter {
+  private val users = mutable.Map.empty[Long, User]
+  var lastAccess: Instant = Instant.now()
+  protected var createdCount: Int = 0
+
+  /** Adds a user unless the identifier is already taken */
+  def add(user: User): Boolean = {
+    lastAccess = Instant.now()
+    if (users.contains(user.id)) false
+    else {
+      users(user.id) = user
+      createdCount += 1
+      true
+    }
+  }
+
+  /** Finds a user by identifier */
+  def find(id: Long): Option[User] = users.get(id)
+
+  def greet(user: User): String = user match {
+    case User(_, name, Some(_)) => s"Hello, $name"
+    case User(_, name, None)    => s"Hi, $name"
+  }
+
+  protected def reset(): Unit = {
+    users.clear()
+    createdCount = 0
+  }
+
+  private[basic] def size: Int = users.size
+}
+
+/** Entry point */
+object Main {
+  def main(args: Array[String]): Unit = {
+    val service = new UserService
+    val user = User.create("John Doe", Some("john@example.com"))
+    service.add(user)
+    println(service.greet(user))
+  }
+}
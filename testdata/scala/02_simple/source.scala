@@ -0,0 +1,99 @@
+package com.example.simple
+
+import scala.util.{Failure, Success, Try}
+import scala.math.{max => maximum}
+
+/**
+ * Inheritance, abstract classes, sealed hierarchies and type aliases
+ */
+
+/** Identifier of an entity */
+type EntityId = String
+
+/** Base of every persisted entity */
+abstract class Entity(val id: EntityId) {
+  /** Kind of the entity, used in logs */
+  def kind: String
+
+  protected def validate(): Boolean
+
+  /** Validates the entity and reports failures */
+  final def check(): Try[Unit] =
+    if (validate()) Success(()) else Failure(new IllegalStateException(s"invalid $kind $id"))
+}
+
+/** Product sold in the shop */
+class Product(id: EntityId, val name: String, private var price: BigDecimal) extends Entity(id) {
+  def kind: String = "product"
+
+  protected def validate(): Boolean = name.nonEmpty && price >= 0
+
+  /** Current price */
+  def currentPrice: BigDecimal = price
+
+  /** Applies a discount in percent */
+  def discount(percent: Int): Unit = {
+    require(percent >= 0 && percent <= 100)
+    price = price * (100 - percent) / 100
+  }
+
+  override def toString: String = s"Product($id, $name, $price)"
+}
+
+/** Result of an order operation */
+sealed trait OrderResult
+
+object OrderResult {
+  case class Placed(orderId: String, total: BigDecimal) extends OrderResult
+  case class Rejected(reason: String) extends OrderResult
+  case object OutOfStock extends OrderResult
+}
+
+/** Something that can be stored */
+trait Repository[T <: Entity] {
+  def save(entity: T): Unit
+  def findById(id: EntityId): Option[T]
+  def all: Seq[T]
+}
+
+/** Repository keeping products in a vector */
+class ProductRepository extends Repository[Product] {
+  private var items: Vector[Product] = Vector.empty
+
+  def save(entity: Product): Unit = items = items.filterNot(_.id == entity.id) :+ entity
+
+  def findById(id: EntityId): Option[Product] = items.find(_.id == id)
+
+  def all: Seq[Product] = items
+
+  /** Most expensive product price */
+  def highestPrice: BigDecimal = items.map(_.currentPrice).foldLeft(BigDecimal(0))(maximum)
+}
+
+/** Places orders for products */
+class OrderService(repository: ProductRepository) {
+  private val stock = scala.collection.mutable.Map.empty[EntityId, Int]
+
+  /** Adds stock for a product */
+  def restock(id: EntityId, amount: Int): Unit =
+    stock.updateWith(id)(current => Some(current.getOrElse(0) + amount))
+
+  /** Places an order and describes the result */
+  def order(id: EntityId, quantity: Int): OrderResult =
+    repository.findById(id) match {
+      case None => OrderResult.Rejected(s"unknown product $id")
+      case Some(product) if available(id) < quantity => OrderResult.OutOfStock
+      case Some(product) =>
+        stock(id) = available(id) - quantity
+        OrderResult.Placed(nextOrderId(), product.currentPrice * quantity)
+    }
+
+  private def available(id: EntityId): Int = stock.getOrElse(id, 0)
+
+  private var counter = 0
+
+  private def nextOrderId(): String = {
+    counter += 1
+    f"ORD-$counter%05d"
+  }
+}
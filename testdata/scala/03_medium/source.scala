@@ -0,0 +1,99 @@
+package com.example.medium
+
+import scala.concurrent.{ExecutionContext, Future}
+import scala.collection.immutable.SortedMap
+
+/**
+ * Generics with variance and bounds, implicits, type classes and
+ * multiple parameter lists
+ */
+
+/** Type class describing how to encode a value as text */
+trait Encoder[-A] {
+  def encode(value: A): String
+}
+
+object Encoder {
+  /** Summons the encoder of a type */
+  def apply[A](implicit encoder: Encoder[A]): Encoder[A] = encoder
+
+  implicit val intEncoder: Encoder[Int] = (value: Int) => value.toString
+
+  implicit val stringEncoder: Encoder[String] = (value: String) => "\"" + value + "\""
+
+  /** Encodes lists with the encoder of their elements */
+  implicit def listEncoder[A](implicit inner: Encoder[A]): Encoder[List[A]] =
+    (values: List[A]) => values.map(inner.encode).mkString("[", ",", "]")
+}
+
+/** Immutable stack with covariant elements */
+sealed abstract class Stack[+A] {
+  def push[B >: A](value: B): Stack[B] = NonEmpty(value, this)
+  def peek: Option[A]
+  def pop: Stack[A]
+  def isEmpty: Boolean
+}
+
+case object Empty extends Stack[Nothing] {
+  def peek: Option[Nothing] = None
+  def pop: Stack[Nothing] = throw new NoSuchElementException("empty stack")
+  def isEmpty: Boolean = true
+}
+
+final case class NonEmpty[+A](head: A, tail: Stack[A]) extends Stack[A] {
+  def peek: Option[A] = Some(head)
+  def pop: Stack[A] = tail
+  def isEmpty: Boolean = false
+}
+
+/** Caches computed values by key */
+class Cache[K: Ordering, V](capacity: Int) {
+  private var entries: SortedMap[K, V] = SortedMap.empty
+  protected[medium] var hits: Long = 0
+  private[this] var misses: Long = 0
+
+  /** Returns the cached value or computes and stores it */
+  def getOrCompute(key: K)(compute: => V): V =
+    entries.get(key) match {
+      case Some(value) =>
+        hits += 1
+        value
+      case None =>
+        misses += 1
+        val value = compute
+        store(key, value)
+        value
+    }
+
+  private def store(key: K, value: V): Unit = {
+    entries = entries + (key -> value)
+    if (entries.size > capacity) entries = entries.drop(1)
+  }
+
+  /** Fraction of lookups served from the cache */
+  def hitRatio: Double = if (hits + misses == 0) 0.0 else hits.toDouble / (hits + misses)
+}
+
+/** Loads remote documents asynchronously */
+class DocumentLoader(baseUrl: String)(implicit ec: ExecutionContext) {
+  private lazy val cache = new Cache[String, String](128)
+
+  /** Loads a document, using the cache for repeated requests */
+  def load(path: String): Future[String] =
+    Future(cache.getOrCompute(path)(fetch(path)))
+
+  /** Loads several documents in parallel */
+  def loadAll(paths: Seq[String]): Future[Seq[String]] =
+    Future.sequence(paths.map(load))
+
+  protected def fetch(path: String): String = s"$baseUrl/$path"
+}
+
+/** Helpers working with any encodable value */
+object Codec {
+  /** Encodes a value with the implicit encoder in scope */
+  def render[A: Encoder](value: A): String = Encoder[A].encode(value)
+
+  def renderAll[A](values: A*)(implicit encoder: Encoder[A]): Seq[String] =
+    values.map(encoder.encode)
+}
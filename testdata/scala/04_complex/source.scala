@@ -0,0 +1,100 @@
+package com.example.complex
+
+import scala.concurrent.duration._
+import scala.util.chaining._
+
+/**
+ * Scala 3 features: enums, givens, extension methods, opaque types,
+ * union types and indentation syntax
+ */
+
+/** Strongly typed identifiers */
+object Ids:
+  opaque type AccountId = String
+
+  object AccountId:
+    def apply(value: String): AccountId = value
+
+  extension (id: AccountId)
+    def value: String = id
+
+export Ids.AccountId
+
+/** Supported currencies */
+enum Currency(val symbol: String):
+  case EUR extends Currency("€")
+  case USD extends Currency("$")
+  case CZK extends Currency("Kč")
+
+  /** Formats an amount in this currency */
+  def format(amount: BigDecimal): String = s"$amount $symbol"
+
+/** A monetary amount */
+final case class Money(amount: BigDecimal, currency: Currency):
+  def +(other: Money): Money =
+    require(currency == other.currency, "currency mismatch")
+    copy(amount = amount + other.amount)
+
+  def isNegative: Boolean = amount < 0
+
+/** Ledger events */
+enum Event:
+  case Deposited(account: AccountId, money: Money)
+  case Withdrawn(account: AccountId, money: Money)
+  case Closed(account: AccountId)
+
+/** Errors returned by the ledger */
+enum LedgerError:
+  case InsufficientFunds, AccountClosed, UnknownAccount
+
+/** Ordering of money within one currency */
+given moneyOrdering: Ordering[Money] = Ordering.by(_.amount)
+
+given Ordering[Currency] = Ordering.by(_.ordinal)
+
+extension (money: Money)
+  /** Formats the amount with its currency symbol */
+  def show: String = money.currency.format(money.amount)
+
+  def negate: Money = money.copy(amount = -money.amount)
+
+/** In-memory ledger applying events to balances */
+class Ledger(using clock: () => Long):
+  private var balances = Map.empty[AccountId, Money]
+  private var closed = Set.empty[AccountId]
+  protected val retention: FiniteDuration = 30.days
+
+  /** Applies an event and returns the new balance */
+  def apply(event: Event): Either[LedgerError, Money] = event match
+    case Event.Deposited(account, money) => update(account, money)
+    case Event.Withdrawn(account, money) =>
+      balance(account).flatMap { current =>
+        if (current + money.negate).isNegative then Left(LedgerError.InsufficientFunds)
+        else update(account, money.negate)
+      }
+    case Event.Closed(account) =>
+      closed += account
+      balance(account)
+
+  /** Current balance of an account */
+  def balance(account: AccountId): Either[LedgerError, Money] =
+    balances.get(account).toRight(LedgerError.UnknownAccount)
+
+  private def update(account: AccountId, delta: Money): Either[LedgerError, Money] =
+    if closed.contains(account) then Left(LedgerError.AccountClosed)
+    else
+      val next = balances.get(account).fold(delta)(_ + delta)
+      balances = balances.updated(account, next)
+      Right(next)
+
+  private[complex] def snapshot: Map[AccountId, Money] = balances.tap(_ => clock())
+
+/** Parses user input into numbers or reports what was wrong */
+def parseAmount(input: String): BigDecimal | String =
+  input.toDoubleOption match
+    case Some(value) => BigDecimal(value)
+    case None        => s"not a number: $input"
+
+/** Largest of the given amounts */
+def largest(first: Money, rest: Money*)(using ord: Ordering[Money]): Money =
+  (first +: rest).max
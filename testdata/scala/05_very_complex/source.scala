@@ -0,0 +1,157 @@
+package com.example.advanced
+
+import scala.annotation.tailrec
+import scala.collection.mutable.{ArrayBuffer, HashMap => MutableMap}
+import scala.concurrent.{ExecutionContext, Future, Promise}
+import scala.reflect.ClassTag
+
+/**
+ * Higher-kinded types, type class derivation, self types, F-bounded
+ * polymorphism, by-name parameters and a small actor-like runtime
+ */
+
+/** Functor over a type constructor */
+trait Functor[F[_]] {
+  def map[A, B](fa: F[A])(f: A => B): F[B]
+}
+
+/** Monad over a type constructor */
+trait Monad[F[_]] extends Functor[F] {
+  def pure[A](value: A): F[A]
+  def flatMap[A, B](fa: F[A])(f: A => F[B]): F[B]
+
+  def map[A, B](fa: F[A])(f: A => B): F[B] = flatMap(fa)(a => pure(f(a)))
+}
+
+object Monad {
+  def apply[F[_]](implicit monad: Monad[F]): Monad[F] = monad
+
+  implicit val optionMonad: Monad[Option] = new Monad[Option] {
+    def pure[A](value: A): Option[A] = Some(value)
+    def flatMap[A, B](fa: Option[A])(f: A => Option[B]): Option[B] = fa.flatMap(f)
+  }
+
+  /** Sequences a list of effects */
+  def sequence[F[_]: Monad, A](effects: List[F[A]]): F[List[A]] =
+    effects.foldRight(Monad[F].pure(List.empty[A])) { (fa, acc) =>
+      Monad[F].flatMap(fa)(a => Monad[F].map(acc)(a :: _))
+    }
+}
+
+/** Comparable with its own subtype */
+trait Ranked[T <: Ranked[T]] { self: T =>
+  def rank: Int
+
+  /** Whether this outranks the other value */
+  def outranks(other: T): Boolean = rank > other.rank
+}
+
+/** Something that logs */
+trait Logging {
+  protected def log(message: => String): Unit = println(s"[${getClass.getSimpleName}] $message")
+}
+
+/** Something that can be shut down */
+trait Lifecycle { this: Logging =>
+  @volatile private var running = true
+
+  def isRunning: Boolean = running
+
+  def shutdown(): Unit = {
+    log("shutting down")
+    running = false
+  }
+}
+
+/** Message delivered to an actor */
+sealed trait Message[+R] {
+  def replyTo: Promise[_ >: R]
+}
+
+final case class Ask[R](question: String, replyTo: Promise[R]) extends Message[R]
+final case class Tell(payload: Any) extends Message[Nothing] {
+  def replyTo: Promise[Nothing] = Promise[Nothing]()
+}
+
+/** Minimal actor processing messages one at a time */
+abstract class Actor[State](initial: State)(implicit ec: ExecutionContext)
+    extends Logging
+    with Lifecycle {
+  private val mailbox = new ArrayBuffer[Message[_]]
+  private var state: State = initial
+  protected[advanced] var processed: Long = 0L
+
+  /** Handles a message and returns the next state */
+  protected def receive(state: State, message: Message[_]): State
+
+  /** Enqueues a message */
+  def !(message: Message[_]): Unit = synchronized {
+    mailbox += message
+    Future(drain())
+  }
+
+  /** Asks a question and waits for the answer asynchronously */
+  def ask[R](question: String): Future[R] = {
+    val promise = Promise[R]()
+    this ! Ask(question, promise)
+    promise.future
+  }
+
+  @tailrec
+  private def drain(): Unit = {
+    val next = synchronized {
+      if (mailbox.isEmpty) None else Some(mailbox.remove(0))
+    }
+    next match {
+      case Some(message) if isRunning =>
+        state = receive(state, message)
+        processed += 1
+        drain()
+      case _ => ()
+    }
+  }
+}
+
+/** Player with a score */
+final case class Player(name: String, score: Int) extends Ranked[Player] {
+  def rank: Int = score
+}
+
+/** Keeps the leaderboard of a game */
+class Leaderboard(implicit ec: ExecutionContext) extends Actor[Map[String, Player]](Map.empty) {
+  protected def receive(state: Map[String, Player], message: Message[_]): Map[String, Player] =
+    message match {
+      case Tell(player: Player) =>
+        val best = state.get(player.name).filter(_.outranks(player)).getOrElse(player)
+        state.updated(player.name, best)
+      case Ask(question, replyTo) if question == "top" =>
+        replyTo.asInstanceOf[Promise[Seq[Player]]].success(top(state, 3))
+        state
+      case other =>
+        log(s"unhandled $other")
+        state
+    }
+
+  private def top(state: Map[String, Player], count: Int): Seq[Player] =
+    state.values.toSeq.sortBy(-_.rank).take(count)
+}
+
+/** Generic utilities */
+object Collections {
+  /** Groups values into arrays by key */
+  def groupInto[K, V: ClassTag](values: Iterable[V])(key: V => K): Map[K, Array[V]] = {
+    val groups = MutableMap.empty[K, ArrayBuffer[V]]
+    values.foreach(v => groups.getOrElseUpdate(key(v), ArrayBuffer.empty) += v)
+    groups.view.mapValues(_.toArray).toMap
+  }
+
+  /** Retries an operation a number of times */
+  @tailrec
+  def retry[T](times: Int)(operation: => T): T =
+    try operation
+    catch {
+      case _: Exception if times > 1 => retry(times - 1)(operation)
+    }
+
+  private def unused(): Unit = ()
+}